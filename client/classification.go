@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/types"
+)
+
+// ClassifierFilter фильтры списка классификаторов. Нулевые значения не фильтруют.
+type ClassifierFilter struct {
+	ClientID   int
+	ProjectID  int
+	ActiveOnly bool
+}
+
+// ListClassifiers возвращает классификаторы категорий
+func (c *Client) ListClassifiers(ctx context.Context, filter ClassifierFilter) ([]*database.CategoryClassifier, error) {
+	query := url.Values{}
+	setInt(query, "client_id", filter.ClientID)
+	setInt(query, "project_id", filter.ProjectID)
+	if filter.ActiveOnly {
+		query.Set("active_only", strconv.FormatBool(true))
+	}
+
+	var classifiers []*database.CategoryClassifier
+	if err := c.get(ctx, "/api/classification/classifiers", query, &classifiers); err != nil {
+		return nil, err
+	}
+	return classifiers, nil
+}
+
+// Classify классифицирует элемент по наименованию (или по item_id) с помощью AI-классификатора
+func (c *Client) Classify(ctx context.Context, req types.ClassifyRequest) (*types.ClassificationResult, error) {
+	var result types.ClassificationResult
+	if err := c.do(ctx, http.MethodPost, "/api/classification/classify", nil, req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,331 @@
+// Package client — типизированный Go-клиент REST API сервера.
+//
+// Клиент использует те же структуры запросов и ответов, что и обработчики сервера
+// (пакеты database и server/types), поэтому изменение контракта API ломает сборку
+// клиента, а не интеграцию в рантайме.
+//
+//	c, err := client.New("http://localhost:9999", client.WithAPIKey(key))
+//	clients, err := c.ListClients(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout      = 60 * time.Second
+	defaultMaxRetries   = 3
+	defaultRetryBackoff = 500 * time.Millisecond
+	maxRetryBackoff     = 30 * time.Second
+	userAgent           = "httpserver-go-client/1.0"
+)
+
+// Client клиент REST API сервера. Безопасен для параллельного использования.
+type Client struct {
+	baseURL      *url.URL
+	httpClient   *http.Client
+	apiKey       string
+	timeout      time.Duration
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Option настройка клиента
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP-клиент (транспорт, прокси, TLS).
+// Таймаут http.Client прерывает и SSE-подписки, для обычных запросов используйте WithTimeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithAPIKey задает API-ключ, передаваемый в заголовке X-API-Key
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithTimeout задает таймаут одного запроса (без учета повторов). 0 — без таймаута.
+// На SSE-подписки не влияет.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry задает число повторов идемпотентных запросов и начальную задержку между ними.
+// Задержка удваивается с каждой попыткой; заголовок Retry-After сервера имеет приоритет.
+// maxRetries = 0 отключает повторы.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		if maxRetries >= 0 {
+			c.maxRetries = maxRetries
+		}
+		if backoff > 0 {
+			c.retryBackoff = backoff
+		}
+	}
+}
+
+// New создает клиент для сервера по адресу baseURL (например, http://localhost:9999)
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:      parsed,
+		httpClient:   &http.Client{},
+		timeout:      defaultTimeout,
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// APIError ошибка, возвращенная сервером (HTTP статус 4xx/5xx)
+type APIError struct {
+	StatusCode int
+	Message    string
+	RequestID  string
+	Body       []byte
+}
+
+// Error реализует интерфейс error
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request_id=%s)", e.RequestID)
+	}
+	return msg
+}
+
+// IsNotFound проверяет, что сервер ответил 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// newAPIError разбирает тело ответа с ошибкой. Сервер использует несколько форматов:
+// {"error": "..."} (middleware), {"error": true, "message": "..."} (Gin) и текст (http.Error).
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		Body:       body,
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if msg, ok := payload["error"].(string); ok {
+			apiErr.Message = msg
+		}
+		if msg, ok := payload["message"].(string); ok && apiErr.Message == "" {
+			apiErr.Message = msg
+		}
+		if reqID, ok := payload["request_id"].(string); ok && reqID != "" {
+			apiErr.RequestID = reqID
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return apiErr
+}
+
+// get выполняет GET-запрос и декодирует JSON-ответ в out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// do выполняет запрос с JSON-телом и декодирует JSON-ответ в out (если out != nil).
+// Идемпотентные запросы повторяются при сетевых ошибках и ответах 429/502/503/504.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var payload []byte
+	if in != nil {
+		var err error
+		if payload, err = json.Marshal(in); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	maxAttempts := 1
+	if isIdempotent(method) {
+		maxAttempts += c.maxRetries
+	}
+
+	var lastErr error
+	var retryAfter time.Duration
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, c.backoff(attempt, retryAfter)); err != nil {
+				return lastErr
+			}
+		}
+
+		var retry bool
+		retry, retryAfter, lastErr = c.attempt(ctx, method, path, query, payload, out)
+		if lastErr == nil || !retry || ctx.Err() != nil {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+// attempt выполняет одну попытку запроса. retry сообщает, имеет ли смысл повтор,
+// retryAfter — задержку, запрошенную сервером.
+func (c *Client) attempt(ctx context.Context, method, path string, query url.Values, payload []byte, out interface{}) (retry bool, retryAfter time.Duration, err error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return false, 0, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return isTemporary(err), 0, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return isTemporary(err), 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return isRetryableStatus(resp.StatusCode), parseRetryAfter(resp.Header.Get("Retry-After")), newAPIError(resp, data)
+	}
+
+	if out != nil && len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return false, 0, fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+		}
+	}
+	return false, 0, nil
+}
+
+// newRequest создает запрос к пути API относительно базового адреса
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *c.baseURL
+	u.Path = strings.TrimRight(u.Path, "/") + path
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return req, nil
+}
+
+// backoff возвращает задержку перед попыткой attempt (с 1)
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryBackoff)
+	}
+	delay := c.retryBackoff << (attempt - 1)
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTemporary определяет сетевые ошибки, после которых запрос можно повторить
+func isTemporary(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter разбирает Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"httpserver/server/types"
+)
+
+// TestRetryIdempotentRequests проверяет повторы GET при 503 и отсутствие повторов POST
+func TestRetryIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if r.Method == http.MethodPost || n < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, `{"error":"overloaded"}`)
+			return
+		}
+		if r.Header.Get("X-API-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `[{"id":1,"name":"Клиент"}]`)
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL, WithAPIKey("secret"), WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	clients, err := c.ListClients(context.Background())
+	if err != nil || len(clients) != 1 || clients[0].Name != "Клиент" {
+		t.Fatalf("ListClients() = %+v, %v", clients, err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("GET attempts = %d, want 3", calls.Load())
+	}
+
+	calls.Store(0)
+	_, err = c.Classify(context.Background(), types.ClassifyRequest{ItemName: "Болт М8"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message != "overloaded" {
+		t.Fatalf("Classify() error = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("POST attempts = %d, want 1", calls.Load())
+	}
+}
+
+// TestAPIErrorFormats проверяет разбор всех форматов ошибок сервера
+func TestAPIErrorFormats(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		message     string
+		requestID   string
+	}{
+		{"middleware", "application/json", `{"error":"Upload not found","timestamp":"2026-10-18T10:00:00Z","request_id":"req-1"}`, "Upload not found", "req-1"},
+		{"gin", "application/json", `{"error":true,"message":"database_id is required"}`, "database_id is required", ""},
+		{"response", "application/json", `{"success":false,"error":"Invalid request body","message":"Invalid request body"}`, "Invalid request body", ""},
+		{"text", "text/plain", "Method not allowed\n", "Method not allowed", ""},
+		{"empty", "text/plain", "", "Bad Request", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c, _ := New(srv.URL)
+			_, err := c.QualityScore(context.Background(), 1)
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("error = %v, want *APIError", err)
+			}
+			if apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != tt.message || apiErr.RequestID != tt.requestID {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+		})
+	}
+}
+
+// TestSubscriptionReconnect проверяет разбор SSE и переподключение с Last-Event-ID
+func TestSubscriptionReconnect(t *testing.T) {
+	lastEventIDs := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastEventIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+
+		fmt.Fprint(w, "data: {\"type\":\"connected\",\"message\":\"Connected to normalization events\"}\n\n")
+		if r.Header.Get("Last-Event-ID") == "" {
+			fmt.Fprint(w, ": heartbeat\n\n")
+			fmt.Fprint(w, "id: 41\ndata: {\"type\":\"log\",\"message\":\"Обработано 100\",\"timestamp\":\"2026-10-18T10:00:00Z\"}\n\n")
+			flusher.Flush()
+			return // обрыв потока
+		}
+		fmt.Fprint(w, "id: 42\ndata: {\"type\":\"log\",\"message\":\"Готово\"}\n\n")
+		flusher.Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, WithRetry(3, time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sub, err := c.SubscribeNormalizationEvents(ctx, 3, 7)
+	if err != nil {
+		t.Fatalf("SubscribeNormalizationEvents() error = %v", err)
+	}
+
+	var logs []Event
+	for event := range sub.Events() {
+		if event.Type == "log" {
+			logs = append(logs, event)
+		}
+		if len(logs) == 2 {
+			break
+		}
+	}
+	sub.Close()
+
+	if len(logs) != 2 || logs[0].ID != "41" || logs[0].Message != "Обработано 100" || logs[1].ID != "42" {
+		t.Fatalf("log events = %+v", logs)
+	}
+	if ts, ok := logs[0].EventTime(); !ok || ts.Year() != 2026 {
+		t.Errorf("EventTime() = %v, %v", ts, ok)
+	}
+	if first, second := <-lastEventIDs, <-lastEventIDs; first != "" || second != "41" {
+		t.Errorf("Last-Event-ID headers = %q, %q", first, second)
+	}
+	if err := sub.Err(); err != nil {
+		t.Errorf("Err() after Close = %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"httpserver/database"
+	"httpserver/server/types"
+)
+
+// ListClients возвращает всех клиентов
+func (c *Client) ListClients(ctx context.Context) ([]*database.Client, error) {
+	var clients []*database.Client
+	if err := c.get(ctx, "/api/clients", nil, &clients); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+// GetClient возвращает клиента с проектами, статистикой и документами
+func (c *Client) GetClient(ctx context.Context, clientID int) (*types.ClientDetailResponse, error) {
+	var details types.ClientDetailResponse
+	if err := c.get(ctx, fmt.Sprintf("/api/clients/%d", clientID), nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// CreateClient создает клиента. Запрос не повторяется автоматически.
+func (c *Client) CreateClient(ctx context.Context, req types.CreateClientRequest) (*database.Client, error) {
+	var created database.Client
+	if err := c.do(ctx, http.MethodPost, "/api/clients", nil, req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateClient обновляет поля клиента
+func (c *Client) UpdateClient(ctx context.Context, clientID int, client *database.Client) (*database.Client, error) {
+	var updated database.Client
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/clients/%d", clientID), nil, client, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteClient удаляет клиента
+func (c *Client) DeleteClient(ctx context.Context, clientID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/clients/%d", clientID), nil, nil, nil)
+}
+
+// ListProjects возвращает проекты клиента
+func (c *Client) ListProjects(ctx context.Context, clientID int) ([]*database.ClientProject, error) {
+	var projects []*database.ClientProject
+	if err := c.get(ctx, fmt.Sprintf("/api/clients/%d/projects", clientID), nil, &projects); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// GetProject возвращает проект клиента со статистикой эталонов
+func (c *Client) GetProject(ctx context.Context, clientID, projectID int) (*types.ClientProjectDetails, error) {
+	var details types.ClientProjectDetails
+	if err := c.get(ctx, fmt.Sprintf("/api/clients/%d/projects/%d", clientID, projectID), nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// CreateProject создает проект клиента (name и project_type обязательны).
+// Запрос не повторяется автоматически.
+func (c *Client) CreateProject(ctx context.Context, clientID int, req types.ClientProjectRequest) (*database.ClientProject, error) {
+	var created database.ClientProject
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/clients/%d/projects", clientID), nil, req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateProject обновляет проект клиента
+func (c *Client) UpdateProject(ctx context.Context, clientID, projectID int, req types.ClientProjectRequest) (*database.ClientProject, error) {
+	var updated database.ClientProject
+	if err := c.do(ctx, http.MethodPut, fmt.Sprintf("/api/clients/%d/projects/%d", clientID, projectID), nil, req, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteProject удаляет проект клиента
+func (c *Client) DeleteProject(ctx context.Context, clientID, projectID int) error {
+	return c.do(ctx, http.MethodDelete, fmt.Sprintf("/api/clients/%d/projects/%d", clientID, projectID), nil, nil, nil)
+}
+
+// ListProjectDatabases возвращает базы данных проекта со статистикой выгрузок
+func (c *Client) ListProjectDatabases(ctx context.Context, clientID, projectID int) ([]types.ProjectDatabaseInfo, error) {
+	var resp types.ProjectDatabasesResponse
+	if err := c.get(ctx, fmt.Sprintf("/api/clients/%d/projects/%d/databases", clientID, projectID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Databases, nil
+}
+
+// GetProjectDatabase возвращает базу данных проекта с таблицами и статистикой
+func (c *Client) GetProjectDatabase(ctx context.Context, clientID, projectID, databaseID int) (*types.ProjectDatabaseDetails, error) {
+	var details types.ProjectDatabaseDetails
+	if err := c.get(ctx, fmt.Sprintf("/api/clients/%d/projects/%d/databases/%d", clientID, projectID, databaseID), nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// CreateProjectDatabase регистрирует базу данных в проекте.
+// Если база с таким именем или путем уже есть, сервер возвращает ее.
+func (c *Client) CreateProjectDatabase(ctx context.Context, clientID, projectID int, req types.ProjectDatabaseRequest) (*database.ProjectDatabase, error) {
+	var created database.ProjectDatabase
+	if err := c.do(ctx, http.MethodPost, fmt.Sprintf("/api/clients/%d/projects/%d/databases", clientID, projectID), nil, req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
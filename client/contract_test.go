@@ -0,0 +1,376 @@
+package client_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"httpserver/client"
+	"httpserver/database"
+	"httpserver/server"
+	"httpserver/server/types"
+)
+
+// contractEnv сервер с реальными обработчиками и клиент к нему
+type contractEnv struct {
+	client    *client.Client
+	db        *database.DB
+	serviceDB *database.ServiceDB
+}
+
+// newContractEnv поднимает httptest-сервер поверх server.Server с временными БД.
+// Сервер открывает БД эталонов, истории сканирования и ГОСТов по относительным путям,
+// поэтому тест выполняется во временном каталоге
+func newContractEnv(t *testing.T) *contractEnv {
+	t.Helper()
+	tempDir := t.TempDir()
+	t.Chdir(tempDir)
+	serviceDBPath := filepath.Join(tempDir, "service.db")
+
+	// Миграциям сервисной БД нужна таблица catalog_items
+	conn, err := sql.Open("sqlite3", serviceDBPath)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	if _, err := conn.Exec(`CREATE TABLE IF NOT EXISTS catalog_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		catalog_id INTEGER NOT NULL,
+		reference TEXT NOT NULL,
+		code TEXT,
+		name TEXT,
+		attributes_xml TEXT,
+		table_parts_xml TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("create catalog_items error = %v", err)
+	}
+	conn.Close()
+
+	serviceDB, err := database.NewServiceDB(serviceDBPath)
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	t.Cleanup(func() { serviceDB.Close() })
+
+	dbPath := filepath.Join(tempDir, "data.db")
+	db, err := database.NewDB(dbPath)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	normalizedPath := filepath.Join(tempDir, "normalized.db")
+	normalizedDB, err := database.NewDB(normalizedPath)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { normalizedDB.Close() })
+
+	config := &server.Config{
+		Port:                       "9999",
+		DatabasePath:               dbPath,
+		NormalizedDatabasePath:     normalizedPath,
+		ServiceDatabasePath:        serviceDBPath,
+		MaxOpenConns:               25,
+		MaxIdleConns:               5,
+		ConnMaxLifetime:            5 * time.Minute,
+		LogBufferSize:              100,
+		NormalizerEventsBufferSize: 100,
+	}
+	srv := server.NewServerWithConfig(db, normalizedDB, serviceDB, dbPath, normalizedPath, config)
+	httpServer := httptest.NewServer(srv)
+	t.Cleanup(httpServer.Close)
+
+	c, err := client.New(httpServer.URL, client.WithRetry(0, 0), client.WithTimeout(30*time.Second))
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	return &contractEnv{client: c, db: db, serviceDB: serviceDB}
+}
+
+// requireAPIError проверяет, что err — ошибка API с ожидаемым статусом и сообщением
+func requireAPIError(t *testing.T, err error, status int) *client.APIError {
+	t.Helper()
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want *client.APIError", err)
+	}
+	if apiErr.StatusCode != status || apiErr.Message == "" {
+		t.Fatalf("APIError = %+v, want status %d with message", apiErr, status)
+	}
+	return apiErr
+}
+
+// TestContractClientsProjectsDatabases проверяет CRUD клиентов, проектов и баз данных проекта
+func TestContractClientsProjectsDatabases(t *testing.T) {
+	env := newContractEnv(t)
+	c, ctx := env.client, context.Background()
+
+	created, err := c.CreateClient(ctx, types.CreateClientRequest{Name: "Ромашка", LegalName: "ООО Ромашка", TaxID: "7701234567", Country: "RU"})
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	if created.ID == 0 || created.Name != "Ромашка" || created.TaxID != "7701234567" {
+		t.Fatalf("created client = %+v", created)
+	}
+	if _, err := c.CreateClient(ctx, types.CreateClientRequest{}); err == nil {
+		t.Error("CreateClient() without name expected error")
+	} else {
+		requireAPIError(t, err, http.StatusBadRequest)
+	}
+
+	clients, err := c.ListClients(ctx)
+	if err != nil {
+		t.Fatalf("ListClients() error = %v", err)
+	}
+	found := false
+	for _, item := range clients {
+		found = found || item.ID == created.ID
+	}
+	if !found {
+		t.Fatalf("ListClients() does not contain client %d", created.ID)
+	}
+
+	update := *created
+	update.Description = "Поставщик метизов"
+	updated, err := c.UpdateClient(ctx, created.ID, &update)
+	if err != nil || updated.Description != "Поставщик метизов" {
+		t.Fatalf("UpdateClient() = %+v, %v", updated, err)
+	}
+
+	project, err := c.CreateProject(ctx, created.ID, types.ClientProjectRequest{Name: "НСИ", ProjectType: "nomenclature", SourceSystem: "1C", TargetQualityScore: 0.9})
+	if err != nil {
+		t.Fatalf("CreateProject() error = %v", err)
+	}
+	if project.ClientID != created.ID || project.ProjectType != "nomenclature" {
+		t.Fatalf("project = %+v", project)
+	}
+	if _, err := c.CreateProject(ctx, created.ID, types.ClientProjectRequest{Name: "Без типа"}); err == nil {
+		t.Error("CreateProject() without project_type expected error")
+	} else {
+		requireAPIError(t, err, http.StatusBadRequest)
+	}
+
+	details, err := c.GetClient(ctx, created.ID)
+	if err != nil || details.Client.ID != created.ID || len(details.Projects) != 1 {
+		t.Fatalf("GetClient() = %+v, %v", details, err)
+	}
+
+	projectDetails, err := c.GetProject(ctx, created.ID, project.ID)
+	if err != nil || projectDetails.Project.ID != project.ID || projectDetails.ClientName != "Ромашка" {
+		t.Fatalf("GetProject() = %+v, %v", projectDetails, err)
+	}
+	if _, err := c.GetProject(ctx, created.ID, project.ID+100); !client.IsNotFound(err) {
+		t.Errorf("GetProject() missing error = %v, want 404", err)
+	}
+
+	renamed, err := c.UpdateProject(ctx, created.ID, project.ID, types.ClientProjectRequest{Name: "НСИ 2026", ProjectType: "nomenclature"})
+	if err != nil || renamed.Name != "НСИ 2026" {
+		t.Fatalf("UpdateProject() = %+v, %v", renamed, err)
+	}
+
+	projectDB, err := c.CreateProjectDatabase(ctx, created.ID, project.ID, types.ProjectDatabaseRequest{Name: "Основная", Description: "УТ 11"})
+	if err != nil || projectDB.ID == 0 || projectDB.ClientProjectID != project.ID {
+		t.Fatalf("CreateProjectDatabase() = %+v, %v", projectDB, err)
+	}
+	again, err := c.CreateProjectDatabase(ctx, created.ID, project.ID, types.ProjectDatabaseRequest{Name: "Основная"})
+	if err != nil || again.ID != projectDB.ID {
+		t.Fatalf("CreateProjectDatabase() duplicate = %+v, %v", again, err)
+	}
+
+	databases, err := c.ListProjectDatabases(ctx, created.ID, project.ID)
+	if err != nil || len(databases) != 1 || databases[0].Name != "Основная" || databases[0].CreatedAt == "" {
+		t.Fatalf("ListProjectDatabases() = %+v, %v", databases, err)
+	}
+	dbDetails, err := c.GetProjectDatabase(ctx, created.ID, project.ID, projectDB.ID)
+	if err != nil || dbDetails.ID != projectDB.ID || dbDetails.Status != "active" {
+		t.Fatalf("GetProjectDatabase() = %+v, %v", dbDetails, err)
+	}
+
+	if err := c.DeleteProject(ctx, created.ID, project.ID); err != nil {
+		t.Fatalf("DeleteProject() error = %v", err)
+	}
+	if err := c.DeleteClient(ctx, created.ID); err != nil {
+		t.Fatalf("DeleteClient() error = %v", err)
+	}
+	if _, err := c.GetClient(ctx, created.ID); !client.IsNotFound(err) {
+		t.Errorf("GetClient() after delete error = %v, want 404", err)
+	}
+}
+
+// TestContractUploads проверяет список выгрузок, постраничный итератор и детали выгрузки
+func TestContractUploads(t *testing.T) {
+	env := newContractEnv(t)
+	c, ctx := env.client, context.Background()
+
+	for _, uuid := range []string{"upload-1", "upload-2", "upload-3"} {
+		if _, err := env.db.CreateUploadWithDatabase(uuid, "8.3.24", "УправлениеТорговлей", nil, "PC-1", "Иванов", "11.5", 1, "", "", "", nil); err != nil {
+			t.Fatalf("CreateUploadWithDatabase() error = %v", err)
+		}
+	}
+	if _, err := env.db.CreateUpload("upload-other", "8.3.24", "Бухгалтерия"); err != nil {
+		t.Fatalf("CreateUpload() error = %v", err)
+	}
+
+	page, err := c.ListUploads(ctx, client.UploadFilter{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListUploads() error = %v", err)
+	}
+	if page.Total != 4 || page.Count != 2 || !page.HasMore || len(page.Uploads) != 2 {
+		t.Fatalf("ListUploads() page = %+v", page)
+	}
+
+	var uuids []string
+	for upload, err := range c.AllUploads(ctx, client.UploadFilter{Search: "Торговлей", Limit: 2}) {
+		if err != nil {
+			t.Fatalf("AllUploads() error = %v", err)
+		}
+		uuids = append(uuids, upload.UploadUUID)
+	}
+	if len(uuids) != 3 {
+		t.Fatalf("AllUploads() = %v, want 3 uploads", uuids)
+	}
+
+	// Выгрузки без привязки к клиенту не попадают в выборку по клиенту
+	byClient, err := c.ListUploads(ctx, client.UploadFilter{ClientID: 7})
+	if err != nil || byClient.Total != 0 {
+		t.Fatalf("ListUploads(client) = %+v, %v", byClient, err)
+	}
+
+	details, err := c.GetUpload(ctx, "upload-1")
+	if err != nil {
+		t.Fatalf("GetUpload() error = %v", err)
+	}
+	if details.UploadUUID != "upload-1" || details.ConfigName != "УправлениеТорговлей" || details.ComputerName != "PC-1" {
+		t.Fatalf("GetUpload() = %+v", details)
+	}
+	if _, err := c.GetUpload(ctx, "missing"); !client.IsNotFound(err) {
+		t.Errorf("GetUpload() missing error = %v, want 404", err)
+	}
+}
+
+// TestContractNormalizedCounterparties проверяет постраничное чтение нормализованных контрагентов
+func TestContractNormalizedCounterparties(t *testing.T) {
+	env := newContractEnv(t)
+	c, ctx := env.client, context.Background()
+
+	owner, err := env.serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := env.serviceDB.CreateClientProject(owner.ID, "Контрагенты", "counterparty", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	for i, name := range []string{"ООО Альфа", "ООО Бета", "ООО Гамма", "ООО Дельта", "ООО Эпсилон"} {
+		if err := env.serviceDB.SaveNormalizedCounterparty(project.ID, name, name, name,
+			"770123456"+string(rune('0'+i)), "", "", "", "", "", "", "", "ООО", "", "", "", "", 0, 0.9, false, "", "", ""); err != nil {
+			t.Fatalf("SaveNormalizedCounterparty() error = %v", err)
+		}
+	}
+
+	page, err := c.ListNormalizedCounterparties(ctx, client.CounterpartyFilter{ProjectID: project.ID, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListNormalizedCounterparties() error = %v", err)
+	}
+	if page.Total != 5 || len(page.Counterparties) != 2 || len(page.Projects) != 1 || page.Projects[0].ID != project.ID {
+		t.Fatalf("ListNormalizedCounterparties() = %+v", page)
+	}
+
+	seen := map[int]bool{}
+	for counterparty, err := range c.AllNormalizedCounterparties(ctx, client.CounterpartyFilter{ClientID: owner.ID, Limit: 2}) {
+		if err != nil {
+			t.Fatalf("AllNormalizedCounterparties() error = %v", err)
+		}
+		seen[counterparty.ID] = true
+	}
+	if len(seen) != 5 {
+		t.Fatalf("AllNormalizedCounterparties() returned %d unique counterparties, want 5", len(seen))
+	}
+
+	_, err = c.ListNormalizedCounterparties(ctx, client.CounterpartyFilter{})
+	requireAPIError(t, err, http.StatusBadRequest)
+}
+
+// TestContractNormalizationClassificationQuality проверяет статус нормализации, SSE-поток,
+// классификацию, отчеты качества и мониторинг
+func TestContractNormalizationClassificationQuality(t *testing.T) {
+	env := newContractEnv(t)
+	c, ctx := env.client, context.Background()
+
+	owner, err := env.serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := env.serviceDB.CreateClientProject(owner.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	status, err := c.ProjectNormalizationStatus(ctx, owner.ID, project.ID)
+	if err != nil || status.IsRunning || status.CurrentStep == "" {
+		t.Fatalf("ProjectNormalizationStatus() = %+v, %v", status, err)
+	}
+	if _, err := c.ProjectNormalizationStatus(ctx, owner.ID, project.ID+100); !client.IsNotFound(err) {
+		t.Errorf("ProjectNormalizationStatus() missing project error = %v, want 404", err)
+	}
+	if _, err := c.StartProjectNormalization(ctx, owner.ID+100, project.ID, types.ProjectNormalizationOptions{}); !client.IsNotFound(err) {
+		t.Errorf("StartProjectNormalization() missing client error = %v, want 404", err)
+	}
+
+	subCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	sub, err := c.SubscribeNormalizationEvents(subCtx, owner.ID, project.ID)
+	if err != nil {
+		t.Fatalf("SubscribeNormalizationEvents() error = %v", err)
+	}
+	select {
+	case event := <-sub.Events():
+		if event.Type != "connected" {
+			t.Errorf("first event = %+v, want connected", event)
+		}
+	case <-subCtx.Done():
+		t.Fatal("no events received from normalization stream")
+	}
+	sub.Close()
+	if err := sub.Err(); err != nil {
+		t.Errorf("Subscription.Err() after Close = %v", err)
+	}
+
+	// Без настроенного AI-провайдера классификация возвращает ошибку API, а не обрыв соединения
+	if _, err := c.Classify(ctx, types.ClassifyRequest{ItemName: "Болт М8х40"}); err == nil {
+		t.Error("Classify() without AI provider expected error")
+	} else {
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.Message == "" {
+			t.Errorf("Classify() error = %v, want *client.APIError", err)
+		}
+	}
+	if _, err := c.ListClassifiers(ctx, client.ClassifierFilter{ActiveOnly: true}); err != nil {
+		t.Errorf("ListClassifiers() error = %v", err)
+	}
+
+	report, err := c.QualityReport(ctx, 5)
+	if err != nil || report.DatabaseID != 5 || report.GeneratedAt == "" {
+		t.Fatalf("QualityReport() = %+v, %v", report, err)
+	}
+	score, err := c.QualityScore(ctx, 5)
+	if err != nil || score.DatabaseID != 5 {
+		t.Fatalf("QualityScore() = %+v, %v", score, err)
+	}
+
+	providers, err := c.MonitoringProviders(ctx)
+	if err != nil || providers.Providers == nil {
+		t.Fatalf("MonitoringProviders() = %+v, %v", providers, err)
+	}
+	if _, err := c.MonitoringMetrics(ctx); err != nil {
+		t.Errorf("MonitoringMetrics() error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+
+	"httpserver/database"
+	"httpserver/server/types"
+)
+
+// CounterpartyFilter фильтры нормализованных контрагентов.
+// Нужен ClientID (все проекты клиента, ProjectID сужает выборку) или ProjectID.
+type CounterpartyFilter struct {
+	ClientID    int
+	ProjectID   int
+	Search      string
+	Enrichment  string
+	Subcategory string
+	// Limit размер страницы (по умолчанию на сервере 100, максимум 1000)
+	Limit  int
+	Offset int
+}
+
+func (f CounterpartyFilter) query() url.Values {
+	query := url.Values{}
+	setInt(query, "client_id", f.ClientID)
+	setInt(query, "project_id", f.ProjectID)
+	setString(query, "search", f.Search)
+	setString(query, "enrichment", f.Enrichment)
+	setString(query, "subcategory", f.Subcategory)
+	setInt(query, "limit", f.Limit)
+	if f.Offset > 0 {
+		setInt(query, "offset", f.Offset)
+	}
+	return query
+}
+
+// ListNormalizedCounterparties возвращает страницу нормализованных контрагентов
+func (c *Client) ListNormalizedCounterparties(ctx context.Context, filter CounterpartyFilter) (*types.NormalizedCounterpartiesResponse, error) {
+	var resp types.NormalizedCounterpartiesResponse
+	if err := c.get(ctx, "/api/counterparties/normalized", filter.query(), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AllNormalizedCounterparties перебирает всех нормализованных контрагентов, подходящих под фильтр,
+// запрашивая страницы по мере чтения. При ошибке итератор возвращает ее и завершается.
+func (c *Client) AllNormalizedCounterparties(ctx context.Context, filter CounterpartyFilter) iter.Seq2[*database.NormalizedCounterparty, error] {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	return func(yield func(*database.NormalizedCounterparty, error) bool) {
+		for {
+			page, err := c.ListNormalizedCounterparties(ctx, filter)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, counterparty := range page.Counterparties {
+				if !yield(counterparty, nil) {
+					return
+				}
+			}
+			filter.Offset += len(page.Counterparties)
+			if len(page.Counterparties) == 0 || filter.Offset >= page.Total {
+				return
+			}
+		}
+	}
+}
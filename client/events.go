@@ -0,0 +1,214 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"httpserver/server/types"
+)
+
+// maxEventSize максимальный размер строки SSE-потока
+const maxEventSize = 1 << 20
+
+// Event событие SSE-потока. ID пуст для событий, не сохраняемых в журнале шины
+// (например, начальное событие "connected").
+type Event struct {
+	ID string
+	types.StreamEvent
+}
+
+// Subscription подписка на SSE-поток
+type Subscription struct {
+	events chan Event
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu  sync.Mutex
+	err error
+}
+
+// Events возвращает канал событий. Канал закрывается при завершении подписки.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Err возвращает причину завершения подписки (nil после Close или отмены контекста)
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Close завершает подписку и ждет остановки чтения потока
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// subscribe открывает SSE-поток. Первое подключение выполняется синхронно,
+// чтобы ошибки вроде 404 возвращались вызывающему коду.
+func (c *Client) subscribe(ctx context.Context, path string) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	body, err := c.openStream(ctx, path, "")
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	sub := &Subscription{
+		events: make(chan Event, 64),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go c.readStream(ctx, sub, path, body)
+	return sub, nil
+}
+
+// readStream читает поток и переподключается после обрыва
+func (c *Client) readStream(ctx context.Context, sub *Subscription, path string, body io.ReadCloser) {
+	defer close(sub.done)
+	defer close(sub.events)
+	defer sub.cancel()
+
+	lastEventID := ""
+	failures := 0
+	for {
+		delivered, err := readEvents(ctx, body, sub.events, &lastEventID)
+		body.Close()
+		if ctx.Err() != nil {
+			return
+		}
+		if delivered {
+			failures = 0
+		}
+
+		// Поток оборвался — переподключаемся с последним полученным ID
+		for {
+			failures++
+			if failures > c.maxRetries {
+				if err == nil {
+					err = io.ErrUnexpectedEOF
+				}
+				sub.mu.Lock()
+				sub.err = fmt.Errorf("event stream closed: %w", err)
+				sub.mu.Unlock()
+				return
+			}
+			if sleepContext(ctx, c.backoff(failures, 0)) != nil {
+				return
+			}
+			body, err = c.openStream(ctx, path, lastEventID)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && !isRetryableStatus(apiErr.StatusCode) {
+				sub.mu.Lock()
+				sub.err = err
+				sub.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// openStream выполняет GET-запрос SSE-потока
+func (c *Client) openStream(ctx context.Context, path, lastEventID string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxEventSize))
+		return nil, newAPIError(resp, data)
+	}
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/event-stream") {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected content type %q for event stream %s", contentType, path)
+	}
+	return resp.Body, nil
+}
+
+// readEvents разбирает SSE-поток до его окончания и отправляет события в out.
+// delivered сообщает, было ли получено хотя бы одно событие.
+func readEvents(ctx context.Context, r io.Reader, out chan<- Event, lastEventID *string) (delivered bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxEventSize)
+
+	var id string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// Пустая строка завершает событие
+			if len(data) > 0 {
+				event := parseEvent(id, strings.Join(data, "\n"))
+				if id != "" {
+					*lastEventID = id
+				}
+				select {
+				case out <- event:
+					delivered = true
+				case <-ctx.Done():
+					return delivered, ctx.Err()
+				}
+			}
+			id, data = "", nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// Комментарий (heartbeat)
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			id = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return delivered, scanner.Err()
+}
+
+// parseEvent декодирует поле data. Данные не в формате StreamEvent возвращаются в Message.
+func parseEvent(id, data string) Event {
+	event := Event{ID: id}
+	if err := json.Unmarshal([]byte(data), &event.StreamEvent); err != nil {
+		event.StreamEvent = types.StreamEvent{Message: data}
+	}
+	return event
+}
+
+// EventTime возвращает время события, если сервер его передал
+func (e Event) EventTime() (time.Time, bool) {
+	if e.Timestamp == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, e.Timestamp)
+	return t, err == nil
+}
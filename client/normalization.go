@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"httpserver/server/types"
+)
+
+// StartProjectNormalization запускает нормализацию проекта. Запрос не повторяется автоматически:
+// повтор после обрыва соединения может попасть на уже запущенную нормализацию.
+func (c *Client) StartProjectNormalization(ctx context.Context, clientID, projectID int, opts types.ProjectNormalizationOptions) (*types.NormalizationStartResponse, error) {
+	var resp types.NormalizationStartResponse
+	if err := c.do(ctx, http.MethodPost, projectNormalizationPath(clientID, projectID, "start"), nil, opts, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ProjectNormalizationStatus возвращает прогресс нормализации проекта
+func (c *Client) ProjectNormalizationStatus(ctx context.Context, clientID, projectID int) (*types.NormalizationStatus, error) {
+	var status types.NormalizationStatus
+	if err := c.get(ctx, projectNormalizationPath(clientID, projectID, "status"), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// StopProjectNormalization останавливает нормализацию проекта
+func (c *Client) StopProjectNormalization(ctx context.Context, clientID, projectID int) (*types.NormalizationStopResponse, error) {
+	var resp types.NormalizationStopResponse
+	if err := c.do(ctx, http.MethodPost, projectNormalizationPath(clientID, projectID, "stop"), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// NormalizationStatus возвращает общий статус нормализации сервера
+func (c *Client) NormalizationStatus(ctx context.Context) (*types.NormalizationStatus, error) {
+	var status types.NormalizationStatus
+	if err := c.get(ctx, "/api/normalization/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SubscribeNormalizationEvents подписывается на SSE-поток событий нормализации проекта.
+// clientID = 0 подписывает на общий поток /api/normalization/events.
+// Подписка переподключается после обрыва с заголовком Last-Event-ID, поэтому события,
+// сохраненные в журнале шины событий, не теряются. Подписка завершается при отмене ctx,
+// вызове Close или исчерпании повторов подключения.
+func (c *Client) SubscribeNormalizationEvents(ctx context.Context, clientID, projectID int) (*Subscription, error) {
+	path := "/api/normalization/events"
+	if clientID != 0 {
+		path = projectNormalizationPath(clientID, projectID, "events")
+	}
+	return c.subscribe(ctx, path)
+}
+
+func projectNormalizationPath(clientID, projectID int, action string) string {
+	return fmt.Sprintf("/api/clients/%d/projects/%d/normalization/%s", clientID, projectID, action)
+}
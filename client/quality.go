@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"httpserver/server/types"
+)
+
+// QualityReport возвращает отчет о качестве данных базы
+func (c *Client) QualityReport(ctx context.Context, databaseID int) (*types.QualityReportResponse, error) {
+	query := url.Values{"database_id": {strconv.Itoa(databaseID)}}
+	var report types.QualityReportResponse
+	if err := c.get(ctx, "/api/quality/report", query, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// QualityScore возвращает оценку качества данных базы
+func (c *Client) QualityScore(ctx context.Context, databaseID int) (*types.QualityScoreResponse, error) {
+	var score types.QualityScoreResponse
+	if err := c.get(ctx, fmt.Sprintf("/api/quality/score/%d", databaseID), nil, &score); err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// ProjectQualityStats возвращает статистику качества, агрегированную по базам проекта
+func (c *Client) ProjectQualityStats(ctx context.Context, clientID, projectID int) (map[string]interface{}, error) {
+	query := url.Values{"project": {fmt.Sprintf("%d:%d", clientID, projectID)}}
+	var stats map[string]interface{}
+	if err := c.get(ctx, "/api/quality/stats", query, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// MonitoringMetrics возвращает метрики сервера (circuit breaker, батч-процессор, checkpoint)
+func (c *Client) MonitoringMetrics(ctx context.Context) (map[string]interface{}, error) {
+	var metrics map[string]interface{}
+	if err := c.get(ctx, "/api/monitoring/metrics", nil, &metrics); err != nil {
+		return nil, err
+	}
+	return metrics, nil
+}
+
+// MonitoringProviders возвращает метрики AI-провайдеров
+func (c *Client) MonitoringProviders(ctx context.Context) (*types.MonitoringData, error) {
+	var data types.MonitoringData
+	if err := c.get(ctx, "/api/monitoring/providers", nil, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
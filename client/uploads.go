@@ -0,0 +1,95 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/url"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/types"
+)
+
+// defaultPageSize размер страницы итераторов по умолчанию
+const defaultPageSize = 100
+
+// UploadFilter фильтры списка выгрузок. Нулевые значения не фильтруют.
+type UploadFilter struct {
+	DatabaseID int
+	ClientID   int
+	ProjectID  int
+	Status     string
+	// Search ищет по UUID, конфигурации, версии 1С, компьютеру и пользователю
+	Search string
+	// Limit размер страницы (по умолчанию на сервере 50, максимум 1000)
+	Limit  int
+	Offset int
+}
+
+func (f UploadFilter) query() url.Values {
+	query := url.Values{}
+	setInt(query, "database_id", f.DatabaseID)
+	setInt(query, "client_id", f.ClientID)
+	setInt(query, "project_id", f.ProjectID)
+	setString(query, "status", f.Status)
+	setString(query, "search", f.Search)
+	setInt(query, "limit", f.Limit)
+	setInt(query, "offset", f.Offset)
+	return query
+}
+
+// ListUploads возвращает страницу выгрузок
+func (c *Client) ListUploads(ctx context.Context, filter UploadFilter) (*types.UploadListResponse, error) {
+	var resp types.UploadListResponse
+	if err := c.get(ctx, "/api/uploads", filter.query(), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AllUploads перебирает все выгрузки, подходящие под фильтр, запрашивая страницы по мере чтения.
+// Offset фильтра задает начальную позицию. При ошибке итератор возвращает ее и завершается.
+func (c *Client) AllUploads(ctx context.Context, filter UploadFilter) iter.Seq2[*database.Upload, error] {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	}
+	return func(yield func(*database.Upload, error) bool) {
+		for {
+			page, err := c.ListUploads(ctx, filter)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, upload := range page.Uploads {
+				if !yield(upload, nil) {
+					return
+				}
+			}
+			if !page.HasMore || len(page.Uploads) == 0 {
+				return
+			}
+			filter.Offset += len(page.Uploads)
+		}
+	}
+}
+
+// GetUpload возвращает выгрузку со справочниками, константами и запусками правил автоматизации
+func (c *Client) GetUpload(ctx context.Context, uploadUUID string) (*types.UploadDetails, error) {
+	var details types.UploadDetails
+	if err := c.get(ctx, "/api/uploads/"+uploadUUID, nil, &details); err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+func setInt(query url.Values, key string, value int) {
+	if value != 0 {
+		query.Set(key, strconv.Itoa(value))
+	}
+}
+
+func setString(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
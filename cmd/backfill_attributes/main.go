@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"httpserver/database"
+)
+
+func main() {
+	var (
+		dbPath    = flag.String("db", "", "Путь к базе данных выгрузки")
+		batchSize = flag.Int("batch", 1000, "Размер пакета элементов")
+	)
+	flag.Parse()
+
+	if *dbPath == "" {
+		log.Fatal("Необходимо указать -db с путем к базе данных выгрузки")
+	}
+
+	// NewDB создает таблицу source_item_attributes, если ее еще нет
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия базы данных: %v", err)
+	}
+	defer db.Close()
+
+	log.Printf("Разбор реквизитов элементов, загруженных до появления хранилища атрибутов: %s", *dbPath)
+	result, err := db.BackfillSourceItemAttributes(*batchSize)
+	if err != nil {
+		log.Fatalf("Ошибка заполнения хранилища атрибутов: %v", err)
+	}
+
+	log.Printf("Элементов справочников: %d", result.CatalogItems)
+	log.Printf("Элементов номенклатуры: %d", result.NomenclatureItems)
+	log.Printf("Сохранено реквизитов: %d", result.Attributes)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+func main() {
+	var (
+		mode           = flag.String("mode", "", "Режим: export или import")
+		benchmarksPath = flag.String("benchmarks", "data/benchmarks.db", "Путь к базе эталонов")
+		servicePath    = flag.String("service", "service.db", "Путь к сервисной базе (ключи подписи, эталоны клиентов)")
+		filePath       = flag.String("file", "", "Файл пакета эталонов")
+		entityType     = flag.String("entity-type", "", "Тип эталонов для экспорта (пусто - все)")
+		projectID      = flag.Int("project", 0, "Проект: эталоны клиента для экспорта или проект для импорта")
+		strategy       = flag.String("strategy", services.BundleStrategySkip, "Стратегия при совпадении: skip, overwrite, merge, newer")
+		dryRun         = flag.Bool("dry-run", false, "Только отчет о конфликтах без изменений")
+		allowUntrusted = flag.Bool("allow-untrusted", false, "Разрешить пакеты, подписанные недоверенным ключом")
+		instance       = flag.String("instance", os.Getenv("INSTANCE_NAME"), "Имя инсталляции в экспортируемом пакете")
+	)
+	flag.Parse()
+
+	if *filePath == "" {
+		log.Fatal("Необходимо указать -file с путем к файлу пакета")
+	}
+
+	benchmarksDB, err := database.NewBenchmarksDB(*benchmarksPath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия базы эталонов: %v", err)
+	}
+	defer benchmarksDB.Close()
+
+	serviceDB, err := database.NewServiceDB(*servicePath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия сервисной базы: %v", err)
+	}
+	defer serviceDB.Close()
+
+	service := services.NewBenchmarkBundleService(benchmarksDB, serviceDB, *instance)
+
+	switch *mode {
+	case "export":
+		bundle, err := service.Export(services.BenchmarkBundleExportOptions{
+			EntityType: *entityType,
+			ActiveOnly: true,
+			ProjectID:  *projectID,
+			ExportedBy: "cli",
+		})
+		if err != nil {
+			log.Fatalf("Ошибка экспорта: %v", err)
+		}
+		data, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			log.Fatalf("Ошибка сериализации пакета: %v", err)
+		}
+		if err := os.WriteFile(*filePath, data, 0644); err != nil {
+			log.Fatalf("Ошибка записи файла: %v", err)
+		}
+		log.Printf("Пакет эталонов сохранен: %s (ключ подписи %s)", *filePath, bundle.Signature.KeyID)
+
+	case "import":
+		data, err := os.ReadFile(*filePath)
+		if err != nil {
+			log.Fatalf("Ошибка чтения файла: %v", err)
+		}
+		var bundle services.BenchmarkBundle
+		if err := json.Unmarshal(data, &bundle); err != nil {
+			log.Fatalf("Некорректный файл пакета: %v", err)
+		}
+		report, err := service.Import(&bundle, services.BenchmarkBundleImportOptions{
+			Strategy:       *strategy,
+			DryRun:         *dryRun,
+			ProjectID:      *projectID,
+			AllowUntrusted: *allowUntrusted,
+			ImportedBy:     "cli",
+		})
+		if err != nil {
+			log.Fatalf("Ошибка импорта: %v", err)
+		}
+
+		if report.DryRun {
+			log.Printf("Пробный импорт, изменения не сохранены")
+		}
+		log.Printf("Пакет %s из %s, ключ %s (доверенный: %t), стратегия %s",
+			report.BundleID, report.SourceInstance, report.SignerKeyID, report.Trusted, report.Strategy)
+		log.Printf("Всего: %d, создано: %d, обновлено: %d, без изменений: %d, пропущено: %d",
+			report.Total, report.Created, report.Updated, report.Unchanged, report.Skipped)
+		for _, conflict := range report.Conflicts {
+			log.Printf("Конфликт [%s] %q: локальный %s, совпадение по %s, поля %v -> %s",
+				conflict.Kind, conflict.Name, conflict.LocalID, conflict.MatchedBy, conflict.Fields, conflict.Resolution)
+		}
+		for _, warning := range report.Warnings {
+			log.Printf("Предупреждение: %s", warning)
+		}
+
+	default:
+		log.Fatal("Необходимо указать -mode export или -mode import")
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"httpserver/database"
+	"httpserver/server/services"
+	"httpserver/server/types"
+)
+
+func main() {
+	var (
+		dbPath        = flag.String("db", "data.db", "Путь к базе данных выгрузок")
+		serviceDBPath = flag.String("service-db", "service.db", "Путь к сервисной базе (для определения database_id по handshake)")
+		filePath      = flag.String("file", "", "Файл обмена 1С для однократного импорта")
+		watchDir      = flag.String("watch", "", "Каталог для наблюдения за файлами обмена")
+		interval      = flag.Duration("interval", 10*time.Second, "Интервал проверки каталога")
+		databaseID    = flag.String("database-id", "", "database_id, переопределяющий значение из файла")
+		batchSize     = flag.Int("batch", services.DefaultExchangeImportBatchSize, "Размер пакета элементов")
+	)
+	flag.Parse()
+
+	if *filePath == "" && *watchDir == "" {
+		log.Fatalf("Укажите -file или -watch")
+	}
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия базы данных: %v", err)
+	}
+	defer db.Close()
+
+	var serviceDB *database.ServiceDB
+	if *serviceDBPath != "" {
+		serviceDB, err = database.NewServiceDB(*serviceDBPath)
+		if err != nil {
+			log.Fatalf("Ошибка открытия сервисной базы данных: %v", err)
+		}
+		defer serviceDB.Close()
+	}
+
+	logFunc := func(entry interface{}) {
+		if logEntry, ok := entry.(types.LogEntry); ok {
+			log.Printf("[%s] %s", logEntry.Level, logEntry.Message)
+		}
+	}
+
+	uploadService := services.NewUploadService(db, serviceDB, nil, logFunc)
+	importService := services.NewExchangeImportService(uploadService, logFunc)
+	importService.SetBatchSize(*batchSize)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	opts := services.ExchangeImportOptions{DatabaseID: *databaseID}
+
+	if *filePath != "" {
+		progress, err := importService.ImportFile(ctx, *filePath, opts)
+		if err != nil {
+			log.Fatalf("Ошибка импорта: %v", err)
+		}
+		log.Printf("Выгрузка %s: констант %d, справочников %d, элементов %d, номенклатуры %d, ошибок %d",
+			progress.UploadUUID, progress.Constants, progress.Catalogs, progress.CatalogItems,
+			progress.NomenclatureItems, progress.FailedItems)
+		return
+	}
+
+	log.Printf("Наблюдение за каталогом %s (интервал %v)", *watchDir, *interval)
+	if err := importService.WatchDirectory(ctx, *watchDir, *interval, opts); err != nil {
+		log.Fatalf("Ошибка наблюдения за каталогом: %v", err)
+	}
+}
@@ -133,6 +133,11 @@ func main() {
 			} else {
 				normalizer.SetHierarchicalClassifier(hierarchicalClassifier)
 				log.Println("✓ Иерархический КПВЭД классификатор инициализирован")
+				if decisionEngine, err := normalization.NewDecisionEngine(db); err != nil {
+					log.Printf("⚠ Предупреждение: не удалось инициализировать проверку кодов КПВЭД: %v", err)
+				} else {
+					normalizer.SetDecisionEngine(decisionEngine)
+				}
 			}
 		} else {
 			log.Println("⚠ ARLIAI_API_KEY не установлен, КПВЭД классификация будет пропущена")
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"httpserver/database"
+)
+
+func main() {
+	var (
+		servicePath = flag.String("service", "service.db", "Путь к сервисной базе")
+		dir         = flag.String("dir", "", "Каталог с файлами bik.tsv, kpp_reasons.tsv, tax_regions.tsv, tax_offices.tsv")
+		bundled     = flag.Bool("bundled", false, "Загрузить справочники, поставляемые вместе с сервером")
+		ed807       = flag.String("cbr-ed807", "", "Электронный справочник БИК Банка России (ED807, XML или ZIP)")
+		taxOffices  = flag.String("fns-tax-offices", "", "Перечень налоговых органов ФНС (классификатор СОУН, XML или CSV)")
+	)
+	flag.Parse()
+
+	official := *ed807 != "" || *taxOffices != ""
+	if *dir == "" && !*bundled && !official {
+		log.Fatal("Необходимо указать -dir с каталогом справочников, -cbr-ed807/-fns-tax-offices или -bundled")
+	}
+
+	serviceDB, err := database.NewServiceDB(*servicePath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия сервисной базы: %v", err)
+	}
+	defer serviceDB.Close()
+
+	switch {
+	case *bundled:
+		err = database.LoadBundledRequisiteRegistries(serviceDB)
+	case official:
+		err = database.LoadOfficialRequisiteRegistries(serviceDB, *ed807, *taxOffices)
+	default:
+		err = database.LoadRequisiteRegistriesFromDir(serviceDB, *dir)
+	}
+	if err != nil {
+		log.Fatalf("Ошибка загрузки справочников: %v", err)
+	}
+
+	versions, err := database.GetRequisiteRegistryVersions(serviceDB)
+	if err != nil {
+		log.Fatalf("Ошибка чтения версий справочников: %v", err)
+	}
+	for _, v := range versions {
+		log.Printf("%s: %d записей (источник %s, полный: %v)", v.Registry, v.Entries, v.Source, v.Complete)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+
+	"httpserver/database"
+	"httpserver/normalization/algorithms"
+)
+
+func main() {
+	var (
+		dbPath    = flag.String("db", "1c_data.db", "Путь к базе данных с normalized_item_attributes")
+		projectID = flag.Int("project", 0, "ID проекта (0 - обучать глобальную модель по всем проектам)")
+		modelDir  = flag.String("models", "models/ner", "Каталог для файлов моделей")
+		epochs    = flag.Int("epochs", 10, "Количество эпох обучения")
+		evalSplit = flag.Float64("eval-split", 0.2, "Доля примеров для оценки (0 - без оценки)")
+		seed      = flag.Int64("seed", 42, "Seed для перемешивания выборки")
+		limit     = flag.Int("limit", 0, "Максимальное количество наименований (0 - без ограничения)")
+		evalOnly  = flag.Bool("eval-only", false, "Только оценить существующую модель на подтвержденных данных")
+	)
+	flag.Parse()
+
+	db, err := database.NewDB(*dbPath)
+	if err != nil {
+		log.Fatalf("Ошибка открытия базы данных: %v", err)
+	}
+	defer db.Close()
+
+	var projectFilter *int
+	if *projectID > 0 {
+		projectFilter = projectID
+	}
+
+	records, err := db.GetConfirmedAttributesForTraining(projectFilter, *limit)
+	if err != nil {
+		log.Fatalf("Ошибка получения подтвержденных атрибутов: %v", err)
+	}
+	log.Printf("Наименований с подтвержденными атрибутами: %d", len(records))
+
+	examples := buildExamples(records)
+	if len(examples) == 0 {
+		log.Fatalf("Нет обучающих примеров: подтвердите атрибуты через /api/normalization/item-attributes/{id}/confirm")
+	}
+	log.Printf("Обучающих примеров: %d", len(examples))
+
+	modelPath := algorithms.NERModelPath(*modelDir, *projectID)
+
+	if *evalOnly {
+		tagger, err := algorithms.LoadNERForProject(*modelDir, *projectID)
+		if err != nil {
+			log.Fatalf("Ошибка загрузки модели: %v", err)
+		}
+		if !tagger.HasModel() {
+			log.Printf("Модель не найдена, оценивается словарный NER")
+		}
+		printEvaluation("Оценка", algorithms.EvaluateNER(tagger, examples))
+		return
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	rng.Shuffle(len(examples), func(i, j int) { examples[i], examples[j] = examples[j], examples[i] })
+
+	trainSet, evalSet := examples, []algorithms.NERTrainingExample(nil)
+	if *evalSplit > 0 && *evalSplit < 1 && len(examples) >= 5 {
+		evalSize := int(float64(len(examples)) * *evalSplit)
+		if evalSize < 1 {
+			evalSize = 1
+		}
+		evalSet = examples[:evalSize]
+		trainSet = examples[evalSize:]
+	}
+
+	dictionary := algorithms.NewRussianNER()
+	model := algorithms.NewPerceptronNER(dictionary)
+	model.ProjectID = *projectID
+	log.Printf("Обучение на %d примерах, %d эпох...", len(trainSet), *epochs)
+	model.Train(trainSet, *epochs, *seed)
+
+	if len(evalSet) > 0 {
+		baseline := algorithms.EvaluateNER(dictionary, evalSet)
+		printEvaluation("Словарный NER (базовая линия)", baseline)
+		model.Metrics = algorithms.EvaluateNER(model, evalSet)
+		printEvaluation("Обученная модель", model.Metrics)
+	}
+
+	if err := os.MkdirAll(*modelDir, 0755); err != nil {
+		log.Fatalf("Ошибка создания каталога моделей: %v", err)
+	}
+	if err := model.SaveToFile(modelPath); err != nil {
+		log.Fatalf("Ошибка сохранения модели: %v", err)
+	}
+	log.Printf("Модель сохранена: %s", modelPath)
+}
+
+// buildExamples преобразует подтвержденные атрибуты в BIO-размеченные примеры
+func buildExamples(records []*database.ConfirmedAttributesRecord) []algorithms.NERTrainingExample {
+	examples := make([]algorithms.NERTrainingExample, 0, len(records))
+	skipped := 0
+	for _, record := range records {
+		var spans []algorithms.NERLabeledSpan
+		for _, attr := range record.Attributes {
+			entityType, ok := algorithms.NEREntityTypeForAttribute(attr.AttributeType, attr.AttributeName)
+			if !ok {
+				continue
+			}
+			text := attr.OriginalText
+			if text == "" {
+				text = attr.AttributeValue
+			}
+			spans = append(spans, algorithms.NERLabeledSpan{Type: entityType, Text: text})
+		}
+		if len(spans) == 0 {
+			skipped++
+			continue
+		}
+		example, placed := algorithms.BuildNERTrainingExample(record.SourceName, spans)
+		if placed == 0 {
+			skipped++
+			continue
+		}
+		examples = append(examples, example)
+	}
+	if skipped > 0 {
+		log.Printf("Пропущено наименований без размещаемых атрибутов: %d", skipped)
+	}
+	return examples
+}
+
+// printEvaluation выводит метрики оценки
+func printEvaluation(title string, eval *algorithms.NEREvaluation) {
+	fmt.Printf("\n%s (%d примеров, %d токенов)\n", title, eval.Examples, eval.Tokens)
+	fmt.Printf("  Точность по токенам: %.3f\n", eval.TokenAccuracy)
+	fmt.Printf("  Итого: P=%.3f R=%.3f F1=%.3f\n", eval.Overall.Precision, eval.Overall.Recall, eval.Overall.F1)
+	for _, t := range eval.SortedTypes() {
+		m := eval.ByType[t]
+		fmt.Printf("  %-10s P=%.3f R=%.3f F1=%.3f (tp=%d fp=%d fn=%d)\n",
+			t, m.Precision, m.Recall, m.F1, m.TruePositives, m.FalsePositives, m.FalseNegatives)
+	}
+}
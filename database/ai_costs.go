@@ -0,0 +1,362 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AIPrice цена вызова модели провайдера. Model "*" - цена по умолчанию для всех моделей провайдера.
+type AIPrice struct {
+	ID                int       `json:"id"`
+	Provider          string    `json:"provider"`
+	Model             string    `json:"model"`
+	InputPer1KTokens  float64   `json:"input_per_1k_tokens"`
+	OutputPer1KTokens float64   `json:"output_per_1k_tokens"`
+	PerRequest        float64   `json:"per_request"`
+	Currency          string    `json:"currency"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// AIUsageLedgerEntry агрегированная строка журнала потребления AI за день
+type AIUsageLedgerEntry struct {
+	Day          string  `json:"day"` // YYYY-MM-DD
+	Provider     string  `json:"provider"`
+	Model        string  `json:"model"`
+	ClientID     int     `json:"client_id"`
+	ProjectID    int     `json:"project_id"`
+	SessionID    string  `json:"session_id"`
+	Operation    string  `json:"operation"`
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
+	Estimated    int64   `json:"estimated_requests"` // вызовы с оцененными токенами
+}
+
+// AIUsageFilter фильтр выборки журнала потребления
+type AIUsageFilter struct {
+	From      string // YYYY-MM-DD включительно
+	To        string // YYYY-MM-DD включительно
+	Provider  string
+	ClientID  int
+	ProjectID int
+	SessionID string
+	GroupBy   []string // day, provider, model, client, project, session, operation
+}
+
+// AIBudget бюджет на AI. Scope: global, client, project; Period: daily, monthly.
+type AIBudget struct {
+	ID        int       `json:"id"`
+	Scope     string    `json:"scope"`
+	ScopeID   int       `json:"scope_id"`
+	Period    string    `json:"period"`
+	SoftLimit float64   `json:"soft_limit"` // при превышении - предупреждение
+	HardLimit float64   `json:"hard_limit"` // при превышении - остановка AI обработки
+	Currency  string    `json:"currency"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// aiUsageGroupColumns допустимые колонки группировки журнала
+var aiUsageGroupColumns = map[string]string{
+	"day":       "day",
+	"provider":  "provider",
+	"model":     "model",
+	"client":    "client_id",
+	"project":   "project_id",
+	"session":   "session_id",
+	"operation": "operation",
+}
+
+// CreateAICostTables создает таблицы цен, журнала потребления и бюджетов AI
+func CreateAICostTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_prices (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '*',
+			input_per_1k_tokens REAL NOT NULL DEFAULT 0,
+			output_per_1k_tokens REAL NOT NULL DEFAULT 0,
+			per_request REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'USD',
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(provider, model)
+		);
+
+		CREATE TABLE IF NOT EXISTS ai_usage_ledger (
+			day TEXT NOT NULL,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			client_id INTEGER NOT NULL DEFAULT 0,
+			project_id INTEGER NOT NULL DEFAULT 0,
+			session_id TEXT NOT NULL DEFAULT '',
+			operation TEXT NOT NULL DEFAULT '',
+			requests INTEGER NOT NULL DEFAULT 0,
+			input_tokens INTEGER NOT NULL DEFAULT 0,
+			output_tokens INTEGER NOT NULL DEFAULT 0,
+			cost REAL NOT NULL DEFAULT 0,
+			estimated_requests INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (day, provider, model, client_id, project_id, session_id, operation)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ai_usage_ledger_client ON ai_usage_ledger(client_id, day);
+		CREATE INDEX IF NOT EXISTS idx_ai_usage_ledger_project ON ai_usage_ledger(project_id, day);
+
+		CREATE TABLE IF NOT EXISTS ai_budgets (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			scope TEXT NOT NULL, -- global, client, project
+			scope_id INTEGER NOT NULL DEFAULT 0,
+			period TEXT NOT NULL, -- daily, monthly
+			soft_limit REAL NOT NULL DEFAULT 0,
+			hard_limit REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'USD',
+			enabled BOOLEAN DEFAULT TRUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(scope, scope_id, period)
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create AI cost tables: %w", err)
+	}
+	return nil
+}
+
+// GetAIPrices возвращает таблицу цен AI провайдеров
+func (db *ServiceDB) GetAIPrices() ([]*AIPrice, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, provider, model, input_per_1k_tokens, output_per_1k_tokens, per_request, currency, updated_at
+		FROM ai_prices ORDER BY provider, model
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI prices: %w", err)
+	}
+	defer rows.Close()
+
+	var prices []*AIPrice
+	for rows.Next() {
+		p := &AIPrice{}
+		if err := rows.Scan(&p.ID, &p.Provider, &p.Model, &p.InputPer1KTokens, &p.OutputPer1KTokens,
+			&p.PerRequest, &p.Currency, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan AI price: %w", err)
+		}
+		prices = append(prices, p)
+	}
+	return prices, rows.Err()
+}
+
+// UpsertAIPrice создает или обновляет цену модели провайдера
+func (db *ServiceDB) UpsertAIPrice(price *AIPrice) error {
+	model := price.Model
+	if model == "" {
+		model = "*"
+	}
+	currency := price.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO ai_prices (provider, model, input_per_1k_tokens, output_per_1k_tokens, per_request, currency, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(provider, model) DO UPDATE SET
+			input_per_1k_tokens = excluded.input_per_1k_tokens,
+			output_per_1k_tokens = excluded.output_per_1k_tokens,
+			per_request = excluded.per_request,
+			currency = excluded.currency,
+			updated_at = CURRENT_TIMESTAMP
+	`, strings.ToLower(price.Provider), model, price.InputPer1KTokens, price.OutputPer1KTokens, price.PerRequest, currency)
+	if err != nil {
+		return fmt.Errorf("failed to save AI price: %w", err)
+	}
+	return nil
+}
+
+// DeleteAIPrice удаляет цену по ID
+func (db *ServiceDB) DeleteAIPrice(id int) error {
+	_, err := db.conn.Exec(`DELETE FROM ai_prices WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete AI price: %w", err)
+	}
+	return nil
+}
+
+// AddAIUsage добавляет потребление в дневную строку журнала
+func (db *ServiceDB) AddAIUsage(entry *AIUsageLedgerEntry) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO ai_usage_ledger (day, provider, model, client_id, project_id, session_id, operation,
+			requests, input_tokens, output_tokens, cost, estimated_requests, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(day, provider, model, client_id, project_id, session_id, operation) DO UPDATE SET
+			requests = requests + excluded.requests,
+			input_tokens = input_tokens + excluded.input_tokens,
+			output_tokens = output_tokens + excluded.output_tokens,
+			cost = cost + excluded.cost,
+			estimated_requests = estimated_requests + excluded.estimated_requests,
+			updated_at = CURRENT_TIMESTAMP
+	`, entry.Day, entry.Provider, entry.Model, entry.ClientID, entry.ProjectID, entry.SessionID, entry.Operation,
+		entry.Requests, entry.InputTokens, entry.OutputTokens, entry.Cost, entry.Estimated)
+	if err != nil {
+		return fmt.Errorf("failed to add AI usage: %w", err)
+	}
+	return nil
+}
+
+// GetAIUsage возвращает журнал потребления, агрегированный по колонкам filter.GroupBy
+func (db *ServiceDB) GetAIUsage(filter AIUsageFilter) ([]*AIUsageLedgerEntry, error) {
+	var groupCols []string
+	for _, g := range filter.GroupBy {
+		col, ok := aiUsageGroupColumns[g]
+		if !ok {
+			return nil, fmt.Errorf("unsupported group by column: %s", g)
+		}
+		groupCols = append(groupCols, col)
+	}
+
+	selectCols := make([]string, 0, len(aiUsageGroupColumns))
+	for _, col := range []string{"day", "provider", "model", "client_id", "project_id", "session_id", "operation"} {
+		grouped := len(groupCols) == 0
+		for _, g := range groupCols {
+			if g == col {
+				grouped = true
+			}
+		}
+		if grouped {
+			selectCols = append(selectCols, col)
+		} else if col == "client_id" || col == "project_id" {
+			selectCols = append(selectCols, "0")
+		} else {
+			selectCols = append(selectCols, "''")
+		}
+	}
+
+	where := []string{"1=1"}
+	var args []interface{}
+	if filter.From != "" {
+		where = append(where, "day >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To != "" {
+		where = append(where, "day <= ?")
+		args = append(args, filter.To)
+	}
+	if filter.Provider != "" {
+		where = append(where, "provider = ?")
+		args = append(args, strings.ToLower(filter.Provider))
+	}
+	if filter.ClientID > 0 {
+		where = append(where, "client_id = ?")
+		args = append(args, filter.ClientID)
+	}
+	if filter.ProjectID > 0 {
+		where = append(where, "project_id = ?")
+		args = append(args, filter.ProjectID)
+	}
+	if filter.SessionID != "" {
+		where = append(where, "session_id = ?")
+		args = append(args, filter.SessionID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s, SUM(requests), SUM(input_tokens), SUM(output_tokens), SUM(cost), SUM(estimated_requests)
+		FROM ai_usage_ledger WHERE %s`, strings.Join(selectCols, ", "), strings.Join(where, " AND "))
+	if len(groupCols) > 0 {
+		query += " GROUP BY " + strings.Join(groupCols, ", ") + " ORDER BY " + strings.Join(groupCols, ", ")
+	} else {
+		query += " GROUP BY day, provider, model, client_id, project_id, session_id, operation ORDER BY day, provider, model"
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI usage: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*AIUsageLedgerEntry
+	for rows.Next() {
+		e := &AIUsageLedgerEntry{}
+		if err := rows.Scan(&e.Day, &e.Provider, &e.Model, &e.ClientID, &e.ProjectID, &e.SessionID, &e.Operation,
+			&e.Requests, &e.InputTokens, &e.OutputTokens, &e.Cost, &e.Estimated); err != nil {
+			return nil, fmt.Errorf("failed to scan AI usage: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// GetAISpend возвращает сумму затрат за период [from, to] для области бюджета
+func (db *ServiceDB) GetAISpend(scope string, scopeID int, from, to string) (float64, error) {
+	query := `SELECT COALESCE(SUM(cost), 0) FROM ai_usage_ledger WHERE day >= ? AND day <= ?`
+	args := []interface{}{from, to}
+	switch scope {
+	case "client":
+		query += " AND client_id = ?"
+		args = append(args, scopeID)
+	case "project":
+		query += " AND project_id = ?"
+		args = append(args, scopeID)
+	}
+
+	var spend float64
+	if err := db.conn.QueryRow(query, args...).Scan(&spend); err != nil {
+		return 0, fmt.Errorf("failed to get AI spend: %w", err)
+	}
+	return spend, nil
+}
+
+// GetAIBudgets возвращает все бюджеты AI
+func (db *ServiceDB) GetAIBudgets() ([]*AIBudget, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, scope, scope_id, period, soft_limit, hard_limit, currency, enabled, created_at, updated_at
+		FROM ai_budgets ORDER BY scope, scope_id, period
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AI budgets: %w", err)
+	}
+	defer rows.Close()
+
+	var budgets []*AIBudget
+	for rows.Next() {
+		b := &AIBudget{}
+		if err := rows.Scan(&b.ID, &b.Scope, &b.ScopeID, &b.Period, &b.SoftLimit, &b.HardLimit,
+			&b.Currency, &b.Enabled, &b.CreatedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan AI budget: %w", err)
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// UpsertAIBudget создает или обновляет бюджет (уникален по scope, scope_id, period)
+func (db *ServiceDB) UpsertAIBudget(budget *AIBudget) error {
+	currency := budget.Currency
+	if currency == "" {
+		currency = "USD"
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO ai_budgets (scope, scope_id, period, soft_limit, hard_limit, currency, enabled, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(scope, scope_id, period) DO UPDATE SET
+			soft_limit = excluded.soft_limit,
+			hard_limit = excluded.hard_limit,
+			currency = excluded.currency,
+			enabled = excluded.enabled,
+			updated_at = CURRENT_TIMESTAMP
+	`, budget.Scope, budget.ScopeID, budget.Period, budget.SoftLimit, budget.HardLimit, currency, budget.Enabled)
+	if err != nil {
+		return fmt.Errorf("failed to save AI budget: %w", err)
+	}
+	return nil
+}
+
+// DeleteAIBudget удаляет бюджет по ID
+func (db *ServiceDB) DeleteAIBudget(id int) error {
+	_, err := db.conn.Exec(`DELETE FROM ai_budgets WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete AI budget: %w", err)
+	}
+	return nil
+}
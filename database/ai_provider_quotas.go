@@ -0,0 +1,200 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// AIProviderQuota общая для процессов корзина квоты AI провайдера (ключ API + модель).
+// Время хранится в unix-секундах (REAL), чтобы пополнение токенов считалось одним UPDATE
+type AIProviderQuota struct {
+	Key             string    `json:"key"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	KeyFingerprint  string    `json:"key_fingerprint"`
+	Rate            float64   `json:"rate"`
+	MinRate         float64   `json:"min_rate"`
+	Ceiling         float64   `json:"ceiling"`
+	Burst           int       `json:"burst"`
+	Tokens          float64   `json:"tokens"`
+	LastRefill      time.Time `json:"last_refill"`
+	BlockedUntil    time.Time `json:"blocked_until"`
+	ThrottledCount  int       `json:"throttled_count"`
+	LastThrottledAt time.Time `json:"last_throttled_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// AIProviderQuotaAdjustment изменение корзины квоты
+type AIProviderQuotaAdjustment struct {
+	RateFactor   float64 // 0 - без изменений
+	RateStep     float64
+	Ceiling      float64 // 0 - без изменений
+	BlockedUntil time.Time
+	Throttled    bool
+}
+
+// CreateAIProviderQuotasTable создает таблицу корзин квот AI провайдеров
+func CreateAIProviderQuotasTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ai_provider_quotas (
+			quota_key TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			model TEXT NOT NULL DEFAULT '',
+			key_fingerprint TEXT NOT NULL DEFAULT '',
+			rate REAL NOT NULL,
+			min_rate REAL NOT NULL,
+			ceiling REAL NOT NULL,
+			burst INTEGER NOT NULL DEFAULT 1,
+			tokens REAL NOT NULL DEFAULT 0,
+			last_refill REAL NOT NULL DEFAULT 0,
+			blocked_until REAL NOT NULL DEFAULT 0,
+			throttled_count INTEGER NOT NULL DEFAULT 0,
+			last_throttled_at REAL NOT NULL DEFAULT 0,
+			updated_at REAL NOT NULL DEFAULT 0
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_ai_provider_quotas_provider ON ai_provider_quotas(provider);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create AI provider quotas table: %w", err)
+	}
+	return nil
+}
+
+// EnsureAIProviderQuota создает корзину, если ее еще нет (состояние существующей не меняется)
+func (db *ServiceDB) EnsureAIProviderQuota(quota *AIProviderQuota) error {
+	_, err := db.conn.Exec(`
+		INSERT OR IGNORE INTO ai_provider_quotas
+			(quota_key, provider, model, key_fingerprint, rate, min_rate, ceiling, burst, tokens, last_refill, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, quota.Key, quota.Provider, quota.Model, quota.KeyFingerprint, quota.Rate, quota.MinRate, quota.Ceiling,
+		quota.Burst, quota.Tokens, unixSeconds(quota.LastRefill), unixSeconds(quota.UpdatedAt))
+	if err != nil {
+		return fmt.Errorf("failed to ensure AI provider quota: %w", err)
+	}
+	return nil
+}
+
+// AcquireAIProviderQuota атомарно пополняет корзину и забирает токен.
+// Возвращает 0, если токен получен, иначе время до появления токена или снятия блокировки
+func (db *ServiceDB) AcquireAIProviderQuota(key string, now time.Time) (time.Duration, error) {
+	nowSec := unixSeconds(now)
+	result, err := db.conn.Exec(`
+		UPDATE ai_provider_quotas
+		SET tokens = MIN(burst, tokens + MAX(0, @now - last_refill) * rate) - 1,
+		    last_refill = MAX(last_refill, @now),
+		    updated_at = @now
+		WHERE quota_key = @key
+		  AND blocked_until <= @now
+		  AND MIN(burst, tokens + MAX(0, @now - last_refill) * rate) >= 1
+	`, sql.Named("now", nowSec), sql.Named("key", key))
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire AI provider quota: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected > 0 {
+		return 0, nil
+	}
+
+	var tokens, lastRefill, rate, blockedUntil float64
+	var burst int
+	err = db.conn.QueryRow(`
+		SELECT tokens, last_refill, rate, burst, blocked_until FROM ai_provider_quotas WHERE quota_key = ?
+	`, key).Scan(&tokens, &lastRefill, &rate, &burst, &blockedUntil)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("AI provider quota %s is not registered", key)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read AI provider quota: %w", err)
+	}
+	if blockedUntil > nowSec {
+		return secondsDuration(blockedUntil - nowSec), nil
+	}
+	available := math.Min(float64(burst), tokens+math.Max(0, nowSec-lastRefill)*rate)
+	if rate <= 0 {
+		return time.Second, nil
+	}
+	// Токен мог освободиться между UPDATE и SELECT - тогда повторная попытка будет сразу
+	return secondsDuration(math.Max(0, 1-available) / rate), nil
+}
+
+// AdjustAIProviderQuota атомарно меняет скорость, потолок и блокировку корзины
+func (db *ServiceDB) AdjustAIProviderQuota(key string, adj AIProviderQuotaAdjustment, now time.Time) error {
+	factor := adj.RateFactor
+	if factor <= 0 {
+		factor = 1
+	}
+	throttled := 0
+	if adj.Throttled {
+		throttled = 1
+	}
+	_, err := db.conn.Exec(`
+		UPDATE ai_provider_quotas
+		SET ceiling = CASE WHEN @ceiling > 0 THEN @ceiling ELSE ceiling END,
+		    rate = MAX(min_rate, MIN(CASE WHEN @ceiling > 0 THEN @ceiling ELSE ceiling END, rate * @factor + @step)),
+		    blocked_until = MAX(blocked_until, @blocked),
+		    throttled_count = throttled_count + @throttled,
+		    last_throttled_at = CASE WHEN @throttled = 1 THEN @now ELSE last_throttled_at END,
+		    tokens = CASE WHEN @throttled = 1 THEN 0 ELSE tokens END,
+		    last_refill = CASE WHEN @throttled = 1 THEN @now ELSE last_refill END,
+		    updated_at = @now
+		WHERE quota_key = @key
+	`, sql.Named("ceiling", adj.Ceiling), sql.Named("factor", factor), sql.Named("step", adj.RateStep),
+		sql.Named("blocked", unixSeconds(adj.BlockedUntil)), sql.Named("throttled", throttled),
+		sql.Named("now", unixSeconds(now)), sql.Named("key", key))
+	if err != nil {
+		return fmt.Errorf("failed to adjust AI provider quota: %w", err)
+	}
+	return nil
+}
+
+// GetAIProviderQuotas возвращает все корзины квот
+func (db *ServiceDB) GetAIProviderQuotas() ([]*AIProviderQuota, error) {
+	rows, err := db.conn.Query(`
+		SELECT quota_key, provider, model, key_fingerprint, rate, min_rate, ceiling, burst, tokens,
+		       last_refill, blocked_until, throttled_count, last_throttled_at, updated_at
+		FROM ai_provider_quotas
+		ORDER BY provider, model
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query AI provider quotas: %w", err)
+	}
+	defer rows.Close()
+
+	var quotas []*AIProviderQuota
+	for rows.Next() {
+		quota := &AIProviderQuota{}
+		var lastRefill, blockedUntil, lastThrottledAt, updatedAt float64
+		if err := rows.Scan(&quota.Key, &quota.Provider, &quota.Model, &quota.KeyFingerprint, &quota.Rate,
+			&quota.MinRate, &quota.Ceiling, &quota.Burst, &quota.Tokens, &lastRefill, &blockedUntil,
+			&quota.ThrottledCount, &lastThrottledAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan AI provider quota: %w", err)
+		}
+		quota.LastRefill = fromUnixSeconds(lastRefill)
+		quota.BlockedUntil = fromUnixSeconds(blockedUntil)
+		quota.LastThrottledAt = fromUnixSeconds(lastThrottledAt)
+		quota.UpdatedAt = fromUnixSeconds(updatedAt)
+		quotas = append(quotas, quota)
+	}
+	return quotas, rows.Err()
+}
+
+func unixSeconds(t time.Time) float64 {
+	if t.IsZero() {
+		return 0
+	}
+	return float64(t.UnixNano()) / float64(time.Second)
+}
+
+func fromUnixSeconds(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
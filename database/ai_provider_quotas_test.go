@@ -0,0 +1,73 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestAIProviderQuotaSharedBetweenProcesses проверяет, что два подключения к одной БД делят общий бюджет запросов
+func TestAIProviderQuotaSharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.db")
+	first, err := NewServiceDB(path)
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	defer first.Close()
+	second, err := NewServiceDB(path)
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	defer second.Close()
+
+	now := time.Unix(1700000000, 0)
+	quota := &AIProviderQuota{
+		Key: "openrouter|model|abc", Provider: "openrouter", Model: "model", KeyFingerprint: "abc",
+		Rate: 1, MinRate: 0.1, Ceiling: 10, Burst: 2, Tokens: 2, LastRefill: now, UpdatedAt: now,
+	}
+	for _, db := range []*ServiceDB{first, second} {
+		if err := db.EnsureAIProviderQuota(quota); err != nil {
+			t.Fatalf("EnsureAIProviderQuota() error = %v", err)
+		}
+	}
+
+	if wait, err := first.AcquireAIProviderQuota(quota.Key, now); err != nil || wait != 0 {
+		t.Fatalf("first acquire = %v, %v", wait, err)
+	}
+	if wait, err := second.AcquireAIProviderQuota(quota.Key, now); err != nil || wait != 0 {
+		t.Fatalf("second acquire = %v, %v", wait, err)
+	}
+	if wait, err := first.AcquireAIProviderQuota(quota.Key, now); err != nil || wait <= 0 {
+		t.Fatalf("acquire over burst = %v, %v, want wait", wait, err)
+	}
+
+	// Ограничение, полученное одним процессом, видно другому
+	blockedUntil := now.Add(10 * time.Second)
+	if err := second.AdjustAIProviderQuota(quota.Key, AIProviderQuotaAdjustment{
+		RateFactor: 0.5, BlockedUntil: blockedUntil, Throttled: true,
+	}, now); err != nil {
+		t.Fatalf("AdjustAIProviderQuota() error = %v", err)
+	}
+	wait, err := first.AcquireAIProviderQuota(quota.Key, now.Add(4*time.Second))
+	if err != nil || wait < 5*time.Second {
+		t.Errorf("acquire while blocked = %v, %v, want about 6s", wait, err)
+	}
+
+	quotas, err := first.GetAIProviderQuotas()
+	if err != nil || len(quotas) != 1 {
+		t.Fatalf("GetAIProviderQuotas() = %v, %v", quotas, err)
+	}
+	if quotas[0].Rate != 0.5 || quotas[0].ThrottledCount != 1 || !quotas[0].BlockedUntil.Equal(blockedUntil) {
+		t.Errorf("quota = %+v", quotas[0])
+	}
+
+	// Скорость не опускается ниже min_rate и не поднимается выше потолка
+	for i := 0; i < 10; i++ {
+		_ = first.AdjustAIProviderQuota(quota.Key, AIProviderQuotaAdjustment{RateFactor: 0.5}, now)
+	}
+	_ = first.AdjustAIProviderQuota(quota.Key, AIProviderQuotaAdjustment{Ceiling: 0.3, RateStep: 5}, now)
+	quotas, _ = first.GetAIProviderQuotas()
+	if quotas[0].Rate != 0.3 || quotas[0].Ceiling != 0.3 {
+		t.Errorf("rate = %v, ceiling = %v, want 0.3", quotas[0].Rate, quotas[0].Ceiling)
+	}
+}
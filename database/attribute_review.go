@@ -0,0 +1,131 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MigrateItemAttributesReviewFields добавляет в normalized_item_attributes поля подтверждения ревьюером.
+// Подтвержденные атрибуты используются как обучающая выборка для NER.
+func MigrateItemAttributesReviewFields(db *sql.DB) error {
+	migrations := []string{
+		`ALTER TABLE normalized_item_attributes ADD COLUMN reviewer_confirmed INTEGER DEFAULT 0`,
+		`ALTER TABLE normalized_item_attributes ADD COLUMN confirmed_by TEXT`,
+		`ALTER TABLE normalized_item_attributes ADD COLUMN confirmed_at TIMESTAMP`,
+		`CREATE INDEX IF NOT EXISTS idx_attributes_confirmed ON normalized_item_attributes(reviewer_confirmed)`,
+	}
+
+	for _, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			errStr := strings.ToLower(err.Error())
+			if !strings.Contains(errStr, "duplicate column") &&
+				!strings.Contains(errStr, "already exists") {
+				return fmt.Errorf("migration failed: %s, error: %w", migration, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ConfirmItemAttribute отмечает атрибут как подтвержденный (или снимает отметку) ревьюером
+func (db *DB) ConfirmItemAttribute(attributeID int, reviewer string, confirmed bool) error {
+	var result sql.Result
+	var err error
+	if confirmed {
+		result, err = db.conn.Exec(`
+			UPDATE normalized_item_attributes
+			SET reviewer_confirmed = 1, confirmed_by = ?, confirmed_at = CURRENT_TIMESTAMP
+			WHERE id = ?
+		`, reviewer, attributeID)
+	} else {
+		result, err = db.conn.Exec(`
+			UPDATE normalized_item_attributes
+			SET reviewer_confirmed = 0, confirmed_by = NULL, confirmed_at = NULL
+			WHERE id = ?
+		`, attributeID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to confirm attribute: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("attribute not found: %d", attributeID)
+	}
+	return nil
+}
+
+// ConfirmedAttributesRecord наименование вместе с подтвержденными ревьюером атрибутами
+type ConfirmedAttributesRecord struct {
+	NormalizedItemID int              `json:"normalized_item_id"`
+	SourceName       string           `json:"source_name"`
+	ProjectID        *int             `json:"project_id,omitempty"`
+	Attributes       []*ItemAttribute `json:"attributes"`
+}
+
+// GetConfirmedAttributesForTraining возвращает наименования с подтвержденными атрибутами.
+// projectID nil - по всем проектам. limit <= 0 - без ограничения.
+func (db *DB) GetConfirmedAttributesForTraining(projectID *int, limit int) ([]*ConfirmedAttributesRecord, error) {
+	query := `
+		SELECT nd.id, COALESCE(nd.source_name, ''), nd.project_id,
+		       a.id, a.attribute_type, COALESCE(a.attribute_name, ''), a.attribute_value,
+		       COALESCE(a.unit, ''), COALESCE(a.original_text, ''), COALESCE(a.confidence, 1.0)
+		FROM normalized_item_attributes a
+		INNER JOIN normalized_data nd ON nd.id = a.normalized_item_id
+		WHERE a.reviewer_confirmed = 1
+	`
+	var args []interface{}
+	if projectID != nil {
+		query += ` AND nd.project_id = ?`
+		args = append(args, *projectID)
+	}
+	query += ` ORDER BY nd.id, a.id`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query confirmed attributes: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ConfirmedAttributesRecord
+	var current *ConfirmedAttributesRecord
+	for rows.Next() {
+		var itemID int
+		var sourceName string
+		var itemProjectID sql.NullInt64
+		attr := &ItemAttribute{}
+		if err := rows.Scan(&itemID, &sourceName, &itemProjectID,
+			&attr.ID, &attr.AttributeType, &attr.AttributeName, &attr.AttributeValue,
+			&attr.Unit, &attr.OriginalText, &attr.Confidence); err != nil {
+			return nil, fmt.Errorf("failed to scan confirmed attribute: %w", err)
+		}
+		attr.NormalizedItemID = itemID
+
+		if current == nil || current.NormalizedItemID != itemID {
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+			current = &ConfirmedAttributesRecord{
+				NormalizedItemID: itemID,
+				SourceName:       sourceName,
+			}
+			if itemProjectID.Valid {
+				pid := int(itemProjectID.Int64)
+				current.ProjectID = &pid
+			}
+			records = append(records, current)
+		}
+		current.Attributes = append(current.Attributes, attr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating confirmed attributes: %w", err)
+	}
+
+	return records, nil
+}
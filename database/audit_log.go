@@ -0,0 +1,419 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AuditLogEntry запись журнала аудита изменяющих операций
+type AuditLogEntry struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Actor      string    `json:"actor"`
+	APIKey     string    `json:"api_key,omitempty"` // отпечаток ключа, сам ключ не хранится
+	Role       string    `json:"role,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	ClientIP   string    `json:"client_ip,omitempty"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id,omitempty"`
+	Before     string    `json:"before,omitempty"` // JSON состояния до изменения
+	After      string    `json:"after,omitempty"`  // JSON состояния после изменения
+	Diff       string    `json:"diff,omitempty"`   // JSON изменившихся полей
+	Reason     string    `json:"reason,omitempty"`
+	PrevHash   string    `json:"prev_hash"`
+	Hash       string    `json:"hash"`
+}
+
+// AuditLogFilter фильтр выборки журнала аудита
+type AuditLogFilter struct {
+	From       *time.Time
+	To         *time.Time
+	Actor      string
+	RequestID  string
+	EntityType string
+	EntityID   string
+	Action     string
+	Method     string
+	Limit      int
+	Offset     int
+}
+
+// AuditChainVerification результат проверки цепочки хешей журнала аудита
+type AuditChainVerification struct {
+	Valid      bool             `json:"valid"`
+	Checked    int              `json:"checked"`
+	BrokenAt   int64            `json:"broken_at,omitempty"`
+	Reason     string           `json:"reason,omitempty"`
+	Checkpoint *AuditCheckpoint `json:"checkpoint,omitempty"` // последняя очистка, с которой начинается цепочка
+}
+
+// AuditCheckpoint отметка очистки журнала: последняя удаленная запись и ее хеш.
+// Первая оставшаяся запись должна ссылаться на этот хеш, поэтому удаление записей
+// в обход очистки обнаруживается при проверке цепочки
+type AuditCheckpoint struct {
+	ID             int64     `json:"id"`
+	LastPrunedID   int64     `json:"last_pruned_id"`
+	LastPrunedHash string    `json:"last_pruned_hash"`
+	PrunedCount    int64     `json:"pruned_count"` // записей удалено этой очисткой
+	TotalPruned    int64     `json:"total_pruned"` // записей удалено всеми очистками
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CreateAuditLogTables создает журнал аудита. Изменение записей запрещено триггером,
+// удаление допускается только для старых записей при очистке по сроку хранения.
+func CreateAuditLogTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			created_at TIMESTAMP NOT NULL,
+			actor TEXT,
+			api_key TEXT,
+			role TEXT,
+			request_id TEXT,
+			client_ip TEXT,
+			method TEXT,
+			path TEXT,
+			status_code INTEGER,
+			action TEXT,
+			entity_type TEXT,
+			entity_id TEXT,
+			before_json TEXT,
+			after_json TEXT,
+			diff_json TEXT,
+			reason TEXT,
+			prev_hash TEXT NOT NULL,
+			hash TEXT NOT NULL
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity_type, entity_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_request ON audit_log(request_id);
+
+		CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+		BEFORE UPDATE ON audit_log
+		BEGIN
+			SELECT RAISE(ABORT, 'audit_log is append-only');
+		END;
+
+		CREATE TABLE IF NOT EXISTS audit_checkpoints (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			last_pruned_id INTEGER NOT NULL,
+			last_pruned_hash TEXT NOT NULL,
+			pruned_count INTEGER NOT NULL,
+			total_pruned INTEGER NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+
+		CREATE TRIGGER IF NOT EXISTS audit_checkpoints_no_update
+		BEFORE UPDATE ON audit_checkpoints
+		BEGIN
+			SELECT RAISE(ABORT, 'audit_checkpoints is append-only');
+		END;
+
+		CREATE TRIGGER IF NOT EXISTS audit_checkpoints_no_delete
+		BEFORE DELETE ON audit_checkpoints
+		BEGIN
+			SELECT RAISE(ABORT, 'audit_checkpoints is append-only');
+		END;
+
+		CREATE TABLE IF NOT EXISTS audit_settings (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			retention_days INTEGER NOT NULL DEFAULT 365,
+			updated_by TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit log tables: %w", err)
+	}
+	return nil
+}
+
+// AuditEntryHash вычисляет хеш записи журнала, связанный с хешем предыдущей записи
+func AuditEntryHash(e *AuditLogEntry) string {
+	h := sha256.New()
+	fields := []string{
+		e.PrevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Actor, e.APIKey, e.Role, e.RequestID, e.ClientIP,
+		e.Method, e.Path, fmt.Sprintf("%d", e.StatusCode),
+		e.Action, e.EntityType, e.EntityID,
+		e.Before, e.After, e.Diff, e.Reason,
+	}
+	for _, f := range fields {
+		h.Write([]byte(f))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// AppendAuditLogEntry добавляет запись в конец журнала, связывая ее хешем с предыдущей
+func (db *ServiceDB) AppendAuditLogEntry(e *AuditLogEntry) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var prevHash sql.NullString
+	err = tx.QueryRow(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to get last audit hash: %w", err)
+	}
+	e.PrevHash = prevHash.String
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	e.CreatedAt = e.CreatedAt.UTC()
+	e.Hash = AuditEntryHash(e)
+
+	result, err := tx.Exec(`
+		INSERT INTO audit_log (created_at, actor, api_key, role, request_id, client_ip, method, path, status_code,
+			action, entity_type, entity_id, before_json, after_json, diff_json, reason, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.CreatedAt.Format(time.RFC3339Nano), e.Actor, e.APIKey, e.Role, e.RequestID, e.ClientIP, e.Method, e.Path, e.StatusCode,
+		e.Action, e.EntityType, e.EntityID, e.Before, e.After, e.Diff, e.Reason, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to append audit log entry: %w", err)
+	}
+	if e.ID, err = result.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to get audit log entry id: %w", err)
+	}
+	return tx.Commit()
+}
+
+// auditWhere строит условие выборки журнала
+func auditWhere(f AuditLogFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if f.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.From.UTC().Format(time.RFC3339Nano))
+	}
+	if f.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, f.To.UTC().Format(time.RFC3339Nano))
+	}
+	for column, value := range map[string]string{
+		"actor":       f.Actor,
+		"request_id":  f.RequestID,
+		"entity_type": f.EntityType,
+		"entity_id":   f.EntityID,
+		"action":      f.Action,
+		"method":      strings.ToUpper(f.Method),
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// QueryAuditLog возвращает записи журнала по фильтру (от новых к старым) и их общее количество
+func (db *ServiceDB) QueryAuditLog(f AuditLogFilter) ([]*AuditLogEntry, int, error) {
+	where, args := auditWhere(f)
+
+	var total int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count audit log entries: %w", err)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM audit_log` + where + ` ORDER BY id DESC`
+	if f.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d OFFSET %d", f.Limit, f.Offset)
+	}
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+const auditColumns = `id, created_at, COALESCE(actor, ''), COALESCE(api_key, ''), COALESCE(role, ''),
+	COALESCE(request_id, ''), COALESCE(client_ip, ''), COALESCE(method, ''), COALESCE(path, ''), COALESCE(status_code, 0),
+	COALESCE(action, ''), COALESCE(entity_type, ''), COALESCE(entity_id, ''), COALESCE(before_json, ''),
+	COALESCE(after_json, ''), COALESCE(diff_json, ''), COALESCE(reason, ''), prev_hash, hash`
+
+// scanAuditEntries читает записи журнала из результата запроса
+func scanAuditEntries(rows *sql.Rows) ([]*AuditLogEntry, error) {
+	var entries []*AuditLogEntry
+	for rows.Next() {
+		e := &AuditLogEntry{}
+		var createdAt string
+		if err := rows.Scan(&e.ID, &createdAt, &e.Actor, &e.APIKey, &e.Role, &e.RequestID, &e.ClientIP,
+			&e.Method, &e.Path, &e.StatusCode, &e.Action, &e.EntityType, &e.EntityID,
+			&e.Before, &e.After, &e.Diff, &e.Reason, &e.PrevHash, &e.Hash); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+		t, err := time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse audit timestamp %q: %w", createdAt, err)
+		}
+		e.CreatedAt = t
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// VerifyAuditLogChain проверяет целостность цепочки хешей. Первая запись должна начинать
+// цепочку (пустой prev_hash) или, после очистки, ссылаться на хеш последней удаленной записи
+// из отметки очистки.
+func (db *ServiceDB) VerifyAuditLogChain() (*AuditChainVerification, error) {
+	checkpoint, err := db.GetLastAuditCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`SELECT ` + auditColumns + ` FROM audit_log ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuditChainVerification{Valid: true, Checkpoint: checkpoint}
+	prevHash := ""
+	if checkpoint != nil {
+		prevHash = checkpoint.LastPrunedHash
+	}
+	for i, e := range entries {
+		if i == 0 && checkpoint != nil && e.ID <= checkpoint.LastPrunedID {
+			result.Valid, result.BrokenAt, result.Reason = false, e.ID, "entry precedes the last pruning checkpoint"
+			return result, nil
+		}
+		if e.PrevHash != prevHash {
+			reason := "prev_hash does not match previous entry"
+			if i == 0 {
+				reason = "first entry does not continue the last pruning checkpoint"
+			}
+			result.Valid, result.BrokenAt, result.Reason = false, e.ID, reason
+			return result, nil
+		}
+		if AuditEntryHash(e) != e.Hash {
+			result.Valid, result.BrokenAt, result.Reason = false, e.ID, "entry hash mismatch"
+			return result, nil
+		}
+		prevHash = e.Hash
+		result.Checked++
+	}
+	return result, nil
+}
+
+// DeleteAuditLogBefore удаляет записи старше указанного момента (очистка по сроку хранения).
+// Удаляется начало цепочки до последней записи старше before; перед удалением в той же
+// транзакции сохраняется отметка очистки с хешем последней удаленной записи
+func (db *ServiceDB) DeleteAuditLogBefore(before time.Time) (int64, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var lastID sql.NullInt64
+	if err := tx.QueryRow(`SELECT MAX(id) FROM audit_log WHERE created_at < ?`,
+		before.UTC().Format(time.RFC3339Nano)).Scan(&lastID); err != nil {
+		return 0, fmt.Errorf("failed to find old audit log entries: %w", err)
+	}
+	if !lastID.Valid {
+		return 0, nil
+	}
+
+	var lastHash string
+	var count int64
+	if err := tx.QueryRow(`SELECT hash FROM audit_log WHERE id = ?`, lastID.Int64).Scan(&lastHash); err != nil {
+		return 0, fmt.Errorf("failed to get last pruned audit hash: %w", err)
+	}
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM audit_log WHERE id <= ?`, lastID.Int64).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count old audit log entries: %w", err)
+	}
+	var totalPruned int64
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(total_pruned), 0) FROM audit_checkpoints`).Scan(&totalPruned); err != nil {
+		return 0, fmt.Errorf("failed to get audit checkpoint: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO audit_checkpoints (last_pruned_id, last_pruned_hash, pruned_count, total_pruned, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, lastID.Int64, lastHash, count, totalPruned+count, time.Now().UTC().Format(time.RFC3339Nano)); err != nil {
+		return 0, fmt.Errorf("failed to save audit checkpoint: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM audit_log WHERE id <= ?`, lastID.Int64); err != nil {
+		return 0, fmt.Errorf("failed to delete old audit log entries: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit audit log pruning: %w", err)
+	}
+	return count, nil
+}
+
+// GetLastAuditCheckpoint возвращает последнюю отметку очистки журнала (nil, если очисток не было)
+func (db *ServiceDB) GetLastAuditCheckpoint() (*AuditCheckpoint, error) {
+	checkpoint := &AuditCheckpoint{}
+	var createdAt string
+	err := db.conn.QueryRow(`
+		SELECT id, last_pruned_id, last_pruned_hash, pruned_count, total_pruned, created_at
+		FROM audit_checkpoints ORDER BY id DESC LIMIT 1
+	`).Scan(&checkpoint.ID, &checkpoint.LastPrunedID, &checkpoint.LastPrunedHash,
+		&checkpoint.PrunedCount, &checkpoint.TotalPruned, &createdAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit checkpoint: %w", err)
+	}
+	if checkpoint.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, fmt.Errorf("failed to parse audit checkpoint timestamp %q: %w", createdAt, err)
+	}
+	return checkpoint, nil
+}
+
+// GetAuditRetentionDays возвращает срок хранения журнала аудита (0, если не настроен)
+func (db *ServiceDB) GetAuditRetentionDays() (int, error) {
+	var days int
+	err := db.conn.QueryRow(`SELECT retention_days FROM audit_settings WHERE id = 1`).Scan(&days)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get audit retention: %w", err)
+	}
+	return days, nil
+}
+
+// SetAuditRetentionDays сохраняет срок хранения журнала аудита
+func (db *ServiceDB) SetAuditRetentionDays(days int, updatedBy string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO audit_settings (id, retention_days, updated_by, updated_at)
+		VALUES (1, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(id) DO UPDATE SET
+			retention_days = excluded.retention_days,
+			updated_by = excluded.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, days, updatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save audit retention: %w", err)
+	}
+	return nil
+}
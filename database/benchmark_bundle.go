@@ -0,0 +1,329 @@
+package database
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// BenchmarkProvenance происхождение эталона, импортированного из пакета другой инсталляции
+type BenchmarkProvenance struct {
+	BenchmarkID       string    `json:"benchmark_id"`
+	OriginInstance    string    `json:"origin_instance"`
+	OriginBenchmarkID string    `json:"origin_benchmark_id"`
+	BundleID          string    `json:"bundle_id"`
+	SignerKeyID       string    `json:"signer_key_id,omitempty"`
+	ImportedBy        string    `json:"imported_by,omitempty"`
+	ImportedAt        time.Time `json:"imported_at"`
+}
+
+// ListAllBenchmarks возвращает все эталоны с вариациями (включая неактивные, если activeOnly = false)
+func (db *BenchmarksDB) ListAllBenchmarks(entityType string, activeOnly bool) ([]*Benchmark, error) {
+	count, err := db.CountBenchmarks(entityType, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	benchmarks, err := db.ListBenchmarks(entityType, activeOnly, count, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.conn.Query(`SELECT benchmark_id, variation FROM benchmark_variations ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variations: %w", err)
+	}
+	defer rows.Close()
+	variations := make(map[string][]string)
+	for rows.Next() {
+		var benchmarkID, variation string
+		if err := rows.Scan(&benchmarkID, &variation); err != nil {
+			return nil, fmt.Errorf("failed to scan variation: %w", err)
+		}
+		variations[benchmarkID] = append(variations[benchmarkID], variation)
+	}
+	for _, b := range benchmarks {
+		b.Variations = variations[b.ID]
+	}
+	return benchmarks, rows.Err()
+}
+
+// GetBenchmarkProvenance возвращает происхождение эталонов (ключ - ID эталона)
+func (db *BenchmarksDB) GetBenchmarkProvenance() (map[string]*BenchmarkProvenance, error) {
+	rows, err := db.conn.Query(`
+		SELECT benchmark_id, origin_instance, origin_benchmark_id, bundle_id,
+		       COALESCE(signer_key_id, ''), COALESCE(imported_by, ''), imported_at
+		FROM benchmark_provenance
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query benchmark provenance: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]*BenchmarkProvenance)
+	for rows.Next() {
+		p := &BenchmarkProvenance{}
+		if err := rows.Scan(&p.BenchmarkID, &p.OriginInstance, &p.OriginBenchmarkID, &p.BundleID,
+			&p.SignerKeyID, &p.ImportedBy, &p.ImportedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan benchmark provenance: %w", err)
+		}
+		result[p.BenchmarkID] = p
+	}
+	return result, rows.Err()
+}
+
+// SetBenchmarkProvenance сохраняет происхождение импортированного эталона
+func (db *BenchmarksDB) SetBenchmarkProvenance(p *BenchmarkProvenance) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO benchmark_provenance (benchmark_id, origin_instance, origin_benchmark_id, bundle_id, signer_key_id, imported_by, imported_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(benchmark_id) DO UPDATE SET
+			origin_instance = excluded.origin_instance,
+			origin_benchmark_id = excluded.origin_benchmark_id,
+			bundle_id = excluded.bundle_id,
+			signer_key_id = excluded.signer_key_id,
+			imported_by = excluded.imported_by,
+			imported_at = CURRENT_TIMESTAMP
+	`, p.BenchmarkID, p.OriginInstance, p.OriginBenchmarkID, p.BundleID, p.SignerKeyID, p.ImportedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save benchmark provenance: %w", err)
+	}
+	return nil
+}
+
+// BenchmarkBundleTrustedKey открытый ключ инсталляции, пакетам которой доверяет сервер
+type BenchmarkBundleTrustedKey struct {
+	KeyID     string    `json:"key_id"`
+	PublicKey string    `json:"public_key"` // base64
+	Name      string    `json:"name"`
+	AddedBy   string    `json:"added_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BenchmarkBundleImportRecord запись журнала импорта пакетов эталонов
+type BenchmarkBundleImportRecord struct {
+	ID             int       `json:"id"`
+	BundleID       string    `json:"bundle_id"`
+	OriginInstance string    `json:"origin_instance"`
+	SignerKeyID    string    `json:"signer_key_id"`
+	Strategy       string    `json:"strategy"`
+	Created        int       `json:"created"`
+	Updated        int       `json:"updated"`
+	Skipped        int       `json:"skipped"`
+	Conflicts      int       `json:"conflicts"`
+	ImportedBy     string    `json:"imported_by,omitempty"`
+	ImportedAt     time.Time `json:"imported_at"`
+}
+
+// CreateBenchmarkBundleTables создает таблицы ключей подписи и журнала импорта пакетов эталонов
+func CreateBenchmarkBundleTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS benchmark_bundle_signing_key (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			key_id TEXT NOT NULL,
+			private_key TEXT NOT NULL, -- ed25519, base64
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS benchmark_bundle_trusted_keys (
+			key_id TEXT PRIMARY KEY,
+			public_key TEXT NOT NULL,
+			name TEXT NOT NULL,
+			added_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE IF NOT EXISTS benchmark_bundle_imports (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			bundle_id TEXT NOT NULL,
+			origin_instance TEXT NOT NULL,
+			signer_key_id TEXT NOT NULL,
+			strategy TEXT NOT NULL,
+			created_count INTEGER NOT NULL DEFAULT 0,
+			updated_count INTEGER NOT NULL DEFAULT 0,
+			skipped_count INTEGER NOT NULL DEFAULT 0,
+			conflict_count INTEGER NOT NULL DEFAULT 0,
+			imported_by TEXT,
+			imported_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create benchmark bundle tables: %w", err)
+	}
+	return nil
+}
+
+// BundleKeyID возвращает идентификатор открытого ключа (первые 16 hex символов SHA-256)
+func BundleKeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:8])
+}
+
+// GetOrCreateBenchmarkBundleSigningKey возвращает ключ подписи пакетов эталонов этой инсталляции,
+// при первом обращении генерирует его
+func (db *ServiceDB) GetOrCreateBenchmarkBundleSigningKey() (string, ed25519.PrivateKey, error) {
+	var keyID, encoded string
+	err := db.conn.QueryRow(`SELECT key_id, private_key FROM benchmark_bundle_signing_key WHERE id = 1`).Scan(&keyID, &encoded)
+	if err == nil {
+		raw, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(raw) != ed25519.PrivateKeySize {
+			return "", nil, fmt.Errorf("invalid stored benchmark bundle signing key")
+		}
+		return keyID, ed25519.PrivateKey(raw), nil
+	}
+	if err != sql.ErrNoRows {
+		return "", nil, fmt.Errorf("failed to get benchmark bundle signing key: %w", err)
+	}
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate benchmark bundle signing key: %w", err)
+	}
+	keyID = BundleKeyID(publicKey)
+	// INSERT OR IGNORE: при гонке остается ключ, сохраненный первым
+	if _, err := db.conn.Exec(`INSERT OR IGNORE INTO benchmark_bundle_signing_key (id, key_id, private_key) VALUES (1, ?, ?)`,
+		keyID, base64.StdEncoding.EncodeToString(privateKey)); err != nil {
+		return "", nil, fmt.Errorf("failed to save benchmark bundle signing key: %w", err)
+	}
+	return db.GetOrCreateBenchmarkBundleSigningKey()
+}
+
+// GetBenchmarkBundleTrustedKeys возвращает доверенные ключи подписи пакетов эталонов
+func (db *ServiceDB) GetBenchmarkBundleTrustedKeys() ([]*BenchmarkBundleTrustedKey, error) {
+	rows, err := db.conn.Query(`
+		SELECT key_id, public_key, name, COALESCE(added_by, ''), created_at
+		FROM benchmark_bundle_trusted_keys ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trusted bundle keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*BenchmarkBundleTrustedKey
+	for rows.Next() {
+		key := &BenchmarkBundleTrustedKey{}
+		if err := rows.Scan(&key.KeyID, &key.PublicKey, &key.Name, &key.AddedBy, &key.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trusted bundle key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// AddBenchmarkBundleTrustedKey добавляет доверенный ключ подписи пакетов эталонов
+func (db *ServiceDB) AddBenchmarkBundleTrustedKey(key *BenchmarkBundleTrustedKey) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO benchmark_bundle_trusted_keys (key_id, public_key, name, added_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(key_id) DO UPDATE SET name = excluded.name
+	`, key.KeyID, key.PublicKey, key.Name, key.AddedBy)
+	if err != nil {
+		return fmt.Errorf("failed to add trusted bundle key: %w", err)
+	}
+	return nil
+}
+
+// DeleteBenchmarkBundleTrustedKey удаляет доверенный ключ подписи
+func (db *ServiceDB) DeleteBenchmarkBundleTrustedKey(keyID string) error {
+	result, err := db.conn.Exec(`DELETE FROM benchmark_bundle_trusted_keys WHERE key_id = ?`, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete trusted bundle key: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordBenchmarkBundleImport сохраняет запись журнала импорта пакета эталонов
+func (db *ServiceDB) RecordBenchmarkBundleImport(record *BenchmarkBundleImportRecord) error {
+	result, err := db.conn.Exec(`
+		INSERT INTO benchmark_bundle_imports (bundle_id, origin_instance, signer_key_id, strategy,
+			created_count, updated_count, skipped_count, conflict_count, imported_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, record.BundleID, record.OriginInstance, record.SignerKeyID, record.Strategy,
+		record.Created, record.Updated, record.Skipped, record.Conflicts, record.ImportedBy)
+	if err != nil {
+		return fmt.Errorf("failed to record benchmark bundle import: %w", err)
+	}
+	id, _ := result.LastInsertId()
+	record.ID = int(id)
+	record.ImportedAt = time.Now()
+	return nil
+}
+
+// OKPD2Entry код и наименование позиции справочника ОКПД2
+type OKPD2Entry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// GetOKPD2EntriesByIDs возвращает коды и наименования ОКПД2 по идентификаторам справочника
+func (db *ServiceDB) GetOKPD2EntriesByIDs(ids []int) (map[int]OKPD2Entry, error) {
+	result := make(map[int]OKPD2Entry, len(ids))
+	for _, id := range ids {
+		if _, ok := result[id]; ok {
+			continue
+		}
+		var entry OKPD2Entry
+		err := db.conn.QueryRow(`SELECT code, name FROM okpd2_classifier WHERE id = ?`, id).Scan(&entry.Code, &entry.Name)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get OKPD2 entry: %w", err)
+		}
+		result[id] = entry
+	}
+	return result, nil
+}
+
+// SaveClientBenchmarkRecord создает (ID = 0) или обновляет эталон клиента со всеми полями
+func (db *ServiceDB) SaveClientBenchmarkRecord(b *ClientBenchmark) error {
+	var approvedAt interface{}
+	if b.ApprovedAt != nil {
+		approvedAt = *b.ApprovedAt
+	}
+	args := []interface{}{
+		b.ClientProjectID, b.OriginalName, b.NormalizedName, b.Category, b.Subcategory, b.Attributes,
+		b.QualityScore, b.IsApproved, b.ApprovedBy, approvedAt, b.SourceDatabase,
+		b.TaxID, b.KPP, b.OGRN, b.Region, b.LegalAddress, b.PostalAddress, b.ContactPhone, b.ContactEmail,
+		b.ContactPerson, b.LegalForm, b.BankName, b.BankAccount, b.CorrespondentAccount, b.BIK, b.OKPD2ReferenceID,
+	}
+
+	if b.ID == 0 {
+		result, err := db.conn.Exec(`
+			INSERT INTO client_benchmarks (client_project_id, original_name, normalized_name, category, subcategory, attributes,
+				quality_score, is_approved, approved_by, approved_at, source_database,
+				tax_id, kpp, ogrn, region, legal_address, postal_address, contact_phone, contact_email,
+				contact_person, legal_form, bank_name, bank_account, correspondent_account, bik, okpd2_reference_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, args...)
+		if err != nil {
+			return fmt.Errorf("failed to create client benchmark: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get client benchmark ID: %w", err)
+		}
+		b.ID = int(id)
+		return nil
+	}
+
+	_, err := db.conn.Exec(`
+		UPDATE client_benchmarks SET client_project_id = ?, original_name = ?, normalized_name = ?, category = ?, subcategory = ?,
+			attributes = ?, quality_score = ?, is_approved = ?, approved_by = ?, approved_at = ?, source_database = ?,
+			tax_id = ?, kpp = ?, ogrn = ?, region = ?, legal_address = ?, postal_address = ?, contact_phone = ?, contact_email = ?,
+			contact_person = ?, legal_form = ?, bank_name = ?, bank_account = ?, correspondent_account = ?, bik = ?,
+			okpd2_reference_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, append(args, b.ID)...)
+	if err != nil {
+		return fmt.Errorf("failed to update client benchmark: %w", err)
+	}
+	return nil
+}
@@ -34,6 +34,18 @@ func InitBenchmarksSchema(db *sql.DB) error {
 		FOREIGN KEY (benchmark_id) REFERENCES benchmarks(id) ON DELETE CASCADE
 	);
 
+	-- Происхождение эталонов, перенесенных из других инсталляций пакетами эталонов
+	CREATE TABLE IF NOT EXISTS benchmark_provenance (
+		benchmark_id TEXT PRIMARY KEY,
+		origin_instance TEXT NOT NULL,
+		origin_benchmark_id TEXT NOT NULL,
+		bundle_id TEXT NOT NULL,
+		signer_key_id TEXT,
+		imported_by TEXT,
+		imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (benchmark_id) REFERENCES benchmarks(id) ON DELETE CASCADE
+	);
+
 	-- Индексы для оптимизации запросов
 	CREATE INDEX IF NOT EXISTS idx_benchmarks_entity_type ON benchmarks(entity_type);
 	CREATE INDEX IF NOT EXISTS idx_benchmarks_name ON benchmarks(name);
@@ -0,0 +1,155 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ClientCertificateBinding привязка клиентского сертификата 1С к клиенту и проекту,
+// в которые разрешена выгрузка
+type ClientCertificateBinding struct {
+	ID          int        `json:"id"`
+	ClientID    int        `json:"client_id"`
+	ProjectID   *int       `json:"project_id,omitempty"` // nil - любой проект клиента
+	Subject     string     `json:"subject"`              // CN субъекта сертификата
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Description string     `json:"description,omitempty"`
+	CreatedBy   string     `json:"created_by,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CreateClientCertificatesTable создает таблицу привязок клиентских сертификатов
+func CreateClientCertificatesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS client_certificates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			client_id INTEGER NOT NULL,
+			project_id INTEGER,
+			subject TEXT NOT NULL,
+			fingerprint TEXT, -- SHA-256 сертификата; если задан, CN сертификата недостаточно
+			description TEXT,
+			created_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP,
+			FOREIGN KEY(client_id) REFERENCES clients(id) ON DELETE CASCADE,
+			FOREIGN KEY(project_id) REFERENCES client_projects(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_client_certificates_subject ON client_certificates(subject);
+		CREATE INDEX IF NOT EXISTS idx_client_certificates_client ON client_certificates(client_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create client_certificates table: %w", err)
+	}
+	return nil
+}
+
+const clientCertificateColumns = `id, client_id, project_id, subject, COALESCE(fingerprint, ''), COALESCE(description, ''),
+	COALESCE(created_by, ''), created_at, revoked_at`
+
+func scanClientCertificateBinding(scanner interface{ Scan(...interface{}) error }) (*ClientCertificateBinding, error) {
+	binding := &ClientCertificateBinding{}
+	var projectID sql.NullInt64
+	var revokedAt sql.NullTime
+	if err := scanner.Scan(&binding.ID, &binding.ClientID, &projectID, &binding.Subject, &binding.Fingerprint,
+		&binding.Description, &binding.CreatedBy, &binding.CreatedAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	if projectID.Valid {
+		id := int(projectID.Int64)
+		binding.ProjectID = &id
+	}
+	if revokedAt.Valid {
+		binding.RevokedAt = &revokedAt.Time
+	}
+	return binding, nil
+}
+
+// CreateClientCertificateBinding создает привязку сертификата к клиенту
+func (db *ServiceDB) CreateClientCertificateBinding(binding *ClientCertificateBinding) error {
+	var projectID interface{}
+	if binding.ProjectID != nil {
+		projectID = *binding.ProjectID
+	}
+	var fingerprint interface{}
+	if binding.Fingerprint != "" {
+		fingerprint = binding.Fingerprint
+	}
+	result, err := db.conn.Exec(`
+		INSERT INTO client_certificates (client_id, project_id, subject, fingerprint, description, created_by)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, binding.ClientID, projectID, binding.Subject, fingerprint, binding.Description, binding.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to create client certificate binding: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get client certificate binding id: %w", err)
+	}
+	binding.ID = int(id)
+	binding.CreatedAt = time.Now()
+	return nil
+}
+
+// GetClientCertificateBindings возвращает привязки сертификатов клиента
+func (db *ServiceDB) GetClientCertificateBindings(clientID int) ([]*ClientCertificateBinding, error) {
+	rows, err := db.conn.Query(`SELECT `+clientCertificateColumns+` FROM client_certificates WHERE client_id = ? ORDER BY id`, clientID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query client certificate bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []*ClientCertificateBinding
+	for rows.Next() {
+		binding, err := scanClientCertificateBinding(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client certificate binding: %w", err)
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, rows.Err()
+}
+
+// FindClientCertificateBindings возвращает все действующие привязки по CN и отпечатку сертификата
+// (один сертификат может быть привязан к нескольким проектам). Привязка с отпечатком подходит
+// только сертификату с тем же отпечатком; привязки с отпечатком идут первыми
+func (db *ServiceDB) FindClientCertificateBindings(subject, fingerprint string) ([]*ClientCertificateBinding, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+clientCertificateColumns+`
+		FROM client_certificates
+		WHERE subject = ? AND revoked_at IS NULL
+		  AND (fingerprint IS NULL OR fingerprint = '' OR fingerprint = ?)
+		ORDER BY CASE WHEN fingerprint = ? THEN 0 ELSE 1 END, id
+	`, subject, fingerprint, fingerprint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find client certificate bindings: %w", err)
+	}
+	defer rows.Close()
+
+	var bindings []*ClientCertificateBinding
+	for rows.Next() {
+		binding, err := scanClientCertificateBinding(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client certificate binding: %w", err)
+		}
+		bindings = append(bindings, binding)
+	}
+	return bindings, rows.Err()
+}
+
+// RevokeClientCertificateBinding отзывает привязку сертификата клиента
+func (db *ServiceDB) RevokeClientCertificateBinding(clientID, id int) error {
+	result, err := db.conn.Exec(`
+		UPDATE client_certificates SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND client_id = ? AND revoked_at IS NULL
+	`, id, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke client certificate binding: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
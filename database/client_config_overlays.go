@@ -0,0 +1,101 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ClientConfigOverlayRecord запись клиентского overlay конфигурации
+type ClientConfigOverlayRecord struct {
+	ClientID    int       `json:"client_id"`
+	OverlayJSON string    `json:"overlay_json"`
+	UpdatedBy   string    `json:"updated_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateClientConfigOverlaysTable создает таблицу клиентских overlay конфигурации
+func CreateClientConfigOverlaysTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS client_config_overlays (
+			client_id INTEGER PRIMARY KEY,
+			overlay_json TEXT NOT NULL, -- JSON с переопределениями глобальной конфигурации
+			updated_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(client_id) REFERENCES clients(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create client_config_overlays table: %w", err)
+	}
+	return nil
+}
+
+// GetClientConfigOverlay получает overlay конфигурации клиента (nil, если не задан)
+func (db *ServiceDB) GetClientConfigOverlay(clientID int) (*ClientConfigOverlayRecord, error) {
+	record := &ClientConfigOverlayRecord{}
+	var updatedBy sql.NullString
+	err := db.conn.QueryRow(`
+		SELECT client_id, overlay_json, updated_by, created_at, updated_at
+		FROM client_config_overlays WHERE client_id = ?
+	`, clientID).Scan(&record.ClientID, &record.OverlayJSON, &updatedBy, &record.CreatedAt, &record.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client config overlay: %w", err)
+	}
+	record.UpdatedBy = updatedBy.String
+	return record, nil
+}
+
+// SaveClientConfigOverlay сохраняет overlay конфигурации клиента
+func (db *ServiceDB) SaveClientConfigOverlay(clientID int, overlayJSON, updatedBy string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO client_config_overlays (client_id, overlay_json, updated_by, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(client_id) DO UPDATE SET
+			overlay_json = excluded.overlay_json,
+			updated_by = excluded.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, clientID, overlayJSON, updatedBy)
+	if err != nil {
+		return fmt.Errorf("failed to save client config overlay: %w", err)
+	}
+	return nil
+}
+
+// DeleteClientConfigOverlay удаляет overlay конфигурации клиента
+func (db *ServiceDB) DeleteClientConfigOverlay(clientID int) error {
+	_, err := db.conn.Exec(`DELETE FROM client_config_overlays WHERE client_id = ?`, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete client config overlay: %w", err)
+	}
+	return nil
+}
+
+// GetAllClientConfigOverlays получает overlay всех клиентов
+func (db *ServiceDB) GetAllClientConfigOverlays() ([]*ClientConfigOverlayRecord, error) {
+	rows, err := db.conn.Query(`
+		SELECT client_id, overlay_json, updated_by, created_at, updated_at
+		FROM client_config_overlays ORDER BY client_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client config overlays: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*ClientConfigOverlayRecord
+	for rows.Next() {
+		record := &ClientConfigOverlayRecord{}
+		var updatedBy sql.NullString
+		if err := rows.Scan(&record.ClientID, &record.OverlayJSON, &updatedBy, &record.CreatedAt, &record.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan client config overlay: %w", err)
+		}
+		record.UpdatedBy = updatedBy.String
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
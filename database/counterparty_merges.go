@@ -0,0 +1,415 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Статусы событий слияния контрагентов
+const (
+	CounterpartyMergeStatusMerged   = "merged"
+	CounterpartyMergeStatusUnmerged = "unmerged"
+)
+
+// Роли участников слияния
+const (
+	CounterpartyMergeRoleMaster = "master"
+	CounterpartyMergeRoleMerged = "merged"
+)
+
+// ErrCounterpartyMergeConflict слияние нельзя отменить без потери данных
+var ErrCounterpartyMergeConflict = errors.New("counterparty merge cannot be reverted")
+
+// CounterpartyMergeFieldSource происхождение поля мастер-контрагента после слияния
+type CounterpartyMergeFieldSource struct {
+	Field              string `json:"field"`
+	FromCounterpartyID int    `json:"from_counterparty_id"`
+	OldValue           string `json:"old_value"`
+	NewValue           string `json:"new_value"`
+}
+
+// CounterpartyMergeParticipant состояние участника слияния до слияния
+type CounterpartyMergeParticipant struct {
+	EventID        int                     `json:"event_id"`
+	CounterpartyID int                     `json:"counterparty_id"`
+	Role           string                  `json:"role"`
+	State          *NormalizedCounterparty `json:"state"`
+	Links          []DatabaseSource        `json:"links"`
+}
+
+// CounterpartyMergeEvent событие слияния нормализованных контрагентов
+type CounterpartyMergeEvent struct {
+	ID           int                             `json:"id"`
+	ProjectID    int                             `json:"project_id"`
+	MasterID     int                             `json:"master_id"`
+	MergedIDs    []int                           `json:"merged_ids"`
+	Source       string                          `json:"source"`              // manual, duplicate_group
+	GroupKey     string                          `json:"group_key,omitempty"` // ИНН/БИН группы дубликатов
+	FieldSources []*CounterpartyMergeFieldSource `json:"field_sources"`
+	Status       string                          `json:"status"`
+	MergedBy     string                          `json:"merged_by,omitempty"`
+	CreatedAt    time.Time                       `json:"created_at"`
+	UnmergedAt   *time.Time                      `json:"unmerged_at,omitempty"`
+	UnmergedBy   string                          `json:"unmerged_by,omitempty"`
+	Participants []*CounterpartyMergeParticipant `json:"participants,omitempty"`
+}
+
+// CreateCounterpartyMergeTables создает таблицы журнала слияний контрагентов
+func CreateCounterpartyMergeTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS counterparty_merge_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL,
+			master_id INTEGER NOT NULL,
+			merged_ids TEXT NOT NULL,
+			source TEXT NOT NULL DEFAULT 'manual',
+			group_key TEXT,
+			field_sources TEXT,
+			status TEXT NOT NULL DEFAULT 'merged',
+			merged_by TEXT,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			unmerged_at TIMESTAMP,
+			unmerged_by TEXT
+		);
+
+		CREATE TABLE IF NOT EXISTS counterparty_merge_participants (
+			event_id INTEGER NOT NULL,
+			counterparty_id INTEGER NOT NULL,
+			role TEXT NOT NULL,
+			state_json TEXT NOT NULL,
+			links_json TEXT,
+			PRIMARY KEY (event_id, counterparty_id),
+			FOREIGN KEY(event_id) REFERENCES counterparty_merge_events(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_counterparty_merge_events_project ON counterparty_merge_events(project_id, created_at);
+		CREATE INDEX IF NOT EXISTS idx_counterparty_merge_participants_cp ON counterparty_merge_participants(counterparty_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create counterparty merge tables: %w", err)
+	}
+	return nil
+}
+
+// CreateCounterpartyMergeEvent сохраняет событие слияния вместе с состоянием участников
+func (db *ServiceDB) CreateCounterpartyMergeEvent(event *CounterpartyMergeEvent) (int, error) {
+	mergedIDs, err := json.Marshal(event.MergedIDs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal merged ids: %w", err)
+	}
+	fieldSources, err := json.Marshal(event.FieldSources)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal field sources: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO counterparty_merge_events (project_id, master_id, merged_ids, source, group_key, field_sources, status, merged_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, event.ProjectID, event.MasterID, string(mergedIDs), event.Source, event.GroupKey, string(fieldSources),
+		CounterpartyMergeStatusMerged, event.MergedBy)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save counterparty merge event: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get merge event id: %w", err)
+	}
+
+	for _, p := range event.Participants {
+		state, err := json.Marshal(p.State)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal participant state: %w", err)
+		}
+		links, err := json.Marshal(p.Links)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal participant links: %w", err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO counterparty_merge_participants (event_id, counterparty_id, role, state_json, links_json)
+			VALUES (?, ?, ?, ?, ?)
+		`, id, p.CounterpartyID, p.Role, string(state), string(links)); err != nil {
+			return 0, fmt.Errorf("failed to save merge participant: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit merge event: %w", err)
+	}
+	event.ID = int(id)
+	event.Status = CounterpartyMergeStatusMerged
+	return event.ID, nil
+}
+
+// GetCounterpartyMergeEvent возвращает событие слияния с участниками (nil, если не найдено)
+func (db *ServiceDB) GetCounterpartyMergeEvent(id int) (*CounterpartyMergeEvent, error) {
+	events, err := db.queryCounterpartyMergeEvents(`WHERE e.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	event := events[0]
+	if event.Participants, err = db.getCounterpartyMergeParticipants(id); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetCounterpartyMergeHistory возвращает события слияния, в которых участвовал контрагент, от новых к старым
+func (db *ServiceDB) GetCounterpartyMergeHistory(counterpartyID int) ([]*CounterpartyMergeEvent, error) {
+	events, err := db.queryCounterpartyMergeEvents(`
+		WHERE e.id IN (SELECT event_id FROM counterparty_merge_participants WHERE counterparty_id = ?)`, counterpartyID)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.Participants, err = db.getCounterpartyMergeParticipants(event.ID); err != nil {
+			return nil, err
+		}
+	}
+	return events, nil
+}
+
+// queryCounterpartyMergeEvents выбирает события слияния по условию
+func (db *ServiceDB) queryCounterpartyMergeEvents(where string, args ...interface{}) ([]*CounterpartyMergeEvent, error) {
+	rows, err := db.conn.Query(`
+		SELECT e.id, e.project_id, e.master_id, e.merged_ids, e.source, COALESCE(e.group_key, ''),
+		       COALESCE(e.field_sources, ''), e.status, COALESCE(e.merged_by, ''), e.created_at,
+		       e.unmerged_at, COALESCE(e.unmerged_by, '')
+		FROM counterparty_merge_events e `+where+`
+		ORDER BY e.created_at DESC, e.id DESC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counterparty merge events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*CounterpartyMergeEvent
+	for rows.Next() {
+		e := &CounterpartyMergeEvent{}
+		var mergedIDs, fieldSources string
+		var unmergedAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.ProjectID, &e.MasterID, &mergedIDs, &e.Source, &e.GroupKey,
+			&fieldSources, &e.Status, &e.MergedBy, &e.CreatedAt, &unmergedAt, &e.UnmergedBy); err != nil {
+			return nil, fmt.Errorf("failed to scan counterparty merge event: %w", err)
+		}
+		if err := json.Unmarshal([]byte(mergedIDs), &e.MergedIDs); err != nil {
+			return nil, fmt.Errorf("failed to parse merged ids: %w", err)
+		}
+		if fieldSources != "" {
+			if err := json.Unmarshal([]byte(fieldSources), &e.FieldSources); err != nil {
+				return nil, fmt.Errorf("failed to parse field sources: %w", err)
+			}
+		}
+		if unmergedAt.Valid {
+			t := unmergedAt.Time
+			e.UnmergedAt = &t
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// getCounterpartyMergeParticipants возвращает участников события слияния
+func (db *ServiceDB) getCounterpartyMergeParticipants(eventID int) ([]*CounterpartyMergeParticipant, error) {
+	rows, err := db.conn.Query(`
+		SELECT event_id, counterparty_id, role, state_json, COALESCE(links_json, '')
+		FROM counterparty_merge_participants WHERE event_id = ?
+		ORDER BY CASE role WHEN 'master' THEN 0 ELSE 1 END, counterparty_id
+	`, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query merge participants: %w", err)
+	}
+	defer rows.Close()
+
+	var participants []*CounterpartyMergeParticipant
+	for rows.Next() {
+		p := &CounterpartyMergeParticipant{}
+		var state, links string
+		if err := rows.Scan(&p.EventID, &p.CounterpartyID, &p.Role, &state, &links); err != nil {
+			return nil, fmt.Errorf("failed to scan merge participant: %w", err)
+		}
+		if err := json.Unmarshal([]byte(state), &p.State); err != nil {
+			return nil, fmt.Errorf("failed to parse participant state: %w", err)
+		}
+		if links != "" && links != "null" {
+			if err := json.Unmarshal([]byte(links), &p.Links); err != nil {
+				return nil, fmt.Errorf("failed to parse participant links: %w", err)
+			}
+		}
+		participants = append(participants, p)
+	}
+	return participants, rows.Err()
+}
+
+// HasLaterCounterpartyMerges проверяет, участвуют ли контрагенты в более поздних неотмененных слияниях
+func (db *ServiceDB) HasLaterCounterpartyMerges(eventID int, counterpartyIDs []int) (bool, error) {
+	if len(counterpartyIDs) == 0 {
+		return false, nil
+	}
+	args := []interface{}{eventID, CounterpartyMergeStatusMerged}
+	for _, id := range counterpartyIDs {
+		args = append(args, id)
+	}
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) FROM counterparty_merge_events e
+		JOIN counterparty_merge_participants p ON p.event_id = e.id
+		WHERE e.id > ? AND e.status = ? AND p.counterparty_id IN (?`+strings.Repeat(", ?", len(counterpartyIDs)-1)+`)
+	`, args...).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check later merges: %w", err)
+	}
+	return count > 0, nil
+}
+
+// RevertCounterpartyMerge отменяет слияние в одной транзакции: восстанавливает мастер-контрагента
+// в состоянии до слияния, пересоздает объединенных контрагентов с исходными ID и
+// переносит на них их связи counterparty_databases.
+func (db *ServiceDB) RevertCounterpartyMerge(eventID int, unmergedBy string) error {
+	event, err := db.GetCounterpartyMergeEvent(eventID)
+	if err != nil {
+		return err
+	}
+	if event == nil {
+		return fmt.Errorf("counterparty merge event %d not found", eventID)
+	}
+	if event.Status != CounterpartyMergeStatusMerged {
+		return fmt.Errorf("%w: merge event %d is already %s", ErrCounterpartyMergeConflict, eventID, event.Status)
+	}
+	if err := CreateCounterpartyDatabasesTable(db.conn); err != nil {
+		return fmt.Errorf("failed to create counterparty_databases table: %w", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range event.Participants {
+		if p.State == nil {
+			return fmt.Errorf("merge participant %d has no saved state", p.CounterpartyID)
+		}
+		var exists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM normalized_counterparties WHERE id = ?)`, p.CounterpartyID).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check counterparty: %w", err)
+		}
+
+		switch p.Role {
+		case CounterpartyMergeRoleMaster:
+			if !exists {
+				return fmt.Errorf("%w: master counterparty %d no longer exists", ErrCounterpartyMergeConflict, p.CounterpartyID)
+			}
+			if err := restoreCounterpartyState(tx, p.State, false); err != nil {
+				return err
+			}
+		case CounterpartyMergeRoleMerged:
+			if exists {
+				return fmt.Errorf("%w: counterparty %d already exists", ErrCounterpartyMergeConflict, p.CounterpartyID)
+			}
+			if err := restoreCounterpartyState(tx, p.State, true); err != nil {
+				return err
+			}
+			for _, link := range p.Links {
+				// Связь, перенесенная на мастера при слиянии, возвращается исходному контрагенту
+				if _, err := tx.Exec(`
+					DELETE FROM counterparty_databases
+					WHERE normalized_counterparty_id = ? AND project_database_id = ? AND COALESCE(source_reference, '') = ?
+				`, event.MasterID, link.DatabaseID, link.SourceReference); err != nil {
+					return fmt.Errorf("failed to detach merged link from master: %w", err)
+				}
+				if _, err := tx.Exec(`
+					INSERT OR IGNORE INTO counterparty_databases (normalized_counterparty_id, project_database_id, source_reference, source_name)
+					VALUES (?, ?, ?, ?)
+				`, p.CounterpartyID, link.DatabaseID, link.SourceReference, link.SourceName); err != nil {
+					return fmt.Errorf("failed to restore counterparty database link: %w", err)
+				}
+			}
+		}
+	}
+
+	// Связи, которые были у мастера до слияния, должны остаться у него
+	for _, p := range event.Participants {
+		if p.Role != CounterpartyMergeRoleMaster {
+			continue
+		}
+		for _, link := range p.Links {
+			if _, err := tx.Exec(`
+				INSERT OR IGNORE INTO counterparty_databases (normalized_counterparty_id, project_database_id, source_reference, source_name)
+				VALUES (?, ?, ?, ?)
+			`, p.CounterpartyID, link.DatabaseID, link.SourceReference, link.SourceName); err != nil {
+				return fmt.Errorf("failed to restore master database link: %w", err)
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE counterparty_merge_events SET status = ?, unmerged_at = CURRENT_TIMESTAMP, unmerged_by = ?
+		WHERE id = ?
+	`, CounterpartyMergeStatusUnmerged, unmergedBy, eventID); err != nil {
+		return fmt.Errorf("failed to update merge event: %w", err)
+	}
+	return tx.Commit()
+}
+
+// restoreCounterpartyState записывает сохраненное состояние контрагента (insert - пересоздать с исходным ID)
+func restoreCounterpartyState(tx *sql.Tx, cp *NormalizedCounterparty, insert bool) error {
+	var benchmarkID interface{}
+	if cp.BenchmarkID != nil {
+		benchmarkID = *cp.BenchmarkID
+	}
+	values := []interface{}{
+		cp.ClientProjectID, cp.SourceReference, cp.SourceName, cp.NormalizedName,
+		cp.TaxID, cp.KPP, cp.BIN, cp.LegalAddress, cp.PostalAddress,
+		cp.ContactPhone, cp.ContactEmail, cp.ContactPerson, cp.LegalForm,
+		cp.BankName, cp.BankAccount, cp.CorrespondentAccount, cp.BIK,
+		benchmarkID, cp.QualityScore, cp.EnrichmentApplied, cp.SourceEnrichment, cp.SourceDatabase,
+		cp.Subcategory,
+	}
+
+	if insert {
+		_, err := tx.Exec(`
+			INSERT INTO normalized_counterparties (
+				client_project_id, source_reference, source_name, normalized_name,
+				tax_id, kpp, bin, legal_address, postal_address,
+				contact_phone, contact_email, contact_person, legal_form,
+				bank_name, bank_account, correspondent_account, bik,
+				benchmark_id, quality_score, enrichment_applied, source_enrichment, source_database,
+				subcategory, id, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, append(values, cp.ID, cp.CreatedAt)...)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				return fmt.Errorf("%w: counterparty with reference %q already exists in project", ErrCounterpartyMergeConflict, cp.SourceReference)
+			}
+			return fmt.Errorf("failed to restore counterparty %d: %w", cp.ID, err)
+		}
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		UPDATE normalized_counterparties SET
+			client_project_id = ?, source_reference = ?, source_name = ?, normalized_name = ?,
+			tax_id = ?, kpp = ?, bin = ?, legal_address = ?, postal_address = ?,
+			contact_phone = ?, contact_email = ?, contact_person = ?, legal_form = ?,
+			bank_name = ?, bank_account = ?, correspondent_account = ?, bik = ?,
+			benchmark_id = ?, quality_score = ?, enrichment_applied = ?, source_enrichment = ?, source_database = ?,
+			subcategory = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, append(values, cp.ID)...)
+	if err != nil {
+		return fmt.Errorf("failed to restore counterparty %d: %w", cp.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Важность изменений, обнаруженных при повторной сверке контрагента с реестрами
+const (
+	ReverificationSeverityInfo     = "info"
+	ReverificationSeverityWarning  = "warning"
+	ReverificationSeverityCritical = "critical"
+)
+
+// CounterpartyVerificationState состояние повторной сверки контрагента с реестрами
+type CounterpartyVerificationState struct {
+	CounterpartyID  int        `json:"counterparty_id"`
+	ClientProjectID int        `json:"client_project_id"`
+	Status          string     `json:"status"` // нормализованный статус юрлица (active, liquidated, ...)
+	RiskScore       float64    `json:"risk_score"`
+	LastVerifiedAt  *time.Time `json:"last_verified_at,omitempty"`
+	NextVerifyAt    time.Time  `json:"next_verify_at"`
+	LastSource      string     `json:"last_source,omitempty"`
+	LastResult      string     `json:"last_result,omitempty"` // JSON EnrichmentResult
+	Failures        int        `json:"failures"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// CounterpartyEnrichmentChange изменение реквизита контрагента, обнаруженное при сверке
+type CounterpartyEnrichmentChange struct {
+	ID              int       `json:"id"`
+	CounterpartyID  int       `json:"counterparty_id"`
+	ClientProjectID int       `json:"client_project_id"`
+	Field           string    `json:"field"`
+	OldValue        string    `json:"old_value"`
+	NewValue        string    `json:"new_value"`
+	Severity        string    `json:"severity"`
+	Source          string    `json:"source"`
+	Applied         bool      `json:"applied"` // значение записано в контрагента
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// CounterpartyEnrichmentChangeFilter фильтр журнала изменений
+type CounterpartyEnrichmentChangeFilter struct {
+	ProjectID      int
+	CounterpartyID int
+	Severity       string
+	Limit          int
+	Offset         int
+}
+
+// CreateCounterpartyReverificationTables создает таблицы состояния сверки и журнала изменений
+func CreateCounterpartyReverificationTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS counterparty_verification_state (
+			counterparty_id INTEGER PRIMARY KEY,
+			client_project_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'unknown',
+			risk_score REAL NOT NULL DEFAULT 0,
+			last_verified_at TIMESTAMP,
+			next_verify_at TIMESTAMP NOT NULL,
+			last_source TEXT,
+			last_result TEXT,
+			failures INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_cp_verification_next ON counterparty_verification_state(next_verify_at);
+
+		CREATE TABLE IF NOT EXISTS counterparty_enrichment_changes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			counterparty_id INTEGER NOT NULL,
+			client_project_id INTEGER NOT NULL,
+			field TEXT NOT NULL,
+			old_value TEXT,
+			new_value TEXT,
+			severity TEXT NOT NULL DEFAULT 'info',
+			source TEXT,
+			applied BOOLEAN NOT NULL DEFAULT 0,
+			detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_cp_enrichment_changes_cp ON counterparty_enrichment_changes(counterparty_id, detected_at);
+		CREATE INDEX IF NOT EXISTS idx_cp_enrichment_changes_project ON counterparty_enrichment_changes(client_project_id, detected_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create counterparty reverification tables: %w", err)
+	}
+	return nil
+}
+
+// GetCounterpartiesDueForReverification возвращает контрагентов с ИНН/БИН, которые ни разу не сверялись
+// или срок сверки которых наступил: сначала с наибольшим риском, затем самые давно проверенные
+func (db *ServiceDB) GetCounterpartiesDueForReverification(now time.Time, limit int) ([]*NormalizedCounterparty, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := db.conn.Query(`
+		SELECT nc.id
+		FROM normalized_counterparties nc
+		LEFT JOIN counterparty_verification_state vs ON vs.counterparty_id = nc.id
+		WHERE (COALESCE(nc.tax_id, '') != '' OR COALESCE(nc.bin, '') != '')
+		  AND (vs.counterparty_id IS NULL OR vs.next_verify_at <= ?)
+		ORDER BY COALESCE(vs.risk_score, 0) DESC, vs.last_verified_at IS NOT NULL, vs.last_verified_at, nc.id
+		LIMIT ?
+	`, now, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query counterparties due for reverification: %w", err)
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan counterparty id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]*NormalizedCounterparty, 0, len(ids))
+	for _, id := range ids {
+		cp, err := db.GetNormalizedCounterparty(id)
+		if err != nil {
+			continue
+		}
+		result = append(result, cp)
+	}
+	return result, nil
+}
+
+// GetCounterpartyVerificationState возвращает состояние сверки контрагента (nil, если сверки не было)
+func (db *ServiceDB) GetCounterpartyVerificationState(counterpartyID int) (*CounterpartyVerificationState, error) {
+	state := &CounterpartyVerificationState{}
+	var lastVerified sql.NullTime
+	var lastSource, lastResult, lastError sql.NullString
+	err := db.conn.QueryRow(`
+		SELECT counterparty_id, client_project_id, status, risk_score, last_verified_at, next_verify_at,
+		       last_source, last_result, failures, last_error
+		FROM counterparty_verification_state WHERE counterparty_id = ?
+	`, counterpartyID).Scan(&state.CounterpartyID, &state.ClientProjectID, &state.Status, &state.RiskScore,
+		&lastVerified, &state.NextVerifyAt, &lastSource, &lastResult, &state.Failures, &lastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get counterparty verification state: %w", err)
+	}
+	if lastVerified.Valid {
+		state.LastVerifiedAt = &lastVerified.Time
+	}
+	state.LastSource = lastSource.String
+	state.LastResult = lastResult.String
+	state.LastError = lastError.String
+	return state, nil
+}
+
+// SaveCounterpartyVerificationState сохраняет состояние сверки контрагента
+func (db *ServiceDB) SaveCounterpartyVerificationState(state *CounterpartyVerificationState) error {
+	var lastVerified interface{}
+	if state.LastVerifiedAt != nil {
+		lastVerified = *state.LastVerifiedAt
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO counterparty_verification_state (counterparty_id, client_project_id, status, risk_score,
+			last_verified_at, next_verify_at, last_source, last_result, failures, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(counterparty_id) DO UPDATE SET
+			client_project_id = excluded.client_project_id,
+			status = excluded.status,
+			risk_score = excluded.risk_score,
+			last_verified_at = excluded.last_verified_at,
+			next_verify_at = excluded.next_verify_at,
+			last_source = excluded.last_source,
+			last_result = excluded.last_result,
+			failures = excluded.failures,
+			last_error = excluded.last_error
+	`, state.CounterpartyID, state.ClientProjectID, state.Status, state.RiskScore, lastVerified, state.NextVerifyAt,
+		state.LastSource, state.LastResult, state.Failures, state.LastError)
+	if err != nil {
+		return fmt.Errorf("failed to save counterparty verification state: %w", err)
+	}
+	return nil
+}
+
+// AddCounterpartyEnrichmentChanges записывает обнаруженные изменения реквизитов
+func (db *ServiceDB) AddCounterpartyEnrichmentChanges(changes []*CounterpartyEnrichmentChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO counterparty_enrichment_changes (counterparty_id, client_project_id, field, old_value, new_value,
+			severity, source, applied, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare enrichment change insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, change := range changes {
+		if change.DetectedAt.IsZero() {
+			change.DetectedAt = time.Now()
+		}
+		result, err := stmt.Exec(change.CounterpartyID, change.ClientProjectID, change.Field, change.OldValue,
+			change.NewValue, change.Severity, change.Source, change.Applied, change.DetectedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert enrichment change: %w", err)
+		}
+		id, _ := result.LastInsertId()
+		change.ID = int(id)
+	}
+	return tx.Commit()
+}
+
+// GetCounterpartyEnrichmentChanges возвращает журнал изменений реквизитов (новые первыми) и общее количество
+func (db *ServiceDB) GetCounterpartyEnrichmentChanges(filter CounterpartyEnrichmentChangeFilter) ([]*CounterpartyEnrichmentChange, int, error) {
+	where := " WHERE 1=1"
+	var args []interface{}
+	if filter.ProjectID > 0 {
+		where += " AND client_project_id = ?"
+		args = append(args, filter.ProjectID)
+	}
+	if filter.CounterpartyID > 0 {
+		where += " AND counterparty_id = ?"
+		args = append(args, filter.CounterpartyID)
+	}
+	if filter.Severity != "" {
+		where += " AND severity = ?"
+		args = append(args, filter.Severity)
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM counterparty_enrichment_changes"+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count enrichment changes: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`
+		SELECT id, counterparty_id, client_project_id, field, COALESCE(old_value, ''), COALESCE(new_value, ''),
+		       severity, COALESCE(source, ''), applied, detected_at
+		FROM counterparty_enrichment_changes`+where+`
+		ORDER BY detected_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query enrichment changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []*CounterpartyEnrichmentChange{}
+	for rows.Next() {
+		change := &CounterpartyEnrichmentChange{}
+		if err := rows.Scan(&change.ID, &change.CounterpartyID, &change.ClientProjectID, &change.Field, &change.OldValue,
+			&change.NewValue, &change.Severity, &change.Source, &change.Applied, &change.DetectedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan enrichment change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, total, rows.Err()
+}
+
+// GetCounterpartyVerificationSummary возвращает количество сверенных контрагентов по статусам
+func (db *ServiceDB) GetCounterpartyVerificationSummary() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT status, COUNT(*) FROM counterparty_verification_state GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query verification summary: %w", err)
+	}
+	defer rows.Close()
+
+	summary := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan verification summary: %w", err)
+		}
+		summary[status] = count
+	}
+	return summary, rows.Err()
+}
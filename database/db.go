@@ -10,6 +10,8 @@ import (
 	"strings"
 	"time"
 
+	"httpserver/extractors"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -75,6 +77,9 @@ type CatalogItem struct {
 	Attributes  string    `json:"attributes" xml:"attributes"`   // XML строка
 	TableParts  string    `json:"table_parts" xml:"table_parts"` // XML строка
 	CreatedAt   time.Time `json:"created_at" xml:"created_at"`
+
+	// AttributeSet реквизиты из хранилища атрибутов (пусто, если элемент еще не разобран)
+	AttributeSet extractors.AttributeSet `json:"-" xml:"-"`
 }
 
 // NewDB создает новое подключение к базе данных
@@ -407,6 +412,12 @@ func (db *DB) AddCatalogItem(catalogID int, reference, code, name string, attrib
 		}
 	}
 
+	return db.AddCatalogItemWithAttributes(catalogID, reference, code, name, attrsXML, partsXML, nil)
+}
+
+// AddCatalogItemWithAttributes добавляет элемент справочника вместе с разобранными реквизитами
+// в хранилище атрибутов в одной транзакции
+func (db *DB) AddCatalogItemWithAttributes(catalogID int, reference, code, name, attrsXML, partsXML string, attrs []extractors.Attribute) error {
 	// Используем транзакцию для атомарности операций
 	tx, err := db.conn.Begin()
 	if err != nil {
@@ -419,11 +430,21 @@ func (db *DB) AddCatalogItem(catalogID int, reference, code, name string, attrib
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	_, err = tx.Exec(query, catalogID, reference, code, name, attrsXML, partsXML)
+	result, err := tx.Exec(query, catalogID, reference, code, name, attrsXML, partsXML)
 	if err != nil {
 		return fmt.Errorf("failed to add catalog item: %w", err)
 	}
 
+	if len(attrs) > 0 {
+		itemID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get catalog item id: %w", err)
+		}
+		if err := insertSourceItemAttributes(tx, SourceItemTypeCatalog, itemID, attrs); err != nil {
+			return err
+		}
+	}
+
 	// Обновляем счетчик в uploads через catalog в той же транзакции
 	var uploadID int
 	err = tx.QueryRow("SELECT upload_id FROM catalogs WHERE id = ?", catalogID).Scan(&uploadID)
@@ -456,6 +477,9 @@ type NomenclatureItem struct {
 	AttributesXML           string    `json:"attributes_xml"`
 	TablePartsXML           string    `json:"table_parts_xml"`
 	CreatedAt               time.Time `json:"created_at"`
+
+	// AttributeSet разобранные реквизиты для записи в хранилище атрибутов при загрузке
+	AttributeSet extractors.AttributeSet `json:"-"`
 }
 
 // AddNomenclatureItem добавляет элемент номенклатуры с характеристикой
@@ -542,11 +566,20 @@ func (db *DB) AddNomenclatureItemsBatch(uploadID int, items []NomenclatureItem)
 	defer stmt.Close()
 
 	for _, item := range items {
-		_, err = stmt.Exec(uploadID, item.NomenclatureReference, item.NomenclatureCode, item.NomenclatureName,
+		result, err := stmt.Exec(uploadID, item.NomenclatureReference, item.NomenclatureCode, item.NomenclatureName,
 			item.CharacteristicReference, item.CharacteristicName, item.AttributesXML, item.TablePartsXML)
 		if err != nil {
 			return fmt.Errorf("failed to add nomenclature item: %w", err)
 		}
+		if len(item.AttributeSet) > 0 {
+			itemID, err := result.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("failed to get nomenclature item id: %w", err)
+			}
+			if err := insertSourceItemAttributes(tx, SourceItemTypeNomenclature, itemID, item.AttributeSet); err != nil {
+				return err
+			}
+		}
 	}
 
 	// Обновляем счетчик в uploads
@@ -1526,6 +1559,10 @@ func (db *DB) GetCatalogItemsByUpload(uploadID int, catalogNames []string, offse
 		return nil, 0, fmt.Errorf("error iterating catalog items: %w", err)
 	}
 
+	if err := db.attachCatalogItemAttributes(items); err != nil {
+		return nil, 0, err
+	}
+
 	return items, totalCount, nil
 }
 
@@ -1622,6 +1659,7 @@ type NormalizedItem struct {
 	KpvedConfidence     float64   `json:"kpved_confidence"`
 	QualityScore        float64   `json:"quality_score"`
 	CreatedAt           time.Time `json:"created_at"`
+	DecisionTrace       string    `json:"-"` // JSON записи решений конвейера, сохраняется в normalized_item_decisions
 }
 
 // ItemAttribute представляет извлеченный атрибут товара
@@ -1707,6 +1745,10 @@ func (db *DB) GetAllCatalogItems() ([]*CatalogItem, error) {
 		return nil, fmt.Errorf("error iterating catalog items: %w", err)
 	}
 
+	if err := db.attachCatalogItemAttributes(items); err != nil {
+		return nil, err
+	}
+
 	return items, nil
 }
 
@@ -2013,6 +2055,17 @@ func (db *DB) InsertNormalizedItemsWithAttributesBatch(items []*NormalizedItem,
 	}
 	defer attrStmt.Close()
 
+	// Подготавливаем statement для записей решений конвейера
+	traceStmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO normalized_item_decisions
+		(normalized_item_id, normalization_session_id, trace)
+		VALUES (?, ?, ?)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare decision trace statement: %w", err)
+	}
+	defer traceStmt.Close()
+
 	codeToID := make(map[string]int)
 
 	// Вставляем items
@@ -2050,6 +2103,11 @@ func (db *DB) InsertNormalizedItemsWithAttributesBatch(items []*NormalizedItem,
 		}
 
 		itemID := int(id)
+		if item.DecisionTrace != "" {
+			if _, err = traceStmt.Exec(itemID, sessionID, item.DecisionTrace); err != nil {
+				return nil, fmt.Errorf("failed to insert decision trace for item %d: %w", itemID, err)
+			}
+		}
 		if item.Code != "" {
 			codeToID[item.Code] = itemID
 
@@ -2082,6 +2140,20 @@ func (db *DB) InsertNormalizedItemsWithAttributesBatch(items []*NormalizedItem,
 	return codeToID, nil
 }
 
+// GetNormalizedItemDecision возвращает JSON записи решений конвейера для элемента normalized_data.
+// Пустая строка без ошибки, если запись не сохранялась (элемент нормализован до появления записей)
+func (db *DB) GetNormalizedItemDecision(normalizedItemID int) (string, error) {
+	var trace string
+	err := db.conn.QueryRow(`SELECT trace FROM normalized_item_decisions WHERE normalized_item_id = ?`, normalizedItemID).Scan(&trace)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get decision trace: %w", err)
+	}
+	return trace, nil
+}
+
 // GetItemAttributes получает все атрибуты для нормализованного товара
 func (db *DB) GetItemAttributes(normalizedItemID int) ([]*ItemAttribute, error) {
 	query := `
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// GISPRegistryEntry позиция реестра российской промышленной продукции ГИСП для сопоставления с номенклатурой
+type GISPRegistryEntry struct {
+	ID               int    `json:"id"`
+	ProductName      string `json:"product_name"`
+	NormalizedName   string `json:"normalized_name"`
+	OKPD2Code        string `json:"okpd2_code"`
+	TNVEDCode        string `json:"tnved_code,omitempty"`
+	Standard         string `json:"standard,omitempty"` // ТУ/ГОСТ, по которому изготовлена продукция
+	RegistryNumber   string `json:"registry_number,omitempty"`
+	ValidityPeriod   string `json:"validity_period,omitempty"`
+	ManufacturerName string `json:"manufacturer_name,omitempty"`
+	ManufacturerINN  string `json:"manufacturer_inn,omitempty"`
+}
+
+// gispEntryAttributes поля атрибутов номенклатуры ГИСП, которые пишет импортер
+type gispEntryAttributes struct {
+	RegistryNumber   string `json:"registry_number"`
+	ValidityPeriod   string `json:"validity_period"`
+	ManufacturerName string `json:"manufacturer_name"`
+	ManufacturerINN  string `json:"manufacturer_inn"`
+}
+
+// GetGISPRegistryEntriesByOKPD2 возвращает позиции реестра ГИСП, код ОКПД2 которых равен prefix
+// или входит в раздел prefix (по границе уровня)
+func (db *ServiceDB) GetGISPRegistryEntriesByOKPD2(prefix string) ([]*GISPRegistryEntry, error) {
+	prefix = strings.TrimSuffix(strings.TrimSpace(prefix), ".")
+	if prefix == "" {
+		return nil, nil
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT cb.id, cb.original_name, COALESCE(cb.normalized_name, ''), o.code,
+		       COALESCE(t.code, ''), COALESCE(g.code, ''), COALESCE(cb.attributes, ''),
+		       COALESCE(m.original_name, ''), COALESCE(m.tax_id, '')
+		FROM client_benchmarks cb
+		JOIN okpd2_classifier o ON o.id = cb.okpd2_reference_id
+		LEFT JOIN tnved_reference t ON t.id = cb.tnved_reference_id
+		LEFT JOIN tu_gost_reference g ON g.id = cb.tu_gost_reference_id
+		LEFT JOIN client_benchmarks m ON m.id = cb.manufacturer_benchmark_id
+		WHERE cb.category = 'nomenclature' AND cb.source_database = 'gisp_gov_ru'
+		  AND (o.code = ? OR o.code LIKE ?)
+		ORDER BY cb.id
+	`, prefix, prefix+".%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GISP registry entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*GISPRegistryEntry
+	for rows.Next() {
+		entry := &GISPRegistryEntry{}
+		var attributes string
+		if err := rows.Scan(&entry.ID, &entry.ProductName, &entry.NormalizedName, &entry.OKPD2Code,
+			&entry.TNVEDCode, &entry.Standard, &attributes, &entry.ManufacturerName, &entry.ManufacturerINN); err != nil {
+			return nil, fmt.Errorf("failed to scan GISP registry entry: %w", err)
+		}
+		if attributes != "" {
+			var attrs gispEntryAttributes
+			if json.Unmarshal([]byte(attributes), &attrs) == nil {
+				entry.RegistryNumber = attrs.RegistryNumber
+				entry.ValidityPeriod = attrs.ValidityPeriod
+				if entry.ManufacturerName == "" {
+					entry.ManufacturerName = attrs.ManufacturerName
+				}
+				if entry.ManufacturerINN == "" {
+					entry.ManufacturerINN = attrs.ManufacturerINN
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// CountGISPRegistryEntries возвращает число позиций реестра ГИСП в сервисной БД
+func (db *ServiceDB) CountGISPRegistryEntries() (int, error) {
+	var count int
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) FROM client_benchmarks
+		WHERE category = 'nomenclature' AND source_database = 'gisp_gov_ru'
+	`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count GISP registry entries: %w", err)
+	}
+	return count, nil
+}
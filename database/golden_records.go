@@ -0,0 +1,450 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GoldenRecord эталонная запись номенклатуры проекта, собранная из нескольких баз 1С
+type GoldenRecord struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	GoldenKey   string    `json:"golden_key"`
+	Name        string    `json:"name"`
+	SourceCount int       `json:"source_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// GoldenRecordAttribute значение атрибута эталонной записи с происхождением (lineage)
+type GoldenRecordAttribute struct {
+	GoldenRecordID      int        `json:"golden_record_id"`
+	Attribute           string     `json:"attribute"`
+	Value               string     `json:"value"`
+	Strategy            string     `json:"strategy"`
+	SourceDatabaseID    int        `json:"source_database_id,omitempty"`
+	SourceCatalogItemID int        `json:"source_catalog_item_id,omitempty"`
+	SourceReference     string     `json:"source_reference,omitempty"`
+	SourceObservedAt    *time.Time `json:"source_observed_at,omitempty"`
+	Overridden          bool       `json:"overridden"`
+	OverrideBy          string     `json:"override_by,omitempty"`
+	OverrideReason      string     `json:"override_reason,omitempty"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// GoldenRecordSource запись catalog_items, вошедшая в эталонную запись
+type GoldenRecordSource struct {
+	GoldenRecordID int       `json:"golden_record_id"`
+	DatabaseID     int       `json:"database_id"`
+	CatalogItemID  int       `json:"catalog_item_id"`
+	Reference      string    `json:"reference"`
+	Code           string    `json:"code"`
+	Name           string    `json:"name"`
+	AttributesJSON string    `json:"attributes_json"` // разобранные атрибуты источника
+	ObservedAt     time.Time `json:"observed_at"`
+}
+
+// GoldenSurvivorshipRuleRecord правило выживания атрибута проекта
+type GoldenSurvivorshipRuleRecord struct {
+	ProjectID      int       `json:"project_id"`
+	Attribute      string    `json:"attribute"`
+	Strategy       string    `json:"strategy"`
+	SourcePriority string    `json:"source_priority"` // JSON массив ID баз
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// CreateGoldenRecordTables создает таблицы эталонных записей номенклатуры
+func CreateGoldenRecordTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS golden_records (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL,
+			golden_key TEXT NOT NULL,
+			name TEXT NOT NULL,
+			source_count INTEGER DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_id, golden_key),
+			FOREIGN KEY(project_id) REFERENCES client_projects(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS golden_record_attributes (
+			golden_record_id INTEGER NOT NULL,
+			attribute TEXT NOT NULL,
+			value TEXT,
+			strategy TEXT,
+			source_database_id INTEGER,
+			source_catalog_item_id INTEGER,
+			source_reference TEXT,
+			source_observed_at TIMESTAMP,
+			overridden BOOLEAN DEFAULT FALSE,
+			override_by TEXT,
+			override_reason TEXT,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (golden_record_id, attribute),
+			FOREIGN KEY(golden_record_id) REFERENCES golden_records(id) ON DELETE CASCADE
+		);
+
+		CREATE TABLE IF NOT EXISTS golden_record_sources (
+			golden_record_id INTEGER NOT NULL,
+			database_id INTEGER NOT NULL,
+			catalog_item_id INTEGER NOT NULL,
+			reference TEXT,
+			code TEXT,
+			name TEXT,
+			attributes_json TEXT,
+			observed_at TIMESTAMP,
+			PRIMARY KEY (golden_record_id, database_id, catalog_item_id),
+			FOREIGN KEY(golden_record_id) REFERENCES golden_records(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_golden_record_sources_item ON golden_record_sources(database_id, catalog_item_id);
+
+		CREATE TABLE IF NOT EXISTS golden_survivorship_rules (
+			project_id INTEGER NOT NULL,
+			attribute TEXT NOT NULL, -- '*' - правило по умолчанию
+			strategy TEXT NOT NULL,
+			source_priority TEXT, -- JSON массив ID баз проекта
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (project_id, attribute)
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create golden record tables: %w", err)
+	}
+	return nil
+}
+
+// GetGoldenSurvivorshipRules возвращает правила выживания проекта
+func (db *ServiceDB) GetGoldenSurvivorshipRules(projectID int) ([]*GoldenSurvivorshipRuleRecord, error) {
+	rows, err := db.conn.Query(`
+		SELECT project_id, attribute, strategy, COALESCE(source_priority, ''), updated_at
+		FROM golden_survivorship_rules WHERE project_id = ? ORDER BY attribute
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get survivorship rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*GoldenSurvivorshipRuleRecord
+	for rows.Next() {
+		r := &GoldenSurvivorshipRuleRecord{}
+		if err := rows.Scan(&r.ProjectID, &r.Attribute, &r.Strategy, &r.SourcePriority, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan survivorship rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// ReplaceGoldenSurvivorshipRules заменяет набор правил выживания проекта
+func (db *ServiceDB) ReplaceGoldenSurvivorshipRules(projectID int, rules []*GoldenSurvivorshipRuleRecord) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM golden_survivorship_rules WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("failed to delete survivorship rules: %w", err)
+	}
+	for _, r := range rules {
+		if _, err := tx.Exec(`
+			INSERT INTO golden_survivorship_rules (project_id, attribute, strategy, source_priority, updated_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`, projectID, r.Attribute, r.Strategy, r.SourcePriority); err != nil {
+			return fmt.Errorf("failed to save survivorship rule: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GoldenRecordTx транзакция пересборки эталонных записей проекта
+type GoldenRecordTx struct {
+	tx *sql.Tx
+}
+
+// RebuildGoldenRecords выполняет пересборку эталонных записей в одной транзакции:
+// при ошибке fn записи остаются в прежнем состоянии
+func (db *ServiceDB) RebuildGoldenRecords(fn func(tx *GoldenRecordTx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&GoldenRecordTx{tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit golden records: %w", err)
+	}
+	return nil
+}
+
+// UpsertGoldenRecord создает или обновляет эталонную запись по (project_id, golden_key), возвращает ID
+func (t *GoldenRecordTx) UpsertGoldenRecord(projectID int, goldenKey, name string, sourceCount int) (int, error) {
+	_, err := t.tx.Exec(`
+		INSERT INTO golden_records (project_id, golden_key, name, source_count, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(project_id, golden_key) DO UPDATE SET
+			name = excluded.name,
+			source_count = excluded.source_count,
+			updated_at = CURRENT_TIMESTAMP
+	`, projectID, goldenKey, name, sourceCount)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save golden record: %w", err)
+	}
+	var id int
+	if err := t.tx.QueryRow(`SELECT id FROM golden_records WHERE project_id = ? AND golden_key = ?`,
+		projectID, goldenKey).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get golden record id: %w", err)
+	}
+	return id, nil
+}
+
+// ReplaceGoldenRecordSources заменяет список источников эталонной записи
+func (t *GoldenRecordTx) ReplaceGoldenRecordSources(recordID int, sources []*GoldenRecordSource) error {
+	if _, err := t.tx.Exec(`DELETE FROM golden_record_sources WHERE golden_record_id = ?`, recordID); err != nil {
+		return fmt.Errorf("failed to delete golden record sources: %w", err)
+	}
+	for _, s := range sources {
+		if _, err := t.tx.Exec(`
+			INSERT INTO golden_record_sources (golden_record_id, database_id, catalog_item_id, reference, code, name, attributes_json, observed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, recordID, s.DatabaseID, s.CatalogItemID, s.Reference, s.Code, s.Name, s.AttributesJSON, s.ObservedAt); err != nil {
+			return fmt.Errorf("failed to save golden record source: %w", err)
+		}
+	}
+	return nil
+}
+
+// SaveGoldenRecordAttribute сохраняет вычисленное значение атрибута.
+// Атрибуты, переопределенные вручную, не перезаписываются.
+func (t *GoldenRecordTx) SaveGoldenRecordAttribute(attr *GoldenRecordAttribute) error {
+	_, err := t.tx.Exec(`
+		INSERT INTO golden_record_attributes (golden_record_id, attribute, value, strategy,
+			source_database_id, source_catalog_item_id, source_reference, source_observed_at, overridden, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, FALSE, CURRENT_TIMESTAMP)
+		ON CONFLICT(golden_record_id, attribute) DO UPDATE SET
+			value = excluded.value,
+			strategy = excluded.strategy,
+			source_database_id = excluded.source_database_id,
+			source_catalog_item_id = excluded.source_catalog_item_id,
+			source_reference = excluded.source_reference,
+			source_observed_at = excluded.source_observed_at,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE golden_record_attributes.overridden = FALSE
+	`, attr.GoldenRecordID, attr.Attribute, attr.Value, attr.Strategy,
+		attr.SourceDatabaseID, attr.SourceCatalogItemID, attr.SourceReference, attr.SourceObservedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save golden record attribute: %w", err)
+	}
+	return nil
+}
+
+// OverrideGoldenRecordAttribute вручную задает значение атрибута эталонной записи
+func (db *ServiceDB) OverrideGoldenRecordAttribute(recordID int, attribute, value, overrideBy, reason string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO golden_record_attributes (golden_record_id, attribute, value, strategy,
+			source_database_id, source_catalog_item_id, source_reference, source_observed_at,
+			overridden, override_by, override_reason, updated_at)
+		VALUES (?, ?, ?, 'manual', NULL, NULL, NULL, NULL, TRUE, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(golden_record_id, attribute) DO UPDATE SET
+			value = excluded.value,
+			strategy = 'manual',
+			source_database_id = NULL,
+			source_catalog_item_id = NULL,
+			source_reference = NULL,
+			source_observed_at = NULL,
+			overridden = TRUE,
+			override_by = excluded.override_by,
+			override_reason = excluded.override_reason,
+			updated_at = CURRENT_TIMESTAMP
+	`, recordID, attribute, value, overrideBy, reason)
+	if err != nil {
+		return fmt.Errorf("failed to override golden record attribute: %w", err)
+	}
+	return nil
+}
+
+// ClearGoldenRecordOverride снимает ручное переопределение атрибута
+// (значение будет пересчитано при следующей сборке)
+func (db *ServiceDB) ClearGoldenRecordOverride(recordID int, attribute string) error {
+	_, err := db.conn.Exec(`
+		UPDATE golden_record_attributes
+		SET overridden = FALSE, override_by = NULL, override_reason = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE golden_record_id = ? AND attribute = ?
+	`, recordID, attribute)
+	if err != nil {
+		return fmt.Errorf("failed to clear golden record override: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleGoldenRecordAttributes удаляет вычисленные атрибуты, которых больше нет в источниках
+func (t *GoldenRecordTx) DeleteStaleGoldenRecordAttributes(recordID int, keep []string) error {
+	query := `DELETE FROM golden_record_attributes WHERE golden_record_id = ? AND overridden = FALSE`
+	args := []interface{}{recordID}
+	if len(keep) > 0 {
+		query += " AND attribute NOT IN (?" + strings.Repeat(", ?", len(keep)-1) + ")"
+		for _, a := range keep {
+			args = append(args, a)
+		}
+	}
+	if _, err := t.tx.Exec(query, args...); err != nil {
+		return fmt.Errorf("failed to delete stale golden record attributes: %w", err)
+	}
+	return nil
+}
+
+// GetGoldenRecord возвращает эталонную запись по ID (nil, если не найдена)
+func (db *ServiceDB) GetGoldenRecord(id int) (*GoldenRecord, error) {
+	r := &GoldenRecord{}
+	err := db.conn.QueryRow(`
+		SELECT id, project_id, golden_key, name, source_count, created_at, updated_at
+		FROM golden_records WHERE id = ?
+	`, id).Scan(&r.ID, &r.ProjectID, &r.GoldenKey, &r.Name, &r.SourceCount, &r.CreatedAt, &r.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get golden record: %w", err)
+	}
+	return r, nil
+}
+
+// GetGoldenRecords возвращает эталонные записи проекта с поиском по имени и общее количество
+func (db *ServiceDB) GetGoldenRecords(projectID int, search string, limit, offset int) ([]*GoldenRecord, int, error) {
+	where := "project_id = ?"
+	args := []interface{}{projectID}
+	if search != "" {
+		where += " AND (name LIKE ? OR golden_key LIKE ?)"
+		pattern := "%" + search + "%"
+		args = append(args, pattern, pattern)
+	}
+
+	var total int
+	if err := db.conn.QueryRow("SELECT COUNT(*) FROM golden_records WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count golden records: %w", err)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, project_id, golden_key, name, source_count, created_at, updated_at
+		FROM golden_records WHERE `+where+` ORDER BY name LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get golden records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*GoldenRecord
+	for rows.Next() {
+		r := &GoldenRecord{}
+		if err := rows.Scan(&r.ID, &r.ProjectID, &r.GoldenKey, &r.Name, &r.SourceCount, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan golden record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, total, rows.Err()
+}
+
+// GetGoldenRecordAttributes возвращает атрибуты эталонной записи
+func (db *ServiceDB) GetGoldenRecordAttributes(recordID int) ([]*GoldenRecordAttribute, error) {
+	rows, err := db.conn.Query(`
+		SELECT golden_record_id, attribute, COALESCE(value, ''), COALESCE(strategy, ''),
+		       COALESCE(source_database_id, 0), COALESCE(source_catalog_item_id, 0), COALESCE(source_reference, ''),
+		       source_observed_at, overridden, COALESCE(override_by, ''), COALESCE(override_reason, ''), updated_at
+		FROM golden_record_attributes WHERE golden_record_id = ? ORDER BY attribute
+	`, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get golden record attributes: %w", err)
+	}
+	defer rows.Close()
+
+	var attrs []*GoldenRecordAttribute
+	for rows.Next() {
+		a := &GoldenRecordAttribute{}
+		var observedAt sql.NullTime
+		if err := rows.Scan(&a.GoldenRecordID, &a.Attribute, &a.Value, &a.Strategy,
+			&a.SourceDatabaseID, &a.SourceCatalogItemID, &a.SourceReference,
+			&observedAt, &a.Overridden, &a.OverrideBy, &a.OverrideReason, &a.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan golden record attribute: %w", err)
+		}
+		if observedAt.Valid {
+			t := observedAt.Time
+			a.SourceObservedAt = &t
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs, rows.Err()
+}
+
+// GetGoldenRecordSources возвращает записи catalog_items, из которых собрана эталонная запись
+func (db *ServiceDB) GetGoldenRecordSources(recordID int) ([]*GoldenRecordSource, error) {
+	rows, err := db.conn.Query(`
+		SELECT golden_record_id, database_id, catalog_item_id, COALESCE(reference, ''), COALESCE(code, ''),
+		       COALESCE(name, ''), COALESCE(attributes_json, ''), observed_at
+		FROM golden_record_sources WHERE golden_record_id = ? ORDER BY database_id, catalog_item_id
+	`, recordID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get golden record sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []*GoldenRecordSource
+	for rows.Next() {
+		s := &GoldenRecordSource{}
+		var observedAt sql.NullTime
+		if err := rows.Scan(&s.GoldenRecordID, &s.DatabaseID, &s.CatalogItemID, &s.Reference, &s.Code,
+			&s.Name, &s.AttributesJSON, &observedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan golden record source: %w", err)
+		}
+		if observedAt.Valid {
+			s.ObservedAt = observedAt.Time
+		}
+		sources = append(sources, s)
+	}
+	return sources, rows.Err()
+}
+
+// DeleteGoldenRecordsExcept удаляет эталонные записи проекта, которых нет в keepIDs и
+// у которых нет ручных переопределений
+func (t *GoldenRecordTx) DeleteGoldenRecordsExcept(projectID int, keepIDs map[int]bool) (int, error) {
+	rows, err := t.tx.Query(`
+		SELECT id FROM golden_records r
+		WHERE project_id = ? AND NOT EXISTS (
+			SELECT 1 FROM golden_record_attributes a WHERE a.golden_record_id = r.id AND a.overridden = TRUE
+		)
+	`, projectID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get golden records: %w", err)
+	}
+	var stale []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan golden record id: %w", err)
+		}
+		if !keepIDs[id] {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range stale {
+		if _, err := t.tx.Exec(`DELETE FROM golden_record_attributes WHERE golden_record_id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to delete golden record attributes: %w", err)
+		}
+		if _, err := t.tx.Exec(`DELETE FROM golden_record_sources WHERE golden_record_id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to delete golden record sources: %w", err)
+		}
+		if _, err := t.tx.Exec(`DELETE FROM golden_records WHERE id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to delete golden record: %w", err)
+		}
+	}
+	return len(stale), nil
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ImportMappingProfile сохраненное сопоставление колонок табличного файла клиента
+// (например, прайс-листа поставщика), применяемое при следующих импортах
+type ImportMappingProfile struct {
+	ID         int               `json:"id"`
+	ClientID   int               `json:"client_id"`
+	Name       string            `json:"name"`
+	EntityType string            `json:"entity_type"` // nomenclature, counterparties
+	Sheet      string            `json:"sheet,omitempty"`
+	HeaderRow  int               `json:"header_row,omitempty"` // 0 - определять автоматически
+	Mapping    map[string]string `json:"mapping"`              // поле -> заголовок колонки
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// CreateImportMappingProfilesTable создает таблицу профилей сопоставления колонок
+func CreateImportMappingProfilesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS import_mapping_profiles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			client_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			entity_type TEXT NOT NULL,
+			sheet TEXT NOT NULL DEFAULT '',
+			header_row INTEGER NOT NULL DEFAULT 0,
+			mapping_json TEXT NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(client_id, name),
+			FOREIGN KEY(client_id) REFERENCES clients(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_import_mapping_profiles_client ON import_mapping_profiles(client_id, entity_type);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create import_mapping_profiles table: %w", err)
+	}
+	return nil
+}
+
+const importMappingProfileColumns = `id, client_id, name, entity_type, sheet, header_row, mapping_json, created_at, updated_at`
+
+// SaveImportMappingProfile создает профиль или заменяет профиль клиента с тем же именем
+func (db *ServiceDB) SaveImportMappingProfile(profile *ImportMappingProfile) (*ImportMappingProfile, error) {
+	mappingJSON, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO import_mapping_profiles (client_id, name, entity_type, sheet, header_row, mapping_json)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(client_id, name) DO UPDATE SET
+			entity_type = excluded.entity_type,
+			sheet = excluded.sheet,
+			header_row = excluded.header_row,
+			mapping_json = excluded.mapping_json,
+			updated_at = CURRENT_TIMESTAMP
+	`, profile.ClientID, profile.Name, profile.EntityType, profile.Sheet, profile.HeaderRow, string(mappingJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save import mapping profile: %w", err)
+	}
+	row := db.conn.QueryRow(`SELECT `+importMappingProfileColumns+` FROM import_mapping_profiles WHERE client_id = ? AND name = ?`,
+		profile.ClientID, profile.Name)
+	return scanImportMappingProfile(row)
+}
+
+// UpdateImportMappingProfile изменяет профиль по ID; клиент профиля не меняется
+func (db *ServiceDB) UpdateImportMappingProfile(profile *ImportMappingProfile) error {
+	mappingJSON, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return fmt.Errorf("failed to marshal mapping: %w", err)
+	}
+	result, err := db.conn.Exec(`
+		UPDATE import_mapping_profiles
+		SET name = ?, entity_type = ?, sheet = ?, header_row = ?, mapping_json = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, profile.Name, profile.EntityType, profile.Sheet, profile.HeaderRow, string(mappingJSON), profile.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update import mapping profile: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetImportMappingProfile возвращает профиль по ID (nil, если не найден)
+func (db *ServiceDB) GetImportMappingProfile(id int) (*ImportMappingProfile, error) {
+	row := db.conn.QueryRow(`SELECT `+importMappingProfileColumns+` FROM import_mapping_profiles WHERE id = ?`, id)
+	profile, err := scanImportMappingProfile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return profile, err
+}
+
+// GetImportMappingProfiles возвращает профили клиента (entityType пустой - все типы)
+func (db *ServiceDB) GetImportMappingProfiles(clientID int, entityType string) ([]*ImportMappingProfile, error) {
+	query := `SELECT ` + importMappingProfileColumns + ` FROM import_mapping_profiles WHERE client_id = ?`
+	args := []interface{}{clientID}
+	if entityType != "" {
+		query += ` AND entity_type = ?`
+		args = append(args, entityType)
+	}
+	rows, err := db.conn.Query(query+` ORDER BY name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import mapping profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := make([]*ImportMappingProfile, 0)
+	for rows.Next() {
+		profile, err := scanImportMappingProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+	return profiles, rows.Err()
+}
+
+// DeleteImportMappingProfile удаляет профиль
+func (db *ServiceDB) DeleteImportMappingProfile(id int) error {
+	result, err := db.conn.Exec(`DELETE FROM import_mapping_profiles WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete import mapping profile: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+type importMappingProfileScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanImportMappingProfile(row importMappingProfileScanner) (*ImportMappingProfile, error) {
+	var profile ImportMappingProfile
+	var mappingJSON string
+	err := row.Scan(&profile.ID, &profile.ClientID, &profile.Name, &profile.EntityType, &profile.Sheet,
+		&profile.HeaderRow, &mappingJSON, &profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan import mapping profile: %w", err)
+	}
+	profile.Mapping = make(map[string]string)
+	if mappingJSON != "" {
+		if err := json.Unmarshal([]byte(mappingJSON), &profile.Mapping); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal mapping: %w", err)
+		}
+	}
+	return &profile, nil
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNameTemplateConflict шаблон для этой области и раздела уже существует
+var ErrNameTemplateConflict = errors.New("name template for this scope and match already exists")
+
+// NameTemplateRecord шаблон нормализованного имени для раздела КПВЭД/ОКПД2 или категории
+type NameTemplateRecord struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Scope     string    `json:"scope"` // kpved, okpd2, category
+	Match     string    `json:"match"` // префикс кода или название категории
+	Pattern   string    `json:"pattern"`
+	Priority  int       `json:"priority"`
+	Enabled   bool      `json:"enabled"`
+	Version   int       `json:"version"` // увеличивается при каждом изменении шаблона
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NameTemplateSourceItem нормализованный элемент с атрибутами для повторного применения шаблонов
+type NameTemplateSourceItem struct {
+	ID                  int
+	SourceName          string
+	NormalizedName      string
+	NormalizedReference string
+	Category            string
+	KpvedCode           string
+	Okpd2Code           string
+	Attributes          []*ItemAttribute
+}
+
+// CreateNameTemplatesTable создает таблицу шаблонов имен
+func CreateNameTemplatesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS name_templates (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			scope TEXT NOT NULL,
+			match_value TEXT NOT NULL,
+			pattern TEXT NOT NULL,
+			priority INTEGER NOT NULL DEFAULT 0,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			version INTEGER NOT NULL DEFAULT 1,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_name_templates_scope_match ON name_templates(scope, match_value);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create name templates table: %w", err)
+	}
+	return nil
+}
+
+const nameTemplateColumns = `id, name, scope, match_value, pattern, priority, enabled, version, created_at, updated_at`
+
+func scanNameTemplate(scanner interface{ Scan(...interface{}) error }) (*NameTemplateRecord, error) {
+	t := &NameTemplateRecord{}
+	err := scanner.Scan(&t.ID, &t.Name, &t.Scope, &t.Match, &t.Pattern, &t.Priority, &t.Enabled, &t.Version, &t.CreatedAt, &t.UpdatedAt)
+	return t, err
+}
+
+// GetNameTemplates возвращает шаблоны имен (enabledOnly - только включенные)
+func (db *ServiceDB) GetNameTemplates(enabledOnly bool) ([]*NameTemplateRecord, error) {
+	query := `SELECT ` + nameTemplateColumns + ` FROM name_templates`
+	if enabledOnly {
+		query += ` WHERE enabled = 1`
+	}
+	query += ` ORDER BY scope, match_value`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query name templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*NameTemplateRecord
+	for rows.Next() {
+		t, err := scanNameTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan name template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+// GetNameTemplate возвращает шаблон по ID (nil, если не найден)
+func (db *ServiceDB) GetNameTemplate(id int) (*NameTemplateRecord, error) {
+	t, err := scanNameTemplate(db.conn.QueryRow(`SELECT `+nameTemplateColumns+` FROM name_templates WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get name template: %w", err)
+	}
+	return t, nil
+}
+
+// CreateNameTemplate сохраняет новый шаблон и заполняет ID
+func (db *ServiceDB) CreateNameTemplate(t *NameTemplateRecord) error {
+	result, err := db.conn.Exec(`
+		INSERT INTO name_templates (name, scope, match_value, pattern, priority, enabled)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, t.Name, t.Scope, t.Match, t.Pattern, t.Priority, t.Enabled)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrNameTemplateConflict
+		}
+		return fmt.Errorf("failed to create name template: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get name template id: %w", err)
+	}
+	t.ID = int(id)
+	t.Version = 1
+	return nil
+}
+
+// UpdateNameTemplate обновляет шаблон и увеличивает его версию. Возвращает false, если шаблон не найден
+func (db *ServiceDB) UpdateNameTemplate(t *NameTemplateRecord) (bool, error) {
+	result, err := db.conn.Exec(`
+		UPDATE name_templates
+		SET name = ?, scope = ?, match_value = ?, pattern = ?, priority = ?, enabled = ?,
+		    version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, t.Name, t.Scope, t.Match, t.Pattern, t.Priority, t.Enabled, t.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, ErrNameTemplateConflict
+		}
+		return false, fmt.Errorf("failed to update name template: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// DeleteNameTemplate удаляет шаблон. Возвращает false, если шаблон не найден
+func (db *ServiceDB) DeleteNameTemplate(id int) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM name_templates WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete name template: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// GetProjectItemsForNameTemplates возвращает нормализованные элементы проекта вместе с атрибутами
+func (db *DB) GetProjectItemsForNameTemplates(projectID int) ([]*NameTemplateSourceItem, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, COALESCE(source_name, ''), COALESCE(normalized_name, ''), COALESCE(normalized_reference, ''),
+		       COALESCE(category, ''), COALESCE(kpved_code, ''), COALESCE(stage12_okpd2_code, '')
+		FROM normalized_data
+		WHERE project_id = ?
+		ORDER BY id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query normalized items: %w", err)
+	}
+
+	var items []*NameTemplateSourceItem
+	byID := make(map[int]*NameTemplateSourceItem)
+	for rows.Next() {
+		item := &NameTemplateSourceItem{}
+		if err := rows.Scan(&item.ID, &item.SourceName, &item.NormalizedName, &item.NormalizedReference,
+			&item.Category, &item.KpvedCode, &item.Okpd2Code); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan normalized item: %w", err)
+		}
+		items = append(items, item)
+		byID[item.ID] = item
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	attrRows, err := db.conn.Query(`
+		SELECT a.normalized_item_id, a.attribute_type, COALESCE(a.attribute_name, ''), a.attribute_value,
+		       COALESCE(a.unit, ''), COALESCE(a.original_text, ''), COALESCE(a.confidence, 1.0)
+		FROM normalized_item_attributes a
+		JOIN normalized_data d ON d.id = a.normalized_item_id
+		WHERE d.project_id = ?
+		ORDER BY a.id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query item attributes: %w", err)
+	}
+	defer attrRows.Close()
+	for attrRows.Next() {
+		attr := &ItemAttribute{}
+		if err := attrRows.Scan(&attr.NormalizedItemID, &attr.AttributeType, &attr.AttributeName, &attr.AttributeValue,
+			&attr.Unit, &attr.OriginalText, &attr.Confidence); err != nil {
+			return nil, fmt.Errorf("failed to scan item attribute: %w", err)
+		}
+		if item, ok := byID[attr.NormalizedItemID]; ok {
+			item.Attributes = append(item.Attributes, attr)
+		}
+	}
+	return items, attrRows.Err()
+}
+
+// UpdateNormalizedNames обновляет normalized_name элементов в одной транзакции (id -> новое имя).
+// normalized_reference не меняется: он остается ключом группы, из которого строится имя по шаблону
+func (db *DB) UpdateNormalizedNames(names map[int]string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE normalized_data SET normalized_name = ? WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, name := range names {
+		if _, err := stmt.Exec(name, id); err != nil {
+			return fmt.Errorf("failed to update normalized name for item %d: %w", id, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNameTemplatesCRUD(t *testing.T) {
+	db := newTestServiceDB(t)
+
+	template := &NameTemplateRecord{Name: "Трубы", Scope: "kpved", Match: "24.20", Pattern: "{type} {dimensions}", Enabled: true}
+	if err := db.CreateNameTemplate(template); err != nil {
+		t.Fatalf("CreateNameTemplate() error = %v", err)
+	}
+	if template.ID == 0 {
+		t.Fatal("CreateNameTemplate() must set ID")
+	}
+
+	duplicate := &NameTemplateRecord{Name: "Дубль", Scope: "kpved", Match: "24.20", Pattern: "{type}", Enabled: true}
+	if err := db.CreateNameTemplate(duplicate); !errors.Is(err, ErrNameTemplateConflict) {
+		t.Fatalf("CreateNameTemplate() duplicate error = %v, want ErrNameTemplateConflict", err)
+	}
+
+	template.Pattern = "{type} {material} {dimensions}"
+	template.Enabled = false
+	if ok, err := db.UpdateNameTemplate(template); err != nil || !ok {
+		t.Fatalf("UpdateNameTemplate() = %v, %v", ok, err)
+	}
+	stored, err := db.GetNameTemplate(template.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetNameTemplate() = %v, %v", stored, err)
+	}
+	if stored.Pattern != template.Pattern || stored.Enabled || stored.Version != 2 {
+		t.Errorf("stored template = %+v", stored)
+	}
+
+	enabled, err := db.GetNameTemplates(true)
+	if err != nil || len(enabled) != 0 {
+		t.Errorf("GetNameTemplates(true) = %d templates, %v", len(enabled), err)
+	}
+
+	if ok, err := db.DeleteNameTemplate(template.ID); err != nil || !ok {
+		t.Fatalf("DeleteNameTemplate() = %v, %v", ok, err)
+	}
+	if stored, _ := db.GetNameTemplate(template.ID); stored != nil {
+		t.Error("template must be deleted")
+	}
+}
+
+func TestGetProjectItemsForNameTemplatesAndUpdate(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create DB: %v", err)
+	}
+	defer db.Close()
+
+	projectID := 7
+	items := []*NormalizedItem{
+		{SourceName: "Труба 57х3,5", Code: "001", NormalizedName: "труба", NormalizedReference: "труба", Category: "трубы", KpvedCode: "24.20.13", MergedCount: 1},
+	}
+	attrs := map[string][]*ItemAttribute{
+		"001": {{AttributeType: "dimension", AttributeName: "width", AttributeValue: "57", OriginalText: "57х3,5", Confidence: 1}},
+	}
+	codeToID, err := db.InsertNormalizedItemsWithAttributesBatch(items, attrs, nil, &projectID)
+	if err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	loaded, err := db.GetProjectItemsForNameTemplates(projectID)
+	if err != nil {
+		t.Fatalf("GetProjectItemsForNameTemplates() error = %v", err)
+	}
+	if len(loaded) != 1 || loaded[0].KpvedCode != "24.20.13" || len(loaded[0].Attributes) != 1 {
+		t.Fatalf("loaded items = %+v", loaded)
+	}
+
+	if err := db.UpdateNormalizedNames(map[int]string{codeToID["001"]: "труба 57х3,5"}); err != nil {
+		t.Fatalf("UpdateNormalizedNames() error = %v", err)
+	}
+	item, err := db.GetNormalizedItem(codeToID["001"])
+	if err != nil {
+		t.Fatalf("GetNormalizedItem() error = %v", err)
+	}
+	if item.NormalizedName != "труба 57х3,5" || item.NormalizedReference != "труба" {
+		t.Errorf("item after update = %+v", item)
+	}
+}
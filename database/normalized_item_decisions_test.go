@@ -0,0 +1,36 @@
+package database
+
+import "testing"
+
+// TestInsertNormalizedItemsWithDecisionTrace проверяет сохранение записи решений вместе с элементом
+func TestInsertNormalizedItemsWithDecisionTrace(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create DB: %v", err)
+	}
+	defer db.Close()
+
+	trace := `{"steps":[{"stage":"rules","outcome":"applied","result":"болт м8"}]}`
+	items := []*NormalizedItem{
+		{SourceName: "Болт М8", Code: "001", NormalizedName: "болт м8", Category: "крепеж", MergedCount: 1, ProcessingLevel: "basic", DecisionTrace: trace},
+		{SourceName: "Гайка М8", Code: "002", NormalizedName: "гайка м8", Category: "крепеж", MergedCount: 1, ProcessingLevel: "basic"},
+	}
+	codeToID, err := db.InsertNormalizedItemsWithAttributesBatch(items, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	got, err := db.GetNormalizedItemDecision(codeToID["001"])
+	if err != nil {
+		t.Fatalf("GetNormalizedItemDecision() error = %v", err)
+	}
+	if got != trace {
+		t.Errorf("GetNormalizedItemDecision() = %q, want %q", got, trace)
+	}
+
+	// Для элемента без записи возвращается пустая строка без ошибки
+	got, err = db.GetNormalizedItemDecision(codeToID["002"])
+	if err != nil || got != "" {
+		t.Errorf("GetNormalizedItemDecision() for item without trace = %q, %v", got, err)
+	}
+}
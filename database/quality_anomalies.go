@@ -0,0 +1,557 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Важность аномалий в трендах качества
+const (
+	QualityAnomalySeverityLow      = "low"
+	QualityAnomalySeverityMedium   = "medium"
+	QualityAnomalySeverityHigh     = "high"
+	QualityAnomalySeverityCritical = "critical"
+)
+
+// Статусы аномалий в трендах качества
+const (
+	QualityAnomalyStatusOpen         = "open"
+	QualityAnomalyStatusAcknowledged = "acknowledged" // принята в работу, повторные срабатывания не уведомляют
+	QualityAnomalyStatusResolved     = "resolved"
+)
+
+// SystemMetricsDatabaseID идентификатор базы для рядов метрик производительности сервера
+const SystemMetricsDatabaseID = 0
+
+// QualityMetricBaseline базовая линия ряда метрики базы данных
+type QualityMetricBaseline struct {
+	DatabaseID     int       `json:"database_id"`
+	Metric         string    `json:"metric"`
+	Mean           float64   `json:"mean"`
+	Variance       float64   `json:"variance"`
+	CUSUMHigh      float64   `json:"cusum_high"`
+	CUSUMLow       float64   `json:"cusum_low"`
+	Samples        int       `json:"samples"`
+	LastValue      float64   `json:"last_value"`
+	LastObservedAt time.Time `json:"last_observed_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// QualityAnomaly аномалия в ряду метрики: скачок или дрейф в неблагоприятную сторону
+type QualityAnomaly struct {
+	ID             int        `json:"id"`
+	DatabaseID     int        `json:"database_id"`
+	Metric         string     `json:"metric"`
+	Detector       string     `json:"detector"`  // ewma или cusum
+	Direction      string     `json:"direction"` // up или down
+	Severity       string     `json:"severity"`
+	Status         string     `json:"status"`
+	ObservedValue  float64    `json:"observed_value"`
+	ExpectedValue  float64    `json:"expected_value"`
+	Sigma          float64    `json:"sigma"`
+	Deviation      float64    `json:"deviation"` // отклонение в сигмах
+	UploadID       *int       `json:"upload_id,omitempty"`
+	Occurrences    int        `json:"occurrences"`
+	DetectedAt     time.Time  `json:"detected_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// QualityAnomalyFilter фильтр списка аномалий
+type QualityAnomalyFilter struct {
+	DatabaseID *int
+	Metric     string
+	Status     string
+	Severity   string
+	Limit      int
+	Offset     int
+}
+
+// QualityObservation значение ряда метрики
+type QualityObservation struct {
+	Metric     string
+	Value      float64
+	UploadID   int // 0 для дневных трендов и метрик производительности
+	ObservedAt time.Time
+}
+
+// CreateQualityAnomalyTables создает таблицы базовых линий метрик и аномалий
+func CreateQualityAnomalyTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS quality_metric_baselines (
+			database_id INTEGER NOT NULL,
+			metric TEXT NOT NULL,
+			mean REAL NOT NULL DEFAULT 0,
+			variance REAL NOT NULL DEFAULT 0,
+			cusum_high REAL NOT NULL DEFAULT 0,
+			cusum_low REAL NOT NULL DEFAULT 0,
+			samples INTEGER NOT NULL DEFAULT 0,
+			last_value REAL NOT NULL DEFAULT 0,
+			last_observed_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (database_id, metric)
+		);
+
+		CREATE TABLE IF NOT EXISTS quality_anomalies (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			database_id INTEGER NOT NULL,
+			metric TEXT NOT NULL,
+			detector TEXT NOT NULL,
+			direction TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			observed_value REAL NOT NULL,
+			expected_value REAL NOT NULL,
+			sigma REAL NOT NULL,
+			deviation REAL NOT NULL,
+			upload_id INTEGER,
+			occurrences INTEGER NOT NULL DEFAULT 1,
+			detected_at TIMESTAMP NOT NULL,
+			last_seen_at TIMESTAMP NOT NULL,
+			acknowledged_at TIMESTAMP,
+			resolved_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_quality_anomalies_db_metric ON quality_anomalies(database_id, metric, status);
+		CREATE INDEX IF NOT EXISTS idx_quality_anomalies_status ON quality_anomalies(status, severity);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create quality anomaly tables: %w", err)
+	}
+	return nil
+}
+
+// GetQualityMetricBaselines возвращает базовые линии всех рядов базы данных
+func (db *DB) GetQualityMetricBaselines(databaseID int) ([]*QualityMetricBaseline, error) {
+	rows, err := db.conn.Query(`
+		SELECT database_id, metric, mean, variance, cusum_high, cusum_low, samples,
+		       last_value, last_observed_at, updated_at
+		FROM quality_metric_baselines
+		WHERE database_id = ?
+		ORDER BY metric
+	`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quality metric baselines: %w", err)
+	}
+	defer rows.Close()
+
+	var baselines []*QualityMetricBaseline
+	for rows.Next() {
+		b := &QualityMetricBaseline{}
+		if err := rows.Scan(&b.DatabaseID, &b.Metric, &b.Mean, &b.Variance, &b.CUSUMHigh, &b.CUSUMLow,
+			&b.Samples, &b.LastValue, &b.LastObservedAt, &b.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan quality metric baseline: %w", err)
+		}
+		baselines = append(baselines, b)
+	}
+	return baselines, rows.Err()
+}
+
+// SaveQualityMetricBaseline сохраняет базовую линию ряда
+func (db *DB) SaveQualityMetricBaseline(b *QualityMetricBaseline) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO quality_metric_baselines (
+			database_id, metric, mean, variance, cusum_high, cusum_low, samples,
+			last_value, last_observed_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(database_id, metric) DO UPDATE SET
+			mean = excluded.mean, variance = excluded.variance,
+			cusum_high = excluded.cusum_high, cusum_low = excluded.cusum_low,
+			samples = excluded.samples, last_value = excluded.last_value,
+			last_observed_at = excluded.last_observed_at, updated_at = CURRENT_TIMESTAMP
+	`, b.DatabaseID, b.Metric, b.Mean, b.Variance, b.CUSUMHigh, b.CUSUMLow, b.Samples,
+		b.LastValue, b.LastObservedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save quality metric baseline: %w", err)
+	}
+	return nil
+}
+
+// GetQualityObservations возвращает значения рядов базы данных начиная с since в хронологическом порядке:
+// метрики выгрузок из data_quality_metrics, число проблем каждого типа на выгрузку (issues.<тип>)
+// и баллы завершенных дней из quality_trends (trend.<балл>)
+func (db *DB) GetQualityObservations(databaseID int, since time.Time) ([]QualityObservation, error) {
+	var observations []QualityObservation
+
+	rows, err := db.conn.Query(`
+		SELECT upload_id, metric_name, metric_value, measured_at
+		FROM data_quality_metrics
+		WHERE database_id = ? AND measured_at >= ?
+		ORDER BY measured_at, id
+	`, databaseID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quality metrics: %w", err)
+	}
+	uploadTimes := make(map[int]time.Time)
+	for rows.Next() {
+		var obs QualityObservation
+		if err := rows.Scan(&obs.UploadID, &obs.Metric, &obs.Value, &obs.ObservedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan quality metric: %w", err)
+		}
+		observations = append(observations, obs)
+		if obs.ObservedAt.After(uploadTimes[obs.UploadID]) {
+			uploadTimes[obs.UploadID] = obs.ObservedAt
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read quality metrics: %w", err)
+	}
+
+	issueObservations, err := db.getIssueCountObservations(databaseID, uploadTimes)
+	if err != nil {
+		return nil, err
+	}
+	observations = append(observations, issueObservations...)
+
+	rows, err = db.conn.Query(`
+		SELECT measurement_date, overall_score, COALESCE(issues_count, 0)
+		FROM quality_trends
+		WHERE database_id = ? AND measurement_date >= date(?) AND measurement_date < date('now')
+		ORDER BY measurement_date
+	`, databaseID, since.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quality trends: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date time.Time
+		var overall float64
+		var issues int
+		if err := rows.Scan(&date, &overall, &issues); err != nil {
+			return nil, fmt.Errorf("failed to scan quality trend: %w", err)
+		}
+		// Значение дня известно полностью только после его окончания
+		observedAt := date.AddDate(0, 0, 1)
+		observations = append(observations,
+			QualityObservation{Metric: "trend.overall_score", Value: overall, ObservedAt: observedAt},
+			QualityObservation{Metric: "trend.issues_count", Value: float64(issues), ObservedAt: observedAt},
+		)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read quality trends: %w", err)
+	}
+
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].ObservedAt.Before(observations[j].ObservedAt)
+	})
+	return observations, nil
+}
+
+// getIssueCountObservations считает проблемы каждого типа в выгрузках. Типы, встречавшиеся в базе,
+// но отсутствующие в выгрузке, дают нулевое значение, иначе ряд не заметит всплеск после нулей.
+func (db *DB) getIssueCountObservations(databaseID int, uploadTimes map[int]time.Time) ([]QualityObservation, error) {
+	if len(uploadTimes) == 0 {
+		return nil, nil
+	}
+
+	rows, err := db.conn.Query(`SELECT DISTINCT issue_type FROM data_quality_issues WHERE database_id = ?`, databaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query issue types: %w", err)
+	}
+	var issueTypes []string
+	for rows.Next() {
+		var issueType string
+		if err := rows.Scan(&issueType); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan issue type: %w", err)
+		}
+		issueTypes = append(issueTypes, issueType)
+	}
+	rows.Close()
+	if len(issueTypes) == 0 {
+		return nil, nil
+	}
+
+	uploadIDs := make([]interface{}, 0, len(uploadTimes))
+	for uploadID := range uploadTimes {
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+	rows, err = db.conn.Query(`
+		SELECT upload_id, issue_type, COUNT(*)
+		FROM data_quality_issues
+		WHERE upload_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(uploadIDs)), ",")+`)
+		GROUP BY upload_id, issue_type
+	`, uploadIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count quality issues: %w", err)
+	}
+	defer rows.Close()
+	counts := make(map[int]map[string]int)
+	for rows.Next() {
+		var uploadID, count int
+		var issueType string
+		if err := rows.Scan(&uploadID, &issueType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan issue count: %w", err)
+		}
+		if counts[uploadID] == nil {
+			counts[uploadID] = make(map[string]int)
+		}
+		counts[uploadID][issueType] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read issue counts: %w", err)
+	}
+
+	observations := make([]QualityObservation, 0, len(uploadTimes)*len(issueTypes))
+	for uploadID, observedAt := range uploadTimes {
+		for _, issueType := range issueTypes {
+			observations = append(observations, QualityObservation{
+				Metric:     "issues." + issueType,
+				Value:      float64(counts[uploadID][issueType]),
+				UploadID:   uploadID,
+				ObservedAt: observedAt,
+			})
+		}
+	}
+	return observations, nil
+}
+
+// GetPerformanceObservations возвращает средние за завершенные часы показатели AI и кэша
+// из performance_metrics_history. Учитываются только снимки с ненулевой пропускной способностью,
+// иначе простой сервера выглядел бы как падение показателей.
+func (db *DB) GetPerformanceObservations(since time.Time) ([]QualityObservation, error) {
+	rows, err := db.conn.Query(`
+		SELECT strftime('%Y-%m-%d %H:00:00', timestamp) AS hour,
+		       AVG(ai_success_rate), AVG(cache_hit_rate)
+		FROM performance_metrics_history
+		WHERE timestamp >= ? AND COALESCE(throughput, 0) > 0
+		GROUP BY hour
+		HAVING hour < strftime('%Y-%m-%d %H:00:00', 'now')
+		ORDER BY hour
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query performance metrics: %w", err)
+	}
+	defer rows.Close()
+
+	var observations []QualityObservation
+	for rows.Next() {
+		var hour string
+		var aiSuccessRate, cacheHitRate sql.NullFloat64
+		if err := rows.Scan(&hour, &aiSuccessRate, &cacheHitRate); err != nil {
+			return nil, fmt.Errorf("failed to scan performance metrics: %w", err)
+		}
+		start, err := time.Parse("2006-01-02 15:04:05", hour)
+		if err != nil {
+			continue
+		}
+		observedAt := start.Add(time.Hour)
+		if aiSuccessRate.Valid {
+			observations = append(observations, QualityObservation{Metric: "perf.ai_success_rate", Value: aiSuccessRate.Float64, ObservedAt: observedAt})
+		}
+		if cacheHitRate.Valid {
+			observations = append(observations, QualityObservation{Metric: "perf.cache_hit_rate", Value: cacheHitRate.Float64, ObservedAt: observedAt})
+		}
+	}
+	return observations, rows.Err()
+}
+
+// CreateQualityAnomaly сохраняет новую аномалию
+func (db *DB) CreateQualityAnomaly(a *QualityAnomaly) error {
+	if a.Status == "" {
+		a.Status = QualityAnomalyStatusOpen
+	}
+	if a.Occurrences == 0 {
+		a.Occurrences = 1
+	}
+	if a.LastSeenAt.IsZero() {
+		a.LastSeenAt = a.DetectedAt
+	}
+	result, err := db.conn.Exec(`
+		INSERT INTO quality_anomalies (
+			database_id, metric, detector, direction, severity, status,
+			observed_value, expected_value, sigma, deviation, upload_id,
+			occurrences, detected_at, last_seen_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.DatabaseID, a.Metric, a.Detector, a.Direction, a.Severity, a.Status,
+		a.ObservedValue, a.ExpectedValue, a.Sigma, a.Deviation, a.UploadID,
+		a.Occurrences, a.DetectedAt, a.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to create quality anomaly: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quality anomaly id: %w", err)
+	}
+	a.ID = int(id)
+	return nil
+}
+
+// UpdateQualityAnomalyOccurrence записывает повторное срабатывание: последнее значение, важность и счетчик
+func (db *DB) UpdateQualityAnomalyOccurrence(a *QualityAnomaly) error {
+	_, err := db.conn.Exec(`
+		UPDATE quality_anomalies
+		SET observed_value = ?, deviation = ?, severity = ?, upload_id = ?,
+		    occurrences = ?, last_seen_at = ?
+		WHERE id = ?
+	`, a.ObservedValue, a.Deviation, a.Severity, a.UploadID, a.Occurrences, a.LastSeenAt, a.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update quality anomaly: %w", err)
+	}
+	return nil
+}
+
+// UpdateQualityAnomalyStatus меняет статус аномалии. Возвращает sql.ErrNoRows, если аномалии нет.
+func (db *DB) UpdateQualityAnomalyStatus(id int, status string, at time.Time) error {
+	query := `UPDATE quality_anomalies SET status = ? WHERE id = ?`
+	args := []interface{}{status, id}
+	switch status {
+	case QualityAnomalyStatusAcknowledged:
+		query = `UPDATE quality_anomalies SET status = ?, acknowledged_at = ? WHERE id = ?`
+		args = []interface{}{status, at, id}
+	case QualityAnomalyStatusResolved:
+		query = `UPDATE quality_anomalies SET status = ?, resolved_at = ? WHERE id = ?`
+		args = []interface{}{status, at, id}
+	}
+	result, err := db.conn.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update quality anomaly status: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const qualityAnomalyColumns = `
+	id, database_id, metric, detector, direction, severity, status,
+	observed_value, expected_value, sigma, deviation, upload_id,
+	occurrences, detected_at, last_seen_at, acknowledged_at, resolved_at`
+
+func scanQualityAnomaly(scanner interface{ Scan(...interface{}) error }) (*QualityAnomaly, error) {
+	a := &QualityAnomaly{}
+	var uploadID sql.NullInt64
+	var acknowledgedAt, resolvedAt sql.NullTime
+	if err := scanner.Scan(&a.ID, &a.DatabaseID, &a.Metric, &a.Detector, &a.Direction, &a.Severity, &a.Status,
+		&a.ObservedValue, &a.ExpectedValue, &a.Sigma, &a.Deviation, &uploadID,
+		&a.Occurrences, &a.DetectedAt, &a.LastSeenAt, &acknowledgedAt, &resolvedAt); err != nil {
+		return nil, err
+	}
+	if uploadID.Valid {
+		id := int(uploadID.Int64)
+		a.UploadID = &id
+	}
+	if acknowledgedAt.Valid {
+		a.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if resolvedAt.Valid {
+		a.ResolvedAt = &resolvedAt.Time
+	}
+	return a, nil
+}
+
+// GetQualityAnomaly возвращает аномалию по ID или nil, если ее нет
+func (db *DB) GetQualityAnomaly(id int) (*QualityAnomaly, error) {
+	a, err := scanQualityAnomaly(db.conn.QueryRow(`SELECT `+qualityAnomalyColumns+` FROM quality_anomalies WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quality anomaly: %w", err)
+	}
+	return a, nil
+}
+
+// GetActiveQualityAnomalies возвращает незакрытые (open и acknowledged) аномалии базы данных
+func (db *DB) GetActiveQualityAnomalies(databaseID int) ([]*QualityAnomaly, error) {
+	rows, err := db.conn.Query(`SELECT `+qualityAnomalyColumns+`
+		FROM quality_anomalies
+		WHERE database_id = ? AND status != ?
+		ORDER BY id`, databaseID, QualityAnomalyStatusResolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active quality anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	var anomalies []*QualityAnomaly
+	for rows.Next() {
+		a, err := scanQualityAnomaly(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quality anomaly: %w", err)
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, rows.Err()
+}
+
+// GetQualityAnomalies возвращает аномалии по фильтру (новые первыми) и их общее число
+func (db *DB) GetQualityAnomalies(filter QualityAnomalyFilter) ([]*QualityAnomaly, int, error) {
+	where := []string{"1=1"}
+	var args []interface{}
+	if filter.DatabaseID != nil {
+		where = append(where, "database_id = ?")
+		args = append(args, *filter.DatabaseID)
+	}
+	if filter.Metric != "" {
+		where = append(where, "metric = ?")
+		args = append(args, filter.Metric)
+	}
+	if filter.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Severity != "" {
+		where = append(where, "severity = ?")
+		args = append(args, filter.Severity)
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int
+	if err := db.conn.QueryRow(`SELECT COUNT(*) FROM quality_anomalies WHERE `+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count quality anomalies: %w", err)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	rows, err := db.conn.Query(`SELECT `+qualityAnomalyColumns+`
+		FROM quality_anomalies
+		WHERE `+whereClause+`
+		ORDER BY last_seen_at DESC, id DESC
+		LIMIT ? OFFSET ?`, append(args, limit, filter.Offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query quality anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	anomalies := []*QualityAnomaly{}
+	for rows.Next() {
+		a, err := scanQualityAnomaly(rows)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan quality anomaly: %w", err)
+		}
+		anomalies = append(anomalies, a)
+	}
+	return anomalies, total, rows.Err()
+}
+
+// CountOpenQualityAnomalies возвращает число открытых аномалий по важности
+func (db *DB) CountOpenQualityAnomalies() (map[string]int, error) {
+	rows, err := db.conn.Query(`
+		SELECT severity, COUNT(*) FROM quality_anomalies
+		WHERE status = ?
+		GROUP BY severity
+	`, QualityAnomalyStatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count open quality anomalies: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var severity string
+		var count int
+		if err := rows.Scan(&severity, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan anomaly count: %w", err)
+		}
+		counts[severity] = count
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,535 @@
+package database
+
+import (
+	"bufio"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Имена файлов справочников реквизитов (TSV: значения через табуляцию, строки с # - комментарии)
+const (
+	RequisiteRegistryBanks      = "bik.tsv"
+	RequisiteRegistryKPPReasons = "kpp_reasons.tsv"
+	RequisiteRegistryRegions    = "tax_regions.tsv"
+	RequisiteRegistryTaxOffices = "tax_offices.tsv"
+)
+
+// Статусы участника расчетов в справочнике БИК
+const (
+	BankStatusActive     = "active"
+	BankStatusRevoked    = "revoked"    // отозвана лицензия
+	BankStatusLiquidated = "liquidated" // исключен из справочника
+)
+
+// requisiteRegistryFiles поставляемые вместе с сервером справочники
+//
+//go:embed requisite_registries/*.tsv
+var requisiteRegistryFiles embed.FS
+
+// BankDirectoryEntry запись справочника БИК (ЦБ РФ) или кодов банков (НБ РК)
+type BankDirectoryEntry struct {
+	BIK         string `json:"bik"`     // 9 цифр для РФ, BIC (8 или 11 символов) для РК
+	Country     string `json:"country"` // RU или KZ
+	Name        string `json:"name"`
+	CorrAccount string `json:"corr_account"` // корреспондентский счет в Банке России (РФ)
+	BankCode    string `json:"bank_code"`    // 3-значный код банка в IBAN (РК)
+	Status      string `json:"status"`       // active, revoked, liquidated
+}
+
+// KPPReasonEntry код причины постановки на учет (позиции 5-6 КПП)
+type KPPReasonEntry struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	CrossRegion bool   `json:"cross_region"` // регион КПП может отличаться от региона ИНН
+}
+
+// TaxRegionEntry код субъекта РФ (позиции 1-2 ИНН и КПП)
+type TaxRegionEntry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// TaxOfficeEntry код налогового органа (позиции 1-4 КПП)
+type TaxOfficeEntry struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	RegionCode string `json:"region_code"`
+}
+
+// RequisiteRegistryData содержимое справочников реквизитов
+type RequisiteRegistryData struct {
+	// BanksComplete справочник БИК полный: отсутствие БИК в нем считается нарушением.
+	// Поставляемая выборка неполная, полный справочник загружается из выгрузки ЦБ РФ.
+	BanksComplete bool
+
+	Banks      []BankDirectoryEntry
+	KPPReasons []KPPReasonEntry
+	Regions    []TaxRegionEntry
+	TaxOffices []TaxOfficeEntry
+}
+
+// RequisiteRegistryVersion сведения о загруженной версии справочника
+type RequisiteRegistryVersion struct {
+	Registry string    `json:"registry"`
+	Source   string    `json:"source"`
+	Entries  int       `json:"entries"`
+	Complete bool      `json:"complete"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// CreateRequisiteRegistryTables создает таблицы справочников БИК, кодов причин КПП и налоговых органов
+func CreateRequisiteRegistryTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS requisite_bank_directory (
+			bik TEXT PRIMARY KEY,
+			country TEXT NOT NULL DEFAULT 'RU',
+			name TEXT NOT NULL DEFAULT '',
+			corr_account TEXT NOT NULL DEFAULT '',
+			bank_code TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'active'
+		);
+		CREATE INDEX IF NOT EXISTS idx_requisite_bank_directory_code ON requisite_bank_directory(country, bank_code);
+
+		CREATE TABLE IF NOT EXISTS requisite_kpp_reasons (
+			code TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			cross_region INTEGER NOT NULL DEFAULT 0
+		);
+
+		CREATE TABLE IF NOT EXISTS requisite_tax_regions (
+			code TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS requisite_tax_offices (
+			code TEXT PRIMARY KEY,
+			name TEXT NOT NULL DEFAULT '',
+			region_code TEXT NOT NULL DEFAULT ''
+		);
+
+		CREATE TABLE IF NOT EXISTS requisite_registry_versions (
+			registry TEXT PRIMARY KEY,
+			source TEXT NOT NULL DEFAULT '',
+			entries INTEGER NOT NULL DEFAULT 0,
+			complete INTEGER NOT NULL DEFAULT 1,
+			loaded_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create requisite registry tables: %w", err)
+	}
+	return nil
+}
+
+// readRegistryTSV читает строки TSV справочника, пропуская пустые строки и комментарии
+func readRegistryTSV(r io.Reader, fn func(lineNum int, fields []string) error) error {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		if fields[0] == "" {
+			log.Printf("Warning: registry line %d has empty code: %s", lineNum, line)
+			continue
+		}
+		for len(fields) < 8 {
+			fields = append(fields, "")
+		}
+		if err := fn(lineNum, fields); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ParseBankDirectory парсит справочник БИК: bik, country, name, corr_account, bank_code, status
+func ParseBankDirectory(r io.Reader) ([]BankDirectoryEntry, error) {
+	entries := []BankDirectoryEntry{}
+	err := readRegistryTSV(r, func(lineNum int, fields []string) error {
+		entry := BankDirectoryEntry{
+			BIK:         strings.ToUpper(fields[0]),
+			Country:     strings.ToUpper(fields[1]),
+			Name:        fields[2],
+			CorrAccount: fields[3],
+			BankCode:    fields[4],
+			Status:      strings.ToLower(fields[5]),
+		}
+		if entry.Country == "" {
+			entry.Country = "RU"
+		}
+		if entry.Status == "" {
+			entry.Status = BankStatusActive
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bank directory: %w", err)
+	}
+	return entries, nil
+}
+
+// ParseKPPReasons парсит коды причин постановки на учет: code, name, cross_region.
+// Код может быть задан диапазоном "51-99".
+func ParseKPPReasons(r io.Reader) ([]KPPReasonEntry, error) {
+	entries := []KPPReasonEntry{}
+	err := readRegistryTSV(r, func(lineNum int, fields []string) error {
+		crossRegion := fields[2] == "1" || strings.EqualFold(fields[2], "true")
+
+		from, to := fields[0], fields[0]
+		if idx := strings.Index(fields[0], "-"); idx > 0 {
+			from, to = fields[0][:idx], fields[0][idx+1:]
+		}
+		start, errFrom := strconv.Atoi(from)
+		end, errTo := strconv.Atoi(to)
+		if errFrom != nil || errTo != nil || start > end {
+			// Буквенные коды причин (с 2013 года) задаются без диапазонов
+			entries = append(entries, KPPReasonEntry{Code: strings.ToUpper(fields[0]), Name: fields[1], CrossRegion: crossRegion})
+			return nil
+		}
+		for code := start; code <= end; code++ {
+			entries = append(entries, KPPReasonEntry{Code: fmt.Sprintf("%02d", code), Name: fields[1], CrossRegion: crossRegion})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KPP reasons: %w", err)
+	}
+	return entries, nil
+}
+
+// ParseTaxRegions парсит коды субъектов РФ: code, name
+func ParseTaxRegions(r io.Reader) ([]TaxRegionEntry, error) {
+	entries := []TaxRegionEntry{}
+	err := readRegistryTSV(r, func(lineNum int, fields []string) error {
+		entries = append(entries, TaxRegionEntry{Code: fields[0], Name: fields[1]})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax regions: %w", err)
+	}
+	return entries, nil
+}
+
+// ParseTaxOffices парсит коды налоговых органов: code, name, region_code
+func ParseTaxOffices(r io.Reader) ([]TaxOfficeEntry, error) {
+	entries := []TaxOfficeEntry{}
+	err := readRegistryTSV(r, func(lineNum int, fields []string) error {
+		entry := TaxOfficeEntry{Code: fields[0], Name: fields[1], RegionCode: fields[2]}
+		if entry.RegionCode == "" && len(entry.Code) >= 2 {
+			entry.RegionCode = entry.Code[:2]
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax offices: %w", err)
+	}
+	return entries, nil
+}
+
+// parseRequisiteRegistry парсит справочник по имени файла в RequisiteRegistryData
+func parseRequisiteRegistry(name string, r io.Reader, data *RequisiteRegistryData) error {
+	var err error
+	switch name {
+	case RequisiteRegistryBanks:
+		data.Banks, err = ParseBankDirectory(r)
+	case RequisiteRegistryKPPReasons:
+		data.KPPReasons, err = ParseKPPReasons(r)
+	case RequisiteRegistryRegions:
+		data.Regions, err = ParseTaxRegions(r)
+	case RequisiteRegistryTaxOffices:
+		data.TaxOffices, err = ParseTaxOffices(r)
+	default:
+		return fmt.Errorf("unknown requisite registry: %s", name)
+	}
+	return err
+}
+
+// BundledRequisiteRegistries возвращает справочники, поставляемые вместе с сервером
+func BundledRequisiteRegistries() (*RequisiteRegistryData, error) {
+	data := &RequisiteRegistryData{}
+	for _, name := range []string{RequisiteRegistryBanks, RequisiteRegistryKPPReasons, RequisiteRegistryRegions, RequisiteRegistryTaxOffices} {
+		file, err := requisiteRegistryFiles.Open("requisite_registries/" + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bundled registry %s: %w", name, err)
+		}
+		err = parseRequisiteRegistry(name, file, data)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// ParseRequisiteRegistryDir читает справочники из каталога. Отсутствующие файлы пропускаются,
+// соответствующие срезы в результате остаются nil.
+func ParseRequisiteRegistryDir(dir string) (*RequisiteRegistryData, error) {
+	data := &RequisiteRegistryData{}
+	found := 0
+	for _, name := range []string{RequisiteRegistryBanks, RequisiteRegistryKPPReasons, RequisiteRegistryRegions, RequisiteRegistryTaxOffices} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open registry file %s: %w", name, err)
+		}
+		err = parseRequisiteRegistry(name, file, data)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		found++
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("no requisite registry files found in %s", dir)
+	}
+	data.BanksComplete = data.Banks != nil
+	return data, nil
+}
+
+// LoadRequisiteRegistriesToDatabase заменяет содержимое справочников, переданных в data.
+// Справочники с nil-срезом не изменяются, что позволяет обновлять их по отдельности.
+func LoadRequisiteRegistriesToDatabase(db DBConnection, data *RequisiteRegistryData, source string) error {
+	tx, err := db.GetDB().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	type registryLoad struct {
+		name    string
+		table   string
+		insert  string
+		entries int
+		partial bool
+		exec    func(stmt *sql.Stmt) error
+	}
+
+	loads := []registryLoad{}
+	if data.Banks != nil {
+		loads = append(loads, registryLoad{
+			name:    RequisiteRegistryBanks,
+			table:   "requisite_bank_directory",
+			insert:  `INSERT OR REPLACE INTO requisite_bank_directory (bik, country, name, corr_account, bank_code, status) VALUES (?, ?, ?, ?, ?, ?)`,
+			entries: len(data.Banks),
+			partial: !data.BanksComplete,
+			exec: func(stmt *sql.Stmt) error {
+				for _, e := range data.Banks {
+					if _, err := stmt.Exec(e.BIK, e.Country, e.Name, e.CorrAccount, e.BankCode, e.Status); err != nil {
+						return fmt.Errorf("failed to insert BIK %s: %w", e.BIK, err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+	if data.KPPReasons != nil {
+		loads = append(loads, registryLoad{
+			name:    RequisiteRegistryKPPReasons,
+			table:   "requisite_kpp_reasons",
+			insert:  `INSERT OR REPLACE INTO requisite_kpp_reasons (code, name, cross_region) VALUES (?, ?, ?)`,
+			entries: len(data.KPPReasons),
+			exec: func(stmt *sql.Stmt) error {
+				for _, e := range data.KPPReasons {
+					if _, err := stmt.Exec(e.Code, e.Name, e.CrossRegion); err != nil {
+						return fmt.Errorf("failed to insert KPP reason %s: %w", e.Code, err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+	if data.Regions != nil {
+		loads = append(loads, registryLoad{
+			name:    RequisiteRegistryRegions,
+			table:   "requisite_tax_regions",
+			insert:  `INSERT OR REPLACE INTO requisite_tax_regions (code, name) VALUES (?, ?)`,
+			entries: len(data.Regions),
+			exec: func(stmt *sql.Stmt) error {
+				for _, e := range data.Regions {
+					if _, err := stmt.Exec(e.Code, e.Name); err != nil {
+						return fmt.Errorf("failed to insert tax region %s: %w", e.Code, err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+	if data.TaxOffices != nil {
+		loads = append(loads, registryLoad{
+			name:    RequisiteRegistryTaxOffices,
+			table:   "requisite_tax_offices",
+			insert:  `INSERT OR REPLACE INTO requisite_tax_offices (code, name, region_code) VALUES (?, ?, ?)`,
+			entries: len(data.TaxOffices),
+			exec: func(stmt *sql.Stmt) error {
+				for _, e := range data.TaxOffices {
+					if _, err := stmt.Exec(e.Code, e.Name, e.RegionCode); err != nil {
+						return fmt.Errorf("failed to insert tax office %s: %w", e.Code, err)
+					}
+				}
+				return nil
+			},
+		})
+	}
+
+	for _, load := range loads {
+		if _, err := tx.Exec("DELETE FROM " + load.table); err != nil {
+			return fmt.Errorf("failed to clear %s table: %w", load.table, err)
+		}
+		stmt, err := tx.Prepare(load.insert)
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
+		}
+		err = load.exec(stmt)
+		stmt.Close()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO requisite_registry_versions (registry, source, entries, complete, loaded_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, load.name, source, load.entries, !load.partial, time.Now()); err != nil {
+			return fmt.Errorf("failed to save registry version: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Printf("Successfully loaded %d requisite registries from %s", len(loads), source)
+	return nil
+}
+
+// LoadRequisiteRegistriesFromDir - вспомогательная функция для загрузки справочников из каталога в БД
+func LoadRequisiteRegistriesFromDir(db DBConnection, dir string) error {
+	log.Printf("Loading requisite registries from directory: %s", dir)
+
+	data, err := ParseRequisiteRegistryDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to parse requisite registries: %w", err)
+	}
+
+	if err := LoadRequisiteRegistriesToDatabase(db, data, dir); err != nil {
+		return fmt.Errorf("failed to load requisite registries to database: %w", err)
+	}
+	return nil
+}
+
+// LoadBundledRequisiteRegistries загружает в БД справочники, поставляемые вместе с сервером
+func LoadBundledRequisiteRegistries(db DBConnection) error {
+	data, err := BundledRequisiteRegistries()
+	if err != nil {
+		return err
+	}
+	return LoadRequisiteRegistriesToDatabase(db, data, "bundled")
+}
+
+// GetRequisiteRegistries читает все справочники реквизитов из БД
+func GetRequisiteRegistries(db DBConnection) (*RequisiteRegistryData, error) {
+	conn := db.GetDB()
+	data := &RequisiteRegistryData{}
+
+	err := conn.QueryRow(`SELECT complete FROM requisite_registry_versions WHERE registry = ?`, RequisiteRegistryBanks).Scan(&data.BanksComplete)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to query bank directory version: %w", err)
+	}
+
+	rows, err := conn.Query(`SELECT bik, country, name, corr_account, bank_code, status FROM requisite_bank_directory`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query bank directory: %w", err)
+	}
+	for rows.Next() {
+		var e BankDirectoryEntry
+		if err := rows.Scan(&e.BIK, &e.Country, &e.Name, &e.CorrAccount, &e.BankCode, &e.Status); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan bank directory entry: %w", err)
+		}
+		data.Banks = append(data.Banks, e)
+	}
+	rows.Close()
+
+	rows, err = conn.Query(`SELECT code, name, cross_region FROM requisite_kpp_reasons`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query KPP reasons: %w", err)
+	}
+	for rows.Next() {
+		var e KPPReasonEntry
+		if err := rows.Scan(&e.Code, &e.Name, &e.CrossRegion); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan KPP reason: %w", err)
+		}
+		data.KPPReasons = append(data.KPPReasons, e)
+	}
+	rows.Close()
+
+	rows, err = conn.Query(`SELECT code, name FROM requisite_tax_regions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tax regions: %w", err)
+	}
+	for rows.Next() {
+		var e TaxRegionEntry
+		if err := rows.Scan(&e.Code, &e.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan tax region: %w", err)
+		}
+		data.Regions = append(data.Regions, e)
+	}
+	rows.Close()
+
+	rows, err = conn.Query(`SELECT code, name, region_code FROM requisite_tax_offices`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tax offices: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e TaxOfficeEntry
+		if err := rows.Scan(&e.Code, &e.Name, &e.RegionCode); err != nil {
+			return nil, fmt.Errorf("failed to scan tax office: %w", err)
+		}
+		data.TaxOffices = append(data.TaxOffices, e)
+	}
+
+	return data, rows.Err()
+}
+
+// GetRequisiteRegistryVersions возвращает сведения о загруженных версиях справочников
+func GetRequisiteRegistryVersions(db DBConnection) ([]RequisiteRegistryVersion, error) {
+	rows, err := db.GetDB().Query(`SELECT registry, source, entries, complete, loaded_at FROM requisite_registry_versions ORDER BY registry`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []RequisiteRegistryVersion
+	for rows.Next() {
+		var v RequisiteRegistryVersion
+		if err := rows.Scan(&v.Registry, &v.Source, &v.Entries, &v.Complete, &v.LoadedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan registry version: %w", err)
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseKPPReasons_Ranges(t *testing.T) {
+	input := "# comment\n01\tПо месту нахождения\t0\n51-53\tИностранная организация\t1\n"
+	entries, err := ParseKPPReasons(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseKPPReasons: %v", err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 entries, got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Code != "01" || entries[0].CrossRegion {
+		t.Errorf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[3].Code != "53" || !entries[3].CrossRegion {
+		t.Errorf("unexpected last entry: %+v", entries[3])
+	}
+}
+
+func TestRequisiteRegistries_LoadAndUpdate(t *testing.T) {
+	db, err := NewServiceDB(filepath.Join(t.TempDir(), "service.db"))
+	if err != nil {
+		t.Fatalf("NewServiceDB: %v", err)
+	}
+	defer db.Close()
+
+	if err := LoadBundledRequisiteRegistries(db); err != nil {
+		t.Fatalf("LoadBundledRequisiteRegistries: %v", err)
+	}
+	data, err := GetRequisiteRegistries(db)
+	if err != nil {
+		t.Fatalf("GetRequisiteRegistries: %v", err)
+	}
+	if len(data.Regions) == 0 || len(data.Banks) == 0 || data.BanksComplete {
+		t.Fatalf("unexpected bundled data: %d regions, %d banks, complete=%v", len(data.Regions), len(data.Banks), data.BanksComplete)
+	}
+	regions := len(data.Regions)
+
+	// Обновление только справочника БИК не затрагивает остальные справочники
+	dir := t.TempDir()
+	bik := "044525225\tRU\tПАО Сбербанк\t30101810400000000225\t\tactive\n044525187\tRU\tБанк ВТБ\t30101810700000000187\t\trevoked\n"
+	if err := os.WriteFile(filepath.Join(dir, RequisiteRegistryBanks), []byte(bik), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadRequisiteRegistriesFromDir(db, dir); err != nil {
+		t.Fatalf("LoadRequisiteRegistriesFromDir: %v", err)
+	}
+
+	data, err = GetRequisiteRegistries(db)
+	if err != nil {
+		t.Fatalf("GetRequisiteRegistries: %v", err)
+	}
+	if len(data.Banks) != 2 || !data.BanksComplete {
+		t.Errorf("expected complete directory with 2 banks, got %d, complete=%v", len(data.Banks), data.BanksComplete)
+	}
+	if len(data.Regions) != regions {
+		t.Errorf("regions changed after bank directory update: %d -> %d", regions, len(data.Regions))
+	}
+
+	versions, err := GetRequisiteRegistryVersions(db)
+	if err != nil {
+		t.Fatalf("GetRequisiteRegistryVersions: %v", err)
+	}
+	for _, v := range versions {
+		if v.Registry == RequisiteRegistryBanks && (v.Source != dir || v.Entries != 2) {
+			t.Errorf("unexpected bank directory version: %+v", v)
+		}
+	}
+
+	if err := LoadRequisiteRegistriesFromDir(db, t.TempDir()); err == nil {
+		t.Error("expected error for directory without registry files")
+	}
+}
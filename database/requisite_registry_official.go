@@ -0,0 +1,358 @@
+package database
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Коды ED807, определяющие статус участника расчетов
+const (
+	ed807StatusDeleted      = "PSDL" // ParticipantStatus: участник исключается из справочника
+	ed807RstrLicenceRevoked = "LWRS" // RstrList/Rstr: отзыв (аннулирование) лицензии
+	ed807AccountCorr        = "CRSA" // Accounts/RegulationAccountType: корреспондентский счет
+	ed807AccountDeleted     = "ACDL" // Accounts/AccountStatus: счет закрыт
+)
+
+// ed807Entry запись BICDirectoryEntry электронного справочника БИК
+type ed807Entry struct {
+	BIC         string `xml:"BIC,attr"`
+	ChangeType  string `xml:"ChangeType,attr"`
+	Participant struct {
+		NameP  string `xml:"NameP,attr"`
+		Status string `xml:"ParticipantStatus,attr"`
+		Rstr   []struct {
+			Code string `xml:"Rstr,attr"`
+		} `xml:"RstrList"`
+	} `xml:"ParticipantInfo"`
+	Accounts []struct {
+		Account string `xml:"Account,attr"`
+		Type    string `xml:"RegulationAccountType,attr"`
+		Status  string `xml:"AccountStatus,attr"`
+	} `xml:"Accounts"`
+}
+
+// registryCharsetReader декодирует XML справочников в windows-1251, в которой их публикуют ЦБ РФ и ФНС
+func registryCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(label) {
+	case "windows-1251", "cp1251":
+		return charmap.Windows1251.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("unsupported registry encoding: %s", label)
+}
+
+// ParseCBRBankDirectory потоково парсит полный электронный справочник БИК Банка России (ED807).
+// Статус участника: PSDL - liquidated, ограничение LWRS (отзыв лицензии) - revoked, иначе active.
+// Изменения справочника (записи с ChangeType) не принимаются: загрузка заменяет справочник целиком.
+func ParseCBRBankDirectory(r io.Reader) ([]BankDirectoryEntry, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = registryCharsetReader
+
+	entries := []BankDirectoryEntry{}
+	rootSeen := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ED807: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "ED807":
+			rootSeen = true
+		case "BICDirectoryEntry":
+			var e ed807Entry
+			if err := decoder.DecodeElement(&e, &start); err != nil {
+				return nil, fmt.Errorf("failed to parse BICDirectoryEntry: %w", err)
+			}
+			if e.ChangeType != "" {
+				return nil, fmt.Errorf("ED807 with changes (ChangeType %s for BIC %s) is not supported, load the full directory", e.ChangeType, e.BIC)
+			}
+			entries = append(entries, e.toBankDirectoryEntry())
+		}
+	}
+	if !rootSeen {
+		return nil, fmt.Errorf("file is not an ED807 bank directory")
+	}
+	return entries, nil
+}
+
+// toBankDirectoryEntry преобразует запись ED807 в запись справочника БИК
+func (e *ed807Entry) toBankDirectoryEntry() BankDirectoryEntry {
+	entry := BankDirectoryEntry{
+		BIK:     strings.TrimSpace(e.BIC),
+		Country: "RU",
+		Name:    strings.TrimSpace(e.Participant.NameP),
+		Status:  BankStatusActive,
+	}
+	for _, rstr := range e.Participant.Rstr {
+		if rstr.Code == ed807RstrLicenceRevoked {
+			entry.Status = BankStatusRevoked
+		}
+	}
+	if e.Participant.Status == ed807StatusDeleted {
+		entry.Status = BankStatusLiquidated
+	}
+	for _, account := range e.Accounts {
+		if account.Type == ed807AccountCorr && account.Status != ed807AccountDeleted {
+			entry.CorrAccount = account.Account
+			break
+		}
+	}
+	return entry
+}
+
+// Имена полей кода и наименования налогового органа в выгрузках классификатора СОУН
+var (
+	fnsTaxOfficeCodeFields = []string{"КОД", "КОДНО", "CODE", "KOD", "KODNO"}
+	fnsTaxOfficeNameFields = []string{"НАИМ", "НАИМНО", "НАИМЕНОВАНИЕ", "NAME", "NAIM", "NAIMNO"}
+	fnsTaxOfficeCodeRe     = regexp.MustCompile(`^\d{4}$`)
+)
+
+// ParseFNSTaxOffices парсит перечень налоговых органов ФНС России (классификатор СОУН) в XML или CSV.
+// XML: записи - элементы с атрибутами кода и наименования (КОД/НАИМ, Код/Наим, CODE/NAME).
+// CSV (разделитель ";" или ","): первая строка - заголовок с теми же именами колонок.
+// Кодировка UTF-8 или windows-1251. Учитываются только коды из 4 цифр (позиции 1-4 КПП).
+func ParseFNSTaxOffices(r io.Reader) ([]TaxOfficeEntry, error) {
+	br := bufio.NewReader(r)
+	if bom, _ := br.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		_, _ = br.Discard(3)
+	}
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tax office list: %w", err)
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+		case '<':
+			return parseFNSTaxOfficesXML(br)
+		default:
+			return parseFNSTaxOfficesCSV(br)
+		}
+	}
+}
+
+// lookupField возвращает значение первого найденного поля из names (без учета регистра)
+func lookupField(values map[string]string, names []string) string {
+	for _, name := range names {
+		if v, ok := values[name]; ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// newTaxOfficeEntry создает запись налогового органа, если код корректен
+func newTaxOfficeEntry(code, name string) (TaxOfficeEntry, bool) {
+	if !fnsTaxOfficeCodeRe.MatchString(code) {
+		return TaxOfficeEntry{}, false
+	}
+	return TaxOfficeEntry{Code: code, Name: name, RegionCode: code[:2]}, true
+}
+
+// parseFNSTaxOfficesXML разбирает XML-выгрузку классификатора СОУН
+func parseFNSTaxOfficesXML(r io.Reader) ([]TaxOfficeEntry, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = registryCharsetReader
+
+	entries := []TaxOfficeEntry{}
+	seen := make(map[string]bool)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tax office list: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || len(start.Attr) == 0 {
+			continue
+		}
+		attrs := make(map[string]string, len(start.Attr))
+		for _, a := range start.Attr {
+			attrs[strings.ToUpper(a.Name.Local)] = a.Value
+		}
+		entry, ok := newTaxOfficeEntry(lookupField(attrs, fnsTaxOfficeCodeFields), lookupField(attrs, fnsTaxOfficeNameFields))
+		if ok && !seen[entry.Code] {
+			seen[entry.Code] = true
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no tax offices found: expected elements with code and name attributes (КОД, НАИМ)")
+	}
+	return entries, nil
+}
+
+// parseFNSTaxOfficesCSV разбирает CSV-выгрузку классификатора СОУН
+func parseFNSTaxOfficesCSV(r io.Reader) ([]TaxOfficeEntry, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax office list: %w", err)
+	}
+	if !utf8.Valid(content) {
+		if content, err = charmap.Windows1251.NewDecoder().Bytes(content); err != nil {
+			return nil, fmt.Errorf("failed to decode tax office list: %w", err)
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	if firstLine, _, _ := bytes.Cut(content, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tax office list header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToUpper(strings.TrimSpace(name))] = i
+	}
+	codeCol, nameCol := -1, -1
+	for _, name := range fnsTaxOfficeCodeFields {
+		if i, ok := columns[name]; ok && codeCol < 0 {
+			codeCol = i
+		}
+	}
+	for _, name := range fnsTaxOfficeNameFields {
+		if i, ok := columns[name]; ok && nameCol < 0 {
+			nameCol = i
+		}
+	}
+	if codeCol < 0 || nameCol < 0 {
+		return nil, fmt.Errorf("tax office list header must contain code and name columns (КОД, НАИМ), got %v", header)
+	}
+
+	entries := []TaxOfficeEntry{}
+	seen := make(map[string]bool)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tax office list: %w", err)
+		}
+		if codeCol >= len(record) || nameCol >= len(record) {
+			continue
+		}
+		entry, ok := newTaxOfficeEntry(strings.TrimSpace(record[codeCol]), strings.TrimSpace(record[nameCol]))
+		if ok && !seen[entry.Code] {
+			seen[entry.Code] = true
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no tax offices found in tax office list")
+	}
+	return entries, nil
+}
+
+// openRegistrySource открывает файл справочника. Из ZIP-архива (так ЦБ РФ публикует ED807)
+// берется первый файл с расширением .xml или .csv.
+func openRegistrySource(path string) (io.ReadCloser, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return os.Open(path)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range archive.File {
+		ext := strings.ToLower(filepath.Ext(f.Name))
+		if f.FileInfo().IsDir() || (ext != ".xml" && ext != ".csv") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			archive.Close()
+			return nil, err
+		}
+		return &zipEntryReader{ReadCloser: rc, archive: archive}, nil
+	}
+	archive.Close()
+	return nil, fmt.Errorf("archive %s contains no .xml or .csv file", path)
+}
+
+// zipEntryReader закрывает вместе с файлом и сам архив
+type zipEntryReader struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+// Close закрывает файл архива и архив
+func (z *zipEntryReader) Close() error {
+	err := z.ReadCloser.Close()
+	if cerr := z.archive.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ParseOfficialRequisiteRegistries читает справочник БИК ЦБ РФ (ED807) и перечень налоговых органов ФНС.
+// Пустой путь пропускает справочник, соответствующий срез остается nil. Справочник БИК из ED807 полный.
+func ParseOfficialRequisiteRegistries(ed807Path, taxOfficesPath string) (*RequisiteRegistryData, error) {
+	if ed807Path == "" && taxOfficesPath == "" {
+		return nil, fmt.Errorf("no official registry files specified")
+	}
+	data := &RequisiteRegistryData{}
+	if ed807Path != "" {
+		file, err := openRegistrySource(ed807Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open ED807 file: %w", err)
+		}
+		data.Banks, err = ParseCBRBankDirectory(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+		data.BanksComplete = true
+	}
+	if taxOfficesPath != "" {
+		file, err := openRegistrySource(taxOfficesPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open tax office list: %w", err)
+		}
+		data.TaxOffices, err = ParseFNSTaxOffices(file)
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// LoadOfficialRequisiteRegistries загружает в БД справочник БИК из ED807 и перечень налоговых органов ФНС
+func LoadOfficialRequisiteRegistries(db DBConnection, ed807Path, taxOfficesPath string) error {
+	data, err := ParseOfficialRequisiteRegistries(ed807Path, taxOfficesPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse official requisite registries: %w", err)
+	}
+	source := strings.Trim(strings.Join([]string{ed807Path, taxOfficesPath}, ", "), ", ")
+	if err := LoadRequisiteRegistriesToDatabase(db, data, source); err != nil {
+		return fmt.Errorf("failed to load requisite registries to database: %w", err)
+	}
+	return nil
+}
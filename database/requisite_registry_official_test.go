@@ -0,0 +1,144 @@
+package database
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// testED807 фрагмент полного справочника БИК в формате ЦБ РФ
+const testED807 = `<?xml version="1.0" encoding="WINDOWS-1251"?>
+<ED807 xmlns="urn:cbr-ru:ed:v2.0" EDNo="1" EDDate="2024-01-09" EDAuthor="4583001999" CreationReason="FCBD" InfoTypeCode="FIRR" BusinessDay="2024-01-09" DirectoryVersion="1">
+<BICDirectoryEntry BIC="044525225">
+<ParticipantInfo NameP="ПАО Сбербанк" RegN="1481" CntrCd="RU" Rgn="45" PtType="20" ParticipantStatus="PSAC"/>
+<SWBICS SWBIC="SABRRUMMXXX" DefaultSWBIC="1"/>
+<Accounts Account="30101810400000000225" RegulationAccountType="CRSA" CK="66" AccountCBRBIC="044525000" AccountStatus="ACAC"/>
+</BICDirectoryEntry>
+<BICDirectoryEntry BIC="044525001">
+<ParticipantInfo NameP="Банк с отозванной лицензией" PtType="20" ParticipantStatus="PSAC"><RstrList Rstr="LWRS" RstrDate="2023-12-01"/></ParticipantInfo>
+<Accounts Account="30101810000000000001" RegulationAccountType="CRSA" AccountStatus="ACDL"/>
+</BICDirectoryEntry>
+<BICDirectoryEntry BIC="044525002">
+<ParticipantInfo NameP="Исключенный банк" PtType="20" ParticipantStatus="PSDL"/>
+</BICDirectoryEntry>
+</ED807>`
+
+func encodeWindows1251(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := charmap.Windows1251.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatalf("encode windows-1251: %v", err)
+	}
+	return b
+}
+
+func TestParseCBRBankDirectory(t *testing.T) {
+	entries, err := ParseCBRBankDirectory(strings.NewReader(string(encodeWindows1251(t, testED807))))
+	if err != nil {
+		t.Fatalf("ParseCBRBankDirectory: %v", err)
+	}
+	want := []BankDirectoryEntry{
+		{BIK: "044525225", Country: "RU", Name: "ПАО Сбербанк", CorrAccount: "30101810400000000225", Status: BankStatusActive},
+		{BIK: "044525001", Country: "RU", Name: "Банк с отозванной лицензией", Status: BankStatusRevoked},
+		{BIK: "044525002", Country: "RU", Name: "Исключенный банк", Status: BankStatusLiquidated},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %+v", len(want), len(entries), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	changes := `<ED807><BICDirectoryEntry BIC="044525225" ChangeType="CHGD"><ParticipantInfo NameP="Банк"/></BICDirectoryEntry></ED807>`
+	if _, err := ParseCBRBankDirectory(strings.NewReader(changes)); err == nil {
+		t.Error("expected error for ED807 with changes")
+	}
+	if _, err := ParseCBRBankDirectory(strings.NewReader(`<bik><item/></bik>`)); err == nil {
+		t.Error("expected error for file without ED807 root")
+	}
+}
+
+func TestParseFNSTaxOffices(t *testing.T) {
+	xmlList := `<?xml version="1.0" encoding="windows-1251"?>
+<Файл ИдФайл="SOUN"><СОУН КОД="7701" НАИМ="ИФНС России № 1 по г.Москве"/><СОУН КОД="7702" НАИМ="ИФНС России № 2 по г.Москве"/><СОУН КОД="77" НАИМ="УФНС России по г.Москве"/></Файл>`
+	entries, err := ParseFNSTaxOffices(strings.NewReader(string(encodeWindows1251(t, xmlList))))
+	if err != nil {
+		t.Fatalf("ParseFNSTaxOffices(xml): %v", err)
+	}
+	if len(entries) != 2 || entries[0] != (TaxOfficeEntry{Code: "7701", Name: "ИФНС России № 1 по г.Москве", RegionCode: "77"}) {
+		t.Errorf("unexpected xml entries: %+v", entries)
+	}
+
+	csvList := "\ufeffКод;Наименование;Адрес\r\n5001;ИФНС России по г.Балашихе;г. Балашиха\r\n5001;дубль;\r\n50;УФНС России по Московской области;\r\n"
+	entries, err = ParseFNSTaxOffices(strings.NewReader(csvList))
+	if err != nil {
+		t.Fatalf("ParseFNSTaxOffices(csv): %v", err)
+	}
+	if len(entries) != 1 || entries[0].Code != "5001" || entries[0].RegionCode != "50" || entries[0].Name != "ИФНС России по г.Балашихе" {
+		t.Errorf("unexpected csv entries: %+v", entries)
+	}
+
+	if _, err := ParseFNSTaxOffices(strings.NewReader("a,b\n1,2\n")); err == nil {
+		t.Error("expected error for csv without code and name columns")
+	}
+}
+
+func TestLoadOfficialRequisiteRegistries(t *testing.T) {
+	db, err := NewServiceDB(filepath.Join(t.TempDir(), "service.db"))
+	if err != nil {
+		t.Fatalf("NewServiceDB: %v", err)
+	}
+	defer db.Close()
+	if err := LoadBundledRequisiteRegistries(db); err != nil {
+		t.Fatalf("LoadBundledRequisiteRegistries: %v", err)
+	}
+
+	// ЦБ РФ публикует ED807 в ZIP-архиве
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "20240109_ED807_full.zip")
+	archiveFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(archiveFile)
+	w, err := zw.Create("20240109_ED807_full.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(encodeWindows1251(t, testED807)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	archiveFile.Close()
+
+	taxOfficesPath := filepath.Join(dir, "soun.csv")
+	if err := os.WriteFile(taxOfficesPath, []byte("KOD,NAIM\n7701,ИФНС России № 1 по г.Москве\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadOfficialRequisiteRegistries(db, archivePath, taxOfficesPath); err != nil {
+		t.Fatalf("LoadOfficialRequisiteRegistries: %v", err)
+	}
+	data, err := GetRequisiteRegistries(db)
+	if err != nil {
+		t.Fatalf("GetRequisiteRegistries: %v", err)
+	}
+	if len(data.Banks) != 3 || !data.BanksComplete {
+		t.Errorf("expected complete directory with 3 banks, got %d, complete=%v", len(data.Banks), data.BanksComplete)
+	}
+	if len(data.TaxOffices) != 1 || len(data.KPPReasons) == 0 {
+		t.Errorf("expected 1 tax office and bundled KPP reasons, got %d and %d", len(data.TaxOffices), len(data.KPPReasons))
+	}
+
+	if err := LoadOfficialRequisiteRegistries(db, "", ""); err == nil {
+		t.Error("expected error without official registry files")
+	}
+}
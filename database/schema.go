@@ -139,6 +139,11 @@ func InitSchema(db *sql.DB) error {
 		}
 	}
 
+	// Создаем хранилище реквизитов, разобранных из attributes_xml/table_parts_xml
+	if err := CreateSourceItemAttributesTable(db); err != nil {
+		return fmt.Errorf("failed to create source item attributes table: %w", err)
+	}
+
 	// Создаем таблицу normalized_data
 	if err := CreateNormalizedDataTable(db); err != nil {
 		return fmt.Errorf("failed to create normalized_data table: %w", err)
@@ -154,6 +159,21 @@ func InitSchema(db *sql.DB) error {
 		return fmt.Errorf("failed to create normalized_item_attributes table: %w", err)
 	}
 
+	// Добавляем поля подтверждения атрибутов ревьюером (обучающая выборка NER)
+	if err := ensureMigrationApplied(db, "normalized_item_attributes_review_v1", MigrateItemAttributesReviewFields); err != nil {
+		return fmt.Errorf("failed to migrate attribute review fields: %w", err)
+	}
+
+	// Создаем таблицу записей решений конвейера по нормализованным элементам
+	if err := CreateNormalizedItemDecisionsTable(db); err != nil {
+		return fmt.Errorf("failed to create normalized_item_decisions table: %w", err)
+	}
+
+	// Создаем таблицы результатов этапов конвейера (по элементу и этапу) и каталога этапов
+	if err := CreateStageResultTables(db); err != nil {
+		return fmt.Errorf("failed to create stage result tables: %w", err)
+	}
+
 	// Добавляем КПВЭД поля в normalized_data
 	if err := MigrateNormalizedDataKpvedFields(db); err != nil {
 		return fmt.Errorf("failed to migrate KPVED fields: %w", err)
@@ -194,6 +214,11 @@ func InitSchema(db *sql.DB) error {
 		return fmt.Errorf("failed to create data quality tables: %w", err)
 	}
 
+	// Базовые линии метрик качества и обнаруженные в трендах аномалии
+	if err := CreateQualityAnomalyTables(db); err != nil {
+		return fmt.Errorf("failed to create quality anomaly tables: %w", err)
+	}
+
 	// Создаем таблицы для срезов данных
 	// CreateSnapshotTables должна быть определена в другом месте или закомментирована
 	// if err := CreateSnapshotTables(db); err != nil {
@@ -440,6 +465,30 @@ func CreateNormalizedItemAttributesTable(db *sql.DB) error {
 	return nil
 }
 
+// CreateNormalizedItemDecisionsTable создает таблицу записей решений конвейера нормализации.
+// Одна строка на элемент normalized_data: JSON с этапами (валидация, паттерны, эталоны, AI, КПВЭД)
+func CreateNormalizedItemDecisionsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS normalized_item_decisions (
+			normalized_item_id INTEGER PRIMARY KEY,
+			normalization_session_id INTEGER,
+			trace TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY(normalized_item_id) REFERENCES normalized_data(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create normalized_item_decisions table: %w", err)
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_item_decisions_session ON normalized_item_decisions(normalization_session_id)`)
+	if err != nil {
+		return fmt.Errorf("failed to create index: %w", err)
+	}
+
+	return nil
+}
+
 // MigrateNormalizedDataAIFields добавляет AI поля в таблицу normalized_data
 func MigrateNormalizedDataAIFields(db *sql.DB) error {
 	migrations := []string{
@@ -889,6 +938,86 @@ func InitServiceSchema(db *sql.DB) error {
 		return fmt.Errorf("failed to add data standardization providers: %w", err)
 	}
 
+	// Создаем таблицу клиентских overlay конфигурации
+	if err := CreateClientConfigOverlaysTable(db); err != nil {
+		return fmt.Errorf("failed to create client config overlays table: %w", err)
+	}
+
+	// Создаем таблицы учета затрат на AI (цены, журнал потребления, бюджеты)
+	if err := CreateAICostTables(db); err != nil {
+		return fmt.Errorf("failed to create AI cost tables: %w", err)
+	}
+
+	// Создаем таблицы эталонных записей номенклатуры (golden record)
+	if err := CreateGoldenRecordTables(db); err != nil {
+		return fmt.Errorf("failed to create golden record tables: %w", err)
+	}
+
+	// Создаем журнал слияний контрагентов (для отмены слияний)
+	if err := CreateCounterpartyMergeTables(db); err != nil {
+		return fmt.Errorf("failed to create counterparty merge tables: %w", err)
+	}
+
+	// Создаем журнал аудита изменяющих операций
+	if err := CreateAuditLogTables(db); err != nil {
+		return fmt.Errorf("failed to create audit log tables: %w", err)
+	}
+
+	// Создаем таблицу привязок клиентских сертификатов 1С (mTLS)
+	if err := CreateClientCertificatesTable(db); err != nil {
+		return fmt.Errorf("failed to create client certificates table: %w", err)
+	}
+
+	// Ключи подписи и журнал импорта пакетов эталонов
+	if err := CreateBenchmarkBundleTables(db); err != nil {
+		return fmt.Errorf("failed to create benchmark bundle tables: %w", err)
+	}
+
+	// Повторная сверка контрагентов с реестрами
+	if err := CreateCounterpartyReverificationTables(db); err != nil {
+		return fmt.Errorf("failed to create counterparty reverification tables: %w", err)
+	}
+
+	// Справочники БИК, кодов причин КПП и налоговых органов для проверки реквизитов
+	if err := CreateRequisiteRegistryTables(db); err != nil {
+		return fmt.Errorf("failed to create requisite registry tables: %w", err)
+	}
+
+	// Шаблоны нормализованных имен по разделам классификатора и категориям
+	if err := CreateNameTemplatesTable(db); err != nil {
+		return fmt.Errorf("failed to create name templates table: %w", err)
+	}
+
+	// Конфигурации конвейера этапов нормализации по проектам
+	if err := CreatePipelineStageConfigsTable(db); err != nil {
+		return fmt.Errorf("failed to create pipeline stage configs table: %w", err)
+	}
+
+	// Общие для процессов квоты AI провайдеров (адаптивное ограничение скорости)
+	if err := CreateAIProviderQuotasTable(db); err != nil {
+		return fmt.Errorf("failed to create AI provider quotas table: %w", err)
+	}
+
+	// Правила автоматизации после завершения выгрузки и история их запусков
+	if err := CreateUploadAutomationTables(db); err != nil {
+		return fmt.Errorf("failed to create upload automation tables: %w", err)
+	}
+
+	// Очередь проверки исправлений опечаток и белый список слов
+	if err := CreateSpellingTables(db); err != nil {
+		return fmt.Errorf("failed to create spelling tables: %w", err)
+	}
+
+	// Словари сокращений, синонимов и стоп-слов (общий и проектов)
+	if err := CreateTermDictionaryTable(db); err != nil {
+		return fmt.Errorf("failed to create term dictionary table: %w", err)
+	}
+
+	// Профили сопоставления колонок табличного импорта (XLSX/CSV)
+	if err := CreateImportMappingProfilesTable(db); err != nil {
+		return fmt.Errorf("failed to create import mapping profiles table: %w", err)
+	}
+
 	return nil
 }
 
@@ -1880,6 +1880,27 @@ func (db *ServiceDB) UpdateBenchmarkFields(benchmarkID int, subcategory, sourceD
 	return nil
 }
 
+// GetNormalizationConfigByID получает конфигурацию нормализации по ID (nil, если не найдена)
+func (db *ServiceDB) GetNormalizationConfigByID(id int) (*NormalizationConfig, error) {
+	config := &NormalizationConfig{}
+	err := db.conn.QueryRow(`
+		SELECT id, database_path, source_table, reference_column, code_column, name_column, created_at, updated_at
+		FROM normalization_config
+		WHERE id = ?
+	`, id).Scan(
+		&config.ID, &config.DatabasePath, &config.SourceTable,
+		&config.ReferenceColumn, &config.CodeColumn, &config.NameColumn,
+		&config.CreatedAt, &config.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get normalization config %d: %w", id, err)
+	}
+	return config, nil
+}
+
 // GetNormalizationConfig получает конфигурацию нормализации
 func (db *ServiceDB) GetNormalizationConfig() (*NormalizationConfig, error) {
 	query := `
@@ -4439,17 +4460,17 @@ func (db *ServiceDB) catalogItemToUnified(item *CatalogItem, project *ClientProj
 
 	// Извлекаем данные из атрибутов используя extractors
 	// Извлекаем ИНН
-	if inn, err := extractors.ExtractINNFromAttributes(item.Attributes); err == nil {
+	if inn, err := item.ExtractAttribute(extractors.FieldINN); err == nil {
 		unified.TaxID = inn
 	}
 
 	// Извлекаем КПП
-	if kpp, err := extractors.ExtractKPPFromAttributes(item.Attributes); err == nil {
+	if kpp, err := item.ExtractAttribute(extractors.FieldKPP); err == nil {
 		unified.KPP = kpp
 	}
 
 	// Извлекаем БИН
-	if bin, err := extractors.ExtractBINFromAttributes(item.Attributes); err == nil {
+	if bin, err := item.ExtractAttribute(extractors.FieldBIN); err == nil {
 		unified.BIN = bin
 	}
 
@@ -4490,23 +4511,23 @@ func (db *ServiceDB) catalogItemToUnified(item *CatalogItem, project *ClientProj
 	}
 
 	// Извлекаем адрес
-	if address, err := extractors.ExtractAddressFromAttributes(item.Attributes); err == nil {
+	if address, err := item.ExtractAttribute(extractors.FieldAddress); err == nil {
 		unified.LegalAddress = address
 		unified.PostalAddress = address
 	}
 
 	// Извлекаем телефон
-	if phone, err := extractors.ExtractContactPhoneFromAttributes(item.Attributes); err == nil {
+	if phone, err := item.ExtractAttribute(extractors.FieldContactPhone); err == nil {
 		unified.ContactPhone = phone
 	}
 
 	// Извлекаем email
-	if email, err := extractors.ExtractContactEmailFromAttributes(item.Attributes); err == nil {
+	if email, err := item.ExtractAttribute(extractors.FieldContactEmail); err == nil {
 		unified.ContactEmail = email
 	}
 
 	// Извлекаем контактное лицо
-	if person, err := extractors.ExtractContactPersonFromAttributes(item.Attributes); err == nil {
+	if person, err := item.ExtractAttribute(extractors.FieldContactPerson); err == nil {
 		unified.ContactPerson = person
 	}
 
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"httpserver/extractors"
+)
+
+// Типы исходных элементов в хранилище атрибутов
+const (
+	SourceItemTypeCatalog      = "catalog_item"
+	SourceItemTypeNomenclature = "nomenclature_item"
+)
+
+// sourceAttributesBatchSize размер пакета идентификаторов в запросах к хранилищу атрибутов
+const sourceAttributesBatchSize = 500
+
+// CreateSourceItemAttributesTable создает хранилище реквизитов исходных элементов,
+// разобранных из attributes_xml/table_parts_xml при загрузке
+func CreateSourceItemAttributesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS source_item_attributes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			item_type TEXT NOT NULL,
+			item_id INTEGER NOT NULL,
+			table_part TEXT NOT NULL DEFAULT '',
+			row_number INTEGER NOT NULL DEFAULT 0,
+			name TEXT NOT NULL,
+			value_type TEXT NOT NULL,
+			value TEXT,
+			reference TEXT
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_source_item_attributes_item ON source_item_attributes(item_type, item_id);
+		CREATE INDEX IF NOT EXISTS idx_source_item_attributes_name_value ON source_item_attributes(name, value);
+		CREATE INDEX IF NOT EXISTS idx_source_item_attributes_reference ON source_item_attributes(reference);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create source_item_attributes table: %w", err)
+	}
+	return nil
+}
+
+// sqlExecutor общий интерфейс *sql.DB и *sql.Tx для записи атрибутов
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertSourceItemAttributes записывает разобранные реквизиты элемента
+func insertSourceItemAttributes(exec sqlExecutor, itemType string, itemID int64, attrs []extractors.Attribute) error {
+	for _, attr := range attrs {
+		_, err := exec.Exec(`
+			INSERT INTO source_item_attributes (item_type, item_id, table_part, row_number, name, value_type, value, reference)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, itemType, itemID, attr.TablePart, attr.RowNumber, attr.Name, string(attr.Type), attr.Value, attr.Reference)
+		if err != nil {
+			return fmt.Errorf("failed to insert attribute %s: %w", attr.Name, err)
+		}
+	}
+	return nil
+}
+
+// ReplaceSourceItemAttributes заменяет реквизиты элемента в хранилище
+func (db *DB) ReplaceSourceItemAttributes(itemType string, itemID int, attrs []extractors.Attribute) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM source_item_attributes WHERE item_type = ? AND item_id = ?`, itemType, itemID); err != nil {
+		return fmt.Errorf("failed to delete item attributes: %w", err)
+	}
+	if err := insertSourceItemAttributes(tx, itemType, int64(itemID), attrs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSourceItemAttributes возвращает реквизиты элементов по их идентификаторам
+func (db *DB) GetSourceItemAttributes(itemType string, itemIDs []int) (map[int]extractors.AttributeSet, error) {
+	result := make(map[int]extractors.AttributeSet, len(itemIDs))
+	for start := 0; start < len(itemIDs); start += sourceAttributesBatchSize {
+		end := start + sourceAttributesBatchSize
+		if end > len(itemIDs) {
+			end = len(itemIDs)
+		}
+		batch := itemIDs[start:end]
+		args := make([]interface{}, 0, len(batch)+1)
+		args = append(args, itemType)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+
+		rows, err := db.conn.Query(`
+			SELECT item_id, table_part, row_number, name, value_type, COALESCE(value, ''), COALESCE(reference, '')
+			FROM source_item_attributes
+			WHERE item_type = ? AND item_id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")+`)
+			ORDER BY id
+		`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query item attributes: %w", err)
+		}
+		for rows.Next() {
+			var itemID int
+			var attr extractors.Attribute
+			var valueType string
+			if err := rows.Scan(&itemID, &attr.TablePart, &attr.RowNumber, &attr.Name, &valueType, &attr.Value, &attr.Reference); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan item attribute: %w", err)
+			}
+			attr.Type = extractors.AttributeType(valueType)
+			result[itemID] = append(result[itemID], attr)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("error iterating item attributes: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// attachCatalogItemAttributes заполняет AttributeSet элементов справочников из хранилища
+func (db *DB) attachCatalogItemAttributes(items []*CatalogItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	ids := make([]int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	sets, err := db.GetSourceItemAttributes(SourceItemTypeCatalog, ids)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.AttributeSet = sets[item.ID]
+	}
+	return nil
+}
+
+// ExtractAttribute извлекает поле элемента из хранилища атрибутов; attributes_xml
+// разбирается только для элементов, еще не попавших в хранилище
+func (ci *CatalogItem) ExtractAttribute(field extractors.Field) (string, error) {
+	if len(ci.AttributeSet) > 0 {
+		return extractors.ExtractFromSet(ci.AttributeSet, field)
+	}
+	return extractors.ExtractFromXML(ci.Attributes, field)
+}
+
+// BackfillResult итог заполнения хранилища атрибутов для существующих элементов
+type BackfillResult struct {
+	CatalogItems      int `json:"catalog_items"`
+	NomenclatureItems int `json:"nomenclature_items"`
+	Attributes        int `json:"attributes"`
+}
+
+// BackfillSourceItemAttributes разбирает attributes_xml/table_parts_xml элементов, загруженных
+// до появления хранилища атрибутов. Уже разобранные элементы пропускаются.
+func (db *DB) BackfillSourceItemAttributes(batchSize int) (*BackfillResult, error) {
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+	result := &BackfillResult{}
+	sources := []struct {
+		itemType string
+		table    string
+		counter  *int
+	}{
+		{SourceItemTypeCatalog, "catalog_items", &result.CatalogItems},
+		{SourceItemTypeNomenclature, "nomenclature_items", &result.NomenclatureItems},
+	}
+
+	for _, source := range sources {
+		lastID := 0
+		for {
+			rows, err := db.conn.Query(`
+				SELECT t.id, COALESCE(t.attributes_xml, ''), COALESCE(t.table_parts_xml, '')
+				FROM `+source.table+` t
+				WHERE t.id > ?
+				  AND (COALESCE(t.attributes_xml, '') != '' OR COALESCE(t.table_parts_xml, '') != '')
+				  AND NOT EXISTS (
+					SELECT 1 FROM source_item_attributes a WHERE a.item_type = ? AND a.item_id = t.id
+				  )
+				ORDER BY t.id
+				LIMIT ?
+			`, lastID, source.itemType, batchSize)
+			if err != nil {
+				return result, fmt.Errorf("failed to query %s: %w", source.table, err)
+			}
+
+			type pending struct {
+				id    int
+				attrs []extractors.Attribute
+			}
+			var batch []pending
+			for rows.Next() {
+				var id int
+				var attrsXML, partsXML string
+				if err := rows.Scan(&id, &attrsXML, &partsXML); err != nil {
+					rows.Close()
+					return result, fmt.Errorf("failed to scan %s: %w", source.table, err)
+				}
+				batch = append(batch, pending{id, extractors.ParseAttributes(attrsXML, partsXML)})
+				lastID = id
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return result, fmt.Errorf("error iterating %s: %w", source.table, err)
+			}
+			if len(batch) == 0 {
+				break
+			}
+
+			tx, err := db.conn.Begin()
+			if err != nil {
+				return result, fmt.Errorf("failed to begin transaction: %w", err)
+			}
+			for _, item := range batch {
+				if err := insertSourceItemAttributes(tx, source.itemType, int64(item.id), item.attrs); err != nil {
+					tx.Rollback()
+					return result, err
+				}
+				*source.counter++
+				result.Attributes += len(item.attrs)
+			}
+			if err := tx.Commit(); err != nil {
+				return result, fmt.Errorf("failed to commit backfill batch: %w", err)
+			}
+		}
+	}
+	return result, nil
+}
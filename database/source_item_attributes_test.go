@@ -0,0 +1,99 @@
+package database
+
+import (
+	"testing"
+
+	"httpserver/extractors"
+)
+
+// TestAddCatalogItemWithAttributes проверяет запись реквизитов при загрузке и их подгрузку в элементы
+func TestAddCatalogItemWithAttributes(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create DB: %v", err)
+	}
+	defer db.Close()
+
+	upload, err := db.CreateUpload("test-uuid", "8.3", "test-config")
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	catalog, err := db.AddCatalog(upload.ID, "Контрагенты", "counterparties")
+	if err != nil {
+		t.Fatalf("Failed to create catalog: %v", err)
+	}
+
+	attrsXML := `<ИНН>7701234567</ИНН><КПП>770101001</КПП>`
+	attrs := extractors.ParseAttributes(attrsXML, "")
+	if err := db.AddCatalogItemWithAttributes(catalog.ID, "ref1", "001", "ООО Ромашка", attrsXML, "", attrs); err != nil {
+		t.Fatalf("AddCatalogItemWithAttributes() error = %v", err)
+	}
+
+	items, _, err := db.GetCatalogItemsByUpload(upload.ID, []string{"Контрагенты"}, 0, 0)
+	if err != nil {
+		t.Fatalf("GetCatalogItemsByUpload() error = %v", err)
+	}
+	if len(items) != 1 || len(items[0].AttributeSet) != 2 {
+		t.Fatalf("expected 1 item with 2 stored attributes, got %+v", items)
+	}
+	if inn, err := items[0].ExtractAttribute(extractors.FieldINN); err != nil || inn != "7701234567" {
+		t.Errorf("ExtractAttribute(INN) = %q, %v", inn, err)
+	}
+}
+
+// TestBackfillSourceItemAttributes проверяет разбор элементов, загруженных до появления хранилища
+func TestBackfillSourceItemAttributes(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create DB: %v", err)
+	}
+	defer db.Close()
+
+	upload, err := db.CreateUpload("test-uuid", "8.3", "test-config")
+	if err != nil {
+		t.Fatalf("Failed to create upload: %v", err)
+	}
+	catalog, err := db.AddCatalog(upload.ID, "Номенклатура", "nomenclature")
+	if err != nil {
+		t.Fatalf("Failed to create catalog: %v", err)
+	}
+
+	// Элементы без разобранных реквизитов, как в базах до миграции
+	for _, ref := range []string{"ref1", "ref2", "ref3"} {
+		if _, err := db.Exec(`INSERT INTO catalog_items (catalog_id, reference, code, name, attributes_xml, table_parts_xml) VALUES (?, ?, ?, ?, ?, ?)`,
+			catalog.ID, ref, ref, "Болт", `<Артикул>`+ref+`</Артикул>`, `<Штрихкоды><row><Штрихкод>4601234567890</Штрихкод></row></Штрихкоды>`); err != nil {
+			t.Fatalf("Failed to insert catalog item: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO nomenclature_items (upload_id, nomenclature_reference, nomenclature_code, nomenclature_name, attributes_xml) VALUES (?, ?, ?, ?, ?)`,
+		upload.ID, "nref", "n1", "Гайка", `<Артикул>Г-1</Артикул>`); err != nil {
+		t.Fatalf("Failed to insert nomenclature item: %v", err)
+	}
+
+	result, err := db.BackfillSourceItemAttributes(2)
+	if err != nil {
+		t.Fatalf("BackfillSourceItemAttributes() error = %v", err)
+	}
+	if result.CatalogItems != 3 || result.NomenclatureItems != 1 || result.Attributes != 7 {
+		t.Errorf("unexpected backfill result: %+v", result)
+	}
+
+	// Повторный запуск не дублирует реквизиты
+	result, err = db.BackfillSourceItemAttributes(2)
+	if err != nil {
+		t.Fatalf("BackfillSourceItemAttributes() error = %v", err)
+	}
+	if result.CatalogItems != 0 || result.NomenclatureItems != 0 {
+		t.Errorf("expected nothing to backfill, got %+v", result)
+	}
+
+	items, _, err := db.GetCatalogItemsByUpload(upload.ID, []string{"Номенклатура"}, 0, 0)
+	if err != nil {
+		t.Fatalf("GetCatalogItemsByUpload() error = %v", err)
+	}
+	for _, item := range items {
+		if article := item.AttributeSet.Value("Артикул"); article != item.Reference {
+			t.Errorf("item %s: article = %q", item.Reference, article)
+		}
+	}
+}
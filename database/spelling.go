@@ -0,0 +1,363 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Статусы исправлений опечаток в очереди проверки
+const (
+	SpellingStatusPending  = "pending"  // Найдено при нормализации, не проверено
+	SpellingStatusApproved = "approved" // Применять всегда
+	SpellingStatusRejected = "rejected" // Никогда не предлагать
+)
+
+// SpellingCorrection исправление "слово -> вариант" проекта (project_id = 0 - для всех проектов)
+type SpellingCorrection struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	Original    string    `json:"original"`
+	Suggested   string    `json:"suggested"`
+	Status      string    `json:"status"`
+	Occurrences int       `json:"occurrences"`
+	Distance    int       `json:"distance"`
+	Confidence  float64   `json:"confidence"`
+	Example     string    `json:"example,omitempty"` // пример наименования с опечаткой
+	ReviewedBy  string    `json:"reviewed_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SpellingWhitelistEntry слово, которое не исправляется (project_id = 0 - для всех проектов)
+type SpellingWhitelistEntry struct {
+	ID        int       `json:"id"`
+	ProjectID int       `json:"project_id"`
+	Word      string    `json:"word"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateSpellingTables создает таблицы очереди проверки исправлений и белого списка слов
+func CreateSpellingTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS spelling_corrections (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL DEFAULT 0,
+			original_word TEXT NOT NULL,
+			suggested_word TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			occurrences INTEGER NOT NULL DEFAULT 0,
+			distance INTEGER NOT NULL DEFAULT 0,
+			confidence REAL NOT NULL DEFAULT 0,
+			example TEXT NOT NULL DEFAULT '',
+			reviewed_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_id, original_word, suggested_word)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_spelling_corrections_project ON spelling_corrections(project_id, status);
+
+		CREATE TABLE IF NOT EXISTS spelling_whitelist (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL DEFAULT 0,
+			word TEXT NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_id, word)
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create spelling tables: %w", err)
+	}
+	return nil
+}
+
+const spellingCorrectionColumns = `id, project_id, original_word, suggested_word, status, occurrences, distance, confidence, example, reviewed_by, created_at, updated_at`
+
+// RecordSpellingCorrections добавляет исправления, встреченные при нормализации проекта.
+// Для известных пар увеличивается число вхождений, статус проверки не меняется
+func (db *ServiceDB) RecordSpellingCorrections(projectID int, corrections []SpellingCorrection) error {
+	if len(corrections) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO spelling_corrections (project_id, original_word, suggested_word, status, occurrences, distance, confidence, example)
+		VALUES (?, ?, ?, 'pending', ?, ?, ?, ?)
+		ON CONFLICT(project_id, original_word, suggested_word) DO UPDATE SET
+			occurrences = occurrences + excluded.occurrences,
+			confidence = MAX(confidence, excluded.confidence),
+			example = CASE WHEN example = '' THEN excluded.example ELSE example END,
+			updated_at = CURRENT_TIMESTAMP
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare spelling correction insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range corrections {
+		if _, err := stmt.Exec(projectID, c.Original, c.Suggested, c.Occurrences, c.Distance, c.Confidence, c.Example); err != nil {
+			return fmt.Errorf("failed to record spelling correction %s -> %s: %w", c.Original, c.Suggested, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetSpellingCorrections возвращает исправления проекта (status пустой - все статусы),
+// самые частые первыми
+func (db *ServiceDB) GetSpellingCorrections(projectID int, status string, limit int) ([]*SpellingCorrection, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `SELECT ` + spellingCorrectionColumns + ` FROM spelling_corrections WHERE project_id = ?`
+	args := []interface{}{projectID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY occurrences DESC, id LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spelling corrections: %w", err)
+	}
+	defer rows.Close()
+
+	corrections := make([]*SpellingCorrection, 0)
+	for rows.Next() {
+		c, err := scanSpellingCorrection(rows)
+		if err != nil {
+			return nil, err
+		}
+		corrections = append(corrections, c)
+	}
+	return corrections, rows.Err()
+}
+
+// GetSpellingReviews возвращает проверенные исправления проекта и общие для всех проектов
+func (db *ServiceDB) GetSpellingReviews(projectID int) ([]*SpellingCorrection, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+spellingCorrectionColumns+`
+		FROM spelling_corrections
+		WHERE project_id IN (0, ?) AND status IN ('approved', 'rejected')
+		ORDER BY project_id, id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spelling reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := make([]*SpellingCorrection, 0)
+	for rows.Next() {
+		c, err := scanSpellingCorrection(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, c)
+	}
+	return reviews, rows.Err()
+}
+
+// GetSpellingCorrection возвращает исправление по ID (nil, если не найдено)
+func (db *ServiceDB) GetSpellingCorrection(id int) (*SpellingCorrection, error) {
+	row := db.conn.QueryRow(`SELECT `+spellingCorrectionColumns+` FROM spelling_corrections WHERE id = ?`, id)
+	c, err := scanSpellingCorrection(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// UpdateSpellingCorrectionStatus сохраняет решение проверки
+func (db *ServiceDB) UpdateSpellingCorrectionStatus(id int, status, reviewedBy string) error {
+	result, err := db.conn.Exec(`
+		UPDATE spelling_corrections SET status = ?, reviewed_by = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, status, reviewedBy, id)
+	if err != nil {
+		return fmt.Errorf("failed to update spelling correction: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SaveSpellingReview создает или изменяет проверенное исправление (например, добавленное вручную)
+func (db *ServiceDB) SaveSpellingReview(projectID int, original, suggested, status, reviewedBy string) (*SpellingCorrection, error) {
+	_, err := db.conn.Exec(`
+		INSERT INTO spelling_corrections (project_id, original_word, suggested_word, status, reviewed_by)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(project_id, original_word, suggested_word) DO UPDATE SET
+			status = excluded.status,
+			reviewed_by = excluded.reviewed_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, projectID, original, suggested, status, reviewedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save spelling review: %w", err)
+	}
+	row := db.conn.QueryRow(`
+		SELECT `+spellingCorrectionColumns+` FROM spelling_corrections
+		WHERE project_id = ? AND original_word = ? AND suggested_word = ?
+	`, projectID, original, suggested)
+	return scanSpellingCorrection(row)
+}
+
+// AddSpellingWhitelistWord добавляет слово в белый список (повторное добавление обновляет заметку)
+func (db *ServiceDB) AddSpellingWhitelistWord(projectID int, word, note string) (*SpellingWhitelistEntry, error) {
+	_, err := db.conn.Exec(`
+		INSERT INTO spelling_whitelist (project_id, word, note) VALUES (?, ?, ?)
+		ON CONFLICT(project_id, word) DO UPDATE SET note = excluded.note
+	`, projectID, word, note)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add whitelist word: %w", err)
+	}
+	var entry SpellingWhitelistEntry
+	err = db.conn.QueryRow(`
+		SELECT id, project_id, word, note, created_at FROM spelling_whitelist WHERE project_id = ? AND word = ?
+	`, projectID, word).Scan(&entry.ID, &entry.ProjectID, &entry.Word, &entry.Note, &entry.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whitelist word: %w", err)
+	}
+	return &entry, nil
+}
+
+// GetSpellingWhitelist возвращает белый список проекта; includeGlobal добавляет общие слова
+func (db *ServiceDB) GetSpellingWhitelist(projectID int, includeGlobal bool) ([]*SpellingWhitelistEntry, error) {
+	query := `SELECT id, project_id, word, note, created_at FROM spelling_whitelist WHERE project_id = ?`
+	if includeGlobal && projectID != 0 {
+		query = `SELECT id, project_id, word, note, created_at FROM spelling_whitelist WHERE project_id IN (0, ?)`
+	}
+	rows, err := db.conn.Query(query+` ORDER BY word`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query spelling whitelist: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*SpellingWhitelistEntry, 0)
+	for rows.Next() {
+		var entry SpellingWhitelistEntry
+		if err := rows.Scan(&entry.ID, &entry.ProjectID, &entry.Word, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan whitelist word: %w", err)
+		}
+		entries = append(entries, &entry)
+	}
+	return entries, rows.Err()
+}
+
+// DeleteSpellingWhitelistWord удаляет слово из белого списка; возвращает проект слова
+func (db *ServiceDB) DeleteSpellingWhitelistWord(id int) (int, error) {
+	var projectID int
+	if err := db.conn.QueryRow(`SELECT project_id FROM spelling_whitelist WHERE id = ?`, id).Scan(&projectID); err != nil {
+		return 0, err
+	}
+	if _, err := db.conn.Exec(`DELETE FROM spelling_whitelist WHERE id = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to delete whitelist word: %w", err)
+	}
+	return projectID, nil
+}
+
+// GetClassifierNames возвращает наименования позиций КПВЭД и ОКПД2 (корпус словаря опечаток).
+// Отсутствующие таблицы классификаторов пропускаются
+func (db *ServiceDB) GetClassifierNames() ([]string, error) {
+	var names []string
+	for _, table := range []string{"kpved_classifier", "okpd2_classifier"} {
+		rows, err := db.conn.Query(`SELECT name FROM ` + table)
+		if err != nil {
+			if strings.Contains(err.Error(), "no such table") {
+				continue
+			}
+			return nil, fmt.Errorf("failed to query %s names: %w", table, err)
+		}
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s name: %w", table, err)
+			}
+			names = append(names, name)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// GetClientBenchmarkNameWeights возвращает нормализованные имена эталонов проекта с весом
+// (1 + число использований эталона)
+func (db *ServiceDB) GetClientBenchmarkNameWeights(projectID int) (map[string]int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT normalized_name, SUM(1 + COALESCE(usage_count, 0))
+		FROM client_benchmarks
+		WHERE client_project_id = ?
+		GROUP BY normalized_name
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query client benchmark names: %w", err)
+	}
+	defer rows.Close()
+
+	weights := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var weight int64
+		if err := rows.Scan(&name, &weight); err != nil {
+			return nil, fmt.Errorf("failed to scan client benchmark name: %w", err)
+		}
+		weights[name] = weight
+	}
+	return weights, rows.Err()
+}
+
+// GetProjectNormalizedNameCounts возвращает нормализованные имена проекта с числом записей
+func (db *DB) GetProjectNormalizedNameCounts(projectID int) (map[string]int64, error) {
+	rows, err := db.conn.Query(`
+		SELECT normalized_name, COUNT(*)
+		FROM normalized_data
+		WHERE project_id = ? AND normalized_name IS NOT NULL AND normalized_name != ''
+		GROUP BY normalized_name
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query normalized names: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan normalized name: %w", err)
+		}
+		counts[name] = count
+	}
+	return counts, rows.Err()
+}
+
+type spellingCorrectionScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSpellingCorrection(row spellingCorrectionScanner) (*SpellingCorrection, error) {
+	var c SpellingCorrection
+	err := row.Scan(&c.ID, &c.ProjectID, &c.Original, &c.Suggested, &c.Status, &c.Occurrences,
+		&c.Distance, &c.Confidence, &c.Example, &c.ReviewedBy, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan spelling correction: %w", err)
+	}
+	return &c, nil
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Статусы результата этапа конвейера
+const (
+	StageStatusCompleted = "completed" // этап выполнен
+	StageStatusReview    = "review"    // этап выполнен, результат требует ручной проверки
+	StageStatusRejected  = "rejected"  // этап отбраковал элемент, зависимые этапы не выполняются
+	StageStatusSkipped   = "skipped"   // этап не выполнялся (не выполнена зависимость или нет входных данных)
+	StageStatusFailed    = "failed"    // ошибка выполнения этапа
+)
+
+// StageResultRecord результат этапа конвейера для одного нормализованного элемента
+type StageResultRecord struct {
+	NormalizedItemID int                    `json:"normalized_item_id"`
+	StageID          string                 `json:"stage_id"`
+	Status           string                 `json:"status"`
+	Confidence       float64                `json:"confidence"`
+	Payload          map[string]interface{} `json:"payload,omitempty"`
+	Error            string                 `json:"error,omitempty"`
+	DurationMs       int64                  `json:"duration_ms"`
+	CompletedAt      time.Time              `json:"completed_at"`
+}
+
+// StageCatalogEntry описание этапа в БД: имя, номер аналогичного этапа stageNN_* и позиция в порядке выполнения
+type StageCatalogEntry struct {
+	StageID      string `json:"stage_id"`
+	Name         string `json:"name"`
+	LegacyNumber string `json:"legacy_number,omitempty"`
+	Position     int    `json:"position"`
+}
+
+// StageResultStats агрегированная статистика результатов этапа
+type StageResultStats struct {
+	StageCatalogEntry
+	Completed     int     `json:"completed"`
+	Review        int     `json:"review"`
+	Rejected      int     `json:"rejected"`
+	Skipped       int     `json:"skipped"`
+	Failed        int     `json:"failed"`
+	AvgConfidence float64 `json:"avg_confidence"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+	LastUpdated   string  `json:"last_updated"`
+}
+
+// CreateStageResultTables создает таблицы результатов этапов и каталога этапов
+func CreateStageResultTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS normalized_item_stage_results (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			normalized_item_id INTEGER NOT NULL,
+			stage_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			confidence REAL DEFAULT 0.0,
+			payload TEXT,
+			error TEXT,
+			duration_ms INTEGER DEFAULT 0,
+			completed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(normalized_item_id, stage_id),
+			FOREIGN KEY (normalized_item_id) REFERENCES normalized_data(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_stage_results_stage_status ON normalized_item_stage_results(stage_id, status);
+
+		CREATE TABLE IF NOT EXISTS pipeline_stage_catalog (
+			stage_id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			legacy_number TEXT,
+			position INTEGER NOT NULL DEFAULT 0,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create stage result tables: %w", err)
+	}
+	return nil
+}
+
+// SaveStageCatalog обновляет описания этапов, результаты которых пишутся в БД
+func (db *DB) SaveStageCatalog(entries []StageCatalogEntry) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if _, err := tx.Exec(`
+			INSERT INTO pipeline_stage_catalog (stage_id, name, legacy_number, position, updated_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(stage_id) DO UPDATE SET
+				name = excluded.name, legacy_number = excluded.legacy_number,
+				position = excluded.position, updated_at = CURRENT_TIMESTAMP
+		`, entry.StageID, entry.Name, entry.LegacyNumber, entry.Position); err != nil {
+			return fmt.Errorf("failed to save stage %s: %w", entry.StageID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SaveStageResults сохраняет результаты этапов (повторный запуск этапа заменяет прежний результат)
+func (db *DB) SaveStageResults(results []*StageResultRecord) error {
+	if len(results) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO normalized_item_stage_results
+			(normalized_item_id, stage_id, status, confidence, payload, error, duration_ms, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(normalized_item_id, stage_id) DO UPDATE SET
+			status = excluded.status, confidence = excluded.confidence, payload = excluded.payload,
+			error = excluded.error, duration_ms = excluded.duration_ms, completed_at = excluded.completed_at
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, result := range results {
+		var payload interface{}
+		if len(result.Payload) > 0 {
+			data, err := json.Marshal(result.Payload)
+			if err != nil {
+				return fmt.Errorf("failed to marshal payload of stage %s: %w", result.StageID, err)
+			}
+			payload = string(data)
+		}
+		completedAt := result.CompletedAt
+		if completedAt.IsZero() {
+			completedAt = time.Now()
+		}
+		if _, err := stmt.Exec(result.NormalizedItemID, result.StageID, result.Status, result.Confidence,
+			payload, result.Error, result.DurationMs, completedAt); err != nil {
+			return fmt.Errorf("failed to save result of stage %s for item %d: %w", result.StageID, result.NormalizedItemID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetItemStageResults возвращает результаты всех этапов элемента в порядке каталога
+func (db *DB) GetItemStageResults(itemID int) ([]*StageResultRecord, error) {
+	rows, err := db.conn.Query(`
+		SELECT r.normalized_item_id, r.stage_id, r.status, COALESCE(r.confidence, 0), COALESCE(r.payload, ''),
+		       COALESCE(r.error, ''), COALESCE(r.duration_ms, 0), r.completed_at
+		FROM normalized_item_stage_results r
+		LEFT JOIN pipeline_stage_catalog c ON c.stage_id = r.stage_id
+		WHERE r.normalized_item_id = ?
+		ORDER BY COALESCE(c.position, 0), r.stage_id
+	`, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stage results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*StageResultRecord
+	for rows.Next() {
+		result := &StageResultRecord{}
+		var payload string
+		if err := rows.Scan(&result.NormalizedItemID, &result.StageID, &result.Status, &result.Confidence,
+			&payload, &result.Error, &result.DurationMs, &result.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stage result: %w", err)
+		}
+		if payload != "" {
+			if err := json.Unmarshal([]byte(payload), &result.Payload); err != nil {
+				return nil, fmt.Errorf("failed to decode payload of stage %s: %w", result.StageID, err)
+			}
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// GetStageResultStats возвращает статистику по этапам из каталога (в порядке выполнения).
+// Пустой результат означает, что для БД еще не выполнялся проверочный прогон этапов
+func GetStageResultStats(db *DB) ([]*StageResultStats, error) {
+	rows, err := db.conn.Query(`
+		SELECT c.stage_id, c.name, COALESCE(c.legacy_number, ''), c.position,
+		       COALESCE(SUM(CASE WHEN r.status = 'completed' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.status = 'review' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.status = 'rejected' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.status = 'skipped' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN r.status = 'failed' THEN 1 ELSE 0 END), 0),
+		       COALESCE(AVG(CASE WHEN r.status IN ('completed', 'review') AND r.confidence > 0 THEN r.confidence END), 0),
+		       COALESCE(AVG(r.duration_ms), 0),
+		       COALESCE(MAX(r.completed_at), '')
+		FROM pipeline_stage_catalog c
+		LEFT JOIN normalized_item_stage_results r ON r.stage_id = c.stage_id
+		GROUP BY c.stage_id, c.name, c.legacy_number, c.position
+		ORDER BY c.position, c.stage_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stage result stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*StageResultStats
+	for rows.Next() {
+		s := &StageResultStats{}
+		if err := rows.Scan(&s.StageID, &s.Name, &s.LegacyNumber, &s.Position,
+			&s.Completed, &s.Review, &s.Rejected, &s.Skipped, &s.Failed,
+			&s.AvgConfidence, &s.AvgDurationMs, &s.LastUpdated); err != nil {
+			return nil, fmt.Errorf("failed to scan stage result stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// GetStageFailures возвращает последние ошибки и отбраковки этапа
+func GetStageFailures(db *DB, stageID string, limit int) ([]*StageResultRecord, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	rows, err := db.conn.Query(`
+		SELECT normalized_item_id, stage_id, status, COALESCE(confidence, 0), COALESCE(error, ''),
+		       COALESCE(duration_ms, 0), completed_at
+		FROM normalized_item_stage_results
+		WHERE stage_id = ? AND status IN ('failed', 'rejected')
+		ORDER BY completed_at DESC, id DESC
+		LIMIT ?
+	`, stageID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stage failures: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*StageResultRecord
+	for rows.Next() {
+		result := &StageResultRecord{}
+		if err := rows.Scan(&result.NormalizedItemID, &result.StageID, &result.Status, &result.Confidence,
+			&result.Error, &result.DurationMs, &result.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan stage failure: %w", err)
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// PipelineStageConfigEntry настройка этапа в конвейере проекта
+type PipelineStageConfigEntry struct {
+	ID        string                 `json:"id"`
+	Enabled   bool                   `json:"enabled"`
+	DependsOn []string               `json:"depends_on,omitempty"` // переопределяет зависимости этапа по умолчанию
+	Params    map[string]interface{} `json:"params,omitempty"`
+}
+
+// PipelineStageConfig конфигурация конвейера этапов проекта
+type PipelineStageConfig struct {
+	ProjectID int                        `json:"project_id"`
+	Stages    []PipelineStageConfigEntry `json:"stages"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// CreatePipelineStageConfigsTable создает таблицу конфигураций конвейера этапов проектов
+func CreatePipelineStageConfigsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS pipeline_stage_configs (
+			project_id INTEGER PRIMARY KEY,
+			stages TEXT NOT NULL,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (project_id) REFERENCES client_projects(id) ON DELETE CASCADE
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create pipeline stage configs table: %w", err)
+	}
+	return nil
+}
+
+// GetPipelineStageConfig возвращает конфигурацию конвейера проекта (nil, если не задана)
+func (db *ServiceDB) GetPipelineStageConfig(projectID int) (*PipelineStageConfig, error) {
+	var stages string
+	config := &PipelineStageConfig{ProjectID: projectID}
+	err := db.conn.QueryRow(`SELECT stages, updated_at FROM pipeline_stage_configs WHERE project_id = ?`, projectID).
+		Scan(&stages, &config.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pipeline stage config: %w", err)
+	}
+	if err := json.Unmarshal([]byte(stages), &config.Stages); err != nil {
+		return nil, fmt.Errorf("failed to decode pipeline stage config: %w", err)
+	}
+	return config, nil
+}
+
+// SavePipelineStageConfig сохраняет конфигурацию конвейера проекта
+func (db *ServiceDB) SavePipelineStageConfig(config *PipelineStageConfig) error {
+	stages, err := json.Marshal(config.Stages)
+	if err != nil {
+		return fmt.Errorf("failed to encode pipeline stage config: %w", err)
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO pipeline_stage_configs (project_id, stages, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(project_id) DO UPDATE SET stages = excluded.stages, updated_at = CURRENT_TIMESTAMP
+	`, config.ProjectID, string(stages))
+	if err != nil {
+		return fmt.Errorf("failed to save pipeline stage config: %w", err)
+	}
+	return nil
+}
+
+// DeletePipelineStageConfig удаляет конфигурацию проекта (проект возвращается к конвейеру по умолчанию)
+func (db *ServiceDB) DeletePipelineStageConfig(projectID int) error {
+	if _, err := db.conn.Exec(`DELETE FROM pipeline_stage_configs WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("failed to delete pipeline stage config: %w", err)
+	}
+	return nil
+}
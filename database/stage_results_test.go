@@ -0,0 +1,110 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStageResults проверяет сохранение результатов этапов и статистику по ним
+func TestStageResults(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create DB: %v", err)
+	}
+	defer db.Close()
+
+	items := []*NormalizedItem{
+		{SourceName: "Болт М8", Code: "001", NormalizedName: "болт м8", Category: "крепеж", MergedCount: 1, ProcessingLevel: "basic"},
+		{SourceName: "Гайка М8", Code: "002", NormalizedName: "гайка м8", Category: "крепеж", MergedCount: 1, ProcessingLevel: "basic"},
+	}
+	codeToID, err := db.InsertNormalizedItemsWithAttributesBatch(items, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	if err := db.SaveStageCatalog([]StageCatalogEntry{
+		{StageID: "lowercase", Name: "Нормализация наименования", LegacyNumber: "1", Position: 1},
+		{StageID: "final_decision", Name: "Итоговое решение", LegacyNumber: "9", Position: 2},
+	}); err != nil {
+		t.Fatalf("SaveStageCatalog() error = %v", err)
+	}
+
+	now := time.Now()
+	results := []*StageResultRecord{
+		{NormalizedItemID: codeToID["001"], StageID: "lowercase", Status: StageStatusCompleted, Confidence: 1, CompletedAt: now},
+		{NormalizedItemID: codeToID["002"], StageID: "lowercase", Status: StageStatusCompleted, Confidence: 1, CompletedAt: now},
+		{NormalizedItemID: codeToID["001"], StageID: "final_decision", Status: StageStatusFailed, Error: "boom", CompletedAt: now},
+		{NormalizedItemID: codeToID["002"], StageID: "final_decision", Status: StageStatusReview, Confidence: 0.4,
+			Payload: map[string]interface{}{"code": "25.94.11"}, CompletedAt: now},
+	}
+	if err := db.SaveStageResults(results); err != nil {
+		t.Fatalf("SaveStageResults() error = %v", err)
+	}
+	// Повторное сохранение заменяет результат этапа, а не добавляет новый
+	results[2].Status = StageStatusCompleted
+	results[2].Error = ""
+	results[2].Confidence = 0.9
+	if err := db.SaveStageResults(results[2:3]); err != nil {
+		t.Fatalf("SaveStageResults() rerun error = %v", err)
+	}
+
+	itemResults, err := db.GetItemStageResults(codeToID["002"])
+	if err != nil || len(itemResults) != 2 {
+		t.Fatalf("GetItemStageResults() = %d results, %v", len(itemResults), err)
+	}
+	if final := itemResults[1]; final.StageID != "final_decision" || final.Payload["code"] != "25.94.11" {
+		t.Errorf("final stage result = %+v", final)
+	}
+
+	stats, err := GetStageResultStats(db)
+	if err != nil || len(stats) != 2 {
+		t.Fatalf("GetStageResultStats() = %d, %v", len(stats), err)
+	}
+	if final := stats[1]; final.StageID != "final_decision" || final.Completed != 1 || final.Review != 1 || final.Failed != 0 {
+		t.Errorf("final stage stats = %+v", final)
+	}
+
+	failures, err := GetStageFailures(db, "final_decision", 10)
+	if err != nil || len(failures) != 0 {
+		t.Errorf("GetStageFailures() = %d, %v", len(failures), err)
+	}
+}
+
+func TestPipelineStageConfig(t *testing.T) {
+	db := newTestServiceDB(t)
+	client, err := db.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := db.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	config, err := db.GetPipelineStageConfig(project.ID)
+	if err != nil || config != nil {
+		t.Fatalf("GetPipelineStageConfig() for missing project = %v, %v", config, err)
+	}
+
+	config = &PipelineStageConfig{ProjectID: project.ID, Stages: []PipelineStageConfigEntry{
+		{ID: "lowercase", Enabled: true},
+		{ID: "fallback", Enabled: true, DependsOn: []string{"lowercase"}, Params: map[string]interface{}{"min_confidence": 0.5}},
+	}}
+	if err := db.SavePipelineStageConfig(config); err != nil {
+		t.Fatalf("SavePipelineStageConfig() error = %v", err)
+	}
+	stored, err := db.GetPipelineStageConfig(project.ID)
+	if err != nil || stored == nil || len(stored.Stages) != 2 {
+		t.Fatalf("GetPipelineStageConfig() = %+v, %v", stored, err)
+	}
+	if stored.Stages[1].Params["min_confidence"] != 0.5 || stored.Stages[1].DependsOn[0] != "lowercase" {
+		t.Errorf("stored stage = %+v", stored.Stages[1])
+	}
+
+	if err := db.DeletePipelineStageConfig(project.ID); err != nil {
+		t.Fatalf("DeletePipelineStageConfig() error = %v", err)
+	}
+	if stored, _ := db.GetPipelineStageConfig(project.ID); stored != nil {
+		t.Errorf("config after delete = %+v", stored)
+	}
+}
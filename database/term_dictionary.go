@@ -0,0 +1,278 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Статусы записей словаря терминов
+const (
+	TermStatusPending  = "pending"  // Кандидат найден в корпусе, не проверен
+	TermStatusApproved = "approved" // Применяется при нормализации и сравнении
+	TermStatusRejected = "rejected" // Не применяется и не предлагается повторно
+)
+
+// Источники записей словаря терминов
+const (
+	TermSourceManual = "manual" // Добавлена вручную
+	TermSourceMined  = "mined"  // Найдена в наименованиях проекта
+)
+
+// TermDictionaryEntry запись словаря сокращений, синонимов и стоп-слов
+// (project_id = 0 - общий словарь для всех проектов)
+type TermDictionaryEntry struct {
+	ID          int       `json:"id"`
+	ProjectID   int       `json:"project_id"`
+	Kind        string    `json:"kind"`
+	Term        string    `json:"term"`
+	Replacement string    `json:"replacement"`
+	Status      string    `json:"status"`
+	Source      string    `json:"source"`
+	Occurrences int64     `json:"occurrences"`
+	Confidence  float64   `json:"confidence"`
+	Example     string    `json:"example,omitempty"`
+	ReviewedBy  string    `json:"reviewed_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CreateTermDictionaryTable создает таблицу словаря терминов
+func CreateTermDictionaryTable(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS term_dictionary_entries (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL DEFAULT 0,
+			kind TEXT NOT NULL,
+			term TEXT NOT NULL,
+			replacement TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			source TEXT NOT NULL DEFAULT 'manual',
+			occurrences INTEGER NOT NULL DEFAULT 0,
+			confidence REAL NOT NULL DEFAULT 0,
+			example TEXT NOT NULL DEFAULT '',
+			reviewed_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(project_id, term)
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_term_dictionary_project ON term_dictionary_entries(project_id, status, kind);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create term dictionary table: %w", err)
+	}
+	return nil
+}
+
+const termDictionaryColumns = `id, project_id, kind, term, replacement, status, source, occurrences, confidence, example, reviewed_by, created_at, updated_at`
+
+// RecordTermCandidates сохраняет найденных в корпусе кандидатов со статусом pending.
+// Непроверенные найденные ранее кандидаты обновляются, проверенные и добавленные вручную записи не меняются
+func (db *ServiceDB) RecordTermCandidates(projectID int, candidates []TermDictionaryEntry) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO term_dictionary_entries (project_id, kind, term, replacement, status, source, occurrences, confidence, example)
+		VALUES (?, ?, ?, ?, 'pending', 'mined', ?, ?, ?)
+		ON CONFLICT(project_id, term) DO UPDATE SET
+			replacement = excluded.replacement,
+			occurrences = excluded.occurrences,
+			confidence = excluded.confidence,
+			example = excluded.example,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE term_dictionary_entries.status = 'pending' AND term_dictionary_entries.source = 'mined'
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare term candidate insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range candidates {
+		if _, err := stmt.Exec(projectID, c.Kind, c.Term, c.Replacement, c.Occurrences, c.Confidence, c.Example); err != nil {
+			return fmt.Errorf("failed to record term candidate %s -> %s: %w", c.Term, c.Replacement, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTermDictionaryEntries возвращает записи словаря проекта (kind и status пустые - все),
+// самые частые первыми
+func (db *ServiceDB) GetTermDictionaryEntries(projectID int, kind, status string, limit int) ([]*TermDictionaryEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	query := `SELECT ` + termDictionaryColumns + ` FROM term_dictionary_entries WHERE project_id = ?`
+	args := []interface{}{projectID}
+	if kind != "" {
+		query += ` AND kind = ?`
+		args = append(args, kind)
+	}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY occurrences DESC, term LIMIT ?`
+	args = append(args, limit)
+
+	return db.queryTermDictionaryEntries(query, args...)
+}
+
+// GetReviewedTermEntries возвращает проверенные записи общего словаря и словаря проекта
+// (общие первыми, чтобы записи проекта их переопределяли)
+func (db *ServiceDB) GetReviewedTermEntries(projectID int) ([]*TermDictionaryEntry, error) {
+	return db.queryTermDictionaryEntries(`
+		SELECT `+termDictionaryColumns+`
+		FROM term_dictionary_entries
+		WHERE project_id IN (0, ?) AND status IN ('approved', 'rejected')
+		ORDER BY project_id, id
+	`, projectID)
+}
+
+// GetTermDictionaryTerms возвращает термины проекта и общего словаря в любом статусе
+func (db *ServiceDB) GetTermDictionaryTerms(projectID int) (map[string]bool, error) {
+	rows, err := db.conn.Query(`SELECT term FROM term_dictionary_entries WHERE project_id IN (0, ?)`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query term dictionary terms: %w", err)
+	}
+	defer rows.Close()
+
+	terms := make(map[string]bool)
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, fmt.Errorf("failed to scan term: %w", err)
+		}
+		terms[term] = true
+	}
+	return terms, rows.Err()
+}
+
+// GetTermDictionaryEntry возвращает запись по ID (nil, если не найдена)
+func (db *ServiceDB) GetTermDictionaryEntry(id int) (*TermDictionaryEntry, error) {
+	row := db.conn.QueryRow(`SELECT `+termDictionaryColumns+` FROM term_dictionary_entries WHERE id = ?`, id)
+	entry, err := scanTermDictionaryEntry(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return entry, err
+}
+
+// SaveTermDictionaryEntry создает или заменяет запись словаря, добавленную вручную
+func (db *ServiceDB) SaveTermDictionaryEntry(projectID int, kind, term, replacement, status, reviewedBy string) (*TermDictionaryEntry, error) {
+	_, err := db.conn.Exec(`
+		INSERT INTO term_dictionary_entries (project_id, kind, term, replacement, status, source, reviewed_by)
+		VALUES (?, ?, ?, ?, ?, 'manual', ?)
+		ON CONFLICT(project_id, term) DO UPDATE SET
+			kind = excluded.kind,
+			replacement = excluded.replacement,
+			status = excluded.status,
+			reviewed_by = excluded.reviewed_by,
+			updated_at = CURRENT_TIMESTAMP
+	`, projectID, kind, term, replacement, status, reviewedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save term dictionary entry: %w", err)
+	}
+	row := db.conn.QueryRow(`
+		SELECT `+termDictionaryColumns+` FROM term_dictionary_entries WHERE project_id = ? AND term = ?
+	`, projectID, term)
+	return scanTermDictionaryEntry(row)
+}
+
+// ReviewTermDictionaryEntry сохраняет решение проверки; пустая замена оставляет прежнюю
+func (db *ServiceDB) ReviewTermDictionaryEntry(id int, status, replacement, reviewedBy string) error {
+	result, err := db.conn.Exec(`
+		UPDATE term_dictionary_entries
+		SET status = ?, replacement = CASE WHEN ? = '' THEN replacement ELSE ? END,
+			reviewed_by = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, status, replacement, replacement, reviewedBy, id)
+	if err != nil {
+		return fmt.Errorf("failed to review term dictionary entry: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTermDictionaryEntry удаляет запись словаря; возвращает проект записи
+func (db *ServiceDB) DeleteTermDictionaryEntry(id int) (int, error) {
+	var projectID int
+	if err := db.conn.QueryRow(`SELECT project_id FROM term_dictionary_entries WHERE id = ?`, id).Scan(&projectID); err != nil {
+		return 0, err
+	}
+	if _, err := db.conn.Exec(`DELETE FROM term_dictionary_entries WHERE id = ?`, id); err != nil {
+		return 0, fmt.Errorf("failed to delete term dictionary entry: %w", err)
+	}
+	return projectID, nil
+}
+
+func (db *ServiceDB) queryTermDictionaryEntries(query string, args ...interface{}) ([]*TermDictionaryEntry, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query term dictionary entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]*TermDictionaryEntry, 0)
+	for rows.Next() {
+		entry, err := scanTermDictionaryEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetSourceNameCounts возвращает исходные наименования нормализованных записей с числом записей
+// (projectID = 0 - всех проектов). Корпус поиска сокращений: в нормализованных именах они уже раскрыты
+func (db *DB) GetSourceNameCounts(projectID int) (map[string]int64, error) {
+	query := `
+		SELECT source_name, COUNT(*)
+		FROM normalized_data
+		WHERE source_name IS NOT NULL AND source_name != ''`
+	var args []interface{}
+	if projectID > 0 {
+		query += ` AND project_id = ?`
+		args = append(args, projectID)
+	}
+	rows, err := db.conn.Query(query+` GROUP BY source_name`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query source names: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var count int64
+		if err := rows.Scan(&name, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan source name: %w", err)
+		}
+		counts[name] = count
+	}
+	return counts, rows.Err()
+}
+
+func scanTermDictionaryEntry(row spellingCorrectionScanner) (*TermDictionaryEntry, error) {
+	var e TermDictionaryEntry
+	err := row.Scan(&e.ID, &e.ProjectID, &e.Kind, &e.Term, &e.Replacement, &e.Status, &e.Source,
+		&e.Occurrences, &e.Confidence, &e.Example, &e.ReviewedBy, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan term dictionary entry: %w", err)
+	}
+	return &e, nil
+}
@@ -0,0 +1,324 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Статусы выполнения правила автоматизации и его шагов
+const (
+	UploadAutomationStatusRunning   = "running"
+	UploadAutomationStatusCompleted = "completed"
+	UploadAutomationStatusFailed    = "failed"
+	UploadAutomationStatusSkipped   = "skipped"
+)
+
+// UploadAutomationConditions условия срабатывания правила (пустые значения не проверяются)
+type UploadAutomationConditions struct {
+	MinItems       int    `json:"min_items,omitempty"`       // минимальное число элементов в выгрузке
+	IterationLabel string `json:"iteration_label,omitempty"` // метка итерации выгрузки
+	UploadPurpose  string `json:"upload_purpose,omitempty"`  // назначение выгрузки
+}
+
+// UploadAutomationAction шаг правила: действие и его параметры
+type UploadAutomationAction struct {
+	Type            string                 `json:"type"`
+	Params          map[string]interface{} `json:"params,omitempty"`
+	ContinueOnError bool                   `json:"continue_on_error,omitempty"` // не прерывать правило при ошибке шага
+}
+
+// UploadAutomationRule правило проекта "выгрузка завершена -> цепочка действий"
+type UploadAutomationRule struct {
+	ID         int                        `json:"id"`
+	ProjectID  int                        `json:"project_id"`
+	Name       string                     `json:"name"`
+	Enabled    bool                       `json:"enabled"`
+	Position   int                        `json:"position"`              // порядок выполнения правил проекта
+	DatabaseID *int                       `json:"database_id,omitempty"` // только выгрузки этой БД проекта
+	ConfigName string                     `json:"config_name,omitempty"` // только выгрузки этой конфигурации 1С
+	Conditions UploadAutomationConditions `json:"conditions"`
+	Actions    []UploadAutomationAction   `json:"actions"`
+	CreatedAt  time.Time                  `json:"created_at"`
+	UpdatedAt  time.Time                  `json:"updated_at"`
+}
+
+// UploadAutomationStepResult результат шага правила
+type UploadAutomationStepResult struct {
+	Type       string                 `json:"type"`
+	Status     string                 `json:"status"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+// UploadAutomationRun запуск правила для выгрузки, отображается в истории выгрузки
+type UploadAutomationRun struct {
+	ID         int                          `json:"id"`
+	RuleID     int                          `json:"rule_id"`
+	RuleName   string                       `json:"rule_name"`
+	ProjectID  int                          `json:"project_id"`
+	UploadID   int                          `json:"upload_id"`
+	UploadUUID string                       `json:"upload_uuid"`
+	Status     string                       `json:"status"`
+	Reason     string                       `json:"reason,omitempty"` // почему правило пропущено
+	Steps      []UploadAutomationStepResult `json:"steps"`
+	Error      string                       `json:"error,omitempty"`
+	StartedAt  time.Time                    `json:"started_at"`
+	FinishedAt *time.Time                   `json:"finished_at,omitempty"`
+}
+
+// CreateUploadAutomationTables создает таблицы правил автоматизации выгрузок и их запусков
+func CreateUploadAutomationTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS upload_automation_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			project_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			position INTEGER NOT NULL DEFAULT 0,
+			database_id INTEGER,
+			config_name TEXT NOT NULL DEFAULT '',
+			conditions TEXT NOT NULL DEFAULT '{}',
+			actions TEXT NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (project_id) REFERENCES client_projects(id) ON DELETE CASCADE
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_upload_automation_rules_project ON upload_automation_rules(project_id, position);
+
+		CREATE TABLE IF NOT EXISTS upload_automation_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			rule_id INTEGER NOT NULL,
+			rule_name TEXT NOT NULL DEFAULT '',
+			project_id INTEGER NOT NULL,
+			upload_id INTEGER NOT NULL,
+			upload_uuid TEXT NOT NULL,
+			status TEXT NOT NULL,
+			reason TEXT NOT NULL DEFAULT '',
+			steps TEXT NOT NULL DEFAULT '[]',
+			error TEXT NOT NULL DEFAULT '',
+			started_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			finished_at TIMESTAMP
+		);
+
+		CREATE INDEX IF NOT EXISTS idx_upload_automation_runs_upload ON upload_automation_runs(upload_uuid);
+		CREATE INDEX IF NOT EXISTS idx_upload_automation_runs_project ON upload_automation_runs(project_id, started_at);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create upload automation tables: %w", err)
+	}
+	return nil
+}
+
+const uploadAutomationRuleColumns = `id, project_id, name, enabled, position, database_id, config_name, conditions, actions, created_at, updated_at`
+
+// CreateUploadAutomationRule создает правило автоматизации проекта
+func (db *ServiceDB) CreateUploadAutomationRule(rule *UploadAutomationRule) (*UploadAutomationRule, error) {
+	conditions, actions, err := encodeUploadAutomationRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	result, err := db.conn.Exec(`
+		INSERT INTO upload_automation_rules (project_id, name, enabled, position, database_id, config_name, conditions, actions)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, rule.ProjectID, rule.Name, rule.Enabled, rule.Position, rule.DatabaseID, rule.ConfigName, conditions, actions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload automation rule: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload automation rule id: %w", err)
+	}
+	return db.GetUploadAutomationRule(int(id))
+}
+
+// UpdateUploadAutomationRule изменяет правило автоматизации
+func (db *ServiceDB) UpdateUploadAutomationRule(rule *UploadAutomationRule) (*UploadAutomationRule, error) {
+	conditions, actions, err := encodeUploadAutomationRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	result, err := db.conn.Exec(`
+		UPDATE upload_automation_rules
+		SET name = ?, enabled = ?, position = ?, database_id = ?, config_name = ?, conditions = ?, actions = ?,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?
+	`, rule.Name, rule.Enabled, rule.Position, rule.DatabaseID, rule.ConfigName, conditions, actions, rule.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update upload automation rule: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return nil, nil
+	}
+	return db.GetUploadAutomationRule(rule.ID)
+}
+
+// DeleteUploadAutomationRule удаляет правило; история запусков сохраняется
+func (db *ServiceDB) DeleteUploadAutomationRule(id int) (bool, error) {
+	result, err := db.conn.Exec(`DELETE FROM upload_automation_rules WHERE id = ?`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete upload automation rule: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+// GetUploadAutomationRule возвращает правило по ID (nil, если не найдено)
+func (db *ServiceDB) GetUploadAutomationRule(id int) (*UploadAutomationRule, error) {
+	row := db.conn.QueryRow(`SELECT `+uploadAutomationRuleColumns+` FROM upload_automation_rules WHERE id = ?`, id)
+	rule, err := scanUploadAutomationRule(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return rule, err
+}
+
+// GetUploadAutomationRules возвращает правила проекта в порядке выполнения
+func (db *ServiceDB) GetUploadAutomationRules(projectID int) ([]*UploadAutomationRule, error) {
+	rows, err := db.conn.Query(`
+		SELECT `+uploadAutomationRuleColumns+`
+		FROM upload_automation_rules
+		WHERE project_id = ?
+		ORDER BY position, id
+	`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upload automation rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]*UploadAutomationRule, 0)
+	for rows.Next() {
+		rule, err := scanUploadAutomationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateUploadAutomationRun сохраняет запуск правила и заполняет его ID
+func (db *ServiceDB) CreateUploadAutomationRun(run *UploadAutomationRun) error {
+	steps, err := json.Marshal(run.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload automation steps: %w", err)
+	}
+	result, err := db.conn.Exec(`
+		INSERT INTO upload_automation_runs
+			(rule_id, rule_name, project_id, upload_id, upload_uuid, status, reason, steps, error, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.RuleID, run.RuleName, run.ProjectID, run.UploadID, run.UploadUUID, run.Status, run.Reason,
+		string(steps), run.Error, run.StartedAt, run.FinishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create upload automation run: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get upload automation run id: %w", err)
+	}
+	run.ID = int(id)
+	return nil
+}
+
+// UpdateUploadAutomationRun сохраняет статус и шаги запуска
+func (db *ServiceDB) UpdateUploadAutomationRun(run *UploadAutomationRun) error {
+	steps, err := json.Marshal(run.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to encode upload automation steps: %w", err)
+	}
+	_, err = db.conn.Exec(`
+		UPDATE upload_automation_runs SET status = ?, reason = ?, steps = ?, error = ?, finished_at = ? WHERE id = ?
+	`, run.Status, run.Reason, string(steps), run.Error, run.FinishedAt, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update upload automation run: %w", err)
+	}
+	return nil
+}
+
+// GetUploadAutomationRuns возвращает запуски правил для выгрузки в порядке выполнения
+func (db *ServiceDB) GetUploadAutomationRuns(uploadUUID string) ([]*UploadAutomationRun, error) {
+	return db.queryUploadAutomationRuns(`WHERE upload_uuid = ? ORDER BY started_at, id`, uploadUUID)
+}
+
+// GetProjectUploadAutomationRuns возвращает последние запуски правил проекта
+func (db *ServiceDB) GetProjectUploadAutomationRuns(projectID, limit int) ([]*UploadAutomationRun, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	return db.queryUploadAutomationRuns(`WHERE project_id = ? ORDER BY started_at DESC, id DESC LIMIT ?`, projectID, limit)
+}
+
+func (db *ServiceDB) queryUploadAutomationRuns(where string, args ...interface{}) ([]*UploadAutomationRun, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, rule_id, rule_name, project_id, upload_id, upload_uuid, status, reason, steps, error, started_at, finished_at
+		FROM upload_automation_runs `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query upload automation runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]*UploadAutomationRun, 0)
+	for rows.Next() {
+		run := &UploadAutomationRun{}
+		var steps string
+		var finishedAt sql.NullTime
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.RuleName, &run.ProjectID, &run.UploadID, &run.UploadUUID,
+			&run.Status, &run.Reason, &steps, &run.Error, &run.StartedAt, &finishedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan upload automation run: %w", err)
+		}
+		if err := json.Unmarshal([]byte(steps), &run.Steps); err != nil {
+			return nil, fmt.Errorf("failed to decode upload automation steps: %w", err)
+		}
+		if finishedAt.Valid {
+			run.FinishedAt = &finishedAt.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+func encodeUploadAutomationRule(rule *UploadAutomationRule) (string, string, error) {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode upload automation conditions: %w", err)
+	}
+	actions, err := json.Marshal(rule.Actions)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode upload automation actions: %w", err)
+	}
+	return string(conditions), string(actions), nil
+}
+
+// uploadAutomationRuleScanner общий интерфейс *sql.Row и *sql.Rows
+type uploadAutomationRuleScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanUploadAutomationRule(row uploadAutomationRuleScanner) (*UploadAutomationRule, error) {
+	rule := &UploadAutomationRule{}
+	var databaseID sql.NullInt64
+	var conditions, actions string
+	if err := row.Scan(&rule.ID, &rule.ProjectID, &rule.Name, &rule.Enabled, &rule.Position, &databaseID,
+		&rule.ConfigName, &conditions, &actions, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan upload automation rule: %w", err)
+	}
+	if databaseID.Valid {
+		id := int(databaseID.Int64)
+		rule.DatabaseID = &id
+	}
+	if err := json.Unmarshal([]byte(conditions), &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("failed to decode upload automation conditions: %w", err)
+	}
+	if err := json.Unmarshal([]byte(actions), &rule.Actions); err != nil {
+		return nil, fmt.Errorf("failed to decode upload automation actions: %w", err)
+	}
+	return rule, nil
+}
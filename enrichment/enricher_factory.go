@@ -49,6 +49,12 @@ func NewEnricherFactory(configs map[string]*EnricherConfig) *EnricherFactory {
 	return factory
 }
 
+// AddEnricher добавляет обогатитель (например, локальный StubEnricher) с учетом приоритета
+func (f *EnricherFactory) AddEnricher(enricher Enricher) {
+	f.enrichers = append(f.enrichers, enricher)
+	f.sortByPriority()
+}
+
 // GetEnrichers возвращает список доступных обогатителей для данного ИНН/БИН
 func (f *EnricherFactory) GetEnrichers(inn, bin string) []Enricher {
 	var supported []Enricher
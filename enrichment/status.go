@@ -0,0 +1,44 @@
+package enrichment
+
+import "strings"
+
+// Нормализованные статусы юридического лица в реестрах
+const (
+	CompanyStatusActive       = "active"
+	CompanyStatusLiquidating  = "liquidating"
+	CompanyStatusLiquidated   = "liquidated"
+	CompanyStatusBankrupt     = "bankrupt"
+	CompanyStatusReorganizing = "reorganizing"
+	CompanyStatusUnknown      = "unknown"
+)
+
+// NormalizeCompanyStatus приводит статус из DaData (ACTIVE, LIQUIDATING, ...), Adata и ГИСП
+// (русскоязычные формулировки) к единому набору значений
+func NormalizeCompanyStatus(status string) string {
+	s := strings.ToLower(strings.TrimSpace(status))
+	switch {
+	case s == "":
+		return CompanyStatusUnknown
+	case strings.Contains(s, "bankrupt") || strings.Contains(s, "банкрот"):
+		return CompanyStatusBankrupt
+	case s == "liquidating" || strings.Contains(s, "в процессе ликвидации") || strings.Contains(s, "ликвидируется"):
+		return CompanyStatusLiquidating
+	case strings.Contains(s, "liquidat") || strings.Contains(s, "ликвид") || strings.Contains(s, "прекращ") || strings.Contains(s, "недейств"):
+		return CompanyStatusLiquidated
+	case strings.Contains(s, "reorganiz") || strings.Contains(s, "реорганиз"):
+		return CompanyStatusReorganizing
+	case s == "active" || strings.Contains(s, "действ") || strings.Contains(s, "активн"):
+		return CompanyStatusActive
+	default:
+		return CompanyStatusUnknown
+	}
+}
+
+// IsCriticalCompanyStatus сообщает, что контрагент прекращает или прекратил деятельность
+func IsCriticalCompanyStatus(status string) bool {
+	switch status {
+	case CompanyStatusLiquidating, CompanyStatusLiquidated, CompanyStatusBankrupt, CompanyStatusReorganizing:
+		return true
+	}
+	return false
+}
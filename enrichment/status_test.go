@@ -0,0 +1,48 @@
+package enrichment
+
+import "testing"
+
+func TestNormalizeCompanyStatus(t *testing.T) {
+	tests := []struct {
+		status string
+		want   string
+	}{
+		{"ACTIVE", CompanyStatusActive},
+		{"Действующее", CompanyStatusActive},
+		{"LIQUIDATING", CompanyStatusLiquidating},
+		{"В процессе ликвидации", CompanyStatusLiquidating},
+		{"LIQUIDATED", CompanyStatusLiquidated},
+		{"Недействующее", CompanyStatusLiquidated},
+		{"Деятельность прекращена", CompanyStatusLiquidated},
+		{"BANKRUPT", CompanyStatusBankrupt},
+		{"REORGANIZING", CompanyStatusReorganizing},
+		{"", CompanyStatusUnknown},
+		{"что-то иное", CompanyStatusUnknown},
+	}
+	for _, tt := range tests {
+		if got := NormalizeCompanyStatus(tt.status); got != tt.want {
+			t.Errorf("NormalizeCompanyStatus(%q) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestEnricherFactory_AddStubEnricher(t *testing.T) {
+	factory := NewEnricherFactory(map[string]*EnricherConfig{})
+	stub := NewStubEnricher("stub", 1)
+	stub.SetResult("7701234567", &EnrichmentResult{Success: true, INN: "7701234567", FullName: "ООО Ромашка", Confidence: 0.9})
+	factory.AddEnricher(stub)
+
+	response := factory.Enrich("7701234567", "")
+	if !response.Success || len(response.Results) != 1 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+	if best := factory.GetBestResult(response.Results); best == nil || best.Source != "stub" || best.FullName != "ООО Ромашка" {
+		t.Errorf("unexpected best result: %+v", best)
+	}
+	if response := factory.Enrich("0000000000", ""); response.Success {
+		t.Error("expected failure for unknown INN")
+	}
+	if stub.Calls() != 2 {
+		t.Errorf("Calls() = %d, want 2", stub.Calls())
+	}
+}
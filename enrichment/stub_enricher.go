@@ -0,0 +1,86 @@
+package enrichment
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StubEnricher локальный обогатитель с заранее заданными ответами.
+// Используется в тестах и для проверки повторной сверки без обращения к внешним реестрам.
+type StubEnricher struct {
+	name     string
+	priority int
+	mu       sync.RWMutex
+	results  map[string]*EnrichmentResult
+	calls    int
+}
+
+// NewStubEnricher создает локальный обогатитель
+func NewStubEnricher(name string, priority int) *StubEnricher {
+	if name == "" {
+		name = "stub"
+	}
+	return &StubEnricher{
+		name:     name,
+		priority: priority,
+		results:  make(map[string]*EnrichmentResult),
+	}
+}
+
+// SetResult задает ответ для ИНН или БИН
+func (s *StubEnricher) SetResult(key string, result *EnrichmentResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[strings.TrimSpace(key)] = result
+}
+
+// Calls возвращает количество обращений к обогатителю
+func (s *StubEnricher) Calls() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.calls
+}
+
+// Enrich возвращает копию заданного ответа
+func (s *StubEnricher) Enrich(inn, bin string) (*EnrichmentResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+
+	key := strings.TrimSpace(inn)
+	if key == "" {
+		key = strings.TrimSpace(bin)
+	}
+	result, ok := s.results[key]
+	if !ok {
+		return nil, fmt.Errorf("no data for %s", key)
+	}
+	copied := *result
+	if copied.Source == "" {
+		copied.Source = s.name
+	}
+	copied.Timestamp = time.Now()
+	return &copied, nil
+}
+
+// Supports поддерживает любые ИНН/БИН
+func (s *StubEnricher) Supports(inn, bin string) bool {
+	return inn != "" || bin != ""
+}
+
+// GetName возвращает название обогатителя
+func (s *StubEnricher) GetName() string {
+	return s.name
+}
+
+// GetPriority возвращает приоритет обогатителя
+func (s *StubEnricher) GetPriority() int {
+	return s.priority
+}
+
+// IsAvailable всегда доступен
+func (s *StubEnricher) IsAvailable() bool {
+	return true
+}
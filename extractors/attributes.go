@@ -0,0 +1,356 @@
+package extractors
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// AttributeType тип значения реквизита
+type AttributeType string
+
+const (
+	AttributeTypeString    AttributeType = "string"
+	AttributeTypeNumber    AttributeType = "number"
+	AttributeTypeBoolean   AttributeType = "boolean"
+	AttributeTypeDate      AttributeType = "date"
+	AttributeTypeReference AttributeType = "reference"
+)
+
+// Attribute реквизит элемента справочника или колонка строки табличной части
+type Attribute struct {
+	Name      string        `json:"name"`
+	Type      AttributeType `json:"type"`
+	Value     string        `json:"value"`
+	Reference string        `json:"reference,omitempty"`  // GUID объекта, если значение — ссылка
+	TablePart string        `json:"table_part,omitempty"` // пусто для реквизитов шапки
+	RowNumber int           `json:"row_number,omitempty"` // номер строки табличной части, с 1
+}
+
+var (
+	requisiteTagRe = regexp.MustCompile(`<Реквизит\s+([^>]*?)/?>`)
+	requisiteArgRe = regexp.MustCompile(`(Имя|Тип|Значение|Ссылка)=["']([^"']*)["']`)
+	guidRe         = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	numberRe       = regexp.MustCompile(`^-?\d+([.,]\d+)?$`)
+	dateRe         = regexp.MustCompile(`^(\d{2}\.\d{2}\.\d{4}|\d{4}-\d{2}-\d{2})([ T]\d{1,2}:\d{2}(:\d{2})?)?$`)
+)
+
+// ParseAttributes разбирает attributes_xml и table_parts_xml элемента из 1С в список реквизитов.
+// Поддерживаются реквизиты вида <Имя>значение</Имя>, <Реквизит Имя="..." Тип="..." Значение="..."/>
+// и табличные части <ИмяТЧ><row><Колонка>значение</Колонка></row></ИмяТЧ>.
+func ParseAttributes(attributesXML, tablePartsXML string) []Attribute {
+	var result []Attribute
+	if strings.TrimSpace(attributesXML) != "" {
+		if requisiteTagRe.MatchString(attributesXML) {
+			result = append(result, parseRequisiteTags(attributesXML)...)
+		} else {
+			result = append(result, parseElementAttributes(attributesXML)...)
+		}
+	}
+	if strings.TrimSpace(tablePartsXML) != "" {
+		result = append(result, parseTableParts(tablePartsXML)...)
+	}
+	return result
+}
+
+// parseRequisiteTags разбирает реквизиты вида <Реквизит Имя="..." Значение="..."/>
+func parseRequisiteTags(attributesXML string) []Attribute {
+	var result []Attribute
+	for _, m := range requisiteTagRe.FindAllStringSubmatch(attributesXML, -1) {
+		args := make(map[string]string)
+		for _, arg := range requisiteArgRe.FindAllStringSubmatch(m[1], -1) {
+			args[arg[1]] = html.UnescapeString(arg[2])
+		}
+		name := strings.TrimSpace(args["Имя"])
+		if name == "" {
+			continue
+		}
+		attr := newAttribute(name, args["Значение"])
+		if declared := declaredAttributeType(args["Тип"]); declared != "" {
+			attr.Type = declared
+		}
+		if ref := args["Ссылка"]; guidRe.MatchString(ref) {
+			attr.Type = AttributeTypeReference
+			attr.Reference = strings.ToLower(ref)
+		}
+		result = append(result, attr)
+	}
+	return result
+}
+
+// parseElementAttributes разбирает плоский фрагмент <Имя>значение</Имя>
+func parseElementAttributes(attributesXML string) []Attribute {
+	var result []Attribute
+	walkXMLFragment(attributesXML, func(path []string, value string) {
+		if len(path) == 1 {
+			result = append(result, newAttribute(path[0], value))
+		}
+	})
+	return result
+}
+
+// parseTableParts разбирает табличные части с построчной нумерацией
+func parseTableParts(tablePartsXML string) []Attribute {
+	var result []Attribute
+	rows := make(map[string]int)
+	walkXMLFragment(tablePartsXML, func(path []string, value string) {
+		// Ожидаемая вложенность: ТабличнаяЧасть / row / Колонка
+		if len(path) != 3 {
+			return
+		}
+		attr := newAttribute(path[2], value)
+		attr.TablePart = path[0]
+		attr.RowNumber = rows[path[0]]
+		result = append(result, attr)
+	}, func(path []string) {
+		if len(path) == 2 && path[1] == "row" {
+			rows[path[0]]++
+		}
+	})
+	return result
+}
+
+// walkXMLFragment обходит фрагмент XML без корневого элемента и вызывает leaf для каждого
+// листового элемента (в том числе пустого); start вызывается при открытии каждого элемента
+func walkXMLFragment(fragment string, leaf func(path []string, value string), start ...func(path []string)) {
+	decoder := xml.NewDecoder(strings.NewReader("<root>" + fragment + "</root>"))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	var path []string
+	var text strings.Builder
+	hasChildren := []bool{false}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if len(hasChildren) > 0 {
+				hasChildren[len(hasChildren)-1] = true
+			}
+			if t.Name.Local != "root" || len(hasChildren) > 1 {
+				path = append(path, t.Name.Local)
+				for _, fn := range start {
+					fn(path)
+				}
+			}
+			hasChildren = append(hasChildren, false)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			leafElement := !hasChildren[len(hasChildren)-1]
+			hasChildren = hasChildren[:len(hasChildren)-1]
+			if len(path) == 0 {
+				continue
+			}
+			if leafElement {
+				leaf(append([]string(nil), path...), strings.TrimSpace(text.String()))
+			}
+			path = path[:len(path)-1]
+			text.Reset()
+		}
+	}
+}
+
+// newAttribute создает реквизит с типом, определенным по значению
+func newAttribute(name, value string) Attribute {
+	value = strings.TrimSpace(value)
+	attr := Attribute{Name: strings.TrimSpace(name), Value: value, Type: InferAttributeType(value)}
+	if attr.Type == AttributeTypeReference {
+		attr.Reference = strings.ToLower(value)
+	}
+	return attr
+}
+
+// InferAttributeType определяет тип значения реквизита по его строковому представлению
+func InferAttributeType(value string) AttributeType {
+	switch {
+	case value == "":
+		return AttributeTypeString
+	case guidRe.MatchString(value):
+		return AttributeTypeReference
+	case numberRe.MatchString(value):
+		return AttributeTypeNumber
+	case dateRe.MatchString(value):
+		return AttributeTypeDate
+	}
+	switch strings.ToLower(value) {
+	case "да", "нет", "true", "false":
+		return AttributeTypeBoolean
+	}
+	return AttributeTypeString
+}
+
+// declaredAttributeType переводит тип, указанный выгрузкой 1С, в тип хранилища
+func declaredAttributeType(declared string) AttributeType {
+	switch strings.ToLower(strings.TrimSpace(declared)) {
+	case "строка", "string":
+		return AttributeTypeString
+	case "число", "number":
+		return AttributeTypeNumber
+	case "булево", "boolean":
+		return AttributeTypeBoolean
+	case "дата", "date":
+		return AttributeTypeDate
+	case "":
+		return ""
+	}
+	if strings.Contains(strings.ToLower(declared), "ссылка") {
+		return AttributeTypeReference
+	}
+	return ""
+}
+
+// AttributeSet реквизиты одного элемента
+type AttributeSet []Attribute
+
+// normalizeAttributeName приводит имя реквизита к виду для сравнения
+func normalizeAttributeName(name string) string {
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(name))
+}
+
+// Value возвращает первое непустое значение реквизита шапки из списка имен (без учета регистра)
+func (s AttributeSet) Value(names ...string) string {
+	for _, name := range names {
+		normalized := normalizeAttributeName(name)
+		for _, attr := range s {
+			if attr.TablePart == "" && attr.Value != "" && normalizeAttributeName(attr.Name) == normalized {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+// HasNameContaining проверяет, есть ли заполненный реквизит, имя которого содержит одну из подстрок
+func (s AttributeSet) HasNameContaining(substrings ...string) bool {
+	for _, attr := range s {
+		if attr.Value == "" {
+			continue
+		}
+		name := normalizeAttributeName(attr.Name)
+		for _, sub := range substrings {
+			if strings.Contains(name, normalizeAttributeName(sub)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Map возвращает реквизиты шапки в виде имя -> значение
+func (s AttributeSet) Map() map[string]string {
+	result := make(map[string]string)
+	for _, attr := range s {
+		if attr.TablePart == "" && attr.Value != "" {
+			result[attr.Name] = attr.Value
+		}
+	}
+	return result
+}
+
+// XML восстанавливает реквизиты шапки в виде <Имя>значение</Имя> для текстовых эвристик
+func (s AttributeSet) XML() string {
+	var b strings.Builder
+	for _, attr := range s {
+		if attr.TablePart != "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<%s>", attr.Name)
+		xml.EscapeText(&b, []byte(attr.Value))
+		fmt.Fprintf(&b, "</%s>", attr.Name)
+	}
+	return b.String()
+}
+
+// Field извлекаемое поле элемента
+type Field string
+
+const (
+	FieldINN                  Field = "inn"
+	FieldKPP                  Field = "kpp"
+	FieldBIN                  Field = "bin"
+	FieldAddress              Field = "address"
+	FieldContactPhone         Field = "contact_phone"
+	FieldContactEmail         Field = "contact_email"
+	FieldContactPerson        Field = "contact_person"
+	FieldLegalForm            Field = "legal_form"
+	FieldBankName             Field = "bank_name"
+	FieldBankAccount          Field = "bank_account"
+	FieldCorrespondentAccount Field = "correspondent_account"
+	FieldBIK                  Field = "bik"
+	FieldArticle              Field = "article"
+	FieldSKU                  Field = "sku"
+	FieldUnit                 Field = "unit"
+	FieldBrand                Field = "brand"
+	FieldManufacturer         Field = "manufacturer"
+	FieldCountry              Field = "country"
+)
+
+// fieldRule имена реквизитов поля, проверка значения и текстовый экстрактор для остальных случаев
+type fieldRule struct {
+	names    []string
+	valid    *regexp.Regexp
+	fallback func(string) (string, error)
+}
+
+var fieldRules = map[Field]fieldRule{
+	FieldINN:                  {[]string{"ИНН", "ИННКонтрагента", "ИННЮридическогоЛица", "INN", "TaxID"}, regexp.MustCompile(`^\d{10}(\d{2})?$`), ExtractINNFromAttributes},
+	FieldKPP:                  {[]string{"КПП", "КППКонтрагента", "KPP"}, regexp.MustCompile(`^\d{4}[\dA-Z]{2}\d{3}$`), ExtractKPPFromAttributes},
+	FieldBIN:                  {[]string{"БИН", "БИНКонтрагента", "БИНЮридическогоЛица", "БизнесИдентификационныйНомер", "BIN"}, regexp.MustCompile(`^\d{12}$`), ExtractBINFromAttributes},
+	FieldAddress:              {[]string{"ЮридическийАдрес", "АдресЮридический", "Адрес", "ФактическийАдрес", "ПочтовыйАдрес", "LegalAddress", "Address"}, nil, ExtractAddressFromAttributes},
+	FieldContactPhone:         {[]string{"Телефон", "ТелефонКонтрагента", "КонтактныйТелефон", "Phone"}, nil, ExtractContactPhoneFromAttributes},
+	FieldContactEmail:         {[]string{"ЭлектроннаяПочта", "АдресЭлектроннойПочты", "Email", "EMail"}, regexp.MustCompile(`@`), ExtractContactEmailFromAttributes},
+	FieldContactPerson:        {[]string{"КонтактноеЛицо", "ОсновноеКонтактноеЛицо", "ContactPerson"}, nil, ExtractContactPersonFromAttributes},
+	FieldLegalForm:            {[]string{"ОрганизационноПравоваяФорма", "ОПФ", "LegalForm"}, nil, ExtractLegalFormFromAttributes},
+	FieldBankName:             {[]string{"Банк", "НаименованиеБанка", "BankName"}, nil, ExtractBankNameFromAttributes},
+	FieldBankAccount:          {[]string{"РасчетныйСчет", "НомерСчета", "ОсновнойБанковскийСчет", "BankAccount"}, regexp.MustCompile(`^\d{20}$`), ExtractBankAccountFromAttributes},
+	FieldCorrespondentAccount: {[]string{"КоррСчет", "КорреспондентскийСчет", "CorrespondentAccount"}, regexp.MustCompile(`^\d{20}$`), ExtractCorrespondentAccountFromAttributes},
+	FieldBIK:                  {[]string{"БИК", "БИКБанка", "BIK"}, regexp.MustCompile(`^\d{9}$`), ExtractBIKFromAttributes},
+	FieldArticle:              {[]string{"Артикул", "АртикулНоменклатуры", "АртикулТовара", "АртикулИзделия", "Article", "ArticleNumber"}, nil, nil},
+	FieldSKU:                  {[]string{"SKU", "КодТовара", "КодНоменклатуры", "ProductCode"}, nil, nil},
+	FieldUnit:                 {[]string{"ЕдиницаИзмерения", "БазоваяЕдиницаИзмерения", "Единица", "ЕдиницаХранения", "ЕИ", "Unit", "BaseUnit"}, nil, nil},
+	FieldBrand:                {[]string{"Бренд", "ТорговаяМарка", "Марка", "ТМ", "Brand", "TradeMark"}, nil, nil},
+	FieldManufacturer:         {[]string{"Производитель", "Изготовитель", "Manufacturer"}, nil, nil},
+	FieldCountry:              {[]string{"СтранаПроисхождения", "Страна", "СтранаПроизводства", "Country"}, nil, nil},
+}
+
+// FieldAttributeNames возвращает имена реквизитов, из которых извлекается поле
+func FieldAttributeNames(field Field) []string {
+	return fieldRules[field].names
+}
+
+// ExtractFromSet извлекает поле из разобранных реквизитов: сначала по имени реквизита
+// с проверкой формата, затем текстовым экстрактором по реквизитам шапки
+func ExtractFromSet(set AttributeSet, field Field) (string, error) {
+	rule, ok := fieldRules[field]
+	if !ok {
+		return "", fmt.Errorf("unknown field %q", field)
+	}
+	for _, name := range rule.names {
+		value := set.Value(name)
+		if value == "" {
+			continue
+		}
+		if rule.valid == nil || rule.valid.MatchString(value) {
+			return value, nil
+		}
+	}
+	if rule.fallback != nil && len(set) > 0 {
+		return rule.fallback(set.XML())
+	}
+	return "", fmt.Errorf("%s not found in attributes", field)
+}
+
+// ExtractFromXML извлекает поле из attributes_xml элемента, еще не разобранного в хранилище атрибутов
+func ExtractFromXML(attributesXML string, field Field) (string, error) {
+	if rule, ok := fieldRules[field]; ok && rule.fallback != nil {
+		return rule.fallback(attributesXML)
+	}
+	return ExtractFromSet(ParseAttributes(attributesXML, ""), field)
+}
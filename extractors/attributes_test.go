@@ -0,0 +1,73 @@
+package extractors
+
+import (
+	"testing"
+)
+
+// TestParseAttributes проверяет разбор реквизитов шапки, тегов <Реквизит> и табличных частей
+func TestParseAttributes(t *testing.T) {
+	attrs := ParseAttributes(
+		`<ИНН>7701234567</ИНН><Вес>1,5</Вес><ЭтоГруппа>false</ЭтоГруппа><Родитель>0F1E2D3C-4B5A-6978-8796-A5B4C3D2E1F0</Родитель>`,
+		`<КонтактнаяИнформация><row><Вид>Телефон</Вид><Представление>+7 495 000-00-00</Представление></row><row><Вид>Email</Вид><Представление>info@example.ru</Представление></row></КонтактнаяИнформация>`,
+	)
+	if len(attrs) != 8 {
+		t.Fatalf("expected 8 attributes, got %d: %+v", len(attrs), attrs)
+	}
+
+	wantTypes := map[string]AttributeType{
+		"ИНН":       AttributeTypeNumber,
+		"Вес":       AttributeTypeNumber,
+		"ЭтоГруппа": AttributeTypeBoolean,
+		"Родитель":  AttributeTypeReference,
+	}
+	for _, attr := range attrs[:4] {
+		if attr.Type != wantTypes[attr.Name] {
+			t.Errorf("%s: type = %s, want %s", attr.Name, attr.Type, wantTypes[attr.Name])
+		}
+	}
+	if attrs[3].Reference != "0f1e2d3c-4b5a-6978-8796-a5b4c3d2e1f0" {
+		t.Errorf("unexpected reference: %q", attrs[3].Reference)
+	}
+
+	last := attrs[7]
+	if last.TablePart != "КонтактнаяИнформация" || last.RowNumber != 2 || last.Name != "Представление" || last.Value != "info@example.ru" {
+		t.Errorf("unexpected table part attribute: %+v", last)
+	}
+
+	legacy := ParseAttributes(`<Реквизит Имя="ДатаРегистрации" Тип="Дата" Значение="2020-01-15"/><Реквизит Имя="КПП" Тип="Строка" Значение="770101001"/>`, "")
+	if len(legacy) != 2 || legacy[0].Type != AttributeTypeDate || legacy[1].Type != AttributeTypeString || legacy[1].Value != "770101001" {
+		t.Errorf("unexpected legacy attributes: %+v", legacy)
+	}
+}
+
+// TestExtractFromSet проверяет извлечение полей из разобранных реквизитов
+func TestExtractFromSet(t *testing.T) {
+	set := AttributeSet(ParseAttributes(`<ИНН>7701234567</ИНН><КПП>770101001</КПП><Артикул>А-100</Артикул><Комментарий>БИК 044525225</Комментарий>`, ""))
+
+	tests := []struct {
+		field   Field
+		want    string
+		wantErr bool
+	}{
+		{FieldINN, "7701234567", false},
+		{FieldKPP, "770101001", false},
+		{FieldArticle, "А-100", false},
+		{FieldBIK, "044525225", false}, // текстовый экстрактор по реквизитам шапки
+		{FieldBrand, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			got, err := ExtractFromSet(set, tt.field)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExtractFromSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractFromSet() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !set.HasNameContaining("комментарий") || set.HasNameContaining("адрес") {
+		t.Error("unexpected HasNameContaining result")
+	}
+}
@@ -0,0 +1,591 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Типы сущностей табличного импорта
+const (
+	TabularEntityNomenclature   = "nomenclature"
+	TabularEntityCounterparties = "counterparties"
+)
+
+// Форматы табличных файлов
+const (
+	TabularFormatXLSX = "xlsx"
+	TabularFormatCSV  = "csv"
+)
+
+// tabularHeaderScanRows число первых строк листа, среди которых ищется строка заголовков
+const tabularHeaderScanRows = 20
+
+// TabularField поле, в которое можно сопоставить колонку файла
+type TabularField struct {
+	Key       string   `json:"key"`
+	Title     string   `json:"title"`
+	Required  bool     `json:"required"`
+	Attribute string   `json:"attribute,omitempty"` // имя реквизита 1С, в который попадает значение
+	synonyms  []string // нормализованные заголовки колонок (см. normalizeTabularHeader)
+	pattern   *regexp.Regexp
+}
+
+var (
+	tabularINNPattern   = regexp.MustCompile(`^\d{10}(\d{2})?$`)
+	tabularKPPPattern   = regexp.MustCompile(`^\d{4}[\dA-Z]{2}\d{3}$`)
+	tabularBINPattern   = regexp.MustCompile(`^\d{12}$`)
+	tabularEmailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	tabularGUIDPattern  = regexp.MustCompile(`(?i)^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// Общие поля номенклатуры и контрагентов
+var (
+	tabularFieldCode      = TabularField{Key: "code", Title: "Код", synonyms: []string{"код", "code", "кодноменклатуры", "кодконтрагента", "id"}}
+	tabularFieldReference = TabularField{Key: "reference", Title: "Ссылка (GUID)", synonyms: []string{"ссылка", "guid", "uuid", "reference", "идентификатор"}, pattern: tabularGUIDPattern}
+)
+
+// tabularFields поля каждого типа сущности в порядке вывода
+var tabularFields = map[string][]TabularField{
+	TabularEntityNomenclature: {
+		{Key: "name", Title: "Наименование", Required: true, synonyms: []string{
+			"наименование", "название", "номенклатура", "товар", "наименованиетовара", "наименованиеноменклатуры",
+			"полноенаименование", "наименованиепродукции", "name", "product", "productname", "item"}},
+		tabularFieldCode,
+		tabularFieldReference,
+		{Key: "article", Title: "Артикул", Attribute: "Артикул", synonyms: []string{"артикул", "article", "sku", "партномер", "partnumber", "каталожныйномер"}},
+		{Key: "unit", Title: "Единица измерения", Attribute: "ЕдиницаИзмерения", synonyms: []string{"ед", "едизм", "ei", "единица", "единицаизмерения", "unit", "uom"}},
+		{Key: "brand", Title: "Бренд", Attribute: "Бренд", synonyms: []string{"бренд", "марка", "торговаямарка", "тм", "brand"}},
+		{Key: "manufacturer", Title: "Производитель", Attribute: "Производитель", synonyms: []string{"производитель", "изготовитель", "manufacturer", "vendor"}},
+		{Key: "country", Title: "Страна происхождения", Attribute: "СтранаПроисхождения", synonyms: []string{"страна", "странапроисхождения", "странапроизводства", "country"}},
+		{Key: "group", Title: "Группа", Attribute: "Группа", synonyms: []string{"группа", "категория", "родитель", "раздел", "group", "category"}},
+	},
+	TabularEntityCounterparties: {
+		{Key: "name", Title: "Наименование", Required: true, synonyms: []string{
+			"наименование", "название", "контрагент", "организация", "поставщик", "покупатель", "клиент",
+			"полноенаименование", "наименованиеорганизации", "name", "company", "counterparty"}},
+		tabularFieldCode,
+		tabularFieldReference,
+		{Key: "inn", Title: "ИНН", Attribute: "ИНН", synonyms: []string{"инн", "inn", "taxid"}, pattern: tabularINNPattern},
+		{Key: "kpp", Title: "КПП", Attribute: "КПП", synonyms: []string{"кпп", "kpp"}, pattern: tabularKPPPattern},
+		{Key: "bin", Title: "БИН", Attribute: "БИН", synonyms: []string{"бин", "bin"}, pattern: tabularBINPattern},
+		{Key: "address", Title: "Юридический адрес", Attribute: "ЮридическийАдрес", synonyms: []string{"адрес", "юридическийадрес", "юрадрес", "address", "legaladdress"}},
+		{Key: "phone", Title: "Телефон", Attribute: "Телефон", synonyms: []string{"телефон", "тел", "phone"}},
+		{Key: "email", Title: "Электронная почта", Attribute: "ЭлектроннаяПочта", synonyms: []string{"email", "эп", "почта", "электроннаяпочта"}, pattern: tabularEmailPattern},
+	},
+}
+
+// TabularFields возвращает поля типа сущности (nil для неизвестного типа)
+func TabularFields(entityType string) []TabularField {
+	return tabularFields[entityType]
+}
+
+// TabularReadOptions параметры чтения файла. Пустые значения определяются автоматически
+type TabularReadOptions struct {
+	Sheet     string `json:"sheet,omitempty"`      // лист XLSX
+	HeaderRow int    `json:"header_row,omitempty"` // номер строки заголовков с 1
+}
+
+// TabularTable прочитанный лист: заголовки и строки данных после строки заголовков
+type TabularTable struct {
+	Format    string     `json:"format"`
+	Sheets    []string   `json:"sheets,omitempty"`
+	Sheet     string     `json:"sheet,omitempty"`
+	Encoding  string     `json:"encoding,omitempty"`  // для CSV: utf-8 или converted
+	Delimiter string     `json:"delimiter,omitempty"` // для CSV
+	HeaderRow int        `json:"header_row"`          // номер строки заголовков с 1
+	Headers   []string   `json:"headers"`
+	Rows      [][]string `json:"-"`
+}
+
+// ReadTabularFile читает XLSX или CSV (формат определяется по расширению и содержимому),
+// определяет кодировку CSV, лист и строку заголовков
+func ReadTabularFile(fileName string, data []byte, opts TabularReadOptions) (*TabularTable, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	var table *TabularTable
+	var err error
+	if detectTabularFormat(fileName, data) == TabularFormatXLSX {
+		table, err = readXLSXTable(data, opts.Sheet)
+	} else {
+		table, err = readCSVTable(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := table.applyHeaderRow(opts.HeaderRow); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// detectTabularFormat определяет формат по расширению, для неизвестного расширения - по сигнатуре ZIP
+func detectTabularFormat(fileName string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xlsx", ".xlsm":
+		return TabularFormatXLSX
+	case ".csv", ".txt", ".tsv":
+		return TabularFormatCSV
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return TabularFormatXLSX
+	}
+	return TabularFormatCSV
+}
+
+// readXLSXTable читает лист книги; без указания листа выбирается лист с наибольшим числом строк
+func readXLSXTable(data []byte, sheet string) (*TabularTable, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Excel file: %w", err)
+	}
+	defer f.Close()
+
+	table := &TabularTable{Format: TabularFormatXLSX, Sheets: f.GetSheetList()}
+	if len(table.Sheets) == 0 {
+		return nil, fmt.Errorf("no sheets found in Excel file")
+	}
+
+	if sheet != "" {
+		rows, err := f.GetRows(sheet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", sheet, err)
+		}
+		table.Sheet, table.Rows = sheet, rows
+		return table, nil
+	}
+
+	for _, name := range table.Sheets {
+		rows, err := f.GetRows(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sheet %q: %w", name, err)
+		}
+		if countNonEmptyRows(rows) > countNonEmptyRows(table.Rows) {
+			table.Sheet, table.Rows = name, rows
+		}
+	}
+	if table.Sheet == "" {
+		table.Sheet = table.Sheets[0]
+	}
+	return table, nil
+}
+
+// readCSVTable перекодирует CSV в UTF-8 (как парсер ГОСТов) и определяет разделитель
+func readCSVTable(data []byte) (*TabularTable, error) {
+	data = bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))
+	// Корректный UTF-8 не перекодируем: эвристики детектора настроены на тексты ГОСТ
+	// и могут принять обычный UTF-8 за Windows-1251
+	converted := data
+	if !utf8.Valid(data) {
+		var err error
+		converted, err = NewGostParser(ParserConfig{}, nil).detectAndConvertEncoding(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect/convert encoding: %w", err)
+		}
+	}
+	table := &TabularTable{Format: TabularFormatCSV, Encoding: "utf-8"}
+	if !bytes.Equal(converted, data) {
+		table.Encoding = "converted"
+	}
+
+	delimiter := detectCSVDelimiter(converted)
+	table.Delimiter = string(delimiter)
+	reader := newTabularCSVReader(converted, delimiter)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		table.Rows = append(table.Rows, record)
+	}
+	return table, nil
+}
+
+func newTabularCSVReader(data []byte, delimiter rune) *csv.Reader {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// detectCSVDelimiter выбирает разделитель, дающий больше всего колонок в первых строках
+// при одинаковом их числе от строки к строке
+func detectCSVDelimiter(data []byte) rune {
+	best, bestScore := ';', 0
+	for _, delimiter := range []rune{';', ',', '\t', '|'} {
+		reader := newTabularCSVReader(data, delimiter)
+		counts := make(map[int]int)
+		for i := 0; i < tabularHeaderScanRows; i++ {
+			record, err := reader.Read()
+			if err != nil {
+				break
+			}
+			if len(record) > 1 {
+				counts[len(record)]++
+			}
+		}
+		score := 0
+		for fields, rows := range counts {
+			if rows*(fields-1) > score {
+				score = rows * (fields - 1)
+			}
+		}
+		if score > bestScore {
+			best, bestScore = delimiter, score
+		}
+	}
+	return best
+}
+
+// applyHeaderRow отделяет строку заголовков (указанную или найденную) от строк данных
+func (t *TabularTable) applyHeaderRow(headerRow int) error {
+	if countNonEmptyRows(t.Rows) == 0 {
+		return fmt.Errorf("no data rows found")
+	}
+	if headerRow <= 0 {
+		headerRow = detectHeaderRow(t.Rows)
+	}
+	if headerRow > len(t.Rows) {
+		return fmt.Errorf("header row %d is beyond the end of the sheet (%d rows)", headerRow, len(t.Rows))
+	}
+
+	headers := t.Rows[headerRow-1]
+	t.HeaderRow = headerRow
+	t.Headers = make([]string, len(headers))
+	seen := make(map[string]int)
+	for i, header := range headers {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			header = fmt.Sprintf("Колонка %d", i+1)
+		}
+		// Повторяющиеся заголовки различаются номером, чтобы сопоставление по имени было однозначным
+		if seen[header]++; seen[header] > 1 {
+			header = fmt.Sprintf("%s (%d)", header, seen[header])
+		}
+		t.Headers[i] = header
+	}
+	t.Rows = t.Rows[headerRow:]
+	return nil
+}
+
+// detectHeaderRow находит строку заголовков среди первых строк: больше всего текстовых ячеек
+// и известных названий колонок; строки-заголовки документа с одной ячейкой проигрывают
+func detectHeaderRow(rows [][]string) int {
+	best, bestScore := 1, -1
+	for i := 0; i < len(rows) && i < tabularHeaderScanRows; i++ {
+		score := 0
+		for _, cell := range rows[i] {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			if isNumericCell(cell) {
+				score--
+				continue
+			}
+			score++
+			if isKnownTabularHeader(cell) {
+				score += 3
+			}
+		}
+		if score > bestScore {
+			best, bestScore = i+1, score
+		}
+	}
+	return best
+}
+
+func isKnownTabularHeader(header string) bool {
+	normalized := normalizeTabularHeader(header)
+	for _, fields := range tabularFields {
+		for _, field := range fields {
+			for _, synonym := range field.synonyms {
+				if normalized == synonym {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// ColumnSuggestion предлагаемое сопоставление колонки полю
+type ColumnSuggestion struct {
+	Field      string  `json:"field"`
+	Column     string  `json:"column"`
+	Index      int     `json:"index"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"` // header, header_partial, values
+}
+
+// SuggestColumnMapping предлагает сопоставление колонок полям сущности по заголовкам
+// (точное или частичное совпадение с известными названиями) и по значениям (ИНН, КПП, e-mail).
+// Каждая колонка и каждое поле используются не более одного раза
+func SuggestColumnMapping(entityType string, table *TabularTable) []ColumnSuggestion {
+	fields := tabularFields[entityType]
+	var candidates []ColumnSuggestion
+	for index, header := range table.Headers {
+		normalized := normalizeTabularHeader(header)
+		values := columnSample(table.Rows, index)
+		for _, field := range fields {
+			if suggestion, ok := matchTabularColumn(field, header, normalized, index, values); ok {
+				candidates = append(candidates, suggestion)
+			}
+		}
+	}
+	if fields != nil {
+		if suggestion, ok := suggestNameByValues(table); ok {
+			candidates = append(candidates, suggestion)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].Index < candidates[j].Index
+	})
+
+	usedFields := make(map[string]bool)
+	usedColumns := make(map[int]bool)
+	suggestions := make([]ColumnSuggestion, 0, len(fields))
+	for _, candidate := range candidates {
+		if usedFields[candidate.Field] || usedColumns[candidate.Index] {
+			continue
+		}
+		usedFields[candidate.Field] = true
+		usedColumns[candidate.Index] = true
+		suggestions = append(suggestions, candidate)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Index < suggestions[j].Index })
+	return suggestions
+}
+
+// matchTabularColumn оценивает колонку для поля по заголовку и значениям
+func matchTabularColumn(field TabularField, header, normalized string, index int, values []string) (ColumnSuggestion, bool) {
+	suggestion := ColumnSuggestion{Field: field.Key, Column: header, Index: index}
+	for _, synonym := range field.synonyms {
+		if normalized == synonym {
+			suggestion.Confidence, suggestion.Reason = 1.0, "header"
+			return suggestion, true
+		}
+	}
+	for _, synonym := range field.synonyms {
+		if len([]rune(synonym)) >= 4 && strings.Contains(normalized, synonym) {
+			suggestion.Confidence, suggestion.Reason = 0.7, "header_partial"
+			return suggestion, true
+		}
+	}
+	if field.pattern != nil && len(values) > 0 {
+		matched := 0
+		for _, value := range values {
+			if field.pattern.MatchString(value) {
+				matched++
+			}
+		}
+		if float64(matched)/float64(len(values)) >= 0.8 {
+			suggestion.Confidence, suggestion.Reason = 0.6, "values"
+			return suggestion, true
+		}
+	}
+	return suggestion, false
+}
+
+// suggestNameByValues предлагает для наименования колонку с самым длинным текстом,
+// если заголовок не распознан
+func suggestNameByValues(table *TabularTable) (ColumnSuggestion, bool) {
+	best := ColumnSuggestion{Field: "name", Index: -1, Confidence: 0.4, Reason: "values"}
+	bestLength := 0.0
+	for index, header := range table.Headers {
+		values := columnSample(table.Rows, index)
+		if len(values) == 0 {
+			continue
+		}
+		total, text := 0, 0
+		for _, value := range values {
+			if !isNumericCell(value) && strings.IndexFunc(value, unicode.IsLetter) >= 0 {
+				text++
+				total += len([]rune(value))
+			}
+		}
+		if text*2 < len(values) {
+			continue
+		}
+		if average := float64(total) / float64(text); average > bestLength {
+			best.Column, best.Index, bestLength = header, index, average
+		}
+	}
+	return best, best.Index >= 0
+}
+
+// TabularRecord строка файла, сопоставленная полям
+type TabularRecord struct {
+	Row        int               `json:"row"` // номер строки в файле с 1
+	Name       string            `json:"name"`
+	Code       string            `json:"code,omitempty"`
+	Reference  string            `json:"reference,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"` // имя реквизита 1С -> значение
+}
+
+// TabularRowError ошибка строки файла
+type TabularRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ResolveColumnMapping переводит сопоставление "поле -> заголовок колонки" в индексы колонок.
+// Заголовок можно указать и номером колонки с 1 ("3"), если заголовки в файле не заполнены
+func ResolveColumnMapping(entityType string, table *TabularTable, mapping map[string]string) (map[string]int, error) {
+	fields := tabularFields[entityType]
+	if fields == nil {
+		return nil, fmt.Errorf("unknown entity type %q", entityType)
+	}
+	headerIndex := make(map[string]int, len(table.Headers))
+	for i, header := range table.Headers {
+		headerIndex[strings.ToLower(header)] = i
+	}
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+	}
+	indices := make(map[string]int, len(mapping))
+	for field, column := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown field %q for %s", field, entityType)
+		}
+		column = strings.TrimSpace(column)
+		if column == "" {
+			continue
+		}
+		index, ok := headerIndex[strings.ToLower(column)]
+		if !ok {
+			number, err := strconv.Atoi(column)
+			if err != nil || number < 1 || number > len(table.Headers) {
+				return nil, fmt.Errorf("column %q for field %q not found in file", column, field)
+			}
+			index = number - 1
+		}
+		indices[field] = index
+	}
+	for _, field := range fields {
+		if _, ok := indices[field.Key]; field.Required && !ok {
+			return nil, fmt.Errorf("required field %q is not mapped", field.Key)
+		}
+	}
+	return indices, nil
+}
+
+// MapTabularRows строит записи по сопоставлению полей с колонками. Пустые строки пропускаются,
+// строки без наименования возвращаются как ошибки
+func MapTabularRows(entityType string, table *TabularTable, columns map[string]int) ([]TabularRecord, []TabularRowError) {
+	attributes := make(map[string]string)
+	for _, field := range tabularFields[entityType] {
+		if field.Attribute != "" {
+			attributes[field.Key] = field.Attribute
+		}
+	}
+
+	records := make([]TabularRecord, 0, len(table.Rows))
+	var rowErrors []TabularRowError
+	for i, row := range table.Rows {
+		if isEmptyRow(row) {
+			continue
+		}
+		record := TabularRecord{Row: table.HeaderRow + i + 1}
+		for field, index := range columns {
+			value := ""
+			if index < len(row) {
+				value = strings.Join(strings.Fields(row[index]), " ")
+			}
+			if value == "" {
+				continue
+			}
+			switch field {
+			case "name":
+				record.Name = value
+			case "code":
+				record.Code = value
+			case "reference":
+				record.Reference = value
+			default:
+				if record.Attributes == nil {
+					record.Attributes = make(map[string]string)
+				}
+				record.Attributes[attributes[field]] = value
+			}
+		}
+		if record.Name == "" {
+			rowErrors = append(rowErrors, TabularRowError{Row: record.Row, Message: "не заполнено наименование"})
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, rowErrors
+}
+
+// normalizeTabularHeader приводит заголовок к виду для сравнения: нижний регистр, только буквы и цифры
+func normalizeTabularHeader(header string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.ReplaceAll(header, "ё", "е")) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// columnSample возвращает непустые значения колонки из первых строк данных
+func columnSample(rows [][]string, index int) []string {
+	var values []string
+	for _, row := range rows {
+		if len(values) >= 50 {
+			break
+		}
+		if index < len(row) {
+			if value := strings.TrimSpace(row[index]); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+func isNumericCell(cell string) bool {
+	hasDigit := false
+	for _, r := range cell {
+		switch {
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case r == '.' || r == ',' || r == '-' || r == ' ' || r == ' ':
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
+
+func countNonEmptyRows(rows [][]string) int {
+	count := 0
+	for _, row := range rows {
+		if !isEmptyRow(row) {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,141 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// TestReadTabularFile_XLSX проверяет выбор листа, поиск строки заголовков и подбор колонок
+func TestReadTabularFile_XLSX(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetSheetRow("Sheet1", "A1", &[]interface{}{"Обложка"})
+	if _, err := f.NewSheet("Прайс"); err != nil {
+		t.Fatalf("NewSheet() error = %v", err)
+	}
+	rows := [][]interface{}{
+		{"Прайс-лист ООО Ромашка на 01.10.2026"},
+		{},
+		{"Артикул", "Наименование товара", "Ед. изм.", "Цена", "Производитель"},
+		{"DIN933-M8", "Болт М8х40 оцинкованный", "шт", 12.5, "Фастек"},
+		{"", "", "", "", ""},
+		{"DIN934-M8", "Гайка М8", "шт", 3.1, ""},
+		{"", "", "шт", 1, ""},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		row := row
+		if err := f.SetSheetRow("Прайс", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow() error = %v", err)
+		}
+	}
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatalf("WriteToBuffer() error = %v", err)
+	}
+
+	table, err := ReadTabularFile("price.xlsx", buf.Bytes(), TabularReadOptions{})
+	if err != nil {
+		t.Fatalf("ReadTabularFile() error = %v", err)
+	}
+	if table.Sheet != "Прайс" || table.HeaderRow != 3 || len(table.Sheets) != 2 {
+		t.Fatalf("sheet = %q, header row = %d, sheets = %v", table.Sheet, table.HeaderRow, table.Sheets)
+	}
+
+	mapping := make(map[string]string)
+	for _, s := range SuggestColumnMapping(TabularEntityNomenclature, table) {
+		mapping[s.Field] = s.Column
+	}
+	want := map[string]string{"article": "Артикул", "name": "Наименование товара", "unit": "Ед. изм.", "manufacturer": "Производитель"}
+	for field, column := range want {
+		if mapping[field] != column {
+			t.Errorf("mapping[%s] = %q, want %q (all: %v)", field, mapping[field], column, mapping)
+		}
+	}
+	if _, ok := mapping["code"]; ok {
+		t.Errorf("price column mapped to code: %v", mapping)
+	}
+
+	columns, err := ResolveColumnMapping(TabularEntityNomenclature, table, mapping)
+	if err != nil {
+		t.Fatalf("ResolveColumnMapping() error = %v", err)
+	}
+	records, rowErrors := MapTabularRows(TabularEntityNomenclature, table, columns)
+	if len(records) != 2 || len(rowErrors) != 1 || rowErrors[0].Row != 7 {
+		t.Fatalf("records = %+v, errors = %+v", records, rowErrors)
+	}
+	if records[0].Row != 4 || records[0].Name != "Болт М8х40 оцинкованный" ||
+		records[0].Attributes["Артикул"] != "DIN933-M8" || records[0].Attributes["ЕдиницаИзмерения"] != "шт" {
+		t.Errorf("record = %+v", records[0])
+	}
+
+	if _, err := ResolveColumnMapping(TabularEntityNomenclature, table, map[string]string{"unit": "Ед. изм."}); err == nil {
+		t.Error("ResolveColumnMapping() without name expected error")
+	}
+	if _, err := ResolveColumnMapping(TabularEntityNomenclature, table, map[string]string{"name": "Нет такой"}); err == nil {
+		t.Error("ResolveColumnMapping() with unknown column expected error")
+	}
+	if columns, err := ResolveColumnMapping(TabularEntityNomenclature, table, map[string]string{"name": "2"}); err != nil || columns["name"] != 1 {
+		t.Errorf("ResolveColumnMapping() by number = %v, %v", columns, err)
+	}
+}
+
+// TestReadTabularFile_CSV проверяет перекодировку Windows-1251, разделитель и подбор колонок по значениям
+func TestReadTabularFile_CSV(t *testing.T) {
+	content := "Контрагент;Налоговый номер;КПП;Почта\n" +
+		"ООО \"Ромашка\";7707083893;773601001;info@romashka.ru\n" +
+		"ИП Иванов И.И.;500100732259;;ivanov@mail.ru\n"
+	data, err := charmap.Windows1251.NewEncoder().Bytes([]byte(content))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	table, err := ReadTabularFile("counterparties.csv", data, TabularReadOptions{})
+	if err != nil {
+		t.Fatalf("ReadTabularFile() error = %v", err)
+	}
+	if table.Delimiter != ";" || table.Encoding != "converted" || table.HeaderRow != 1 || len(table.Rows) != 2 {
+		t.Fatalf("table = %+v", table)
+	}
+
+	mapping := make(map[string]string)
+	for _, s := range SuggestColumnMapping(TabularEntityCounterparties, table) {
+		mapping[s.Field] = s.Column
+		if s.Field == "inn" && s.Reason != "values" {
+			t.Errorf("inn reason = %q, want values", s.Reason)
+		}
+	}
+	want := map[string]string{"name": "Контрагент", "inn": "Налоговый номер", "kpp": "КПП", "email": "Почта"}
+	for field, column := range want {
+		if mapping[field] != column {
+			t.Errorf("mapping[%s] = %q, want %q", field, mapping[field], column)
+		}
+	}
+
+	columns, err := ResolveColumnMapping(TabularEntityCounterparties, table, mapping)
+	if err != nil {
+		t.Fatalf("ResolveColumnMapping() error = %v", err)
+	}
+	records, _ := MapTabularRows(TabularEntityCounterparties, table, columns)
+	if len(records) != 2 || records[0].Name != "ООО \"Ромашка\"" || records[0].Attributes["ИНН"] != "7707083893" {
+		t.Fatalf("records = %+v", records)
+	}
+	if _, ok := records[1].Attributes["КПП"]; ok {
+		t.Errorf("empty KPP stored: %+v", records[1])
+	}
+}
+
+// TestDetectHeaderRow проверяет, что строка-заголовок документа не принимается за заголовки колонок
+func TestDetectHeaderRow(t *testing.T) {
+	rows := [][]string{
+		{"Остатки на складе"},
+		{"Склад: основной", ""},
+		{"Код", "Номенклатура", "Количество"},
+		{"00001", "Кабель ВВГнг 3х2,5", "150"},
+	}
+	if got := detectHeaderRow(rows); got != 3 {
+		t.Errorf("detectHeaderRow() = %d, want 3", got)
+	}
+}
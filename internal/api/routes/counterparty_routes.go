@@ -71,6 +71,8 @@ func RegisterCounterpartyRoutes(mux *http.ServeMux, h *CounterpartyHandlers) {
 		mux.HandleFunc("/api/counterparties/bulk/enrich", h.Handler.HandleBulkEnrichCounterparties)
 		mux.HandleFunc("/api/counterparties/duplicates", h.Handler.HandleCounterpartyDuplicates)
 		mux.HandleFunc("/api/counterparties/duplicates/", h.Handler.HandleCounterpartyDuplicateRoutes)
+		// События слияния контрагентов и их отмена
+		mux.HandleFunc("/api/counterparties/merges/", h.Handler.HandleCounterpartyMergeRoutes)
 		// Автоматический мэппинг контрагентов
 		mux.HandleFunc("/api/projects/", func(w http.ResponseWriter, r *http.Request) {
 			// Обрабатываем /api/projects/{projectId}/counterparties/auto-map
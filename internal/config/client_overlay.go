@@ -0,0 +1,229 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"httpserver/enrichment"
+)
+
+// ClientConfigOverlay клиентские настройки поверх глобальной конфигурации.
+// Пустые поля означают "использовать глобальное значение".
+type ClientConfigOverlay struct {
+	ClientID int `json:"client_id"`
+
+	// Providers клиентские учетные данные и параметры провайдеров (AI и обогащения)
+	Providers map[string]*ClientProviderOverride `json:"providers,omitempty"`
+	// SpendLimits лимиты расходов клиента на внешние провайдеры
+	SpendLimits *ClientSpendLimits `json:"spend_limits,omitempty"`
+	// UploadRateLimit ограничение частоты запросов выгрузки из 1С
+	UploadRateLimit *ClientRateLimit `json:"upload_rate_limit,omitempty"`
+	// MaxWorkers ограничение количества параллельных воркеров нормализации баз клиента
+	MaxWorkers *int `json:"max_workers,omitempty"`
+	// CacheNamespace пространство имен кэшей клиента (по умолчанию client:<id>)
+	CacheNamespace string `json:"cache_namespace,omitempty"`
+}
+
+// ClientProviderOverride переопределение параметров провайдера для клиента
+type ClientProviderOverride struct {
+	APIKey    string `json:"api_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+	BaseURL   string `json:"base_url,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Enabled   *bool  `json:"enabled,omitempty"`
+	Priority  *int   `json:"priority,omitempty"`
+	RateLimit *int   `json:"rate_limit,omitempty"` // Лимит запросов в минуту
+}
+
+// ClientSpendLimits лимиты расходов клиента (в валюте Currency)
+type ClientSpendLimits struct {
+	DailyLimit   float64 `json:"daily_limit,omitempty"`
+	MonthlyLimit float64 `json:"monthly_limit,omitempty"`
+	Currency     string  `json:"currency,omitempty"`
+}
+
+// ClientRateLimit ограничение частоты запросов
+type ClientRateLimit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst,omitempty"`
+}
+
+// ParseClientConfigOverlay разбирает JSON клиентского overlay
+func ParseClientConfigOverlay(data string) (*ClientConfigOverlay, error) {
+	overlay := &ClientConfigOverlay{}
+	if strings.TrimSpace(data) == "" {
+		return overlay, nil
+	}
+	if err := json.Unmarshal([]byte(data), overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse client config overlay: %w", err)
+	}
+	return overlay, nil
+}
+
+// Validate проверяет корректность клиентского overlay
+func (o *ClientConfigOverlay) Validate() error {
+	var errors []string
+
+	for name, p := range o.Providers {
+		if strings.TrimSpace(name) == "" {
+			errors = append(errors, "provider name is required")
+		}
+		if p == nil {
+			errors = append(errors, fmt.Sprintf("provider %s: override is empty", name))
+			continue
+		}
+		if p.RateLimit != nil && *p.RateLimit < 0 {
+			errors = append(errors, fmt.Sprintf("provider %s: rate limit must be non-negative", name))
+		}
+	}
+	if o.SpendLimits != nil {
+		if o.SpendLimits.DailyLimit < 0 || o.SpendLimits.MonthlyLimit < 0 {
+			errors = append(errors, "spend limits must be non-negative")
+		}
+		if o.SpendLimits.DailyLimit > 0 && o.SpendLimits.MonthlyLimit > 0 &&
+			o.SpendLimits.DailyLimit > o.SpendLimits.MonthlyLimit {
+			errors = append(errors, "daily spend limit cannot exceed monthly limit")
+		}
+	}
+	if o.UploadRateLimit != nil {
+		if o.UploadRateLimit.RequestsPerMinute < 0 {
+			errors = append(errors, "upload requests per minute must be non-negative")
+		}
+		if o.UploadRateLimit.Burst < 0 {
+			errors = append(errors, "upload burst must be non-negative")
+		}
+	}
+	if o.MaxWorkers != nil && (*o.MaxWorkers < 1 || *o.MaxWorkers > 100) {
+		errors = append(errors, "max workers must be between 1 and 100")
+	}
+	if strings.ContainsAny(o.CacheNamespace, " \t\n") {
+		errors = append(errors, "cache namespace must not contain whitespace")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("client config overlay validation failed: %s", strings.Join(errors, "; "))
+	}
+	return nil
+}
+
+// EffectiveCacheNamespace возвращает пространство имен кэшей клиента
+func (o *ClientConfigOverlay) EffectiveCacheNamespace() string {
+	if o != nil && o.CacheNamespace != "" {
+		return o.CacheNamespace
+	}
+	clientID := 0
+	if o != nil {
+		clientID = o.ClientID
+	}
+	return ClientCacheNamespace(clientID)
+}
+
+// ClientCacheNamespace возвращает пространство имен кэшей по умолчанию для клиента
+func ClientCacheNamespace(clientID int) string {
+	if clientID <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("client:%d", clientID)
+}
+
+// Provider возвращает переопределение провайдера без учета регистра имени
+func (o *ClientConfigOverlay) Provider(name string) (*ClientProviderOverride, bool) {
+	if o == nil {
+		return nil, false
+	}
+	for key, p := range o.Providers {
+		if p != nil && strings.EqualFold(key, name) {
+			return p, true
+		}
+	}
+	return nil, false
+}
+
+// Masked возвращает копию overlay со скрытыми ключами для ответов API
+func (o *ClientConfigOverlay) Masked() *ClientConfigOverlay {
+	if o == nil {
+		return nil
+	}
+	masked := *o
+	if o.Providers != nil {
+		masked.Providers = make(map[string]*ClientProviderOverride, len(o.Providers))
+		for name, p := range o.Providers {
+			if p == nil {
+				continue
+			}
+			copyP := *p
+			copyP.APIKey = maskSecret(p.APIKey)
+			copyP.SecretKey = maskSecret(p.SecretKey)
+			masked.Providers[name] = &copyP
+		}
+	}
+	return &masked
+}
+
+// maskSecret скрывает секрет, оставляя последние 4 символа
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) <= 4 {
+		return "****"
+	}
+	return "****" + secret[len(secret)-4:]
+}
+
+// ApplyClientOverlay возвращает копию глобальной конфигурации с примененным overlay клиента.
+// Глобальная конфигурация не изменяется.
+func ApplyClientOverlay(base *Config, overlay *ClientConfigOverlay) *Config {
+	if base == nil {
+		return nil
+	}
+	effective := *base
+	if overlay == nil {
+		return &effective
+	}
+
+	if p, ok := overlay.Provider("arliai"); ok {
+		if p.APIKey != "" {
+			effective.ArliaiAPIKey = p.APIKey
+		}
+		if p.Model != "" {
+			effective.ArliaiModel = p.Model
+		}
+	}
+
+	if base.Enrichment != nil {
+		enrichmentCopy := *base.Enrichment
+		enrichmentCopy.Services = make(map[string]*enrichment.EnricherConfig, len(base.Enrichment.Services))
+		for name, svc := range base.Enrichment.Services {
+			if svc == nil {
+				continue
+			}
+			svcCopy := *svc
+			if p, ok := overlay.Provider(name); ok {
+				if p.APIKey != "" {
+					svcCopy.APIKey = p.APIKey
+				}
+				if p.SecretKey != "" {
+					svcCopy.SecretKey = p.SecretKey
+				}
+				if p.BaseURL != "" {
+					svcCopy.BaseURL = p.BaseURL
+				}
+				if p.Enabled != nil {
+					svcCopy.Enabled = *p.Enabled
+				}
+				if p.Priority != nil {
+					svcCopy.Priority = *p.Priority
+				}
+				if p.RateLimit != nil {
+					svcCopy.MaxRequests = *p.RateLimit
+				}
+			}
+			enrichmentCopy.Services[name] = &svcCopy
+		}
+		effective.Enrichment = &enrichmentCopy
+	}
+
+	return &effective
+}
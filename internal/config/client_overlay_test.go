@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+
+	"httpserver/enrichment"
+)
+
+func TestApplyClientOverlay(t *testing.T) {
+	base := &Config{
+		ArliaiAPIKey: "global-key",
+		ArliaiModel:  "GLM-4.5-Air",
+		Enrichment: &EnrichmentConfig{
+			Services: map[string]*enrichment.EnricherConfig{
+				"dadata": {APIKey: "global-dadata", Enabled: true, MaxRequests: 100},
+			},
+		},
+	}
+
+	rateLimit := 10
+	overlay := &ClientConfigOverlay{
+		ClientID: 5,
+		Providers: map[string]*ClientProviderOverride{
+			"Arliai": {APIKey: "client-key", Model: "GLM-4.6"},
+			"dadata": {APIKey: "client-dadata", RateLimit: &rateLimit},
+		},
+	}
+
+	effective := ApplyClientOverlay(base, overlay)
+
+	if effective.ArliaiAPIKey != "client-key" || effective.ArliaiModel != "GLM-4.6" {
+		t.Errorf("arliai = %q/%q, want client-key/GLM-4.6", effective.ArliaiAPIKey, effective.ArliaiModel)
+	}
+	dadata := effective.Enrichment.Services["dadata"]
+	if dadata.APIKey != "client-dadata" || dadata.MaxRequests != 10 {
+		t.Errorf("dadata = %+v, want client key and 10 requests", dadata)
+	}
+	// Глобальная конфигурация не должна изменяться
+	if base.ArliaiAPIKey != "global-key" || base.Enrichment.Services["dadata"].APIKey != "global-dadata" {
+		t.Error("ApplyClientOverlay must not modify the global config")
+	}
+}
+
+func TestClientConfigOverlayValidate(t *testing.T) {
+	workers := 0
+	tests := []struct {
+		name    string
+		overlay ClientConfigOverlay
+		wantErr bool
+	}{
+		{"empty", ClientConfigOverlay{}, false},
+		{"daily above monthly", ClientConfigOverlay{SpendLimits: &ClientSpendLimits{DailyLimit: 100, MonthlyLimit: 50}}, true},
+		{"negative rate", ClientConfigOverlay{UploadRateLimit: &ClientRateLimit{RequestsPerMinute: -1}}, true},
+		{"zero workers", ClientConfigOverlay{MaxWorkers: &workers}, true},
+		{"namespace with space", ClientConfigOverlay{CacheNamespace: "client 1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.overlay.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestClientConfigOverlayMasked(t *testing.T) {
+	overlay := &ClientConfigOverlay{
+		ClientID:  1,
+		Providers: map[string]*ClientProviderOverride{"arliai": {APIKey: "secret-api-key-1234"}},
+	}
+	masked := overlay.Masked()
+	if got := masked.Providers["arliai"].APIKey; got != "****1234" {
+		t.Errorf("masked key = %q, want ****1234", got)
+	}
+	if overlay.Providers["arliai"].APIKey != "secret-api-key-1234" {
+		t.Error("Masked() must not modify the original overlay")
+	}
+	if ns := overlay.EffectiveCacheNamespace(); ns != "client:1" {
+		t.Errorf("EffectiveCacheNamespace() = %q, want client:1", ns)
+	}
+}
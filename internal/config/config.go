@@ -36,7 +36,8 @@ type Config struct {
 	LogLevel       string `json:"log_level"`
 
 	// Нормализация
-	NormalizerEventsBufferSize int `json:"normalizer_events_buffer_size"`
+	NormalizerEventsBufferSize int    `json:"normalizer_events_buffer_size"`
+	NERModelDir                string `json:"ner_model_dir"` // Каталог моделей NER (cmd/train_ner)
 
 	// Мульти-провайдерная нормализация
 	MultiProviderEnabled bool          `json:"multi_provider_enabled"`
@@ -48,6 +49,44 @@ type Config struct {
 
 	// Веб-поиск для валидации
 	WebSearch *WebSearchConfig `json:"web_search"`
+
+	// HTTPS и клиентские сертификаты
+	TLS *TLSConfig `json:"tls"`
+
+	// Импорт файлов обмена 1С из каталога
+	Exchange *ExchangeConfig `json:"exchange"`
+}
+
+// DefaultNERModelDir каталог моделей NER по умолчанию (совпадает с -models у cmd/train_ner)
+const DefaultNERModelDir = "models/ner"
+
+// Режимы проверки клиентских сертификатов на эндпоинтах выгрузки
+const (
+	ClientAuthNone     = "none"     // сертификат не запрашивается
+	ClientAuthOptional = "optional" // проверяется, если предъявлен
+	ClientAuthRequire  = "require"  // выгрузка без сертификата отклоняется
+)
+
+// TLSConfig конфигурация HTTPS
+type TLSConfig struct {
+	Enabled  bool   `json:"enabled"`
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// SelfSigned выпускает самоподписанный сертификат для разработки, если файлов нет
+	SelfSigned bool `json:"self_signed"`
+	// ClientCAFile корневые сертификаты для проверки клиентских сертификатов 1С
+	ClientCAFile     string `json:"client_ca_file"`
+	UploadClientAuth string `json:"upload_client_auth"`
+}
+
+// DefaultExchangeWatchInterval интервал проверки каталога файлов обмена по умолчанию
+const DefaultExchangeWatchInterval = 10 * time.Second
+
+// ExchangeConfig конфигурация импорта файлов обмена 1С из каталога
+type ExchangeConfig struct {
+	WatchDir      string        `json:"watch_dir"`      // Каталог файлов обмена, пусто - наблюдение выключено
+	WatchInterval time.Duration `json:"watch_interval"` // Интервал проверки каталога
+	DatabaseID    string        `json:"database_id"`    // Переопределяет database_id из заголовка файла
 }
 
 // EnrichmentConfig конфигурация обогащения
@@ -57,6 +96,17 @@ type EnrichmentConfig struct {
 	MinQualityScore float64                               `json:"min_quality_score"`
 	Services        map[string]*enrichment.EnricherConfig `json:"services"`
 	Cache           *enrichment.CacheConfig               `json:"cache"`
+	Reverification  *ReverificationConfig                 `json:"reverification,omitempty"`
+}
+
+// ReverificationConfig расписание повторной проверки нормализованных контрагентов по реестрам
+type ReverificationConfig struct {
+	Enabled        bool          `json:"enabled"`
+	Interval       time.Duration `json:"interval"`          // Период запуска планировщика
+	MaxAge         time.Duration `json:"max_age"`           // Срок актуальности данных при низком риске
+	HighRiskMaxAge time.Duration `json:"high_risk_max_age"` // Срок актуальности данных при высоком риске
+	BatchSize      int           `json:"batch_size"`        // Контрагентов за один запуск
+	ApplyChanges   bool          `json:"apply_changes"`     // Записывать новые реквизиты в контрагента
 }
 
 // LoadConfig загружает конфигурацию из сервисной БД (если serviceDB передан) или из переменных окружения
@@ -93,11 +143,25 @@ func LoadConfig(serviceDB ...*database.ServiceDB) (*Config, error) {
 					LogBufferSize:              cfgJSON.LogBufferSize,
 					LogLevel:                   cfgJSON.LogLevel,
 					NormalizerEventsBufferSize: cfgJSON.NormalizerEventsBufferSize,
+					NERModelDir:                cfgJSON.NERModelDir,
 					MultiProviderEnabled:       cfgJSON.MultiProviderEnabled,
 					AggregationStrategy:        cfgJSON.AggregationStrategy,
 					AITimeout:                  aiTimeout,
 					Enrichment:                 cfgJSON.Enrichment,
 					WebSearch:                  cfgJSON.WebSearch,
+					TLS:                        cfgJSON.TLS,
+					Exchange:                   cfgJSON.Exchange,
+				}
+				if config.TLS == nil {
+					config.TLS = LoadTLSConfig()
+				}
+				if config.Exchange == nil {
+					if config.Exchange, err = LoadExchangeConfig(); err != nil {
+						return nil, fmt.Errorf("invalid config: %w", err)
+					}
+				}
+				if config.NERModelDir == "" {
+					config.NERModelDir = getEnv("NER_MODEL_DIR", DefaultNERModelDir)
 				}
 
 				log.Printf("Config loaded from service database")
@@ -115,6 +179,10 @@ func LoadConfig(serviceDB ...*database.ServiceDB) (*Config, error) {
 	}
 
 	// Fallback на переменные окружения
+	exchange, err := LoadExchangeConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
 	config = &Config{
 		// Сервер
 		Port: getEnv("SERVER_PORT", "9999"),
@@ -139,6 +207,7 @@ func LoadConfig(serviceDB ...*database.ServiceDB) (*Config, error) {
 
 		// Нормализация
 		NormalizerEventsBufferSize: getEnvInt("NORMALIZER_EVENTS_BUFFER_SIZE", 100),
+		NERModelDir:                getEnv("NER_MODEL_DIR", DefaultNERModelDir),
 
 		// Мульти-провайдерная нормализация
 		MultiProviderEnabled: getEnv("MULTI_PROVIDER_ENABLED", "false") == "true",
@@ -150,6 +219,12 @@ func LoadConfig(serviceDB ...*database.ServiceDB) (*Config, error) {
 
 		// Веб-поиск
 		WebSearch: LoadWebSearchConfig(),
+
+		// HTTPS
+		TLS: LoadTLSConfig(),
+
+		// Файлы обмена 1С
+		Exchange: exchange,
 	}
 
 	// Валидация
@@ -223,6 +298,14 @@ func LoadEnrichmentConfig() *EnrichmentConfig {
 			TTL:             getEnvDuration("ENRICHMENT_CACHE_TTL", 24*time.Hour),
 			CleanupInterval: getEnvDuration("ENRICHMENT_CACHE_CLEANUP", 1*time.Hour),
 		},
+		Reverification: &ReverificationConfig{
+			Enabled:        getEnv("ENRICHMENT_REVERIFY_ENABLED", "false") == "true",
+			Interval:       getEnvDuration("ENRICHMENT_REVERIFY_INTERVAL", time.Hour),
+			MaxAge:         getEnvDuration("ENRICHMENT_REVERIFY_MAX_AGE", 30*24*time.Hour),
+			HighRiskMaxAge: getEnvDuration("ENRICHMENT_REVERIFY_HIGH_RISK_MAX_AGE", 7*24*time.Hour),
+			BatchSize:      getEnvInt("ENRICHMENT_REVERIFY_BATCH", 50),
+			ApplyChanges:   getEnv("ENRICHMENT_REVERIFY_APPLY", "true") == "true",
+		},
 	}
 }
 
@@ -264,6 +347,36 @@ type WebSearchConfig struct {
 	BaseURL         string        `json:"base_url"`
 }
 
+// LoadTLSConfig загружает конфигурацию HTTPS из переменных окружения
+func LoadTLSConfig() *TLSConfig {
+	return &TLSConfig{
+		Enabled:          getEnv("TLS_ENABLED", "false") == "true",
+		CertFile:         getEnv("TLS_CERT_FILE", "certs/server.crt"),
+		KeyFile:          getEnv("TLS_KEY_FILE", "certs/server.key"),
+		SelfSigned:       getEnv("TLS_SELF_SIGNED", "false") == "true",
+		ClientCAFile:     os.Getenv("TLS_CLIENT_CA_FILE"),
+		UploadClientAuth: getEnv("TLS_UPLOAD_CLIENT_AUTH", ClientAuthNone),
+	}
+}
+
+// LoadExchangeConfig загружает конфигурацию импорта файлов обмена из переменных окружения.
+// Некорректный EXCHANGE_WATCH_INTERVAL возвращается как ошибка, а не заменяется значением по умолчанию.
+func LoadExchangeConfig() (*ExchangeConfig, error) {
+	cfg := &ExchangeConfig{
+		WatchDir:      os.Getenv("EXCHANGE_WATCH_DIR"),
+		WatchInterval: DefaultExchangeWatchInterval,
+		DatabaseID:    os.Getenv("EXCHANGE_DATABASE_ID"),
+	}
+	if value := os.Getenv("EXCHANGE_WATCH_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXCHANGE_WATCH_INTERVAL %q: %w", value, err)
+		}
+		cfg.WatchInterval = interval
+	}
+	return cfg, nil
+}
+
 // LoadWebSearchConfig загружает конфигурацию веб-поиска
 func LoadWebSearchConfig() *WebSearchConfig {
 	enabled := getEnv("WEB_SEARCH_ENABLED", "true") == "true"
@@ -297,11 +410,14 @@ type configJSON struct {
 	LogBufferSize              int                        `json:"log_buffer_size"`
 	LogLevel                   string                     `json:"log_level"`
 	NormalizerEventsBufferSize int                        `json:"normalizer_events_buffer_size"`
+	NERModelDir                string                     `json:"ner_model_dir"`
 	MultiProviderEnabled       bool                       `json:"multi_provider_enabled"`
 	AggregationStrategy        string                     `json:"aggregation_strategy"`
 	AITimeout                  string                     `json:"ai_timeout"` // time.Duration как строка
 	Enrichment                 *EnrichmentConfig          `json:"enrichment"`
 	WebSearch                  *WebSearchConfig           `json:"web_search"`
+	TLS                        *TLSConfig                 `json:"tls"`
+	Exchange                   *ExchangeConfig            `json:"exchange"`
 }
 
 // SaveConfig сохраняет конфигурацию в сервисную БД
@@ -329,11 +445,14 @@ func SaveConfigWithHistory(cfg *Config, serviceDB *database.ServiceDB, changedBy
 		LogBufferSize:              cfg.LogBufferSize,
 		LogLevel:                   cfg.LogLevel,
 		NormalizerEventsBufferSize: cfg.NormalizerEventsBufferSize,
+		NERModelDir:                cfg.NERModelDir,
 		MultiProviderEnabled:       cfg.MultiProviderEnabled,
 		AggregationStrategy:        cfg.AggregationStrategy,
 		AITimeout:                  cfg.AITimeout.String(),
 		Enrichment:                 cfg.Enrichment,
 		WebSearch:                  cfg.WebSearch,
+		TLS:                        cfg.TLS,
+		Exchange:                   cfg.Exchange,
 	}
 
 	configJSONBytes, err := json.Marshal(cfgJSON)
@@ -65,3 +65,78 @@ func TestConfigLogLevelDefault(t *testing.T) {
 	}
 }
 
+// TestTLSConfig_Validate проверяет согласованность режимов клиентских сертификатов
+func TestTLSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     TLSConfig
+		wantErr bool
+	}{
+		{"выключен", TLSConfig{}, false},
+		{"https без mTLS", TLSConfig{Enabled: true, CertFile: "a", KeyFile: "b", UploadClientAuth: ClientAuthNone}, false},
+		{"mTLS без CA", TLSConfig{Enabled: true, CertFile: "a", KeyFile: "b", UploadClientAuth: ClientAuthRequire}, true},
+		{"mTLS без https", TLSConfig{UploadClientAuth: ClientAuthRequire, ClientCAFile: "ca"}, true},
+		{"неизвестный режим", TLSConfig{UploadClientAuth: "always"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnrichmentConfig_ValidateReverification(t *testing.T) {
+	valid := ReverificationConfig{Enabled: true, Interval: time.Hour, MaxAge: 30 * 24 * time.Hour, HighRiskMaxAge: 7 * 24 * time.Hour, BatchSize: 10}
+	tests := []struct {
+		name    string
+		modify  func(*ReverificationConfig)
+		wantErr bool
+	}{
+		{"корректная", func(*ReverificationConfig) {}, false},
+		{"выключена", func(c *ReverificationConfig) { c.Enabled = false; c.BatchSize = 0 }, false},
+		{"короткий интервал", func(c *ReverificationConfig) { c.Interval = time.Second }, true},
+		{"срок меньше срока высокого риска", func(c *ReverificationConfig) { c.MaxAge = time.Hour }, true},
+		{"пустая порция", func(c *ReverificationConfig) { c.BatchSize = 0 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rv := valid
+			tt.modify(&rv)
+			ec := GetDefaultEnrichmentConfig()
+			ec.Reverification = &rv
+			if err := ec.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLoadExchangeConfig проверяет, что некорректный интервал наблюдения не заменяется значением по умолчанию
+func TestLoadExchangeConfig(t *testing.T) {
+	t.Setenv("EXCHANGE_WATCH_DIR", "exchange")
+	t.Setenv("EXCHANGE_DATABASE_ID", "db-1")
+
+	t.Setenv("EXCHANGE_WATCH_INTERVAL", "30s")
+	cfg, err := LoadExchangeConfig()
+	if err != nil || cfg.WatchDir != "exchange" || cfg.WatchInterval != 30*time.Second || cfg.DatabaseID != "db-1" {
+		t.Fatalf("LoadExchangeConfig() = %+v, err %v", cfg, err)
+	}
+
+	t.Setenv("EXCHANGE_WATCH_INTERVAL", "10")
+	if _, err := LoadExchangeConfig(); err == nil {
+		t.Error("LoadExchangeConfig() with interval without unit should fail")
+	}
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig() with invalid EXCHANGE_WATCH_INTERVAL should fail")
+	}
+
+	t.Setenv("EXCHANGE_WATCH_INTERVAL", "100ms")
+	if cfg, err := LoadExchangeConfig(); err != nil || cfg.Validate() == nil {
+		t.Errorf("interval 100ms: load error %v, Validate() should fail", err)
+	}
+	if err := (&ExchangeConfig{WatchInterval: 0}).Validate(); err != nil {
+		t.Errorf("disabled watcher: Validate() error = %v", err)
+	}
+}
@@ -108,6 +108,20 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Валидация HTTPS
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("tls config: %v", err))
+		}
+	}
+
+	// Валидация импорта файлов обмена
+	if c.Exchange != nil {
+		if err := c.Exchange.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("exchange config: %v", err))
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("validation errors: %s", strings.Join(errors, "; "))
 	}
@@ -154,6 +168,19 @@ func (ec *EnrichmentConfig) Validate() error {
 		}
 	}
 
+	// Валидация повторной проверки
+	if rv := ec.Reverification; rv != nil && rv.Enabled {
+		if rv.Interval < time.Minute {
+			errors = append(errors, "reverification interval must be at least 1 minute")
+		}
+		if rv.HighRiskMaxAge <= 0 || rv.MaxAge < rv.HighRiskMaxAge {
+			errors = append(errors, "reverification max age must be positive and not less than high risk max age")
+		}
+		if rv.BatchSize < 1 {
+			errors = append(errors, "reverification batch size must be at least 1")
+		}
+	}
+
 	if len(errors) > 0 {
 		return fmt.Errorf("enrichment validation errors: %s", strings.Join(errors, "; "))
 	}
@@ -161,6 +188,43 @@ func (ec *EnrichmentConfig) Validate() error {
 	return nil
 }
 
+// Validate проверяет корректность конфигурации HTTPS
+func (tc *TLSConfig) Validate() error {
+	var errors []string
+
+	switch tc.UploadClientAuth {
+	case "", ClientAuthNone, ClientAuthOptional, ClientAuthRequire:
+	default:
+		errors = append(errors, fmt.Sprintf("invalid upload client auth: %s (valid: %s, %s, %s)",
+			tc.UploadClientAuth, ClientAuthNone, ClientAuthOptional, ClientAuthRequire))
+	}
+
+	if tc.Enabled {
+		if tc.CertFile == "" || tc.KeyFile == "" {
+			errors = append(errors, "cert file and key file are required when TLS is enabled")
+		}
+		if tc.UploadClientAuth != "" && tc.UploadClientAuth != ClientAuthNone && tc.ClientCAFile == "" {
+			errors = append(errors, "client CA file is required for upload client certificate auth")
+		}
+	} else if tc.UploadClientAuth == ClientAuthRequire {
+		errors = append(errors, "upload client certificate auth requires TLS to be enabled")
+	}
+
+	if len(errors) > 0 {
+		return fmt.Errorf("tls validation errors: %s", strings.Join(errors, "; "))
+	}
+
+	return nil
+}
+
+// Validate проверяет корректность конфигурации импорта файлов обмена
+func (ec *ExchangeConfig) Validate() error {
+	if ec.WatchDir != "" && ec.WatchInterval < time.Second {
+		return fmt.Errorf("exchange watch interval must be at least 1 second, got %v", ec.WatchInterval)
+	}
+	return nil
+}
+
 // GetDefaults возвращает конфигурацию со значениями по умолчанию
 func GetDefaults() *Config {
 	return &Config{
@@ -320,6 +320,7 @@ type DashboardOverviewResponse struct {
 	RecentActivity      []ActivityLog        `json:"recent_activity"`
 	SystemHealth        string              `json:"system_health"` // "ok", "warning", "error"
 	ProviderMetrics     interface{}         `json:"provider_metrics,omitempty"` // MonitoringData, но без прямого импорта
+	QualityAnomalies    interface{}         `json:"quality_anomalies,omitempty"` // QualityAnomalySummary, но без прямого импорта
 }
 
 // API Models для получения данных
@@ -69,6 +69,11 @@ func (a *ArliaiProviderAdapter) GetProviderName() string {
 	return a.name
 }
 
+// ReportsUsage AIClient сам учитывает токены по ответу API
+func (a *ArliaiProviderAdapter) ReportsUsage() bool {
+	return true
+}
+
 func (a *ArliaiProviderAdapter) IsEnabled() bool {
 	return a.client != nil
 }
@@ -93,6 +98,11 @@ func (o *OpenRouterProviderAdapter) GetCompletion(systemPrompt, userPrompt strin
 		{Role: "user", Content: userPrompt},
 	}
 
+	return o.client.ChatCompletion(o.GetModelName(), messages)
+}
+
+// GetModelName возвращает модель OpenRouter
+func (o *OpenRouterProviderAdapter) GetModelName() string {
 	// Получаем модель из конфигурации с приоритетом: Config > Env > Default
 	model := ""
 
@@ -105,8 +115,7 @@ func (o *OpenRouterProviderAdapter) GetCompletion(systemPrompt, userPrompt strin
 	if model == "" {
 		model = "z.ai/glm-4.5" // z.ai/glm-4.5 как приоритетная модель по умолчанию
 	}
-
-	return o.client.ChatCompletion(model, messages)
+	return model
 }
 
 func (o *OpenRouterProviderAdapter) GetProviderName() string {
@@ -136,6 +145,15 @@ func (h *HuggingFaceProviderAdapter) GetCompletion(systemPrompt, userPrompt stri
 		{Role: "user", Content: userPrompt},
 	}
 
+	// Используем контекст с таймаутом для предотвращения зависания
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return h.client.ChatCompletionWithContext(ctx, h.GetModelName(), messages)
+}
+
+// GetModelName возвращает модель Hugging Face
+func (h *HuggingFaceProviderAdapter) GetModelName() string {
 	// Получаем модель из WorkerConfigManager, если он доступен
 	model := ""
 
@@ -147,12 +165,7 @@ func (h *HuggingFaceProviderAdapter) GetCompletion(systemPrompt, userPrompt stri
 			model = "mistralai/Mistral-7B-Instruct-v0.1"
 		}
 	}
-
-	// Используем контекст с таймаутом для предотвращения зависания
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	return h.client.ChatCompletionWithContext(ctx, model, messages)
+	return model
 }
 
 func (h *HuggingFaceProviderAdapter) GetProviderName() string {
@@ -182,18 +195,22 @@ func (e *EdenAIProviderAdapter) GetCompletion(systemPrompt, userPrompt string) (
 		{Role: "user", Content: userPrompt},
 	}
 
+	// Используем контекст с таймаутом для предотвращения зависания
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	return e.client.ChatCompletionWithContext(ctx, e.GetModelName(), messages)
+}
+
+// GetModelName возвращает модель Eden AI
+func (e *EdenAIProviderAdapter) GetModelName() string {
 	// Получаем модель из переменной окружения или используем дефолтную
 	model := os.Getenv("EDENAI_MODEL")
 	if model == "" {
 		// Используем дефолтную модель Eden AI
 		model = "openai/gpt-3.5-turbo"
 	}
-
-	// Используем контекст с таймаутом для предотвращения зависания
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	return e.client.ChatCompletionWithContext(ctx, model, messages)
+	return model
 }
 
 func (e *EdenAIProviderAdapter) GetProviderName() string {
@@ -230,6 +247,18 @@ type ProviderOrchestrator struct {
 	timeout           time.Duration
 	logger            *slog.Logger // Структурированный логгер
 	mu                sync.RWMutex
+
+	usageMu   sync.Mutex
+	usage     map[string]*ProviderUsage // Потребление и стоимость по провайдерам
+	usageTags nomenclature.UsageTags    // Атрибуты вызовов для учета затрат
+}
+
+// ProviderUsage накопленное потребление провайдера с момента запуска
+type ProviderUsage struct {
+	Requests     int64   `json:"requests"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	Cost         float64 `json:"cost"`
 }
 
 // NewProviderOrchestrator создает новый оркестратор провайдеров.
@@ -251,6 +280,7 @@ func NewProviderOrchestrator(timeout time.Duration, monitoringManager *monitorin
 		monitoringManager: monitoringManager,
 		metricsCollector:  servermonitoring.NewMetricsCollector(),
 		providers:         make(map[string]*ProviderWrapper),
+		usage:             make(map[string]*ProviderUsage),
 		strategy:          strategy,
 		timeout:           timeout,
 		logger:            logger,
@@ -327,6 +357,11 @@ func (po *ProviderOrchestrator) Normalize(systemPrompt, userPrompt string) (*Agg
 		return nil, fmt.Errorf("no active providers available")
 	}
 
+	usageTags := po.GetUsageTags()
+	if err := nomenclature.CheckBudget(usageTags); err != nil {
+		return nil, err
+	}
+
 	// Генерируем request_id для трассировки
 	requestID := fmt.Sprintf("req_%d", time.Now().UnixNano())
 	logger := po.logger.With("request_id", requestID)
@@ -369,6 +404,9 @@ func (po *ProviderOrchestrator) Normalize(systemPrompt, userPrompt string) (*Agg
 				latencyMs := float64(result.Duration.Milliseconds())
 				po.monitoringManager.RecordResponse(p.ID, latencyMs, err)
 			}
+			if err == nil {
+				po.recordUsage(p, usageTags, systemPrompt, userPrompt, response)
+			}
 			if err != nil {
 				errorType := "unknown"
 				errorMsg := err.Error()
@@ -485,9 +523,70 @@ func (po *ProviderOrchestrator) Normalize(systemPrompt, userPrompt string) (*Agg
 	return aggregated, nil
 }
 
-// GetMetrics возвращает метрики оркестратора
+// GetMetrics возвращает метрики оркестратора: потребление токенов и стоимость по провайдерам
 func (po *ProviderOrchestrator) GetMetrics() map[string]interface{} {
-	return map[string]interface{}{}
+	po.usageMu.Lock()
+	defer po.usageMu.Unlock()
+
+	providers := make(map[string]ProviderUsage, len(po.usage))
+	var totalCost float64
+	var totalRequests int64
+	for id, u := range po.usage {
+		providers[id] = *u
+		totalCost += u.Cost
+		totalRequests += u.Requests
+	}
+	return map[string]interface{}{
+		"providers":      providers,
+		"total_requests": totalRequests,
+		"total_cost":     totalCost,
+	}
+}
+
+// SetUsageTags задает атрибуты (клиент, проект, сессия) для учета затрат на вызовы
+func (po *ProviderOrchestrator) SetUsageTags(tags nomenclature.UsageTags) {
+	po.usageMu.Lock()
+	po.usageTags = tags
+	po.usageMu.Unlock()
+}
+
+// GetUsageTags возвращает атрибуты учета затрат
+func (po *ProviderOrchestrator) GetUsageTags() nomenclature.UsageTags {
+	po.usageMu.Lock()
+	defer po.usageMu.Unlock()
+	return po.usageTags
+}
+
+// recordUsage учитывает успешный вызов провайдера. Провайдеры, не возвращающие usage,
+// учитываются по оценке токенов; Arliai учитывается самим AIClient.
+func (po *ProviderOrchestrator) recordUsage(p *ProviderWrapper, tags nomenclature.UsageTags, systemPrompt, userPrompt, response string) {
+	record := nomenclature.UsageRecord{
+		Provider:     p.ID,
+		InputTokens:  nomenclature.EstimateTokens(systemPrompt, userPrompt),
+		OutputTokens: nomenclature.EstimateTokens(response),
+		Estimated:    true,
+		Tags:         tags,
+	}
+	if named, ok := p.Client.(ModelNamedClient); ok {
+		record.Model = named.GetModelName()
+	}
+
+	var cost float64
+	if reporter, ok := p.Client.(UsageReportingClient); !ok || !reporter.ReportsUsage() {
+		cost = nomenclature.RecordUsage(record)
+	}
+
+	po.usageMu.Lock()
+	defer po.usageMu.Unlock()
+	u, ok := po.usage[p.ID]
+	if !ok {
+		u = &ProviderUsage{}
+		po.usage[p.ID] = u
+	}
+	u.Requests++
+	u.InputTokens += int64(record.InputTokens)
+	u.OutputTokens += int64(record.OutputTokens)
+	u.Cost += cost
 }
 
 // executeWithContext выполняет запрос с поддержкой контекста
@@ -9,3 +9,14 @@ type ProviderClient interface {
 	// IsEnabled проверяет, активен ли провайдер
 	IsEnabled() bool
 }
+
+// UsageReportingClient провайдер, который сам передает потребление токенов в учет затрат
+// (оркестратор не должен учитывать такие вызовы повторно)
+type UsageReportingClient interface {
+	ReportsUsage() bool
+}
+
+// ModelNamedClient провайдер, сообщающий используемую модель для учета затрат
+type ModelNamedClient interface {
+	GetModelName() string
+}
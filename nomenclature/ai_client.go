@@ -8,11 +8,11 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
 
-	"golang.org/x/time/rate"
 )
 
 // CircuitBreakerState состояние Circuit Breaker
@@ -42,8 +42,10 @@ type AIClient struct {
 	baseURL        string
 	model          string
 	httpClient     *http.Client
-	rateLimiter    *rate.Limiter     // Rate limiter для защиты от превышения квот API
+	rateLimiter    *ProviderRateLimiter // Адаптивное ограничение скорости по ключу и модели (общее для процессов через QuotaStore)
 	circuitBreaker *CircuitBreaker   // Circuit breaker для защиты от каскадных сбоев
+	usageMu        sync.RWMutex
+	usageTags      UsageTags         // Атрибуты вызовов для учета затрат
 }
 
 // AIRequest структура запроса к API
@@ -72,6 +74,10 @@ type AIResponse struct {
 		Message string `json:"message"`
 		Type    string `json:"type"`
 	} `json:"error,omitempty"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
 }
 
 // AIProcessingResult результат обработки ИИ
@@ -85,9 +91,8 @@ type AIProcessingResult struct {
 
 // NewAIClient создает новый клиент для работы с Arliai API
 func NewAIClient(apiKey, model string) *AIClient {
-	// Rate limiter: 60 запросов в минуту (1 запрос/сек) с burst=5
-	// Это защищает от превышения квот API и неконтролируемых расходов
-	limiter := rate.NewLimiter(rate.Every(time.Second), 5)
+	// Rate limiter: начинает с 60 запросов в минуту (1 запрос/сек) с burst=5 и адаптируется
+	// к ответам провайдера (429, Retry-After, X-RateLimit-*); см. QuotaManager
 
 	// Circuit Breaker: защита от каскадных сбоев
 	// - 5 ошибок подряд -> открываем breaker (блокируем запросы)
@@ -123,7 +128,7 @@ func NewAIClient(apiKey, model string) *AIClient {
 			Timeout:   15 * time.Second, // Общий таймаут для запроса
 			Transport: transport,
 		},
-		rateLimiter:    limiter,
+		rateLimiter:    Quotas().Limiter("arliai", apiKey, model),
 		circuitBreaker: breaker,
 	}
 }
@@ -131,9 +136,8 @@ func NewAIClient(apiKey, model string) *AIClient {
 
 // NewAIClientWithBaseURL создает новый клиент с указанным baseURL
 func NewAIClientWithBaseURL(apiKey, model, baseURL string) *AIClient {
-	// Rate limiter: 60 запросов в минуту (1 запрос/сек) с burst=5
-	// Это защищает от превышения квот API и неконтролируемых расходов
-	limiter := rate.NewLimiter(rate.Every(time.Second), 5)
+	// Rate limiter: начинает с 60 запросов в минуту (1 запрос/сек) с burst=5 и адаптируется
+	// к ответам провайдера (429, Retry-After, X-RateLimit-*); см. QuotaManager
 
 	// Circuit Breaker: защита от каскадных сбоев
 	// - 5 ошибок подряд -> открываем breaker (блокируем запросы)
@@ -169,7 +173,7 @@ func NewAIClientWithBaseURL(apiKey, model, baseURL string) *AIClient {
 			Timeout:   15 * time.Second, // Общий таймаут для запроса
 			Transport: transport,
 		},
-		rateLimiter:    limiter,
+		rateLimiter:    Quotas().Limiter(quotaProviderForURL(baseURL), apiKey, model),
 		circuitBreaker: breaker,
 	}
 }
@@ -182,6 +186,11 @@ func (c *AIClient) ProcessProduct(productName, systemPrompt string) (*AIProcessi
 		return nil, fmt.Errorf("circuit breaker is open (state: %s), API calls are temporarily blocked", c.circuitBreaker.getState())
 	}
 
+	// Проверяем бюджет до отправки платного запроса
+	if err := CheckBudget(c.GetUsageTags()); err != nil {
+		return nil, err
+	}
+
 	messages := []Message{
 		{
 			Role:    "system",
@@ -240,6 +249,7 @@ func (c *AIClient) ProcessProduct(productName, systemPrompt string) (*AIProcessi
 		// Детальная обработка различных HTTP статусов
 		var errorMsg string
 		if resp.StatusCode == http.StatusTooManyRequests {
+			c.rateLimiter.OnThrottled(resp.Header)
 			errorMsg = fmt.Sprintf("rate limit exceeded (429): %s", string(body))
 		} else if resp.StatusCode == http.StatusUnauthorized {
 			errorMsg = fmt.Sprintf("unauthorized (401): invalid API key - %s", string(body))
@@ -272,6 +282,7 @@ func (c *AIClient) ProcessProduct(productName, systemPrompt string) (*AIProcessi
 		   strings.Contains(strings.ToLower(errorMsg), "rate limit") ||
 		   strings.Contains(strings.ToLower(errorType), "quota") ||
 		   strings.Contains(strings.ToLower(errorType), "rate_limit") {
+			c.rateLimiter.OnThrottled(resp.Header)
 			return nil, fmt.Errorf("quota/rate limit error: %s (type: %s)", errorMsg, errorType)
 		}
 		
@@ -283,6 +294,9 @@ func (c *AIClient) ProcessProduct(productName, systemPrompt string) (*AIProcessi
 		return nil, fmt.Errorf("no choices in response")
 	}
 
+	c.rateLimiter.OnSuccess(resp.Header)
+	c.recordUsage(&aiResp, messages, aiResp.Choices[0].Message.Content)
+
 	result, err := c.parseAIResponse(aiResp.Choices[0].Message.Content, productName)
 	if err != nil {
 		c.circuitBreaker.recordFailure()
@@ -354,6 +368,11 @@ func (c *AIClient) GetCompletionWithContext(ctx context.Context, systemPrompt, u
 		return "", fmt.Errorf("circuit breaker is open (state: %s), API calls are temporarily blocked", c.circuitBreaker.getState())
 	}
 
+	// Проверяем бюджет до отправки платного запроса
+	if err := CheckBudget(c.GetUsageTags()); err != nil {
+		return "", err
+	}
+
 	// Проверяем, не отменен ли контекст
 	if ctx.Err() != nil {
 		return "", fmt.Errorf("context cancelled: %v", ctx.Err())
@@ -450,6 +469,7 @@ func (c *AIClient) GetCompletionWithContext(ctx context.Context, systemPrompt, u
 		// Детальная обработка различных HTTP статусов
 		var errorMsg string
 		if resp.StatusCode == http.StatusTooManyRequests {
+			c.rateLimiter.OnThrottled(resp.Header)
 			errorMsg = fmt.Sprintf("rate limit exceeded (429): %s", string(body))
 		} else if resp.StatusCode == http.StatusUnauthorized {
 			errorMsg = fmt.Sprintf("unauthorized (401): invalid API key - %s", string(body))
@@ -482,6 +502,7 @@ func (c *AIClient) GetCompletionWithContext(ctx context.Context, systemPrompt, u
 		   strings.Contains(strings.ToLower(errorMsg), "rate limit") ||
 		   strings.Contains(strings.ToLower(errorType), "quota") ||
 		   strings.Contains(strings.ToLower(errorType), "rate_limit") {
+			c.rateLimiter.OnThrottled(resp.Header)
 			return "", fmt.Errorf("quota/rate limit error: %s (type: %s)", errorMsg, errorType)
 		}
 		
@@ -495,11 +516,47 @@ func (c *AIClient) GetCompletionWithContext(ctx context.Context, systemPrompt, u
 
 	// Успешный запрос - записываем в Circuit Breaker
 	c.circuitBreaker.recordSuccess()
+	c.rateLimiter.OnSuccess(resp.Header)
+	c.recordUsage(&aiResp, messages, aiResp.Choices[0].Message.Content)
 
 	// Возвращаем очищенный ответ
 	return c.cleanJSONResponse(aiResp.Choices[0].Message.Content), nil
 }
 
+// SetUsageTags задает атрибуты (клиент, проект, сессия) для учета затрат на вызовы
+func (c *AIClient) SetUsageTags(tags UsageTags) {
+	c.usageMu.Lock()
+	c.usageTags = tags
+	c.usageMu.Unlock()
+}
+
+// GetUsageTags возвращает атрибуты учета затрат клиента
+func (c *AIClient) GetUsageTags() UsageTags {
+	c.usageMu.RLock()
+	defer c.usageMu.RUnlock()
+	return c.usageTags
+}
+
+// recordUsage передает фактическое (или оцененное) потребление токенов в учет затрат
+func (c *AIClient) recordUsage(resp *AIResponse, messages []Message, output string) {
+	record := UsageRecord{
+		Provider: "arliai",
+		Model:    c.model,
+		Tags:     c.GetUsageTags(),
+	}
+	if resp != nil && resp.Usage != nil && (resp.Usage.PromptTokens > 0 || resp.Usage.CompletionTokens > 0) {
+		record.InputTokens = resp.Usage.PromptTokens
+		record.OutputTokens = resp.Usage.CompletionTokens
+	} else {
+		for _, m := range messages {
+			record.InputTokens += EstimateTokens(m.Content)
+		}
+		record.OutputTokens = EstimateTokens(output)
+		record.Estimated = true
+	}
+	RecordUsage(record)
+}
+
 // --- Circuit Breaker методы ---
 
 // canProceed проверяет, можно ли выполнить запрос к API
@@ -684,3 +741,18 @@ func (c *AIClient) WaitForCircuitBreakerRecovery(maxWaitTime time.Duration) bool
 }
 
 
+
+// quotaProviderForURL определяет имя провайдера для учета квот по адресу API
+func quotaProviderForURL(baseURL string) string {
+	lower := strings.ToLower(baseURL)
+	switch {
+	case strings.Contains(lower, "arliai"):
+		return "arliai"
+	case strings.Contains(lower, "openrouter"):
+		return "openrouter"
+	}
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return "custom"
+}
@@ -0,0 +1,525 @@
+package nomenclature
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Параметры адаптивного ограничения скорости: при 429/ошибке квоты скорость уменьшается вдвое,
+// после серии успешных запросов без ограничений - осторожно увеличивается
+const (
+	quotaDecreaseFactor = 0.5
+	quotaIncreaseStep   = 0.1 // запросов в секунду за одну пробу
+	quotaProbeEvery     = 10  // успешных запросов подряд перед пробой
+	quotaProbeCooldown  = 30 * time.Second
+	quotaDefaultBackoff = 5 * time.Second // пауза после 429 без Retry-After
+)
+
+// QuotaLimits ограничения корзины квоты по умолчанию
+type QuotaLimits struct {
+	InitialRate float64 // начальная скорость, запросов в секунду
+	MinRate     float64 // нижняя граница при снижении
+	MaxRate     float64 // потолок, пока провайдер не сообщил свой лимит
+	Burst       int
+}
+
+// DefaultQuotaLimits прежнее фиксированное ограничение (1 запрос/сек, burst 5) как начальная точка
+var DefaultQuotaLimits = QuotaLimits{InitialRate: 1, MinRate: 0.05, MaxRate: 10, Burst: 5}
+
+// QuotaState состояние корзины квоты для пары (ключ API, модель) провайдера
+type QuotaState struct {
+	Key             string    `json:"key"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	KeyFingerprint  string    `json:"key_fingerprint"` // первые символы SHA-256 ключа API
+	Rate            float64   `json:"rate"`            // текущая эффективная скорость, запросов в секунду
+	MinRate         float64   `json:"min_rate"`
+	Ceiling         float64   `json:"ceiling"` // потолок скорости (лимит провайдера или MaxRate)
+	Burst           int       `json:"burst"`
+	Tokens          float64   `json:"tokens"`
+	LastRefill      time.Time `json:"last_refill"`
+	BlockedUntil    time.Time `json:"blocked_until,omitempty"`
+	ThrottledCount  int       `json:"throttled_count"`
+	LastThrottledAt time.Time `json:"last_throttled_at,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RequestsPerMinute возвращает эффективную скорость в запросах в минуту
+func (s QuotaState) RequestsPerMinute() float64 {
+	return s.Rate * 60
+}
+
+// QuotaAdjustment изменение корзины по результату запроса
+type QuotaAdjustment struct {
+	RateFactor   float64   // множитель скорости (0 - без изменений)
+	RateStep     float64   // прибавка к скорости
+	Ceiling      float64   // новый потолок (0 - без изменений)
+	BlockedUntil time.Time // запросы не отправляются до этого момента
+	Throttled    bool      // запрос получил 429/ошибку квоты
+}
+
+// QuotaStore хранилище корзин квот. Реализация поверх сервисной БД позволяет
+// нескольким процессам с одним ключом API делить общий бюджет запросов
+type QuotaStore interface {
+	// EnsureQuota создает корзину с указанным состоянием, если ее еще нет
+	EnsureQuota(state QuotaState) error
+	// AcquireQuota атомарно забирает токен; возвращает 0 или время до появления токена
+	AcquireQuota(key string, now time.Time) (time.Duration, error)
+	// AdjustQuota атомарно применяет изменение скорости и блокировки
+	AdjustQuota(key string, adj QuotaAdjustment, now time.Time) error
+	// QuotaStates возвращает состояние всех корзин
+	QuotaStates() ([]QuotaState, error)
+}
+
+// QuotaManager адаптивно ограничивает запросы к AI провайдерам по ключу API и модели
+type QuotaManager struct {
+	mu            sync.Mutex
+	store         QuotaStore
+	local         *MemoryQuotaStore
+	limits        QuotaLimits
+	ensured       map[string]bool
+	successes     map[string]int
+	lastThrottled map[string]time.Time
+	now           func() time.Time
+}
+
+// NewQuotaManager создает менеджер квот, хранящий корзины в памяти процесса
+func NewQuotaManager(limits QuotaLimits) *QuotaManager {
+	return &QuotaManager{
+		local:         NewMemoryQuotaStore(),
+		limits:        limits,
+		ensured:       make(map[string]bool),
+		successes:     make(map[string]int),
+		lastThrottled: make(map[string]time.Time),
+		now:           time.Now,
+	}
+}
+
+var defaultQuotaManager = NewQuotaManager(DefaultQuotaLimits)
+
+// Quotas возвращает общий для процесса менеджер квот
+func Quotas() *QuotaManager {
+	return defaultQuotaManager
+}
+
+// SetQuotaStore подключает общее хранилище квот к менеджеру процесса (nil - только память процесса)
+func SetQuotaStore(store QuotaStore) {
+	defaultQuotaManager.SetStore(store)
+}
+
+// SetStore задает хранилище корзин; при ошибках хранилища используется память процесса
+func (m *QuotaManager) SetStore(store QuotaStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	m.ensured = make(map[string]bool)
+}
+
+// Limiter возвращает ограничитель для ключа API и модели провайдера
+func (m *QuotaManager) Limiter(provider, apiKey, model string) *ProviderRateLimiter {
+	fingerprint := quotaKeyFingerprint(apiKey)
+	return &ProviderRateLimiter{
+		manager:     m,
+		key:         provider + "|" + model + "|" + fingerprint,
+		provider:    provider,
+		model:       model,
+		fingerprint: fingerprint,
+	}
+}
+
+// States возвращает состояние всех корзин, отсортированное по провайдеру и модели
+func (m *QuotaManager) States() []QuotaState {
+	states, err := m.currentStore().QuotaStates()
+	if err != nil {
+		log.Printf("[Quota] Failed to read shared quota states, using local: %v", err)
+		states, _ = m.local.QuotaStates()
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Provider != states[j].Provider {
+			return states[i].Provider < states[j].Provider
+		}
+		return states[i].Model < states[j].Model
+	})
+	return states
+}
+
+// ProviderStates возвращает состояние корзин одного провайдера
+func (m *QuotaManager) ProviderStates(provider string) []QuotaState {
+	states := make([]QuotaState, 0)
+	for _, state := range m.States() {
+		if state.Provider == provider {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+func (m *QuotaManager) currentStore() QuotaStore {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.store != nil {
+		return m.store
+	}
+	return m.local
+}
+
+// ensure создает корзину в хранилище при первом обращении
+func (m *QuotaManager) ensure(l *ProviderRateLimiter, store QuotaStore) error {
+	m.mu.Lock()
+	done := m.ensured[l.key]
+	m.mu.Unlock()
+	if done {
+		return nil
+	}
+	now := m.now()
+	err := store.EnsureQuota(QuotaState{
+		Key:            l.key,
+		Provider:       l.provider,
+		Model:          l.model,
+		KeyFingerprint: l.fingerprint,
+		Rate:           m.limits.InitialRate,
+		MinRate:        m.limits.MinRate,
+		Ceiling:        m.limits.MaxRate,
+		Burst:          m.limits.Burst,
+		Tokens:         float64(m.limits.Burst),
+		LastRefill:     now,
+		UpdatedAt:      now,
+	})
+	if err == nil {
+		m.mu.Lock()
+		m.ensured[l.key] = true
+		m.mu.Unlock()
+	}
+	return err
+}
+
+// acquire забирает токен, при ошибке общего хранилища - из памяти процесса
+func (m *QuotaManager) acquire(l *ProviderRateLimiter) (time.Duration, error) {
+	store := m.currentStore()
+	if err := m.ensure(l, store); err == nil {
+		wait, err := store.AcquireQuota(l.key, m.now())
+		if err == nil {
+			return wait, nil
+		}
+		log.Printf("[Quota] Shared quota unavailable for %s, using local limiter: %v", l.key, err)
+	} else if store != m.local {
+		log.Printf("[Quota] Failed to register shared quota %s, using local limiter: %v", l.key, err)
+	}
+	if err := m.ensureLocal(l); err != nil {
+		return 0, err
+	}
+	return m.local.AcquireQuota(l.key, m.now())
+}
+
+func (m *QuotaManager) ensureLocal(l *ProviderRateLimiter) error {
+	now := m.now()
+	return m.local.EnsureQuota(QuotaState{
+		Key: l.key, Provider: l.provider, Model: l.model, KeyFingerprint: l.fingerprint,
+		Rate: m.limits.InitialRate, MinRate: m.limits.MinRate, Ceiling: m.limits.MaxRate,
+		Burst: m.limits.Burst, Tokens: float64(m.limits.Burst), LastRefill: now, UpdatedAt: now,
+	})
+}
+
+// adjust применяет изменение в хранилище и в памяти процесса
+func (m *QuotaManager) adjust(l *ProviderRateLimiter, adj QuotaAdjustment) {
+	now := m.now()
+	store := m.currentStore()
+	if store != m.local {
+		if err := m.ensure(l, store); err == nil {
+			if err := store.AdjustQuota(l.key, adj, now); err != nil {
+				log.Printf("[Quota] Failed to update shared quota %s: %v", l.key, err)
+			}
+		}
+	}
+	if err := m.ensureLocal(l); err == nil {
+		_ = m.local.AdjustQuota(l.key, adj, now)
+	}
+}
+
+// ProviderRateLimiter ограничитель запросов для ключа API и модели провайдера
+type ProviderRateLimiter struct {
+	manager     *QuotaManager
+	key         string
+	provider    string
+	model       string
+	fingerprint string
+}
+
+// Wait ожидает разрешения на запрос с учетом текущей скорости и блокировок провайдера
+func (l *ProviderRateLimiter) Wait(ctx context.Context) error {
+	for {
+		wait, err := l.manager.acquire(l)
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return fmt.Errorf("rate limit wait %v exceeds context deadline", wait.Round(time.Millisecond))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// OnSuccess учитывает успешный ответ: лимиты из заголовков и проба увеличения скорости
+func (l *ProviderRateLimiter) OnSuccess(header http.Header) {
+	m := l.manager
+	now := m.now()
+	signal := ParseQuotaHeaders(header, now)
+	adj := QuotaAdjustment{}
+	if signal.Limit > 0 {
+		adj.Ceiling = signal.Limit
+	}
+	if signal.Remaining == 0 && signal.ResetAt.After(now) {
+		adj.BlockedUntil = signal.ResetAt
+	}
+
+	m.mu.Lock()
+	m.successes[l.key]++
+	if m.successes[l.key] >= quotaProbeEvery && now.Sub(m.lastThrottled[l.key]) >= quotaProbeCooldown {
+		adj.RateStep = quotaIncreaseStep
+		m.successes[l.key] = 0
+	}
+	m.mu.Unlock()
+
+	if adj != (QuotaAdjustment{}) {
+		m.adjust(l, adj)
+	}
+}
+
+// OnThrottled учитывает 429 или ошибку квоты: скорость уменьшается, запросы приостанавливаются
+// на Retry-After (или до сброса лимита провайдера)
+func (l *ProviderRateLimiter) OnThrottled(header http.Header) {
+	m := l.manager
+	now := m.now()
+	signal := ParseQuotaHeaders(header, now)
+	blockedUntil := now.Add(quotaDefaultBackoff)
+	if signal.RetryAfter > 0 {
+		blockedUntil = now.Add(signal.RetryAfter)
+	} else if signal.ResetAt.After(now) {
+		blockedUntil = signal.ResetAt
+	}
+
+	m.mu.Lock()
+	m.successes[l.key] = 0
+	m.lastThrottled[l.key] = now
+	m.mu.Unlock()
+
+	log.Printf("[Quota] %s %s throttled, paused until %s", l.provider, l.model, blockedUntil.Format(time.RFC3339))
+	m.adjust(l, QuotaAdjustment{
+		RateFactor:   quotaDecreaseFactor,
+		Ceiling:      signal.Limit,
+		BlockedUntil: blockedUntil,
+		Throttled:    true,
+	})
+}
+
+// QuotaSignal сведения о лимитах из заголовков ответа провайдера
+type QuotaSignal struct {
+	RetryAfter time.Duration
+	Limit      float64 // лимит провайдера, запросов в секунду (0 - не сообщен)
+	Remaining  int     // оставшиеся запросы в окне (-1 - не сообщено)
+	ResetAt    time.Time
+}
+
+// ParseQuotaHeaders разбирает Retry-After и заголовки X-RateLimit-* (формат OpenRouter и OpenAI).
+// Лимит провайдера считается заданным на минуту
+func ParseQuotaHeaders(header http.Header, now time.Time) QuotaSignal {
+	signal := QuotaSignal{Remaining: -1}
+	if header == nil {
+		return signal
+	}
+
+	if value := strings.TrimSpace(header.Get("Retry-After")); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			signal.RetryAfter = time.Duration(seconds * float64(time.Second))
+		} else if at, err := http.ParseTime(value); err == nil && at.After(now) {
+			signal.RetryAfter = at.Sub(now)
+		}
+	}
+
+	if value := firstHeader(header, "X-RateLimit-Limit-Requests", "X-RateLimit-Limit"); value != "" {
+		if limit, err := strconv.ParseFloat(value, 64); err == nil && limit > 0 {
+			signal.Limit = limit / 60
+		}
+	}
+	if value := firstHeader(header, "X-RateLimit-Remaining-Requests", "X-RateLimit-Remaining"); value != "" {
+		if remaining, err := strconv.Atoi(value); err == nil {
+			signal.Remaining = remaining
+		}
+	}
+	if value := firstHeader(header, "X-RateLimit-Reset-Requests", "X-RateLimit-Reset"); value != "" {
+		signal.ResetAt = parseQuotaReset(value, now)
+	}
+	return signal
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(header.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseQuotaReset разбирает момент сброса лимита: длительность ("6m0s"), unix-время в секундах или миллисекундах
+func parseQuotaReset(value string, now time.Time) time.Time {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(d)
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case number > 1e12:
+		return time.UnixMilli(int64(number))
+	case number > 1e9:
+		return time.Unix(int64(number), 0)
+	default:
+		return now.Add(time.Duration(number * float64(time.Second)))
+	}
+}
+
+func quotaKeyFingerprint(apiKey string) string {
+	if apiKey == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// MemoryQuotaStore хранилище корзин в памяти процесса
+type MemoryQuotaStore struct {
+	mu     sync.Mutex
+	states map[string]*QuotaState
+}
+
+// NewMemoryQuotaStore создает хранилище корзин в памяти
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{states: make(map[string]*QuotaState)}
+}
+
+// EnsureQuota создает корзину, если ее нет
+func (s *MemoryQuotaStore) EnsureQuota(state QuotaState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.states[state.Key]; !ok {
+		s.states[state.Key] = &state
+	}
+	return nil
+}
+
+// AcquireQuota забирает токен из корзины
+func (s *MemoryQuotaStore) AcquireQuota(key string, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return 0, fmt.Errorf("quota %s is not registered", key)
+	}
+	if state.BlockedUntil.After(now) {
+		return state.BlockedUntil.Sub(now), nil
+	}
+	elapsed := now.Sub(state.LastRefill).Seconds()
+	if elapsed > 0 {
+		state.Tokens += elapsed * state.Rate
+		if state.Tokens > float64(state.Burst) {
+			state.Tokens = float64(state.Burst)
+		}
+		state.LastRefill = now
+	}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		return 0, nil
+	}
+	return time.Duration((1 - state.Tokens) / state.Rate * float64(time.Second)), nil
+}
+
+// AdjustQuota применяет изменение скорости и блокировки
+func (s *MemoryQuotaStore) AdjustQuota(key string, adj QuotaAdjustment, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		return fmt.Errorf("quota %s is not registered", key)
+	}
+	if adj.Ceiling > 0 {
+		state.Ceiling = adj.Ceiling
+	}
+	rate := state.Rate
+	if adj.RateFactor > 0 {
+		rate *= adj.RateFactor
+	}
+	rate += adj.RateStep
+	if rate > state.Ceiling {
+		rate = state.Ceiling
+	}
+	if rate < state.MinRate {
+		rate = state.MinRate
+	}
+	state.Rate = rate
+	if adj.BlockedUntil.After(state.BlockedUntil) {
+		state.BlockedUntil = adj.BlockedUntil
+	}
+	if adj.Throttled {
+		state.ThrottledCount++
+		state.LastThrottledAt = now
+		state.Tokens = 0
+		state.LastRefill = now
+	}
+	state.UpdatedAt = now
+	return nil
+}
+
+// QuotaStates возвращает копии всех корзин
+func (s *MemoryQuotaStore) QuotaStates() ([]QuotaState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	states := make([]QuotaState, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, *state)
+	}
+	return states, nil
+}
+
+// QuotaProviderSummary эффективная скорость запросов к провайдеру по всем ключам и моделям
+type QuotaProviderSummary struct {
+	Provider     string       `json:"provider"`
+	EffectiveRPM float64      `json:"effective_rpm"` // сумма скоростей корзин, запросов в минуту
+	Blocked      bool         `json:"blocked"`       // все корзины провайдера приостановлены
+	Buckets      []QuotaState `json:"buckets"`
+}
+
+// ProviderSummary возвращает сводку квот провайдера для статусов воркеров
+func (m *QuotaManager) ProviderSummary(provider string) QuotaProviderSummary {
+	summary := QuotaProviderSummary{Provider: provider, Buckets: m.ProviderStates(provider)}
+	now := m.now()
+	blocked := 0
+	for _, state := range summary.Buckets {
+		if state.BlockedUntil.After(now) {
+			blocked++
+			continue
+		}
+		summary.EffectiveRPM += state.RequestsPerMinute()
+	}
+	summary.Blocked = len(summary.Buckets) > 0 && blocked == len(summary.Buckets)
+	return summary
+}
@@ -0,0 +1,91 @@
+package nomenclature
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestQuotaManager(now *time.Time) *QuotaManager {
+	m := NewQuotaManager(QuotaLimits{InitialRate: 2, MinRate: 0.1, MaxRate: 10, Burst: 1})
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func TestParseQuotaHeaders(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	header := http.Header{}
+	header.Set("Retry-After", "7")
+	header.Set("X-RateLimit-Limit", "120")
+	header.Set("X-RateLimit-Remaining", "0")
+	header.Set("X-RateLimit-Reset", "1700000030000")
+
+	signal := ParseQuotaHeaders(header, now)
+	if signal.RetryAfter != 7*time.Second {
+		t.Errorf("RetryAfter = %v, want 7s", signal.RetryAfter)
+	}
+	if signal.Limit != 2 {
+		t.Errorf("Limit = %v, want 2 rps", signal.Limit)
+	}
+	if signal.Remaining != 0 {
+		t.Errorf("Remaining = %d, want 0", signal.Remaining)
+	}
+	if !signal.ResetAt.Equal(now.Add(30 * time.Second)) {
+		t.Errorf("ResetAt = %v, want %v", signal.ResetAt, now.Add(30*time.Second))
+	}
+
+	header = http.Header{}
+	header.Set("Retry-After", now.Add(time.Minute).UTC().Format(http.TimeFormat))
+	if signal := ParseQuotaHeaders(header, now); signal.RetryAfter != time.Minute || signal.Remaining != -1 {
+		t.Errorf("HTTP-date Retry-After signal = %+v", signal)
+	}
+}
+
+func TestProviderRateLimiterAdaptsRate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := newTestQuotaManager(&now)
+	limiter := m.Limiter("openrouter", "secret-key", "model-a")
+
+	if wait, err := m.acquire(limiter); err != nil || wait != 0 {
+		t.Fatalf("first acquire = %v, %v", wait, err)
+	}
+	if wait, _ := m.acquire(limiter); wait <= 0 {
+		t.Error("second acquire without refill should wait")
+	}
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	limiter.OnThrottled(header)
+	states := m.ProviderStates("openrouter")
+	if len(states) != 1 || states[0].Rate != 1 || states[0].ThrottledCount != 1 {
+		t.Fatalf("states after throttle = %+v", states)
+	}
+	if states[0].KeyFingerprint == "secret-key" {
+		t.Error("API key must not be stored in quota state")
+	}
+	now = now.Add(2 * time.Second)
+	if wait, _ := m.acquire(limiter); wait < time.Second {
+		t.Errorf("acquire while blocked = %v, want remaining Retry-After", wait)
+	}
+
+	// После паузы и серии успешных запросов скорость осторожно растет
+	now = now.Add(quotaProbeCooldown)
+	for i := 0; i < quotaProbeEvery; i++ {
+		limiter.OnSuccess(nil)
+	}
+	summary := m.ProviderSummary("openrouter")
+	if got := summary.Buckets[0].Rate; got != 1+quotaIncreaseStep {
+		t.Errorf("rate after probe = %v, want %v", got, 1+quotaIncreaseStep)
+	}
+	if summary.EffectiveRPM != summary.Buckets[0].RequestsPerMinute() || summary.Blocked {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	// Лимит из заголовков становится потолком скорости
+	header = http.Header{}
+	header.Set("X-RateLimit-Limit", "30")
+	limiter.OnSuccess(header)
+	if got := m.ProviderStates("openrouter")[0]; got.Ceiling != 0.5 || got.Rate != 0.5 {
+		t.Errorf("state after provider limit = %+v", got)
+	}
+}
@@ -0,0 +1,89 @@
+package nomenclature
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrAIBudgetExceeded возвращается, когда жесткий бюджет на AI исчерпан и запрос не отправлялся
+var ErrAIBudgetExceeded = errors.New("AI budget exceeded")
+
+// UsageTags атрибуты вызова AI для учета затрат
+type UsageTags struct {
+	ClientID  int    `json:"client_id,omitempty"`
+	ProjectID int    `json:"project_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Operation string `json:"operation,omitempty"` // normalization, kpved, benchmark ...
+}
+
+// UsageRecord один вызов AI провайдера
+type UsageRecord struct {
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Estimated    bool      `json:"estimated"` // токены оценены по длине текста, провайдер их не вернул
+	Tags         UsageTags `json:"tags"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// UsageRecorder принимает записи об использовании AI и контролирует бюджеты
+type UsageRecorder interface {
+	// RecordAIUsage сохраняет вызов и возвращает его стоимость
+	RecordAIUsage(record UsageRecord) float64
+	// AllowAIRequest возвращает ErrAIBudgetExceeded (обернутую), если бюджет исчерпан
+	AllowAIRequest(tags UsageTags) error
+}
+
+var (
+	usageRecorderMu sync.RWMutex
+	usageRecorder   UsageRecorder
+)
+
+// SetUsageRecorder устанавливает глобальный приемник учета AI (nil отключает учет)
+func SetUsageRecorder(recorder UsageRecorder) {
+	usageRecorderMu.Lock()
+	usageRecorder = recorder
+	usageRecorderMu.Unlock()
+}
+
+func getUsageRecorder() UsageRecorder {
+	usageRecorderMu.RLock()
+	defer usageRecorderMu.RUnlock()
+	return usageRecorder
+}
+
+// RecordUsage передает запись в глобальный приемник и возвращает стоимость вызова
+func RecordUsage(record UsageRecord) float64 {
+	recorder := getUsageRecorder()
+	if recorder == nil {
+		return 0
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	return recorder.RecordAIUsage(record)
+}
+
+// CheckBudget проверяет, разрешен ли вызов AI с указанными атрибутами
+func CheckBudget(tags UsageTags) error {
+	recorder := getUsageRecorder()
+	if recorder == nil {
+		return nil
+	}
+	return recorder.AllowAIRequest(tags)
+}
+
+// EstimateTokens грубо оценивает число токенов текста (~4 символа на токен)
+func EstimateTokens(texts ...string) int {
+	total := 0
+	for _, text := range texts {
+		total += utf8.RuneCountInString(text)
+	}
+	if total == 0 {
+		return 0
+	}
+	return (total + 3) / 4
+}
@@ -0,0 +1,75 @@
+package nomenclature
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// recordingUsageRecorder приемник учета затрат для тестов
+type recordingUsageRecorder struct {
+	records []UsageRecord
+	blocked bool
+}
+
+func (r *recordingUsageRecorder) RecordAIUsage(record UsageRecord) float64 {
+	r.records = append(r.records, record)
+	return 0.5
+}
+
+func (r *recordingUsageRecorder) AllowAIRequest(tags UsageTags) error {
+	if r.blocked {
+		return fmt.Errorf("%w: test", ErrAIBudgetExceeded)
+	}
+	return nil
+}
+
+// TestAIClient_RecordsUsage проверяет передачу токенов из ответа API и блокировку по бюджету
+func TestAIClient_RecordsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"{\"ok\":true}"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`)
+	}))
+	defer server.Close()
+
+	recorder := &recordingUsageRecorder{}
+	SetUsageRecorder(recorder)
+	defer SetUsageRecorder(nil)
+
+	client := NewAIClientWithBaseURL("key", "test-model", server.URL)
+	client.SetUsageTags(UsageTags{ClientID: 4, Operation: "test"})
+
+	if _, err := client.GetCompletion("system", "user"); err != nil {
+		t.Fatalf("GetCompletion() error = %v", err)
+	}
+	if len(recorder.records) != 1 {
+		t.Fatalf("recorded %d usage records, want 1", len(recorder.records))
+	}
+	rec := recorder.records[0]
+	if rec.Provider != "arliai" || rec.Model != "test-model" || rec.InputTokens != 12 || rec.OutputTokens != 3 || rec.Estimated {
+		t.Errorf("usage record = %+v, want provider tokens from response", rec)
+	}
+	if rec.Tags.ClientID != 4 {
+		t.Errorf("usage tags = %+v, want client 4", rec.Tags)
+	}
+
+	recorder.blocked = true
+	if _, err := client.GetCompletion("system", "user"); !errors.Is(err, ErrAIBudgetExceeded) {
+		t.Errorf("GetCompletion() with exhausted budget = %v, want ErrAIBudgetExceeded", err)
+	}
+	if len(recorder.records) != 1 {
+		t.Error("blocked call must not be recorded")
+	}
+}
+
+// TestEstimateTokens проверяет оценку токенов по длине текста
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d, want 0", got)
+	}
+	if got := EstimateTokens("абвг", "д"); got != 2 {
+		t.Errorf("EstimateTokens() = %d, want 2", got)
+	}
+}
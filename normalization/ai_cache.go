@@ -9,6 +9,7 @@ import (
 
 // CacheEntry представляет запись в кеше
 type CacheEntry struct {
+	Namespace      string // Пространство имен (клиент), пустое - общий кэш
 	NormalizedName string
 	Category       string
 	Confidence     float64
@@ -50,18 +51,24 @@ func NewAICache(ttl time.Duration, maxEntries int) *AICache {
 	return cache
 }
 
-// generateKey создает уникальный ключ для исходного наименования
-func (c *AICache) generateKey(sourceName string) string {
-	hash := sha256.Sum256([]byte(sourceName))
+// generateKey создает уникальный ключ для исходного наименования в пространстве имен.
+// Пространство имен входит в хеш, поэтому записи разных клиентов не пересекаются.
+func (c *AICache) generateKey(namespace, sourceName string) string {
+	hash := sha256.Sum256([]byte(namespace + "\x00" + sourceName))
 	return hex.EncodeToString(hash[:])
 }
 
-// Get получает результат из кеша
+// Get получает результат из общего кеша
 func (c *AICache) Get(sourceName string) (*CacheEntry, bool) {
+	return c.GetInNamespace("", sourceName)
+}
+
+// GetInNamespace получает результат из кеша в пространстве имен клиента
+func (c *AICache) GetInNamespace(namespace, sourceName string) (*CacheEntry, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	key := c.generateKey(sourceName)
+	key := c.generateKey(namespace, sourceName)
 	entry, exists := c.cache[key]
 
 	if !exists {
@@ -93,8 +100,13 @@ func (c *AICache) Get(sourceName string) (*CacheEntry, bool) {
 	return entry, true
 }
 
-// Set добавляет результат в кеш
+// Set добавляет результат в общий кеш
 func (c *AICache) Set(sourceName, normalizedName, category string, confidence float64, reasoning string) {
+	c.SetInNamespace("", sourceName, normalizedName, category, confidence, reasoning)
+}
+
+// SetInNamespace добавляет результат в кеш в пространстве имен клиента
+func (c *AICache) SetInNamespace(namespace, sourceName, normalizedName, category string, confidence float64, reasoning string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -103,10 +115,11 @@ func (c *AICache) Set(sourceName, normalizedName, category string, confidence fl
 		c.evictOldest()
 	}
 
-	key := c.generateKey(sourceName)
+	key := c.generateKey(namespace, sourceName)
 	now := time.Now()
 
 	c.cache[key] = &CacheEntry{
+		Namespace:      namespace,
 		NormalizedName: normalizedName,
 		Category:       category,
 		Confidence:     confidence,
@@ -191,6 +204,21 @@ func (c *AICache) Clear() {
 	c.misses = 0
 }
 
+// ClearNamespace удаляет все записи пространства имен клиента
+func (c *AICache) ClearNamespace(namespace string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key, entry := range c.cache {
+		if entry.Namespace == namespace {
+			delete(c.cache, key)
+			removed++
+		}
+	}
+	return removed
+}
+
 // Size возвращает количество записей в кеше
 func (c *AICache) Size() int {
 	c.mu.RLock()
@@ -0,0 +1,36 @@
+package normalization
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAICache_Namespaces проверяет изоляцию записей кэша по клиентам
+func TestAICache_Namespaces(t *testing.T) {
+	cache := NewAICache(time.Hour, 100)
+
+	cache.SetInNamespace("client:1", "болт м8", "болт", "крепеж", 0.9, "")
+	cache.SetInNamespace("client:2", "болт м8", "болт м8 оцинкованный", "метизы", 0.8, "")
+
+	entry, ok := cache.GetInNamespace("client:1", "болт м8")
+	if !ok || entry.Category != "крепеж" {
+		t.Fatalf("client:1 entry = %+v, %v; want category крепеж", entry, ok)
+	}
+	entry, ok = cache.GetInNamespace("client:2", "болт м8")
+	if !ok || entry.Category != "метизы" {
+		t.Fatalf("client:2 entry = %+v, %v; want category метизы", entry, ok)
+	}
+	if _, ok := cache.Get("болт м8"); ok {
+		t.Error("client entries must not be visible in the shared namespace")
+	}
+	if _, ok := cache.GetInNamespace("client:3", "болт м8"); ok {
+		t.Error("client entries must not leak into another client namespace")
+	}
+
+	if removed := cache.ClearNamespace("client:1"); removed != 1 {
+		t.Errorf("ClearNamespace() removed %d, want 1", removed)
+	}
+	if _, ok := cache.GetInNamespace("client:2", "болт м8"); !ok {
+		t.Error("ClearNamespace must keep other namespaces")
+	}
+}
@@ -20,6 +20,19 @@ type AIResult struct {
 	Category       string  `json:"category"`
 	Confidence     float64 `json:"confidence"`
 	Reasoning      string  `json:"reasoning"`
+	// Votes ответы отдельных провайдеров, если результат получен голосованием нескольких моделей
+	Votes []ProviderVote `json:"votes,omitempty"`
+}
+
+// ProviderVote ответ одного провайдера при мульти-провайдерной нормализации
+type ProviderVote struct {
+	Provider       string  `json:"provider"`
+	NormalizedName string  `json:"normalized_name,omitempty"`
+	Category       string  `json:"category,omitempty"`
+	Confidence     float64 `json:"confidence,omitempty"`
+	Success        bool    `json:"success"`
+	Error          string  `json:"error,omitempty"`
+	DurationMs     int64   `json:"duration_ms"`
 }
 
 // AIStats статистика работы AI нормализатора
@@ -36,6 +49,7 @@ type AIStats struct {
 type AINormalizer struct {
 	aiClient       *nomenclature.AIClient
 	cache          *AICache
+	cacheNamespace string // Пространство имен кэша (клиент), пустое - общий кэш
 	statsCollector *StatsCollector
 	systemPrompt   string
 	stats          *AIStats        // старая статистика для совместимости
@@ -163,7 +177,7 @@ func (a *AINormalizer) NormalizeWithAI(name string) (*AIResult, error) {
 	// Проверяем кэш (case-insensitive)
 	sourceName := strings.ToLower(strings.TrimSpace(name))
 
-	if cached, exists := a.cache.Get(sourceName); exists {
+	if cached, exists := a.cache.GetInNamespace(a.cacheNamespace, sourceName); exists {
 		// Кеш hit
 		atomic.AddInt64(&a.stats.CacheHits, 1)
 		cacheStats := a.cache.GetStats()
@@ -207,7 +221,7 @@ func (a *AINormalizer) NormalizeWithAI(name string) (*AIResult, error) {
 			Reasoning:      result.Reasoning,
 		}
 
-		a.cache.Set(sourceName, aiResult.NormalizedName, aiResult.Category, aiResult.Confidence, aiResult.Reasoning)
+		a.cache.SetInNamespace(a.cacheNamespace, sourceName, aiResult.NormalizedName, aiResult.Category, aiResult.Confidence, aiResult.Reasoning)
 
 		return aiResult, nil
 	}
@@ -251,7 +265,7 @@ func (a *AINormalizer) NormalizeWithAI(name string) (*AIResult, error) {
 	}
 
 	// Сохраняем в кэш
-	a.cache.Set(sourceName, result.NormalizedName, result.Category, result.Confidence, result.Reasoning)
+	a.cache.SetInNamespace(a.cacheNamespace, sourceName, result.NormalizedName, result.Category, result.Confidence, result.Reasoning)
 
 	// Обновляем статистику
 	latency := time.Since(startTime).Nanoseconds()
@@ -336,6 +350,24 @@ func (a *AINormalizer) ClearCache() {
 	log.Println("AI normalizer cache cleared")
 }
 
+// SetCacheNamespace задает пространство имен кэша, чтобы результаты
+// нормализации одного клиента не использовались для другого
+func (a *AINormalizer) SetCacheNamespace(namespace string) {
+	a.cacheNamespace = namespace
+}
+
+// SetUsageTags задает атрибуты учета затрат на вызовы AI
+func (a *AINormalizer) SetUsageTags(tags nomenclature.UsageTags) {
+	if a.aiClient != nil {
+		a.aiClient.SetUsageTags(tags)
+	}
+}
+
+// GetCacheNamespace возвращает текущее пространство имен кэша
+func (a *AINormalizer) GetCacheNamespace() string {
+	return a.cacheNamespace
+}
+
 // GetCacheSize возвращает размер кэша
 func (a *AINormalizer) GetCacheSize() int {
 	return a.cache.Size()
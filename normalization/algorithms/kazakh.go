@@ -0,0 +1,296 @@
+package algorithms
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// wordRegex слово наименования - последовательность символов без пробелов
+var wordRegex = regexp.MustCompile(`\S+`)
+
+// kazakhLatinToCyrillic соответствие букв казахской латиницы кириллице.
+// Основа - алфавит 2021 года (ä, ğ, ñ, ş, ç, ı), дополнительно принимаются буквы
+// с акутом из алфавита 2018 года (á, ǵ, ń, ó, ú)
+var kazakhLatinToCyrillic = map[rune]string{
+	'a': "а", 'ä': "ә", 'á': "ә", 'b': "б", 'c': "ц", 'ç': "ч", 'd': "д", 'e': "е",
+	'f': "ф", 'g': "г", 'ğ': "ғ", 'ǵ': "ғ", 'h': "х", 'i': "і", 'ı': "ы", 'j': "ж",
+	'k': "к", 'l': "л", 'm': "м", 'n': "н", 'ñ': "ң", 'ń': "ң", 'ŋ': "ң", 'o': "о",
+	'ö': "ө", 'ó': "ө", 'p': "п", 'q': "қ", 'r': "р", 's': "с", 'ş': "ш", 't': "т",
+	'u': "у", 'ū': "ұ", 'ü': "ү", 'ú': "ү", 'v': "в", 'w': "у", 'x': "кс", 'y': "й",
+	'z': "з",
+}
+
+// kazakhCyrillicToLatin соответствие казахской кириллицы латинице (алфавит 2021 года)
+var kazakhCyrillicToLatin = map[rune]string{
+	'а': "a", 'ә': "ä", 'б': "b", 'в': "v", 'г': "g", 'ғ': "ğ", 'д': "d", 'е': "e",
+	'ё': "io", 'ж': "j", 'з': "z", 'и': "i", 'й': "y", 'і': "i", 'к': "k", 'қ': "q",
+	'л': "l", 'м': "m", 'н': "n", 'ң': "ñ", 'о': "o", 'ө': "ö", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ұ': "ū", 'ү': "ü", 'ф': "f", 'х': "h", 'һ': "h",
+	'ц': "ts", 'ч': "ç", 'ш': "ş", 'щ': "şş", 'ъ': "", 'ы': "ı", 'ь': "", 'э': "e",
+	'ю': "iu", 'я': "ia",
+}
+
+// kazakhFolding сводит казахские буквы к ближайшим по звучанию русским,
+// чтобы фонетические алгоритмы для русского языка не теряли их
+var kazakhFolding = map[rune]rune{
+	'ә': 'а', 'ғ': 'г', 'қ': 'к', 'ң': 'н', 'ө': 'о', 'ұ': 'у', 'ү': 'у', 'һ': 'х', 'і': 'и',
+	'Ә': 'А', 'Ғ': 'Г', 'Қ': 'К', 'Ң': 'Н', 'Ө': 'О', 'Ұ': 'У', 'Ү': 'У', 'Һ': 'Х', 'І': 'И',
+}
+
+// KazakhLatinToCyrillic переводит казахский текст с латиницы на кириллицу.
+// Диграфы sh и ch алфавита 2018 года переводятся в ш и ч; символы, не являющиеся
+// латинскими буквами (цифры, кириллица, знаки), сохраняются как есть. Результат в нижнем регистре
+func KazakhLatinToCyrillic(text string) string {
+	runes := []rune(strings.ToLower(text))
+	var result strings.Builder
+	result.Grow(len(text))
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if i+1 < len(runes) && runes[i+1] == 'h' {
+			if r == 's' {
+				result.WriteString("ш")
+				i++
+				continue
+			}
+			if r == 'c' {
+				result.WriteString("ч")
+				i++
+				continue
+			}
+		}
+		if cyr, ok := kazakhLatinToCyrillic[r]; ok {
+			result.WriteString(cyr)
+			continue
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// KazakhCyrillicToLatin переводит казахский (и русский) текст с кириллицы на латиницу
+// по алфавиту 2021 года. Результат в нижнем регистре
+func KazakhCyrillicToLatin(text string) string {
+	var result strings.Builder
+	result.Grow(len(text))
+	for _, r := range strings.ToLower(text) {
+		if lat, ok := kazakhCyrillicToLatin[r]; ok {
+			result.WriteString(lat)
+			continue
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// ToKazakhCyrillic приводит наименование к кириллице, если оно написано казахской латиницей.
+// Слова с цифрами и слова из заглавных букв (коды, аббревиатуры) остаются как есть
+func ToKazakhCyrillic(text string, lang Language) string {
+	if lang != LanguageKazakhLatin {
+		return text
+	}
+	return wordRegex.ReplaceAllStringFunc(text, func(token string) string {
+		if !IsTransliterableToken(token) {
+			return token
+		}
+		return KazakhLatinToCyrillic(token)
+	})
+}
+
+// FoldKazakh заменяет казахские буквы ближайшими русскими (ә -> а, қ -> к, і -> и и т.д.)
+func FoldKazakh(text string) string {
+	return strings.Map(func(r rune) rune {
+		if folded, ok := kazakhFolding[r]; ok {
+			return folded
+		}
+		return r
+	}, text)
+}
+
+// PhoneticForm приводит текст к виду, пригодному для русских фонетических алгоритмов:
+// казахская латиница переводится на кириллицу, казахские буквы сводятся к русским.
+// Для остальных языков текст возвращается без изменений
+func PhoneticForm(text string, lang Language) string {
+	if !lang.IsKazakhLanguage() {
+		return text
+	}
+	return FoldKazakh(ToKazakhCyrillic(text, lang))
+}
+
+// PhoneticKey возвращает фонетический ключ наименования с учетом языка
+// (Soundex и Metaphone по каждому слову). Казахские наименования на кириллице
+// и латинице с одинаковым звучанием получают одинаковый ключ
+func PhoneticKey(text string, lang Language) string {
+	form := strings.ToLower(PhoneticForm(text, lang))
+	soundex := NewSoundexRU()
+	metaphone := NewMetaphoneRU()
+
+	var keys []string
+	for _, word := range strings.FieldsFunc(form, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if code := soundex.Encode(word); code != "" {
+			keys = append(keys, code+metaphone.Encode(word))
+		} else {
+			keys = append(keys, word)
+		}
+	}
+	return strings.Join(keys, " ")
+}
+
+// kazakhStopWords служебные слова казахского языка, не влияющие на смысл наименования
+var kazakhStopWords = map[string]bool{
+	"және": true, "мен": true, "бен": true, "пен": true, "үшін": true, "немесе": true,
+	"бірақ": true, "ал": true, "да": true, "де": true, "та": true, "те": true,
+	"бар": true, "жоқ": true, "арналған": true, "бойынша": true,
+}
+
+// KazakhTokenize разбивает казахское наименование на слова: латиница переводится
+// на кириллицу, служебные слова и слова короче двух букв отбрасываются
+func KazakhTokenize(text string) []string {
+	text = strings.ToLower(ToKazakhCyrillic(text, DetectLanguage(text)))
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < 2 || kazakhStopWords[word] {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// kazakhSuffixes окончания казахского языка: множественное число, падежи и притяжательность.
+// Отсортированы по убыванию длины, чтобы сначала отрезались самые длинные
+var kazakhSuffixes = func() []string {
+	suffixes := []string{
+		// Множественное число
+		"лар", "лер", "дар", "дер", "тар", "тер",
+		// Родительный падеж
+		"ның", "нің", "дың", "дің", "тың", "тің",
+		// Дательный падеж
+		"ға", "ге", "қа", "ке", "на", "не",
+		// Винительный падеж
+		"ны", "ні", "ды", "ді", "ты", "ті",
+		// Местный падеж
+		"нда", "нде", "да", "де", "та", "те",
+		// Исходный падеж
+		"нан", "нен", "дан", "ден", "тан", "тен",
+		// Творительный падеж
+		"мен", "бен", "пен",
+		// Притяжательные окончания (в том числе после множественного числа: өнім-дер-і)
+		"ымыз", "іміз", "ыңыз", "іңіз", "ың", "ің", "сы", "сі",
+		"лары", "лері", "дары", "дері", "тары", "тері",
+	}
+	sort.SliceStable(suffixes, func(i, j int) bool {
+		return len([]rune(suffixes[i])) > len([]rune(suffixes[j]))
+	})
+	return suffixes
+}()
+
+const (
+	// kazakhMinStemLength минимальная длина основы в буквах после отсечения окончания
+	kazakhMinStemLength = 3
+	// kazakhMaxSuffixes максимальное число последовательно отсекаемых окончаний
+	// (например, сүт-тер-і-нің: множественное число, притяжательность, падеж)
+	kazakhMaxSuffixes = 3
+)
+
+// KazakhStemmer реализует Stemmer для казахского языка отсечением окончаний.
+// Казахский язык агглютинативный, поэтому окончания отсекаются последовательно
+// с конца слова, пока основа не станет короче kazakhMinStemLength
+type KazakhStemmer struct {
+	cache    map[string]string
+	mu       sync.RWMutex
+	useCache bool
+}
+
+// NewKazakhStemmer создает стеммер казахского языка с кэшем
+func NewKazakhStemmer() *KazakhStemmer {
+	return &KazakhStemmer{
+		cache:    make(map[string]string),
+		useCache: true,
+	}
+}
+
+// Stem возвращает основу слова. Слова на казахской латинице предварительно
+// переводятся на кириллицу. Пример: "сүттердің" -> "сүт"
+func (s *KazakhStemmer) Stem(word string) string {
+	normalized := strings.ToLower(strings.TrimSpace(word))
+	if normalized == "" {
+		return ""
+	}
+	if DetectLanguage(normalized) == LanguageKazakhLatin {
+		normalized = KazakhLatinToCyrillic(normalized)
+	}
+	return stemKazakh(normalized)
+}
+
+// stemKazakh отсекает казахские окончания у слова на кириллице в нижнем регистре
+func stemKazakh(word string) string {
+	stem := []rune(word)
+	for i := 0; i < kazakhMaxSuffixes; i++ {
+		stripped := false
+		for _, suffix := range kazakhSuffixes {
+			suffixRunes := []rune(suffix)
+			if len(stem)-len(suffixRunes) < kazakhMinStemLength {
+				continue
+			}
+			if string(stem[len(stem)-len(suffixRunes):]) == suffix {
+				stem = stem[:len(stem)-len(suffixRunes)]
+				stripped = true
+				break
+			}
+		}
+		if !stripped {
+			break
+		}
+	}
+	return string(stem)
+}
+
+// StemWithCache возвращает основу слова с кэшированием
+func (s *KazakhStemmer) StemWithCache(word string) string {
+	if !s.useCache {
+		return s.Stem(word)
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(word))
+	if normalized == "" {
+		return ""
+	}
+
+	s.mu.RLock()
+	if cached, found := s.cache[normalized]; found {
+		s.mu.RUnlock()
+		return cached
+	}
+	s.mu.RUnlock()
+
+	stemmed := s.Stem(normalized)
+
+	s.mu.Lock()
+	s.cache[normalized] = stemmed
+	s.mu.Unlock()
+
+	return stemmed
+}
+
+// StemTokens возвращает основы нескольких слов
+func (s *KazakhStemmer) StemTokens(tokens []string) []string {
+	stemmed := make([]string, len(tokens))
+	for i, token := range tokens {
+		stemmed[i] = s.StemWithCache(token)
+	}
+	return stemmed
+}
+
+// StemText возвращает текст, в котором каждое слово заменено основой
+func (s *KazakhStemmer) StemText(text string) string {
+	return strings.Join(s.StemTokens(strings.Fields(text)), " ")
+}
@@ -0,0 +1,126 @@
+package algorithms
+
+import (
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Language
+	}{
+		{"Молоко пастеризованное 3,2%", LanguageRussian},
+		{"Сүт пастерленген 3,2%", LanguageKazakh},
+		{"Қант ақ", LanguageKazakh},
+		{"Sút pasterlengen", LanguageKazakhLatin},
+		{"Qant aq ünemdi", LanguageKazakhLatin},
+		{"Müller Milch", LanguageUnknown},
+		{"Bosch GSR 120", LanguageUnknown},
+		{"12345", LanguageUnknown},
+		{"qalamdar", LanguageKazakhLatin},
+		// Бренды и коды с q и диакритикой не казахские
+		{"Cisco QSFP-40G-SR4 transceiver", LanguageUnknown},
+		{"Iqos heets", LanguageUnknown},
+		{"Çelik boru", LanguageUnknown},
+		{"Şişecam bardak", LanguageUnknown},
+		{"Señorío de Sarría", LanguageUnknown},
+		{"Jamón ibérico Año Nuevo", LanguageUnknown},
+		{"Сік яблучний", LanguageRussian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := DetectLanguage(tt.input); got != tt.expected {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKazakhTransliteration(t *testing.T) {
+	tests := []struct {
+		latin    string
+		cyrillic string
+	}{
+		{"sút", "сүт"},
+		{"qant", "қант"},
+		{"ğalam", "ғалам"},
+		{"shai", "шаі"},
+		{"şai", "шаі"},
+		{"añ", "аң"},
+		{"öñim", "өңім"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.latin, func(t *testing.T) {
+			if got := KazakhLatinToCyrillic(tt.latin); got != tt.cyrillic {
+				t.Errorf("KazakhLatinToCyrillic(%q) = %q, want %q", tt.latin, got, tt.cyrillic)
+			}
+		})
+	}
+
+	if got := KazakhCyrillicToLatin("Қант өнімі"); got != "qant önimi" {
+		t.Errorf("KazakhCyrillicToLatin = %q, want %q", got, "qant önimi")
+	}
+	if got := KazakhLatinToCyrillic(KazakhCyrillicToLatin("сүт өнімдері")); got != "сүт өнімдері" {
+		t.Errorf("round trip = %q, want %q", got, "сүт өнімдері")
+	}
+}
+
+func TestKazakhStemmer_Stem(t *testing.T) {
+	stemmer := NewKazakhStemmer()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"сүттер", "сүт"},
+		{"сүттердің", "сүт"},
+		{"кітаптарға", "кітап"},
+		{"үйден", "үйден"}, // основа короче трех букв не отсекается
+		{"нан", "нан"},
+		{"qalamdar", "қалам"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := stemmer.StemWithCache(tt.input); got != tt.expected {
+				t.Errorf("Stem(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToKazakhCyrillic_SkipsCodes(t *testing.T) {
+	if got := ToKazakhCyrillic("Qant aq QSFP SR4", LanguageKazakhLatin); got != "қант ақ QSFP SR4" {
+		t.Errorf("ToKazakhCyrillic = %q, want %q", got, "қант ақ QSFP SR4")
+	}
+	if got := ToKazakhCyrillic("Iqos heets", LanguageUnknown); got != "Iqos heets" {
+		t.Errorf("ToKazakhCyrillic = %q, want unchanged", got)
+	}
+}
+
+func TestKazakhTokenize(t *testing.T) {
+	tokens := KazakhTokenize("Сүт және қант үшін")
+	if len(tokens) != 2 || tokens[0] != "сүт" || tokens[1] != "қант" {
+		t.Errorf("KazakhTokenize = %v, want [сүт қант]", tokens)
+	}
+
+	tokens = KazakhTokenize("Sút jäne qant")
+	if len(tokens) != 2 || tokens[0] != "сүт" || tokens[1] != "қант" {
+		t.Errorf("KazakhTokenize (latin) = %v, want [сүт қант]", tokens)
+	}
+}
+
+func TestPhoneticKey_KazakhScripts(t *testing.T) {
+	cyrillic := PhoneticKey("Қант ақ", LanguageKazakh)
+	latin := PhoneticKey("Qant aq", DetectLanguage("Qant aq"))
+	if cyrillic == "" || cyrillic != latin {
+		t.Errorf("PhoneticKey mismatch: cyrillic %q, latin %q", cyrillic, latin)
+	}
+
+	// Казахские буквы не должны теряться фонетическим алгоритмом
+	if PhoneticKey("қант", LanguageKazakh) != PhoneticKey("кант", LanguageRussian) {
+		t.Error("expected қ to be folded to к in phonetic key")
+	}
+}
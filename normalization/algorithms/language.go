@@ -0,0 +1,132 @@
+package algorithms
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language язык наименования, определяемый перед нормализацией
+type Language string
+
+const (
+	// LanguageRussian русский язык (кириллица без казахских букв)
+	LanguageRussian Language = "ru"
+	// LanguageKazakh казахский язык на кириллице
+	LanguageKazakh Language = "kk"
+	// LanguageKazakhLatin казахский язык на латинице (алфавиты 2018 и 2021 годов)
+	LanguageKazakhLatin Language = "kk-Latn"
+	// LanguageUnknown язык не определен (нет букв или латиница без казахских признаков)
+	LanguageUnknown Language = "und"
+)
+
+// kazakhCyrillicLetters буквы, которые есть в казахской кириллице и отсутствуют в русской.
+// і в признаки не входит: она есть в украинском и белорусском
+const kazakhCyrillicLetters = "әғқңөұүһӘҒҚҢӨҰҮҺ"
+
+// kazakhLatinLetters буквы казахской латиницы, которых нет в английском, турецком и испанском.
+// Остальные буквы алфавита (ä, ğ, ñ, ş, ç, ı, á, ó, ú) встречаются в турецких, испанских
+// и немецких брендах (Çelik, Año, Müller) и признаком казахского языка не считаются
+const kazakhLatinLetters = "ǵńŋūǴŃŊŪ"
+
+// kazakhLexicon частые слова казахских наименований (на кириллице). Каждое содержит
+// казахскую букву, поэтому в латинице совпадает только с казахским написанием
+var kazakhLexicon = map[string]bool{
+	"және": true, "үшін": true, "немесе": true, "арналған": true, "бойынша": true, "жоқ": true,
+	"сүт": true, "қант": true, "ақ": true, "қара": true, "ұн": true, "тұз": true, "өнім": true,
+	"тағам": true, "сусын": true, "ірімшік": true, "қаймақ": true, "жұмыртқа": true, "балық": true,
+	"күріш": true, "қалам": true, "құрылыс": true, "тауар": true, "киім": true, "үй": true,
+}
+
+// kazakhMinSignal минимальная сумма казахских признаков, при которой наименование считается казахским:
+// две казахские буквы, казахская буква с окончанием или слово из kazakhLexicon
+const kazakhMinSignal = 2
+
+// isKazakhQ возвращает true для буквы q (қ), за которой не следует u:
+// в английских и латинских словах q почти всегда стоит перед u (aqua, quartz)
+func isKazakhQ(runes []rune, i int) bool {
+	if runes[i] != 'q' && runes[i] != 'Q' {
+		return false
+	}
+	return i+1 >= len(runes) || (runes[i+1] != 'u' && runes[i+1] != 'U')
+}
+
+// IsKazakhLanguage возвращает true для казахского языка в любой графике
+func (l Language) IsKazakhLanguage() bool {
+	return l == LanguageKazakh || l == LanguageKazakhLatin
+}
+
+// DetectLanguage определяет язык наименования по буквам и словам.
+// Одной казахской буквы недостаточно: q и буквы с диакритикой встречаются в брендах
+// и кодах (Iqos, QSFP, Çelik). Наименование считается казахским, если в его словах
+// набирается kazakhMinSignal признаков: казахские буквы (1), казахское окончание
+// у слова с казахской буквой (1), слово из kazakhLexicon (2). Слова с цифрами
+// и слова, написанные заглавными буквами (коды, аббревиатуры), не учитываются
+func DetectLanguage(text string) Language {
+	cyrillic, latin, signal := 0, 0, 0
+	for _, token := range strings.Fields(text) {
+		runes := []rune(token)
+		tokenCyrillic, tokenLatin, letters := 0, 0, 0
+		for i, r := range runes {
+			switch {
+			case unicode.Is(unicode.Cyrillic, r):
+				tokenCyrillic++
+				if strings.ContainsRune(kazakhCyrillicLetters, r) {
+					letters++
+				}
+			case unicode.Is(unicode.Latin, r):
+				tokenLatin++
+				if strings.ContainsRune(kazakhLatinLetters, r) || isKazakhQ(runes, i) {
+					letters++
+				}
+			}
+		}
+		cyrillic += tokenCyrillic
+		latin += tokenLatin
+		if !IsTransliterableToken(token) {
+			continue
+		}
+
+		word := strings.ToLower(strings.TrimFunc(token, func(r rune) bool { return !unicode.IsLetter(r) }))
+		if tokenLatin > tokenCyrillic {
+			word = KazakhLatinToCyrillic(word)
+		}
+		stem := stemKazakh(word)
+		switch {
+		case kazakhLexicon[word] || kazakhLexicon[stem]:
+			signal += 2
+		case letters > 0 && stem != word:
+			signal += letters + 1
+		default:
+			signal += letters
+		}
+	}
+
+	switch {
+	case signal >= kazakhMinSignal && cyrillic > 0 && cyrillic >= latin:
+		return LanguageKazakh
+	case signal >= kazakhMinSignal && latin > cyrillic:
+		return LanguageKazakhLatin
+	case cyrillic > 0:
+		return LanguageRussian
+	default:
+		return LanguageUnknown
+	}
+}
+
+// IsTransliterableToken возвращает false для слов, которые нельзя переводить с латиницы
+// на кириллицу: с цифрами (QSFP-40G, SR4) и написанных заглавными буквами (аббревиатуры, бренды)
+func IsTransliterableToken(token string) bool {
+	letters, upper := 0, 0
+	for _, r := range token {
+		switch {
+		case unicode.IsDigit(r):
+			return false
+		case unicode.IsLetter(r):
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters < 2 || upper < letters
+}
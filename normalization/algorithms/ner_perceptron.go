@@ -0,0 +1,483 @@
+package algorithms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NERModelFormat идентификатор формата файла модели NER
+const NERModelFormat = "perceptron-ner"
+
+// NERModelFormatVersion текущая версия формата файла модели NER
+const NERModelFormatVersion = 1
+
+// NERTagger общий интерфейс для теггеров именованных сущностей.
+// Реализуется словарным RussianNER, обучаемым PerceptronNER и HybridNER.
+type NERTagger interface {
+	ExtractEntities(text string) []NEREntity
+	TagWithBIO(text string) []BIOTaggedToken
+}
+
+// NERTrainingExample обучающий пример: наименование и BIO-разметка его токенов
+type NERTrainingExample struct {
+	Text   string           `json:"text"`
+	Tokens []BIOTaggedToken `json:"tokens"`
+}
+
+// NERModelFile сериализованное представление модели NER
+type NERModelFile struct {
+	Format    string                        `json:"format"`
+	Version   int                           `json:"version"`
+	ProjectID int                           `json:"project_id,omitempty"`
+	TrainedAt time.Time                     `json:"trained_at"`
+	Examples  int                           `json:"examples"`
+	Epochs    int                           `json:"epochs"`
+	Labels    []string                      `json:"labels"`
+	Weights   map[string]map[string]float64 `json:"weights"`
+	Metrics   *NEREvaluation                `json:"metrics,omitempty"`
+}
+
+// PerceptronNER обучаемый последовательный теггер на основе усредненного перцептрона.
+// Словарный RussianNER используется как источник признаков.
+type PerceptronNER struct {
+	labels     []string
+	weights    map[string]map[string]float64
+	dictionary *RussianNER
+
+	// Состояние для усреднения весов во время обучения
+	totals     map[string]map[string]float64
+	timestamps map[string]map[string]int
+	instances  int
+
+	// Метаданные модели
+	ProjectID int
+	TrainedAt time.Time
+	Examples  int
+	Epochs    int
+	Metrics   *NEREvaluation
+}
+
+// NewPerceptronNER создает пустую модель. dictionary может быть nil,
+// тогда словарные признаки не используются.
+func NewPerceptronNER(dictionary *RussianNER) *PerceptronNER {
+	return &PerceptronNER{
+		labels:     []string{string(BIO_O)},
+		weights:    make(map[string]map[string]float64),
+		dictionary: dictionary,
+		totals:     make(map[string]map[string]float64),
+		timestamps: make(map[string]map[string]int),
+	}
+}
+
+// nerLabel собирает метку вида "B-MATERIAL" из BIO-тега и типа сущности
+func nerLabel(tag BIOTag, entityType NEREntityType) string {
+	if tag == BIO_O || tag == "" || entityType == "" {
+		return string(BIO_O)
+	}
+	return string(tag) + "-" + string(entityType)
+}
+
+// splitNERLabel разбирает метку вида "B-MATERIAL" на BIO-тег и тип сущности
+func splitNERLabel(label string) (BIOTag, NEREntityType) {
+	if label == string(BIO_O) || label == "" {
+		return BIO_O, ""
+	}
+	parts := strings.SplitN(label, "-", 2)
+	if len(parts) != 2 {
+		return BIO_O, ""
+	}
+	return BIOTag(parts[0]), NEREntityType(parts[1])
+}
+
+// tokenizeForNER разбивает текст на токены с позициями (как RussianNER.TagWithBIO)
+func tokenizeForNER(text string) []BIOTaggedToken {
+	words := strings.Fields(text)
+	tokens := make([]BIOTaggedToken, 0, len(words))
+	pos := 0
+	for _, word := range words {
+		wordStart := strings.Index(text[pos:], word)
+		if wordStart < 0 {
+			continue
+		}
+		wordStart += pos
+		tokens = append(tokens, BIOTaggedToken{
+			Token: word,
+			Tag:   BIO_O,
+			Start: wordStart,
+			End:   wordStart + len(word),
+		})
+		pos = wordStart + len(word)
+	}
+	return tokens
+}
+
+// wordShape возвращает сжатую форму слова: X - заглавная, x - строчная, d - цифра
+func wordShape(word string) string {
+	var b strings.Builder
+	var last rune
+	for _, r := range word {
+		var c rune
+		switch {
+		case unicode.IsDigit(r):
+			c = 'd'
+		case unicode.IsUpper(r):
+			c = 'X'
+		case unicode.IsLetter(r):
+			c = 'x'
+		default:
+			c = r
+		}
+		if c != last {
+			b.WriteRune(c)
+			last = c
+		}
+	}
+	return b.String()
+}
+
+// runeSuffix возвращает последние n символов слова
+func runeSuffix(word string, n int) string {
+	runes := []rune(word)
+	if len(runes) <= n {
+		return word
+	}
+	return string(runes[len(runes)-n:])
+}
+
+// runePrefix возвращает первые n символов слова
+func runePrefix(word string, n int) string {
+	runes := []rune(word)
+	if len(runes) <= n {
+		return word
+	}
+	return string(runes[:n])
+}
+
+// dictionaryLabels возвращает метки словарного теггера для токенов
+func (p *PerceptronNER) dictionaryLabels(text string, tokens []BIOTaggedToken) []string {
+	labels := make([]string, len(tokens))
+	for i := range labels {
+		labels[i] = string(BIO_O)
+	}
+	if p.dictionary == nil {
+		return labels
+	}
+	dictTokens := p.dictionary.TagWithBIO(text)
+	byStart := make(map[int]string, len(dictTokens))
+	for _, t := range dictTokens {
+		byStart[t.Start] = nerLabel(t.Tag, t.EntityType)
+	}
+	for i, t := range tokens {
+		if label, ok := byStart[t.Start]; ok {
+			labels[i] = label
+		}
+	}
+	return labels
+}
+
+// features формирует признаки i-го токена
+func (p *PerceptronNER) features(tokens []BIOTaggedToken, dictLabels []string, i int, prevLabel, prev2Label string) []string {
+	word := strings.ToLower(tokens[i].Token)
+	prevWord, nextWord := "<s>", "</s>"
+	if i > 0 {
+		prevWord = strings.ToLower(tokens[i-1].Token)
+	}
+	if i+1 < len(tokens) {
+		nextWord = strings.ToLower(tokens[i+1].Token)
+	}
+
+	feats := []string{
+		"bias",
+		"w=" + word,
+		"suf3=" + runeSuffix(word, 3),
+		"suf2=" + runeSuffix(word, 2),
+		"pre3=" + runePrefix(word, 3),
+		"shape=" + wordShape(tokens[i].Token),
+		"pw=" + prevWord,
+		"nw=" + nextWord,
+		"psuf3=" + runeSuffix(prevWord, 3),
+		"nsuf3=" + runeSuffix(nextWord, 3),
+		"pl=" + prevLabel,
+		"pl2=" + prev2Label + "|" + prevLabel,
+		"pl|w=" + prevLabel + "|" + word,
+		"dict=" + dictLabels[i],
+	}
+	if i > 0 {
+		feats = append(feats, "pdict="+dictLabels[i-1])
+	}
+	if i+1 < len(tokens) {
+		feats = append(feats, "ndict="+dictLabels[i+1])
+	}
+	if strings.ContainsAny(word, "0123456789") {
+		feats = append(feats, "has_digit")
+	}
+	if i == 0 {
+		feats = append(feats, "first")
+	}
+	return feats
+}
+
+// score вычисляет оценки всех меток для набора признаков
+func (p *PerceptronNER) score(feats []string) map[string]float64 {
+	scores := make(map[string]float64, len(p.labels))
+	for _, f := range feats {
+		weights, ok := p.weights[f]
+		if !ok {
+			continue
+		}
+		for label, w := range weights {
+			scores[label] += w
+		}
+	}
+	return scores
+}
+
+// predictLabel выбирает метку с максимальной оценкой (при равенстве остается O)
+func (p *PerceptronNER) predictLabel(feats []string) string {
+	scores := p.score(feats)
+	best := string(BIO_O)
+	bestScore := scores[best]
+	for _, label := range p.labels {
+		if s := scores[label]; s > bestScore {
+			best = label
+			bestScore = s
+		}
+	}
+	return best
+}
+
+// updateWeight изменяет вес признака с учетом усреднения
+func (p *PerceptronNER) updateWeight(feat, label string, delta float64) {
+	if p.weights[feat] == nil {
+		p.weights[feat] = make(map[string]float64)
+	}
+	if p.totals[feat] == nil {
+		p.totals[feat] = make(map[string]float64)
+		p.timestamps[feat] = make(map[string]int)
+	}
+	w := p.weights[feat][label]
+	p.totals[feat][label] += float64(p.instances-p.timestamps[feat][label]) * w
+	p.timestamps[feat][label] = p.instances
+	p.weights[feat][label] = w + delta
+}
+
+// addLabel регистрирует метку, если она встречается впервые
+func (p *PerceptronNER) addLabel(label string) {
+	for _, l := range p.labels {
+		if l == label {
+			return
+		}
+	}
+	p.labels = append(p.labels, label)
+	sort.Strings(p.labels)
+}
+
+// Train обучает модель на размеченных примерах заданное число эпох.
+// Порядок примеров перемешивается с фиксированным seed для воспроизводимости.
+func (p *PerceptronNER) Train(examples []NERTrainingExample, epochs int, seed int64) {
+	if epochs <= 0 {
+		epochs = 10
+	}
+	for _, ex := range examples {
+		for _, t := range ex.Tokens {
+			p.addLabel(nerLabel(t.Tag, t.EntityType))
+		}
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	order := make([]int, len(examples))
+	for i := range order {
+		order[i] = i
+	}
+
+	for epoch := 0; epoch < epochs; epoch++ {
+		rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+		for _, idx := range order {
+			ex := examples[idx]
+			if len(ex.Tokens) == 0 {
+				continue
+			}
+			dictLabels := p.dictionaryLabels(ex.Text, ex.Tokens)
+			prev, prev2 := "<s>", "<s>"
+			for i, t := range ex.Tokens {
+				p.instances++
+				gold := nerLabel(t.Tag, t.EntityType)
+				feats := p.features(ex.Tokens, dictLabels, i, prev, prev2)
+				guess := p.predictLabel(feats)
+				if guess != gold {
+					for _, f := range feats {
+						p.updateWeight(f, gold, 1)
+						p.updateWeight(f, guess, -1)
+					}
+				}
+				// Используем предсказанную метку как контекст (как при разборе)
+				prev2, prev = prev, guess
+			}
+		}
+	}
+
+	p.averageWeights()
+	p.Examples = len(examples)
+	p.Epochs = epochs
+	p.TrainedAt = time.Now()
+}
+
+// averageWeights заменяет веса их средними значениями за время обучения
+func (p *PerceptronNER) averageWeights() {
+	if p.instances == 0 {
+		return
+	}
+	for feat, weights := range p.weights {
+		for label, w := range weights {
+			total := p.totals[feat][label] + float64(p.instances-p.timestamps[feat][label])*w
+			avg := total / float64(p.instances)
+			if avg == 0 {
+				delete(weights, label)
+				continue
+			}
+			weights[label] = avg
+		}
+		if len(weights) == 0 {
+			delete(p.weights, feat)
+		}
+	}
+	p.totals = make(map[string]map[string]float64)
+	p.timestamps = make(map[string]map[string]int)
+	p.instances = 0
+}
+
+// TagWithBIO выполняет BIO-тегирование токенов обученной моделью
+func (p *PerceptronNER) TagWithBIO(text string) []BIOTaggedToken {
+	tokens := tokenizeForNER(text)
+	if len(tokens) == 0 {
+		return tokens
+	}
+	dictLabels := p.dictionaryLabels(text, tokens)
+	prev, prev2 := "<s>", "<s>"
+	for i := range tokens {
+		label := p.predictLabel(p.features(tokens, dictLabels, i, prev, prev2))
+		tag, entityType := splitNERLabel(label)
+		// I- без предшествующей сущности того же типа трактуем как начало
+		if tag == BIO_I && (i == 0 || tokens[i-1].EntityType != entityType) {
+			tag = BIO_B
+		}
+		tokens[i].Tag = tag
+		tokens[i].EntityType = entityType
+		prev2, prev = prev, label
+	}
+	return tokens
+}
+
+// ExtractEntities извлекает сущности, объединяя B/I токены в спаны
+func (p *PerceptronNER) ExtractEntities(text string) []NEREntity {
+	return entitiesFromBIO(text, p.TagWithBIO(text), 0.8)
+}
+
+// entitiesFromBIO собирает сущности из последовательности BIO-токенов
+func entitiesFromBIO(text string, tokens []BIOTaggedToken, confidence float64) []NEREntity {
+	var entities []NEREntity
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Tag != BIO_B {
+			continue
+		}
+		start, end := tokens[i].Start, tokens[i].End
+		j := i + 1
+		for j < len(tokens) && tokens[j].Tag == BIO_I && tokens[j].EntityType == tokens[i].EntityType {
+			end = tokens[j].End
+			j++
+		}
+		spanText := text[start:end]
+		entities = append(entities, NEREntity{
+			Type:       tokens[i].EntityType,
+			Text:       spanText,
+			Start:      start,
+			End:        end,
+			Confidence: confidence,
+			Value:      strings.ToLower(spanText),
+		})
+		i = j - 1
+	}
+	return entities
+}
+
+// Labels возвращает список известных модели меток
+func (p *PerceptronNER) Labels() []string {
+	return append([]string(nil), p.labels...)
+}
+
+// Save сохраняет модель в формате NERModelFile
+func (p *PerceptronNER) Save(w io.Writer) error {
+	file := NERModelFile{
+		Format:    NERModelFormat,
+		Version:   NERModelFormatVersion,
+		ProjectID: p.ProjectID,
+		TrainedAt: p.TrainedAt,
+		Examples:  p.Examples,
+		Epochs:    p.Epochs,
+		Labels:    p.labels,
+		Weights:   p.weights,
+		Metrics:   p.Metrics,
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", " ")
+	if err := encoder.Encode(&file); err != nil {
+		return fmt.Errorf("failed to encode NER model: %w", err)
+	}
+	return nil
+}
+
+// SaveToFile сохраняет модель в файл
+func (p *PerceptronNER) SaveToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create NER model file: %w", err)
+	}
+	defer f.Close()
+	return p.Save(f)
+}
+
+// LoadPerceptronNER загружает модель из потока. dictionary используется как источник признаков
+// и должен совпадать со словарем, с которым модель обучалась.
+func LoadPerceptronNER(r io.Reader, dictionary *RussianNER) (*PerceptronNER, error) {
+	var file NERModelFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to decode NER model: %w", err)
+	}
+	if file.Format != NERModelFormat {
+		return nil, fmt.Errorf("unsupported NER model format: %q", file.Format)
+	}
+	if file.Version > NERModelFormatVersion {
+		return nil, fmt.Errorf("unsupported NER model version: %d", file.Version)
+	}
+
+	p := NewPerceptronNER(dictionary)
+	if len(file.Labels) > 0 {
+		p.labels = file.Labels
+	}
+	if file.Weights != nil {
+		p.weights = file.Weights
+	}
+	p.ProjectID = file.ProjectID
+	p.TrainedAt = file.TrainedAt
+	p.Examples = file.Examples
+	p.Epochs = file.Epochs
+	p.Metrics = file.Metrics
+	return p, nil
+}
+
+// LoadPerceptronNERFromFile загружает модель из файла
+func LoadPerceptronNERFromFile(path string, dictionary *RussianNER) (*PerceptronNER, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open NER model file: %w", err)
+	}
+	defer f.Close()
+	return LoadPerceptronNER(f, dictionary)
+}
@@ -0,0 +1,158 @@
+package algorithms
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func nerTrainingSet() []NERTrainingExample {
+	samples := []struct {
+		text  string
+		spans []NERLabeledSpan
+	}{
+		{"Труба ПНД 32 мм синяя", []NERLabeledSpan{{NEREntityTypeLength, "32 мм"}, {NEREntityTypeColor, "синяя"}}},
+		{"Труба ПНД 50 мм черная", []NERLabeledSpan{{NEREntityTypeLength, "50 мм"}, {NEREntityTypeColor, "черная"}}},
+		{"Лист оцинкованный 1250x2500", []NERLabeledSpan{{NEREntityTypeMaterial, "оцинкованный"}, {NEREntityTypeDimension, "1250x2500"}}},
+		{"Лист оцинкованный 1000x2000", []NERLabeledSpan{{NEREntityTypeMaterial, "оцинкованный"}, {NEREntityTypeDimension, "1000x2000"}}},
+		{"Кабель медный 100 м", []NERLabeledSpan{{NEREntityTypeMaterial, "медный"}, {NEREntityTypeLength, "100 м"}}},
+		{"Провод медный 20 м белый", []NERLabeledSpan{{NEREntityTypeMaterial, "медный"}, {NEREntityTypeLength, "20 м"}, {NEREntityTypeColor, "белый"}}},
+	}
+
+	examples := make([]NERTrainingExample, 0, len(samples))
+	for _, s := range samples {
+		ex, _ := BuildNERTrainingExample(s.text, s.spans)
+		examples = append(examples, ex)
+	}
+	return examples
+}
+
+func TestBuildNERTrainingExample(t *testing.T) {
+	ex, placed := BuildNERTrainingExample("Труба ПНД 32 мм синяя", []NERLabeledSpan{
+		{Type: NEREntityTypeLength, Text: "32 мм"},
+		{Type: NEREntityTypeColor, Text: "Синяя"},
+		{Type: NEREntityTypeCode, Text: "отсутствует"},
+	})
+
+	if placed != 2 {
+		t.Fatalf("placed = %d, want 2", placed)
+	}
+	want := []string{"O", "O", "B-LENGTH", "I-LENGTH", "B-COLOR"}
+	if len(ex.Tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(ex.Tokens), len(want))
+	}
+	for i, tok := range ex.Tokens {
+		if got := nerLabel(tok.Tag, tok.EntityType); got != want[i] {
+			t.Errorf("token %q: label %s, want %s", tok.Token, got, want[i])
+		}
+	}
+}
+
+func TestNEREntityTypeForAttribute(t *testing.T) {
+	tests := []struct {
+		attrType, attrName string
+		want               NEREntityType
+		ok                 bool
+	}{
+		{"text_value", "material", NEREntityTypeMaterial, true},
+		{"dimension", "width", NEREntityTypeDimension, true},
+		{"numeric_value", "thickness", NEREntityTypeLength, true},
+		{"technical_code", "", NEREntityTypeCode, true},
+		{"numeric_value", "duration", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NEREntityTypeForAttribute(tt.attrType, tt.attrName)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NEREntityTypeForAttribute(%q, %q) = %q, %v; want %q, %v", tt.attrType, tt.attrName, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPerceptronNER_TrainAndTag(t *testing.T) {
+	examples := nerTrainingSet()
+	model := NewPerceptronNER(NewRussianNER())
+	model.Train(examples, 15, 1)
+
+	eval := EvaluateNER(model, examples)
+	if eval.Overall.F1 < 0.9 {
+		t.Errorf("training set F1 = %.2f, want >= 0.9", eval.Overall.F1)
+	}
+
+	// "оцинкованный" отсутствует в словаре RussianNER и выучивается только из данных
+	entities := model.ExtractEntities("Лист оцинкованный 1500x3000")
+	found := false
+	for _, e := range entities {
+		if e.Type == NEREntityTypeMaterial && e.Text == "оцинкованный" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected MATERIAL 'оцинкованный', got %+v", entities)
+	}
+}
+
+func TestPerceptronNER_SaveLoad(t *testing.T) {
+	model := NewPerceptronNER(NewRussianNER())
+	model.ProjectID = 7
+	model.Train(nerTrainingSet(), 10, 1)
+
+	var buf bytes.Buffer
+	if err := model.Save(&buf); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := LoadPerceptronNER(&buf, NewRussianNER())
+	if err != nil {
+		t.Fatalf("LoadPerceptronNER() error = %v", err)
+	}
+	if loaded.ProjectID != 7 {
+		t.Errorf("ProjectID = %d, want 7", loaded.ProjectID)
+	}
+
+	text := "Труба ПНД 40 мм синяя"
+	original := model.TagWithBIO(text)
+	restored := loaded.TagWithBIO(text)
+	for i := range original {
+		if original[i].Tag != restored[i].Tag || original[i].EntityType != restored[i].EntityType {
+			t.Errorf("token %q: loaded model tag %s-%s, want %s-%s", original[i].Token,
+				restored[i].Tag, restored[i].EntityType, original[i].Tag, original[i].EntityType)
+		}
+	}
+
+	if _, err := LoadPerceptronNER(bytes.NewBufferString(`{"format":"other","version":1}`), nil); err == nil {
+		t.Error("expected error for unknown model format")
+	}
+}
+
+func TestLoadNERForProject_Fallback(t *testing.T) {
+	dir := t.TempDir()
+
+	tagger, err := LoadNERForProject(dir, 3)
+	if err != nil {
+		t.Fatalf("LoadNERForProject() error = %v", err)
+	}
+	if tagger.HasModel() {
+		t.Error("expected dictionary-only tagger when no model file exists")
+	}
+	if len(tagger.ExtractEntities("стальной белый кабель")) == 0 {
+		t.Error("dictionary fallback should extract entities")
+	}
+
+	model := NewPerceptronNER(NewRussianNER())
+	model.Train(nerTrainingSet(), 5, 1)
+	if err := model.SaveToFile(NERModelPath(dir, 0)); err != nil {
+		t.Fatalf("SaveToFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ner_global.json")); err != nil {
+		t.Fatalf("global model file not created: %v", err)
+	}
+
+	tagger, err = LoadNERForProject(dir, 3)
+	if err != nil {
+		t.Fatalf("LoadNERForProject() error = %v", err)
+	}
+	if !tagger.HasModel() {
+		t.Error("expected project to fall back to the global model")
+	}
+}
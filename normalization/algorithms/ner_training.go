@@ -0,0 +1,311 @@
+package algorithms
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NERLabeledSpan размеченный фрагмент наименования (например, подтвержденный атрибут)
+type NERLabeledSpan struct {
+	Type NEREntityType `json:"type"`
+	Text string        `json:"text"`
+}
+
+// NEREntityTypeForAttribute сопоставляет тип и имя атрибута из normalized_item_attributes
+// типу сущности NER. Обратное преобразование к NameNormalizer.ExtractAttributesWithNER.
+func NEREntityTypeForAttribute(attributeType, attributeName string) (NEREntityType, bool) {
+	switch strings.ToLower(attributeName) {
+	case "material":
+		return NEREntityTypeMaterial, true
+	case "color":
+		return NEREntityTypeColor, true
+	case "type":
+		return NEREntityTypeType, true
+	case "dimension", "width", "height", "depth":
+		return NEREntityTypeDimension, true
+	case "weight":
+		return NEREntityTypeWeight, true
+	case "length", "thickness", "diameter":
+		return NEREntityTypeLength, true
+	case "volume":
+		return NEREntityTypeVolume, true
+	case "power":
+		return NEREntityTypePower, true
+	case "code", "article":
+		return NEREntityTypeCode, true
+	}
+
+	switch strings.ToLower(attributeType) {
+	case "dimension":
+		return NEREntityTypeDimension, true
+	case "article_code", "technical_code":
+		return NEREntityTypeCode, true
+	}
+	return "", false
+}
+
+// BuildNERTrainingExample строит BIO-разметку наименования по списку размеченных фрагментов.
+// Фрагменты ищутся без учета регистра; не найденные в тексте фрагменты пропускаются.
+// Возвращает пример и количество фрагментов, которые удалось разместить.
+func BuildNERTrainingExample(text string, spans []NERLabeledSpan) (NERTrainingExample, int) {
+	tokens := tokenizeForNER(text)
+	lowerText := strings.ToLower(text)
+	placed := 0
+
+	for _, span := range spans {
+		needle := strings.ToLower(strings.TrimSpace(span.Text))
+		if needle == "" || span.Type == "" {
+			continue
+		}
+		// Ищем первое вхождение, не пересекающееся с уже размеченными токенами
+		searchFrom := 0
+		for searchFrom < len(lowerText) {
+			idx := strings.Index(lowerText[searchFrom:], needle)
+			if idx < 0 {
+				break
+			}
+			start := searchFrom + idx
+			end := start + len(needle)
+			if labelTokensInRange(tokens, start, end, span.Type) {
+				placed++
+				break
+			}
+			searchFrom = end
+		}
+	}
+
+	return NERTrainingExample{Text: text, Tokens: tokens}, placed
+}
+
+// labelTokensInRange размечает токены, пересекающиеся с диапазоном [start, end).
+// Возвращает false, если диапазон не покрывает токенов или они уже размечены.
+func labelTokensInRange(tokens []BIOTaggedToken, start, end int, entityType NEREntityType) bool {
+	var idx []int
+	for i, t := range tokens {
+		if t.Start < end && t.End > start {
+			if t.Tag != BIO_O {
+				return false
+			}
+			idx = append(idx, i)
+		}
+	}
+	if len(idx) == 0 {
+		return false
+	}
+	for n, i := range idx {
+		if n == 0 {
+			tokens[i].Tag = BIO_B
+		} else {
+			tokens[i].Tag = BIO_I
+		}
+		tokens[i].EntityType = entityType
+	}
+	return true
+}
+
+// NERTypeMetrics метрики качества для одного типа сущности
+type NERTypeMetrics struct {
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+}
+
+// NEREvaluation результат оценки теггера на размеченной выборке (по точному совпадению спанов)
+type NEREvaluation struct {
+	Examples      int                               `json:"examples"`
+	Tokens        int                               `json:"tokens"`
+	TokenAccuracy float64                           `json:"token_accuracy"`
+	Overall       NERTypeMetrics                    `json:"overall"`
+	ByType        map[NEREntityType]*NERTypeMetrics `json:"by_type"`
+}
+
+// nerSpanKey ключ спана для сравнения предсказаний с эталоном
+type nerSpanKey struct {
+	start, end int
+	entityType NEREntityType
+}
+
+// spansFromTokens выделяет спаны сущностей из BIO-токенов
+func spansFromTokens(tokens []BIOTaggedToken) map[nerSpanKey]bool {
+	spans := make(map[nerSpanKey]bool)
+	for i := 0; i < len(tokens); i++ {
+		if tokens[i].Tag == BIO_O {
+			continue
+		}
+		start := tokens[i].Start
+		end := tokens[i].End
+		j := i + 1
+		for j < len(tokens) && tokens[j].Tag == BIO_I && tokens[j].EntityType == tokens[i].EntityType {
+			end = tokens[j].End
+			j++
+		}
+		spans[nerSpanKey{start: start, end: end, entityType: tokens[i].EntityType}] = true
+		i = j - 1
+	}
+	return spans
+}
+
+// EvaluateNER оценивает теггер на размеченных примерах
+func EvaluateNER(tagger NERTagger, examples []NERTrainingExample) *NEREvaluation {
+	eval := &NEREvaluation{
+		Examples: len(examples),
+		ByType:   make(map[NEREntityType]*NERTypeMetrics),
+	}
+	correctTokens := 0
+
+	metricsFor := func(t NEREntityType) *NERTypeMetrics {
+		m, ok := eval.ByType[t]
+		if !ok {
+			m = &NERTypeMetrics{}
+			eval.ByType[t] = m
+		}
+		return m
+	}
+
+	for _, ex := range examples {
+		predicted := tagger.TagWithBIO(ex.Text)
+		predByStart := make(map[int]BIOTaggedToken, len(predicted))
+		for _, t := range predicted {
+			predByStart[t.Start] = t
+		}
+		for _, gold := range ex.Tokens {
+			eval.Tokens++
+			if p, ok := predByStart[gold.Start]; ok &&
+				nerLabel(p.Tag, p.EntityType) == nerLabel(gold.Tag, gold.EntityType) {
+				correctTokens++
+			}
+		}
+
+		goldSpans := spansFromTokens(ex.Tokens)
+		predSpans := spansFromTokens(predicted)
+		for span := range predSpans {
+			if goldSpans[span] {
+				metricsFor(span.entityType).TruePositives++
+			} else {
+				metricsFor(span.entityType).FalsePositives++
+			}
+		}
+		for span := range goldSpans {
+			if !predSpans[span] {
+				metricsFor(span.entityType).FalseNegatives++
+			}
+		}
+	}
+
+	for _, m := range eval.ByType {
+		eval.Overall.TruePositives += m.TruePositives
+		eval.Overall.FalsePositives += m.FalsePositives
+		eval.Overall.FalseNegatives += m.FalseNegatives
+		m.computeScores()
+	}
+	eval.Overall.computeScores()
+	if eval.Tokens > 0 {
+		eval.TokenAccuracy = float64(correctTokens) / float64(eval.Tokens)
+	}
+	return eval
+}
+
+// computeScores вычисляет precision/recall/F1 по счетчикам
+func (m *NERTypeMetrics) computeScores() {
+	if m.TruePositives+m.FalsePositives > 0 {
+		m.Precision = float64(m.TruePositives) / float64(m.TruePositives+m.FalsePositives)
+	}
+	if m.TruePositives+m.FalseNegatives > 0 {
+		m.Recall = float64(m.TruePositives) / float64(m.TruePositives+m.FalseNegatives)
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+}
+
+// SortedTypes возвращает типы сущностей из оценки в стабильном порядке
+func (e *NEREvaluation) SortedTypes() []NEREntityType {
+	types := make([]NEREntityType, 0, len(e.ByType))
+	for t := range e.ByType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// HybridNER использует обученную модель, а словарный NER - как запасной вариант,
+// если модель не загружена или не нашла ни одной сущности.
+type HybridNER struct {
+	model    *PerceptronNER
+	fallback *RussianNER
+}
+
+// NewHybridNER создает гибридный теггер. model может быть nil.
+func NewHybridNER(model *PerceptronNER, fallback *RussianNER) *HybridNER {
+	if fallback == nil {
+		fallback = NewRussianNER()
+	}
+	return &HybridNER{model: model, fallback: fallback}
+}
+
+// HasModel возвращает true, если загружена обученная модель
+func (h *HybridNER) HasModel() bool {
+	return h.model != nil
+}
+
+// ExtractEntities извлекает сущности моделью с откатом на словарь
+func (h *HybridNER) ExtractEntities(text string) []NEREntity {
+	if h.model != nil {
+		if entities := h.model.ExtractEntities(text); len(entities) > 0 {
+			return entities
+		}
+	}
+	return h.fallback.ExtractEntities(text)
+}
+
+// TagWithBIO выполняет BIO-тегирование моделью с откатом на словарь
+func (h *HybridNER) TagWithBIO(text string) []BIOTaggedToken {
+	if h.model != nil {
+		tokens := h.model.TagWithBIO(text)
+		for _, t := range tokens {
+			if t.Tag != BIO_O {
+				return tokens
+			}
+		}
+	}
+	return h.fallback.TagWithBIO(text)
+}
+
+// NERModelPath возвращает путь к файлу модели проекта (projectID <= 0 - глобальная модель)
+func NERModelPath(modelDir string, projectID int) string {
+	if projectID <= 0 {
+		return filepath.Join(modelDir, "ner_global.json")
+	}
+	return filepath.Join(modelDir, fmt.Sprintf("ner_project_%d.json", projectID))
+}
+
+// LoadNERForProject загружает модель проекта, затем глобальную модель;
+// если ни одной нет, возвращает гибридный теггер только со словарем.
+func LoadNERForProject(modelDir string, projectID int) (*HybridNER, error) {
+	dictionary := NewRussianNER()
+	if modelDir == "" {
+		return NewHybridNER(nil, dictionary), nil
+	}
+
+	candidates := []string{NERModelPath(modelDir, projectID)}
+	if projectID > 0 {
+		candidates = append(candidates, NERModelPath(modelDir, 0))
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		model, err := LoadPerceptronNERFromFile(path, dictionary)
+		if err != nil {
+			return NewHybridNER(nil, dictionary), err
+		}
+		return NewHybridNER(model, dictionary), nil
+	}
+	return NewHybridNER(nil, dictionary), nil
+}
@@ -0,0 +1,215 @@
+package algorithms
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SpellSuggestion вариант исправления слова
+type SpellSuggestion struct {
+	Term     string `json:"term"`
+	Distance int    `json:"distance"`
+	Count    int64  `json:"count"`
+}
+
+// SymSpell словарь для исправления опечаток по алгоритму Symmetric Delete.
+// Для каждого слова словаря заранее строятся варианты с удаленными буквами
+// (только для префикса длины prefixLength), поэтому поиск кандидатов
+// сводится к поиску по индексу удалений без перебора всего словаря.
+// Помимо частот слов хранятся частоты биграмм для выбора исправления по контексту
+type SymSpell struct {
+	maxEditDistance int
+	prefixLength    int
+	words           map[string]int64
+	deletes         map[string][]string
+	bigrams         map[string]int64
+	maxWordLength   int
+	distance        *DamerauLevenshtein
+	mu              sync.RWMutex
+}
+
+// NewSymSpell создает пустой словарь. maxEditDistance - максимальное расстояние
+// Дамерау-Левенштейна для поиска (обычно 2), prefixLength - длина префикса
+// для индекса удалений (обычно 7)
+func NewSymSpell(maxEditDistance, prefixLength int) *SymSpell {
+	if maxEditDistance < 1 {
+		maxEditDistance = 1
+	}
+	if prefixLength <= maxEditDistance {
+		prefixLength = maxEditDistance + 1
+	}
+	return &SymSpell{
+		maxEditDistance: maxEditDistance,
+		prefixLength:    prefixLength,
+		words:           make(map[string]int64),
+		deletes:         make(map[string][]string),
+		bigrams:         make(map[string]int64),
+		distance:        NewDamerauLevenshtein(),
+	}
+}
+
+// MaxEditDistance возвращает максимальное расстояние поиска
+func (s *SymSpell) MaxEditDistance() int {
+	return s.maxEditDistance
+}
+
+// AddWord добавляет слово в словарь или увеличивает его частоту
+func (s *SymSpell) AddWord(word string, count int64) {
+	if word == "" || count <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.words[word]; exists {
+		s.words[word] += count
+		return
+	}
+	s.words[word] = count
+
+	runes := []rune(word)
+	if len(runes) > s.maxWordLength {
+		s.maxWordLength = len(runes)
+	}
+	if len(runes) > s.prefixLength {
+		runes = runes[:s.prefixLength]
+	}
+	for _, del := range s.editsDeletes(runes) {
+		s.deletes[del] = append(s.deletes[del], word)
+	}
+}
+
+// AddBigram увеличивает частоту пары соседних слов
+func (s *SymSpell) AddBigram(first, second string, count int64) {
+	if first == "" || second == "" || count <= 0 {
+		return
+	}
+	s.mu.Lock()
+	s.bigrams[first+" "+second] += count
+	s.mu.Unlock()
+}
+
+// WordCount возвращает частоту слова (0, если слова нет в словаре)
+func (s *SymSpell) WordCount(word string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.words[word]
+}
+
+// BigramCount возвращает частоту пары соседних слов
+func (s *SymSpell) BigramCount(first, second string) int64 {
+	if first == "" || second == "" {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.bigrams[first+" "+second]
+}
+
+// WordsCount возвращает число слов в словаре
+func (s *SymSpell) WordsCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.words)
+}
+
+// BigramsCount возвращает число биграмм в словаре
+func (s *SymSpell) BigramsCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.bigrams)
+}
+
+// Lookup возвращает варианты исправления слова в пределах maxEditDistance,
+// отсортированные по расстоянию и убыванию частоты. Если слово есть в словаре,
+// оно возвращается первым с расстоянием 0
+func (s *SymSpell) Lookup(word string, maxEditDistance int) []SpellSuggestion {
+	if word == "" {
+		return nil
+	}
+	if maxEditDistance <= 0 || maxEditDistance > s.maxEditDistance {
+		maxEditDistance = s.maxEditDistance
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	runes := []rune(word)
+	if len(runes)-maxEditDistance > s.maxWordLength {
+		return nil
+	}
+
+	var suggestions []SpellSuggestion
+	seen := map[string]bool{word: true}
+	if count, ok := s.words[word]; ok {
+		suggestions = append(suggestions, SpellSuggestion{Term: word, Distance: 0, Count: count})
+	}
+
+	prefix := runes
+	if len(prefix) > s.prefixLength {
+		prefix = prefix[:s.prefixLength]
+	}
+	for _, candidate := range s.editsDeletes(prefix) {
+		for _, term := range s.deletes[candidate] {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+
+			termRunes := []rune(term)
+			if lengthDiff := len(termRunes) - len(runes); lengthDiff > maxEditDistance || -lengthDiff > maxEditDistance {
+				continue
+			}
+			dist := s.distance.DistanceRunes(runes, termRunes)
+			if dist > maxEditDistance {
+				continue
+			}
+			suggestions = append(suggestions, SpellSuggestion{Term: term, Distance: dist, Count: s.words[term]})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		if suggestions[i].Count != suggestions[j].Count {
+			return suggestions[i].Count > suggestions[j].Count
+		}
+		return strings.Compare(suggestions[i].Term, suggestions[j].Term) < 0
+	})
+	return suggestions
+}
+
+// editsDeletes возвращает само слово и все его варианты с удаленными 1..maxEditDistance буквами
+func (s *SymSpell) editsDeletes(runes []rune) []string {
+	result := make(map[string]bool)
+	current := [][]rune{runes}
+	for d := 0; d < s.maxEditDistance; d++ {
+		var next [][]rune
+		for _, word := range current {
+			if len(word) <= 1 {
+				continue
+			}
+			for i := range word {
+				del := make([]rune, 0, len(word)-1)
+				del = append(del, word[:i]...)
+				del = append(del, word[i+1:]...)
+				key := string(del)
+				if !result[key] {
+					result[key] = true
+					next = append(next, del)
+				}
+			}
+		}
+		current = next
+	}
+
+	deletes := make([]string, 0, len(result)+1)
+	deletes = append(deletes, string(runes))
+	for key := range result {
+		deletes = append(deletes, key)
+	}
+	return deletes
+}
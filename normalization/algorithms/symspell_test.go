@@ -0,0 +1,55 @@
+package algorithms
+
+import (
+	"testing"
+)
+
+func TestSymSpell_Lookup(t *testing.T) {
+	dict := NewSymSpell(2, 7)
+	dict.AddWord("кабель", 100)
+	dict.AddWord("кабина", 5)
+	dict.AddWord("болт", 50)
+	dict.AddWord("шуруповерт", 30)
+
+	tests := []struct {
+		input    string
+		expected string
+		distance int
+	}{
+		{"кабельь", "кабель", 1},
+		{"кабел", "кабель", 1},
+		{"болд", "болт", 1},
+		{"шурупаверт", "шуруповерт", 1},
+		{"шуруповертт", "шуруповерт", 1},
+		{"кабель", "кабель", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			suggestions := dict.Lookup(tt.input, 2)
+			if len(suggestions) == 0 {
+				t.Fatalf("Lookup(%q) returned no suggestions", tt.input)
+			}
+			if suggestions[0].Term != tt.expected || suggestions[0].Distance != tt.distance {
+				t.Errorf("Lookup(%q) = %+v, want %q at distance %d", tt.input, suggestions[0], tt.expected, tt.distance)
+			}
+		})
+	}
+
+	if suggestions := dict.Lookup("молоток", 2); len(suggestions) != 0 {
+		t.Errorf("Lookup(молоток) = %+v, want no suggestions", suggestions)
+	}
+}
+
+func TestSymSpell_Bigrams(t *testing.T) {
+	dict := NewSymSpell(2, 7)
+	dict.AddBigram("болт", "оцинкованный", 3)
+	dict.AddBigram("болт", "оцинкованный", 2)
+
+	if got := dict.BigramCount("болт", "оцинкованный"); got != 5 {
+		t.Errorf("BigramCount = %d, want 5", got)
+	}
+	if got := dict.BigramCount("оцинкованный", "болт"); got != 0 {
+		t.Errorf("BigramCount (reversed) = %d, want 0", got)
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 
 	"httpserver/database"
+	"httpserver/internal/config"
 	"httpserver/nomenclature"
+	"httpserver/normalization/algorithms"
 )
 
 // ClientNormalizationResult результат нормализации для клиента
@@ -36,7 +39,8 @@ type ClientNormalizationGroup struct {
 	KpvedCode       string
 	KpvedName       string
 	KpvedConfidence float64
-	Attributes      map[string][]*database.ItemAttribute // code -> attributes
+	Attributes      map[string][]*database.ItemAttribute     // code -> attributes
+	Traces          map[*database.CatalogItem]*DecisionTrace // Записи решений по элементам группы
 }
 
 // ClientNormalizer нормализатор с поддержкой клиентских эталонов
@@ -50,6 +54,7 @@ type ClientNormalizer struct {
 	basicNormalizer *Normalizer
 	events          chan<- string
 	sessionID       *int // ID сессии нормализации
+	nerModel        bool // Установлена обученная модель NER проекта
 }
 
 // WorkerConfigManagerInterface интерфейс для получения конфигурации модели
@@ -94,6 +99,8 @@ func NewClientNormalizerWithConfig(clientID, projectID int, db *database.DB, ser
 	}
 	
 	normalizer.basicNormalizer = NewNormalizerWithStopCheck(db, events, aiConfig, nil, getAPIKey)
+	// Результаты AI кэшируются в пространстве имен клиента
+	normalizer.basicNormalizer.SetCacheNamespace(config.ClientCacheNamespace(clientID))
 
 	// Инициализация AI клиента
 	var apiKey, model string
@@ -118,6 +125,7 @@ func NewClientNormalizerWithConfig(clientID, projectID int, db *database.DB, ser
 	if apiKey != "" {
 		normalizer.aiClient = nomenclature.NewAIClient(apiKey, model)
 	}
+	normalizer.applyUsageTags()
 
 	return normalizer
 }
@@ -137,9 +145,19 @@ func (c *ClientNormalizer) ProcessWithClientBenchmarks(items []*database.Catalog
 	processedCount := 0
 
 	for _, item := range items {
+		// Запись решений по элементу (см. /api/normalization/explain/{id})
+		trace := &DecisionTrace{}
+
 		// 1. Проверка против эталонов клиента
 		benchmark, found := c.benchmarkStore.FindBenchmark(item.Name)
 		if found {
+			trace.Add(DecisionStep{
+				Stage:   DecisionStageBenchmark,
+				Outcome: DecisionOutcomeApplied,
+				Result:  benchmark.NormalizedName,
+				Score:   1.0,
+				Details: map[string]interface{}{"benchmark_id": benchmark.ID, "category": benchmark.Category},
+			})
 			// Используем эталонную запись
 			result.BenchmarkMatches++
 			c.sendEvent(fmt.Sprintf("✓ Найдено совпадение с эталоном: %s -> %s", item.Name, benchmark.NormalizedName))
@@ -159,36 +177,52 @@ func (c *ClientNormalizer) ProcessWithClientBenchmarks(items []*database.Catalog
 					AIConfidence:    1.0, // Эталоны имеют максимальную уверенность
 					Items:           make([]*database.CatalogItem, 0),
 					Attributes:      make(map[string][]*database.ItemAttribute),
+					Traces:          make(map[*database.CatalogItem]*DecisionTrace),
 				}
 			}
 			groups[key].Items = append(groups[key].Items, item)
+			groups[key].Traces[item] = trace
 			processedCount++
 			continue
 		}
+		trace.Add(DecisionStep{Stage: DecisionStageBenchmark, Outcome: DecisionOutcomeNoMatch})
 
-		// 2. Базовая нормализация с извлечением атрибутов
-		category := c.basicNormalizer.categorizer.Categorize(item.Name)
+		// 2. Исправление опечаток, словарь терминов и базовая нормализация с извлечением атрибутов
+		name := c.basicNormalizer.correctSpelling(item.Name, trace)
+		name = c.basicNormalizer.applyTermDictionary(name, trace)
+		category := c.basicNormalizer.categorizer.Categorize(name)
 		var normalizedName string
 		var attributes []*database.ItemAttribute
 		if c.basicNormalizer.nameNormalizer != nil {
-			normalizedName, attributes = c.basicNormalizer.nameNormalizer.ExtractAttributes(item.Name)
+			normalizedName, attributes = c.basicNormalizer.nameNormalizer.ExtractAttributes(name)
 		} else {
 			// Fallback на простую нормализацию
 			normalizedName = item.Name
 			attributes = []*database.ItemAttribute{}
 		}
+		if c.nerModel {
+			attributes = mergeNERAttributes(attributes, c.basicNormalizer.nameNormalizer.NERAttributes(name))
+		}
 		if normalizedName == "" {
 			normalizedName = item.Name // Используем исходное имя, если нормализация дала пустую строку
 		}
+		trace.Add(DecisionStep{
+			Stage:   DecisionStageRules,
+			Outcome: DecisionOutcomeApplied,
+			Result:  normalizedName,
+			Details: map[string]interface{}{"category": category, "attributes": len(attributes)},
+		})
 		aiConfidence := 0.0
 		aiReasoning := ""
 		processingLevel := "basic"
 
 		// 3. AI-усиление если требуется
 		if c.basicNormalizer.useAI && c.basicNormalizer.aiNormalizer != nil &&
-			c.basicNormalizer.aiNormalizer.RequiresAI(item.Name, category) {
-			aiResult, err := c.basicNormalizer.processWithAI(item.Name)
-			if err == nil && aiResult.Confidence >= c.basicNormalizer.aiConfig.MinConfidence {
+			c.basicNormalizer.aiNormalizer.RequiresAI(name, category) {
+			aiResult, err := c.basicNormalizer.processWithAI(name)
+			accepted := err == nil && aiResult.Confidence >= c.basicNormalizer.aiConfig.MinConfidence
+			trace.Add(TraceAI(aiResult, err, c.basicNormalizer.aiConfig.MinConfidence, accepted))
+			if accepted {
 				category = aiResult.Category
 				normalizedName = aiResult.NormalizedName
 				aiConfidence = aiResult.Confidence
@@ -226,9 +260,11 @@ func (c *ClientNormalizer) ProcessWithClientBenchmarks(items []*database.Catalog
 				ProcessingLevel: processingLevel,
 				Items:           make([]*database.CatalogItem, 0),
 				Attributes:      make(map[string][]*database.ItemAttribute),
+				Traces:          make(map[*database.CatalogItem]*DecisionTrace),
 			}
 		}
 		groups[key].Items = append(groups[key].Items, item)
+		groups[key].Traces[item] = trace
 		if len(attributes) > 0 && item.Code != "" {
 			groups[key].Attributes[item.Code] = attributes
 		}
@@ -256,6 +292,72 @@ func (c *ClientNormalizer) SetSessionID(sessionID int) {
 	if c.basicNormalizer != nil {
 		c.basicNormalizer.SetSessionID(sessionID)
 	}
+	c.applyUsageTags()
+}
+
+// applyUsageTags передает клиента, проект и сессию в учет затрат на AI
+func (c *ClientNormalizer) applyUsageTags() {
+	tags := nomenclature.UsageTags{
+		ClientID:  c.clientID,
+		ProjectID: c.projectID,
+		Operation: "normalization",
+	}
+	if c.sessionID != nil {
+		tags.SessionID = strconv.Itoa(*c.sessionID)
+	}
+	if c.aiClient != nil {
+		c.aiClient.SetUsageTags(tags)
+	}
+	if c.basicNormalizer != nil {
+		c.basicNormalizer.SetUsageTags(tags)
+	}
+}
+
+// SetSpellCorrector устанавливает исправление опечаток по словарю проекта
+func (c *ClientNormalizer) SetSpellCorrector(corrector *SpellCorrector) {
+	if c.basicNormalizer != nil {
+		c.basicNormalizer.SetSpellCorrector(corrector)
+	}
+}
+
+// SetTermDictionary устанавливает словарь терминов проекта для базового нормализатора
+func (c *ClientNormalizer) SetTermDictionary(dictionary *TermDictionary) {
+	if c.basicNormalizer != nil {
+		c.basicNormalizer.SetTermDictionary(dictionary)
+	}
+}
+
+// SetNERTagger устанавливает обученную модель NER проекта (algorithms.LoadNERForProject).
+// Найденные моделью атрибуты дополняют извлеченные правилами; nil отключает модель
+func (c *ClientNormalizer) SetNERTagger(tagger algorithms.NERTagger) {
+	if c.basicNormalizer == nil || c.basicNormalizer.nameNormalizer == nil {
+		return
+	}
+	c.basicNormalizer.nameNormalizer.SetNERTagger(tagger)
+	c.nerModel = tagger != nil
+}
+
+// mergeNERAttributes добавляет атрибуты NER, которых нет среди извлеченных правилами
+func mergeNERAttributes(attributes, nerAttributes []*database.ItemAttribute) []*database.ItemAttribute {
+	known := make(map[string]bool, len(attributes))
+	for _, attr := range attributes {
+		known[attr.AttributeName] = true
+	}
+	for _, attr := range nerAttributes {
+		if known[attr.AttributeName] {
+			continue
+		}
+		known[attr.AttributeName] = true
+		attributes = append(attributes, attr)
+	}
+	return attributes
+}
+
+// SetCacheNamespace переопределяет пространство имен AI кэша клиента
+func (c *ClientNormalizer) SetCacheNamespace(namespace string) {
+	if c.basicNormalizer != nil && namespace != "" {
+		c.basicNormalizer.SetCacheNamespace(namespace)
+	}
 }
 
 // sendEvent отправляет событие в канал
@@ -0,0 +1,58 @@
+package normalization
+
+import (
+	"testing"
+
+	"httpserver/database"
+	"httpserver/normalization/algorithms"
+)
+
+// stubNERTagger теггер с заранее заданными сущностями
+type stubNERTagger struct {
+	entities []algorithms.NEREntity
+}
+
+func (s stubNERTagger) ExtractEntities(text string) []algorithms.NEREntity {
+	return s.entities
+}
+
+func (s stubNERTagger) TagWithBIO(text string) []algorithms.BIOTaggedToken {
+	return nil
+}
+
+func TestClientNormalizer_NERModelAddsAttributes(t *testing.T) {
+	t.Setenv("ARLIAI_API_KEY", "")
+	serviceDB, err := database.NewServiceDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	defer serviceDB.Close()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	normalizer := NewClientNormalizer(1, 1, db, serviceDB, nil)
+	normalizer.SetNERTagger(stubNERTagger{entities: []algorithms.NEREntity{
+		{Type: algorithms.NEREntityTypeMaterial, Text: "медный", Value: "медный", Confidence: 0.9},
+		{Type: algorithms.NEREntityTypeMaterial, Text: "луженый", Value: "луженый", Confidence: 0.6},
+	}})
+	item := &database.CatalogItem{Name: "Провод медный 20 м", Code: "1"}
+
+	result, err := normalizer.ProcessWithClientBenchmarks([]*database.CatalogItem{item})
+	if err != nil {
+		t.Fatalf("ProcessWithClientBenchmarks() error = %v", err)
+	}
+	var materials []string
+	for _, group := range result.Groups {
+		for _, attr := range group.Attributes[item.Code] {
+			if attr.AttributeName == "material" {
+				materials = append(materials, attr.AttributeValue)
+			}
+		}
+	}
+	if len(materials) != 1 || materials[0] != "медный" {
+		t.Errorf("material attributes = %v, want [медный]", materials)
+	}
+}
@@ -41,6 +41,16 @@ type CounterpartyDuplicateItem struct {
 	EnrichmentApplied    bool
 	SourceEnrichment     string
 	DatabaseCount        int // Количество связанных баз данных
+	AttributeSet         extractors.AttributeSet
+}
+
+// ExtractAttribute извлекает поле из разобранных при загрузке реквизитов,
+// а при их отсутствии - из attributes_xml
+func (item *CounterpartyDuplicateItem) ExtractAttribute(field extractors.Field) (string, error) {
+	if len(item.AttributeSet) > 0 {
+		return extractors.ExtractFromSet(item.AttributeSet, field)
+	}
+	return extractors.ExtractFromXML(item.Attributes, field)
 }
 
 // CounterpartyDuplicateAnalyzer анализатор дублей контрагентов
@@ -82,8 +92,8 @@ func (cda *CounterpartyDuplicateAnalyzer) groupByINNKPP(counterparties []*databa
 
 	// Извлекаем данные и группируем
 	for _, item := range counterparties {
-		inn, _ := extractors.ExtractINNFromAttributes(item.Attributes)
-		kpp, _ := extractors.ExtractKPPFromAttributes(item.Attributes)
+		inn, _ := item.ExtractAttribute(extractors.FieldINN)
+		kpp, _ := item.ExtractAttribute(extractors.FieldKPP)
 
 		// Создаем ключ: ИНН/КПП или только ИНН
 		var key string
@@ -124,7 +134,7 @@ func (cda *CounterpartyDuplicateAnalyzer) groupByBIN(counterparties []*database.
 
 	// Извлекаем данные и группируем
 	for _, item := range counterparties {
-		bin, err := extractors.ExtractBINFromAttributes(item.Attributes)
+		bin, err := item.ExtractAttribute(extractors.FieldBIN)
 		if err != nil || bin == "" {
 			continue // Пропускаем, если нет БИН
 		}
@@ -456,6 +466,7 @@ func (cda *CounterpartyDuplicateAnalyzer) catalogItemToDuplicateItem(item *datab
 		Code:           item.Code,
 		Name:           item.Name,
 		Attributes:     item.Attributes, // Сохраняем оригинальные атрибуты
+		AttributeSet:   item.AttributeSet,
 		INN:            inn,
 		KPP:            kpp,
 		BIN:            bin,
@@ -466,49 +477,49 @@ func (cda *CounterpartyDuplicateAnalyzer) catalogItemToDuplicateItem(item *datab
 	// Извлекаем данные из атрибутов, если они не были переданы
 	if item.Attributes != "" {
 		if inn == "" {
-			if extractedINN, err := extractors.ExtractINNFromAttributes(item.Attributes); err == nil {
+			if extractedINN, err := item.ExtractAttribute(extractors.FieldINN); err == nil {
 				duplicateItem.INN = extractedINN
 			}
 		}
 		if kpp == "" {
-			if extractedKPP, err := extractors.ExtractKPPFromAttributes(item.Attributes); err == nil {
+			if extractedKPP, err := item.ExtractAttribute(extractors.FieldKPP); err == nil {
 				duplicateItem.KPP = extractedKPP
 			}
 		}
 		if bin == "" {
-			if extractedBIN, err := extractors.ExtractBINFromAttributes(item.Attributes); err == nil {
+			if extractedBIN, err := item.ExtractAttribute(extractors.FieldBIN); err == nil {
 				duplicateItem.BIN = extractedBIN
 			}
 		}
 
 		// Извлекаем адреса
-		if addr, err := extractors.ExtractAddressFromAttributes(item.Attributes); err == nil {
+		if addr, err := item.ExtractAttribute(extractors.FieldAddress); err == nil {
 			duplicateItem.LegalAddress = addr
 			duplicateItem.PostalAddress = addr
 		}
 
 		// Извлекаем контактную информацию
-		if phone, err := extractors.ExtractContactPhoneFromAttributes(item.Attributes); err == nil {
+		if phone, err := item.ExtractAttribute(extractors.FieldContactPhone); err == nil {
 			duplicateItem.ContactPhone = phone
 		}
-		if email, err := extractors.ExtractContactEmailFromAttributes(item.Attributes); err == nil {
+		if email, err := item.ExtractAttribute(extractors.FieldContactEmail); err == nil {
 			duplicateItem.ContactEmail = email
 		}
-		if person, err := extractors.ExtractContactPersonFromAttributes(item.Attributes); err == nil {
+		if person, err := item.ExtractAttribute(extractors.FieldContactPerson); err == nil {
 			duplicateItem.ContactPerson = person
 		}
 
 		// Извлекаем банковские реквизиты
-		if bank, err := extractors.ExtractBankNameFromAttributes(item.Attributes); err == nil {
+		if bank, err := item.ExtractAttribute(extractors.FieldBankName); err == nil {
 			duplicateItem.BankName = bank
 		}
-		if account, err := extractors.ExtractBankAccountFromAttributes(item.Attributes); err == nil {
+		if account, err := item.ExtractAttribute(extractors.FieldBankAccount); err == nil {
 			duplicateItem.BankAccount = account
 		}
-		if corrAccount, err := extractors.ExtractCorrespondentAccountFromAttributes(item.Attributes); err == nil {
+		if corrAccount, err := item.ExtractAttribute(extractors.FieldCorrespondentAccount); err == nil {
 			duplicateItem.CorrespondentAccount = corrAccount
 		}
-		if bik, err := extractors.ExtractBIKFromAttributes(item.Attributes); err == nil {
+		if bik, err := item.ExtractAttribute(extractors.FieldBIK); err == nil {
 			duplicateItem.BIK = bik
 		}
 	}
@@ -524,9 +535,9 @@ func (cda *CounterpartyDuplicateAnalyzer) FindDuplicatesForCounterparty(
 	duplicates := []*CounterpartyDuplicateItem{}
 
 	// Извлекаем идентификаторы
-	inn, _ := extractors.ExtractINNFromAttributes(counterparty.Attributes)
-	kpp, _ := extractors.ExtractKPPFromAttributes(counterparty.Attributes)
-	bin, _ := extractors.ExtractBINFromAttributes(counterparty.Attributes)
+	inn, _ := counterparty.ExtractAttribute(extractors.FieldINN)
+	kpp, _ := counterparty.ExtractAttribute(extractors.FieldKPP)
+	bin, _ := counterparty.ExtractAttribute(extractors.FieldBIN)
 
 	// Ищем дубликаты по ИНН/КПП
 	if inn != "" {
@@ -535,8 +546,8 @@ func (cda *CounterpartyDuplicateAnalyzer) FindDuplicatesForCounterparty(
 				continue // Пропускаем сам элемент
 			}
 
-			itemINN, _ := extractors.ExtractINNFromAttributes(item.Attributes)
-			itemKPP, _ := extractors.ExtractKPPFromAttributes(item.Attributes)
+			itemINN, _ := item.ExtractAttribute(extractors.FieldINN)
+			itemKPP, _ := item.ExtractAttribute(extractors.FieldKPP)
 
 			// Проверяем совпадение по ИНН
 			if itemINN == inn {
@@ -562,7 +573,7 @@ func (cda *CounterpartyDuplicateAnalyzer) FindDuplicatesForCounterparty(
 				continue
 			}
 
-			itemBIN, err := extractors.ExtractBINFromAttributes(item.Attributes)
+			itemBIN, err := item.ExtractAttribute(extractors.FieldBIN)
 			if err == nil && itemBIN == bin {
 				// Проверяем, не добавлен ли уже этот элемент
 				alreadyAdded := false
@@ -449,15 +449,15 @@ func (cm *CounterpartyMapper) getOrCreateNormalizedCounterparty(projectID int, i
 
 	// Если данные не были извлечены, пробуем извлечь из атрибутов
 	if inn == "" && bin == "" && item.Attributes != "" {
-		if extractedINN, err := extractors.ExtractINNFromAttributes(item.Attributes); err == nil {
+		if extractedINN, err := item.ExtractAttribute(extractors.FieldINN); err == nil {
 			inn = extractedINN
 		}
-		if extractedBIN, err := extractors.ExtractBINFromAttributes(item.Attributes); err == nil {
+		if extractedBIN, err := item.ExtractAttribute(extractors.FieldBIN); err == nil {
 			bin = extractedBIN
 		}
 	}
 	if kpp == "" && item.Attributes != "" {
-		if extractedKPP, err := extractors.ExtractKPPFromAttributes(item.Attributes); err == nil {
+		if extractedKPP, err := item.ExtractAttribute(extractors.FieldKPP); err == nil {
 			kpp = extractedKPP
 		}
 	}
@@ -496,43 +496,43 @@ func (cm *CounterpartyMapper) getOrCreateNormalizedCounterparty(projectID int, i
 	// Если данные не были извлечены, пробуем извлечь из атрибутов
 	if item.Attributes != "" {
 		if legalAddress == "" {
-			if addr, err := extractors.ExtractAddressFromAttributes(item.Attributes); err == nil {
+			if addr, err := item.ExtractAttribute(extractors.FieldAddress); err == nil {
 				legalAddress = addr
 				postalAddress = addr
 			}
 		}
 		if contactPhone == "" {
-			if phone, err := extractors.ExtractContactPhoneFromAttributes(item.Attributes); err == nil {
+			if phone, err := item.ExtractAttribute(extractors.FieldContactPhone); err == nil {
 				contactPhone = phone
 			}
 		}
 		if contactEmail == "" {
-			if email, err := extractors.ExtractContactEmailFromAttributes(item.Attributes); err == nil {
+			if email, err := item.ExtractAttribute(extractors.FieldContactEmail); err == nil {
 				contactEmail = email
 			}
 		}
 		if contactPerson == "" {
-			if person, err := extractors.ExtractContactPersonFromAttributes(item.Attributes); err == nil {
+			if person, err := item.ExtractAttribute(extractors.FieldContactPerson); err == nil {
 				contactPerson = person
 			}
 		}
 		if bankName == "" {
-			if bank, err := extractors.ExtractBankNameFromAttributes(item.Attributes); err == nil {
+			if bank, err := item.ExtractAttribute(extractors.FieldBankName); err == nil {
 				bankName = bank
 			}
 		}
 		if bankAccount == "" {
-			if account, err := extractors.ExtractBankAccountFromAttributes(item.Attributes); err == nil {
+			if account, err := item.ExtractAttribute(extractors.FieldBankAccount); err == nil {
 				bankAccount = account
 			}
 		}
 		if correspondentAccount == "" {
-			if corrAccount, err := extractors.ExtractCorrespondentAccountFromAttributes(item.Attributes); err == nil {
+			if corrAccount, err := item.ExtractAttribute(extractors.FieldCorrespondentAccount); err == nil {
 				correspondentAccount = corrAccount
 			}
 		}
 		if bik == "" {
-			if bikCode, err := extractors.ExtractBIKFromAttributes(item.Attributes); err == nil {
+			if bikCode, err := item.ExtractAttribute(extractors.FieldBIK); err == nil {
 				bik = bikCode
 			}
 		}
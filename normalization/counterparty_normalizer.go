@@ -346,13 +346,13 @@ func (cn *CounterpartyNormalizer) processCounterparty(
 	kpp := ""
 
 	if cp.Attributes != "" {
-		if extractedINN, err := extractors.ExtractINNFromAttributes(cp.Attributes); err == nil {
+		if extractedINN, err := cp.ExtractAttribute(extractors.FieldINN); err == nil {
 			inn = extractedINN
 		}
-		if extractedBIN, err := extractors.ExtractBINFromAttributes(cp.Attributes); err == nil {
+		if extractedBIN, err := cp.ExtractAttribute(extractors.FieldBIN); err == nil {
 			bin = extractedBIN
 		}
-		if extractedKPP, err := extractors.ExtractKPPFromAttributes(cp.Attributes); err == nil {
+		if extractedKPP, err := cp.ExtractAttribute(extractors.FieldKPP); err == nil {
 			kpp = extractedKPP
 		}
 	}
@@ -431,32 +431,32 @@ func (cn *CounterpartyNormalizer) processCounterparty(
 	bik := ""
 
 	if cp.Attributes != "" {
-		if addr, err := extractors.ExtractAddressFromAttributes(cp.Attributes); err == nil {
+		if addr, err := cp.ExtractAttribute(extractors.FieldAddress); err == nil {
 			legalAddress = addr
 			postalAddress = addr
 		}
-		if phone, err := extractors.ExtractContactPhoneFromAttributes(cp.Attributes); err == nil {
+		if phone, err := cp.ExtractAttribute(extractors.FieldContactPhone); err == nil {
 			contactPhone = phone
 		}
-		if email, err := extractors.ExtractContactEmailFromAttributes(cp.Attributes); err == nil {
+		if email, err := cp.ExtractAttribute(extractors.FieldContactEmail); err == nil {
 			contactEmail = email
 		}
-		if person, err := extractors.ExtractContactPersonFromAttributes(cp.Attributes); err == nil {
+		if person, err := cp.ExtractAttribute(extractors.FieldContactPerson); err == nil {
 			contactPerson = person
 		}
-		if form, err := extractors.ExtractLegalFormFromAttributes(cp.Attributes); err == nil {
+		if form, err := cp.ExtractAttribute(extractors.FieldLegalForm); err == nil {
 			legalForm = form
 		}
-		if bank, err := extractors.ExtractBankNameFromAttributes(cp.Attributes); err == nil {
+		if bank, err := cp.ExtractAttribute(extractors.FieldBankName); err == nil {
 			bankName = bank
 		}
-		if account, err := extractors.ExtractBankAccountFromAttributes(cp.Attributes); err == nil {
+		if account, err := cp.ExtractAttribute(extractors.FieldBankAccount); err == nil {
 			bankAccount = account
 		}
-		if corrAccount, err := extractors.ExtractCorrespondentAccountFromAttributes(cp.Attributes); err == nil {
+		if corrAccount, err := cp.ExtractAttribute(extractors.FieldCorrespondentAccount); err == nil {
 			correspondentAccount = corrAccount
 		}
-		if bikCode, err := extractors.ExtractBIKFromAttributes(cp.Attributes); err == nil {
+		if bikCode, err := cp.ExtractAttribute(extractors.FieldBIK); err == nil {
 			bik = bikCode
 		}
 	}
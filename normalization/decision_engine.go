@@ -9,12 +9,13 @@ import (
 
 // FinalDecision финальное решение по классификации
 type FinalDecision struct {
-	Code             string  // Итоговый код КПВЭД
-	Name             string  // Итоговое название
-	Confidence       float64 // Финальная уверенность
-	Method           string  // Метод: "stage6", "stage7", "stage65", "stage8", "manual"
-	ValidationPassed bool    // Прошла ли валидация
-	DecisionReason   string  // Причина выбора
+	Code             string            // Итоговый код КПВЭД
+	Name             string            // Итоговое название
+	Confidence       float64           // Финальная уверенность
+	Method           string            // Метод: "stage6", "stage7", "stage65", "stage8", "manual"
+	ValidationPassed bool              // Прошла ли валидация
+	DecisionReason   string            // Причина выбора
+	Candidates       []CandidateResult // Рассмотренные кандидаты в порядке приоритета
 }
 
 // CandidateResult кандидат для финального выбора
@@ -64,6 +65,19 @@ func (d *DecisionEngine) Decide(
 	// Собираем всех кандидатов
 	candidates := d.collectCandidates(stage6Result, stage7Result, stage8Result)
 
+	decision := d.decide(candidates, stage8Result, itemType, attributes)
+	// decide сортирует кандидатов по приоритету, сохраняем их для объяснения решения
+	decision.Candidates = candidates
+	return decision
+}
+
+// decide выбирает лучшего кандидата с учетом валидации кода и типа элемента
+func (d *DecisionEngine) decide(
+	candidates []CandidateResult,
+	stage8Result *FallbackResult,
+	itemType string,
+	attributes map[string]interface{},
+) *FinalDecision {
 	if len(candidates) == 0 {
 		log.Printf("[Decision] No valid candidates found, manual review required")
 		return &FinalDecision{
@@ -0,0 +1,314 @@
+package normalization
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Этапы конвейера, оставляющие запись о решении по элементу
+const (
+	DecisionStageValidation = "validation" // Правила ValidationEngine
+	DecisionStageSpelling   = "spelling"   // Исправление опечаток по словарю
+	DecisionStageDictionary = "dictionary" // Сокращения, синонимы и стоп-слова словаря терминов
+	DecisionStageWebSearch  = "websearch"  // Правила валидации через веб-поиск
+	DecisionStageRules      = "rules"      // Категоризатор и нормализация имени правилами
+	DecisionStagePatterns   = "patterns"   // Паттерны PatternDetector
+	DecisionStageBenchmark  = "benchmark"  // Сопоставление с эталонами
+	DecisionStageAI         = "ai"         // AI нормализация и голоса провайдеров
+	DecisionStageKpved      = "kpved"      // Иерархическая классификация КПВЭД
+	DecisionStageDecision   = "decision"   // Финальный выбор кода DecisionEngine
+	DecisionStageTemplate   = "template"   // Шаблон нормализованного имени
+)
+
+// Итоги этапа
+const (
+	DecisionOutcomeApplied  = "applied"  // Результат этапа использован
+	DecisionOutcomeRejected = "rejected" // Этап дал результат, но он отклонен (низкая уверенность и т.п.)
+	DecisionOutcomeSkipped  = "skipped"  // Этап не выполнялся
+	DecisionOutcomeNoMatch  = "no_match" // Этап выполнен, совпадений нет
+	DecisionOutcomeFailed   = "failed"   // Ошибка этапа
+	DecisionOutcomePassed   = "passed"   // Проверка пройдена
+	DecisionOutcomeWarning  = "warning"  // Проверка не пройдена, обработка продолжена
+)
+
+// maxTraceTextLength ограничение длины текстовых полей записи (reasoning моделей бывает длинным)
+const maxTraceTextLength = 500
+
+// DecisionStep запись одного этапа конвейера
+type DecisionStep struct {
+	Stage   string                 `json:"stage"`
+	Outcome string                 `json:"outcome"`
+	Result  string                 `json:"result,omitempty"` // Имя или код, полученные на этапе
+	Score   float64                `json:"score,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// DecisionTrace компактная запись решений конвейера по элементу
+type DecisionTrace struct {
+	Steps []DecisionStep `json:"steps"`
+}
+
+// Add добавляет этап; безопасно вызывается на nil
+func (t *DecisionTrace) Add(step DecisionStep) {
+	if t == nil {
+		return
+	}
+	t.Steps = append(t.Steps, step)
+}
+
+// Merge возвращает новую запись из этапов t и other (записи групп дополняют записи элементов)
+func (t *DecisionTrace) Merge(other *DecisionTrace) *DecisionTrace {
+	merged := &DecisionTrace{}
+	if t != nil {
+		merged.Steps = append(merged.Steps, t.Steps...)
+	}
+	if other != nil {
+		merged.Steps = append(merged.Steps, other.Steps...)
+	}
+	return merged
+}
+
+// Marshal сериализует запись в JSON; пустая запись дает пустую строку
+func (t *DecisionTrace) Marshal() string {
+	if t == nil || len(t.Steps) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseDecisionTrace разбирает запись, сохраненную Marshal
+func ParseDecisionTrace(data string) (*DecisionTrace, error) {
+	trace := &DecisionTrace{}
+	if strings.TrimSpace(data) == "" {
+		return trace, nil
+	}
+	if err := json.Unmarshal([]byte(data), trace); err != nil {
+		return nil, err
+	}
+	return trace, nil
+}
+
+// TraceValidation формирует этапы по результатам правил ValidationEngine.
+// Правила веб-поиска (web_search_*) выносятся в отдельный этап websearch.
+func TraceValidation(outcomes []ValidationOutcome) []DecisionStep {
+	var steps []DecisionStep
+	var failed []map[string]interface{}
+	for _, outcome := range outcomes {
+		if strings.HasPrefix(outcome.Rule, "web_search_") {
+			step := DecisionStep{
+				Stage:   DecisionStageWebSearch,
+				Outcome: DecisionOutcomePassed,
+				Details: map[string]interface{}{"rule": outcome.Rule},
+			}
+			if !outcome.Passed {
+				step.Outcome = DecisionOutcomeWarning
+				step.Details["message"] = truncateTraceText(outcome.Message)
+			}
+			steps = append(steps, step)
+			continue
+		}
+		if !outcome.Passed {
+			failed = append(failed, map[string]interface{}{
+				"rule":     outcome.Rule,
+				"severity": string(outcome.Severity),
+				"message":  truncateTraceText(outcome.Message),
+			})
+		}
+	}
+
+	validation := DecisionStep{Stage: DecisionStageValidation, Outcome: DecisionOutcomePassed}
+	if len(failed) > 0 {
+		validation.Outcome = DecisionOutcomeWarning
+		validation.Details = map[string]interface{}{"failed_rules": failed}
+	}
+	return append([]DecisionStep{validation}, steps...)
+}
+
+// TracePatterns формирует этап по найденным паттернам; applied = true, если исправления применены к имени
+func TracePatterns(matches []PatternMatch, fixedName string, applied bool) DecisionStep {
+	if len(matches) == 0 {
+		return DecisionStep{Stage: DecisionStagePatterns, Outcome: DecisionOutcomeNoMatch}
+	}
+	found := make([]map[string]interface{}, 0, len(matches))
+	for _, match := range matches {
+		found = append(found, map[string]interface{}{
+			"type":          string(match.Type),
+			"matched_text":  match.MatchedText,
+			"suggested_fix": match.SuggestedFix,
+			"confidence":    match.Confidence,
+			"auto_fixable":  match.AutoFixable,
+		})
+	}
+	step := DecisionStep{
+		Stage:   DecisionStagePatterns,
+		Outcome: DecisionOutcomeWarning,
+		Details: map[string]interface{}{"matches": found},
+	}
+	if applied {
+		step.Outcome = DecisionOutcomeApplied
+		step.Result = fixedName
+	} else if fixedName != "" {
+		step.Details["suggested_name"] = fixedName
+	}
+	return step
+}
+
+// TraceDictionary формирует этап применения словаря терминов
+func TraceDictionary(result TermDictionaryResult) DecisionStep {
+	if len(result.Replacements) == 0 {
+		return DecisionStep{Stage: DecisionStageDictionary, Outcome: DecisionOutcomeNoMatch}
+	}
+	replacements := make([]map[string]interface{}, 0, len(result.Replacements))
+	for _, replacement := range result.Replacements {
+		replacements = append(replacements, map[string]interface{}{
+			"kind":        replacement.Kind,
+			"original":    replacement.Original,
+			"replacement": replacement.Replacement,
+		})
+	}
+	return DecisionStep{
+		Stage:   DecisionStageDictionary,
+		Outcome: DecisionOutcomeApplied,
+		Result:  result.Result,
+		Details: map[string]interface{}{"replacements": replacements},
+	}
+}
+
+// TraceSpelling формирует этап исправления опечаток
+func TraceSpelling(result SpellCheckResult) DecisionStep {
+	if len(result.Corrections) == 0 {
+		return DecisionStep{Stage: DecisionStageSpelling, Outcome: DecisionOutcomeNoMatch}
+	}
+	corrections := make([]map[string]interface{}, 0, len(result.Corrections))
+	for _, correction := range result.Corrections {
+		corrections = append(corrections, map[string]interface{}{
+			"original":   correction.Original,
+			"suggested":  correction.Suggested,
+			"confidence": correction.Confidence,
+			"reason":     correction.Reason,
+			"applied":    correction.Applied,
+		})
+	}
+	step := DecisionStep{
+		Stage:   DecisionStageSpelling,
+		Outcome: DecisionOutcomeWarning,
+		Details: map[string]interface{}{"corrections": corrections},
+	}
+	if result.Corrected != result.Original {
+		step.Outcome = DecisionOutcomeApplied
+		step.Result = result.Corrected
+	}
+	return step
+}
+
+// TraceAI формирует этап AI нормализации; accepted = false, если результат отклонен по порогу уверенности
+func TraceAI(result *AIResult, err error, minConfidence float64, accepted bool) DecisionStep {
+	if err != nil {
+		return DecisionStep{
+			Stage:   DecisionStageAI,
+			Outcome: DecisionOutcomeFailed,
+			Details: map[string]interface{}{"error": truncateTraceText(err.Error())},
+		}
+	}
+	if result == nil {
+		return DecisionStep{Stage: DecisionStageAI, Outcome: DecisionOutcomeSkipped}
+	}
+	step := DecisionStep{
+		Stage:   DecisionStageAI,
+		Outcome: DecisionOutcomeApplied,
+		Result:  result.NormalizedName,
+		Score:   result.Confidence,
+		Details: map[string]interface{}{
+			"category":       result.Category,
+			"reasoning":      truncateTraceText(result.Reasoning),
+			"min_confidence": minConfidence,
+		},
+	}
+	if !accepted {
+		step.Outcome = DecisionOutcomeRejected
+	}
+	if len(result.Votes) > 0 {
+		step.Details["votes"] = result.Votes
+	}
+	return step
+}
+
+// TraceKpved формирует этап иерархической классификации со всеми шагами спуска по дереву
+func TraceKpved(result *HierarchicalResult, err error) DecisionStep {
+	if err != nil {
+		return DecisionStep{
+			Stage:   DecisionStageKpved,
+			Outcome: DecisionOutcomeFailed,
+			Details: map[string]interface{}{"error": truncateTraceText(err.Error())},
+		}
+	}
+	if result == nil || result.FinalCode == "" {
+		return DecisionStep{Stage: DecisionStageKpved, Outcome: DecisionOutcomeNoMatch}
+	}
+	levels := make([]map[string]interface{}, 0, len(result.Steps))
+	for _, step := range result.Steps {
+		levels = append(levels, map[string]interface{}{
+			"level":      step.LevelName,
+			"code":       step.Code,
+			"name":       step.Name,
+			"confidence": step.Confidence,
+			"reasoning":  truncateTraceText(step.Reasoning),
+		})
+	}
+	return DecisionStep{
+		Stage:   DecisionStageKpved,
+		Outcome: DecisionOutcomeApplied,
+		Result:  result.FinalCode,
+		Score:   result.FinalConfidence,
+		Details: map[string]interface{}{
+			"name":     result.FinalName,
+			"levels":   levels,
+			"ai_calls": result.AICallsCount,
+		},
+	}
+}
+
+// TraceDecision формирует этап финального выбора кода с рассмотренными кандидатами
+func TraceDecision(decision *FinalDecision) DecisionStep {
+	if decision == nil {
+		return DecisionStep{Stage: DecisionStageDecision, Outcome: DecisionOutcomeSkipped}
+	}
+	candidates := make([]map[string]interface{}, 0, len(decision.Candidates))
+	for _, candidate := range decision.Candidates {
+		candidates = append(candidates, map[string]interface{}{
+			"source":     candidate.Source,
+			"code":       candidate.Code,
+			"name":       candidate.Name,
+			"confidence": candidate.Confidence,
+		})
+	}
+	outcome := DecisionOutcomeApplied
+	if decision.Method == "manual" {
+		outcome = DecisionOutcomeNoMatch
+	}
+	return DecisionStep{
+		Stage:   DecisionStageDecision,
+		Outcome: outcome,
+		Result:  decision.Code,
+		Score:   decision.Confidence,
+		Details: map[string]interface{}{
+			"method":            decision.Method,
+			"reason":            decision.DecisionReason,
+			"validation_passed": decision.ValidationPassed,
+			"candidates":        candidates,
+		},
+	}
+}
+
+// truncateTraceText обрезает текст по границе руны
+func truncateTraceText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxTraceTextLength {
+		return text
+	}
+	return string(runes[:maxTraceTextLength]) + "…"
+}
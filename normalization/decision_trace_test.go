@@ -0,0 +1,163 @@
+package normalization
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"httpserver/database"
+)
+
+func TestTraceValidation_SplitsWebSearchRules(t *testing.T) {
+	steps := TraceValidation([]ValidationOutcome{
+		{Rule: "min_length", Severity: ValidationSeverityMedium, Passed: false, Message: "слишком короткое"},
+		{Rule: "web_search_existence", Passed: true},
+		{Rule: "web_search_accuracy", Passed: false, Message: "не найдено"},
+	})
+
+	if len(steps) != 3 {
+		t.Fatalf("expected 3 steps, got %d: %+v", len(steps), steps)
+	}
+	if steps[0].Stage != DecisionStageValidation || steps[0].Outcome != DecisionOutcomeWarning {
+		t.Errorf("validation step = %+v", steps[0])
+	}
+	if steps[1].Stage != DecisionStageWebSearch || steps[1].Outcome != DecisionOutcomePassed {
+		t.Errorf("web search step = %+v", steps[1])
+	}
+	if steps[2].Outcome != DecisionOutcomeWarning || steps[2].Details["message"] != "не найдено" {
+		t.Errorf("failed web search step = %+v", steps[2])
+	}
+}
+
+func TestTraceAI_RecordsVotesAndRejection(t *testing.T) {
+	result := &AIResult{
+		NormalizedName: "болт м8",
+		Category:       "крепеж",
+		Confidence:     0.6,
+		Reasoning:      strings.Repeat("а", maxTraceTextLength+10),
+		Votes: []ProviderVote{
+			{Provider: "arliai", NormalizedName: "болт м8", Confidence: 0.7, Success: true},
+			{Provider: "openrouter", Success: false, Error: "timeout"},
+		},
+	}
+
+	step := TraceAI(result, nil, 0.8, false)
+	if step.Outcome != DecisionOutcomeRejected || step.Score != 0.6 {
+		t.Errorf("step = %+v", step)
+	}
+	if votes, ok := step.Details["votes"].([]ProviderVote); !ok || len(votes) != 2 {
+		t.Errorf("votes = %v", step.Details["votes"])
+	}
+	if reasoning := step.Details["reasoning"].(string); len([]rune(reasoning)) != maxTraceTextLength+1 {
+		t.Errorf("reasoning not truncated: %d runes", len([]rune(reasoning)))
+	}
+
+	if failed := TraceAI(nil, errors.New("boom"), 0.8, false); failed.Outcome != DecisionOutcomeFailed {
+		t.Errorf("failed step = %+v", failed)
+	}
+}
+
+func TestDecisionTrace_MergeMarshalRoundTrip(t *testing.T) {
+	itemTrace := &DecisionTrace{}
+	itemTrace.Add(DecisionStep{Stage: DecisionStageRules, Outcome: DecisionOutcomeApplied, Result: "болт м8"})
+	itemTrace.Add(TracePatterns(nil, "", false))
+
+	groupTrace := &DecisionTrace{}
+	groupTrace.Add(TraceKpved(&HierarchicalResult{
+		FinalCode:       "25.94.11",
+		FinalName:       "Болты",
+		FinalConfidence: 0.9,
+		Steps:           []ClassificationStep{{LevelName: "section", Code: "C", Confidence: 0.95}},
+	}, nil))
+
+	data := itemTrace.Merge(groupTrace).Marshal()
+	if len(itemTrace.Steps) != 2 {
+		t.Fatalf("Merge must not modify the item trace, got %d steps", len(itemTrace.Steps))
+	}
+
+	parsed, err := ParseDecisionTrace(data)
+	if err != nil {
+		t.Fatalf("ParseDecisionTrace() error = %v", err)
+	}
+	if len(parsed.Steps) != 3 {
+		t.Fatalf("expected 3 steps, got %+v", parsed.Steps)
+	}
+	if parsed.Steps[1].Outcome != DecisionOutcomeNoMatch {
+		t.Errorf("patterns step = %+v", parsed.Steps[1])
+	}
+	if kpved := parsed.Steps[2]; kpved.Stage != DecisionStageKpved || kpved.Result != "25.94.11" || kpved.Score != 0.9 {
+		t.Errorf("kpved step = %+v", kpved)
+	}
+
+	if (&DecisionTrace{}).Marshal() != "" {
+		t.Error("empty trace must marshal to empty string")
+	}
+	if empty, err := ParseDecisionTrace(""); err != nil || len(empty.Steps) != 0 {
+		t.Errorf("ParseDecisionTrace(\"\") = %+v, %v", empty, err)
+	}
+}
+
+func TestDecisionEngine_DecideKeepsCandidates(t *testing.T) {
+	tree := NewKpvedTree()
+	tree.NodeMap["25.94.11"] = &KpvedNode{Code: "25.94.11", Name: "Болты"}
+	de := &DecisionEngine{
+		tree: tree,
+		codeValidator: &CodeValidator{
+			tree:       tree,
+			codeFormat: regexp.MustCompile(`^\d{2}\.\d{2}(\.\d{2})?(\.\d{3})?$`),
+		},
+	}
+
+	decision := de.Decide(&HierarchicalResult{FinalCode: "25.94.11", FinalName: "Болты", FinalConfidence: 0.9}, nil, nil, "product", nil)
+	if len(decision.Candidates) == 0 {
+		t.Fatal("decision must keep considered candidates")
+	}
+
+	step := TraceDecision(decision)
+	if step.Result != decision.Code || step.Details["candidates"] == nil {
+		t.Errorf("decision step = %+v", step)
+	}
+}
+
+func TestClientNormalizer_RecordsItemTraces(t *testing.T) {
+	t.Setenv("ARLIAI_API_KEY", "")
+	serviceDB, err := database.NewServiceDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	defer serviceDB.Close()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+
+	normalizer := NewClientNormalizer(1, 1, db, serviceDB, nil)
+	normalizer.benchmarkStore.cache["болт м8 din 933"] = &Benchmark{ID: 5, NormalizedName: "болт м8", Category: "крепеж"}
+	benchmarkItem := &database.CatalogItem{Name: "Болт М8 DIN 933", Code: "1"}
+	basicItem := &database.CatalogItem{Name: "Гайка М8", Code: "2"}
+
+	result, err := normalizer.ProcessWithClientBenchmarks([]*database.CatalogItem{benchmarkItem, basicItem})
+	if err != nil {
+		t.Fatalf("ProcessWithClientBenchmarks() error = %v", err)
+	}
+	traces := make(map[*database.CatalogItem]*DecisionTrace)
+	for _, group := range result.Groups {
+		for item, trace := range group.Traces {
+			traces[item] = trace
+		}
+	}
+
+	if trace := traces[benchmarkItem]; trace == nil || len(trace.Steps) != 1 ||
+		trace.Steps[0].Stage != DecisionStageBenchmark || trace.Steps[0].Outcome != DecisionOutcomeApplied {
+		t.Errorf("benchmark item trace = %+v", trace)
+	}
+	trace := traces[basicItem]
+	if trace == nil || len(trace.Steps) < 2 {
+		t.Fatalf("basic item trace = %+v", trace)
+	}
+	if trace.Steps[0].Outcome != DecisionOutcomeNoMatch || trace.Steps[len(trace.Steps)-1].Stage != DecisionStageRules {
+		t.Errorf("basic item trace = %+v", trace.Steps)
+	}
+}
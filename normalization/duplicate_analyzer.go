@@ -65,9 +65,15 @@ type DuplicateAnalyzer struct {
 	// Lemmatizer для лемматизации (более точная, чем стемминг)
 	lemmatizer algorithms.Lemmatizer
 
+	// KazakhStemmer для казахских наименований (выбирается по языку элемента)
+	kazakhStemmer algorithms.Stemmer
+
 	// PrefixIndex для оптимизации поиска дубликатов
 	prefixIndex        *algorithms.PrefixIndex
 	usePrefixFiltering bool // Использовать ли префиксную фильтрацию
+
+	// Словарь терминов: сокращения и синонимы раскрываются, стоп-слова удаляются из токенов
+	termDictionary *TermDictionary
 }
 
 // NewDuplicateAnalyzer создает новый анализатор дубликатов
@@ -86,6 +92,7 @@ func NewDuplicateAnalyzer() *DuplicateAnalyzer {
 		methodSelector:          ms,
 		stemmer:                 algorithms.NewRussianStemmer(),    // Инициализируем stemmer для токенизации
 		lemmatizer:              algorithms.NewRussianLemmatizer(), // Инициализируем lemmatizer
+		kazakhStemmer:           algorithms.NewKazakhStemmer(),     // Stemmer для казахских наименований
 		prefixIndex:             algorithms.NewPrefixIndex(3, 3),   // Префиксный индекс для оптимизации
 		usePrefixFiltering:      true,                              // Использовать префиксную фильтрацию по умолчанию
 	}
@@ -99,6 +106,11 @@ func (da *DuplicateAnalyzer) SetUniversalMatcher(um *UniversalMatcher) {
 	}
 }
 
+// SetTermDictionary устанавливает словарь терминов для токенизации (nil - без словаря)
+func (da *DuplicateAnalyzer) SetTermDictionary(dictionary *TermDictionary) {
+	da.termDictionary = dictionary
+}
+
 // EnableAdvancedMethods включает/выключает использование продвинутых методов
 func (da *DuplicateAnalyzer) EnableAdvancedMethods(enable bool) {
 	da.useAdvancedMethods = enable
@@ -290,6 +302,13 @@ func (da *DuplicateAnalyzer) findSemanticDuplicates(items []DuplicateItem) []Dup
 			return metrics.JaccardIndex(s1, s2)
 		}
 	}
+	if da.termDictionary != nil {
+		// Сокращения и синонимы раскрываются до сравнения
+		compare := computeSimilarity
+		computeSimilarity = func(s1, s2 string) float64 {
+			return compare(da.termDictionary.Apply(s1).Result, da.termDictionary.Apply(s2).Result)
+		}
+	}
 
 	// Сравниваем каждую пару используя выбранный алгоритм
 	processed := make(map[int]bool)
@@ -387,12 +406,19 @@ func (da *DuplicateAnalyzer) findPhoneticDuplicates(items []DuplicateItem) []Dup
 	// Создаем экземпляр фонетического матчера
 	phoneticMatcher := algorithms.NewPhoneticMatcher()
 
+	// Приводим наименования к фонетической форме с учетом языка элемента:
+	// казахская латиница и казахские буквы сводятся к русской кириллице
+	phoneticNames := make([]string, len(items))
+	for idx, item := range items {
+		phoneticNames[idx] = algorithms.PhoneticForm(item.NormalizedName, algorithms.DetectLanguage(item.NormalizedName))
+	}
+
 	// Построим префиксный индекс, если включена фильтрация
 	if da.usePrefixFiltering && da.prefixIndex != nil {
 		da.prefixIndex.Clear()
-		for idx, item := range items {
-			if item.NormalizedName != "" {
-				da.prefixIndex.Add(idx, item.NormalizedName)
+		for idx, name := range phoneticNames {
+			if name != "" {
+				da.prefixIndex.Add(idx, name)
 			}
 		}
 	}
@@ -411,7 +437,7 @@ func (da *DuplicateAnalyzer) findPhoneticDuplicates(items []DuplicateItem) []Dup
 		itemIDs = append(itemIDs, items[i].ID)
 
 		// Используем новый фонетический матчер
-		name1 := items[i].NormalizedName
+		name1 := phoneticNames[i]
 
 		// Получаем кандидатов через префиксную фильтрацию
 		var candidates []int
@@ -431,7 +457,7 @@ func (da *DuplicateAnalyzer) findPhoneticDuplicates(items []DuplicateItem) []Dup
 				continue
 			}
 
-			name2 := items[j].NormalizedName
+			name2 := phoneticNames[j]
 
 			// Используем фонетический матчер для вычисления схожести
 			similarity := phoneticMatcher.Similarity(name1, name2)
@@ -855,10 +881,24 @@ func tokenizeWithOptions(text string, useStopWords bool) []string {
 	return tokens
 }
 
+// tokenizeKazakh токенизирует казахское наименование и приводит слова к основам.
+// Возвращает false, если текст не казахский и должен обрабатываться как русский
+func (da *DuplicateAnalyzer) tokenizeKazakh(text string) ([]string, bool) {
+	if da.kazakhStemmer == nil || !algorithms.DetectLanguage(text).IsKazakhLanguage() {
+		return nil, false
+	}
+	return da.kazakhStemmer.StemTokens(algorithms.KazakhTokenize(text)), true
+}
+
 // tokenizeWithStemming - метод DuplicateAnalyzer для токенизации с поддержкой stemming
+// Для казахских наименований используется казахский стеммер
 func (da *DuplicateAnalyzer) tokenizeWithStemming(text string, useStopWords bool) []string {
-	// Сначала используем стандартную токенизацию
-	tokens := tokenizeWithOptions(text, useStopWords)
+	if tokens, ok := da.tokenizeKazakh(text); ok {
+		return tokens
+	}
+
+	// Сначала используем стандартную токенизацию и словарь терминов
+	tokens := da.termDictionary.NormalizeTokens(tokenizeWithOptions(text, useStopWords))
 
 	// Применяем stemming для улучшения поиска дубликатов
 	// Используем stemmer из структуры (инициализирован в NewDuplicateAnalyzer)
@@ -875,8 +915,13 @@ func (da *DuplicateAnalyzer) tokenizeWithStemming(text string, useStopWords bool
 // Лемматизация более точная, чем стемминг, так как возвращает нормальную форму слова
 // Пример: "маслами" -> "масло", "сливочного" -> "сливочный"
 func (da *DuplicateAnalyzer) tokenizeWithLemmatization(text string, useStopWords bool) []string {
-	// Сначала используем стандартную токенизацию
-	tokens := tokenizeWithOptions(text, useStopWords)
+	// Лемматизатор рассчитан на русский язык, казахские наименования обрабатываются стеммером
+	if tokens, ok := da.tokenizeKazakh(text); ok {
+		return tokens
+	}
+
+	// Сначала используем стандартную токенизацию и словарь терминов
+	tokens := da.termDictionary.NormalizeTokens(tokenizeWithOptions(text, useStopWords))
 
 	// Применяем лемматизацию для улучшения поиска дубликатов
 	// Используем lemmatizer из структуры (инициализирован в NewDuplicateAnalyzer)
@@ -250,3 +250,56 @@ func TestWordBasedSingleWord(t *testing.T) {
 		t.Error("Expected to find word-based group for items with same word")
 	}
 }
+
+// TestKazakhDuplicatesAcrossScripts проверяет поиск дубликатов казахских наименований,
+// записанных кириллицей и латиницей
+func TestKazakhDuplicatesAcrossScripts(t *testing.T) {
+	analyzer := NewDuplicateAnalyzer()
+
+	items := []DuplicateItem{
+		{ID: 1, NormalizedName: "қант ақ", QualityScore: 0.9},
+		{ID: 2, NormalizedName: "qant aq", QualityScore: 0.8},
+		{ID: 3, NormalizedName: "молоток слесарный", QualityScore: 0.8},
+	}
+
+	groups := analyzer.findPhoneticDuplicates(items)
+	if len(groups) != 1 || len(groups[0].ItemIDs) != 2 {
+		t.Fatalf("Expected one phonetic group with 2 items, got %+v", groups)
+	}
+
+	tokens := analyzer.tokenizeWithLemmatization("Сүттер және қант", false)
+	latinTokens := analyzer.tokenizeWithLemmatization("Sútter jäne qant", false)
+	if len(tokens) != 2 || tokens[0] != "сүт" || tokens[1] != "қант" {
+		t.Errorf("tokenizeWithLemmatization = %v, want [сүт қант]", tokens)
+	}
+	if len(latinTokens) != len(tokens) || latinTokens[0] != tokens[0] || latinTokens[1] != tokens[1] {
+		t.Errorf("tokenizeWithLemmatization (latin) = %v, want %v", latinTokens, tokens)
+	}
+}
+
+// TestDuplicatesWithTermDictionary проверяет, что сокращения и синонимы словаря терминов
+// раскрываются при токенизации и сравнении наименований
+func TestDuplicatesWithTermDictionary(t *testing.T) {
+	analyzer := NewDuplicateAnalyzer()
+	analyzer.SetTermDictionary(NewTermDictionary([]TermEntry{
+		{Kind: TermKindAbbreviation, Term: "нерж", Replacement: "нержавеющий"},
+		{Kind: TermKindAbbreviation, Term: "эл.двиг", Replacement: "электродвигатель"},
+		{Kind: TermKindStopWord, Term: "прочее"},
+	}))
+
+	tokens := analyzer.tokenizeWithStemming("Эл.двиг. прочее нерж.", false)
+	expected := analyzer.tokenizeWithStemming("электродвигатель нержавеющий", false)
+	if len(tokens) != len(expected) || tokens[0] != expected[0] || tokens[1] != expected[1] {
+		t.Errorf("tokenizeWithStemming = %v, want %v", tokens, expected)
+	}
+
+	items := []DuplicateItem{
+		{ID: 1, NormalizedName: "лист нерж 2мм", QualityScore: 0.9},
+		{ID: 2, NormalizedName: "лист нержавеющий 2мм", QualityScore: 0.8},
+	}
+	analyzer.EnablePrefixFiltering(false)
+	groups := analyzer.findSemanticDuplicates(items)
+	if len(groups) != 1 || len(groups[0].ItemIDs) != 2 {
+		t.Fatalf("Expected one semantic group with 2 items, got %+v", groups)
+	}
+}
@@ -0,0 +1,217 @@
+package normalization
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SurvivorshipStrategy правило выбора значения атрибута эталонной записи
+type SurvivorshipStrategy string
+
+const (
+	// SurvivorshipMostRecent значение из самой свежей записи
+	SurvivorshipMostRecent SurvivorshipStrategy = "most_recent"
+	// SurvivorshipMostComplete самое полное (длинное) значение
+	SurvivorshipMostComplete SurvivorshipStrategy = "most_complete"
+	// SurvivorshipSourcePriority значение из базы с наивысшим приоритетом
+	SurvivorshipSourcePriority SurvivorshipStrategy = "source_priority"
+	// SurvivorshipMostFrequent значение, встречающееся в большинстве источников
+	SurvivorshipMostFrequent SurvivorshipStrategy = "most_frequent"
+)
+
+// DefaultSurvivorshipAttribute атрибут правила по умолчанию (для атрибутов без своего правила)
+const DefaultSurvivorshipAttribute = "*"
+
+// IsValidSurvivorshipStrategy проверяет название стратегии
+func IsValidSurvivorshipStrategy(strategy SurvivorshipStrategy) bool {
+	switch strategy {
+	case SurvivorshipMostRecent, SurvivorshipMostComplete, SurvivorshipSourcePriority, SurvivorshipMostFrequent:
+		return true
+	}
+	return false
+}
+
+// SurvivorshipRule правило выживания для атрибута
+type SurvivorshipRule struct {
+	Attribute      string               `json:"attribute"`
+	Strategy       SurvivorshipStrategy `json:"strategy"`
+	SourcePriority []int                `json:"source_priority,omitempty"` // ID баз проекта по убыванию приоритета
+}
+
+// GoldenCandidate значение атрибута из конкретной записи catalog_items
+type GoldenCandidate struct {
+	DatabaseID    int       `json:"database_id"`
+	CatalogItemID int       `json:"catalog_item_id"`
+	Reference     string    `json:"reference"`
+	Value         string    `json:"value"`
+	ObservedAt    time.Time `json:"observed_at"`
+}
+
+// GoldenRuleSet набор правил проекта с правилом по умолчанию
+type GoldenRuleSet struct {
+	rules map[string]SurvivorshipRule
+}
+
+// NewGoldenRuleSet создает набор правил. Без правила "*" по умолчанию используется most_complete.
+func NewGoldenRuleSet(rules []SurvivorshipRule) *GoldenRuleSet {
+	set := &GoldenRuleSet{rules: make(map[string]SurvivorshipRule, len(rules))}
+	for _, r := range rules {
+		set.rules[r.Attribute] = r
+	}
+	return set
+}
+
+// RuleFor возвращает правило для атрибута
+func (s *GoldenRuleSet) RuleFor(attribute string) SurvivorshipRule {
+	if r, ok := s.rules[attribute]; ok {
+		return r
+	}
+	if r, ok := s.rules[DefaultSurvivorshipAttribute]; ok {
+		r.Attribute = attribute
+		return r
+	}
+	return SurvivorshipRule{Attribute: attribute, Strategy: SurvivorshipMostComplete}
+}
+
+// SelectSurvivor выбирает значение атрибута по правилу. Пустые значения не участвуют.
+// При равенстве побеждает более свежая запись, затем запись с большим ID.
+func SelectSurvivor(rule SurvivorshipRule, candidates []GoldenCandidate) (GoldenCandidate, bool) {
+	filled := make([]GoldenCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if strings.TrimSpace(c.Value) != "" {
+			filled = append(filled, c)
+		}
+	}
+	if len(filled) == 0 {
+		return GoldenCandidate{}, false
+	}
+
+	newer := func(a, b GoldenCandidate) bool {
+		if !a.ObservedAt.Equal(b.ObservedAt) {
+			return a.ObservedAt.After(b.ObservedAt)
+		}
+		if a.DatabaseID != b.DatabaseID {
+			return a.DatabaseID > b.DatabaseID
+		}
+		return a.CatalogItemID > b.CatalogItemID
+	}
+
+	switch rule.Strategy {
+	case SurvivorshipMostComplete:
+		sort.SliceStable(filled, func(i, j int) bool {
+			li := utf8.RuneCountInString(strings.TrimSpace(filled[i].Value))
+			lj := utf8.RuneCountInString(strings.TrimSpace(filled[j].Value))
+			if li != lj {
+				return li > lj
+			}
+			return newer(filled[i], filled[j])
+		})
+	case SurvivorshipSourcePriority:
+		rank := make(map[int]int, len(rule.SourcePriority))
+		for i, dbID := range rule.SourcePriority {
+			if _, exists := rank[dbID]; !exists {
+				rank[dbID] = i
+			}
+		}
+		rankOf := func(c GoldenCandidate) int {
+			if r, ok := rank[c.DatabaseID]; ok {
+				return r
+			}
+			return len(rule.SourcePriority) // базы вне списка - после приоритетных
+		}
+		sort.SliceStable(filled, func(i, j int) bool {
+			ri, rj := rankOf(filled[i]), rankOf(filled[j])
+			if ri != rj {
+				return ri < rj
+			}
+			return newer(filled[i], filled[j])
+		})
+	case SurvivorshipMostFrequent:
+		counts := make(map[string]int)
+		for _, c := range filled {
+			counts[valueKey(c.Value)]++
+		}
+		sort.SliceStable(filled, func(i, j int) bool {
+			ci, cj := counts[valueKey(filled[i].Value)], counts[valueKey(filled[j].Value)]
+			if ci != cj {
+				return ci > cj
+			}
+			return newer(filled[i], filled[j])
+		})
+	default: // SurvivorshipMostRecent
+		sort.SliceStable(filled, func(i, j int) bool {
+			return newer(filled[i], filled[j])
+		})
+	}
+	return filled[0], true
+}
+
+// valueKey ключ сравнения значений для most_frequent (без учета регистра и пробелов)
+func valueKey(value string) string {
+	return strings.Join(strings.Fields(strings.ToLower(value)), " ")
+}
+
+// GoldenKey ключ группировки номенклатуры разных баз в одну эталонную запись:
+// наименование в нижнем регистре без кавычек и знаков препинания
+func GoldenKey(name string) string {
+	var b strings.Builder
+	runes := []rune(strings.ToLower(name))
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case (r == ',' || r == '.') && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1]):
+			// Десятичные разделители сохраняем, чтобы "1,5" и "15" не совпали
+			b.WriteRune('.')
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// goldenAttributeAliases реквизиты 1С (в нижнем регистре без пробелов и точек),
+// приводимые к каноническим именам атрибутов
+var goldenAttributeAliases = map[string]string{
+	"артикул":                 "article",
+	"единицаизмерения":        "unit",
+	"базоваяединицаизмерения": "unit",
+	"едизм":                   "unit",
+	"наименованиеполное":      "full_name",
+	"полноенаименование":      "full_name",
+	"производитель":           "manufacturer",
+	"изготовитель":            "manufacturer",
+	"видноменклатуры":         "item_kind",
+	"ставкандс":               "vat_rate",
+	"штрихкод":                "barcode",
+	"вес":                     "weight",
+	"странапроисхождения":     "country",
+	"кодтнвэд":                "tnved_code",
+	"комментарий":             "comment",
+	"описание":                "description",
+	"номенклатурнаягруппа":    "group",
+}
+
+// CanonicalAttributeName приводит имя реквизита к каноническому имени атрибута эталона
+func CanonicalAttributeName(name string) string {
+	key := strings.NewReplacer(" ", "", ".", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+	if canonical, ok := goldenAttributeAliases[key]; ok {
+		return canonical
+	}
+	return strings.TrimSpace(name)
+}
+
+// CanonicalAttributes приводит реквизиты элемента (имя -> значение) к каноническим именам
+// атрибутов эталона, пропуская пустые значения
+func CanonicalAttributes(requisites map[string]string) map[string]string {
+	result := make(map[string]string, len(requisites))
+	for name, value := range requisites {
+		if value = strings.TrimSpace(value); value != "" {
+			result[CanonicalAttributeName(name)] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package normalization
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSelectSurvivor(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	candidates := []GoldenCandidate{
+		{DatabaseID: 1, CatalogItemID: 10, Value: "Болт М8", ObservedAt: base},
+		{DatabaseID: 2, CatalogItemID: 20, Value: "Болт М8х40 оцинкованный", ObservedAt: base.Add(-time.Hour)},
+		{DatabaseID: 3, CatalogItemID: 30, Value: "болт  м8", ObservedAt: base.Add(time.Hour)},
+		{DatabaseID: 4, CatalogItemID: 40, Value: "  ", ObservedAt: base.Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name   string
+		rule   SurvivorshipRule
+		wantDB int
+	}{
+		{"most recent skips empty", SurvivorshipRule{Strategy: SurvivorshipMostRecent}, 3},
+		{"most complete", SurvivorshipRule{Strategy: SurvivorshipMostComplete}, 2},
+		{"source priority", SurvivorshipRule{Strategy: SurvivorshipSourcePriority, SourcePriority: []int{4, 1, 2}}, 1},
+		{"most frequent ignores case and spaces", SurvivorshipRule{Strategy: SurvivorshipMostFrequent}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SelectSurvivor(tt.rule, candidates)
+			if !ok {
+				t.Fatal("SelectSurvivor() found no value")
+			}
+			if got.DatabaseID != tt.wantDB {
+				t.Errorf("SelectSurvivor() picked database %d (%q), want %d", got.DatabaseID, got.Value, tt.wantDB)
+			}
+		})
+	}
+
+	if _, ok := SelectSurvivor(SurvivorshipRule{Strategy: SurvivorshipMostRecent}, candidates[3:]); ok {
+		t.Error("SelectSurvivor() with only empty values should find nothing")
+	}
+}
+
+func TestGoldenRuleSet_Default(t *testing.T) {
+	set := NewGoldenRuleSet(nil)
+	if got := set.RuleFor("unit").Strategy; got != SurvivorshipMostComplete {
+		t.Errorf("default strategy = %s, want most_complete", got)
+	}
+	set = NewGoldenRuleSet([]SurvivorshipRule{
+		{Attribute: DefaultSurvivorshipAttribute, Strategy: SurvivorshipMostRecent},
+		{Attribute: "article", Strategy: SurvivorshipMostFrequent},
+	})
+	if got := set.RuleFor("unit"); got.Strategy != SurvivorshipMostRecent || got.Attribute != "unit" {
+		t.Errorf("RuleFor(unit) = %+v, want most_recent from '*'", got)
+	}
+	if got := set.RuleFor("article").Strategy; got != SurvivorshipMostFrequent {
+		t.Errorf("RuleFor(article) = %s, want most_frequent", got)
+	}
+}
+
+func TestCanonicalAttributes(t *testing.T) {
+	attrs := CanonicalAttributes(map[string]string{"Артикул": "A-100", "Единица измерения": "шт", "Вес": " ", "Цвет": "синий"})
+	if attrs["article"] != "A-100" || attrs["unit"] != "шт" || attrs["Цвет"] != "синий" {
+		t.Errorf("CanonicalAttributes() = %v, want article, unit and color", attrs)
+	}
+	if _, ok := attrs["weight"]; ok {
+		t.Error("empty requisite should be skipped")
+	}
+
+	if got := GoldenKey(`Болт "М8", 1,5 мм`); got != "болт м8 1.5 мм" {
+		t.Errorf("GoldenKey() = %q", got)
+	}
+}
@@ -19,7 +19,8 @@ type NameNormalizer struct {
 	articleCodeRegex             *regexp.Regexp         // Артикулы/коды в начале строки (например, "wbc00z0002")
 	trailingSpecialCharsRegex    *regexp.Regexp         // Специальные символы в конце строки
 	lemmatizer                   algorithms.Lemmatizer  // Лемматизатор для нормализации слов
-	ner                          *algorithms.RussianNER // NER для извлечения сущностей
+	kazakhStemmer                *algorithms.KazakhStemmer // Стеммер для казахских наименований
+	ner                          algorithms.NERTagger   // NER для извлечения сущностей
 }
 
 // NewNameNormalizer создает новый нормализатор имен
@@ -39,22 +40,40 @@ func NewNameNormalizer() *NameNormalizer {
 		// Паттерн: буквы + цифры + буквы + цифры (смешанный формат артикулов)
 		articleCodeRegex: regexp.MustCompile(`^[a-zа-я]{2,}\d+[a-zа-я]*\d+\s*`),
 		// Специальные символы в конце строки (*, -, ., и т.д.)
-		trailingSpecialCharsRegex: regexp.MustCompile(`[^\w\sа-яА-Я]+$`),
+		trailingSpecialCharsRegex: regexp.MustCompile(`[^\w\sа-яА-ЯёЁәғқңөұүһіӘҒҚҢӨҰҮҺІ]+$`),
 		// Лемматизатор для нормализации слов
 		lemmatizer: algorithms.NewRussianLemmatizer(),
+		// Стеммер для казахских наименований (лемматизатор рассчитан на русский язык)
+		kazakhStemmer: algorithms.NewKazakhStemmer(),
 		// NER для извлечения сущностей
 		ner: algorithms.NewRussianNER(),
 	}
 }
 
+// SetNERTagger заменяет NER (например, на обученную модель проекта из algorithms.LoadNERForProject)
+func (n *NameNormalizer) SetNERTagger(tagger algorithms.NERTagger) {
+	if tagger == nil {
+		tagger = algorithms.NewRussianNER()
+	}
+	n.ner = tagger
+}
+
+// DetectLanguage определяет язык наименования (русский, казахский на кириллице или латинице)
+func (n *NameNormalizer) DetectLanguage(name string) algorithms.Language {
+	return algorithms.DetectLanguage(name)
+}
+
 // NormalizeName нормализует наименование товара
+// Наименования на казахской латинице переводятся на кириллицу, чтобы совпадать
+// с теми же наименованиями, записанными кириллицей
 func (n *NameNormalizer) NormalizeName(name string) string {
 	if name == "" {
 		return ""
 	}
 
-	// 1. Приводим к нижнему регистру
-	normalized := strings.ToLower(name)
+	// 1. Казахская латиница -> кириллица (до нижнего регистра: слова из заглавных букв
+	// не переводятся), затем приводим к нижнему регистру
+	normalized := strings.ToLower(algorithms.ToKazakhCyrillic(name, algorithms.DetectLanguage(name)))
 
 	// 2. Удаляем артикулы/коды в начале строки (например, "wbc00z0002")
 	normalized = n.articleCodeRegex.ReplaceAllString(normalized, "")
@@ -89,6 +108,7 @@ func (n *NameNormalizer) NormalizeName(name string) string {
 // NormalizeNameWithLemmatization нормализует наименование товара с применением лемматизации
 // Лемматизация приводит слова к нормальной форме (например, "маслами" -> "масло")
 // Это более точная нормализация, чем просто приведение к нижнему регистру
+// Для казахских наименований вместо лемматизатора используется казахский стеммер
 func (n *NameNormalizer) NormalizeNameWithLemmatization(name string) string {
 	// Сначала применяем стандартную нормализацию
 	normalized := n.NormalizeName(name)
@@ -97,6 +117,10 @@ func (n *NameNormalizer) NormalizeNameWithLemmatization(name string) string {
 		return ""
 	}
 
+	if algorithms.DetectLanguage(normalized).IsKazakhLanguage() && n.kazakhStemmer != nil {
+		return n.kazakhStemmer.StemText(normalized)
+	}
+
 	// Затем применяем лемматизацию к каждому слову
 	if n.lemmatizer != nil {
 		lemmatized := n.lemmatizer.LemmatizeText(normalized)
@@ -714,14 +738,23 @@ func (n *NameNormalizer) ExtractAttributesWithNER(name string) (string, []*datab
 		return "", nil
 	}
 
-	// Используем NER для извлечения сущностей
-	entities := n.ner.ExtractEntities(name)
-
-	var attributes []*database.ItemAttribute
+	attributes := n.NERAttributes(name)
 	normalized := strings.ToLower(name)
+	for _, attr := range attributes {
+		// Удаляем найденную сущность из нормализованного текста
+		normalized = strings.Replace(normalized, strings.ToLower(attr.OriginalText), "", 1)
+	}
+
+	// Применяем стандартную нормализацию к оставшемуся тексту
+	normalized = n.NormalizeName(normalized)
 
-	// Преобразуем NER сущности в ItemAttribute
-	for _, entity := range entities {
+	return normalized, attributes
+}
+
+// NERAttributes извлекает атрибуты из сущностей NER (словарного или обученной модели проекта)
+func (n *NameNormalizer) NERAttributes(name string) []*database.ItemAttribute {
+	var attributes []*database.ItemAttribute
+	for _, entity := range n.ner.ExtractEntities(name) {
 		attrType := "text_value"
 		attrName := string(entity.Type)
 
@@ -756,25 +789,16 @@ func (n *NameNormalizer) ExtractAttributesWithNER(name string) (string, []*datab
 			attrName = "code"
 		}
 
-		attr := &database.ItemAttribute{
+		attributes = append(attributes, &database.ItemAttribute{
 			AttributeType:  attrType,
 			AttributeName:  attrName,
 			AttributeValue: entity.Value,
 			Unit:           entity.Unit,
 			OriginalText:   entity.Text,
 			Confidence:     entity.Confidence,
-		}
-
-		attributes = append(attributes, attr)
-
-		// Удаляем найденную сущность из нормализованного текста
-		normalized = strings.Replace(normalized, strings.ToLower(entity.Text), "", 1)
+		})
 	}
-
-	// Применяем стандартную нормализацию к оставшемуся тексту
-	normalized = n.NormalizeName(normalized)
-
-	return normalized, attributes
+	return attributes
 }
 
 // ExtractAttributesWithPositional извлекает атрибуты используя позиционную схему
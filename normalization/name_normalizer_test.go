@@ -1,6 +1,7 @@
 package normalization
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -193,3 +194,33 @@ func TestSpecialCharacters(t *testing.T) {
 		})
 	}
 }
+
+// TestNormalizeName_Kazakh проверяет нормализацию казахских наименований на кириллице и латинице
+func TestNormalizeName_Kazakh(t *testing.T) {
+	normalizer := NewNameNormalizer()
+
+	cyrillic := normalizer.NormalizeName("Сүт пастерленген 1 л")
+	latin := normalizer.NormalizeName("Sút pasterlengen 1 l")
+	if cyrillic != "сүт пастерленген" {
+		t.Errorf("NormalizeName(cyrillic) = %q, want %q", cyrillic, "сүт пастерленген")
+	}
+	if latin != cyrillic {
+		t.Errorf("NormalizeName(latin) = %q, want %q", latin, cyrillic)
+	}
+
+	// Казахская буква в конце строки не должна удаляться как спецсимвол
+	if got := normalizer.NormalizeName("Қант ақ"); got != "қант ақ" {
+		t.Errorf("NormalizeName = %q, want %q", got, "қант ақ")
+	}
+
+	if got := normalizer.NormalizeNameWithLemmatization("Сүттердің өнімдері"); got != "сүт өнім" {
+		t.Errorf("NormalizeNameWithLemmatization = %q, want %q", got, "сүт өнім")
+	}
+
+	// Английские, турецкие и испанские бренды не переводятся на кириллицу
+	for _, name := range []string{"Cisco QSFP-40G-SR4 transceiver", "Iqos heets", "Çelik boru", "Şişecam bardak", "Señorío de Sarría"} {
+		if got := normalizer.NormalizeName(name); strings.ContainsAny(got, "абвгдеёжзийклмнопрстуфхцчшщыэюяіқ") {
+			t.Errorf("NormalizeName(%q) = %q, transliterated to cyrillic", name, got)
+		}
+	}
+}
@@ -0,0 +1,410 @@
+package normalization
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"httpserver/database"
+	"httpserver/normalization/algorithms"
+)
+
+// NameTemplateScope область применения шаблона имени
+type NameTemplateScope string
+
+const (
+	NameTemplateScopeKpved    NameTemplateScope = "kpved"    // Префикс кода КПВЭД (раздел, группа, класс)
+	NameTemplateScopeOkpd2    NameTemplateScope = "okpd2"    // Префикс кода ОКПД2
+	NameTemplateScopeCategory NameTemplateScope = "category" // Внутренняя категория нормализатора (без учета регистра)
+)
+
+// Стандартные слоты шаблона. Любой другой слот заполняется атрибутом с таким attribute_name
+// (например, {diameter}, {weight}, {article}) или сущностью NER того же типа.
+const (
+	NameSlotName       = "name"       // Нормализованное имя целиком
+	NameSlotType       = "type"       // Вид изделия: первое слово нормализованного имени, не являющееся атрибутом
+	NameSlotKind       = "kind"       // Исполнение (сущность NER TYPE: многожильный, одножильный...)
+	NameSlotMaterial   = "material"   // Материал
+	NameSlotColor      = "color"      // Цвет
+	NameSlotDimensions = "dimensions" // Размеры (100x100, 20х30 мм)
+	NameSlotStandard   = "standard"   // Ссылка на стандарт (ГОСТ, ТУ, DIN...)
+)
+
+// maxNameTemplateLength ограничение длины шаблона
+const maxNameTemplateLength = 500
+
+var (
+	nameTemplateSlotRegex = regexp.MustCompile(`\{([^{}]*)\}`)
+	nameSlotNameRegex     = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// Ссылки на стандарты: ГОСТ 3262-75, ГОСТ Р 52857.1-2007, ТУ 14-3-1128-2000, DIN 933, ISO 4017
+	standardRefRegex  = regexp.MustCompile(`(?i)(?:^|[^\p{L}])(ГОСТ(?:\s*Р)?|ОСТ|ТУ|СТО|СНиП|DIN|ISO|EN)\s*(\d[\d.\-–/]*\d|\d)`)
+	emptyBracketRegex = regexp.MustCompile(`\(\s*\)|\[\s*\]`)
+)
+
+// NameTemplate шаблон нормализованного имени для раздела классификатора или категории
+type NameTemplate struct {
+	ID       int               `json:"id"`
+	Name     string            `json:"name"`
+	Scope    NameTemplateScope `json:"scope"`
+	Match    string            `json:"match"`   // Префикс кода или название категории
+	Pattern  string            `json:"pattern"` // Например: "{type} {material} {dimensions} {standard}"
+	Priority int               `json:"priority"`
+	Enabled  bool              `json:"enabled"`
+}
+
+// NameTemplateInput данные элемента для заполнения слотов
+type NameTemplateInput struct {
+	SourceName string
+	BaseName   string // Имя после нормализации (без шаблона)
+	Category   string
+	KpvedCode  string
+	Okpd2Code  string
+	Attributes []*database.ItemAttribute
+	Entities   []algorithms.NEREntity
+}
+
+// NameTemplateRender результат применения шаблона
+type NameTemplateRender struct {
+	TemplateID   int               `json:"template_id"`
+	Name         string            `json:"name"`
+	Slots        map[string]string `json:"slots"`
+	MissingSlots []string          `json:"missing_slots,omitempty"`
+}
+
+// IsValidNameTemplateScope проверяет область применения шаблона
+func IsValidNameTemplateScope(scope NameTemplateScope) bool {
+	switch scope {
+	case NameTemplateScopeKpved, NameTemplateScopeOkpd2, NameTemplateScopeCategory:
+		return true
+	}
+	return false
+}
+
+// ParseNameTemplatePattern проверяет шаблон и возвращает его слоты в порядке появления
+func ParseNameTemplatePattern(pattern string) ([]string, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return nil, fmt.Errorf("шаблон пуст")
+	}
+	if len([]rune(pattern)) > maxNameTemplateLength {
+		return nil, fmt.Errorf("шаблон длиннее %d символов", maxNameTemplateLength)
+	}
+
+	// Фигурные скобки вне слотов означают незакрытый или вложенный слот
+	rest := nameTemplateSlotRegex.ReplaceAllString(pattern, "")
+	if strings.ContainsAny(rest, "{}") {
+		return nil, fmt.Errorf("незакрытая или вложенная фигурная скобка в шаблоне")
+	}
+
+	var slots []string
+	for _, match := range nameTemplateSlotRegex.FindAllStringSubmatch(pattern, -1) {
+		slot := strings.TrimSpace(match[1])
+		if !nameSlotNameRegex.MatchString(slot) {
+			return nil, fmt.Errorf("некорректное имя слота %q: допустимы латинские строчные буквы, цифры и _", match[1])
+		}
+		slots = append(slots, slot)
+	}
+	if len(slots) == 0 {
+		return nil, fmt.Errorf("шаблон не содержит слотов")
+	}
+	return slots, nil
+}
+
+// matchSpecificity возвращает специфичность совпадения шаблона с элементом (0 - не подходит)
+func (t *NameTemplate) matchSpecificity(input *NameTemplateInput) int {
+	match := strings.TrimSpace(t.Match)
+	if match == "" {
+		return 0
+	}
+	switch t.Scope {
+	case NameTemplateScopeKpved:
+		return codePrefixSpecificity(input.KpvedCode, match)
+	case NameTemplateScopeOkpd2:
+		return codePrefixSpecificity(input.Okpd2Code, match)
+	case NameTemplateScopeCategory:
+		if input.Category != "" && strings.EqualFold(strings.TrimSpace(input.Category), match) {
+			return 1
+		}
+	}
+	return 0
+}
+
+// codePrefixSpecificity проверяет, что код входит в раздел prefix (по границе уровня), и возвращает длину префикса
+func codePrefixSpecificity(code, prefix string) int {
+	code = strings.TrimSpace(code)
+	prefix = strings.TrimSuffix(prefix, ".")
+	if code == "" || prefix == "" {
+		return 0
+	}
+	if code == prefix || strings.HasPrefix(code, prefix+".") {
+		return len(prefix) + 1
+	}
+	return 0
+}
+
+// SelectNameTemplate выбирает шаблон для элемента: выше приоритет, затем более узкий раздел
+// классификатора, затем категория. Отключенные шаблоны не рассматриваются.
+func SelectNameTemplate(templates []*NameTemplate, input *NameTemplateInput) *NameTemplate {
+	var best *NameTemplate
+	bestSpecificity := 0
+	for _, template := range templates {
+		if template == nil || !template.Enabled {
+			continue
+		}
+		specificity := template.matchSpecificity(input)
+		if specificity == 0 {
+			continue
+		}
+		if best == nil ||
+			template.Priority > best.Priority ||
+			(template.Priority == best.Priority && specificity > bestSpecificity) ||
+			(template.Priority == best.Priority && specificity == bestSpecificity && template.ID < best.ID) {
+			best = template
+			bestSpecificity = specificity
+		}
+	}
+	return best
+}
+
+// RenderNameTemplate заполняет слоты шаблона данными элемента.
+// Незаполненные слоты удаляются из имени и перечисляются в MissingSlots.
+func RenderNameTemplate(template *NameTemplate, input *NameTemplateInput) (*NameTemplateRender, error) {
+	if _, err := ParseNameTemplatePattern(template.Pattern); err != nil {
+		return nil, err
+	}
+
+	render := &NameTemplateRender{TemplateID: template.ID, Slots: make(map[string]string)}
+	missing := make(map[string]bool)
+	name := nameTemplateSlotRegex.ReplaceAllStringFunc(template.Pattern, func(token string) string {
+		slot := strings.TrimSpace(token[1 : len(token)-1])
+		value := resolveNameSlot(slot, input)
+		if value == "" {
+			if !missing[slot] {
+				missing[slot] = true
+				render.MissingSlots = append(render.MissingSlots, slot)
+			}
+			return ""
+		}
+		render.Slots[slot] = value
+		return value
+	})
+
+	name = emptyBracketRegex.ReplaceAllString(name, "")
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.Trim(name, " ,;-–")
+	render.Name = name
+	return render, nil
+}
+
+// resolveNameSlot возвращает значение слота: атрибуты элемента, затем сущности NER, затем разбор имени
+func resolveNameSlot(slot string, input *NameTemplateInput) string {
+	switch slot {
+	case NameSlotName:
+		return strings.TrimSpace(input.BaseName)
+	case NameSlotType:
+		if value := attributeValue(input.Attributes, "product_type"); value != "" {
+			return value
+		}
+		return productTypeWord(input)
+	case NameSlotKind:
+		if value := attributeValue(input.Attributes, "type"); value != "" {
+			return value
+		}
+		return entityValue(input.Entities, algorithms.NEREntityTypeType)
+	case NameSlotMaterial:
+		if value := attributeValue(input.Attributes, "material"); value != "" {
+			return value
+		}
+		return entityValue(input.Entities, algorithms.NEREntityTypeMaterial)
+	case NameSlotColor:
+		if value := attributeValue(input.Attributes, "color"); value != "" {
+			return value
+		}
+		return entityValue(input.Entities, algorithms.NEREntityTypeColor)
+	case NameSlotDimensions:
+		return dimensionsValue(input)
+	case NameSlotStandard:
+		if value := attributeValue(input.Attributes, "standard"); value != "" {
+			return value
+		}
+		return standardReference(input.SourceName)
+	}
+
+	if value := attributeValue(input.Attributes, slot); value != "" {
+		return value
+	}
+	return entityValue(input.Entities, algorithms.NEREntityType(strings.ToUpper(slot)))
+}
+
+// attributeValue возвращает значение первого атрибута с указанным именем (с единицей измерения)
+func attributeValue(attributes []*database.ItemAttribute, name string) string {
+	for _, attr := range attributes {
+		if attr == nil || attr.AttributeName != name || strings.TrimSpace(attr.AttributeValue) == "" {
+			continue
+		}
+		return joinValueUnit(attr.AttributeValue, attr.Unit)
+	}
+	return ""
+}
+
+// entityValue возвращает нормализованное значение первой сущности NER указанного типа
+func entityValue(entities []algorithms.NEREntity, entityType algorithms.NEREntityType) string {
+	for _, entity := range entities {
+		if entity.Type != entityType {
+			continue
+		}
+		value := entity.Value
+		if value == "" {
+			value = entity.Text
+		}
+		if strings.TrimSpace(value) != "" {
+			return joinValueUnit(value, entity.Unit)
+		}
+	}
+	return ""
+}
+
+// dimensionsValue собирает размеры из атрибутов типа dimension (по исходному фрагменту) или сущностей NER
+func dimensionsValue(input *NameTemplateInput) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, attr := range input.Attributes {
+		if attr == nil || attr.AttributeType != "dimension" {
+			continue
+		}
+		text := strings.TrimSpace(attr.OriginalText)
+		if text == "" {
+			text = joinValueUnit(attr.AttributeValue, attr.Unit)
+		}
+		if text != "" && !seen[text] {
+			seen[text] = true
+			parts = append(parts, text)
+		}
+	}
+	if len(parts) > 0 {
+		return strings.Join(parts, " ")
+	}
+	if value := entityValue(input.Entities, algorithms.NEREntityTypeSize); value != "" {
+		return value
+	}
+	return entityValue(input.Entities, algorithms.NEREntityTypeDimension)
+}
+
+// standardReference находит ссылки на стандарты в исходном имени
+func standardReference(sourceName string) string {
+	return strings.Join(StandardReferences(sourceName), ", ")
+}
+
+// StandardReferences возвращает ссылки на стандарты (ГОСТ, ТУ, DIN...) в тексте в каноническом виде: "ГОСТ 10704-91"
+func StandardReferences(text string) []string {
+	var refs []string
+	for _, match := range standardRefRegex.FindAllStringSubmatch(text, -1) {
+		prefix := strings.ToUpper(strings.Join(strings.Fields(match[1]), " "))
+		if prefix == "СНИП" {
+			prefix = "СНиП"
+		}
+		refs = append(refs, prefix+" "+match[2])
+	}
+	return refs
+}
+
+// productTypeWord возвращает первое слово нормализованного имени, которое не является
+// найденной сущностью (материал, цвет, размер) и не содержит цифр
+func productTypeWord(input *NameTemplateInput) string {
+	entityWords := make(map[string]bool)
+	for _, entity := range input.Entities {
+		for _, word := range strings.Fields(strings.ToLower(entity.Text)) {
+			entityWords[word] = true
+		}
+	}
+	for _, word := range strings.Fields(input.BaseName) {
+		word = strings.Trim(word, ".,;:()[]\"'")
+		if word == "" || entityWords[strings.ToLower(word)] || strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			continue
+		}
+		return word
+	}
+	return ""
+}
+
+func joinValueUnit(value, unit string) string {
+	value = strings.TrimSpace(value)
+	unit = strings.TrimSpace(unit)
+	if unit == "" || strings.HasSuffix(value, unit) {
+		return value
+	}
+	return value + " " + unit
+}
+
+// NameTemplateSet набор шаблонов имен, применяемый конвейером нормализации
+type NameTemplateSet struct {
+	templates []*NameTemplate
+	ner       algorithms.NERTagger
+}
+
+// NewNameTemplateSet создает набор шаблонов; отключенные шаблоны отбрасываются
+func NewNameTemplateSet(templates []*NameTemplate) *NameTemplateSet {
+	enabled := make([]*NameTemplate, 0, len(templates))
+	for _, template := range templates {
+		if template != nil && template.Enabled {
+			enabled = append(enabled, template)
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool { return enabled[i].ID < enabled[j].ID })
+	return &NameTemplateSet{templates: enabled, ner: algorithms.NewRussianNER()}
+}
+
+// Len возвращает число активных шаблонов; безопасно вызывается на nil
+func (s *NameTemplateSet) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.templates)
+}
+
+// Apply выбирает шаблон для элемента и заполняет его. ok = false, если подходящего шаблона нет
+// или имя по шаблону получилось пустым.
+func (s *NameTemplateSet) Apply(input *NameTemplateInput) (*NameTemplateRender, bool) {
+	if s.Len() == 0 {
+		return nil, false
+	}
+	template := SelectNameTemplate(s.templates, input)
+	if template == nil {
+		return nil, false
+	}
+	render, err := s.Render(template, input)
+	if err != nil || render.Name == "" {
+		return render, false
+	}
+	return render, true
+}
+
+// Render заполняет указанный шаблон без выбора по разделу (предпросмотр шаблона).
+// Сущности NER извлекаются из исходного имени, если не переданы.
+func (s *NameTemplateSet) Render(template *NameTemplate, input *NameTemplateInput) (*NameTemplateRender, error) {
+	if input.Entities == nil && s != nil && s.ner != nil {
+		input.Entities = s.ner.ExtractEntities(input.SourceName)
+	}
+	return RenderNameTemplate(template, input)
+}
+
+// TraceNameTemplate формирует этап записи решений по результату шаблона имени
+func TraceNameTemplate(render *NameTemplateRender, applied bool) DecisionStep {
+	step := DecisionStep{
+		Stage:   DecisionStageTemplate,
+		Outcome: DecisionOutcomeApplied,
+		Result:  render.Name,
+		Details: map[string]interface{}{
+			"template_id": render.TemplateID,
+			"slots":       render.Slots,
+		},
+	}
+	if len(render.MissingSlots) > 0 {
+		step.Details["missing_slots"] = render.MissingSlots
+	}
+	if !applied {
+		step.Outcome = DecisionOutcomeRejected
+	}
+	return step
+}
@@ -0,0 +1,91 @@
+package normalization
+
+import (
+	"reflect"
+	"testing"
+
+	"httpserver/database"
+)
+
+func TestParseNameTemplatePattern(t *testing.T) {
+	slots, err := ParseNameTemplatePattern("{type} {material} {dimensions} {standard}")
+	if err != nil {
+		t.Fatalf("ParseNameTemplatePattern() error = %v", err)
+	}
+	if want := []string{"type", "material", "dimensions", "standard"}; !reflect.DeepEqual(slots, want) {
+		t.Errorf("slots = %v, want %v", slots, want)
+	}
+
+	for _, pattern := range []string{"", "труба", "{type", "{type} {Материал}", "{{type}}"} {
+		if _, err := ParseNameTemplatePattern(pattern); err == nil {
+			t.Errorf("ParseNameTemplatePattern(%q) expected error", pattern)
+		}
+	}
+}
+
+func TestSelectNameTemplate(t *testing.T) {
+	templates := []*NameTemplate{
+		{ID: 1, Scope: NameTemplateScopeCategory, Match: "Трубы", Pattern: "{name}", Enabled: true},
+		{ID: 2, Scope: NameTemplateScopeKpved, Match: "24", Pattern: "{type}", Enabled: true},
+		{ID: 3, Scope: NameTemplateScopeKpved, Match: "24.20", Pattern: "{type} {dimensions}", Enabled: true},
+		{ID: 4, Scope: NameTemplateScopeKpved, Match: "24.20.13", Pattern: "{type}", Enabled: false},
+	}
+
+	cases := []struct {
+		input NameTemplateInput
+		want  int
+	}{
+		{NameTemplateInput{KpvedCode: "24.20.13", Category: "трубы"}, 3},
+		{NameTemplateInput{KpvedCode: "24.10.11"}, 2},
+		{NameTemplateInput{KpvedCode: "244.1"}, 0},
+		{NameTemplateInput{Category: "ТРУБЫ"}, 1},
+	}
+	for _, tc := range cases {
+		got := SelectNameTemplate(templates, &tc.input)
+		gotID := 0
+		if got != nil {
+			gotID = got.ID
+		}
+		if gotID != tc.want {
+			t.Errorf("SelectNameTemplate(%+v) = %d, want %d", tc.input, gotID, tc.want)
+		}
+	}
+
+	templates[0].Priority = 10
+	if got := SelectNameTemplate(templates, &NameTemplateInput{KpvedCode: "24.20.13", Category: "трубы"}); got == nil || got.ID != 1 {
+		t.Errorf("priority must win over specificity, got %+v", got)
+	}
+}
+
+func TestNameTemplateSet_ApplyFillsSlotsAndReportsMissing(t *testing.T) {
+	set := NewNameTemplateSet([]*NameTemplate{
+		{ID: 7, Scope: NameTemplateScopeKpved, Match: "24.20", Pattern: "{type} {material} {dimensions} {standard} ({color})", Enabled: true},
+	})
+
+	input := &NameTemplateInput{
+		SourceName: "Труба стальная 57х3,5 ГОСТ 10704-91",
+		BaseName:   "труба стальная",
+		KpvedCode:  "24.20.13",
+		Attributes: []*database.ItemAttribute{
+			{AttributeType: "dimension", AttributeName: "width", AttributeValue: "57", OriginalText: "57х3,5"},
+			{AttributeType: "dimension", AttributeName: "height", AttributeValue: "3,5", OriginalText: "57х3,5"},
+		},
+	}
+	render, ok := set.Apply(input)
+	if !ok {
+		t.Fatalf("Apply() ok = false, render = %+v", render)
+	}
+	if want := "труба сталь 57х3,5 ГОСТ 10704-91"; render.Name != want {
+		t.Errorf("Name = %q, want %q", render.Name, want)
+	}
+	if !reflect.DeepEqual(render.MissingSlots, []string{"color"}) {
+		t.Errorf("MissingSlots = %v", render.MissingSlots)
+	}
+	if render.TemplateID != 7 {
+		t.Errorf("TemplateID = %d", render.TemplateID)
+	}
+
+	if _, ok := set.Apply(&NameTemplateInput{SourceName: "Болт М8", BaseName: "болт", KpvedCode: "25.94.11"}); ok {
+		t.Error("Apply() must not match items outside the template section")
+	}
+}
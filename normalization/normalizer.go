@@ -7,9 +7,11 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"httpserver/database"
+	"httpserver/nomenclature"
 )
 
 // AIConfig конфигурация для AI обработки
@@ -43,6 +45,7 @@ type Normalizer struct {
 	nameNormalizer         *NameNormalizer
 	aiNormalizer           *AINormalizer
 	hierarchicalClassifier *HierarchicalClassifier
+	decisionEngine         *DecisionEngine // Проверка и финальный выбор кода КПВЭД
 	events                 chan<- string
 	useAI                  bool
 	aiConfig               *AIConfig
@@ -66,6 +69,17 @@ type Normalizer struct {
 	benchmarkFinder BenchmarkFinder
 	// Движок валидации (для проверки элементов перед обработкой)
 	validationEngine *ValidationEngine
+	// Детектор паттернов (найденные паттерны записываются в запись решений, имя не меняется)
+	patternDetector *PatternDetector
+	// Шаблоны нормализованных имен по разделам классификатора и категориям
+	nameTemplates   *NameTemplateSet
+	nameTemplatesMu sync.RWMutex
+	// Источник исправления опечаток перед нормализацией имени (словарь проекта и эталонов)
+	spellCorrector   func() *SpellCorrector
+	spellCorrectorMu sync.RWMutex
+	// Словарь сокращений, синонимов и стоп-слов (общий или проекта), применяется после исправления опечаток
+	termDictionary   *TermDictionary
+	termDictionaryMu sync.RWMutex
 }
 
 // groupKey ключ для группировки записей
@@ -84,6 +98,8 @@ type groupValue struct {
 	kpvedName       string
 	kpvedConfidence float64
 	attributes      map[string][]*database.ItemAttribute // code -> attributes
+	trace           *DecisionTrace                       // Этапы, общие для группы (КПВЭД)
+	itemTraces      map[*database.CatalogItem]*DecisionTrace
 }
 
 // NewNormalizer создает новый нормализатор
@@ -94,13 +110,14 @@ func NewNormalizer(db *database.DB, events chan<- string, aiConfig *AIConfig) *N
 // NewNormalizerWithStopCheck создает новый нормализатор с функцией проверки остановки
 func NewNormalizerWithStopCheck(db *database.DB, events chan<- string, aiConfig *AIConfig, stopCheck func() bool, getAPIKey func() string) *Normalizer {
 	normalizer := &Normalizer{
-		db:             db,
-		categorizer:    NewCategorizer(),
-		nameNormalizer: NewNameNormalizer(),
-		events:         events,
-		useAI:          aiConfig != nil && aiConfig.Enabled,
-		aiConfig:       aiConfig,
-		stopCheck:      stopCheck,
+		db:              db,
+		categorizer:     NewCategorizer(),
+		nameNormalizer:  NewNameNormalizer(),
+		patternDetector: NewPatternDetector(),
+		events:          events,
+		useAI:           aiConfig != nil && aiConfig.Enabled,
+		aiConfig:        aiConfig,
+		stopCheck:       stopCheck,
 		// Дефолтные значения
 		sourceTable:     "catalog_items",
 		referenceColumn: "reference",
@@ -153,6 +170,13 @@ func NewNormalizerWithStopCheck(db *database.DB, events chan<- string, aiConfig
 				normalizer.hierarchicalClassifier = hierarchicalClassifier
 				normalizer.sendEvent("✓ Иерархический КПВЭД классификатор включен")
 				log.Println("Иерархический КПВЭД классификатор включен")
+
+				decisionEngine, err := NewDecisionEngine(db)
+				if err != nil {
+					log.Printf("Warning: Failed to initialize KPVED decision engine: %v", err)
+				} else {
+					normalizer.decisionEngine = decisionEngine
+				}
 			}
 		} else {
 			normalizer.sendEvent("⚠ API ключ не установлен, AI отключен. Установите API ключ в разделе 'Воркеры' или через переменную окружения ARLIAI_API_KEY")
@@ -179,12 +203,31 @@ func (n *Normalizer) SetSourceConfig(tableName, referenceCol, codeCol, nameCol s
 		tableName, referenceCol, codeCol, nameCol)
 }
 
+// SetCacheNamespace задает пространство имен AI кэша (изоляция результатов клиентов)
+func (n *Normalizer) SetCacheNamespace(namespace string) {
+	if n.aiNormalizer != nil {
+		n.aiNormalizer.SetCacheNamespace(namespace)
+	}
+}
+
+// SetUsageTags задает атрибуты учета затрат на вызовы AI (клиент, проект, сессия)
+func (n *Normalizer) SetUsageTags(tags nomenclature.UsageTags) {
+	if n.aiNormalizer != nil {
+		n.aiNormalizer.SetUsageTags(tags)
+	}
+}
+
 // SetHierarchicalClassifier устанавливает иерархический классификатор КПВЭД
 func (n *Normalizer) SetHierarchicalClassifier(classifier *HierarchicalClassifier) {
 	n.hierarchicalClassifier = classifier
 	log.Println("Иерархический КПВЭД классификатор установлен")
 }
 
+// SetDecisionEngine устанавливает движок финального выбора кода КПВЭД
+func (n *Normalizer) SetDecisionEngine(engine *DecisionEngine) {
+	n.decisionEngine = engine
+}
+
 // SetBenchmarkFinder устанавливает поисковик эталонов
 func (n *Normalizer) SetBenchmarkFinder(finder BenchmarkFinder) {
 	n.benchmarkFinder = finder
@@ -199,6 +242,82 @@ func (n *Normalizer) SetValidationEngine(engine *ValidationEngine) {
 	}
 }
 
+// SetNameTemplates устанавливает шаблоны имен; применяются со следующего запуска нормализации
+func (n *Normalizer) SetNameTemplates(templates *NameTemplateSet) {
+	n.nameTemplatesMu.Lock()
+	defer n.nameTemplatesMu.Unlock()
+	n.nameTemplates = templates
+}
+
+// SetSpellCorrector устанавливает исправление опечаток, применяемое до нормализации имени
+func (n *Normalizer) SetSpellCorrector(corrector *SpellCorrector) {
+	if corrector == nil {
+		n.SetSpellCorrectorSource(nil)
+		return
+	}
+	n.SetSpellCorrectorSource(func() *SpellCorrector { return corrector })
+}
+
+// SetSpellCorrectorSource устанавливает источник словаря исправления опечаток. Словарь
+// запрашивается при обработке имени, поэтому перестроенный словарь подхватывается без
+// повторной установки
+func (n *Normalizer) SetSpellCorrectorSource(source func() *SpellCorrector) {
+	n.spellCorrectorMu.Lock()
+	defer n.spellCorrectorMu.Unlock()
+	n.spellCorrector = source
+}
+
+// correctSpelling исправляет опечатки в наименовании и добавляет этап в запись решений
+func (n *Normalizer) correctSpelling(name string, trace *DecisionTrace) string {
+	n.spellCorrectorMu.RLock()
+	source := n.spellCorrector
+	n.spellCorrectorMu.RUnlock()
+	if source == nil {
+		return name
+	}
+	corrector := source()
+	if corrector == nil {
+		return name
+	}
+	result := corrector.Correct(name)
+	trace.Add(TraceSpelling(result))
+	return result.Corrected
+}
+
+// SetTermDictionary устанавливает словарь терминов, применяемый до нормализации имени
+// и при поиске дубликатов
+func (n *Normalizer) SetTermDictionary(dictionary *TermDictionary) {
+	n.termDictionaryMu.Lock()
+	defer n.termDictionaryMu.Unlock()
+	n.termDictionary = dictionary
+}
+
+// getTermDictionary возвращает установленный словарь терминов (nil, если не установлен)
+func (n *Normalizer) getTermDictionary() *TermDictionary {
+	n.termDictionaryMu.RLock()
+	defer n.termDictionaryMu.RUnlock()
+	return n.termDictionary
+}
+
+// applyTermDictionary раскрывает сокращения, заменяет синонимы и удаляет стоп-слова
+// и добавляет этап в запись решений
+func (n *Normalizer) applyTermDictionary(name string, trace *DecisionTrace) string {
+	dictionary := n.getTermDictionary()
+	if dictionary == nil {
+		return name
+	}
+	result := dictionary.Apply(name)
+	trace.Add(TraceDictionary(result))
+	return result.Result
+}
+
+// getNameTemplates возвращает текущий набор шаблонов имен
+func (n *Normalizer) getNameTemplates() *NameTemplateSet {
+	n.nameTemplatesMu.RLock()
+	defer n.nameTemplatesMu.RUnlock()
+	return n.nameTemplates
+}
+
 // sendEvent отправляет событие в канал, если он доступен
 func (n *Normalizer) sendEvent(message string) {
 	if n.events != nil {
@@ -282,22 +401,49 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 			return fmt.Errorf("normalization stopped by user at item %d of %d", i, len(items))
 		}
 
+		// Запись решений по элементу (см. /api/normalization/explain/{id})
+		trace := &DecisionTrace{}
+
 		// Валидация элемента (если настроен ValidationEngine)
 		if n.validationEngine != nil {
-			if !n.validationEngine.ValidateItem(item) {
+			valid, outcomes := n.validationEngine.ValidateItemDetailed(item)
+			if !valid {
 				// Элемент не прошел валидацию (критические ошибки)
 				// Пропускаем элемент и продолжаем со следующим
 				log.Printf("Элемент %d (%s) не прошел валидацию, пропускаем", item.ID, item.Name)
 				continue
 			}
+			for _, step := range TraceValidation(outcomes) {
+				trace.Add(step)
+			}
 		}
 
+		// Исправление опечаток по словарю (до нормализации, чтобы опечатки не разбивали группы)
+		name := n.correctSpelling(item.Name, trace)
+		// Сокращения и синонимы по словарю терминов - до правил и AI, чтобы все записи проекта
+		// раскрывались одинаково
+		name = n.applyTermDictionary(name, trace)
+
 		// Базовая нормализация (правила) с извлечением атрибутов
-		category := n.categorizer.Categorize(item.Name)
-		normalizedName, attributes := n.nameNormalizer.ExtractAttributes(item.Name)
+		category := n.categorizer.Categorize(name)
+		normalizedName, attributes := n.nameNormalizer.ExtractAttributes(name)
 		if normalizedName == "" {
 			normalizedName = item.Name // Используем исходное имя, если нормализация дала пустую строку
 		}
+		trace.Add(DecisionStep{
+			Stage:   DecisionStageRules,
+			Outcome: DecisionOutcomeApplied,
+			Result:  normalizedName,
+			Details: map[string]interface{}{"category": category, "attributes": len(attributes)},
+		})
+		if n.patternDetector != nil {
+			matches := n.patternDetector.DetectPatterns(name)
+			suggested := ""
+			if len(matches) > 0 {
+				suggested = n.patternDetector.ApplyFixes(name, matches)
+			}
+			trace.Add(TracePatterns(matches, suggested, false))
+		}
 		aiConfidence := 0.0
 		aiReasoning := ""
 		processingLevel := "basic"
@@ -306,22 +452,34 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 		benchmarkFound := false
 		if n.benchmarkFinder != nil {
 			benchmarkName, found, err := n.benchmarkFinder.FindBestMatch(item.Name, "nomenclature")
-			if err == nil && found {
+			switch {
+			case err != nil:
+				trace.Add(DecisionStep{
+					Stage:   DecisionStageBenchmark,
+					Outcome: DecisionOutcomeFailed,
+					Details: map[string]interface{}{"error": truncateTraceText(err.Error())},
+				})
+			case found:
 				normalizedName = benchmarkName
 				processingLevel = "benchmark"
 				aiConfidence = 1.0 // Эталон имеет максимальную уверенность
 				aiReasoning = "Найдено в эталонах"
 				benchmarkFound = true
+				trace.Add(DecisionStep{Stage: DecisionStageBenchmark, Outcome: DecisionOutcomeApplied, Result: benchmarkName, Score: 1.0})
+			default:
+				trace.Add(DecisionStep{Stage: DecisionStageBenchmark, Outcome: DecisionOutcomeNoMatch})
 			}
 		}
 
 		// AI обработка если требуется (только если эталон не найден)
-		if !benchmarkFound && n.useAI && n.aiNormalizer != nil && n.aiNormalizer.RequiresAI(item.Name, category) {
-			aiResult, err := n.processWithAI(item.Name)
+		if !benchmarkFound && n.useAI && n.aiNormalizer != nil && n.aiNormalizer.RequiresAI(name, category) {
+			aiResult, err := n.processWithAI(name)
+			accepted := err == nil && aiResult.Confidence >= n.aiConfig.MinConfidence
+			trace.Add(TraceAI(aiResult, err, n.aiConfig.MinConfidence, accepted))
 			if err != nil {
 				n.sendEvent(fmt.Sprintf("⚠ AI ошибка для '%s': %v, используем правила", item.Name, err))
 				log.Printf("AI ошибка для '%s': %v, используем правила", item.Name, err)
-			} else if aiResult.Confidence >= n.aiConfig.MinConfidence {
+			} else if accepted {
 				// Используем результат AI если уверенность достаточная
 				category = aiResult.Category
 				normalizedName = aiResult.NormalizedName
@@ -349,16 +507,28 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 		kpvedConfidence := 0.0
 
 		if !exists {
+			groupTrace := &DecisionTrace{}
 			// Для новой группы выполняем иерархическую КПВЭД классификацию
 			// Используем результат КПВЭД как категорию вместо простого Categorizer
 			if n.hierarchicalClassifier != nil {
 				kpvedResult, err := n.hierarchicalClassifier.Classify(normalizedName, category)
+				groupTrace.Add(TraceKpved(kpvedResult, err))
 				if err != nil {
 					log.Printf("Warning: Hierarchical KPVED classification failed for '%s': %v, используем простую категорию", normalizedName, err)
 				} else {
 					kpvedCode = kpvedResult.FinalCode
 					kpvedName = kpvedResult.FinalName
 					kpvedConfidence = kpvedResult.FinalConfidence
+					if n.decisionEngine != nil {
+						// Код проверяется по дереву КПВЭД; решение с кандидатами попадает в запись.
+						// Уверенность остается от классификатора: валидатор дает только базовую оценку
+						decision := n.decisionEngine.Decide(nil, kpvedResult, nil, "", nil)
+						groupTrace.Add(TraceDecision(decision))
+						kpvedCode, kpvedName = decision.Code, decision.Name
+						if kpvedCode == "" {
+							kpvedConfidence = 0
+						}
+					}
 
 					// Используем название из КПВЭД как категорию, если уверенность достаточна
 					// Но только если это не изменит ключ группы (чтобы не создавать дубликаты)
@@ -388,6 +558,8 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 				kpvedName:       kpvedName,
 				kpvedConfidence: kpvedConfidence,
 				attributes:      make(map[string][]*database.ItemAttribute),
+				trace:           groupTrace,
+				itemTraces:      make(map[*database.CatalogItem]*DecisionTrace),
 			}
 			groups[key] = group
 		} else {
@@ -403,6 +575,7 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 		if len(attributes) > 0 {
 			group.attributes[item.Code] = attributes
 		}
+		group.itemTraces[item] = trace
 		processedCount++
 
 		// Отправляем событие каждые 1000 записей
@@ -444,6 +617,9 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 		avgTimePerItem = 0
 	}
 
+	// Шаблоны имен: normalized_reference остается ключом группы, normalized_name строится по шаблону
+	nameTemplates := n.getNameTemplates()
+
 	for key, group := range groups {
 		// Определяем normalized_reference = normalized_name
 		normalizedReference := key.normalizedName
@@ -466,6 +642,23 @@ func (n *Normalizer) ProcessNormalization(uploadID int) error {
 				KpvedName:           group.kpvedName,
 				KpvedConfidence:     group.kpvedConfidence,
 			}
+			trace := group.itemTraces[item].Merge(group.trace)
+			if nameTemplates.Len() > 0 {
+				render, ok := nameTemplates.Apply(&NameTemplateInput{
+					SourceName: item.Name,
+					BaseName:   key.normalizedName,
+					Category:   key.category,
+					KpvedCode:  group.kpvedCode,
+					Attributes: group.attributes[item.Code],
+				})
+				if ok {
+					normalizedItem.NormalizedName = render.Name
+				}
+				if render != nil {
+					trace.Add(TraceNameTemplate(render, ok))
+				}
+			}
+			normalizedItem.DecisionTrace = trace.Marshal()
 
 			batch = append(batch, normalizedItem)
 			// Сохраняем связь кода с группой для доступа к атрибутам
@@ -710,6 +903,7 @@ func (n *Normalizer) filterDuplicatesFromBatch(batch []*database.NormalizedItem)
 
 	// 4. Проверяем каждый элемент батча на дубликаты с существующими записями
 	analyzer := NewDuplicateAnalyzer()
+	analyzer.SetTermDictionary(n.getTermDictionary())
 	// Снижаем порог для exact matching, чтобы ловить больше дубликатов
 	analyzer.exactThreshold = 0.95
 	analyzer.semanticThreshold = 0.95
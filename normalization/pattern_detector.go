@@ -3,6 +3,7 @@ package normalization
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -49,6 +50,8 @@ type PatternDetector struct {
 	patterns []PatternRule
 	parser   *StatefulParser  // Stateful парсер для контекстной детекции
 	analyzer *PatternAnalyzer // Анализатор статистики паттернов
+	// Исправление опечаток по словарю проекта (PatternTypo); без него опечатки не ищутся
+	spellCorrector *SpellCorrector
 }
 
 // PatternRule правило для обнаружения паттерна
@@ -73,6 +76,11 @@ func NewPatternDetector() *PatternDetector {
 	return detector
 }
 
+// SetSpellCorrector подключает словарное исправление опечаток (паттерн PatternTypo)
+func (pd *PatternDetector) SetSpellCorrector(corrector *SpellCorrector) {
+	pd.spellCorrector = corrector
+}
+
 // registerDefaultPatterns регистрирует стандартные паттерны
 func (pd *PatternDetector) registerDefaultPatterns() {
 	// Технические коды (ER-00013004, ABC-12345)
@@ -293,12 +301,29 @@ func (pd *PatternDetector) DetectPatterns(name string) []PatternMatch {
 		}
 	}
 
+	// Опечатки по словарю; автоисправляемы только исправления выше порога уверенности
+	if pd.spellCorrector != nil {
+		for _, correction := range pd.spellCorrector.Correct(name).Corrections {
+			matches = append(matches, PatternMatch{
+				Type:         PatternTypo,
+				Position:     correction.Position,
+				Length:       len(correction.Original),
+				MatchedText:  correction.Original,
+				SuggestedFix: matchCase(correction.Suggested, correction.Original),
+				Confidence:   correction.Confidence,
+				Description:  "Опечатка",
+				Severity:     "medium",
+				AutoFixable:  correction.Applied,
+			})
+		}
+	}
+
 	return matches
 }
 
 // ApplyFixes применяет все автоприменяемые исправления
 func (pd *PatternDetector) ApplyFixes(name string, matches []PatternMatch) string {
-	fixed := name
+	fixed := pd.applyTypoFixes(name, matches)
 
 	// Применяем исправления для каждого типа паттерна только один раз
 	appliedTypes := make(map[PatternType]bool)
@@ -321,6 +346,25 @@ func (pd *PatternDetector) ApplyFixes(name string, matches []PatternMatch) strin
 	return fixed
 }
 
+// applyTypoFixes заменяет опечатки по позициям в исходной строке (с конца, чтобы позиции не сдвигались)
+func (pd *PatternDetector) applyTypoFixes(name string, matches []PatternMatch) string {
+	var typos []PatternMatch
+	for _, match := range matches {
+		if match.Type == PatternTypo && match.AutoFixable {
+			typos = append(typos, match)
+		}
+	}
+	sort.Slice(typos, func(i, j int) bool { return typos[i].Position > typos[j].Position })
+	for _, typo := range typos {
+		end := typo.Position + typo.Length
+		if typo.Position < 0 || end > len(name) || name[typo.Position:end] != typo.MatchedText {
+			continue
+		}
+		name = name[:typo.Position] + typo.SuggestedFix + name[end:]
+	}
+	return name
+}
+
 // findRuleByType находит правило по типу
 func (pd *PatternDetector) findRuleByType(patternType PatternType) *PatternRule {
 	for i := range pd.patterns {
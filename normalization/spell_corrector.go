@@ -0,0 +1,501 @@
+package normalization
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"httpserver/normalization/algorithms"
+)
+
+// Причины исправления опечатки
+const (
+	SpellReasonDictionary = "dictionary" // Ближайшее частотное слово словаря
+	SpellReasonContext    = "context"    // Выбор между кандидатами решили биграммы соседних слов
+	SpellReasonApproved   = "approved"   // Исправление подтверждено при проверке
+	SpellReasonYo         = "yo"         // Замена ё на е
+)
+
+// spellWordRegex слова наименования (буквы и цифры без разделителей)
+var spellWordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// SpellCorrectorConfig параметры исправления опечаток
+type SpellCorrectorConfig struct {
+	MaxEditDistance    int     `json:"max_edit_distance"`    // Максимальное расстояние Дамерау-Левенштейна
+	MinWordLength      int     `json:"min_word_length"`      // Более короткие слова не исправляются
+	MinSuggestionCount int64   `json:"min_suggestion_count"` // Минимальная частота слова-кандидата в корпусе
+	DominanceRatio     float64 `json:"dominance_ratio"`      // Во сколько раз кандидат должен встречаться чаще известного слова
+	MinConfidence      float64 `json:"min_confidence"`       // Порог автоматического применения исправления
+}
+
+// DefaultSpellCorrectorConfig возвращает параметры по умолчанию
+func DefaultSpellCorrectorConfig() SpellCorrectorConfig {
+	return SpellCorrectorConfig{
+		MaxEditDistance:    2,
+		MinWordLength:      4,
+		MinSuggestionCount: 3,
+		DominanceRatio:     20,
+		MinConfidence:      0.6,
+	}
+}
+
+// SpellCorrection исправление одного слова наименования
+type SpellCorrection struct {
+	Original   string  `json:"original"`
+	Suggested  string  `json:"suggested"`
+	Position   int     `json:"position"` // Смещение слова в исходной строке (в байтах)
+	Distance   int     `json:"distance"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+	Applied    bool    `json:"applied"` // false - только предложение (уверенность ниже порога)
+}
+
+// SpellCheckResult результат проверки наименования
+type SpellCheckResult struct {
+	Original    string            `json:"original"`
+	Corrected   string            `json:"corrected"`
+	Corrections []SpellCorrection `json:"corrections"`
+}
+
+// SpellReview решение проверки по паре "исходное слово -> исправление"
+type SpellReview struct {
+	Original  string
+	Suggested string
+	Approved  bool // true - применять всегда, false - никогда не предлагать
+}
+
+// ObservedSpellCorrection исправление, встреченное при нормализации (для очереди проверки)
+type ObservedSpellCorrection struct {
+	Original    string  `json:"original"`
+	Suggested   string  `json:"suggested"`
+	Distance    int     `json:"distance"`
+	Confidence  float64 `json:"confidence"`
+	Occurrences int     `json:"occurrences"`
+	Example     string  `json:"example"`
+}
+
+// SpellDictionaryStats размер словаря и списков проверки
+type SpellDictionaryStats struct {
+	Words     int `json:"words"`
+	Bigrams   int `json:"bigrams"`
+	Whitelist int `json:"whitelist"`
+	Approved  int `json:"approved"`
+	Rejected  int `json:"rejected"`
+}
+
+// SpellCorpus частоты слов и пар соседних слов, собранные из наименований
+type SpellCorpus struct {
+	Words   map[string]int64
+	Bigrams map[[2]string]int64
+}
+
+// NewSpellCorpus создает пустой корпус
+func NewSpellCorpus() *SpellCorpus {
+	return &SpellCorpus{
+		Words:   make(map[string]int64),
+		Bigrams: make(map[[2]string]int64),
+	}
+}
+
+// AddName добавляет слова наименования с весом weight (например, числом записей с этим именем).
+// Слова с цифрами пропускаются и разрывают биграммы
+func (c *SpellCorpus) AddName(name string, weight int64) {
+	if weight <= 0 {
+		return
+	}
+	prev := ""
+	for _, word := range spellWordRegex.FindAllString(name, -1) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			prev = ""
+			continue
+		}
+		key := spellKey(word)
+		if len([]rune(key)) < 2 {
+			prev = ""
+			continue
+		}
+		c.Words[key] += weight
+		if prev != "" {
+			c.Bigrams[[2]string{prev, key}] += weight
+		}
+		prev = key
+	}
+}
+
+// AddWord добавляет отдельное слово словаря
+func (c *SpellCorpus) AddWord(word string, count int64) {
+	if key := spellKey(word); key != "" && count > 0 {
+		c.Words[key] += count
+	}
+}
+
+// SpellCorrector исправляет опечатки в наименованиях по словарю, построенному из корпусов
+// проекта и эталонов (SymSpell). Коды, артикулы, бренды, аббревиатуры и слова с цифрами
+// не исправляются; при нескольких кандидатах учитываются биграммы с соседними словами
+type SpellCorrector struct {
+	config          SpellCorrectorConfig
+	dictionary      *algorithms.SymSpell
+	patternDetector *PatternDetector
+
+	mu        sync.RWMutex
+	whitelist map[string]bool
+	approved  map[string]string
+	rejected  map[[2]string]bool
+	observed  map[[2]string]*ObservedSpellCorrection
+}
+
+// NewSpellCorrector строит словарь из корпусов
+func NewSpellCorrector(config SpellCorrectorConfig, corpora ...*SpellCorpus) *SpellCorrector {
+	defaults := DefaultSpellCorrectorConfig()
+	if config.MaxEditDistance <= 0 {
+		config.MaxEditDistance = defaults.MaxEditDistance
+	}
+	if config.MinWordLength <= 0 {
+		config.MinWordLength = defaults.MinWordLength
+	}
+	if config.MinSuggestionCount <= 0 {
+		config.MinSuggestionCount = defaults.MinSuggestionCount
+	}
+	if config.DominanceRatio <= 0 {
+		config.DominanceRatio = defaults.DominanceRatio
+	}
+	if config.MinConfidence <= 0 {
+		config.MinConfidence = defaults.MinConfidence
+	}
+
+	dictionary := algorithms.NewSymSpell(config.MaxEditDistance, 7)
+	for _, corpus := range corpora {
+		if corpus == nil {
+			continue
+		}
+		for word, count := range corpus.Words {
+			dictionary.AddWord(word, count)
+		}
+		for pair, count := range corpus.Bigrams {
+			dictionary.AddBigram(pair[0], pair[1], count)
+		}
+	}
+
+	return &SpellCorrector{
+		config:          config,
+		dictionary:      dictionary,
+		patternDetector: NewPatternDetector(),
+		whitelist:       make(map[string]bool),
+		approved:        make(map[string]string),
+		rejected:        make(map[[2]string]bool),
+		observed:        make(map[[2]string]*ObservedSpellCorrection),
+	}
+}
+
+// SetWhitelist заменяет список слов, которые никогда не исправляются
+func (s *SpellCorrector) SetWhitelist(words []string) {
+	whitelist := make(map[string]bool, len(words))
+	for _, word := range words {
+		if key := spellKey(word); key != "" {
+			whitelist[key] = true
+		}
+	}
+	s.mu.Lock()
+	s.whitelist = whitelist
+	s.mu.Unlock()
+}
+
+// SetReviews заменяет решения проверки: подтвержденные исправления применяются всегда,
+// отклоненные больше не предлагаются
+func (s *SpellCorrector) SetReviews(reviews []SpellReview) {
+	approved := make(map[string]string)
+	rejected := make(map[[2]string]bool)
+	for _, review := range reviews {
+		original, suggested := spellKey(review.Original), spellKey(review.Suggested)
+		if original == "" || suggested == "" {
+			continue
+		}
+		if review.Approved {
+			approved[original] = suggested
+		} else {
+			rejected[[2]string{original, suggested}] = true
+		}
+	}
+	s.mu.Lock()
+	s.approved = approved
+	s.rejected = rejected
+	s.mu.Unlock()
+}
+
+// Stats возвращает размер словаря и списков проверки
+func (s *SpellCorrector) Stats() SpellDictionaryStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return SpellDictionaryStats{
+		Words:     s.dictionary.WordsCount(),
+		Bigrams:   s.dictionary.BigramsCount(),
+		Whitelist: len(s.whitelist),
+		Approved:  len(s.approved),
+		Rejected:  len(s.rejected),
+	}
+}
+
+// Correct исправляет опечатки в наименовании. Исправления с уверенностью ниже
+// MinConfidence возвращаются как предложения и в имя не попадают.
+// Найденные исправления накапливаются для очереди проверки (см. TakeObserved)
+func (s *SpellCorrector) Correct(name string) SpellCheckResult {
+	result := s.Check(name)
+	s.observe(name, result.Corrections)
+	return result
+}
+
+// Check исправляет опечатки как Correct, но не накапливает найденные исправления
+func (s *SpellCorrector) Check(name string) SpellCheckResult {
+	result := SpellCheckResult{Original: name, Corrected: name}
+	if strings.TrimSpace(name) == "" {
+		return result
+	}
+
+	protected := s.protectedRanges(name)
+	nameIsUpper := isUpperText(name)
+	locations := spellWordRegex.FindAllStringIndex(name, -1)
+	keys := make([]string, len(locations))
+	for i, loc := range locations {
+		keys[i] = spellKey(name[loc[0]:loc[1]])
+	}
+
+	s.mu.RLock()
+	var corrected strings.Builder
+	last := 0
+	for i, loc := range locations {
+		word := name[loc[0]:loc[1]]
+		key := keys[i]
+		prev, next := "", ""
+		if i > 0 {
+			prev = keys[i-1]
+		}
+		if i+1 < len(keys) {
+			next = keys[i+1]
+		}
+
+		correction, ok := s.correctWord(word, key, prev, next, nameIsUpper, isProtected(loc, protected))
+		if !ok {
+			continue
+		}
+		correction.Position = loc[0]
+		if correction.Applied {
+			corrected.WriteString(name[last:loc[0]])
+			corrected.WriteString(matchCase(correction.Suggested, word))
+			last = loc[1]
+			keys[i] = correction.Suggested
+		}
+		result.Corrections = append(result.Corrections, correction)
+	}
+	s.mu.RUnlock()
+	if last > 0 {
+		corrected.WriteString(name[last:])
+		result.Corrected = corrected.String()
+	}
+	return result
+}
+
+// correctWord подбирает исправление слова; вызывается под s.mu.RLock
+func (s *SpellCorrector) correctWord(word, key, prev, next string, nameIsUpper, protected bool) (SpellCorrection, bool) {
+	if protected || s.whitelist[key] || s.isProtectedWord(word, nameIsUpper) {
+		return SpellCorrection{}, false
+	}
+
+	if suggested, ok := s.approved[key]; ok && suggested != key {
+		return SpellCorrection{Original: word, Suggested: suggested, Distance: s.editDistance(key, suggested),
+			Confidence: 1, Reason: SpellReasonApproved, Applied: true}, true
+	}
+
+	// Известное слово с ё: ё заменяется на е, чтобы "шуруповёрт" и "шуруповерт" не расходились
+	own := s.dictionary.WordCount(key)
+	if own > 0 && strings.Contains(strings.ToLower(word), "ё") {
+		return SpellCorrection{Original: word, Suggested: key, Confidence: 1, Reason: SpellReasonYo, Applied: true}, true
+	}
+
+	var best *algorithms.SpellSuggestion
+	bestScore, bestContext, frequencyLeader := math.Inf(-1), 0.0, ""
+	keyLength := len([]rune(key))
+	for _, candidate := range s.dictionary.Lookup(key, s.config.MaxEditDistance) {
+		if candidate.Distance == 0 || s.rejected[[2]string{key, candidate.Term}] {
+			continue
+		}
+		if candidate.Count < s.config.MinSuggestionCount || float64(candidate.Count) < float64(own)*s.config.DominanceRatio {
+			continue
+		}
+		// Две правки допустимы только для длинных слов, иначе кандидатом становится другое слово
+		if candidate.Distance > 1 && keyLength < 7 {
+			continue
+		}
+		if frequencyLeader == "" {
+			frequencyLeader = candidate.Term
+		}
+		context := math.Log1p(float64(s.dictionary.BigramCount(prev, candidate.Term))) +
+			math.Log1p(float64(s.dictionary.BigramCount(candidate.Term, next)))
+		score := math.Log1p(float64(candidate.Count)) - 2*float64(candidate.Distance) + 1.5*context
+		if score > bestScore {
+			c := candidate
+			best, bestScore, bestContext = &c, score, context
+		}
+	}
+	if best == nil {
+		return SpellCorrection{}, false
+	}
+
+	confidence := float64(best.Count) / float64(best.Count+own+1) * (1 - 0.15*float64(best.Distance))
+	reason := SpellReasonDictionary
+	if bestContext > 0 {
+		confidence += 0.1
+		if best.Term != frequencyLeader {
+			reason = SpellReasonContext
+		}
+	}
+	confidence = math.Min(confidence, 0.99)
+
+	return SpellCorrection{
+		Original:   word,
+		Suggested:  best.Term,
+		Distance:   best.Distance,
+		Confidence: math.Round(confidence*100) / 100,
+		Reason:     reason,
+		Applied:    confidence >= s.config.MinConfidence,
+	}, true
+}
+
+// isProtectedWord проверяет слова, которые не исправляются по форме: короткие, с цифрами,
+// латиница (бренды и модели), аббревиатуры и слова со смешанным регистром (ВВГнг)
+func (s *SpellCorrector) isProtectedWord(word string, nameIsUpper bool) bool {
+	runes := []rune(word)
+	if len(runes) < s.config.MinWordLength {
+		return true
+	}
+	upper, innerUpper, lower := 0, 0, 0
+	for i, r := range runes {
+		if unicode.IsDigit(r) || unicode.Is(unicode.Latin, r) {
+			return true
+		}
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+			if i > 0 {
+				innerUpper++
+			}
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	// Заглавные внутри слова со строчными: ВВГнг, АлюмСтрой
+	if innerUpper > 0 && lower > 0 {
+		return true
+	}
+	// Слово целиком заглавными в обычном наименовании - аббревиатура
+	return upper == len(runes) && !nameIsUpper
+}
+
+// protectedRanges возвращает участки наименования с брендами, артикулами и техническими кодами
+func (s *SpellCorrector) protectedRanges(name string) [][2]int {
+	var ranges [][2]int
+	for _, match := range s.patternDetector.DetectPatterns(name) {
+		switch match.Type {
+		case PatternArticul, PatternTechnicalCode, PatternBrand:
+			ranges = append(ranges, [2]int{match.Position, match.Position + match.Length})
+		}
+	}
+	return ranges
+}
+
+// observe накапливает встреченные исправления для очереди проверки
+func (s *SpellCorrector) observe(name string, corrections []SpellCorrection) {
+	if len(corrections) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, correction := range corrections {
+		if correction.Reason == SpellReasonYo {
+			continue
+		}
+		key := [2]string{spellKey(correction.Original), correction.Suggested}
+		entry, ok := s.observed[key]
+		if !ok {
+			entry = &ObservedSpellCorrection{Original: key[0], Suggested: key[1], Distance: correction.Distance, Example: name}
+			s.observed[key] = entry
+		}
+		entry.Occurrences++
+		if correction.Confidence > entry.Confidence {
+			entry.Confidence = correction.Confidence
+		}
+	}
+}
+
+// TakeObserved возвращает накопленные исправления и очищает накопитель
+func (s *SpellCorrector) TakeObserved() []ObservedSpellCorrection {
+	s.mu.Lock()
+	observed := s.observed
+	s.observed = make(map[[2]string]*ObservedSpellCorrection)
+	s.mu.Unlock()
+
+	result := make([]ObservedSpellCorrection, 0, len(observed))
+	for _, entry := range observed {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Occurrences != result[j].Occurrences {
+			return result[i].Occurrences > result[j].Occurrences
+		}
+		return result[i].Original < result[j].Original
+	})
+	return result
+}
+
+// editDistance расстояние между словами для подтвержденных исправлений
+func (s *SpellCorrector) editDistance(a, b string) int {
+	return algorithms.NewDamerauLevenshtein().DistanceRunes([]rune(a), []rune(b))
+}
+
+// spellKey приводит слово к ключу словаря: нижний регистр, ё -> е
+func spellKey(word string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(word)), "ё", "е")
+}
+
+// isProtected проверяет, попадает ли слово в защищенный участок
+func isProtected(loc []int, ranges [][2]int) bool {
+	for _, r := range ranges {
+		if loc[0] < r[1] && loc[1] > r[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// isUpperText возвращает true, если все буквы строки заглавные
+func isUpperText(text string) bool {
+	letters := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if !unicode.IsUpper(r) {
+				return false
+			}
+		}
+	}
+	return letters > 0
+}
+
+// matchCase переносит регистр исходного слова на исправление
+func matchCase(suggested, original string) string {
+	runes := []rune(original)
+	if len(runes) == 0 {
+		return suggested
+	}
+	if isUpperText(original) && len(runes) > 1 {
+		return strings.ToUpper(suggested)
+	}
+	if unicode.IsUpper(runes[0]) {
+		s := []rune(suggested)
+		s[0] = unicode.ToUpper(s[0])
+		return string(s)
+	}
+	return suggested
+}
@@ -0,0 +1,109 @@
+package normalization
+
+import (
+	"testing"
+)
+
+// newTestSpellCorrector создает корректор на небольшом корпусе наименований
+func newTestSpellCorrector() *SpellCorrector {
+	corpus := NewSpellCorpus()
+	corpus.AddName("Кабель ВВГнг 3х2.5", 40)
+	corpus.AddName("Кабель медный", 20)
+	corpus.AddName("Болт оцинкованный М8", 30)
+	corpus.AddName("Болд оцинкованный", 1)
+	corpus.AddName("Шуруповерт аккумуляторный", 15)
+	corpus.AddName("Сверло по металлу", 10)
+	corpus.AddName("Сверла по бетону", 10)
+	return NewSpellCorrector(DefaultSpellCorrectorConfig(), corpus)
+}
+
+func TestSpellCorrector_Correct(t *testing.T) {
+	corrector := newTestSpellCorrector()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"лишний мягкий знак", "Кабельь медный", "Кабель медный"},
+		{"редкое слово заменяется частым", "Болд оцинкованный М8", "Болт оцинкованный М8"},
+		{"ё заменяется на е", "Шуруповёрт аккумуляторный", "Шуруповерт аккумуляторный"},
+		{"верхний регистр", "КАБЕЛЬЬ МЕДНЫЙ", "КАБЕЛЬ МЕДНЫЙ"},
+		{"аббревиатура не исправляется", "Кабель ВВГ", "Кабель ВВГ"},
+		{"смешанный регистр не исправляется", "Кабель ВВГнь", "Кабель ВВГнь"},
+		{"бренд не исправляется", "Шуруповерт Bosch", "Шуруповерт Bosch"},
+		{"артикул не исправляется", "Кабель арт. 12345", "Кабель арт. 12345"},
+		{"короткие слова не исправляются", "Болт по металлу", "Болт по металлу"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := corrector.Correct(tt.input)
+			if result.Corrected != tt.expected {
+				t.Errorf("Correct(%q) = %q, want %q (corrections: %+v)", tt.input, result.Corrected, tt.expected, result.Corrections)
+			}
+		})
+	}
+}
+
+func TestSpellCorrector_Context(t *testing.T) {
+	corpus := NewSpellCorpus()
+	corpus.AddName("Сверло металл", 30)
+	corpus.AddName("Сверла бетон", 10)
+	corrector := NewSpellCorrector(DefaultSpellCorrectorConfig(), corpus)
+
+	// Без контекста победило бы более частое "сверло", биграмма с "бетон" выбирает "сверла"
+	result := corrector.Correct("Сверлу бетон")
+	if result.Corrected != "Сверла бетон" {
+		t.Fatalf("Correct = %q, want %q (corrections: %+v)", result.Corrected, "Сверла бетон", result.Corrections)
+	}
+	if result.Corrections[0].Reason != SpellReasonContext {
+		t.Errorf("Reason = %q, want %q", result.Corrections[0].Reason, SpellReasonContext)
+	}
+}
+
+func TestSpellCorrector_ReviewsAndWhitelist(t *testing.T) {
+	corrector := newTestSpellCorrector()
+
+	corrector.SetWhitelist([]string{"болд"})
+	if result := corrector.Correct("Болд оцинкованный"); result.Corrected != "Болд оцинкованный" {
+		t.Errorf("whitelisted word was corrected: %q", result.Corrected)
+	}
+
+	corrector.SetWhitelist(nil)
+	corrector.SetReviews([]SpellReview{{Original: "болд", Suggested: "болт", Approved: false}})
+	if result := corrector.Correct("Болд оцинкованный"); result.Corrected != "Болд оцинкованный" {
+		t.Errorf("rejected correction was applied: %q", result.Corrected)
+	}
+
+	corrector.SetReviews([]SpellReview{{Original: "кабелл", Suggested: "кабель", Approved: true}})
+	result := corrector.Correct("Кабелл медный")
+	if result.Corrected != "Кабель медный" || result.Corrections[0].Reason != SpellReasonApproved {
+		t.Errorf("approved correction = %+v", result)
+	}
+
+	observed := corrector.TakeObserved()
+	if len(observed) != 1 || observed[0].Original != "кабелл" || observed[0].Occurrences != 1 {
+		t.Errorf("TakeObserved = %+v", observed)
+	}
+	if len(corrector.TakeObserved()) != 0 {
+		t.Error("TakeObserved should clear observed corrections")
+	}
+}
+
+func TestNormalizer_SpellCorrectorSource(t *testing.T) {
+	var current *SpellCorrector
+	n := &Normalizer{}
+	n.SetSpellCorrectorSource(func() *SpellCorrector { return current })
+
+	// Словарь еще не построен - имя не меняется
+	if got := n.correctSpelling("Кабельь медный", nil); got != "Кабельь медный" {
+		t.Errorf("without corrector got %q", got)
+	}
+
+	// Перестроенный словарь подхватывается без повторной установки
+	current = newTestSpellCorrector()
+	if got := n.correctSpelling("Кабельь медный", nil); got != "Кабель медный" {
+		t.Errorf("after rebuild got %q, want %q", got, "Кабель медный")
+	}
+}
@@ -0,0 +1,361 @@
+package normalization
+
+import (
+	"fmt"
+	"strings"
+
+	"httpserver/database"
+)
+
+// Выходные поля встроенных этапов
+const (
+	StageFieldCleanedName          = "cleaned_name"
+	StageFieldCorrectedName        = "corrected_name"
+	StageFieldExpandedName         = "expanded_name"
+	StageFieldItemType             = "item_type"
+	StageFieldAttributes           = "attributes"
+	StageFieldGroupKey             = "group_key"
+	StageFieldArticleCode          = "article_code"
+	StageFieldDimensions           = "dimensions"
+	StageFieldClassifierCode       = "classifier_code"
+	StageFieldClassifierConfidence = "classifier_confidence"
+	StageFieldValidatedCode        = "validated_code"
+	StageFieldFallbackCode         = "fallback_code"
+	StageFieldFallbackConfidence   = "fallback_confidence"
+	StageFieldFinalCode            = "final_code"
+	StageFieldFinalConfidence      = "final_confidence"
+)
+
+// StageDependencies внешние зависимости встроенных этапов
+type StageDependencies struct {
+	// KpvedDB классификатор КПВЭД; без него этапы code_validation и fallback не регистрируются
+	KpvedDB KpvedDB
+	// SpellCorrector возвращает текущий словарь исправления опечаток (словарь перестраивается,
+	// поэтому передается функция); без него этап spelling не регистрируется
+	SpellCorrector func() *SpellCorrector
+	// TermDictionary возвращает текущий общий словарь терминов; без него этап dictionary не регистрируется
+	TermDictionary func() *TermDictionary
+}
+
+// funcStage этап, заданный описанием и функцией обработки
+type funcStage struct {
+	spec    StageSpec
+	process func(item *StageItem, params map[string]interface{}) StageResult
+}
+
+func (s *funcStage) Spec() StageSpec {
+	return s.spec
+}
+
+func (s *funcStage) Process(item *StageItem, params map[string]interface{}) StageResult {
+	return s.process(item, params)
+}
+
+// NewDefaultStageRegistry создает реестр со встроенными этапами, аналогичными этапам колонок stageNN_*.
+// Этапы повторяют правила нормализатора упрощенно (без AI, эталонов и иерархического классификатора)
+func NewDefaultStageRegistry(deps StageDependencies) (*StageRegistry, error) {
+	preValidator := NewPreValidator()
+	detector := NewProductServiceDetector()
+	nameNormalizer := NewNameNormalizer()
+	keywordClassifier := NewKeywordClassifier()
+
+	stages := []Stage{
+		&funcStage{
+			spec: StageSpec{ID: "pre_validation", Name: "Предварительная валидация", LegacyNumber: "0.5",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldCleanedName}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				result := preValidator.PreValidate(item.String(StageFieldSourceName))
+				payload := map[string]interface{}{"cleaned_name": result.CleanedName}
+				if !result.IsValid {
+					payload["reason"] = result.ValidationReason
+					return StageResult{Status: database.StageStatusRejected, Confidence: result.Confidence, Payload: payload}
+				}
+				item.Set(StageFieldCleanedName, result.CleanedName)
+				return StageResult{Confidence: result.Confidence, Payload: payload}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "lowercase", Name: "Нормализация наименования", LegacyNumber: "1",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldNormalizedName}, After: []string{"pre_validation", "spelling", "dictionary"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				name := item.String(StageFieldExpandedName)
+				if name == "" {
+					name = item.String(StageFieldCorrectedName)
+				}
+				if name == "" {
+					name = item.String(StageFieldCleanedName)
+				}
+				if name == "" {
+					name = item.String(StageFieldSourceName)
+				}
+				normalized := nameNormalizer.NormalizeName(name)
+				if normalized == "" {
+					return StageResult{Status: database.StageStatusRejected, Payload: map[string]interface{}{"reason": "empty normalized name"}}
+				}
+				item.Set(StageFieldNormalizedName, normalized)
+				return StageResult{Confidence: 1, Payload: map[string]interface{}{"normalized_name": normalized}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "item_type", Name: "Определение товар/услуга", LegacyNumber: "2",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldItemType}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				detection := detector.DetectProductOrService(item.String(StageFieldSourceName), item.String(StageFieldCategory))
+				item.Set(StageFieldItemType, string(detection.Type))
+				return StageResult{Confidence: detection.Confidence, Payload: map[string]interface{}{
+					"item_type": string(detection.Type), "reasoning": detection.Reasoning,
+				}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "attributes", Name: "Извлечение атрибутов", LegacyNumber: "2.5",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldAttributes}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				_, attributes := nameNormalizer.ExtractAttributes(item.String(StageFieldSourceName))
+				item.Set(StageFieldAttributes, attributes)
+				if len(attributes) == 0 {
+					return StageResult{Payload: map[string]interface{}{"count": 0}}
+				}
+				confidence := 0.0
+				for _, attr := range attributes {
+					confidence += attr.Confidence
+				}
+				return StageResult{Confidence: confidence / float64(len(attributes)), Payload: map[string]interface{}{"count": len(attributes)}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "grouping", Name: "Группировка", LegacyNumber: "3",
+				Inputs: []string{StageFieldNormalizedName}, Outputs: []string{StageFieldGroupKey}, DependsOn: []string{"lowercase"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				key := item.String(StageFieldCategory) + "|" + item.String(StageFieldNormalizedName)
+				item.Set(StageFieldGroupKey, key)
+				return StageResult{Confidence: 1, Payload: map[string]interface{}{"group_key": key}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "articles", Name: "Извлечение артикулов", LegacyNumber: "4",
+				Inputs: []string{StageFieldAttributes}, Outputs: []string{StageFieldArticleCode}, DependsOn: []string{"attributes"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				for _, attr := range item.Attributes() {
+					if attr.AttributeType == "article_code" {
+						item.Set(StageFieldArticleCode, attr.AttributeValue)
+						return StageResult{Confidence: attr.Confidence, Payload: map[string]interface{}{"article_code": attr.AttributeValue}}
+					}
+				}
+				return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "no article code"}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "dimensions", Name: "Извлечение размеров", LegacyNumber: "5",
+				Inputs: []string{StageFieldAttributes}, Outputs: []string{StageFieldDimensions}, DependsOn: []string{"attributes"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				dimensions := make(map[string]interface{})
+				for _, attr := range item.Attributes() {
+					if attr.AttributeType == "dimension" {
+						dimensions[attr.AttributeName] = attr.AttributeValue
+					}
+				}
+				if len(dimensions) == 0 {
+					return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "no dimensions"}}
+				}
+				item.Set(StageFieldDimensions, dimensions)
+				return StageResult{Confidence: 1, Payload: map[string]interface{}{"dimensions": dimensions}}
+			},
+		},
+		&funcStage{
+			spec: StageSpec{ID: "keyword_classification", Name: "Классификация по ключевым словам", LegacyNumber: "6",
+				Inputs: []string{StageFieldNormalizedName}, Outputs: []string{StageFieldClassifierCode, StageFieldClassifierConfidence},
+				DependsOn: []string{"lowercase"}, After: []string{"item_type"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				if item.String(StageFieldItemType) == string(ObjectTypeService) {
+					return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "service item"}}
+				}
+				result, found := keywordClassifier.ClassifyByKeyword(item.String(StageFieldNormalizedName), item.String(StageFieldCategory))
+				if !found || result == nil {
+					return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "no keyword match"}}
+				}
+				item.Set(StageFieldClassifierCode, result.FinalCode)
+				item.Set(StageFieldClassifierConfidence, result.FinalConfidence)
+				return StageResult{Confidence: result.FinalConfidence, Payload: map[string]interface{}{
+					"code": result.FinalCode, "name": result.FinalName,
+				}}
+			},
+		},
+	}
+
+	if deps.SpellCorrector != nil {
+		stages = append(stages, &funcStage{
+			spec: StageSpec{ID: "spelling", Name: "Исправление опечаток", LegacyNumber: "0.7",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldCorrectedName}, After: []string{"pre_validation"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				corrector := deps.SpellCorrector()
+				if corrector == nil {
+					return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "spelling dictionary is not built"}}
+				}
+				name := item.String(StageFieldCleanedName)
+				if name == "" {
+					name = item.String(StageFieldSourceName)
+				}
+				result := corrector.Check(name)
+				item.Set(StageFieldCorrectedName, result.Corrected)
+				confidence := 1.0
+				for _, correction := range result.Corrections {
+					if correction.Applied && correction.Confidence < confidence {
+						confidence = correction.Confidence
+					}
+				}
+				return StageResult{Confidence: confidence, Payload: map[string]interface{}{
+					"corrected_name": result.Corrected, "corrections": result.Corrections,
+				}}
+			},
+		})
+	}
+
+	if deps.TermDictionary != nil {
+		stages = append(stages, &funcStage{
+			spec: StageSpec{ID: "dictionary", Name: "Словарь сокращений и синонимов", LegacyNumber: "0.8",
+				Inputs: []string{StageFieldSourceName}, Outputs: []string{StageFieldExpandedName}, After: []string{"pre_validation", "spelling"}},
+			process: func(item *StageItem, params map[string]interface{}) StageResult {
+				dictionary := deps.TermDictionary()
+				if dictionary == nil {
+					return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "term dictionary is not loaded"}}
+				}
+				name := item.String(StageFieldCorrectedName)
+				if name == "" {
+					name = item.String(StageFieldCleanedName)
+				}
+				if name == "" {
+					name = item.String(StageFieldSourceName)
+				}
+				result := dictionary.Apply(name)
+				item.Set(StageFieldExpandedName, result.Result)
+				return StageResult{Confidence: 1, Payload: map[string]interface{}{
+					"expanded_name": result.Result, "replacements": result.Replacements,
+				}}
+			},
+		})
+	}
+
+	if deps.KpvedDB != nil {
+		codeValidator, err := NewCodeValidator(deps.KpvedDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create code validator stage: %w", err)
+		}
+		fallbackClassifier, err := NewFallbackClassifier(deps.KpvedDB)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback stage: %w", err)
+		}
+
+		stages = append(stages,
+			&funcStage{
+				spec: StageSpec{ID: "code_validation", Name: "Валидация кода КПВЭД", LegacyNumber: "6.5",
+					Outputs: []string{StageFieldValidatedCode}, After: []string{"keyword_classification", "item_type"}},
+				process: func(item *StageItem, params map[string]interface{}) StageResult {
+					code := item.String(StageFieldClassifierCode)
+					if code == "" {
+						code = item.String(StageFieldKpvedCode)
+					}
+					if code == "" {
+						return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "no code to validate"}}
+					}
+					result := codeValidator.ValidateCode(code, item.String(StageFieldItemType), nil)
+					payload := map[string]interface{}{"code": result.ValidatedCode, "name": result.ValidatedName, "reason": result.ValidationReason}
+					if len(result.SuggestedCodes) > 0 {
+						payload["suggested_codes"] = result.SuggestedCodes
+					}
+					if !result.IsValid {
+						return StageResult{Status: database.StageStatusRejected, Confidence: result.RefinedConfidence, Payload: payload}
+					}
+					item.Set(StageFieldValidatedCode, result.ValidatedCode)
+					return StageResult{Confidence: result.RefinedConfidence, Payload: payload}
+				},
+			},
+			&funcStage{
+				spec: StageSpec{ID: "fallback", Name: "Резервная классификация", LegacyNumber: "8",
+					Inputs: []string{StageFieldNormalizedName}, Outputs: []string{StageFieldFallbackCode, StageFieldFallbackConfidence},
+					DependsOn: []string{"lowercase"}, After: []string{"keyword_classification", "code_validation"}},
+				process: func(item *StageItem, params map[string]interface{}) StageResult {
+					minConfidence := stageFloatParam(params, "min_confidence", 0.7)
+					if item.has(StageFieldValidatedCode) && item.Float(StageFieldClassifierConfidence) >= minConfidence {
+						return StageResult{Status: database.StageStatusSkipped, Payload: map[string]interface{}{"reason": "classifier result is confident"}}
+					}
+					result := fallbackClassifier.Classify(item.String(StageFieldNormalizedName), item.String(StageFieldCategory),
+						item.String(StageFieldClassifierCode), item.Float(StageFieldClassifierConfidence))
+					if result == nil || result.Code == "" {
+						return StageResult{Status: database.StageStatusRejected, Payload: map[string]interface{}{"reason": "no fallback code"}}
+					}
+					item.Set(StageFieldFallbackCode, result.Code)
+					item.Set(StageFieldFallbackConfidence, result.Confidence)
+					status := database.StageStatusCompleted
+					if result.ManualReviewRequired {
+						status = database.StageStatusReview
+					}
+					return StageResult{Status: status, Confidence: result.Confidence, Payload: map[string]interface{}{
+						"code": result.Code, "name": result.Name, "method": result.Method, "reasoning": result.Reasoning,
+					}}
+				},
+			},
+		)
+	}
+
+	stages = append(stages, &funcStage{
+		spec: StageSpec{ID: "final_decision", Name: "Итоговое решение", LegacyNumber: "9",
+			Outputs: []string{StageFieldFinalCode, StageFieldFinalConfidence},
+			After:   []string{"keyword_classification", "code_validation", "fallback"}},
+		process: func(item *StageItem, params map[string]interface{}) StageResult {
+			reviewThreshold := stageFloatParam(params, "review_threshold", 0.6)
+			code, confidence, source := "", 0.0, ""
+			switch {
+			case item.has(StageFieldValidatedCode):
+				code, confidence, source = item.String(StageFieldValidatedCode), item.Float(StageFieldClassifierConfidence), "code_validation"
+				if confidence == 0 {
+					confidence = 1
+				}
+			case item.has(StageFieldFallbackCode):
+				code, confidence, source = item.String(StageFieldFallbackCode), item.Float(StageFieldFallbackConfidence), "fallback"
+			case item.has(StageFieldClassifierCode):
+				code, confidence, source = item.String(StageFieldClassifierCode), item.Float(StageFieldClassifierConfidence), "keyword_classification"
+			case item.has(StageFieldKpvedCode):
+				code, confidence, source = item.String(StageFieldKpvedCode), 1, "source"
+			}
+			if code == "" {
+				return StageResult{Status: database.StageStatusReview, Payload: map[string]interface{}{"reason": "no code from any stage"}}
+			}
+			item.Set(StageFieldFinalCode, code)
+			item.Set(StageFieldFinalConfidence, confidence)
+			status := database.StageStatusCompleted
+			if confidence < reviewThreshold {
+				status = database.StageStatusReview
+			}
+			return StageResult{Status: status, Confidence: confidence, Payload: map[string]interface{}{"code": code, "source": source}}
+		},
+	})
+
+	registry := NewStageRegistry()
+	for _, stage := range stages {
+		if err := registry.Register(stage); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// NewStageItemWithKpved создает элемент конвейера с известным кодом КПВЭД
+func NewStageItemWithKpved(id int, sourceName, code, category, kpvedCode string) *StageItem {
+	item := NewStageItem(id, sourceName, code, category)
+	if strings.TrimSpace(kpvedCode) != "" {
+		item.Set(StageFieldKpvedCode, kpvedCode)
+	}
+	return item
+}
+
+// stageFloatParam возвращает числовой параметр этапа или значение по умолчанию
+func stageFloatParam(params map[string]interface{}, key string, fallback float64) float64 {
+	switch value := params[key].(type) {
+	case float64:
+		return value
+	case int:
+		return float64(value)
+	}
+	return fallback
+}
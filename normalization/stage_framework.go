@@ -0,0 +1,162 @@
+package normalization
+
+import (
+	"fmt"
+	"strings"
+
+	"httpserver/database"
+)
+
+// Базовые поля элемента, доступные любому этапу без объявления зависимостей
+const (
+	StageFieldSourceName     = "source_name"
+	StageFieldCode           = "code"
+	StageFieldCategory       = "category"
+	StageFieldNormalizedName = "normalized_name"
+	StageFieldKpvedCode      = "kpved_code"
+)
+
+var stageBaseFields = map[string]bool{
+	StageFieldSourceName: true,
+	StageFieldCode:       true,
+	StageFieldCategory:   true,
+	StageFieldKpvedCode:  true,
+}
+
+// StageSpec описание этапа конвейера: входы, выходы и зависимости
+type StageSpec struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	LegacyNumber string   `json:"legacy_number,omitempty"` // номер аналогичного этапа в колонках stageNN_*
+	Inputs       []string `json:"inputs"`                  // поля, без которых этап не выполняется
+	Outputs      []string `json:"outputs"`
+	DependsOn    []string `json:"depends_on,omitempty"` // этапы, которые должны завершиться успешно
+	After        []string `json:"after,omitempty"`      // этапы, после которых выполняется этап, если они включены
+}
+
+// StageResult результат выполнения этапа для одного элемента
+type StageResult struct {
+	Status     string // database.StageStatus*; пустой статус означает completed
+	Confidence float64
+	Payload    map[string]interface{}
+	Err        error
+}
+
+// Stage этап конвейера нормализации
+type Stage interface {
+	Spec() StageSpec
+	// Process обрабатывает элемент: читает входы и записывает выходы через item.Set.
+	// params - параметры этапа из конфигурации проекта
+	Process(item *StageItem, params map[string]interface{}) StageResult
+}
+
+// StageItem состояние элемента, проходящего через конвейер этапов
+type StageItem struct {
+	ID     int
+	values map[string]interface{}
+}
+
+// NewStageItem создает элемент конвейера с базовыми полями
+func NewStageItem(id int, sourceName, code, category string) *StageItem {
+	item := &StageItem{ID: id, values: make(map[string]interface{})}
+	item.Set(StageFieldSourceName, sourceName)
+	item.Set(StageFieldCode, code)
+	item.Set(StageFieldCategory, category)
+	return item
+}
+
+// Set записывает значение поля
+func (i *StageItem) Set(field string, value interface{}) {
+	i.values[field] = value
+}
+
+// Get возвращает значение поля
+func (i *StageItem) Get(field string) (interface{}, bool) {
+	value, ok := i.values[field]
+	return value, ok
+}
+
+// String возвращает строковое значение поля (пустая строка, если поля нет)
+func (i *StageItem) String(field string) string {
+	if value, ok := i.values[field].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// Float возвращает числовое значение поля
+func (i *StageItem) Float(field string) float64 {
+	if value, ok := i.values[field].(float64); ok {
+		return value
+	}
+	return 0
+}
+
+// Attributes возвращает извлеченные атрибуты элемента
+func (i *StageItem) Attributes() []*database.ItemAttribute {
+	if value, ok := i.values["attributes"].([]*database.ItemAttribute); ok {
+		return value
+	}
+	return nil
+}
+
+// has сообщает, задано ли непустое значение поля
+func (i *StageItem) has(field string) bool {
+	value, ok := i.values[field]
+	if !ok || value == nil {
+		return false
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s) != ""
+	}
+	return true
+}
+
+// StageRegistry набор доступных этапов в порядке регистрации
+type StageRegistry struct {
+	stages map[string]Stage
+	order  []string
+}
+
+// NewStageRegistry создает пустой реестр этапов
+func NewStageRegistry() *StageRegistry {
+	return &StageRegistry{stages: make(map[string]Stage)}
+}
+
+// Register добавляет этап в реестр
+func (r *StageRegistry) Register(stage Stage) error {
+	spec := stage.Spec()
+	if spec.ID == "" {
+		return fmt.Errorf("stage id is required")
+	}
+	if _, exists := r.stages[spec.ID]; exists {
+		return fmt.Errorf("stage %q is already registered", spec.ID)
+	}
+	r.stages[spec.ID] = stage
+	r.order = append(r.order, spec.ID)
+	return nil
+}
+
+// Get возвращает этап по идентификатору
+func (r *StageRegistry) Get(id string) (Stage, bool) {
+	stage, ok := r.stages[id]
+	return stage, ok
+}
+
+// Specs возвращает описания всех зарегистрированных этапов
+func (r *StageRegistry) Specs() []StageSpec {
+	specs := make([]StageSpec, 0, len(r.order))
+	for _, id := range r.order {
+		specs = append(specs, r.stages[id].Spec())
+	}
+	return specs
+}
+
+// DefaultConfig возвращает конфигурацию, в которой включены все этапы с зависимостями по умолчанию
+func (r *StageRegistry) DefaultConfig() []database.PipelineStageConfigEntry {
+	entries := make([]database.PipelineStageConfigEntry, 0, len(r.order))
+	for _, id := range r.order {
+		entries = append(entries, database.PipelineStageConfigEntry{ID: id, Enabled: true})
+	}
+	return entries
+}
@@ -0,0 +1,128 @@
+package normalization
+
+import (
+	"strings"
+	"testing"
+
+	"httpserver/database"
+)
+
+// testStage этап с фиксированным описанием, записывающий выходы константами
+type testStage struct {
+	spec   StageSpec
+	status string
+}
+
+func (s *testStage) Spec() StageSpec { return s.spec }
+
+func (s *testStage) Process(item *StageItem, params map[string]interface{}) StageResult {
+	for _, output := range s.spec.Outputs {
+		item.Set(output, s.spec.ID)
+	}
+	return StageResult{Status: s.status, Confidence: 1}
+}
+
+func newTestStageRegistry(t *testing.T, stages ...*testStage) *StageRegistry {
+	registry := NewStageRegistry()
+	for _, stage := range stages {
+		if err := registry.Register(stage); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+	return registry
+}
+
+func TestBuildStageGraph_Validation(t *testing.T) {
+	registry := newTestStageRegistry(t,
+		&testStage{spec: StageSpec{ID: "a", Inputs: []string{StageFieldSourceName}, Outputs: []string{"x"}}},
+		&testStage{spec: StageSpec{ID: "b", Inputs: []string{"x"}, DependsOn: []string{"a"}}},
+		&testStage{spec: StageSpec{ID: "c", Inputs: []string{"x"}}},
+	)
+	if err := registry.Register(&testStage{spec: StageSpec{ID: "a"}}); err == nil {
+		t.Error("Register() of duplicate stage expected error")
+	}
+
+	cases := []struct {
+		name   string
+		config []database.PipelineStageConfigEntry
+		want   string
+	}{
+		{"unknown stage", []database.PipelineStageConfigEntry{{ID: "zzz", Enabled: true}}, "unknown stage"},
+		{"disabled dependency", []database.PipelineStageConfigEntry{{ID: "a"}, {ID: "b", Enabled: true}}, "disabled stage"},
+		{"input without producer", []database.PipelineStageConfigEntry{{ID: "a", Enabled: true}, {ID: "c", Enabled: true}}, "is not produced"},
+		{"cycle", []database.PipelineStageConfigEntry{
+			{ID: "a", Enabled: true, DependsOn: []string{"b"}}, {ID: "b", Enabled: true},
+		}, "cycle"},
+	}
+	for _, tc := range cases {
+		if _, err := BuildStageGraph(registry, tc.config); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: error = %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	// Зависимость из конфигурации проекта делает вход c доступным
+	graph, err := BuildStageGraph(registry, []database.PipelineStageConfigEntry{
+		{ID: "c", Enabled: true, DependsOn: []string{"a"}}, {ID: "a", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("BuildStageGraph() error = %v", err)
+	}
+	if nodes := graph.Nodes(); len(nodes) != 2 || nodes[0].ID != "a" || nodes[1].ID != "c" || nodes[1].Position != 2 {
+		t.Errorf("order = %+v", nodes)
+	}
+}
+
+func TestStageGraph_RunSkipsDependents(t *testing.T) {
+	registry := newTestStageRegistry(t,
+		&testStage{spec: StageSpec{ID: "a", Outputs: []string{"x"}}, status: database.StageStatusRejected},
+		&testStage{spec: StageSpec{ID: "b", Inputs: []string{"x"}, DependsOn: []string{"a"}}},
+		&testStage{spec: StageSpec{ID: "c", Inputs: []string{StageFieldKpvedCode}, After: []string{"a"}}},
+	)
+	graph, err := BuildStageGraph(registry, nil)
+	if err != nil {
+		t.Fatalf("BuildStageGraph() error = %v", err)
+	}
+
+	results := graph.Run([]*StageItem{NewStageItem(1, "Болт М12", "001", "Крепеж")})
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3", len(results))
+	}
+	want := map[string]string{"a": database.StageStatusRejected, "b": database.StageStatusSkipped, "c": database.StageStatusSkipped}
+	for _, result := range results {
+		if result.Status != want[result.StageID] {
+			t.Errorf("stage %s status = %s, want %s (payload %v)", result.StageID, result.Status, want[result.StageID], result.Payload)
+		}
+	}
+}
+
+func TestDefaultStageRegistry_Run(t *testing.T) {
+	registry, err := NewDefaultStageRegistry(StageDependencies{})
+	if err != nil {
+		t.Fatalf("NewDefaultStageRegistry() error = %v", err)
+	}
+	if _, ok := registry.Get("fallback"); ok {
+		t.Error("fallback stage must not be registered without KPVED database")
+	}
+	graph, err := BuildStageGraph(registry, nil)
+	if err != nil {
+		t.Fatalf("BuildStageGraph() error = %v", err)
+	}
+
+	item := NewStageItemWithKpved(1, "Труба стальная 57х3,5 арт. ТР-573", "001", "Трубы", "24.20.13")
+	results := graph.Run([]*StageItem{item})
+	statuses := make(map[string]string, len(results))
+	for _, result := range results {
+		statuses[result.StageID] = result.Status
+	}
+	for _, id := range []string{"pre_validation", "lowercase", "item_type", "attributes", "grouping", "dimensions"} {
+		if statuses[id] != database.StageStatusCompleted {
+			t.Errorf("stage %s status = %q", id, statuses[id])
+		}
+	}
+	if item.String(StageFieldNormalizedName) == "" || item.String(StageFieldGroupKey) == "" {
+		t.Errorf("item fields not set: normalized=%q group=%q", item.String(StageFieldNormalizedName), item.String(StageFieldGroupKey))
+	}
+	if item.String(StageFieldFinalCode) == "" {
+		t.Errorf("final code not set, statuses = %v", statuses)
+	}
+}
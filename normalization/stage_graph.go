@@ -0,0 +1,259 @@
+package normalization
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"httpserver/database"
+)
+
+// StageNode этап в собранном графе конвейера
+type StageNode struct {
+	StageSpec
+	DependsOn []string               `json:"depends_on"` // зависимости с учетом конфигурации проекта
+	After     []string               `json:"after"`      // включенные этапы, после которых выполняется этап
+	Params    map[string]interface{} `json:"params,omitempty"`
+	Position  int                    `json:"position"`
+
+	stage Stage
+}
+
+// StageGraph граф этапов конвейера проекта в порядке выполнения
+type StageGraph struct {
+	nodes []*StageNode
+}
+
+// BuildStageGraph собирает граф из включенных в конфигурации этапов.
+// Пустая конфигурация включает все этапы реестра. Этапы, не перечисленные в конфигурации, отключены.
+// Возвращает ошибку при неизвестном этапе, зависимости от отключенного этапа, входе без источника или цикле
+func BuildStageGraph(registry *StageRegistry, config []database.PipelineStageConfigEntry) (*StageGraph, error) {
+	if len(config) == 0 {
+		config = registry.DefaultConfig()
+	}
+
+	enabled := make(map[string]*StageNode)
+	var listed []*StageNode
+	seen := make(map[string]bool)
+	for index, entry := range config {
+		stage, ok := registry.Get(entry.ID)
+		if !ok {
+			return nil, fmt.Errorf("unknown stage %q", entry.ID)
+		}
+		if seen[entry.ID] {
+			return nil, fmt.Errorf("stage %q is listed twice", entry.ID)
+		}
+		seen[entry.ID] = true
+		if !entry.Enabled {
+			continue
+		}
+		spec := stage.Spec()
+		node := &StageNode{StageSpec: spec, DependsOn: spec.DependsOn, Params: entry.Params, Position: index, stage: stage}
+		if entry.DependsOn != nil {
+			node.DependsOn = entry.DependsOn
+		}
+		enabled[entry.ID] = node
+		listed = append(listed, node)
+	}
+
+	for _, node := range listed {
+		for _, dep := range node.DependsOn {
+			if dep == node.ID {
+				return nil, fmt.Errorf("stage %q depends on itself", node.ID)
+			}
+			if _, ok := registry.Get(dep); !ok {
+				return nil, fmt.Errorf("stage %q depends on unknown stage %q", node.ID, dep)
+			}
+			if enabled[dep] == nil {
+				return nil, fmt.Errorf("stage %q depends on disabled stage %q", node.ID, dep)
+			}
+		}
+		node.After = nil
+		for _, after := range node.StageSpec.After {
+			if enabled[after] != nil && after != node.ID {
+				node.After = append(node.After, after)
+			}
+		}
+	}
+
+	ordered, err := sortStageNodes(listed, enabled)
+	if err != nil {
+		return nil, err
+	}
+	for position, node := range ordered {
+		node.Position = position + 1
+	}
+
+	graph := &StageGraph{nodes: ordered}
+	if err := graph.validateInputs(); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// sortStageNodes упорядочивает этапы топологически; при равенстве сохраняется порядок конфигурации
+func sortStageNodes(listed []*StageNode, enabled map[string]*StageNode) ([]*StageNode, error) {
+	inDegree := make(map[string]int, len(listed))
+	dependents := make(map[string][]string)
+	for _, node := range listed {
+		for _, dep := range append(append([]string{}, node.DependsOn...), node.After...) {
+			inDegree[node.ID]++
+			dependents[dep] = append(dependents[dep], node.ID)
+		}
+	}
+
+	var ready []*StageNode
+	for _, node := range listed {
+		if inDegree[node.ID] == 0 {
+			ready = append(ready, node)
+		}
+	}
+	ordered := make([]*StageNode, 0, len(listed))
+	for len(ready) > 0 {
+		sort.SliceStable(ready, func(i, j int) bool { return ready[i].Position < ready[j].Position })
+		node := ready[0]
+		ready = ready[1:]
+		ordered = append(ordered, node)
+		for _, id := range dependents[node.ID] {
+			inDegree[id]--
+			if inDegree[id] == 0 {
+				ready = append(ready, enabled[id])
+			}
+		}
+	}
+
+	if len(ordered) != len(listed) {
+		var cycle []string
+		for _, node := range listed {
+			if inDegree[node.ID] > 0 {
+				cycle = append(cycle, node.ID)
+			}
+		}
+		return nil, fmt.Errorf("stage dependency cycle: %s", strings.Join(cycle, ", "))
+	}
+	return ordered, nil
+}
+
+// validateInputs проверяет, что каждый вход этапа - базовое поле или выход одного из этапов, от которых он зависит
+func (g *StageGraph) validateInputs() error {
+	byID := make(map[string]*StageNode, len(g.nodes))
+	for _, node := range g.nodes {
+		byID[node.ID] = node
+	}
+
+	var ancestors func(id string, visited map[string]bool)
+	ancestors = func(id string, visited map[string]bool) {
+		for _, dep := range byID[id].DependsOn {
+			if !visited[dep] {
+				visited[dep] = true
+				ancestors(dep, visited)
+			}
+		}
+	}
+
+	for _, node := range g.nodes {
+		visited := make(map[string]bool)
+		ancestors(node.ID, visited)
+		for _, input := range node.Inputs {
+			if stageBaseFields[input] {
+				continue
+			}
+			provided := false
+			for dep := range visited {
+				for _, output := range byID[dep].Outputs {
+					if output == input {
+						provided = true
+					}
+				}
+			}
+			if !provided {
+				return fmt.Errorf("input %q of stage %q is not produced by any stage it depends on", input, node.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// Nodes возвращает этапы графа в порядке выполнения
+func (g *StageGraph) Nodes() []*StageNode {
+	return g.nodes
+}
+
+// Catalog возвращает описания этапов для каталога в БД
+func (g *StageGraph) Catalog() []database.StageCatalogEntry {
+	entries := make([]database.StageCatalogEntry, 0, len(g.nodes))
+	for _, node := range g.nodes {
+		entries = append(entries, database.StageCatalogEntry{
+			StageID:      node.ID,
+			Name:         node.Name,
+			LegacyNumber: node.LegacyNumber,
+			Position:     node.Position,
+		})
+	}
+	return entries
+}
+
+// Run выполняет этапы графа для каждого элемента и возвращает результаты для сохранения.
+// Этап пропускается, если зависимость не завершилась успешно или отсутствует обязательный вход
+func (g *StageGraph) Run(items []*StageItem) []*database.StageResultRecord {
+	results := make([]*database.StageResultRecord, 0, len(items)*len(g.nodes))
+	for _, item := range items {
+		statuses := make(map[string]string, len(g.nodes))
+		for _, node := range g.nodes {
+			record := g.runNode(node, item, statuses)
+			statuses[node.ID] = record.Status
+			results = append(results, record)
+		}
+	}
+	return results
+}
+
+func (g *StageGraph) runNode(node *StageNode, item *StageItem, statuses map[string]string) *database.StageResultRecord {
+	record := &database.StageResultRecord{NormalizedItemID: item.ID, StageID: node.ID}
+
+	for _, dep := range node.DependsOn {
+		if status := statuses[dep]; status != database.StageStatusCompleted && status != database.StageStatusReview {
+			record.Status = database.StageStatusSkipped
+			record.Payload = map[string]interface{}{"reason": fmt.Sprintf("dependency %s: %s", dep, status)}
+			record.CompletedAt = time.Now()
+			return record
+		}
+	}
+	for _, input := range node.Inputs {
+		if !item.has(input) {
+			record.Status = database.StageStatusSkipped
+			record.Payload = map[string]interface{}{"reason": fmt.Sprintf("missing input %s", input)}
+			record.CompletedAt = time.Now()
+			return record
+		}
+	}
+
+	start := time.Now()
+	result := node.stage.Process(item, node.Params)
+	record.DurationMs = time.Since(start).Milliseconds()
+	record.CompletedAt = time.Now()
+	record.Status = result.Status
+	if record.Status == "" {
+		record.Status = database.StageStatusCompleted
+	}
+	record.Confidence = result.Confidence
+	record.Payload = result.Payload
+	if result.Err != nil {
+		record.Status = database.StageStatusFailed
+		record.Error = truncateTraceText(result.Err.Error())
+	}
+	return record
+}
+
+// RunAndSave выполняет граф для элементов и сохраняет каталог этапов и результаты в БД
+func (g *StageGraph) RunAndSave(db *database.DB, items []*StageItem) ([]*database.StageResultRecord, error) {
+	if err := db.SaveStageCatalog(g.Catalog()); err != nil {
+		return nil, err
+	}
+	results := g.Run(items)
+	if err := db.SaveStageResults(results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,284 @@
+package normalization
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Виды записей словаря терминов
+const (
+	TermKindAbbreviation = "abbreviation" // Сокращение -> полная форма ("нерж." -> "нержавеющий")
+	TermKindSynonym      = "synonym"      // Вариант -> каноническое написание ("нержавейка" -> "нержавеющий")
+	TermKindStopWord     = "stopword"     // Слово удаляется из наименования и не участвует в сравнении
+)
+
+// maxTermParts максимальное число слов в термине ("эл.двиг", "нержавеющая сталь")
+const maxTermParts = 4
+
+// TermEntry запись словаря терминов
+type TermEntry struct {
+	Kind        string `json:"kind"`
+	Term        string `json:"term"`
+	Replacement string `json:"replacement,omitempty"` // Пусто у стоп-слов
+}
+
+// TermReplacement замена, выполненная словарем в наименовании
+type TermReplacement struct {
+	Kind        string `json:"kind"`
+	Original    string `json:"original"`
+	Replacement string `json:"replacement"`
+	Position    int    `json:"position"` // Смещение термина в исходной строке (в байтах)
+}
+
+// TermDictionaryResult результат применения словаря к наименованию
+type TermDictionaryResult struct {
+	Original     string            `json:"original"`
+	Result       string            `json:"result"`
+	Replacements []TermReplacement `json:"replacements"`
+}
+
+// TermDictionaryStats размер словаря по видам записей
+type TermDictionaryStats struct {
+	Abbreviations int `json:"abbreviations"`
+	Synonyms      int `json:"synonyms"`
+	StopWords     int `json:"stop_words"`
+}
+
+// TermDictionary детерминированно раскрывает сокращения, заменяет синонимы и удаляет стоп-слова.
+// Термин сопоставляется с целыми словами; слова термина могут разделяться точкой или пробелом,
+// поэтому запись "эл.двиг" находит и "эл.двиг.", и "Эл. двиг.". Словарь заменяется целиком
+// (SetEntries), поэтому его можно передать нормализатору один раз и обновлять после проверки записей
+type TermDictionary struct {
+	mu       sync.RWMutex
+	terms    map[string]TermEntry // TermKey -> запись
+	maxParts int
+}
+
+// NewTermDictionary создает словарь; записи следующих слоев переопределяют предыдущие
+// (например, словарь проекта переопределяет общий)
+func NewTermDictionary(layers ...[]TermEntry) *TermDictionary {
+	d := &TermDictionary{}
+	d.SetEntries(layers...)
+	return d
+}
+
+// SetEntries заменяет записи словаря; записи с неизвестным видом или без замены пропускаются
+func (d *TermDictionary) SetEntries(layers ...[]TermEntry) {
+	terms := make(map[string]TermEntry)
+	maxParts := 0
+	for _, entries := range layers {
+		for _, entry := range entries {
+			key := TermKey(entry.Term)
+			if key == "" || !validTermEntry(entry) {
+				continue
+			}
+			entry.Replacement = strings.Join(strings.Fields(entry.Replacement), " ")
+			terms[key] = entry
+			if parts := strings.Count(key, ".") + 1; parts > maxParts {
+				maxParts = parts
+			}
+		}
+	}
+	if maxParts > maxTermParts {
+		maxParts = maxTermParts
+	}
+
+	d.mu.Lock()
+	d.terms = terms
+	d.maxParts = maxParts
+	d.mu.Unlock()
+}
+
+// Stats возвращает число записей словаря по видам
+func (d *TermDictionary) Stats() TermDictionaryStats {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	var stats TermDictionaryStats
+	for _, entry := range d.terms {
+		switch entry.Kind {
+		case TermKindAbbreviation:
+			stats.Abbreviations++
+		case TermKindSynonym:
+			stats.Synonyms++
+		case TermKindStopWord:
+			stats.StopWords++
+		}
+	}
+	return stats
+}
+
+// Len возвращает число записей словаря
+func (d *TermDictionary) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.terms)
+}
+
+// termPart слово наименования с границами в исходной строке
+type termPart struct {
+	key    string
+	start  int
+	end    int  // Конец слова
+	dotEnd int  // Конец с точкой сокращения сразу после слова
+	joined bool // Отделено от предыдущего слова только точкой и/или пробелами
+}
+
+// Apply применяет словарь к наименованию. Сокращение заменяется вместе с завершающей точкой,
+// регистр исходного слова переносится на замену; после удаления стоп-слов лишние пробелы убираются
+func (d *TermDictionary) Apply(name string) TermDictionaryResult {
+	result := TermDictionaryResult{Original: name, Result: name}
+	if d == nil || strings.TrimSpace(name) == "" {
+		return result
+	}
+	parts := splitTermParts(name)
+
+	d.mu.RLock()
+	var out strings.Builder
+	last := 0
+	removed := false
+	for i := 0; i < len(parts); {
+		entry, n := d.matchLocked(parts, i)
+		if n == 0 {
+			i++
+			continue
+		}
+		first, lastPart := parts[i], parts[i+n-1]
+		end := lastPart.end
+		if entry.Kind == TermKindAbbreviation {
+			end = lastPart.dotEnd
+		}
+		original := name[first.start:end]
+		replacement := ""
+		if entry.Kind != TermKindStopWord {
+			replacement = matchCase(entry.Replacement, original)
+		} else {
+			removed = true
+		}
+		out.WriteString(name[last:first.start])
+		out.WriteString(replacement)
+		last = end
+		result.Replacements = append(result.Replacements, TermReplacement{
+			Kind:        entry.Kind,
+			Original:    original,
+			Replacement: replacement,
+			Position:    first.start,
+		})
+		i += n
+	}
+	d.mu.RUnlock()
+
+	if len(result.Replacements) == 0 {
+		return result
+	}
+	out.WriteString(name[last:])
+	result.Result = out.String()
+	if removed {
+		result.Result = strings.Join(strings.Fields(result.Result), " ")
+	}
+	return result
+}
+
+// NormalizeTokens применяет словарь к токенам сравнения наименований (нижний регистр, без знаков
+// препинания): сокращения и синонимы заменяются словами полной формы, стоп-слова удаляются
+func (d *TermDictionary) NormalizeTokens(tokens []string) []string {
+	if d == nil || len(tokens) == 0 {
+		return tokens
+	}
+	parts := make([]termPart, len(tokens))
+	for i, token := range tokens {
+		parts[i] = termPart{key: spellKey(token), joined: true}
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if len(d.terms) == 0 {
+		return tokens
+	}
+	normalized := make([]string, 0, len(tokens))
+	for i := 0; i < len(parts); {
+		entry, n := d.matchLocked(parts, i)
+		if n == 0 {
+			normalized = append(normalized, tokens[i])
+			i++
+			continue
+		}
+		if entry.Kind != TermKindStopWord {
+			normalized = append(normalized, strings.Fields(strings.ToLower(entry.Replacement))...)
+		}
+		i += n
+	}
+	return normalized
+}
+
+// matchLocked находит самый длинный термин, начинающийся со слова i; возвращает число слов термина
+func (d *TermDictionary) matchLocked(parts []termPart, i int) (TermEntry, int) {
+	for n := d.maxParts; n >= 1; n-- {
+		if i+n > len(parts) {
+			continue
+		}
+		keys := make([]string, 0, n)
+		joined := true
+		for j := i; j < i+n; j++ {
+			if j > i && !parts[j].joined {
+				joined = false
+				break
+			}
+			keys = append(keys, parts[j].key)
+		}
+		if !joined {
+			continue
+		}
+		if entry, ok := d.terms[strings.Join(keys, ".")]; ok {
+			return entry, n
+		}
+	}
+	return TermEntry{}, 0
+}
+
+// splitTermParts разбивает наименование на слова (буквы и цифры без разделителей)
+func splitTermParts(name string) []termPart {
+	locations := spellWordRegex.FindAllStringIndex(name, -1)
+	parts := make([]termPart, 0, len(locations))
+	for i, loc := range locations {
+		part := termPart{key: spellKey(name[loc[0]:loc[1]]), start: loc[0], end: loc[1], dotEnd: loc[1]}
+		if loc[1] < len(name) && name[loc[1]] == '.' {
+			part.dotEnd = loc[1] + 1
+		}
+		if i > 0 {
+			gap := name[parts[i-1].dotEnd:loc[0]]
+			part.joined = strings.TrimSpace(gap) == ""
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// TermKey приводит термин к ключу словаря: нижний регистр, ё -> е, слова через точку
+// ("Эл. двиг." -> "эл.двиг")
+func TermKey(term string) string {
+	words := spellWordRegex.FindAllString(term, -1)
+	for i, word := range words {
+		words[i] = spellKey(word)
+	}
+	return strings.Join(words, ".")
+}
+
+// IsTermKind проверяет вид записи словаря
+func IsTermKind(kind string) bool {
+	switch kind {
+	case TermKindAbbreviation, TermKindSynonym, TermKindStopWord:
+		return true
+	}
+	return false
+}
+
+func validTermEntry(entry TermEntry) bool {
+	if !IsTermKind(entry.Kind) {
+		return false
+	}
+	if entry.Kind == TermKindStopWord {
+		return true
+	}
+	return strings.IndexFunc(entry.Replacement, unicode.IsLetter) >= 0
+}
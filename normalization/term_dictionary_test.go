@@ -0,0 +1,130 @@
+package normalization
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newTestTermDictionary создает словарь из общего слоя и слоя проекта
+func newTestTermDictionary() *TermDictionary {
+	global := []TermEntry{
+		{Kind: TermKindAbbreviation, Term: "нерж", Replacement: "нержавеющий"},
+		{Kind: TermKindAbbreviation, Term: "гофр", Replacement: "гофрированный"},
+		{Kind: TermKindAbbreviation, Term: "эл", Replacement: "электрический"},
+		{Kind: TermKindAbbreviation, Term: "эл.двиг.", Replacement: "электродвигатель"},
+		{Kind: TermKindSynonym, Term: "нержавейка", Replacement: "нержавеющая сталь"},
+		{Kind: TermKindStopWord, Term: "прочее"},
+	}
+	project := []TermEntry{
+		{Kind: TermKindAbbreviation, Term: "оцинк.", Replacement: "оцинкованный"},
+		{Kind: TermKindAbbreviation, Term: "гофр", Replacement: "гофра"}, // переопределяет общий
+		{Kind: TermKindSynonym, Term: "пустая замена"},                   // пропускается
+	}
+	return NewTermDictionary(global, project)
+}
+
+func TestTermDictionary_Apply(t *testing.T) {
+	dictionary := newTestTermDictionary()
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"сокращение с точкой", "Лист нерж. 2мм", "Лист нержавеющий 2мм"},
+		{"сокращение без точки", "лист нерж 2мм", "лист нержавеющий 2мм"},
+		{"составное сокращение", "Эл.двиг. 5,5кВт", "Электродвигатель 5,5кВт"},
+		{"составное сокращение через пробел", "эл. двиг. АИР", "электродвигатель АИР"},
+		{"одиночное слово составного", "Кабель эл. медный", "Кабель электрический медный"},
+		{"верхний регистр", "ТРУБА ОЦИНК.", "ТРУБА ОЦИНКОВАННЫЙ"},
+		{"слой проекта переопределяет общий", "Труба гофр. 20", "Труба гофра 20"},
+		{"синоним", "Нержавейка AISI 304", "Нержавеющая сталь AISI 304"},
+		{"стоп-слово", "Материалы прочее  разные", "Материалы разные"},
+		{"часть слова не заменяется", "Нержавеющий уголок", "Нержавеющий уголок"},
+		{"через дефис не склеивается", "эл-двиг", "электрический-двиг"},
+		{"пустая замена пропущена", "пустая замена", "пустая замена"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := dictionary.Apply(tt.input)
+			if result.Result != tt.expected {
+				t.Errorf("Apply(%q) = %q, want %q (replacements: %+v)", tt.input, result.Result, tt.expected, result.Replacements)
+			}
+		})
+	}
+
+	result := dictionary.Apply("Лист нерж. 2мм")
+	want := []TermReplacement{{Kind: TermKindAbbreviation, Original: "нерж.", Replacement: "нержавеющий", Position: len("Лист ")}}
+	if !reflect.DeepEqual(result.Replacements, want) {
+		t.Errorf("Replacements = %+v, want %+v", result.Replacements, want)
+	}
+
+	stats := dictionary.Stats()
+	if stats.Abbreviations != 5 || stats.Synonyms != 1 || stats.StopWords != 1 {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
+
+func TestTermDictionary_NormalizeTokens(t *testing.T) {
+	dictionary := newTestTermDictionary()
+
+	got := dictionary.NormalizeTokens([]string{"эл", "двиг", "прочее", "нержавейка", "лист"})
+	want := []string{"электродвигатель", "нержавеющая", "сталь", "лист"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeTokens = %v, want %v", got, want)
+	}
+
+	var empty *TermDictionary
+	if got := empty.NormalizeTokens([]string{"нерж"}); !reflect.DeepEqual(got, []string{"нерж"}) {
+		t.Errorf("nil dictionary changed tokens: %v", got)
+	}
+}
+
+func TestTermKey(t *testing.T) {
+	tests := map[string]string{
+		"Эл. двиг.":   "эл.двиг",
+		"НЕРЖ.":       "нерж",
+		"Тёплый  пол": "теплый.пол",
+		" . ":         "",
+	}
+	for input, expected := range tests {
+		if got := TermKey(input); got != expected {
+			t.Errorf("TermKey(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
+
+func TestAbbreviationMiner_Mine(t *testing.T) {
+	miner := NewAbbreviationMiner(AbbreviationMinerConfig{})
+	miner.AddName("Лист нержавеющий 2мм", 5)
+	miner.AddName("Лист нержавеющий 3мм", 3)
+	miner.AddName("Уголок нержавеющий", 2)
+	miner.AddName("Лист нерж. 2мм", 4)
+	miner.AddName("Уголок нерж.", 1)
+	miner.AddName("Труба гофрированная ПНД", 6)
+	miner.AddName("Труба гофр. ПНД", 3)
+	miner.AddName("Электродвигатель АИР", 4)
+	miner.AddName("Эл.двиг. АИР", 2)
+	miner.AddName("Лист стальной", 4)
+	miner.AddName("Лист листовой прокат", 3)
+
+	candidates := miner.Mine(func(term string) bool { return term == "гофр" })
+	found := make(map[string]AbbreviationCandidate)
+	for _, candidate := range candidates {
+		found[candidate.Term] = candidate
+	}
+
+	if c, ok := found["нерж"]; !ok || c.Replacement != "нержавеющий" || c.Occurrences != 5 || c.Confidence < 0.5 {
+		t.Errorf("нерж candidate = %+v (all: %+v)", c, candidates)
+	}
+	if c, ok := found["эл.двиг"]; !ok || c.Replacement != "электродвигатель" {
+		t.Errorf("эл.двиг candidate = %+v (all: %+v)", c, candidates)
+	}
+	if _, ok := found["гофр"]; ok {
+		t.Error("known term was mined again")
+	}
+	if c, ok := found["лист"]; ok {
+		t.Errorf("full word mined as abbreviation: %+v", c)
+	}
+}
@@ -0,0 +1,252 @@
+package normalization
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// termUnitRegex слово наименования вместе с точками сокращения ("эл.двиг.", "гофр.", "нерж")
+var termUnitRegex = regexp.MustCompile(`[\p{L}\p{N}]+(?:\.[\p{L}\p{N}]+)*\.?`)
+
+// AbbreviationMinerConfig параметры поиска кандидатов в сокращения
+type AbbreviationMinerConfig struct {
+	MinOccurrences         int64   `json:"min_occurrences"`           // Минимальная частота сокращения
+	MinFullFormOccurrences int64   `json:"min_full_form_occurrences"` // Минимальная частота полной формы
+	MaxAbbreviationLength  int     `json:"max_abbreviation_length"`   // Максимальная длина сокращения без точки (в буквах)
+	MinContextSimilarity   float64 `json:"min_context_similarity"`    // Минимальное сходство контекстов сокращения и полной формы
+	MaxCandidates          int     `json:"max_candidates"`
+}
+
+// DefaultAbbreviationMinerConfig возвращает параметры по умолчанию
+func DefaultAbbreviationMinerConfig() AbbreviationMinerConfig {
+	return AbbreviationMinerConfig{
+		MinOccurrences:         2,
+		MinFullFormOccurrences: 2,
+		MaxAbbreviationLength:  5,
+		MinContextSimilarity:   0.2,
+		MaxCandidates:          500,
+	}
+}
+
+// AbbreviationCandidate найденное в корпусе сокращение с предполагаемой полной формой
+type AbbreviationCandidate struct {
+	Term                string  `json:"term"`        // Ключ сокращения (TermKey)
+	Replacement         string  `json:"replacement"` // Полная форма
+	Occurrences         int64   `json:"occurrences"`
+	FullFormOccurrences int64   `json:"full_form_occurrences"`
+	ContextSimilarity   float64 `json:"context_similarity"`
+	Confidence          float64 `json:"confidence"`
+	Example             string  `json:"example"` // Пример наименования с сокращением
+}
+
+// termUnitStats частота слова корпуса и слова, встречающиеся с ним в одних наименованиях
+type termUnitStats struct {
+	count   int64
+	dotted  int64 // Сколько раз слово записано с точкой
+	context map[string]float64
+	example string
+}
+
+// AbbreviationMiner ищет сокращения по совместной встречаемости слов: сокращение и его
+// полная форма встречаются с одними и теми же словами ("лист нерж. 2мм" / "лист нержавеющий 3мм"),
+// но почти не встречаются вместе
+type AbbreviationMiner struct {
+	config AbbreviationMinerConfig
+	units  map[string]*termUnitStats
+}
+
+// NewAbbreviationMiner создает поиск сокращений; нулевые параметры заменяются значениями по умолчанию
+func NewAbbreviationMiner(config AbbreviationMinerConfig) *AbbreviationMiner {
+	defaults := DefaultAbbreviationMinerConfig()
+	if config.MinOccurrences <= 0 {
+		config.MinOccurrences = defaults.MinOccurrences
+	}
+	if config.MinFullFormOccurrences <= 0 {
+		config.MinFullFormOccurrences = defaults.MinFullFormOccurrences
+	}
+	if config.MaxAbbreviationLength <= 0 {
+		config.MaxAbbreviationLength = defaults.MaxAbbreviationLength
+	}
+	if config.MinContextSimilarity <= 0 {
+		config.MinContextSimilarity = defaults.MinContextSimilarity
+	}
+	if config.MaxCandidates <= 0 {
+		config.MaxCandidates = defaults.MaxCandidates
+	}
+	return &AbbreviationMiner{config: config, units: make(map[string]*termUnitStats)}
+}
+
+// AddName добавляет наименование корпуса с весом weight (например, числом записей с этим именем).
+// Слова с цифрами пропускаются
+func (m *AbbreviationMiner) AddName(name string, weight int64) {
+	if weight <= 0 {
+		return
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, unit := range termUnitRegex.FindAllString(name, -1) {
+		if strings.IndexFunc(unit, unicode.IsDigit) >= 0 {
+			continue
+		}
+		key := TermKey(unit)
+		if len([]rune(key)) < 2 || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+
+		stats := m.units[key]
+		if stats == nil {
+			stats = &termUnitStats{context: make(map[string]float64), example: name}
+			m.units[key] = stats
+		}
+		stats.count += weight
+		if strings.Contains(unit, ".") {
+			stats.dotted += weight
+		}
+	}
+	for _, key := range keys {
+		stats := m.units[key]
+		for _, other := range keys {
+			if other != key {
+				stats.context[other] += float64(weight)
+			}
+		}
+	}
+}
+
+// Mine возвращает кандидатов в сокращения, самые уверенные первыми. known пропускает
+// термины, уже записанные в словарь (может быть nil)
+func (m *AbbreviationMiner) Mine(known func(term string) bool) []AbbreviationCandidate {
+	// Полные формы индексируются по первым двум буквам
+	fullForms := make(map[string][]string)
+	for key, stats := range m.units {
+		if strings.Contains(key, ".") || stats.dotted*2 > stats.count || stats.count < m.config.MinFullFormOccurrences {
+			continue
+		}
+		runes := []rune(key)
+		if len(runes) < 5 {
+			continue
+		}
+		prefix := string(runes[:2])
+		fullForms[prefix] = append(fullForms[prefix], key)
+	}
+
+	var candidates []AbbreviationCandidate
+	for term, stats := range m.units {
+		if stats.count < m.config.MinOccurrences || (known != nil && known(term)) {
+			continue
+		}
+		dotted := stats.dotted*2 >= stats.count
+		letters := strings.ReplaceAll(term, ".", "")
+		if !dotted && (strings.Contains(term, ".") || len([]rune(letters)) > m.config.MaxAbbreviationLength) {
+			continue
+		}
+		if len([]rune(letters)) < 2 {
+			continue
+		}
+
+		var best AbbreviationCandidate
+		bestScore := 0.0
+		for _, full := range fullForms[string([]rune(letters)[:2])] {
+			if !isAbbreviationOf(term, full) {
+				continue
+			}
+			fullStats := m.units[full]
+			// Сокращение и полная форма в одном наименовании - скорее разные слова ("лист листовой")
+			together := stats.count
+			if fullStats.count < together {
+				together = fullStats.count
+			}
+			if stats.context[full] > 0.3*float64(together) {
+				continue
+			}
+			similarity := contextSimilarity(stats.context, fullStats.context, term, full)
+			if similarity < m.config.MinContextSimilarity {
+				continue
+			}
+			score := similarity * math.Log1p(float64(fullStats.count))
+			if score > bestScore || (score == bestScore && full < best.Replacement) {
+				bestScore = score
+				confidence := similarity
+				if !dotted {
+					// Без точки короткое слово может оказаться самостоятельным словом
+					confidence *= 0.7
+				}
+				best = AbbreviationCandidate{
+					Term:                term,
+					Replacement:         full,
+					Occurrences:         stats.count,
+					FullFormOccurrences: fullStats.count,
+					ContextSimilarity:   math.Round(similarity*1000) / 1000,
+					Confidence:          math.Round(confidence*1000) / 1000,
+					Example:             stats.example,
+				}
+			}
+		}
+		if best.Replacement != "" {
+			candidates = append(candidates, best)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		if candidates[i].Occurrences != candidates[j].Occurrences {
+			return candidates[i].Occurrences > candidates[j].Occurrences
+		}
+		return candidates[i].Term < candidates[j].Term
+	})
+	if len(candidates) > m.config.MaxCandidates {
+		candidates = candidates[:m.config.MaxCandidates]
+	}
+	return candidates
+}
+
+// isAbbreviationOf проверяет, может ли term быть сокращением full: первое слово сокращения -
+// начало полной формы, остальные буквы встречаются в ней по порядку ("эл.двиг" -> "электродвигатель"),
+// полная форма длиннее сокращения хотя бы на две буквы
+func isAbbreviationOf(term, full string) bool {
+	words := strings.Split(term, ".")
+	if !strings.HasPrefix(full, words[0]) {
+		return false
+	}
+	rest := []rune(full[len(words[0]):])
+	letters := []rune(strings.Join(words[1:], ""))
+	if len(rest) < len(letters)+2 {
+		return false
+	}
+	i := 0
+	for _, r := range rest {
+		if i < len(letters) && r == letters[i] {
+			i++
+		}
+	}
+	return i == len(letters)
+}
+
+// contextSimilarity косинусное сходство контекстов двух слов без учета самих слов
+func contextSimilarity(a, b map[string]float64, termA, termB string) float64 {
+	var dot, normA, normB float64
+	for key, value := range a {
+		if key == termA || key == termB {
+			continue
+		}
+		normA += value * value
+		dot += value * b[key]
+	}
+	for key, value := range b {
+		if key == termA || key == termB {
+			continue
+		}
+		normB += value * value
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
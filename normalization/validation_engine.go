@@ -53,6 +53,14 @@ type ValidationRule struct {
 	Validator   func(*database.CatalogItem) error
 }
 
+// ValidationOutcome результат применения одного правила к элементу
+type ValidationOutcome struct {
+	Rule     string             `json:"rule"`
+	Severity ValidationSeverity `json:"severity"`
+	Passed   bool               `json:"passed"`
+	Message  string             `json:"message,omitempty"`
+}
+
 // ValidationEngine движок валидации данных
 // Поддерживает:
 // - Валидацию структуры данных
@@ -186,14 +194,22 @@ func (ve *ValidationEngine) AddRule(rule ValidationRule) {
 // ValidateItem валидирует один элемент по всем правилам
 // Возвращает true если элемент валиден (можно обрабатывать)
 func (ve *ValidationEngine) ValidateItem(item *database.CatalogItem) bool {
+	valid, _ := ve.ValidateItemDetailed(item)
+	return valid
+}
+
+// ValidateItemDetailed валидирует элемент и возвращает результат каждого правила
+func (ve *ValidationEngine) ValidateItemDetailed(item *database.CatalogItem) (bool, []ValidationOutcome) {
 	ve.mu.Lock()
 	ve.totalValidated++
 	ve.mu.Unlock()
 
 	valid := true
+	outcomes := make([]ValidationOutcome, 0, len(ve.rules))
 
 	// Применяем все правила
 	for _, rule := range ve.rules {
+		outcome := ValidationOutcome{Rule: rule.Name, Severity: rule.Severity, Passed: true}
 		if err := rule.Validator(item); err != nil {
 			// Критические ошибки блокируют обработку
 			if rule.Severity == ValidationSeverityCritical {
@@ -201,7 +217,10 @@ func (ve *ValidationEngine) ValidateItem(item *database.CatalogItem) bool {
 			}
 
 			ve.addError(item.ID, item.Name, item.Code, rule.Name, err.Error(), rule.Severity, "", "", "")
+			outcome.Passed = false
+			outcome.Message = err.Error()
 		}
+		outcomes = append(outcomes, outcome)
 	}
 
 	// Дополнительные проверки с предупреждениями
@@ -215,7 +234,7 @@ func (ve *ValidationEngine) ValidateItem(item *database.CatalogItem) bool {
 	}
 	ve.mu.Unlock()
 
-	return valid
+	return valid, outcomes
 }
 
 // checkForWarnings проверяет условия для предупреждений
@@ -3,6 +3,7 @@ package quality
 import (
 	"fmt"
 	"log"
+	"sync"
 
 	"httpserver/database"
 )
@@ -10,11 +11,35 @@ import (
 // QualityAnalyzer основной анализатор качества данных
 type QualityAnalyzer struct {
 	db *database.DB
+
+	requisitesMu sync.RWMutex
+	requisites   *RequisitesRegistry // справочники для перекрестной проверки реквизитов
+
+	onCompleted func(uploadID, databaseID int) // вызывается после обновления трендов
 }
 
 // NewQualityAnalyzer создает новый анализатор качества
 func NewQualityAnalyzer(db *database.DB) *QualityAnalyzer {
-	return &QualityAnalyzer{db: db}
+	return &QualityAnalyzer{db: db, requisites: DefaultRequisitesRegistry()}
+}
+
+// SetRequisitesRegistry заменяет справочники реквизитов, например после загрузки новой версии
+func (qa *QualityAnalyzer) SetRequisitesRegistry(registry *RequisitesRegistry) {
+	qa.requisitesMu.Lock()
+	defer qa.requisitesMu.Unlock()
+	qa.requisites = registry
+}
+
+// SetCompletionHandler задает обработчик завершения анализа выгрузки, например для поиска аномалий в трендах
+func (qa *QualityAnalyzer) SetCompletionHandler(handler func(uploadID, databaseID int)) {
+	qa.onCompleted = handler
+}
+
+// RequisitesRegistry возвращает текущие справочники реквизитов
+func (qa *QualityAnalyzer) RequisitesRegistry() *RequisitesRegistry {
+	qa.requisitesMu.RLock()
+	defer qa.requisitesMu.RUnlock()
+	return qa.requisites
 }
 
 // AnalyzeUpload запускает полный анализ качества для выгрузки
@@ -48,6 +73,10 @@ func (qa *QualityAnalyzer) AnalyzeUpload(uploadID int, databaseID int) error {
 		log.Printf("Error updating quality trends: %v", err)
 	}
 
+	if qa.onCompleted != nil {
+		qa.onCompleted(uploadID, databaseID)
+	}
+
 	log.Printf("Quality analysis completed for upload %d", uploadID)
 	return nil
 }
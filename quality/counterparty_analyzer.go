@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"httpserver/database"
+	"httpserver/extractors"
 )
 
 // analyzeCounterparties анализирует качество данных контрагентов
@@ -44,6 +45,11 @@ func (qa *QualityAnalyzer) analyzeCounterparties(uploadID int, databaseID int) e
 		log.Printf("Error validating counterparty KPP: %v", err)
 	}
 
+	// 5. Перекрестная проверка реквизитов по справочникам
+	if err := qa.validateCounterpartyRequisites(uploadID, databaseID); err != nil {
+		log.Printf("Error validating counterparty requisites: %v", err)
+	}
+
 	return nil
 }
 
@@ -73,33 +79,63 @@ func (qa *QualityAnalyzer) analyzeCounterpartyCompleteness(uploadID int, databas
 
 	var total, withINN, withKPP, withAddress, withContacts int
 
+	var ids []int
+	xmlByID := make(map[int]string)
 	for rows.Next() {
 		var id int
 		var attributesXML sql.NullString
 		if err := rows.Scan(&id, &attributesXML); err != nil {
 			continue
 		}
+		ids = append(ids, id)
+		xmlByID[id] = attributesXML.String
+	}
+	rows.Close()
+
+	// Реквизиты, разобранные при загрузке; attributes_xml разбирается только для
+	// элементов, еще не попавших в хранилище атрибутов
+	sets, err := qa.db.GetSourceItemAttributes(database.SourceItemTypeCatalog, ids)
+	if err != nil {
+		return metric, fmt.Errorf("failed to load counterparty attributes: %w", err)
+	}
 
+	for _, id := range ids {
 		total++
 
-		if attributesXML.Valid && attributesXML.String != "" {
+		if set := sets[id]; len(set) > 0 {
+			if inn, err := extractors.ExtractFromSet(set, extractors.FieldINN); err == nil && inn != "" {
+				withINN++
+			}
+			if kpp, err := extractors.ExtractFromSet(set, extractors.FieldKPP); err == nil && kpp != "" {
+				withKPP++
+			}
+			if set.HasNameContaining("адрес", "address") {
+				withAddress++
+			}
+			if set.HasNameContaining("телефон", "phone", "email", "почта", "контакт", "contact") {
+				withContacts++
+			}
+			continue
+		}
+
+		if attributesXML := xmlByID[id]; attributesXML != "" {
 			// Проверяем наличие ИНН
-			if inn, err := ExtractINNFromAttributes(attributesXML.String); err == nil && inn != "" {
+			if inn, err := ExtractINNFromAttributes(attributesXML); err == nil && inn != "" {
 				withINN++
 			}
 
 			// Проверяем наличие КПП
-			if kpp, err := ExtractKPPFromAttributes(attributesXML.String); err == nil && kpp != "" {
+			if kpp, err := ExtractKPPFromAttributes(attributesXML); err == nil && kpp != "" {
 				withKPP++
 			}
 
 			// Проверяем наличие адреса (простая проверка по ключевым словам)
-			if containsAddress(attributesXML.String) {
+			if containsAddress(attributesXML) {
 				withAddress++
 			}
 
 			// Проверяем наличие контактов
-			if containsContacts(attributesXML.String) {
+			if containsContacts(attributesXML) {
 				withContacts++
 			}
 		}
@@ -389,6 +425,93 @@ func (qa *QualityAnalyzer) validateCounterpartyKPP(uploadID int, databaseID int)
 	return nil
 }
 
+// validateCounterpartyRequisites проверяет согласованность ИНН/КПП, БИН и банковских реквизитов
+// по справочникам БИК, кодов причин КПП и регионов
+func (qa *QualityAnalyzer) validateCounterpartyRequisites(uploadID int, databaseID int) error {
+	registry := qa.RequisitesRegistry()
+	if registry == nil {
+		return nil
+	}
+
+	query := `
+		SELECT ci.id, ci.attributes_xml, ci.reference
+		FROM catalog_items ci
+		INNER JOIN catalogs c ON ci.catalog_id = c.id
+		WHERE c.upload_id = ? AND c.name = 'Контрагенты'
+	`
+
+	rows, err := qa.db.Query(query, uploadID)
+	if err != nil {
+		return fmt.Errorf("failed to query counterparties: %w", err)
+	}
+
+	var ids []int
+	xmlByID := make(map[int]string)
+	referenceByID := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var attributesXML sql.NullString
+		var reference sql.NullString
+		if err := rows.Scan(&id, &attributesXML, &reference); err != nil {
+			continue
+		}
+		ids = append(ids, id)
+		xmlByID[id] = attributesXML.String
+		referenceByID[id] = reference.String
+	}
+	rows.Close()
+
+	sets, err := qa.db.GetSourceItemAttributes(database.SourceItemTypeCatalog, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load counterparty attributes: %w", err)
+	}
+
+	violationCount := 0
+	for _, id := range ids {
+		extract := func(field extractors.Field) string {
+			var value string
+			if set := sets[id]; len(set) > 0 {
+				value, _ = extractors.ExtractFromSet(set, field)
+			} else if xmlByID[id] != "" {
+				value, _ = extractors.ExtractFromXML(xmlByID[id], field)
+			}
+			return value
+		}
+
+		req := CounterpartyRequisites{
+			INN:                  extract(extractors.FieldINN),
+			KPP:                  extract(extractors.FieldKPP),
+			BIN:                  extract(extractors.FieldBIN),
+			BIK:                  extract(extractors.FieldBIK),
+			BankAccount:          extract(extractors.FieldBankAccount),
+			CorrespondentAccount: extract(extractors.FieldCorrespondentAccount),
+		}
+
+		for _, violation := range registry.Check(req) {
+			violationCount++
+			issue := database.DataQualityIssue{
+				UploadID:        uploadID,
+				DatabaseID:      databaseID,
+				EntityType:      "counterparty",
+				EntityReference: referenceByID[id],
+				IssueType:       violation.Rule,
+				IssueSeverity:   violation.Severity,
+				FieldName:       violation.Field,
+				ActualValue:     violation.Value,
+				Description:     violation.Message,
+				DetectedAt:      time.Now(),
+				Status:          "OPEN",
+			}
+			if err := qa.db.SaveQualityIssue(&issue); err != nil {
+				log.Printf("Error saving requisite issue: %v", err)
+			}
+		}
+	}
+
+	log.Printf("Validated requisites for counterparties: %d violations found", violationCount)
+	return nil
+}
+
 // containsAddress проверяет наличие адреса в XML
 func containsAddress(xml string) bool {
 	keywords := []string{"адрес", "address", "Адрес", "улица", "street", "город", "city"}
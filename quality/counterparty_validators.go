@@ -178,12 +178,12 @@ func ValidateBankRequisites(bik, account, correspondentAccount string) (bool, []
 
 	// Проверка соответствия корреспондентского счета БИК
 	if bik != "" && correspondentAccount != "" {
-		// Первые 3 цифры корреспондентского счета должны совпадать с последними 3 цифрами БИК
+		// Последние 3 цифры корреспондентского счета должны совпадать с последними 3 цифрами БИК
 		if len(correspondentAccount) >= 3 && len(bik) >= 3 {
-			corrPrefix := correspondentAccount[:3]
+			corrSuffix := correspondentAccount[len(correspondentAccount)-3:]
 			bikSuffix := bik[len(bik)-3:]
-			if corrPrefix != bikSuffix {
-				errors = append(errors, "Correspondent account prefix does not match BIK suffix")
+			if corrSuffix != bikSuffix {
+				errors = append(errors, "Correspondent account suffix does not match BIK suffix")
 			}
 		}
 	}
@@ -0,0 +1,367 @@
+package quality
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+)
+
+// Правила проверки реквизитов по справочникам
+const (
+	RuleINNRegionUnknown      = "inn_region_unknown"
+	RuleKPPRegionUnknown      = "kpp_region_unknown"
+	RuleKPPRegionMismatch     = "kpp_region_mismatch"
+	RuleKPPReasonUnknown      = "kpp_reason_unknown"
+	RuleKPPTaxOfficeUnknown   = "kpp_tax_office_unknown"
+	RuleBINInvalidStructure   = "bin_invalid_structure"
+	RuleBIKUnknown            = "bik_unknown"
+	RuleBIKInactive           = "bik_inactive"
+	RuleCorrAccountMismatch   = "corr_account_mismatch"
+	RuleCorrAccountKeyInvalid = "corr_account_key_invalid"
+	RuleAccountKeyInvalid     = "account_key_invalid"
+	RuleIBANChecksumInvalid   = "iban_checksum_invalid"
+	RuleIBANBankUnknown       = "iban_bank_unknown"
+	RuleIBANBankMismatch      = "iban_bank_mismatch"
+)
+
+const (
+	requisiteSeverityHigh   = "HIGH"
+	requisiteSeverityMedium = "MEDIUM"
+	requisiteSeverityLow    = "LOW"
+
+	// correspondentAccountPrefix балансовый счет корреспондентских счетов кредитных организаций в Банке России
+	correspondentAccountPrefix = "30101"
+)
+
+// CounterpartyRequisites реквизиты контрагента для перекрестной проверки
+type CounterpartyRequisites struct {
+	INN                  string `json:"inn"`
+	KPP                  string `json:"kpp"`
+	BIN                  string `json:"bin"`
+	BIK                  string `json:"bik"`
+	BankAccount          string `json:"bank_account"`
+	CorrespondentAccount string `json:"correspondent_account"`
+}
+
+// RequisiteViolation нарушение согласованности реквизитов
+type RequisiteViolation struct {
+	Rule     string `json:"rule"`
+	Field    string `json:"field"`
+	Severity string `json:"severity"`
+	Value    string `json:"value"`
+	Message  string `json:"message"`
+}
+
+// RequisitesRegistry справочники БИК, кодов причин КПП и регионов в памяти.
+// Проверки по пустому справочнику не выполняются, а отсутствие БИК считается нарушением
+// только для полного справочника, чтобы неполная загрузка не давала ложных нарушений.
+type RequisitesRegistry struct {
+	banks            map[string]database.BankDirectoryEntry
+	kzBankCodes      map[string]database.BankDirectoryEntry
+	banksComplete    bool
+	kppReasons       map[string]database.KPPReasonEntry
+	regions          map[string]string
+	taxOffices       map[string]string
+	taxOfficeRegions map[string]bool
+}
+
+var (
+	defaultRequisitesRegistry     *RequisitesRegistry
+	defaultRequisitesRegistryOnce sync.Once
+)
+
+// NewRequisitesRegistry создает реестр реквизитов из содержимого справочников
+func NewRequisitesRegistry(data *database.RequisiteRegistryData) *RequisitesRegistry {
+	r := &RequisitesRegistry{
+		banks:            make(map[string]database.BankDirectoryEntry),
+		kzBankCodes:      make(map[string]database.BankDirectoryEntry),
+		kppReasons:       make(map[string]database.KPPReasonEntry),
+		regions:          make(map[string]string),
+		taxOffices:       make(map[string]string),
+		taxOfficeRegions: make(map[string]bool),
+	}
+	if data == nil {
+		return r
+	}
+	r.banksComplete = data.BanksComplete
+
+	for _, bank := range data.Banks {
+		r.banks[strings.ToUpper(bank.BIK)] = bank
+		if bank.Country == "KZ" && bank.BankCode != "" {
+			r.kzBankCodes[bank.BankCode] = bank
+		}
+	}
+	for _, reason := range data.KPPReasons {
+		r.kppReasons[reason.Code] = reason
+	}
+	for _, region := range data.Regions {
+		r.regions[region.Code] = region.Name
+	}
+	for _, office := range data.TaxOffices {
+		r.taxOffices[office.Code] = office.Name
+		r.taxOfficeRegions[office.RegionCode] = true
+	}
+	return r
+}
+
+// LoadRequisitesRegistry загружает реестр реквизитов из сервисной БД
+func LoadRequisitesRegistry(db database.DBConnection) (*RequisitesRegistry, error) {
+	data, err := database.GetRequisiteRegistries(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load requisite registries: %w", err)
+	}
+	return NewRequisitesRegistry(data), nil
+}
+
+// DefaultRequisitesRegistry возвращает реестр из справочников, поставляемых вместе с сервером
+func DefaultRequisitesRegistry() *RequisitesRegistry {
+	defaultRequisitesRegistryOnce.Do(func() {
+		data, err := database.BundledRequisiteRegistries()
+		if err != nil {
+			data = nil
+		}
+		defaultRequisitesRegistry = NewRequisitesRegistry(data)
+	})
+	return defaultRequisitesRegistry
+}
+
+// LookupBank возвращает запись справочника БИК
+func (r *RequisitesRegistry) LookupBank(bik string) (database.BankDirectoryEntry, bool) {
+	bank, ok := r.banks[strings.ToUpper(cleanRequisite(bik))]
+	return bank, ok
+}
+
+// RegionName возвращает наименование субъекта РФ по коду
+func (r *RequisitesRegistry) RegionName(code string) (string, bool) {
+	name, ok := r.regions[code]
+	return name, ok
+}
+
+// Check выполняет перекрестную проверку реквизитов по справочникам.
+// Реквизиты с неверным форматом пропускаются: их отмечают ValidateINN, ValidateKPP и ValidateBankRequisites.
+func (r *RequisitesRegistry) Check(req CounterpartyRequisites) []RequisiteViolation {
+	var violations []RequisiteViolation
+	add := func(rule, field, severity, value, message string) {
+		violations = append(violations, RequisiteViolation{
+			Rule:     rule,
+			Field:    field,
+			Severity: severity,
+			Value:    value,
+			Message:  message,
+		})
+	}
+
+	inn := cleanRequisite(req.INN)
+	kpp := cleanRequisite(req.KPP)
+	bin := cleanRequisite(req.BIN)
+	bik := strings.ToUpper(cleanRequisite(req.BIK))
+	account := strings.ToUpper(cleanRequisite(req.BankAccount))
+	corrAccount := cleanRequisite(req.CorrespondentAccount)
+
+	innValid := inn != "" && ValidateINN(inn)
+	if innValid && len(r.regions) > 0 {
+		if _, ok := r.regions[inn[:2]]; !ok {
+			add(RuleINNRegionUnknown, "ИНН", requisiteSeverityMedium, inn,
+				fmt.Sprintf("Код региона %s в ИНН отсутствует в справочнике субъектов РФ", inn[:2]))
+		}
+	}
+
+	if kpp != "" && ValidateKPP(kpp) {
+		kppRegion, office, reasonCode := kpp[:2], kpp[:4], kpp[4:6]
+
+		if len(r.regions) > 0 {
+			if _, ok := r.regions[kppRegion]; !ok {
+				add(RuleKPPRegionUnknown, "КПП", requisiteSeverityMedium, kpp,
+					fmt.Sprintf("Код региона %s в КПП отсутствует в справочнике субъектов РФ", kppRegion))
+			}
+		}
+
+		reason, reasonKnown := r.kppReasons[reasonCode]
+		if len(r.kppReasons) > 0 && !reasonKnown {
+			add(RuleKPPReasonUnknown, "КПП", requisiteSeverityMedium, kpp,
+				fmt.Sprintf("Код причины постановки на учет %s отсутствует в справочнике", reasonCode))
+		}
+
+		// Для юрлица, состоящего на учете по месту нахождения, регион КПП совпадает с регионом ИНН
+		if innValid && len(inn) == 10 && reasonKnown && !reason.CrossRegion && kppRegion != inn[:2] {
+			add(RuleKPPRegionMismatch, "КПП", requisiteSeverityMedium, kpp,
+				fmt.Sprintf("Регион КПП %s не совпадает с регионом ИНН %s при коде причины %s", kppRegion, inn[:2], reasonCode))
+		}
+
+		if r.taxOfficeRegions[kppRegion] {
+			if _, ok := r.taxOffices[office]; !ok {
+				add(RuleKPPTaxOfficeUnknown, "КПП", requisiteSeverityLow, kpp,
+					fmt.Sprintf("Код налогового органа %s отсутствует в справочнике", office))
+			}
+		}
+	}
+
+	if bin != "" && ValidateBIN(bin) && !validateBINStructure(bin) {
+		add(RuleBINInvalidStructure, "БИН", requisiteSeverityMedium, bin,
+			"БИН содержит некорректный месяц регистрации или тип юридического лица")
+	}
+
+	if strings.HasPrefix(account, "KZ") {
+		violations = append(violations, r.checkKZAccount(bik, account)...)
+		return violations
+	}
+
+	if bik == "" || !ValidateBIK(bik) {
+		return violations
+	}
+
+	bank, bankKnown := r.banks[bik]
+	if r.banksComplete && !bankKnown {
+		add(RuleBIKUnknown, "БИК", requisiteSeverityHigh, bik, "БИК отсутствует в справочнике участников расчетов")
+	}
+	if bankKnown && bank.Status != "" && bank.Status != database.BankStatusActive {
+		add(RuleBIKInactive, "БИК", requisiteSeverityHigh, bik,
+			fmt.Sprintf("Участник расчетов %s исключен из справочника БИК (%s)", bank.Name, bank.Status))
+	}
+
+	if corrAccount != "" && ValidateCorrespondentAccount(corrAccount) {
+		switch {
+		case bankKnown && bank.CorrAccount != "" && bank.CorrAccount != corrAccount:
+			add(RuleCorrAccountMismatch, "КоррСчет", requisiteSeverityHigh, corrAccount,
+				fmt.Sprintf("Корреспондентский счет не совпадает со справочником БИК (%s)", bank.CorrAccount))
+		case !strings.HasPrefix(corrAccount, correspondentAccountPrefix) || corrAccount[17:] != bik[6:]:
+			add(RuleCorrAccountMismatch, "КоррСчет", requisiteSeverityHigh, corrAccount,
+				"Корреспондентский счет должен начинаться с 30101 и оканчиваться тремя последними цифрами БИК")
+		case !ValidateCorrespondentAccountKey(bik, corrAccount):
+			add(RuleCorrAccountKeyInvalid, "КоррСчет", requisiteSeverityHigh, corrAccount,
+				"Контрольный ключ корреспондентского счета не соответствует БИК")
+		}
+	}
+
+	if account != "" && ValidateBankAccount(account) && !ValidateAccountKey(bik, account) {
+		add(RuleAccountKeyInvalid, "РасчетныйСчет", requisiteSeverityHigh, account,
+			"Контрольный ключ расчетного счета не соответствует БИК")
+	}
+
+	return violations
+}
+
+// checkKZAccount проверяет казахстанский IBAN и его соответствие BIC банка
+func (r *RequisitesRegistry) checkKZAccount(bic, iban string) []RequisiteViolation {
+	var violations []RequisiteViolation
+	if !ValidateKZIBAN(iban) {
+		return append(violations, RequisiteViolation{
+			Rule:     RuleIBANChecksumInvalid,
+			Field:    "РасчетныйСчет",
+			Severity: requisiteSeverityHigh,
+			Value:    iban,
+			Message:  "Неверная контрольная сумма IBAN",
+		})
+	}
+
+	bankCode := iban[4:7]
+	bank, ok := r.kzBankCodes[bankCode]
+	if !ok {
+		if r.banksComplete {
+			violations = append(violations, RequisiteViolation{
+				Rule:     RuleIBANBankUnknown,
+				Field:    "РасчетныйСчет",
+				Severity: requisiteSeverityMedium,
+				Value:    iban,
+				Message:  fmt.Sprintf("Код банка %s в IBAN отсутствует в справочнике", bankCode),
+			})
+		}
+		return violations
+	}
+
+	if bic != "" && !strings.HasPrefix(bic, bank.BIK) {
+		violations = append(violations, RequisiteViolation{
+			Rule:     RuleIBANBankMismatch,
+			Field:    "БИК",
+			Severity: requisiteSeverityHigh,
+			Value:    bic,
+			Message:  fmt.Sprintf("BIC %s не соответствует банку счета %s (%s)", bic, bank.Name, bank.BIK),
+		})
+	}
+	return violations
+}
+
+// ValidateAccountKey проверяет контрольный ключ расчетного счета по БИК (алгоритм ЦБ РФ)
+func ValidateAccountKey(bik, account string) bool {
+	bik = cleanRequisite(bik)
+	account = cleanRequisite(account)
+	if !ValidateBIK(bik) || !ValidateBankAccount(account) {
+		return false
+	}
+
+	// Для счетов, открытых в подразделениях Банка России, вместо условного номера кредитной организации
+	// используется "0" и 5-6 разряды БИК
+	prefix := bik[6:]
+	if prefix == "000" || prefix == "001" || prefix == "002" {
+		prefix = "0" + bik[4:6]
+	}
+	return checkAccountControlKey(prefix + account)
+}
+
+// ValidateCorrespondentAccountKey проверяет контрольный ключ корреспондентского счета по БИК
+func ValidateCorrespondentAccountKey(bik, correspondentAccount string) bool {
+	bik = cleanRequisite(bik)
+	correspondentAccount = cleanRequisite(correspondentAccount)
+	if !ValidateBIK(bik) || !ValidateCorrespondentAccount(correspondentAccount) {
+		return false
+	}
+	return checkAccountControlKey("0" + bik[4:6] + correspondentAccount)
+}
+
+// checkAccountControlKey проверяет сумму разрядов с весами 7,1,3 для 23-значной строки
+func checkAccountControlKey(digits string) bool {
+	weights := []int{7, 1, 3}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		sum += int(digits[i]-'0') * weights[i%3]
+	}
+	return sum%10 == 0
+}
+
+// ValidateKZIBAN проверяет казахстанский IBAN (KZ + 18 символов) по контрольной сумме ISO 13616
+func ValidateKZIBAN(iban string) bool {
+	iban = strings.ToUpper(cleanRequisite(iban))
+	if len(iban) != 20 || !strings.HasPrefix(iban, "KZ") {
+		return false
+	}
+
+	rearranged := iban[4:] + iban[:4]
+	var numeric strings.Builder
+	for _, ch := range rearranged {
+		switch {
+		case ch >= '0' && ch <= '9':
+			numeric.WriteRune(ch)
+		case ch >= 'A' && ch <= 'Z':
+			numeric.WriteString(fmt.Sprintf("%d", ch-'A'+10))
+		default:
+			return false
+		}
+	}
+
+	value, ok := new(big.Int).SetString(numeric.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
+
+// validateBINStructure проверяет структуру БИН: YYMM регистрации, тип (4 - резидент, 5 - нерезидент,
+// 6 - ИП совместного предпринимательства) и признак (0-4)
+func validateBINStructure(bin string) bool {
+	month := int(bin[2]-'0')*10 + int(bin[3]-'0')
+	if month < 1 || month > 12 {
+		return false
+	}
+	if bin[4] < '4' || bin[4] > '6' {
+		return false
+	}
+	return bin[5] >= '0' && bin[5] <= '4'
+}
+
+// cleanRequisite убирает пробелы и дефисы из реквизита
+func cleanRequisite(value string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(value), " ", ""), "-", "")
+}
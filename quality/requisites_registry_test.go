@@ -0,0 +1,152 @@
+package quality
+
+import (
+	"testing"
+
+	"httpserver/database"
+)
+
+func hasRule(violations []RequisiteViolation, rule string) bool {
+	for _, v := range violations {
+		if v.Rule == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRequisitesRegistry_BundledDataConsistent(t *testing.T) {
+	data, err := database.BundledRequisiteRegistries()
+	if err != nil {
+		t.Fatalf("BundledRequisiteRegistries: %v", err)
+	}
+	if len(data.Regions) == 0 || len(data.KPPReasons) == 0 || len(data.Banks) == 0 {
+		t.Fatalf("bundled registries are empty: %d regions, %d reasons, %d banks", len(data.Regions), len(data.KPPReasons), len(data.Banks))
+	}
+	if data.BanksComplete {
+		t.Error("bundled bank directory must be marked as partial")
+	}
+
+	for _, bank := range data.Banks {
+		if bank.Country != "RU" {
+			continue
+		}
+		if !ValidateCorrespondentAccountKey(bank.BIK, bank.CorrAccount) {
+			t.Errorf("bundled correspondent account %s fails key check for BIK %s", bank.CorrAccount, bank.BIK)
+		}
+	}
+}
+
+func TestRequisitesRegistry_Check(t *testing.T) {
+	registry := DefaultRequisitesRegistry()
+
+	tests := []struct {
+		name string
+		req  CounterpartyRequisites
+		want []string
+	}{
+		{
+			name: "consistent requisites",
+			req: CounterpartyRequisites{
+				INN:                  "7707083893",
+				KPP:                  "773601001",
+				BIK:                  "044525225",
+				BankAccount:          "40702810200000000001",
+				CorrespondentAccount: "30101810400000000225",
+			},
+		},
+		{
+			name: "KPP region differs from INN region",
+			req:  CounterpartyRequisites{INN: "7707083893", KPP: "500101001"},
+			want: []string{RuleKPPRegionMismatch},
+		},
+		{
+			name: "branch KPP may be in another region",
+			req:  CounterpartyRequisites{INN: "7707083893", KPP: "500143001"},
+		},
+		{
+			name: "largest taxpayer KPP",
+			req:  CounterpartyRequisites{INN: "7707083893", KPP: "997750001"},
+		},
+		{
+			name: "unknown KPP region",
+			req:  CounterpartyRequisites{INN: "7707083893", KPP: "800143001"},
+			want: []string{RuleKPPRegionUnknown},
+		},
+		{
+			name: "correspondent account of another bank",
+			req:  CounterpartyRequisites{BIK: "044525225", CorrespondentAccount: "30101810700000000187"},
+			want: []string{RuleCorrAccountMismatch},
+		},
+		{
+			name: "account key does not match BIK",
+			req:  CounterpartyRequisites{BIK: "044525225", BankAccount: "40702810100000000001"},
+			want: []string{RuleAccountKeyInvalid},
+		},
+		{
+			name: "unknown BIK with partial directory is not a violation",
+			req:  CounterpartyRequisites{BIK: "044525999"},
+		},
+		{
+			name: "BIN with invalid month",
+			req:  CounterpartyRequisites{BIN: "041340001236"},
+			want: []string{RuleBINInvalidStructure},
+		},
+		{
+			name: "valid BIN",
+			req:  CounterpartyRequisites{BIN: "040440001237"},
+		},
+		{
+			name: "Kazakh IBAN matches BIC",
+			req:  CounterpartyRequisites{BIK: "HSBKKZKX", BankAccount: "KZ31601A000000000001"},
+		},
+		{
+			name: "Kazakh IBAN of another bank",
+			req:  CounterpartyRequisites{BIK: "HSBKKZKX", BankAccount: "KZ527220000000000000"},
+			want: []string{RuleIBANBankMismatch},
+		},
+		{
+			name: "Kazakh IBAN with broken checksum",
+			req:  CounterpartyRequisites{BankAccount: "KZ32601A000000000001"},
+			want: []string{RuleIBANChecksumInvalid},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := registry.Check(tt.req)
+			if len(violations) != len(tt.want) {
+				t.Fatalf("Check() = %+v, want rules %v", violations, tt.want)
+			}
+			for _, rule := range tt.want {
+				if !hasRule(violations, rule) {
+					t.Errorf("Check() = %+v, want rule %s", violations, rule)
+				}
+			}
+		})
+	}
+}
+
+func TestRequisitesRegistry_CompleteDirectory(t *testing.T) {
+	registry := NewRequisitesRegistry(&database.RequisiteRegistryData{
+		BanksComplete: true,
+		Banks: []database.BankDirectoryEntry{
+			{BIK: "044525225", Country: "RU", CorrAccount: "30101810400000000225", Status: database.BankStatusActive},
+			{BIK: "044525187", Country: "RU", CorrAccount: "30101810700000000187", Status: database.BankStatusRevoked},
+		},
+		TaxOffices: []database.TaxOfficeEntry{{Code: "7736", RegionCode: "77"}},
+	})
+
+	if v := registry.Check(CounterpartyRequisites{BIK: "044525999"}); !hasRule(v, RuleBIKUnknown) {
+		t.Errorf("expected %s for BIK missing from complete directory, got %+v", RuleBIKUnknown, v)
+	}
+	if v := registry.Check(CounterpartyRequisites{BIK: "044525187"}); !hasRule(v, RuleBIKInactive) {
+		t.Errorf("expected %s for revoked bank, got %+v", RuleBIKInactive, v)
+	}
+	if v := registry.Check(CounterpartyRequisites{KPP: "773401001"}); !hasRule(v, RuleKPPTaxOfficeUnknown) {
+		t.Errorf("expected %s for unknown tax office, got %+v", RuleKPPTaxOfficeUnknown, v)
+	}
+	if v := registry.Check(CounterpartyRequisites{KPP: "500101001"}); len(v) != 0 {
+		t.Errorf("tax offices of a region without loaded directory must not be checked, got %+v", v)
+	}
+}
@@ -0,0 +1,153 @@
+package quality
+
+import "math"
+
+// Детекторы изменения уровня метрики
+const (
+	TrendDetectorEWMA  = "ewma"  // резкий скачок за пределы контрольной полосы EWMA
+	TrendDetectorCUSUM = "cusum" // накопленный дрейф, каждое значение которого в пределах полосы
+)
+
+// Направление изменения метрики
+const (
+	TrendDirectionUp   = "up"
+	TrendDirectionDown = "down"
+)
+
+// TrendDetectorConfig параметры обнаружения изменений. Отклонения измеряются в сигмах базовой линии.
+type TrendDetectorConfig struct {
+	Alpha          float64 // вес нового значения в EWMA среднего и дисперсии
+	EWMAThreshold  float64 // отклонение одного значения, считающееся скачком
+	CUSUMSlack     float64 // допуск k: отклонения меньше k не накапливаются
+	CUSUMThreshold float64 // порог h накопленной суммы CUSUM
+	WarmupSamples  int     // значений для начальной базовой линии, до них сигналов нет
+	MinSigma       float64 // нижняя граница сигмы для почти постоянных рядов
+	RelativeSigma  float64 // нижняя граница сигмы как доля от среднего
+}
+
+// DefaultTrendDetectorConfig параметры для метрик качества в процентах (0-100)
+func DefaultTrendDetectorConfig() TrendDetectorConfig {
+	return TrendDetectorConfig{
+		Alpha:          0.1,
+		EWMAThreshold:  3,
+		CUSUMSlack:     0.5,
+		CUSUMThreshold: 5,
+		WarmupSamples:  5,
+		MinSigma:       1,
+	}
+}
+
+// TrendBaseline базовая линия метрики: EWMA среднего и дисперсии и накопленные суммы CUSUM
+type TrendBaseline struct {
+	Mean      float64 `json:"mean"`
+	Variance  float64 `json:"variance"`
+	CUSUMHigh float64 `json:"cusum_high"`
+	CUSUMLow  float64 `json:"cusum_low"`
+	Samples   int     `json:"samples"`
+}
+
+// TrendSignal обнаруженное изменение уровня метрики
+type TrendSignal struct {
+	Detector  string  `json:"detector"`
+	Direction string  `json:"direction"`
+	Observed  float64 `json:"observed"`
+	Expected  float64 `json:"expected"`  // среднее базовой линии до наблюдения
+	Sigma     float64 `json:"sigma"`     // сигма базовой линии до наблюдения
+	Deviation float64 `json:"deviation"` // (observed - expected) / sigma
+}
+
+// TrendDetector обнаруживает резкие скачки (EWMA) и постепенный дрейф (CUSUM) метрики.
+// Сезонность не учитывается: базовая линия следует за последними значениями.
+type TrendDetector struct {
+	cfg TrendDetectorConfig
+}
+
+// NewTrendDetector создает детектор; нулевые параметры заменяются значениями по умолчанию
+func NewTrendDetector(cfg TrendDetectorConfig) *TrendDetector {
+	defaults := DefaultTrendDetectorConfig()
+	if cfg.Alpha <= 0 || cfg.Alpha >= 1 {
+		cfg.Alpha = defaults.Alpha
+	}
+	if cfg.EWMAThreshold <= 0 {
+		cfg.EWMAThreshold = defaults.EWMAThreshold
+	}
+	if cfg.CUSUMSlack <= 0 {
+		cfg.CUSUMSlack = defaults.CUSUMSlack
+	}
+	if cfg.CUSUMThreshold <= 0 {
+		cfg.CUSUMThreshold = defaults.CUSUMThreshold
+	}
+	if cfg.WarmupSamples <= 0 {
+		cfg.WarmupSamples = defaults.WarmupSamples
+	}
+	if cfg.MinSigma <= 0 {
+		cfg.MinSigma = defaults.MinSigma
+	}
+	return &TrendDetector{cfg: cfg}
+}
+
+// Config возвращает параметры детектора
+func (d *TrendDetector) Config() TrendDetectorConfig {
+	return d.cfg
+}
+
+// Sigma возвращает сигму базовой линии с учетом нижних границ
+func (d *TrendDetector) Sigma(b TrendBaseline) float64 {
+	sigma := math.Sqrt(b.Variance)
+	if floor := d.cfg.RelativeSigma * math.Abs(b.Mean); sigma < floor {
+		sigma = floor
+	}
+	if sigma < d.cfg.MinSigma {
+		sigma = d.cfg.MinSigma
+	}
+	return sigma
+}
+
+// Observe обновляет базовую линию значением и возвращает сигнал, если уровень метрики изменился.
+// После скачка базовая линия продолжает адаптироваться, поэтому устойчивый новый уровень
+// со временем перестает считаться аномалией; накопленные суммы CUSUM сбрасываются после сигнала.
+func (d *TrendDetector) Observe(b *TrendBaseline, value float64) *TrendSignal {
+	if b.Samples < d.cfg.WarmupSamples {
+		// Начальная базовая линия — обычные среднее и дисперсия первых значений
+		b.Samples++
+		delta := value - b.Mean
+		b.Mean += delta / float64(b.Samples)
+		b.Variance += (delta*(value-b.Mean) - b.Variance) / float64(b.Samples)
+		return nil
+	}
+
+	expected := b.Mean
+	sigma := d.Sigma(*b)
+	z := (value - expected) / sigma
+
+	var signal *TrendSignal
+	b.CUSUMHigh = math.Max(0, b.CUSUMHigh+z-d.cfg.CUSUMSlack)
+	b.CUSUMLow = math.Max(0, b.CUSUMLow-z-d.cfg.CUSUMSlack)
+	switch {
+	case math.Abs(z) >= d.cfg.EWMAThreshold:
+		signal = &TrendSignal{Detector: TrendDetectorEWMA, Direction: TrendDirectionUp}
+		if z < 0 {
+			signal.Direction = TrendDirectionDown
+		}
+	case b.CUSUMHigh > d.cfg.CUSUMThreshold:
+		signal = &TrendSignal{Detector: TrendDetectorCUSUM, Direction: TrendDirectionUp}
+	case b.CUSUMLow > d.cfg.CUSUMThreshold:
+		// Направление дрейфа задает накопленная сумма, а не последнее значение
+		signal = &TrendSignal{Detector: TrendDetectorCUSUM, Direction: TrendDirectionDown}
+	}
+	if signal != nil {
+		b.CUSUMHigh, b.CUSUMLow = 0, 0
+		signal.Observed = value
+		signal.Expected = expected
+		signal.Sigma = sigma
+		signal.Deviation = (value - expected) / sigma
+	}
+
+	// EWMA среднего и дисперсии
+	delta := value - b.Mean
+	increment := d.cfg.Alpha * delta
+	b.Mean += increment
+	b.Variance = (1 - d.cfg.Alpha) * (b.Variance + delta*increment)
+	b.Samples++
+	return signal
+}
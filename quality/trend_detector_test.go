@@ -0,0 +1,83 @@
+package quality
+
+import "testing"
+
+// stableSeries значения полноты около 95% с небольшим шумом
+var stableSeries = []float64{95.2, 94.8, 95.1, 94.9, 95.0, 95.3, 94.7, 95.1, 94.9, 95.2}
+
+func TestTrendDetector_StableSeries(t *testing.T) {
+	detector := NewTrendDetector(DefaultTrendDetectorConfig())
+	var baseline TrendBaseline
+	for i, v := range stableSeries {
+		if signal := detector.Observe(&baseline, v); signal != nil {
+			t.Fatalf("value %d (%.1f): unexpected signal %+v", i, v, signal)
+		}
+	}
+	if baseline.Samples != len(stableSeries) {
+		t.Errorf("Samples = %d, want %d", baseline.Samples, len(stableSeries))
+	}
+	if baseline.Mean < 94.5 || baseline.Mean > 95.5 {
+		t.Errorf("Mean = %.2f, want about 95", baseline.Mean)
+	}
+}
+
+func TestTrendDetector_SuddenDrop(t *testing.T) {
+	detector := NewTrendDetector(DefaultTrendDetectorConfig())
+	var baseline TrendBaseline
+	for _, v := range stableSeries {
+		detector.Observe(&baseline, v)
+	}
+
+	// Полнота упала после обновления конфигурации 1С
+	signal := detector.Observe(&baseline, 78)
+	if signal == nil {
+		t.Fatal("expected signal for drop 95 -> 78")
+	}
+	if signal.Detector != TrendDetectorEWMA || signal.Direction != TrendDirectionDown {
+		t.Errorf("signal = %s/%s, want ewma/down", signal.Detector, signal.Direction)
+	}
+	if signal.Deviation > -10 || signal.Expected < 94.5 {
+		t.Errorf("Deviation = %.1f, Expected = %.1f", signal.Deviation, signal.Expected)
+	}
+	if baseline.CUSUMHigh != 0 || baseline.CUSUMLow != 0 {
+		t.Errorf("CUSUM sums not reset after signal: %+v", baseline)
+	}
+}
+
+func TestTrendDetector_GradualDrift(t *testing.T) {
+	detector := NewTrendDetector(DefaultTrendDetectorConfig())
+	var baseline TrendBaseline
+	for _, v := range stableSeries {
+		detector.Observe(&baseline, v)
+	}
+
+	// Медленный дрейф: ни одно значение не выходит за полосу EWMA
+	var signal *TrendSignal
+	value := 95.0
+	for i := 0; i < 20 && signal == nil; i++ {
+		value -= 0.4
+		signal = detector.Observe(&baseline, value)
+		if signal != nil && signal.Detector == TrendDetectorEWMA {
+			t.Fatalf("step %d: drift detected as jump: %+v", i, signal)
+		}
+	}
+	if signal == nil {
+		t.Fatal("expected CUSUM signal for gradual drift")
+	}
+	if signal.Direction != TrendDirectionDown {
+		t.Errorf("Direction = %s, want down", signal.Direction)
+	}
+}
+
+func TestTrendDetector_Warmup(t *testing.T) {
+	detector := NewTrendDetector(TrendDetectorConfig{WarmupSamples: 3})
+	var baseline TrendBaseline
+	for _, v := range []float64{10, 90, 10} {
+		if signal := detector.Observe(&baseline, v); signal != nil {
+			t.Fatalf("signal during warmup: %+v", signal)
+		}
+	}
+	if baseline.Mean < 36.6 || baseline.Mean > 36.7 {
+		t.Errorf("warmup Mean = %.2f, want 36.67", baseline.Mean)
+	}
+}
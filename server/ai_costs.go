@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"httpserver/internal/config"
+	"httpserver/nomenclature"
+	"httpserver/server/services"
+)
+
+// clientSpendLimits возвращает лимиты расходов на AI из overlay клиента
+func (s *Server) clientSpendLimits(clientID int) *config.ClientSpendLimits {
+	if s.clientConfigService == nil {
+		return nil
+	}
+	overlay, err := s.clientConfigService.GetOverlay(clientID)
+	if err != nil {
+		return nil
+	}
+	return overlay.SpendLimits
+}
+
+// onAISoftBudgetExceeded уведомляет о превышении мягкого бюджета на AI
+func (s *Server) onAISoftBudgetExceeded(status *services.AIBudgetStatus) {
+	s.notifyAIBudget(status, services.NotificationTypeWarning, "Превышен мягкий бюджет на AI",
+		fmt.Sprintf("Затраты %.2f из %.2f %s", status.Spent, status.Budget.SoftLimit, status.Budget.Currency))
+}
+
+// onAIHardBudgetExceeded останавливает работу, оплачиваемую исчерпанным жестким бюджетом.
+// Глобальный бюджет останавливает КПВЭД воркеры и нормализацию до начала следующего периода.
+// Бюджет клиента или проекта останавливает только нормализацию этого клиента или проекта;
+// остальные клиенты продолжают работу. Новые вызовы AI в рамках бюджета отклоняются
+// AICostService до начала следующего периода.
+func (s *Server) onAIHardBudgetExceeded(status *services.AIBudgetStatus) {
+	var stopped bool
+	var message string
+	switch status.Budget.Scope {
+	case services.AIBudgetScopeGlobal:
+		s.kpvedWorkersStopMutex.Lock()
+		s.kpvedWorkersStopped = true
+		s.kpvedStoppedByAIBudget = true
+		s.kpvedWorkersStopMutex.Unlock()
+		s.scheduleAIBudgetResume(status)
+
+		s.normalizerMutex.Lock()
+		stopped = s.normalizerRunning
+		s.normalizerRunning = false
+		s.normalizerMutex.Unlock()
+		if s.normalizationService != nil {
+			s.normalizationService.Stop()
+		}
+		message = fmt.Sprintf("Затраты %.2f из %.2f %s, КПВЭД воркеры и нормализация приостановлены",
+			status.Spent, status.Budget.HardLimit, status.Budget.Currency)
+	default:
+		stopped = s.stopNormalizationForAIBudget(status.Budget.Scope, status.Budget.ScopeID)
+		message = fmt.Sprintf("Затраты %.2f из %.2f %s, вызовы AI и нормализация %s %d приостановлены",
+			status.Spent, status.Budget.HardLimit, status.Budget.Currency, aiBudgetScopeName(status.Budget.Scope), status.Budget.ScopeID)
+	}
+
+	log.Printf("[AICost] %s budget %d (%s) exhausted: %s", status.Budget.Scope, status.Budget.ScopeID, status.Budget.Period, message)
+	if stopped {
+		select {
+		case s.normalizerEvents <- "Нормализация остановлена: исчерпан бюджет на AI":
+		default:
+		}
+	}
+	s.notifyAIBudget(status, services.NotificationTypeError, "Исчерпан бюджет на AI", message)
+}
+
+// stopNormalizationForAIBudget останавливает нормализацию проекта, если она запущена для клиента
+// или проекта с исчерпанным бюджетом. Возвращает true, если нормализация была остановлена
+func (s *Server) stopNormalizationForAIBudget(scope string, scopeID int) bool {
+	s.normalizerMutex.Lock()
+	matches := s.normalizerRunning &&
+		((scope == services.AIBudgetScopeClient && s.normalizerClientID == scopeID) ||
+			(scope == services.AIBudgetScopeProject && s.normalizerProjectID == scopeID))
+	if !matches {
+		s.normalizerMutex.Unlock()
+		return false
+	}
+	projectID := s.normalizerProjectID
+	if s.normalizerCancel != nil {
+		s.normalizerCancel()
+		s.normalizerCancel = nil
+	}
+	s.normalizerRunning = false
+	s.normalizerMutex.Unlock()
+
+	s.stopProjectNormalizationSessions(projectID)
+	return true
+}
+
+// scheduleAIBudgetResume возобновляет КПВЭД воркеры, остановленные глобальным бюджетом,
+// с началом следующего периода бюджета
+func (s *Server) scheduleAIBudgetResume(status *services.AIBudgetStatus) {
+	periodEnd, err := time.ParseInLocation("2006-01-02", status.PeriodEnd, time.Local)
+	if err != nil {
+		return
+	}
+	time.AfterFunc(time.Until(periodEnd.AddDate(0, 0, 1)), s.resumeAfterAIBudgetPeriod)
+}
+
+// resumeAfterAIBudgetPeriod снимает остановку КПВЭД воркеров, выставленную исчерпанием глобального
+// бюджета, если ни один глобальный бюджет больше не исчерпан. Остановка пользователем не снимается
+func (s *Server) resumeAfterAIBudgetPeriod() {
+	if s.aiCostService != nil && s.aiCostService.IsBlocked(nomenclature.UsageTags{}) {
+		return
+	}
+	s.kpvedWorkersStopMutex.Lock()
+	defer s.kpvedWorkersStopMutex.Unlock()
+	if !s.kpvedStoppedByAIBudget {
+		return
+	}
+	s.kpvedWorkersStopped = false
+	s.kpvedStoppedByAIBudget = false
+	log.Printf("[AICost] new budget period started, KPVED workers resumed")
+}
+
+// aiBudgetScopeName возвращает название области бюджета для сообщений
+func aiBudgetScopeName(scope string) string {
+	if scope == services.AIBudgetScopeProject {
+		return "проекта"
+	}
+	return "клиента"
+}
+
+// notifyAIBudget отправляет уведомление о состоянии бюджета
+func (s *Server) notifyAIBudget(status *services.AIBudgetStatus, notificationType services.NotificationType, title, message string) {
+	if s.notificationService == nil {
+		return
+	}
+	var clientID, projectID *int
+	switch status.Budget.Scope {
+	case services.AIBudgetScopeClient:
+		id := status.Budget.ScopeID
+		clientID = &id
+	case services.AIBudgetScopeProject:
+		id := status.Budget.ScopeID
+		projectID = &id
+	}
+	metadata := map[string]interface{}{
+		"scope":        status.Budget.Scope,
+		"scope_id":     status.Budget.ScopeID,
+		"period":       status.Budget.Period,
+		"period_start": status.PeriodStart,
+		"spent":        status.Spent,
+		"source":       status.Source,
+	}
+	if _, err := s.notificationService.AddNotification(context.Background(), notificationType, title, message, clientID, projectID, metadata); err != nil {
+		log.Printf("[AICost] failed to add budget notification: %v", err)
+	}
+}
+
+// providerMetrics возвращает метрики оркестратора провайдеров
+func (s *Server) providerMetrics() map[string]interface{} {
+	if s.providerOrchestrator == nil {
+		return nil
+	}
+	return s.providerOrchestrator.GetMetrics()
+}
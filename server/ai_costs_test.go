@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// TestOnAIHardBudgetExceeded_Scope проверяет, что бюджет клиента или проекта останавливает
+// только его нормализацию, а глобальный бюджет - КПВЭД воркеры до начала нового периода
+func TestOnAIHardBudgetExceeded_Scope(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Server{
+		normalizerEvents:    make(chan string, 10),
+		normalizerRunning:   true,
+		normalizerClientID:  1,
+		normalizerProjectID: 2,
+		normalizerCtx:       ctx,
+		normalizerCancel:    cancel,
+	}
+	exceeded := func(scope string, scopeID int) *services.AIBudgetStatus {
+		return &services.AIBudgetStatus{
+			Budget:       &database.AIBudget{Scope: scope, ScopeID: scopeID, Period: services.AIBudgetPeriodDaily, HardLimit: 10},
+			PeriodStart:  "2026-10-18",
+			PeriodEnd:    "2026-10-18",
+			Spent:        11,
+			HardExceeded: true,
+		}
+	}
+
+	// Бюджет другого проекта и другого клиента не останавливает нормализацию
+	s.onAIHardBudgetExceeded(exceeded(services.AIBudgetScopeProject, 3))
+	s.onAIHardBudgetExceeded(exceeded(services.AIBudgetScopeClient, 5))
+	if !s.normalizerRunning || ctx.Err() != nil {
+		t.Fatal("normalization of another client was stopped")
+	}
+	if s.kpvedWorkersStopped {
+		t.Fatal("client budget stopped KPVED workers")
+	}
+
+	s.onAIHardBudgetExceeded(exceeded(services.AIBudgetScopeProject, 2))
+	if s.normalizerRunning || ctx.Err() == nil {
+		t.Error("project budget did not stop project normalization")
+	}
+	if s.kpvedWorkersStopped {
+		t.Error("project budget stopped KPVED workers")
+	}
+
+	s.onAIHardBudgetExceeded(exceeded(services.AIBudgetScopeGlobal, 0))
+	if !s.kpvedWorkersStopped {
+		t.Fatal("global budget did not stop KPVED workers")
+	}
+	s.resumeAfterAIBudgetPeriod()
+	if s.kpvedWorkersStopped {
+		t.Error("KPVED workers were not resumed in a new period")
+	}
+
+	// Остановка пользователем не снимается с началом периода
+	s.kpvedWorkersStopped = true
+	s.resumeAfterAIBudgetPeriod()
+	if !s.kpvedWorkersStopped {
+		t.Error("user stop was reset by budget period")
+	}
+}
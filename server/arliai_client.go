@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"httpserver/nomenclature"
 )
 
 // ArliaiClient клиент для работы с Arliai API
@@ -298,7 +300,11 @@ func (c *ArliaiClient) GetModels(ctx context.Context, requestID string, queryPar
 		// Детальная обработка различных HTTP статусов
 		if resp.StatusCode == http.StatusTooManyRequests {
 			lastErr = fmt.Errorf("rate limit exceeded (429)")
-			log.Printf("[%s] Rate limit exceeded (429), will retry", requestID)
+			// Следующая попытка не раньше, чем разрешил провайдер
+			if signal := nomenclature.ParseQuotaHeaders(resp.Header, time.Now()); signal.RetryAfter > delay {
+				delay = signal.RetryAfter
+			}
+			log.Printf("[%s] Rate limit exceeded (429), will retry after %v", requestID, delay)
 			continue
 		} else if resp.StatusCode == http.StatusUnauthorized {
 			lastErr = fmt.Errorf("unauthorized (401): invalid API key")
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"httpserver/internal/config"
+	"httpserver/server/middleware"
+	"httpserver/server/services"
+)
+
+// beginAudit передает сведения о запросе из middleware в сервис аудита
+func (s *Server) beginAudit(req *middleware.AuditRequest) func(statusCode int) {
+	return s.auditService.Begin(&services.AuditRequest{
+		Method:    req.Method,
+		Path:      req.Path,
+		Actor:     req.Actor,
+		APIKey:    req.APIKey,
+		Role:      req.Role,
+		RequestID: req.RequestID,
+		ClientIP:  req.ClientIP,
+		Reason:    req.Reason,
+		Body:      req.Body,
+	})
+}
+
+// auditIDs извлекает массив идентификаторов из тела запроса
+func auditIDs(body map[string]interface{}, key string) []int {
+	raw, _ := body[key].([]interface{})
+	ids := make([]int, 0, len(raw))
+	for _, v := range raw {
+		if n, ok := v.(json.Number); ok {
+			if id, err := strconv.Atoi(n.String()); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// auditString извлекает строковое поле из тела запроса
+func auditString(body map[string]interface{}, key string) string {
+	s, _ := body[key].(string)
+	return s
+}
+
+// auditFloat извлекает числовое поле из тела запроса
+func auditFloat(body map[string]interface{}, key string) float64 {
+	if n, ok := body[key].(json.Number); ok {
+		f, _ := n.Float64()
+		return f
+	}
+	return 0
+}
+
+// registerAuditRules регистрирует снимки состояния для операций, по которым
+// в журнале аудита нужны диффы «до/после». Остальные изменяющие запросы
+// записываются с телом запроса.
+func (s *Server) registerAuditRules() {
+	if s.auditService == nil {
+		return
+	}
+
+	// Контрагенты: правка, массовое обновление и удаление
+	if s.counterpartyService != nil {
+		counterpartySnapshot := func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			id, err := strconv.Atoi(in.Params["id"])
+			if err != nil {
+				return "", nil, err
+			}
+			cp, err := s.counterpartyService.GetNormalizedCounterparty(id)
+			if err != nil {
+				return in.Params["id"], nil, nil
+			}
+			return in.Params["id"], cp, nil
+		}
+		bulkSnapshot := func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			ids := auditIDs(in.Body, "ids")
+			state := make(map[string]interface{}, len(ids))
+			for _, id := range ids {
+				if cp, err := s.counterpartyService.GetNormalizedCounterparty(id); err == nil {
+					state[strconv.Itoa(id)] = cp
+				}
+			}
+			return fmt.Sprint(ids), state, nil
+		}
+		s.auditService.Register("PUT", "/api/counterparties/normalized/{id}", "counterparty", "update", counterpartySnapshot)
+		s.auditService.Register("POST", "/api/counterparties/bulk/update", "counterparty", "bulk_update", bulkSnapshot)
+		s.auditService.Register("POST", "/api/counterparties/bulk/delete", "counterparty", "bulk_delete", bulkSnapshot)
+	}
+
+	// Сброс классификации КПВЭД
+	if s.classificationService != nil {
+		s.auditService.Register("POST", "/api/kpved/reset", "kpved_classification", "reset", func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			resetAll, _ := in.Body["reset_all"].(bool)
+			state, err := s.classificationService.ClassificationResetSnapshot(auditString(in.Body, "normalized_name"),
+				auditString(in.Body, "category"), auditString(in.Body, "kpved_code"), auditFloat(in.Body, "min_confidence"), resetAll)
+			return auditString(in.Body, "kpved_code"), state, err
+		})
+		s.auditService.Register("POST", "/api/kpved/reset-all", "kpved_classification", "reset_all", func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			state, err := s.classificationService.ClassificationResetSnapshot("", "", "", 0, true)
+			return "", state, err
+		})
+		s.auditService.Register("POST", "/api/kpved/reset-by-code", "kpved_classification", "reset_by_code", func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			code := auditString(in.Body, "kpved_code")
+			state, err := s.classificationService.ClassificationResetSnapshot("", "", code, 0, false)
+			return code, state, err
+		})
+		s.auditService.Register("POST", "/api/kpved/reset-low-confidence", "kpved_classification", "reset_low_confidence", func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			maxConfidence := auditFloat(in.Body, "max_confidence")
+			if maxConfidence <= 0 {
+				maxConfidence = 0.7
+			}
+			state, err := s.classificationService.ClassificationResetSnapshot("", "", "", maxConfidence, false)
+			return "", state, err
+		})
+	}
+
+	// Конфигурация сервера (секреты маскируются сервисом аудита)
+	if s.serviceDB != nil {
+		configSnapshot := func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+			cfg, err := config.LoadConfig(s.serviceDB)
+			return "server", cfg, err
+		}
+		s.auditService.Register("PUT", "/api/config", "config", "update", configSnapshot)
+		s.auditService.Register("POST", "/api/config", "config", "update", configSnapshot)
+
+		// Удаление базы данных проекта
+		s.auditService.Register("DELETE", "/api/clients/{clientId}/projects/{projectId}/databases/{id}", "project_database", "delete",
+			func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+				id, err := strconv.Atoi(in.Params["id"])
+				if err != nil {
+					return "", nil, err
+				}
+				projectDB, err := s.serviceDB.GetProjectDatabase(id)
+				if err != nil {
+					return in.Params["id"], nil, err
+				}
+				return in.Params["id"], projectDB, nil
+			})
+	}
+
+	// Ручные переопределения атрибутов эталонных записей
+	if s.goldenRecordService != nil {
+		s.auditService.Register("", "/api/clients/{clientId}/projects/{projectId}/golden-records/{id}/attributes/{attribute}", "golden_record", "override",
+			func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+				projectID, _ := strconv.Atoi(in.Params["projectId"])
+				recordID, err := strconv.Atoi(in.Params["id"])
+				if err != nil {
+					return "", nil, err
+				}
+				details, err := s.goldenRecordService.GetRecord(projectID, recordID)
+				if err != nil {
+					return in.Params["id"], nil, nil
+				}
+				return in.Params["id"], details, nil
+			})
+	}
+
+	// Overlay конфигурации клиента
+	if s.clientConfigService != nil {
+		s.auditService.Register("", "/api/clients/{id}/config", "client_config", "update",
+			func(in *services.AuditSnapshotInput) (string, interface{}, error) {
+				clientID, err := strconv.Atoi(in.Params["id"])
+				if err != nil {
+					return "", nil, err
+				}
+				overlay, err := s.clientConfigService.GetOverlay(clientID)
+				if err != nil {
+					return in.Params["id"], nil, nil
+				}
+				return in.Params["id"], overlay, nil
+			})
+	}
+}
+
+// startAuditRetention периодически удаляет записи журнала аудита старше срока хранения
+func (s *Server) startAuditRetention() {
+	ticker := time.NewTicker(6 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := s.auditService.ApplyRetention(); err != nil {
+			log.Printf("[Audit] retention cleanup failed: %v", err)
+		} else if deleted > 0 {
+			log.Printf("[Audit] retention cleanup removed %d entries", deleted)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
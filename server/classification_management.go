@@ -394,6 +394,7 @@ func (s *Server) handleKpvedWorkersStop(w http.ResponseWriter, r *http.Request)
 
 	s.kpvedWorkersStopMutex.Lock()
 	s.kpvedWorkersStopped = true
+	s.kpvedStoppedByAIBudget = false
 	s.kpvedWorkersStopMutex.Unlock()
 
 	log.Printf("[KpvedWorkersStop] Workers stop flag set to true")
@@ -414,6 +415,7 @@ func (s *Server) handleKpvedWorkersResume(w http.ResponseWriter, r *http.Request
 
 	s.kpvedWorkersStopMutex.Lock()
 	s.kpvedWorkersStopped = false
+	s.kpvedStoppedByAIBudget = false
 	s.kpvedWorkersStopMutex.Unlock()
 
 	log.Printf("[KpvedWorkersResume] Workers stop flag set to false")
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"httpserver/internal/config"
+	"httpserver/normalization"
+	"httpserver/normalization/algorithms"
+	"httpserver/server/services"
+)
+
+// clientWorkerConfig возвращает конфигурацию модели и API ключа с учетом overlay клиента
+func (s *Server) clientWorkerConfig(clientID int) normalization.WorkerConfigManagerInterface {
+	if s.clientConfigService == nil {
+		if s.workerConfigManager == nil {
+			return nil
+		}
+		return s.workerConfigManager
+	}
+	if s.workerConfigManager == nil {
+		return services.NewClientWorkerConfig(s.clientConfigService, clientID, nil)
+	}
+	return services.NewClientWorkerConfig(s.clientConfigService, clientID, s.workerConfigManager)
+}
+
+// clientCacheNamespace возвращает пространство имен кэшей клиента
+func (s *Server) clientCacheNamespace(clientID int) string {
+	if s.clientConfigService == nil {
+		return ""
+	}
+	return s.clientConfigService.CacheNamespace(clientID)
+}
+
+// clientMaxWorkers ограничивает количество воркеров значением max_workers из overlay клиента
+func (s *Server) clientMaxWorkers(clientID, maxWorkers int) int {
+	if s.clientConfigService == nil {
+		return maxWorkers
+	}
+	return s.clientConfigService.MaxWorkers(clientID, maxWorkers)
+}
+
+// projectNERTagger загружает обученную модель NER проекта, а без нее глобальную (cmd/train_ner).
+// Если моделей нет, возвращает nil - атрибуты извлекаются только правилами
+func (s *Server) projectNERTagger(projectID int) algorithms.NERTagger {
+	modelDir := config.DefaultNERModelDir
+	if s.config != nil && s.config.NERModelDir != "" {
+		modelDir = s.config.NERModelDir
+	}
+	tagger, err := algorithms.LoadNERForProject(modelDir, projectID)
+	if err != nil {
+		log.Printf("[NER] Failed to load model for project %d: %v", projectID, err)
+		return nil
+	}
+	if !tagger.HasModel() {
+		return nil
+	}
+	return tagger
+}
+
+// resolveUploadClient определяет клиента выгрузки по базе upload_uuid (на /handshake - по database_id)
+func (s *Server) resolveUploadClient(uploadUUID, databaseID string) (int, bool) {
+	clientID, _, ok := s.resolveUploadClientProject(uploadUUID, databaseID)
+	return clientID, ok
+}
+
+// resolveUploadClientProject определяет клиента и проект выгрузки. Если указан upload_uuid,
+// используется сохраненная база выгрузки: обработчики пишут именно в нее. database_id
+// учитывается только без upload_uuid (/handshake), а при несовпадении с базой выгрузки
+// клиент не определяется.
+func (s *Server) resolveUploadClientProject(uploadUUID, databaseID string) (int, int, bool) {
+	if s.uploadService == nil {
+		return 0, 0, false
+	}
+
+	dbID := 0
+	if uploadUUID != "" {
+		upload, err := s.uploadService.GetUploadByUUID(uploadUUID)
+		if err != nil || upload == nil || upload.DatabaseID == nil {
+			return 0, 0, false
+		}
+		dbID = *upload.DatabaseID
+		if databaseID != "" && databaseID != strconv.Itoa(dbID) {
+			return 0, 0, false
+		}
+	} else if databaseID != "" {
+		parsed, err := strconv.Atoi(databaseID)
+		if err != nil {
+			return 0, 0, false
+		}
+		dbID = parsed
+	}
+	if dbID <= 0 {
+		return 0, 0, false
+	}
+
+	clientID, projectID, err := s.uploadService.GetClientProjectIDs(dbID)
+	if err != nil || clientID <= 0 {
+		return 0, 0, false
+	}
+	return clientID, projectID, true
+}
+
+// verifyUploadIdentifiers проверяет, что database_id из тела запроса совпадает с базой выгрузки
+func (s *Server) verifyUploadIdentifiers(uploadUUID, databaseID string) error {
+	if s.uploadService == nil {
+		return nil
+	}
+	upload, err := s.uploadService.GetUploadByUUID(uploadUUID)
+	if err != nil || upload == nil {
+		// Неизвестную выгрузку отклонит обработчик
+		return nil
+	}
+	if upload.DatabaseID == nil || strconv.Itoa(*upload.DatabaseID) != databaseID {
+		return fmt.Errorf("database_id %s не совпадает с базой выгрузки %s", databaseID, uploadUUID)
+	}
+	return nil
+}
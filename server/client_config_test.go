@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"httpserver/server/middleware"
+)
+
+// TestClientUploadRateLimit_ChargesUploadOwner проверяет, что лимит выгрузок списывается
+// с клиента базы upload_uuid, а не с клиента database_id из тела запроса
+func TestClientUploadRateLimit_ChargesUploadOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newUploadIdentityFixture(t)
+
+	var charged []int
+	allow := func(clientID int) (bool, time.Duration) {
+		charged = append(charged, clientID)
+		return true, 0
+	}
+	router := gin.New()
+	router.Use(middleware.GinUploadIdentityMiddleware(f.server.verifyUploadIdentifiers))
+	router.Use(middleware.GinClientUploadRateLimitMiddleware(f.server.resolveUploadClient, allow))
+	router.POST("/catalog/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/catalog/items", strings.NewReader(body)))
+		return w.Code
+	}
+
+	if code := post("<catalog_items><upload_uuid>upload-b</upload_uuid><items/></catalog_items>"); code != http.StatusOK {
+		t.Fatalf("own upload: code %d, want 200", code)
+	}
+	if len(charged) != 1 || charged[0] != f.clientB {
+		t.Errorf("charged clients = %v, want [%d]", charged, f.clientB)
+	}
+
+	// database_id клиента A не переносит лимит на A: запрос отклоняется до лимита
+	charged = nil
+	body := fmt.Sprintf("<catalog_items><database_id>%d</database_id><upload_uuid>upload-b</upload_uuid><items/></catalog_items>", f.databaseA)
+	if code := post(body); code != http.StatusForbidden {
+		t.Errorf("mismatched database_id: code %d, want 403", code)
+	}
+	// Без upload_uuid database_id вне /handshake не определяет клиента
+	if code := post(fmt.Sprintf("<catalog_items><database_id>%d</database_id><items/></catalog_items>", f.databaseA)); code != http.StatusOK {
+		t.Errorf("database_id only: code %d, want 200", code)
+	}
+	if len(charged) != 0 {
+		t.Errorf("charged clients = %v, want none", charged)
+	}
+}
@@ -3537,6 +3537,29 @@ func (s *Server) startProjectNormalization(clientID, projectID int, options map[
 		}
 	}
 
+	// config_id выбирает сохраненную конфигурацию нормализации: ее база используется вместо database_path
+	configID := 0
+	if val, ok := options["config_id"].(float64); ok {
+		configID = int(val)
+	} else if val, ok := options["config_id"].(int); ok {
+		configID = val
+	}
+	if configID > 0 {
+		normConfig, err := s.serviceDB.GetNormalizationConfigByID(configID)
+		if err != nil {
+			s.normalizerMutex.Unlock()
+			return err
+		}
+		if normConfig == nil {
+			s.normalizerMutex.Unlock()
+			return fmt.Errorf("normalization config %d not found", configID)
+		}
+		if normConfig.DatabasePath != "" && len(databaseIDs) == 0 {
+			databasePath = normConfig.DatabasePath
+			allActive = false
+		}
+	}
+
 	// Проверяем существование проекта
 	project, err := s.serviceDB.GetClientProject(projectID)
 	if err != nil {
@@ -3646,6 +3669,7 @@ func (s *Server) startProjectNormalization(clientID, projectID int, options map[
 	}
 	s.normalizerCtx, s.normalizerCancel = context.WithCancel(context.Background())
 	s.normalizerRunning = true
+	s.normalizerClientID, s.normalizerProjectID = clientID, projectID
 	s.normalizerMutex.Unlock()
 
 	// Используем структурированное логирование
@@ -3678,6 +3702,7 @@ func (s *Server) startProjectNormalization(clientID, projectID int, options map[
 			// Всегда сбрасываем флаг running при выходе
 			s.normalizerMutex.Lock()
 			s.normalizerRunning = false
+			s.normalizerClientID, s.normalizerProjectID = 0, 0
 			s.normalizerMutex.Unlock()
 			LogNormalizationComplete(clientID, projectID, s.normalizerProcessed, s.normalizerSuccess, s.normalizerErrors, time.Since(startTime))
 		}()
@@ -3833,7 +3858,25 @@ func (s *Server) processNomenclatureDatabase(ctx context.Context, projectDB *dat
 	}
 
 	// Создаем клиентский нормализатор
-	clientNormalizer := normalization.NewClientNormalizerWithConfig(clientID, projectID, sourceDB, s.serviceDB, s.normalizerEvents, s.workerConfigManager)
+	// События нормализатора публикуются с ID сессии
+	sessionEvents, stopSessionEvents := s.normalizationSessionEvents(clientID, projectID, sessionID)
+	defer stopSessionEvents()
+	clientNormalizer := normalization.NewClientNormalizerWithConfig(clientID, projectID, sourceDB, s.serviceDB, sessionEvents, s.clientWorkerConfig(clientID))
+	clientNormalizer.SetCacheNamespace(s.clientCacheNamespace(clientID))
+	var spellCorrector *normalization.SpellCorrector
+	if s.spellingService != nil {
+		// Словарь опечаток проекта; найденные исправления попадают в очередь проверки
+		spellCorrector = s.spellingService.Corrector(projectID)
+		clientNormalizer.SetSpellCorrector(spellCorrector)
+	}
+	if s.termDictionaryService != nil {
+		// Сокращения и синонимы проекта поверх общего словаря
+		clientNormalizer.SetTermDictionary(s.termDictionaryService.Dictionary(projectID))
+	}
+	if tagger := s.projectNERTagger(projectID); tagger != nil {
+		// Обученная модель NER проекта дополняет атрибуты, извлеченные правилами
+		clientNormalizer.SetNERTagger(tagger)
+	}
 
 	// Устанавливаем sessionID для нормализатора
 	clientNormalizer.SetSessionID(sessionID)
@@ -3871,6 +3914,11 @@ func (s *Server) processNomenclatureDatabase(ctx context.Context, projectDB *dat
 	activityTicker.Stop()
 	activityDone <- true
 	finishedAt := time.Now()
+	if s.spellingService != nil {
+		if flushErr := s.spellingService.FlushObserved(projectID, spellCorrector); flushErr != nil {
+			log.Printf("Failed to save spelling corrections for project %d: %v", projectID, flushErr)
+		}
+	}
 
 	// Проверяем, не была ли сессия остановлена
 	session, _ = s.serviceDB.GetNormalizationSession(sessionID)
@@ -2099,6 +2099,7 @@ func (s *Server) convertClientGroupsToNormalizedItems(
 				KpvedCode:           group.KpvedCode,
 				KpvedName:           group.KpvedName,
 				KpvedConfidence:     group.KpvedConfidence,
+				DecisionTrace:       group.Traces[item].Marshal(),
 			}
 
 			normalizedItems = append(normalizedItems, normalizedItem)
@@ -2112,5 +2113,10 @@ func (s *Server) convertClientGroupsToNormalizedItems(
 		}
 	}
 
+	// Имена по шаблонам разделов КПВЭД/ОКПД2 и категорий
+	if s.nameTemplateService != nil {
+		s.nameTemplateService.ApplyToItems(normalizedItems, itemAttributes)
+	}
+
 	return normalizedItems, itemAttributes
 }
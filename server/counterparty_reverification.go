@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"httpserver/database"
+	"httpserver/enrichment"
+	"httpserver/internal/config"
+	"httpserver/server/handlers"
+	"httpserver/server/services"
+)
+
+// reverificationConfig возвращает настройки повторной сверки из конфигурации обогащения
+func (s *Server) reverificationConfig() *config.ReverificationConfig {
+	if s.config == nil || s.config.Enrichment == nil {
+		return nil
+	}
+	return s.config.Enrichment.Reverification
+}
+
+// reverificationSchedulerEnabled сообщает, включен ли запуск сверки по расписанию
+func (s *Server) reverificationSchedulerEnabled() bool {
+	cfg := s.reverificationConfig()
+	return cfg != nil && cfg.Enabled
+}
+
+// setupCounterpartyReverification создает сервис и обработчик повторной сверки контрагентов.
+// Без фабрики обогатителей API доступно только для чтения журнала.
+func (s *Server) setupCounterpartyReverification(factory *enrichment.EnricherFactory, baseHandler *handlers.BaseHandler) {
+	opts := services.CounterpartyReverificationOptions{ApplyChanges: true}
+	if cfg := s.reverificationConfig(); cfg != nil {
+		opts = services.CounterpartyReverificationOptions{
+			MaxAge:         cfg.MaxAge,
+			HighRiskMaxAge: cfg.HighRiskMaxAge,
+			BatchSize:      cfg.BatchSize,
+			ApplyChanges:   cfg.ApplyChanges,
+		}
+	}
+
+	var enricher services.ReverificationEnricher
+	if factory != nil {
+		enricher = factory
+	}
+	s.reverificationService = services.NewCounterpartyReverificationService(s.serviceDB, enricher, opts)
+	s.reverificationService.SetAlertHandler(s.onCounterpartyReverificationAlert)
+	s.reverificationHandler = handlers.NewCounterpartyReverificationHandler(s.reverificationService, baseHandler, s.reverificationSchedulerEnabled)
+}
+
+// startCounterpartyReverification периодически сверяет контрагентов, срок сверки которых наступил
+func (s *Server) startCounterpartyReverification() {
+	interval := time.Hour
+	if cfg := s.reverificationConfig(); cfg != nil && cfg.Interval > 0 {
+		interval = cfg.Interval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.shutdownChan:
+			return
+		}
+
+		run, err := s.reverificationService.RunOnce()
+		if err != nil {
+			log.Printf("[Reverification] run failed: %v", err)
+			continue
+		}
+		if run.Checked > 0 {
+			log.Printf("[Reverification] checked %d counterparties: %d changed, %d status alerts, %d failed",
+				run.Checked, run.Changed, run.StatusAlerts, run.Failed)
+		}
+	}
+}
+
+// onCounterpartyReverificationAlert отправляет уведомление о смене статуса или реквизитов контрагента
+func (s *Server) onCounterpartyReverificationAlert(alert *services.CounterpartyReverificationAlert) {
+	if s.notificationService == nil {
+		return
+	}
+	cp := alert.Counterparty
+
+	notificationType := services.NotificationTypeWarning
+	title := "Изменились реквизиты контрагента"
+	message := fmt.Sprintf("%s (ИНН/БИН %s): %d изменений по данным реестров", cp.NormalizedName, counterpartyKey(cp), len(alert.Changes))
+	if alert.Severity == database.ReverificationSeverityCritical {
+		notificationType = services.NotificationTypeError
+		title = "Изменился статус контрагента"
+		message = fmt.Sprintf("%s (ИНН/БИН %s): статус %s -> %s", cp.NormalizedName, counterpartyKey(cp), alert.PreviousStatus, alert.Status)
+	}
+
+	fields := make([]string, 0, len(alert.Changes))
+	for _, change := range alert.Changes {
+		fields = append(fields, change.Field)
+	}
+	projectID := cp.ClientProjectID
+	metadata := map[string]interface{}{
+		"counterparty_id": cp.ID,
+		"severity":        alert.Severity,
+		"status":          alert.Status,
+		"previous_status": alert.PreviousStatus,
+		"fields":          fields,
+	}
+	if _, err := s.notificationService.AddNotification(context.Background(), notificationType, title, message, nil, &projectID, metadata); err != nil {
+		log.Printf("[Reverification] failed to add notification: %v", err)
+	}
+}
+
+// counterpartyKey возвращает ИНН или БИН контрагента
+func counterpartyKey(cp *database.NormalizedCounterparty) string {
+	if cp.TaxID != "" {
+		return cp.TaxID
+	}
+	return cp.BIN
+}
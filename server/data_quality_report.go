@@ -552,7 +552,7 @@ func (s *Server) analyzeCounterparties(items []*database.CatalogItem) Counterpar
 		var bin string
 
 		// Извлекаем ИНН
-		if extractedINN, err := extractors.ExtractINNFromAttributes(item.Attributes); err == nil {
+		if extractedINN, err := item.ExtractAttribute(extractors.FieldINN); err == nil {
 			inn = extractedINN
 			hasINN = true
 			// Проверяем формат ИНН (должен быть 10 или 12 цифр)
@@ -565,7 +565,7 @@ func (s *Server) analyzeCounterparties(items []*database.CatalogItem) Counterpar
 		}
 
 		// Извлекаем БИН
-		if extractedBIN, err := extractors.ExtractBINFromAttributes(item.Attributes); err == nil {
+		if extractedBIN, err := item.ExtractAttribute(extractors.FieldBIN); err == nil {
 			bin = extractedBIN
 			hasBIN = true
 			// Проверяем формат БИН (должен быть 12 цифр)
@@ -642,10 +642,10 @@ func (s *Server) analyzeCounterparties(items []*database.CatalogItem) Counterpar
 		var inn string
 		var bin string
 
-		if extractedINN, err := extractors.ExtractINNFromAttributes(item.Attributes); err == nil {
+		if extractedINN, err := item.ExtractAttribute(extractors.FieldINN); err == nil {
 			inn = extractedINN
 		}
-		if extractedBIN, err := extractors.ExtractBINFromAttributes(item.Attributes); err == nil {
+		if extractedBIN, err := item.ExtractAttribute(extractors.FieldBIN); err == nil {
 			bin = extractedBIN
 		}
 
@@ -804,38 +804,38 @@ func (s *Server) analyzeNomenclature(items []*database.CatalogItem) Nomenclature
 		var country string
 
 		// Извлекаем артикул из атрибутов
-		article = extractArticleFromAttributes(item.Attributes)
+		article = catalogItemAttribute(item, extractors.FieldArticle, extractArticleFromAttributes)
 		if article != "" {
 			hasArticle = true
 		}
 
 		// Извлекаем SKU из атрибутов
-		sku = extractSKUFromAttributes(item.Attributes)
+		sku = catalogItemAttribute(item, extractors.FieldSKU, extractSKUFromAttributes)
 		if sku != "" {
 			hasSKU = true
 		}
 
 		// Извлекаем единицу измерения
-		unit = extractUnitFromAttributes(item.Attributes)
+		unit = catalogItemAttribute(item, extractors.FieldUnit, extractUnitFromAttributes)
 		if unit != "" {
 			normalizedUnit := normalizeUnit(unit)
 			unitMap[normalizedUnit]++
 		}
 
 		// Извлекаем бренд, производителя, страну
-		brand = extractBrandFromAttributes(item.Attributes)
+		brand = catalogItemAttribute(item, extractors.FieldBrand, extractBrandFromAttributes)
 		if brand != "" {
 			withBrand++
 			brandMap[brand]++
 		}
 
-		manufacturer = extractManufacturerFromAttributes(item.Attributes)
+		manufacturer = catalogItemAttribute(item, extractors.FieldManufacturer, extractManufacturerFromAttributes)
 		if manufacturer != "" {
 			withManufacturer++
 			manufacturerMap[manufacturer]++
 		}
 
-		country = extractCountryFromAttributes(item.Attributes)
+		country = catalogItemAttribute(item, extractors.FieldCountry, extractCountryFromAttributes)
 		if country != "" {
 			withCountry++
 			countryMap[country]++
@@ -902,8 +902,8 @@ func (s *Server) analyzeNomenclature(items []*database.CatalogItem) Nomenclature
 		var article string
 		var sku string
 
-		article = extractArticleFromAttributes(item.Attributes)
-		sku = extractSKUFromAttributes(item.Attributes)
+		article = catalogItemAttribute(item, extractors.FieldArticle, extractArticleFromAttributes)
+		sku = catalogItemAttribute(item, extractors.FieldSKU, extractSKUFromAttributes)
 
 		if article != "" && len(articleMap[article]) > 1 {
 			hasDuplicate = true
@@ -1057,6 +1057,16 @@ func (s *Server) analyzeNomenclature(items []*database.CatalogItem) Nomenclature
 	return stats
 }
 
+// catalogItemAttribute извлекает поле из разобранных при загрузке реквизитов элемента;
+// attributes_xml разбирается только для элементов, еще не попавших в хранилище атрибутов
+func catalogItemAttribute(item *database.CatalogItem, field extractors.Field, fallback func(string) string) string {
+	if len(item.AttributeSet) > 0 {
+		value, _ := extractors.ExtractFromSet(item.AttributeSet, field)
+		return value
+	}
+	return fallback(item.Attributes)
+}
+
 // extractArticleFromAttributes извлекает артикул из XML атрибутов
 func extractArticleFromAttributes(attributesXML string) string {
 	if attributesXML == "" {
@@ -362,6 +362,13 @@ func (s *Server) handleUploadRoutes(w http.ResponseWriter, r *http.Request) {
 		case "verify":
 			// POST /api/uploads/{uuid}/verify - проверка передачи
 			s.handleVerifyUpload(w, r, upload)
+		case "automation":
+			// GET /api/uploads/{uuid}/automation - запуски правил автоматизации
+			if s.uploadAutomationHandler == nil {
+				http.NotFound(w, r)
+				return
+			}
+			s.uploadAutomationHandler.HandleUploadRuns(w, r, upload.UploadUUID)
 		default:
 			http.NotFound(w, r)
 		}
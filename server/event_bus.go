@@ -0,0 +1,122 @@
+package server
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"httpserver/server/events"
+	"httpserver/server/handlers"
+)
+
+// monitoringEventsInterval период публикации метрик провайдеров в шину (как у /api/monitoring/providers/stream)
+const monitoringEventsInterval = 1 * time.Second
+
+// summaryEventsInterval период публикации сводки системы в шину (как у /api/system/summary/stream)
+const summaryEventsInterval = 10 * time.Second
+
+// setupEventBus подключает обработчик единой подписки на события и запускает перекачку
+// канала событий нормализатора в шину. После этого канал читает только шина.
+func (s *Server) setupEventBus(baseHandler *handlers.BaseHandler) {
+	if s.eventBus == nil {
+		return
+	}
+	s.eventsHandler = handlers.NewEventsHandler(s.eventBus, baseHandler)
+	if s.normalizerEvents != nil {
+		go s.pumpNormalizerEvents()
+	}
+}
+
+// pumpNormalizerEvents публикует сообщения нормализатора в топик normalization.
+// Во время нормализации проекта события получают ее клиента и проект.
+func (s *Server) pumpNormalizerEvents() {
+	for {
+		select {
+		case message, ok := <-s.normalizerEvents:
+			if !ok {
+				return
+			}
+			s.eventBus.PublishLog(events.TopicNormalization, s.normalizationScope(), message)
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// normalizationScope возвращает клиента и проект запущенной нормализации проекта
+func (s *Server) normalizationScope() events.Filter {
+	s.normalizerMutex.RLock()
+	defer s.normalizerMutex.RUnlock()
+	return events.Filter{ClientID: s.normalizerClientID, ProjectID: s.normalizerProjectID}
+}
+
+// normalizationSessionEvents возвращает канал событий для нормализатора сессии: сообщения
+// публикуются в топик normalization с клиентом, проектом и ID сессии. stop вызывается после
+// завершения нормализатора. Без шины возвращается общий канал нормализатора.
+func (s *Server) normalizationSessionEvents(clientID, projectID, sessionID int) (chan string, func()) {
+	if s.eventBus == nil {
+		return s.normalizerEvents, func() {}
+	}
+	scope := events.Filter{ClientID: clientID, ProjectID: projectID, SessionID: strconv.Itoa(sessionID)}
+	size := cap(s.normalizerEvents)
+	if size == 0 {
+		size = 100
+	}
+	messages := make(chan string, size)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for {
+			select {
+			case message := <-messages:
+				s.eventBus.PublishLog(events.TopicNormalization, scope, message)
+			case <-done:
+				// Канал не закрывается: нормализатор пишет в него без блокировки и после остановки
+				for {
+					select {
+					case message := <-messages:
+						s.eventBus.PublishLog(events.TopicNormalization, scope, message)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+	return messages, func() {
+		close(done)
+		<-finished
+	}
+}
+
+// startEventSnapshotPublisher периодически публикует метрики провайдеров и сводку системы.
+// Снимки собираются только при наличии подписчиков на соответствующий топик.
+func (s *Server) startEventSnapshotPublisher() {
+	monitoringTicker := time.NewTicker(monitoringEventsInterval)
+	defer monitoringTicker.Stop()
+	summaryTicker := time.NewTicker(summaryEventsInterval)
+	defer summaryTicker.Stop()
+
+	for {
+		select {
+		case <-monitoringTicker.C:
+			if s.monitoringHandler == nil || !s.eventBus.HasSubscribers(events.TopicMonitoringProviders) {
+				continue
+			}
+			data, err := s.monitoringHandler.MonitoringSnapshot()
+			if err != nil {
+				log.Printf("[Events] Failed to collect provider metrics: %v", err)
+				continue
+			}
+			s.eventBus.PublishData(events.TopicMonitoringProviders, "providers_update", events.Filter{}, data)
+		case <-summaryTicker.C:
+			if !s.eventBus.HasSubscribers(events.TopicSystemSummary) {
+				continue
+			}
+			s.eventBus.PublishData(events.TopicSystemSummary, "summary_update", events.Filter{}, s.buildSummaryUpdate())
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"testing"
+
+	"httpserver/server/events"
+)
+
+// TestNormalizationSessionEvents проверяет, что сообщения нормализатора сессии публикуются
+// с клиентом, проектом и ID сессии, а остаток очереди доставляется при остановке
+func TestNormalizationSessionEvents(t *testing.T) {
+	bus := events.NewBus(events.DefaultOptions())
+	defer bus.Close()
+	s := &Server{
+		eventBus:            bus,
+		normalizerEvents:    make(chan string, 10),
+		normalizerClientID:  3,
+		normalizerProjectID: 7,
+	}
+
+	messages, stop := s.normalizationSessionEvents(3, 7, 42)
+	messages <- "Начало нормализации"
+	messages <- "Обработано 10 записей"
+	stop()
+	// Запись после остановки не блокирует нормализатор и не публикуется
+	select {
+	case messages <- "поздно":
+	default:
+	}
+
+	recent := bus.Recent(events.TopicNormalization, 10)
+	if len(recent) != 2 {
+		t.Fatalf("published %d events, want 2", len(recent))
+	}
+	for _, e := range recent {
+		if e.ClientID != 3 || e.ProjectID != 7 || e.SessionID != "42" {
+			t.Errorf("event scope = %d/%d/%q", e.ClientID, e.ProjectID, e.SessionID)
+		}
+	}
+	if scope := s.normalizationScope(); scope.ClientID != 3 || scope.ProjectID != 7 || scope.SessionID != "" {
+		t.Errorf("normalizationScope() = %+v", scope)
+	}
+}
@@ -0,0 +1,528 @@
+// Package events реализует внутреннюю шину событий с типизированными топиками,
+// воспроизведением пропущенных событий и ограничением очередей подписчиков.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Topic топик шины событий
+type Topic string
+
+// Топики событий прогресса и мониторинга
+const (
+	TopicNormalization       Topic = "normalization"
+	TopicReclassification    Topic = "reclassification"
+	TopicMonitoringProviders Topic = "monitoring.providers"
+	TopicSystemSummary       Topic = "system.summary"
+	TopicUploads             Topic = "uploads"
+)
+
+// KnownTopics топики, на которые может подписаться клиент
+var KnownTopics = []Topic{
+	TopicNormalization,
+	TopicReclassification,
+	TopicMonitoringProviders,
+	TopicSystemSummary,
+	TopicUploads,
+}
+
+// Служебные типы событий, которые шина отправляет подписчику сама
+const (
+	// EventTypeReplayTruncated часть событий после Last-Event-ID уже вытеснена из буфера
+	EventTypeReplayTruncated = "replay.truncated"
+	// EventTypeSlowConsumer подписка закрыта из-за переполнения очереди
+	EventTypeSlowConsumer = "slow_consumer"
+)
+
+var (
+	// ErrTooManySubscribers достигнут предел одновременных подписок
+	ErrTooManySubscribers = errors.New("too many event subscribers")
+	// ErrUnknownTopic запрошен неизвестный топик
+	ErrUnknownTopic = errors.New("unknown event topic")
+	// ErrBusClosed шина остановлена
+	ErrBusClosed = errors.New("event bus closed")
+)
+
+// Event событие шины. ID монотонно возрастает в пределах процесса и используется для Last-Event-ID.
+type Event struct {
+	ID        uint64          `json:"id"`
+	Topic     Topic           `json:"topic"`
+	Type      string          `json:"type"`
+	ClientID  int             `json:"client_id,omitempty"`
+	ProjectID int             `json:"project_id,omitempty"`
+	SessionID string          `json:"session_id,omitempty"` // сессия нормализации, UUID выгрузки и т.п.
+	Timestamp time.Time       `json:"timestamp"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// LogData данные журнального события (type = log)
+type LogData struct {
+	Message string `json:"message"`
+}
+
+// EventTypeLog тип журнального события прогресса
+const EventTypeLog = "log"
+
+// Message возвращает текст журнального события или пустую строку для событий других типов
+func (e Event) Message() string {
+	if e.Type != EventTypeLog || len(e.Data) == 0 {
+		return ""
+	}
+	var data LogData
+	if err := json.Unmarshal(e.Data, &data); err != nil {
+		return ""
+	}
+	return data.Message
+}
+
+// Filter ограничивает события подписки. Заданное поле должно совпадать с полем события.
+type Filter struct {
+	ClientID  int    `json:"client_id,omitempty"`
+	ProjectID int    `json:"project_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// Match проверяет, проходит ли событие фильтр
+func (f Filter) Match(e Event) bool {
+	if f.ClientID != 0 && e.ClientID != f.ClientID {
+		return false
+	}
+	if f.ProjectID != 0 && e.ProjectID != f.ProjectID {
+		return false
+	}
+	if f.SessionID != "" && e.SessionID != f.SessionID {
+		return false
+	}
+	return true
+}
+
+// Options настройки шины
+type Options struct {
+	ReplaySize     int // событий в буфере воспроизведения на каждый топик
+	SubscriberSize int // размер очереди подписчика по умолчанию
+	MaxSubscribers int // предел одновременных подписок (0 - без ограничения)
+	MaxDropped     int // пропущенных подряд событий до отключения медленного подписчика
+}
+
+// DefaultOptions настройки шины по умолчанию
+func DefaultOptions() Options {
+	return Options{
+		ReplaySize:     500,
+		SubscriberSize: 256,
+		MaxSubscribers: 200,
+		MaxDropped:     64,
+	}
+}
+
+// SubscribeOptions параметры подписки
+type SubscribeOptions struct {
+	Topics      []Topic
+	Filter      Filter
+	LastEventID uint64 // воспроизвести события с ID больше указанного
+	BufferSize  int    // 0 - размер по умолчанию
+}
+
+// Stats статистика шины
+type Stats struct {
+	Subscribers  int           `json:"subscribers"`
+	Published    uint64        `json:"published"`
+	Dropped      uint64        `json:"dropped"`
+	Disconnected uint64        `json:"disconnected"` // подписки, закрытые из-за переполнения
+	LastEventID  uint64        `json:"last_event_id"`
+	Buffered     map[Topic]int `json:"buffered"`
+	ByTopic      map[Topic]int `json:"subscribers_by_topic"`
+}
+
+// Bus шина событий
+type Bus struct {
+	opts Options
+
+	mu          sync.RWMutex
+	nextID      uint64
+	replay      map[Topic]*ring
+	subscribers map[*Subscription]struct{}
+	closed      bool
+
+	published    uint64
+	dropped      uint64
+	disconnected uint64
+}
+
+// NewBus создает шину событий
+func NewBus(opts Options) *Bus {
+	defaults := DefaultOptions()
+	if opts.ReplaySize <= 0 {
+		opts.ReplaySize = defaults.ReplaySize
+	}
+	if opts.SubscriberSize <= 0 {
+		opts.SubscriberSize = defaults.SubscriberSize
+	}
+	if opts.MaxDropped <= 0 {
+		opts.MaxDropped = defaults.MaxDropped
+	}
+
+	b := &Bus{
+		opts:        opts,
+		replay:      make(map[Topic]*ring),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+	for _, topic := range KnownTopics {
+		b.replay[topic] = newRing(opts.ReplaySize)
+	}
+	return b
+}
+
+// ParseTopics разбирает список топиков через запятую. Пустая строка означает все топики.
+func ParseTopics(value string) ([]Topic, error) {
+	if strings.TrimSpace(value) == "" {
+		return append([]Topic(nil), KnownTopics...), nil
+	}
+	var topics []Topic
+	for _, part := range strings.Split(value, ",") {
+		topic := Topic(strings.TrimSpace(part))
+		if topic == "" {
+			continue
+		}
+		if !IsKnownTopic(topic) {
+			return nil, ErrUnknownTopic
+		}
+		topics = append(topics, topic)
+	}
+	return topics, nil
+}
+
+// IsKnownTopic проверяет, что топик зарегистрирован
+func IsKnownTopic(topic Topic) bool {
+	for _, known := range KnownTopics {
+		if known == topic {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish публикует событие: присваивает ID, сохраняет в буфер воспроизведения и раздает подписчикам.
+// Публикация не блокируется на медленных подписчиках.
+func (b *Bus) Publish(e Event) Event {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return e
+	}
+	b.nextID++
+	e.ID = b.nextID
+	b.published++
+	buffer, ok := b.replay[e.Topic]
+	if !ok {
+		buffer = newRing(b.opts.ReplaySize)
+		b.replay[e.Topic] = buffer
+	}
+	buffer.push(e)
+
+	var slow []*Subscription
+	for sub := range b.subscribers {
+		if !sub.matches(e) {
+			continue
+		}
+		if !sub.deliver(e) {
+			b.dropped++
+			if sub.dropped >= b.opts.MaxDropped {
+				slow = append(slow, sub)
+			}
+		}
+	}
+	for _, sub := range slow {
+		b.disconnected++
+		b.removeLocked(sub, EventTypeSlowConsumer)
+	}
+	b.mu.Unlock()
+	return e
+}
+
+// PublishData публикует событие с сериализацией данных в JSON
+func (b *Bus) PublishData(topic Topic, eventType string, scope Filter, data interface{}) Event {
+	var raw json.RawMessage
+	if data != nil {
+		if encoded, err := json.Marshal(data); err == nil {
+			raw = encoded
+		}
+	}
+	return b.Publish(Event{
+		Topic:     topic,
+		Type:      eventType,
+		ClientID:  scope.ClientID,
+		ProjectID: scope.ProjectID,
+		SessionID: scope.SessionID,
+		Data:      raw,
+	})
+}
+
+// PublishLog публикует журнальное событие прогресса
+func (b *Bus) PublishLog(topic Topic, scope Filter, message string) Event {
+	return b.PublishData(topic, EventTypeLog, scope, LogData{Message: message})
+}
+
+// Subscribe создает подписку. Если указан LastEventID, сначала в очередь попадают
+// сохраненные события после него; если часть из них уже вытеснена, первым приходит replay.truncated.
+func (b *Bus) Subscribe(opts SubscribeOptions) (*Subscription, error) {
+	topics := opts.Topics
+	if len(topics) == 0 {
+		topics = KnownTopics
+	}
+	topicSet := make(map[Topic]bool, len(topics))
+	for _, topic := range topics {
+		if !IsKnownTopic(topic) {
+			return nil, ErrUnknownTopic
+		}
+		topicSet[topic] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrBusClosed
+	}
+	if b.opts.MaxSubscribers > 0 && len(b.subscribers) >= b.opts.MaxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+
+	var backlog []Event
+	truncated := false
+	if opts.LastEventID > 0 {
+		for topic := range topicSet {
+			events, complete := b.replay[topic].since(opts.LastEventID)
+			if !complete {
+				truncated = true
+			}
+			for _, e := range events {
+				if opts.Filter.Match(e) {
+					backlog = append(backlog, e)
+				}
+			}
+		}
+		if opts.LastEventID > b.nextID {
+			// ID из другого запуска сервера: все сохраненные события новее
+			truncated = true
+		}
+		sortEvents(backlog)
+	}
+
+	size := opts.BufferSize
+	if size <= 0 {
+		size = b.opts.SubscriberSize
+	}
+	if size < len(backlog)+1 {
+		size = len(backlog) + 1
+	}
+
+	sub := &Subscription{
+		bus:    b,
+		topics: topicSet,
+		filter: opts.Filter,
+		ch:     make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+	if truncated {
+		sub.ch <- Event{Topic: "", Type: EventTypeReplayTruncated, Timestamp: time.Now()}
+	}
+	for _, e := range backlog {
+		sub.ch <- e
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub, nil
+}
+
+// HasSubscribers сообщает, есть ли подписчики на топик; используется для ленивой публикации снимков
+func (b *Bus) HasSubscribers(topic Topic) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if sub.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// Recent возвращает до limit последних событий топика
+func (b *Bus) Recent(topic Topic, limit int) []Event {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	buffer, ok := b.replay[topic]
+	if !ok {
+		return nil
+	}
+	return buffer.last(limit)
+}
+
+// Stats возвращает статистику шины
+func (b *Bus) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := Stats{
+		Subscribers:  len(b.subscribers),
+		Published:    b.published,
+		Dropped:      b.dropped,
+		Disconnected: b.disconnected,
+		LastEventID:  b.nextID,
+		Buffered:     make(map[Topic]int, len(b.replay)),
+		ByTopic:      make(map[Topic]int),
+	}
+	for topic, buffer := range b.replay {
+		stats.Buffered[topic] = buffer.len()
+	}
+	for sub := range b.subscribers {
+		for topic := range sub.topics {
+			stats.ByTopic[topic]++
+		}
+	}
+	return stats
+}
+
+// Close закрывает шину и все подписки
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		b.removeLocked(sub, "")
+	}
+}
+
+// removeLocked удаляет подписку; вызывается под b.mu
+func (b *Bus) removeLocked(sub *Subscription, reason string) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	sub.reason = reason
+	close(sub.done)
+}
+
+// Subscription подписка на события шины
+type Subscription struct {
+	bus     *Bus
+	topics  map[Topic]bool
+	filter  Filter
+	ch      chan Event
+	done    chan struct{}
+	dropped int    // пропущено подряд, защищено bus.mu
+	reason  string // причина закрытия шиной, защищено bus.mu
+}
+
+// Events канал событий подписки
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Done закрывается, когда подписка завершена шиной или вызовом Close
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Reason причина закрытия подписки шиной (например slow_consumer)
+func (s *Subscription) Reason() string {
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+	return s.reason
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.removeLocked(s, "")
+}
+
+// matches проверяет топик и фильтр подписки
+func (s *Subscription) matches(e Event) bool {
+	return s.topics[e.Topic] && s.filter.Match(e)
+}
+
+// deliver кладет событие в очередь без блокировки; вызывается под bus.mu
+func (s *Subscription) deliver(e Event) bool {
+	select {
+	case s.ch <- e:
+		s.dropped = 0
+		return true
+	default:
+		s.dropped++
+		return false
+	}
+}
+
+// ring кольцевой буфер событий топика
+type ring struct {
+	events  []Event
+	start   int
+	count   int
+	evicted uint64 // наибольший ID вытесненного события
+}
+
+func newRing(size int) *ring {
+	return &ring{events: make([]Event, size)}
+}
+
+func (r *ring) push(e Event) {
+	if r.count < len(r.events) {
+		r.events[(r.start+r.count)%len(r.events)] = e
+		r.count++
+		return
+	}
+	r.evicted = r.events[r.start].ID
+	r.events[r.start] = e
+	r.start = (r.start + 1) % len(r.events)
+}
+
+func (r *ring) len() int {
+	return r.count
+}
+
+func (r *ring) at(i int) Event {
+	return r.events[(r.start+i)%len(r.events)]
+}
+
+// since возвращает события с ID больше lastID; complete = false, если часть из них уже вытеснена
+func (r *ring) since(lastID uint64) ([]Event, bool) {
+	var events []Event
+	for i := 0; i < r.count; i++ {
+		if e := r.at(i); e.ID > lastID {
+			events = append(events, e)
+		}
+	}
+	return events, r.evicted <= lastID
+}
+
+func (r *ring) last(limit int) []Event {
+	if limit <= 0 || limit > r.count {
+		limit = r.count
+	}
+	events := make([]Event, 0, limit)
+	for i := r.count - limit; i < r.count; i++ {
+		events = append(events, r.at(i))
+	}
+	return events
+}
+
+// sortEvents упорядочивает события разных топиков по ID (вставками: backlog небольшой)
+func sortEvents(events []Event) {
+	for i := 1; i < len(events); i++ {
+		for j := i; j > 0 && events[j].ID < events[j-1].ID; j-- {
+			events[j], events[j-1] = events[j-1], events[j]
+		}
+	}
+}
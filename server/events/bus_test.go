@@ -0,0 +1,159 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case e := <-sub.Events():
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("событие не получено")
+		return Event{}
+	}
+}
+
+func TestBus_PublishSubscribeWithFilter(t *testing.T) {
+	bus := NewBus(DefaultOptions())
+	defer bus.Close()
+
+	sub, err := bus.Subscribe(SubscribeOptions{
+		Topics: []Topic{TopicUploads},
+		Filter: Filter{ClientID: 7},
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	bus.PublishData(TopicUploads, "upload.started", Filter{ClientID: 3}, nil)
+	bus.PublishData(TopicNormalization, "log", Filter{ClientID: 7}, nil)
+	published := bus.PublishData(TopicUploads, "upload.completed", Filter{ClientID: 7, ProjectID: 2}, map[string]int{"items": 5})
+
+	e := receive(t, sub)
+	if e.ID != published.ID || e.Type != "upload.completed" {
+		t.Fatalf("получено %+v, ожидалось событие %d", e, published.ID)
+	}
+	if string(e.Data) != `{"items":5}` {
+		t.Errorf("Data = %s", e.Data)
+	}
+	select {
+	case extra := <-sub.Events():
+		t.Fatalf("лишнее событие %+v", extra)
+	default:
+	}
+}
+
+func TestBus_ReplayAfterLastEventID(t *testing.T) {
+	bus := NewBus(Options{ReplaySize: 10})
+	defer bus.Close()
+
+	first := bus.PublishData(TopicNormalization, "log", Filter{}, "a")
+	bus.PublishData(TopicReclassification, "log", Filter{}, "b")
+	third := bus.PublishData(TopicNormalization, "log", Filter{}, "c")
+
+	sub, err := bus.Subscribe(SubscribeOptions{
+		Topics:      []Topic{TopicNormalization, TopicReclassification},
+		LastEventID: first.ID,
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	if e := receive(t, sub); e.Topic != TopicReclassification {
+		t.Errorf("первым ожидалось событие переклассификации, получено %+v", e)
+	}
+	if e := receive(t, sub); e.ID != third.ID {
+		t.Errorf("вторым ожидалось событие %d, получено %d", third.ID, e.ID)
+	}
+}
+
+func TestBus_ReplayTruncated(t *testing.T) {
+	bus := NewBus(Options{ReplaySize: 2})
+	defer bus.Close()
+
+	first := bus.PublishData(TopicNormalization, "log", Filter{}, 1)
+	for i := 0; i < 3; i++ {
+		bus.PublishData(TopicNormalization, "log", Filter{}, i)
+	}
+
+	sub, err := bus.Subscribe(SubscribeOptions{Topics: []Topic{TopicNormalization}, LastEventID: first.ID})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer sub.Close()
+
+	if e := receive(t, sub); e.Type != EventTypeReplayTruncated {
+		t.Fatalf("ожидалось %s, получено %+v", EventTypeReplayTruncated, e)
+	}
+	if e := receive(t, sub); e.ID != first.ID+2 {
+		t.Errorf("ожидалось событие %d, получено %d", first.ID+2, e.ID)
+	}
+}
+
+func TestBus_SlowConsumerDisconnected(t *testing.T) {
+	bus := NewBus(Options{SubscriberSize: 1, MaxDropped: 2})
+	defer bus.Close()
+
+	sub, err := bus.Subscribe(SubscribeOptions{Topics: []Topic{TopicSystemSummary}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		bus.PublishData(TopicSystemSummary, "summary_update", Filter{}, i)
+	}
+
+	select {
+	case <-sub.Done():
+	default:
+		t.Fatal("медленный подписчик не отключен")
+	}
+	if sub.Reason() != EventTypeSlowConsumer {
+		t.Errorf("Reason = %q", sub.Reason())
+	}
+	stats := bus.Stats()
+	if stats.Subscribers != 0 || stats.Disconnected != 1 || stats.Dropped < 2 {
+		t.Errorf("Stats = %+v", stats)
+	}
+}
+
+func TestBus_Limits(t *testing.T) {
+	bus := NewBus(Options{MaxSubscribers: 1})
+	defer bus.Close()
+
+	if _, err := bus.Subscribe(SubscribeOptions{Topics: []Topic{"unknown"}}); err != ErrUnknownTopic {
+		t.Errorf("ожидалась ErrUnknownTopic, получено %v", err)
+	}
+	sub, err := bus.Subscribe(SubscribeOptions{Topics: []Topic{TopicMonitoringProviders}})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if !bus.HasSubscribers(TopicMonitoringProviders) || bus.HasSubscribers(TopicUploads) {
+		t.Error("HasSubscribers вернул неверный результат")
+	}
+	if _, err := bus.Subscribe(SubscribeOptions{}); err != ErrTooManySubscribers {
+		t.Errorf("ожидалась ErrTooManySubscribers, получено %v", err)
+	}
+	sub.Close()
+	if bus.HasSubscribers(TopicMonitoringProviders) {
+		t.Error("подписка не удалена после Close")
+	}
+}
+
+func TestParseTopics(t *testing.T) {
+	topics, err := ParseTopics("normalization, uploads")
+	if err != nil || len(topics) != 2 || topics[1] != TopicUploads {
+		t.Errorf("ParseTopics = %v, %v", topics, err)
+	}
+	if all, _ := ParseTopics(""); len(all) != len(KnownTopics) {
+		t.Errorf("пустая строка должна означать все топики, получено %v", all)
+	}
+	if _, err := ParseTopics("normalization,bogus"); err != ErrUnknownTopic {
+		t.Errorf("ожидалась ErrUnknownTopic, получено %v", err)
+	}
+}
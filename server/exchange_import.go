@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"httpserver/database"
+	"httpserver/internal/config"
+	"httpserver/server/services"
+)
+
+// startExchangeWatcher запускает наблюдение за каталогом файлов обмена 1С.
+// Каталог, интервал проверки и database_id задаются config.Exchange
+// (EXCHANGE_WATCH_DIR, EXCHANGE_WATCH_INTERVAL, EXCHANGE_DATABASE_ID).
+// Без EXCHANGE_DATABASE_ID DatabaseID определяется по заголовку файла.
+func (s *Server) startExchangeWatcher() {
+	cfg := s.exchangeConfig()
+	if cfg.WatchDir == "" || s.exchangeImportService == nil {
+		return
+	}
+	dir, interval := cfg.WatchDir, cfg.WatchInterval
+	opts := services.ExchangeImportOptions{DatabaseID: cfg.DatabaseID}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.shutdownChan
+		cancel()
+	}()
+
+	log.Printf("Наблюдение за каталогом файлов обмена: %s (интервал %v)", dir, interval)
+	if err := s.exchangeImportService.WatchDirectory(ctx, dir, interval, opts); err != nil {
+		s.logErrorf("Exchange directory watcher stopped: %v", err)
+	}
+}
+
+// exchangeConfig возвращает настройки импорта файлов обмена; без конфигурации наблюдение выключено
+func (s *Server) exchangeConfig() *config.ExchangeConfig {
+	if s.config == nil || s.config.Exchange == nil {
+		return &config.ExchangeConfig{}
+	}
+	return s.config.Exchange
+}
+
+// onExchangeUploadCompleted выполняет те же действия, что и /complete:
+// уведомление о завершении и анализ качества выгрузки
+func (s *Server) onExchangeUploadCompleted(upload *database.Upload) {
+	var clientID, projectID *int
+	if upload.DatabaseID != nil && s.uploadService != nil {
+		if cID, pID, err := s.uploadService.GetClientProjectIDs(*upload.DatabaseID); err == nil {
+			clientID = &cID
+			projectID = &pID
+		}
+	}
+
+	if s.notificationService != nil {
+		_, _ = s.notificationService.AddNotification(context.Background(), services.NotificationTypeSuccess,
+			"Загрузка завершена", fmt.Sprintf("Файл обмена загружен, выгрузка %s завершена", upload.UploadUUID),
+			clientID, projectID, map[string]interface{}{"upload_uuid": upload.UploadUUID, "upload_id": upload.ID})
+	}
+	s.onUploadCompletedAutomation(upload)
+
+	if upload.DatabaseID == nil || *upload.DatabaseID <= 0 || s.qualityAnalyzer == nil {
+		return
+	}
+	databaseID := *upload.DatabaseID
+	go func() {
+		if err := s.qualityAnalyzer.AnalyzeUpload(upload.ID, databaseID); err != nil {
+			s.log(LogEntry{
+				Timestamp:  time.Now(),
+				Level:      "ERROR",
+				Message:    fmt.Sprintf("Quality analysis failed for upload %s: %v", upload.UploadUUID, err),
+				UploadUUID: upload.UploadUUID,
+				Endpoint:   "/exchange-import",
+			})
+		}
+	}()
+}
+
+// handleExchangeImports возвращает прогресс импорта файлов обмена
+func (s *Server) handleExchangeImports(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.writeJSONError(w, r, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	imports := s.exchangeImportService.GetImports()
+	s.writeJSONResponse(w, r, map[string]interface{}{
+		"imports":   imports,
+		"total":     len(imports),
+		"watch_dir": s.exchangeConfig().WatchDir,
+	}, http.StatusOK)
+}
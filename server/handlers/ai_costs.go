@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// AICostHandler обработчик учета затрат на AI: цены, журнал потребления, бюджеты и экспорт
+type AICostHandler struct {
+	service     *services.AICostService
+	baseHandler *BaseHandler
+	metrics     func() map[string]interface{} // метрики оркестратора провайдеров (может быть nil)
+}
+
+// NewAICostHandler создает обработчик учета затрат на AI
+func NewAICostHandler(service *services.AICostService, baseHandler *BaseHandler, metrics func() map[string]interface{}) *AICostHandler {
+	return &AICostHandler{
+		service:     service,
+		baseHandler: baseHandler,
+		metrics:     metrics,
+	}
+}
+
+// HandlePrices возвращает или сохраняет цены провайдеров
+// GET /api/ai-costs/prices, PUT /api/ai-costs/prices
+func (h *AICostHandler) HandlePrices(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		prices, err := h.service.GetPrices()
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"prices": prices,
+			"total":  len(prices),
+		}, http.StatusOK)
+	case http.MethodPut, http.MethodPost:
+		var price database.AIPrice
+		if err := json.NewDecoder(r.Body).Decode(&price); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON цены", err))
+			return
+		}
+		if err := h.service.SavePrice(&price); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, price, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut)
+	}
+}
+
+// HandleDeletePrice удаляет цену
+// DELETE /api/ai-costs/prices/{id}
+func (h *AICostHandler) HandleDeletePrice(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "/api/ai-costs/prices/")
+	if !ok {
+		return
+	}
+	if err := h.service.DeletePrice(id); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+}
+
+// HandleBudgets возвращает состояние бюджетов или сохраняет бюджет
+// GET /api/ai-costs/budgets, PUT /api/ai-costs/budgets
+func (h *AICostHandler) HandleBudgets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		statuses, err := h.service.GetBudgetStatuses()
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"budgets": statuses,
+			"total":   len(statuses),
+		}, http.StatusOK)
+	case http.MethodPut, http.MethodPost:
+		budget := database.AIBudget{Enabled: true}
+		if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON бюджета", err))
+			return
+		}
+		if err := h.service.SaveBudget(&budget); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, budget, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut)
+	}
+}
+
+// HandleDeleteBudget удаляет бюджет
+// DELETE /api/ai-costs/budgets/{id}
+func (h *AICostHandler) HandleDeleteBudget(w http.ResponseWriter, r *http.Request) {
+	id, ok := h.pathID(w, r, "/api/ai-costs/budgets/")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteBudget(id); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+}
+
+// HandleUsage возвращает журнал потребления с агрегацией
+// GET /api/ai-costs/usage?from=&to=&provider=&client_id=&project_id=&session_id=&group_by=client,day
+func (h *AICostHandler) HandleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	filter, err := parseAIUsageFilter(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	entries, err := h.service.GetUsage(filter)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	var totalCost float64
+	var totalRequests, totalInput, totalOutput int64
+	for _, e := range entries {
+		totalCost += e.Cost
+		totalRequests += e.Requests
+		totalInput += e.InputTokens
+		totalOutput += e.OutputTokens
+	}
+
+	response := map[string]interface{}{
+		"usage": entries,
+		"totals": map[string]interface{}{
+			"requests":      totalRequests,
+			"input_tokens":  totalInput,
+			"output_tokens": totalOutput,
+			"cost":          totalCost,
+		},
+	}
+	if h.metrics != nil {
+		response["orchestrator"] = h.metrics()
+	}
+	h.baseHandler.WriteJSONResponse(w, r, response, http.StatusOK)
+}
+
+// HandleExport выгружает журнал потребления и бюджеты в Excel
+// GET /api/ai-costs/export?from=&to=&provider=&client_id=&project_id=
+func (h *AICostHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	filter, err := parseAIUsageFilter(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.service.ExportExcel(&buf, filter); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("ai_costs_%s.xlsx", time.Now().Format("20060102_150405"))
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// pathID извлекает числовой ID из хвоста пути
+func (h *AICostHandler) pathID(w http.ResponseWriter, r *http.Request, prefix string) (int, bool) {
+	if r.Method != http.MethodDelete {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodDelete)
+		return 0, false
+	}
+	idStr := strings.Trim(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError(fmt.Sprintf("некорректный ID: %s", idStr), err))
+		return 0, false
+	}
+	return id, true
+}
+
+// parseAIUsageFilter разбирает фильтр журнала из query параметров
+func parseAIUsageFilter(r *http.Request) (database.AIUsageFilter, error) {
+	q := r.URL.Query()
+	filter := database.AIUsageFilter{
+		From:      q.Get("from"),
+		To:        q.Get("to"),
+		Provider:  q.Get("provider"),
+		SessionID: q.Get("session_id"),
+	}
+	for _, date := range []string{filter.From, filter.To} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return filter, NewValidationError(fmt.Sprintf("некорректная дата %q, ожидается YYYY-MM-DD", date), err)
+		}
+	}
+	if v := q.Get("client_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, NewValidationError("некорректный client_id", err)
+		}
+		filter.ClientID = id
+	}
+	if v := q.Get("project_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return filter, NewValidationError("некорректный project_id", err)
+		}
+		filter.ProjectID = id
+	}
+	if v := q.Get("group_by"); v != "" {
+		for _, g := range strings.Split(v, ",") {
+			if g = strings.TrimSpace(g); g != "" {
+				filter.GroupBy = append(filter.GroupBy, g)
+			}
+		}
+	}
+	return filter, nil
+}
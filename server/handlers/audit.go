@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// AuditHandler обработчик журнала аудита
+type AuditHandler struct {
+	service     *services.AuditService
+	baseHandler *BaseHandler
+}
+
+// NewAuditHandler создает обработчик журнала аудита
+func NewAuditHandler(service *services.AuditService, baseHandler *BaseHandler) *AuditHandler {
+	return &AuditHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// parseAuditFilter разбирает параметры фильтра журнала из строки запроса
+func parseAuditFilter(r *http.Request) (database.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := database.AuditLogFilter{
+		Actor:      query.Get("actor"),
+		RequestID:  query.Get("request_id"),
+		EntityType: query.Get("entity_type"),
+		EntityID:   query.Get("entity_id"),
+		Action:     query.Get("action"),
+		Method:     query.Get("method"),
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	for name, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if t, err = time.Parse("2006-01-02", value); err != nil {
+				return filter, NewValidationError(fmt.Sprintf("некорректная дата %s: %s", name, value), err)
+			}
+		}
+		*target = &t
+	}
+	return filter, nil
+}
+
+// HandleQuery возвращает записи журнала аудита
+// GET /api/audit?actor=&entity_type=&entity_id=&request_id=&action=&method=&from=&to=&limit=&offset=
+func (h *AuditHandler) HandleQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	entries, total, err := h.service.Query(filter)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	if entries == nil {
+		entries = []*database.AuditLogEntry{}
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"entries": entries,
+		"total":   total,
+	}, http.StatusOK)
+}
+
+// HandleExport выгружает журнал аудита в JSON или CSV
+// GET /api/audit/export?format=json|csv&<фильтры как в /api/audit>
+func (h *AuditHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("format должен быть json или csv", nil))
+		return
+	}
+
+	contentType := "application/json"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=audit_%s.%s", time.Now().Format("20060102_150405"), format))
+	if err := h.service.Export(filter, format, w); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+	}
+}
+
+// HandleVerify проверяет целостность цепочки хешей журнала
+// GET /api/audit/verify
+func (h *AuditHandler) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	result, err := h.service.Verify()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusOK)
+}
+
+// HandleSettings возвращает или изменяет срок хранения журнала
+// GET/PUT /api/audit/settings
+func (h *AuditHandler) HandleSettings(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var req struct {
+			RetentionDays int `json:"retention_days"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON настроек", err))
+			return
+		}
+		if err := h.service.SetRetentionDays(req.RetentionDays, r.Header.Get("X-User")); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut)
+		return
+	}
+
+	days, err := h.service.GetRetentionDays()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"retention_days": days}, http.StatusOK)
+}
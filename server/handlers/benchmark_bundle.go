@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"httpserver/server/services"
+)
+
+// maxBenchmarkBundleSize предельный размер загружаемого пакета эталонов
+const maxBenchmarkBundleSize = 256 << 20
+
+// BenchmarkBundleHandler обработчик экспорта и импорта пакетов эталонов
+type BenchmarkBundleHandler struct {
+	service     *services.BenchmarkBundleService
+	baseHandler *BaseHandler
+}
+
+// NewBenchmarkBundleHandler создает обработчик пакетов эталонов
+func NewBenchmarkBundleHandler(service *services.BenchmarkBundleService, baseHandler *BaseHandler) *BenchmarkBundleHandler {
+	return &BenchmarkBundleHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleExport выгружает подписанный пакет эталонов
+// GET /api/benchmarks/bundle/export?entity_type=&active_only=true&project_id=
+func (h *BenchmarkBundleHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := services.BenchmarkBundleExportOptions{
+		EntityType: query.Get("entity_type"),
+		ActiveOnly: query.Get("active_only") != "false",
+		ExportedBy: r.Header.Get("X-User"),
+	}
+	if value := query.Get("project_id"); value != "" {
+		projectID, err := strconv.Atoi(value)
+		if err != nil || projectID <= 0 {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный project_id", err))
+			return
+		}
+		opts.ProjectID = projectID
+	}
+
+	bundle, err := h.service.Export(opts)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("benchmarks_%s.json", time.Now().Format("20060102_150405"))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// HandleImport импортирует пакет эталонов из тела запроса
+// POST /api/benchmarks/bundle/import?strategy=skip|overwrite|merge|newer&dry_run=true&project_id=&allow_untrusted=false
+func (h *BenchmarkBundleHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	opts := services.BenchmarkBundleImportOptions{
+		Strategy:       query.Get("strategy"),
+		DryRun:         query.Get("dry_run") == "true",
+		AllowUntrusted: query.Get("allow_untrusted") == "true",
+		ImportedBy:     r.Header.Get("X-User"),
+	}
+	if value := query.Get("project_id"); value != "" {
+		projectID, err := strconv.Atoi(value)
+		if err != nil || projectID <= 0 {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный project_id", err))
+			return
+		}
+		opts.ProjectID = projectID
+	}
+
+	var bundle services.BenchmarkBundle
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBenchmarkBundleSize)).Decode(&bundle); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON пакета эталонов", err))
+		return
+	}
+
+	report, err := h.service.Import(&bundle, opts)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, report, http.StatusOK)
+}
+
+// HandlePublicKey возвращает открытый ключ подписи этой инсталляции для передачи на другие серверы
+// GET /api/benchmarks/bundle/public-key
+func (h *BenchmarkBundleHandler) HandlePublicKey(w http.ResponseWriter, r *http.Request) {
+	keyID, publicKey, err := h.service.GetPublicKey()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"key_id":     keyID,
+		"algorithm":  "ed25519",
+		"public_key": publicKey,
+	}, http.StatusOK)
+}
+
+// HandleTrustedKeys возвращает (GET) или добавляет (POST) доверенные ключи подписи
+// GET/POST /api/benchmarks/bundle/trusted-keys {"name": "Филиал", "public_key": "base64"}
+func (h *BenchmarkBundleHandler) HandleTrustedKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := h.service.ListTrustedKeys()
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, keys, http.StatusOK)
+	case http.MethodPost:
+		var req struct {
+			Name      string `json:"name"`
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON ключа", err))
+			return
+		}
+		key, err := h.service.AddTrustedKey(req.Name, req.PublicKey, r.Header.Get("X-User"))
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, key, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleDeleteTrustedKey удаляет доверенный ключ подписи
+// DELETE /api/benchmarks/bundle/trusted-keys/{keyId}
+func (h *BenchmarkBundleHandler) HandleDeleteTrustedKey(w http.ResponseWriter, r *http.Request, keyID string) {
+	if err := h.service.RemoveTrustedKey(keyID); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"deleted": keyID}, http.StatusOK)
+}
@@ -17,6 +17,7 @@ import (
 	"httpserver/database"
 	apperrors "httpserver/server/errors"
 	"httpserver/server/services"
+	"httpserver/server/types"
 )
 
 // LogEntry представляет запись лога
@@ -35,17 +36,7 @@ type ClassificationHandler struct {
 }
 
 // ClassifyRequest описывает запрос на классификацию
-type ClassifyRequest struct {
-	ItemID     int                    `json:"item_id"`
-	ItemName   string                 `json:"item_name"`
-	ItemCode   string                 `json:"item_code,omitempty"`
-	Classifier string                 `json:"classifier,omitempty"`
-	Model      string                 `json:"model,omitempty"`
-	StrategyID string                 `json:"strategy_id,omitempty"`
-	Category   string                 `json:"category,omitempty"`
-	Context    map[string]interface{} `json:"context,omitempty"`
-	Options    map[string]interface{} `json:"options,omitempty"`
-}
+type ClassifyRequest = types.ClassifyRequest
 
 // ClassifyResponse описывает ответ классификации
 type ClassifyResponse struct {
@@ -534,29 +525,27 @@ func (h *ClassificationHandler) HandleClassifyItem(w http.ResponseWriter, r *htt
 	}
 
 	categoryPath := normalizeCategoryPath(aiResponse.CategoryPath, req.Category)
-	classificationPayload := map[string]interface{}{
-		"category_path": categoryPath,
-		"confidence":    aiResponse.Confidence,
-		"reasoning":     aiResponse.Reasoning,
-		"alternatives":  normalizeAlternatives(aiResponse.Alternatives),
-		"strategy":      strategyID,
-	}
-
-	response := map[string]interface{}{
-		"item_id":        req.ItemID,
-		"item_name":      itemName,
-		"item_code":      req.ItemCode,
-		"original_name":  itemName,
-		"classifier":     classifierName,
-		"strategy":       strategyID,
-		"strategy_id":    strategyID,
-		"category":       categoryPath,
-		"category_path":  categoryPath,
-		"confidence":     aiResponse.Confidence,
-		"reasoning":      aiResponse.Reasoning,
-		"context":        req.Context,
-		"options":        req.Options,
-		"classification": classificationPayload,
+	response := types.ClassificationResult{
+		ItemID:       req.ItemID,
+		ItemName:     itemName,
+		ItemCode:     req.ItemCode,
+		OriginalName: itemName,
+		Classifier:   classifierName,
+		Strategy:     strategyID,
+		StrategyID:   strategyID,
+		Category:     categoryPath,
+		CategoryPath: categoryPath,
+		Confidence:   aiResponse.Confidence,
+		Reasoning:    aiResponse.Reasoning,
+		Context:      req.Context,
+		Options:      req.Options,
+		Classification: types.ClassificationPayload{
+			CategoryPath: categoryPath,
+			Confidence:   aiResponse.Confidence,
+			Reasoning:    aiResponse.Reasoning,
+			Alternatives: normalizeAlternatives(aiResponse.Alternatives),
+			Strategy:     strategyID,
+		},
 	}
 
 	if h.logFunc != nil {
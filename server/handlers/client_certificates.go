@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// ClientCertificateHandler обработчик привязок клиентских сертификатов 1С
+type ClientCertificateHandler struct {
+	service     *services.ClientCertificateService
+	baseHandler *BaseHandler
+}
+
+// NewClientCertificateHandler создает обработчик привязок клиентских сертификатов
+func NewClientCertificateHandler(service *services.ClientCertificateService, baseHandler *BaseHandler) *ClientCertificateHandler {
+	return &ClientCertificateHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleListCertificates возвращает привязки сертификатов клиента
+// GET /api/clients/{clientId}/certificates
+func (h *ClientCertificateHandler) HandleListCertificates(w http.ResponseWriter, r *http.Request, clientID int) {
+	bindings, err := h.service.List(clientID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, bindings, http.StatusOK)
+}
+
+// HandleCreateCertificate привязывает сертификат к клиенту
+// POST /api/clients/{clientId}/certificates
+// {"subject": "CN сертификата", "project_id": 3, "fingerprint": "sha256 hex", "description": "..."}
+func (h *ClientCertificateHandler) HandleCreateCertificate(w http.ResponseWriter, r *http.Request, clientID int) {
+	var req struct {
+		Subject     string `json:"subject"`
+		ProjectID   *int   `json:"project_id"`
+		Fingerprint string `json:"fingerprint"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON привязки сертификата", err))
+		return
+	}
+
+	binding := &database.ClientCertificateBinding{
+		ClientID:    clientID,
+		ProjectID:   req.ProjectID,
+		Subject:     req.Subject,
+		Fingerprint: req.Fingerprint,
+		Description: req.Description,
+		CreatedBy:   r.Header.Get("X-User"),
+	}
+	if err := h.service.Create(binding); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, binding, http.StatusCreated)
+}
+
+// HandleRevokeCertificate отзывает привязку сертификата
+// DELETE /api/clients/{clientId}/certificates/{id}
+func (h *ClientCertificateHandler) HandleRevokeCertificate(w http.ResponseWriter, r *http.Request, clientID, id int) {
+	if err := h.service.Revoke(clientID, id); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"revoked": id}, http.StatusOK)
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"httpserver/internal/config"
+	"httpserver/server/services"
+)
+
+// ClientConfigHandler обработчик клиентских overlay конфигурации
+type ClientConfigHandler struct {
+	service     *services.ClientConfigService
+	baseHandler *BaseHandler
+	baseConfig  func() *config.Config
+}
+
+// NewClientConfigHandler создает обработчик клиентских настроек.
+// baseConfig возвращает текущую глобальную конфигурацию.
+func NewClientConfigHandler(service *services.ClientConfigService, baseHandler *BaseHandler, baseConfig func() *config.Config) *ClientConfigHandler {
+	return &ClientConfigHandler{
+		service:     service,
+		baseHandler: baseHandler,
+		baseConfig:  baseConfig,
+	}
+}
+
+// HandleGetClientConfig возвращает overlay клиента (ключи скрыты)
+// GET /api/clients/{clientId}/config
+func (h *ClientConfigHandler) HandleGetClientConfig(w http.ResponseWriter, r *http.Request, clientID int) {
+	overlay, err := h.service.GetOverlay(clientID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, overlay.Masked(), http.StatusOK)
+}
+
+// HandleUpdateClientConfig сохраняет overlay клиента
+// PUT /api/clients/{clientId}/config
+func (h *ClientConfigHandler) HandleUpdateClientConfig(w http.ResponseWriter, r *http.Request, clientID int) {
+	var overlay config.ClientConfigOverlay
+	if err := json.NewDecoder(r.Body).Decode(&overlay); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON настроек клиента", err))
+		return
+	}
+
+	// Скрытые ключи из GET ответа (****1234) означают "оставить сохраненный ключ"
+	current, err := h.service.GetOverlay(clientID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	for name, p := range overlay.Providers {
+		if p == nil {
+			continue
+		}
+		existing, ok := current.Provider(name)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(p.APIKey, "****") {
+			p.APIKey = existing.APIKey
+		}
+		if strings.HasPrefix(p.SecretKey, "****") {
+			p.SecretKey = existing.SecretKey
+		}
+	}
+
+	updatedBy := r.Header.Get("X-User")
+	if err := h.service.SaveOverlay(clientID, &overlay, updatedBy); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, overlay.Masked(), http.StatusOK)
+}
+
+// HandleDeleteClientConfig удаляет overlay клиента
+// DELETE /api/clients/{clientId}/config
+func (h *ClientConfigHandler) HandleDeleteClientConfig(w http.ResponseWriter, r *http.Request, clientID int) {
+	if err := h.service.DeleteOverlay(clientID); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"success":   true,
+		"client_id": clientID,
+	}, http.StatusOK)
+}
+
+// HandleGetEffectiveClientConfig возвращает итоговую конфигурацию клиента (глобальная + overlay)
+// GET /api/clients/{clientId}/config/effective
+func (h *ClientConfigHandler) HandleGetEffectiveClientConfig(w http.ResponseWriter, r *http.Request, clientID int) {
+	var base *config.Config
+	if h.baseConfig != nil {
+		base = h.baseConfig()
+	}
+	if base == nil {
+		h.baseHandler.HandleHTTPError(w, r, NewInternalError("глобальная конфигурация недоступна", nil))
+		return
+	}
+
+	overlay, err := h.service.GetOverlay(clientID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	effective := config.ApplyClientOverlay(base, overlay)
+
+	// ApplyClientOverlay копирует сервисы обогащения, поэтому ключи можно скрыть в копии
+	if effective.Enrichment != nil {
+		for _, svc := range effective.Enrichment.Services {
+			if svc != nil {
+				svc.APIKey = ""
+				svc.SecretKey = ""
+			}
+		}
+	}
+
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"client_id":          clientID,
+		"arliai_model":       effective.ArliaiModel,
+		"has_arliai_api_key": effective.ArliaiAPIKey != "",
+		"enrichment":         effective.Enrichment,
+		"web_search":         effective.WebSearch,
+		"spend_limits":       overlay.SpendLimits,
+		"upload_rate_limit":  overlay.UploadRateLimit,
+		"max_workers":        overlay.MaxWorkers,
+		"cache_namespace":    overlay.EffectiveCacheNamespace(),
+	}, http.StatusOK)
+}
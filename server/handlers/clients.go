@@ -124,15 +124,7 @@ func (h *ClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
 
 // CreateClient создает нового клиента
 func (h *ClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
-	var req struct {
-		Name         string `json:"name"`
-		LegalName    string `json:"legal_name"`
-		Description  string `json:"description"`
-		ContactEmail string `json:"contact_email"`
-		ContactPhone string `json:"contact_phone"`
-		TaxID        string `json:"tax_id"`
-		Country      string `json:"country"`
-	}
+	var req types.CreateClientRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
@@ -613,13 +605,7 @@ func (h *ClientHandler) GetClientProjects(w http.ResponseWriter, r *http.Request
 
 // CreateClientProject создает новый проект для клиента
 func (h *ClientHandler) CreateClientProject(w http.ResponseWriter, r *http.Request, clientID int) {
-	var req struct {
-		Name               string  `json:"name"`
-		ProjectType        string  `json:"project_type"`
-		Description        string  `json:"description"`
-		SourceSystem       string  `json:"source_system"`
-		TargetQualityScore float64 `json:"target_quality_score"`
-	}
+	var req types.ClientProjectRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
@@ -704,10 +690,10 @@ func (h *ClientHandler) GetClientProject(w http.ResponseWriter, r *http.Request,
 	}
 
 	// Формируем расширенный ответ
-	response := map[string]interface{}{
-		"project":     project,
-		"client_name": client.Name,
-		"statistics":  stats,
+	response := types.ClientProjectDetails{
+		Project:    project,
+		ClientName: client.Name,
+		Statistics: stats,
 	}
 
 	h.baseHandler.WriteJSONResponse(w, r, response, http.StatusOK)
@@ -715,13 +701,7 @@ func (h *ClientHandler) GetClientProject(w http.ResponseWriter, r *http.Request,
 
 // UpdateClientProject обновляет проект клиента
 func (h *ClientHandler) UpdateClientProject(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
-	var req struct {
-		Name               string  `json:"name"`
-		ProjectType        string  `json:"project_type"`
-		Description        string  `json:"description"`
-		SourceSystem       string  `json:"source_system"`
-		TargetQualityScore float64 `json:"target_quality_score"`
-	}
+	var req types.ClientProjectRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
@@ -1378,30 +1358,9 @@ func (h *ClientHandler) GetProjectDatabases(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Форматируем данные с добавлением статистики
-	databasesWithStats := make([]map[string]interface{}, 0, len(databases))
+	databasesWithStats := make([]types.ProjectDatabaseInfo, 0, len(databases))
 	for _, db := range databases {
-		dbInfo := map[string]interface{}{
-			"id":                db.ID,
-			"client_project_id": db.ClientProjectID,
-			"name":              db.Name,
-			"file_path":         db.FilePath,
-			"description":       db.Description,
-			"is_active":         db.IsActive,
-			"file_size":         db.FileSize,
-			"created_at":        db.CreatedAt.Format(time.RFC3339),
-			"updated_at":        db.UpdatedAt.Format(time.RFC3339),
-		}
-
-		if db.LastUsedAt != nil {
-			dbInfo["last_used_at"] = db.LastUsedAt.Format(time.RFC3339)
-		}
-
-		// Получаем размер файла, если возможно
-		if db.FilePath != "" {
-			if info, err := os.Stat(db.FilePath); err == nil {
-				dbInfo["file_size"] = info.Size()
-			}
-		}
+		dbInfo := newProjectDatabaseInfo(db)
 
 		// Получаем метаданные для конфигурации 1С
 		serviceDB := h.clientService.GetServiceDB()
@@ -1411,10 +1370,10 @@ func (h *ClientHandler) GetProjectDatabases(w http.ResponseWriter, r *http.Reque
 				var metadataMap map[string]interface{}
 				if err := json.Unmarshal([]byte(metadata.MetadataJSON), &metadataMap); err == nil {
 					if configName, ok := metadataMap["config_name"].(string); ok && configName != "" {
-						dbInfo["config_name"] = configName
+						dbInfo.ConfigName = configName
 					}
 					if displayName, ok := metadataMap["display_name"].(string); ok && displayName != "" {
-						dbInfo["display_name"] = displayName
+						dbInfo.DisplayName = displayName
 					}
 				}
 			}
@@ -1423,19 +1382,47 @@ func (h *ClientHandler) GetProjectDatabases(w http.ResponseWriter, r *http.Reque
 		// Добавляем статистику из batch-запроса
 		if statsMap != nil {
 			if stats, exists := statsMap[db.ID]; exists && stats != nil {
-				dbInfo["stats"] = stats
+				dbInfo.Stats = stats
 			}
 		}
 
 		databasesWithStats = append(databasesWithStats, dbInfo)
 	}
 
-	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
-		"databases": databasesWithStats,
-		"total":     len(databasesWithStats),
+	h.baseHandler.WriteJSONResponse(w, r, types.ProjectDatabasesResponse{
+		Databases: databasesWithStats,
+		Total:     len(databasesWithStats),
 	}, http.StatusOK)
 }
 
+// newProjectDatabaseInfo форматирует базу данных проекта для ответа API
+func newProjectDatabaseInfo(db *database.ProjectDatabase) types.ProjectDatabaseInfo {
+	info := types.ProjectDatabaseInfo{
+		ID:              db.ID,
+		ClientProjectID: db.ClientProjectID,
+		Name:            db.Name,
+		FilePath:        db.FilePath,
+		Description:     db.Description,
+		IsActive:        db.IsActive,
+		FileSize:        db.FileSize,
+		CreatedAt:       db.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:       db.UpdatedAt.Format(time.RFC3339),
+	}
+
+	if db.LastUsedAt != nil {
+		info.LastUsedAt = db.LastUsedAt.Format(time.RFC3339)
+	}
+
+	// Получаем размер файла, если возможно
+	if db.FilePath != "" {
+		if stat, err := os.Stat(db.FilePath); err == nil {
+			info.FileSize = stat.Size()
+		}
+	}
+
+	return info
+}
+
 // GetProjectDatabase возвращает базу данных проекта
 func (h *ClientHandler) GetProjectDatabase(w http.ResponseWriter, r *http.Request, clientID, projectID, dbID int) {
 	projectDB, err := h.clientService.GetProjectDatabase(r.Context(), clientID, projectID, dbID)
@@ -1445,35 +1432,16 @@ func (h *ClientHandler) GetProjectDatabase(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Форматируем данные для ответа
-	dbInfo := map[string]interface{}{
-		"id":                projectDB.ID,
-		"client_project_id": projectDB.ClientProjectID,
-		"name":              projectDB.Name,
-		"path":              projectDB.FilePath,
-		"file_path":         projectDB.FilePath,
-		"description":       projectDB.Description,
-		"is_active":         projectDB.IsActive,
-		"status":            "active",
-		"file_size":         projectDB.FileSize,
-		"size":              projectDB.FileSize,
-		"created_at":        projectDB.CreatedAt.Format(time.RFC3339),
-		"updated_at":        projectDB.UpdatedAt.Format(time.RFC3339),
+	info := newProjectDatabaseInfo(projectDB)
+	dbInfo := types.ProjectDatabaseDetails{
+		ProjectDatabaseInfo: info,
+		Path:                projectDB.FilePath,
+		Status:              "active",
+		Size:                info.FileSize,
 	}
 
 	if !projectDB.IsActive {
-		dbInfo["status"] = "inactive"
-	}
-
-	if projectDB.LastUsedAt != nil {
-		dbInfo["last_used_at"] = projectDB.LastUsedAt.Format(time.RFC3339)
-	}
-
-	// Получаем размер файла, если возможно
-	if projectDB.FilePath != "" {
-		if info, err := os.Stat(projectDB.FilePath); err == nil {
-			dbInfo["file_size"] = info.Size()
-			dbInfo["size"] = info.Size()
-		}
+		dbInfo.Status = "inactive"
 	}
 
 	// Получаем статистику из uploads, если доступна основная БД
@@ -1496,7 +1464,7 @@ func (h *ClientHandler) GetProjectDatabase(w http.ResponseWriter, r *http.Reques
 					}
 				}
 			}
-			dbInfo["stats"] = stats
+			dbInfo.Stats = stats
 		}
 		// Игнорируем ошибки получения статистики - это не критично
 	}
@@ -1504,7 +1472,7 @@ func (h *ClientHandler) GetProjectDatabase(w http.ResponseWriter, r *http.Reques
 	// Получаем список таблиц с количеством записей
 	if projectDB.FilePath != "" {
 		if tables, err := h.getDatabaseTables(projectDB.FilePath); err == nil && len(tables) > 0 {
-			dbInfo["tables"] = tables
+			dbInfo.Tables = tables
 			// Подсчитываем общую статистику
 			totalRows := 0
 			for _, table := range tables {
@@ -1512,10 +1480,10 @@ func (h *ClientHandler) GetProjectDatabase(w http.ResponseWriter, r *http.Reques
 					totalRows += rowCount
 				}
 			}
-			dbInfo["statistics"] = map[string]interface{}{
+			dbInfo.Statistics = map[string]interface{}{
 				"total_tables": len(tables),
 				"total_rows":   totalRows,
-				"total_size":   dbInfo["size"],
+				"total_size":   dbInfo.Size,
 			}
 		}
 		// Игнорируем ошибки получения таблиц - это не критично
@@ -2277,11 +2245,7 @@ func (h *ClientHandler) GetClientNomenclature(w http.ResponseWriter, r *http.Req
 
 // CreateProjectDatabase создает новую базу данных для проекта
 func (h *ClientHandler) CreateProjectDatabase(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
-	var req struct {
-		Name        string `json:"name"`
-		FilePath    string `json:"file_path"`
-		Description string `json:"description"`
-	}
+	var req types.ProjectDatabaseRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
@@ -14,6 +14,7 @@ import (
 	"httpserver/database"
 	"httpserver/enrichment"
 	"httpserver/normalization"
+	"httpserver/server/types"
 )
 
 const (
@@ -41,6 +42,9 @@ type CounterpartyService interface {
 	BulkDeleteCounterparties(ids []int) (map[string]interface{}, error)
 	DeleteCounterpartyDuplicateGroup(projectID int, groupID string) error
 	ResolveCounterpartyDuplicateGroup(projectID int, groupID string) (*database.NormalizedCounterparty, error)
+	GetCounterpartyMergeHistory(counterpartyID int) ([]*database.CounterpartyMergeEvent, error)
+	GetCounterpartyMergeEvent(eventID int) (*database.CounterpartyMergeEvent, error)
+	UnmergeCounterparties(eventID int, unmergedBy string) (*database.CounterpartyMergeEvent, error)
 }
 
 // CounterpartyHandler обработчик для контрагентов
@@ -492,6 +496,12 @@ func (h *CounterpartyHandler) HandleNormalizedCounterpartyRoutes(w http.Response
 		}
 		return
 	}
+
+	// История слияний контрагента: /api/counterparties/normalized/{id}/merges
+	if len(parts) == 2 && parts[1] == "merges" {
+		h.HandleCounterpartyMergeHistory(w, r)
+		return
+	}
 }
 
 // HandleCounterpartyDuplicatesRoutes обрабатывает маршруты для дубликатов контрагентов
@@ -657,12 +667,9 @@ func (h *CounterpartyHandler) HandleNormalizedCounterparties(w http.ResponseWrit
 	}
 
 	// Формируем ответ с информацией о проектах
-	projectsInfo := make([]map[string]interface{}, len(projects))
+	projectsInfo := make([]types.ProjectRef, len(projects))
 	for i, p := range projects {
-		projectsInfo[i] = map[string]interface{}{
-			"id":   p.ID,
-			"name": p.Name,
-		}
+		projectsInfo[i] = types.ProjectRef{ID: p.ID, Name: p.Name}
 	}
 
 	// Логирование успешного ответа
@@ -674,13 +681,13 @@ func (h *CounterpartyHandler) HandleNormalizedCounterparties(w http.ResponseWrit
 		Endpoint: "/api/counterparties/normalized",
 	})
 
-	h.WriteJSONResponse(w, r, map[string]interface{}{
-		"counterparties": counterparties,
-		"projects":       projectsInfo,
-		"total":          totalCount,
-		"offset":         offset,
-		"limit":          limit,
-		"page":           page,
+	h.WriteJSONResponse(w, r, types.NormalizedCounterpartiesResponse{
+		Counterparties: counterparties,
+		Projects:       projectsInfo,
+		Total:          totalCount,
+		Offset:         offset,
+		Limit:          limit,
+		Page:           page,
 	}, http.StatusOK)
 }
 
@@ -25,6 +25,7 @@ type mockCounterpartyService struct {
 	bulkDeleteCounterpartiesFunc            func(ids []int) (map[string]interface{}, error)
 	deleteCounterpartyDuplicateGroupFunc    func(projectID int, groupID string) error
 	resolveCounterpartyDuplicateGroupFunc   func(projectID int, groupID string) (*database.NormalizedCounterparty, error)
+	unmergeCounterpartiesFunc               func(eventID int, unmergedBy string) (*database.CounterpartyMergeEvent, error)
 }
 
 func (m *mockCounterpartyService) GetServiceDB() *database.ServiceDB {
@@ -125,6 +126,21 @@ func (m *mockCounterpartyService) ResolveCounterpartyDuplicateGroup(projectID in
 	return nil, nil
 }
 
+func (m *mockCounterpartyService) GetCounterpartyMergeHistory(counterpartyID int) ([]*database.CounterpartyMergeEvent, error) {
+	return []*database.CounterpartyMergeEvent{}, nil
+}
+
+func (m *mockCounterpartyService) GetCounterpartyMergeEvent(eventID int) (*database.CounterpartyMergeEvent, error) {
+	return &database.CounterpartyMergeEvent{ID: eventID}, nil
+}
+
+func (m *mockCounterpartyService) UnmergeCounterparties(eventID int, unmergedBy string) (*database.CounterpartyMergeEvent, error) {
+	if m.unmergeCounterpartiesFunc != nil {
+		return m.unmergeCounterpartiesFunc(eventID, unmergedBy)
+	}
+	return &database.CounterpartyMergeEvent{ID: eventID}, nil
+}
+
 // setupTestHandler создает тестовый обработчик
 func setupTestCounterpartyHandler(svc CounterpartyService) *CounterpartyHandler {
 	baseHandler := NewBaseHandler(
@@ -323,3 +339,37 @@ func TestHandleNormalizedCounterparties_WithPagination(t *testing.T) {
 		t.Errorf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
 	}
 }
+
+// TestHandleCounterpartyMergeRoutes_Unmerge тестирует отмену слияния
+func TestHandleCounterpartyMergeRoutes_Unmerge(t *testing.T) {
+	var gotEventID int
+	var gotUser string
+	mockService := &mockCounterpartyService{
+		unmergeCounterpartiesFunc: func(eventID int, unmergedBy string) (*database.CounterpartyMergeEvent, error) {
+			gotEventID, gotUser = eventID, unmergedBy
+			return &database.CounterpartyMergeEvent{ID: eventID, Status: database.CounterpartyMergeStatusUnmerged}, nil
+		},
+	}
+
+	handler := setupTestCounterpartyHandler(mockService)
+
+	req := httptest.NewRequest("POST", "/api/counterparties/merges/7/unmerge", nil)
+	req.Header.Set("X-User", "operator")
+	w := httptest.NewRecorder()
+
+	handler.HandleCounterpartyMergeRoutes(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if gotEventID != 7 || gotUser != "operator" {
+		t.Errorf("UnmergeCounterparties called with (%d, %q), want (7, \"operator\")", gotEventID, gotUser)
+	}
+
+	req = httptest.NewRequest("GET", "/api/counterparties/merges/7/unmerge", nil)
+	w = httptest.NewRecorder()
+	handler.HandleCounterpartyMergeRoutes(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET unmerge, got %d", w.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HandleCounterpartyMergeHistory возвращает историю слияний нормализованного контрагента
+// GET /api/counterparties/normalized/{id}/merges
+func (h *CounterpartyHandler) HandleCounterpartyMergeHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/counterparties/normalized/")
+	id, err := ValidateIDPathParam(strings.TrimSuffix(path, "/merges"), "counterparty_id")
+	if err != nil {
+		h.WriteJSONError(w, r, fmt.Sprintf("Invalid counterparty ID: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.counterpartyService.GetCounterpartyMergeHistory(id)
+	if err != nil {
+		h.HandleHTTPError(w, r, err)
+		return
+	}
+	h.WriteJSONResponse(w, r, map[string]interface{}{
+		"counterparty_id": id,
+		"merges":          events,
+		"total":           len(events),
+	}, http.StatusOK)
+}
+
+// HandleCounterpartyMergeRoutes обрабатывает запросы к событиям слияния
+// GET /api/counterparties/merges/{eventId}, POST /api/counterparties/merges/{eventId}/unmerge
+func (h *CounterpartyHandler) HandleCounterpartyMergeRoutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/counterparties/merges"), "/")
+	parts := strings.Split(path, "/")
+
+	eventID, err := ValidateIDPathParam(parts[0], "merge_id")
+	if err != nil {
+		h.WriteJSONError(w, r, fmt.Sprintf("Invalid merge ID: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		if r.Method != http.MethodGet {
+			h.HandleMethodNotAllowed(w, r, http.MethodGet)
+			return
+		}
+		event, err := h.counterpartyService.GetCounterpartyMergeEvent(eventID)
+		if err != nil {
+			h.HandleHTTPError(w, r, err)
+			return
+		}
+		h.WriteJSONResponse(w, r, event, http.StatusOK)
+	case len(parts) == 2 && parts[1] == "unmerge":
+		if r.Method != http.MethodPost {
+			h.HandleMethodNotAllowed(w, r, http.MethodPost)
+			return
+		}
+		event, err := h.counterpartyService.UnmergeCounterparties(eventID, r.Header.Get("X-User"))
+		if err != nil {
+			h.HandleHTTPError(w, r, err)
+			return
+		}
+		if h.logFunc != nil {
+			h.logFunc(LogEntry{
+				Timestamp: time.Now(),
+				Level:     "INFO",
+				Message:   fmt.Sprintf("Counterparty merge %d reverted, restored counterparties: %v", eventID, event.MergedIDs),
+				Endpoint:  r.URL.Path,
+			})
+		}
+		h.WriteJSONResponse(w, r, map[string]interface{}{
+			"success": true,
+			"message": "Merge reverted successfully",
+			"merge":   event,
+		}, http.StatusOK)
+	default:
+		h.WriteJSONError(w, r, "Not found", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// CounterpartyReverificationHandler обработчик повторной сверки контрагентов с реестрами
+type CounterpartyReverificationHandler struct {
+	service          *services.CounterpartyReverificationService
+	baseHandler      *BaseHandler
+	schedulerEnabled func() bool
+}
+
+// NewCounterpartyReverificationHandler создает обработчик повторной сверки контрагентов
+func NewCounterpartyReverificationHandler(service *services.CounterpartyReverificationService, baseHandler *BaseHandler, schedulerEnabled func() bool) *CounterpartyReverificationHandler {
+	return &CounterpartyReverificationHandler{
+		service:          service,
+		baseHandler:      baseHandler,
+		schedulerEnabled: schedulerEnabled,
+	}
+}
+
+// HandleStatus возвращает параметры расписания, итог последнего запуска и сводку по статусам
+// GET /api/counterparties/reverification/status
+func (h *CounterpartyReverificationHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.Summary()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	opts := h.service.Options()
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"scheduler_enabled": h.schedulerEnabled != nil && h.schedulerEnabled(),
+		"max_age":           opts.MaxAge.String(),
+		"high_risk_max_age": opts.HighRiskMaxAge.String(),
+		"batch_size":        opts.BatchSize,
+		"apply_changes":     opts.ApplyChanges,
+		"last_run":          h.service.LastRun(),
+		"statuses":          summary,
+	}, http.StatusOK)
+}
+
+// HandleRun запускает сверку очередной порции контрагентов вне расписания
+// POST /api/counterparties/reverification/run
+func (h *CounterpartyReverificationHandler) HandleRun(w http.ResponseWriter, r *http.Request) {
+	run, err := h.service.RunOnce()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, run, http.StatusOK)
+}
+
+// HandleChanges возвращает журнал изменений реквизитов, обнаруженных при сверке
+// GET /api/counterparties/reverification/changes?project_id=&counterparty_id=&severity=&limit=&offset=
+func (h *CounterpartyReverificationHandler) HandleChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.CounterpartyEnrichmentChangeFilter{Severity: query.Get("severity")}
+	filter.ProjectID, _ = strconv.Atoi(query.Get("project_id"))
+	filter.CounterpartyID, _ = strconv.Atoi(query.Get("counterparty_id"))
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	changes, total, err := h.service.GetChanges(filter)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"changes": changes,
+		"total":   total,
+	}, http.StatusOK)
+}
+
+// HandleCounterparty возвращает состояние сверки (GET) или сверяет контрагента немедленно (POST)
+// GET/POST /api/counterparties/reverification/{id}
+func (h *CounterpartyReverificationHandler) HandleCounterparty(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		state, err := h.service.GetState(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, state, http.StatusOK)
+	case http.MethodPost:
+		outcome, err := h.service.VerifyCounterparty(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, outcome, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"httpserver/database"
 	"httpserver/server/services"
 	"httpserver/server/types"
 )
@@ -17,6 +18,7 @@ type DashboardHandler struct {
 	qualityService       services.QualityServiceInterface // Используем интерфейс для улучшения тестируемости
 	baseHandler          *BaseHandler
 	getMonitoringMetrics func() MonitoringData // Функция для получения метрик провайдеров
+	getQualityAnomalies  func() (*services.QualityAnomalySummary, error)
 }
 
 // NewDashboardHandler создает новый обработчик для работы с дашбордом
@@ -50,6 +52,11 @@ func NewDashboardHandlerWithServices(
 	}
 }
 
+// SetQualityAnomalySummary задает источник сводки открытых аномалий в трендах качества для обзора
+func (h *DashboardHandler) SetQualityAnomalySummary(fn func() (*services.QualityAnomalySummary, error)) {
+	h.getQualityAnomalies = fn
+}
+
 // HandleGetStats обрабатывает запросы к /api/dashboard/stats
 func (h *DashboardHandler) HandleGetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -206,6 +213,16 @@ func (h *DashboardHandler) HandleDashboardOverview(w http.ResponseWriter, r *htt
 		}
 	}
 
+	// Открытые аномалии в трендах качества; критические понижают SystemHealth до warning
+	if h.getQualityAnomalies != nil {
+		if summary, err := h.getQualityAnomalies(); err == nil {
+			response.QualityAnomalies = summary
+			if summary.BySeverity[database.QualityAnomalySeverityCritical] > 0 && response.SystemHealth == "ok" {
+				response.SystemHealth = "warning"
+			}
+		}
+	}
+
 	// Получаем RecentActivity из разных источников (uploads, databases, projects)
 	if h.dashboardService != nil {
 		activities, err := h.dashboardService.GetRecentActivity(20)
@@ -7,9 +7,9 @@ import (
 	"fmt"
 	"log"
 	"net/http"
-	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
 	"httpserver/database"
@@ -830,9 +830,6 @@ func (h *DataPreviewHandler) getCounterpartiesFromCounterpartiesTable(
 			attributes = make(map[string]interface{})
 		}
 
-		// Нормализуем данные контрагента
-		normalized := normalizeCounterpartyData(name.String, innBin.String, legalAddress.String, actualAddress.String, contactPhone.String, contactEmail.String, attributes)
-
 		record := map[string]interface{}{
 			"id":                   id,
 			"reference":            reference.String,
@@ -846,14 +843,27 @@ func (h *DataPreviewHandler) getCounterpartiesFromCounterpartiesTable(
 			"source_database_id":   projectDB.ID,
 			"source_database_name": projectDB.Name,
 			"source_database_path": projectDB.FilePath,
-			// Добавляем нормализованные поля
-			"normalized": normalized,
 		}
 
 		records = append(records, record)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-	return records, totalCount, rows.Err()
+	// Нормализуем данные контрагентов с учетом реквизитов из хранилища атрибутов
+	stored := loadStoredAttributes(ctx, conn, records)
+	for _, record := range records {
+		attributes, _ := record["attributes"].(map[string]interface{})
+		record["normalized"] = normalizeCounterpartyData(
+			record["name"].(string), record["inn_bin"].(string),
+			record["legal_address"].(string), record["actual_address"].(string),
+			record["contact_phone"].(string), record["contact_email"].(string),
+			attributes, stored[record["reference"].(string)],
+		)
+	}
+
+	return records, totalCount, nil
 }
 
 // getCounterpartiesFromCatalogItems получает записи из таблицы catalog_items
@@ -866,13 +876,14 @@ func (h *DataPreviewHandler) getCounterpartiesFromCatalogItems(
 ) ([]map[string]interface{}, int, error) {
 	// Проверяем, что это контрагенты
 	countQuery := `
-		SELECT COUNT(*) FROM catalog_items 
-		WHERE catalog_name IN ('Контрагенты', 'КонтрагентыЮрЛица', 'КонтрагентыФизЛица')
+		SELECT COUNT(*) FROM catalog_items ci
+		JOIN catalogs c ON ci.catalog_id = c.id
+		WHERE c.name IN ('Контрагенты', 'КонтрагентыЮрЛица', 'КонтрагентыФизЛица')
 	`
 	var countArgs []interface{}
 
 	if search != "" {
-		countQuery += " AND (name LIKE ? OR code LIKE ?)"
+		countQuery += " AND (ci.name LIKE ? OR ci.code LIKE ?)"
 		searchParam := "%" + search + "%"
 		countArgs = append(countArgs, searchParam, searchParam)
 	}
@@ -885,18 +896,18 @@ func (h *DataPreviewHandler) getCounterpartiesFromCatalogItems(
 
 	query := `
 		SELECT 
-			id,
-			reference,
-			code,
-			name,
-			COALESCE(attributes, '{}') as attributes
-		FROM catalog_items
-		WHERE catalog_name IN ('Контрагенты', 'КонтрагентыЮрЛица', 'КонтрагентыФизЛица')
+			ci.id,
+			ci.reference,
+			ci.code,
+			ci.name
+		FROM catalog_items ci
+		JOIN catalogs c ON ci.catalog_id = c.id
+		WHERE c.name IN ('Контрагенты', 'КонтрагентыЮрЛица', 'КонтрагентыФизЛица')
 	`
 
 	var args []interface{}
 	if search != "" {
-		query += " AND (name LIKE ? OR code LIKE ?)"
+		query += " AND (ci.name LIKE ? OR ci.code LIKE ?)"
 		searchParam := "%" + search + "%"
 		args = append(args, searchParam, searchParam)
 	}
@@ -904,7 +915,7 @@ func (h *DataPreviewHandler) getCounterpartiesFromCatalogItems(
 	// Используем внутренний лимит для защиты от перегрузки памяти
 	// Но не применяем offset, так как пагинация будет применена после объединения всех БД
 	const maxRecordsPerDB = 50000
-	query += " ORDER BY name LIMIT ?"
+	query += " ORDER BY ci.name LIMIT ?"
 	args = append(args, maxRecordsPerDB)
 
 	rows, err := conn.QueryContext(ctx, query, args...)
@@ -917,32 +928,17 @@ func (h *DataPreviewHandler) getCounterpartiesFromCatalogItems(
 	for rows.Next() {
 		var id int
 		var reference, code, name sql.NullString
-		var attributesJSON string
 
-		err := rows.Scan(&id, &reference, &code, &name, &attributesJSON)
+		err := rows.Scan(&id, &reference, &code, &name)
 		if err != nil {
 			continue
 		}
 
-		// Парсим attributes
-		var attributes map[string]interface{}
-		if attributesJSON != "" {
-			if err := json.Unmarshal([]byte(attributesJSON), &attributes); err != nil {
-				// Логируем ошибку парсинга, но продолжаем с пустым attributes
-				log.Printf("Failed to unmarshal attributes_json for record %d: %v", id, err)
-				attributes = make(map[string]interface{})
-			}
-		}
-		if attributes == nil {
-			attributes = make(map[string]interface{})
-		}
-
 		record := map[string]interface{}{
 			"id":                   id,
 			"reference":            reference.String,
 			"code":                 code.String,
 			"name":                 name.String,
-			"attributes":           attributes,
 			"source_database_id":   projectDB.ID,
 			"source_database_name": projectDB.Name,
 			"source_database_path": projectDB.FilePath,
@@ -950,12 +946,28 @@ func (h *DataPreviewHandler) getCounterpartiesFromCatalogItems(
 
 		records = append(records, record)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 
-	return records, totalCount, rows.Err()
+	// Реквизиты элементов справочника берем из хранилища атрибутов
+	stored := loadStoredAttributes(ctx, conn, records)
+	for _, record := range records {
+		requisites := stored[record["reference"].(string)]
+		attributes := make(map[string]interface{}, len(requisites))
+		for name, value := range requisites {
+			attributes[name] = value
+		}
+		record["attributes"] = attributes
+		record["normalized"] = normalizeCounterpartyData(record["name"].(string), "", "", "", "", "", attributes, nil)
+	}
+
+	return records, totalCount, nil
 }
 
 // normalizeCounterpartyData нормализует данные контрагента, извлекая поля из атрибутов
-func normalizeCounterpartyData(name, innBin, legalAddress, actualAddress, contactPhone, contactEmail string, attributes map[string]interface{}) map[string]interface{} {
+// stored - реквизиты шапки исходного элемента справочника из хранилища атрибутов (может быть nil)
+func normalizeCounterpartyData(name, innBin, legalAddress, actualAddress, contactPhone, contactEmail string, attributes map[string]interface{}, stored map[string]string) map[string]interface{} {
 	normalized := make(map[string]interface{})
 
 	// Извлекаем полное наименование
@@ -1016,13 +1028,10 @@ func normalizeCounterpartyData(name, innBin, legalAddress, actualAddress, contac
 		normalized["email"] = contactEmail
 	}
 
-	// Парсим XML-подобные структуры в attributes, если они есть
-	if attrsStr, ok := attributes["attributes"].(string); ok && attrsStr != "" {
-		xmlAttrs := parseAttributesXML(attrsStr)
-		for k, v := range xmlAttrs {
-			if _, exists := normalized[k]; !exists {
-				normalized[k] = v
-			}
+	// Добавляем реквизиты из хранилища атрибутов, разобранные при загрузке
+	for k, v := range stored {
+		if _, exists := normalized[k]; !exists {
+			normalized[k] = v
 		}
 	}
 
@@ -1041,42 +1050,51 @@ func extractField(attributes map[string]interface{}, keys ...string) string {
 	return ""
 }
 
-// parseAttributesXML парсит XML-подобные атрибуты вида <Реквизит Имя="..." Значение="..."/>
-func parseAttributesXML(xmlString string) map[string]interface{} {
-	result := make(map[string]interface{})
-
-	if xmlString == "" {
-		return result
-	}
-
-	// Парсим структуру вида: <Реквизит Имя="НаименованиеПолное" Тип="Строка" Значение="..."></Реквизит>
-	// Используем регулярное выражение для извлечения пар имя-значение
-	re := regexp.MustCompile(`<Реквизит\s+[^>]*Имя=["']([^"']+)["'][^>]*Значение=["']([^"']*)["'][^>]*>`)
-	matches := re.FindAllStringSubmatch(xmlString, -1)
-
-	for _, match := range matches {
-		if len(match) >= 3 {
-			name := match[1]
-			value := match[2]
-			if name != "" && value != "" {
-				result[name] = value
-			}
+// loadStoredAttributes возвращает реквизиты шапки элементов справочников из хранилища
+// source_item_attributes по ссылкам записей (reference -> имя -> значение).
+// Для баз без хранилища возвращает пустую карту.
+func loadStoredAttributes(ctx context.Context, conn *sql.DB, records []map[string]interface{}) map[string]map[string]string {
+	result := make(map[string]map[string]string)
+	refs := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		if ref, _ := record["reference"].(string); ref != "" {
+			refs = append(refs, ref)
 		}
 	}
 
-	// Также пробуем парсить самозакрывающиеся теги
-	reSelfClosing := regexp.MustCompile(`<Реквизит\s+[^>]*Имя=["']([^"']+)["'][^>]*Значение=["']([^"']*)["'][^>]*\/>`)
-	matchesSelfClosing := reSelfClosing.FindAllStringSubmatch(xmlString, -1)
-
-	for _, match := range matchesSelfClosing {
-		if len(match) >= 3 {
-			name := match[1]
-			value := match[2]
-			if name != "" && value != "" {
-				result[name] = value
+	const batchSize = 500
+	for start := 0; start < len(refs); start += batchSize {
+		end := start + batchSize
+		if end > len(refs) {
+			end = len(refs)
+		}
+		batch := refs[start:end]
+		args := append([]interface{}{database.SourceItemTypeCatalog}, batch...)
+		rows, err := conn.QueryContext(ctx, `
+			SELECT ci.reference, a.name, COALESCE(a.value, '')
+			FROM source_item_attributes a
+			JOIN catalog_items ci ON ci.id = a.item_id
+			WHERE a.item_type = ? AND a.table_part = ''
+			  AND ci.reference IN (`+strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")+`)
+			ORDER BY a.id
+		`, args...)
+		if err != nil {
+			log.Printf("Failed to query stored attributes: %v", err)
+			return result
+		}
+		for rows.Next() {
+			var ref, name, value string
+			if err := rows.Scan(&ref, &name, &value); err != nil || value == "" {
+				continue
+			}
+			if result[ref] == nil {
+				result[ref] = make(map[string]string)
+			}
+			if _, exists := result[ref][name]; !exists {
+				result[ref][name] = value
 			}
 		}
+		rows.Close()
 	}
-
 	return result
 }
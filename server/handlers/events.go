@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "httpserver/server/errors"
+	"httpserver/server/events"
+	"httpserver/server/types"
+
+	"golang.org/x/net/websocket"
+)
+
+// eventsHeartbeatInterval интервал heartbeat для потоков событий (меньше WriteTimeout сервера)
+const eventsHeartbeatInterval = 15 * time.Second
+
+// EventsHandler единая точка подписки на события шины через SSE и WebSocket
+type EventsHandler struct {
+	bus         *events.Bus
+	baseHandler *BaseHandler
+}
+
+// NewEventsHandler создает обработчик подписки на события
+func NewEventsHandler(bus *events.Bus, baseHandler *BaseHandler) *EventsHandler {
+	return &EventsHandler{
+		bus:         bus,
+		baseHandler: baseHandler,
+	}
+}
+
+// eventSubscriptionRequest управляющее сообщение клиента WebSocket
+type eventSubscriptionRequest struct {
+	Action      string         `json:"action"` // subscribe | ping
+	Topics      []events.Topic `json:"topics"`
+	ClientID    int            `json:"client_id"`
+	ProjectID   int            `json:"project_id"`
+	SessionID   string         `json:"session_id"`
+	LastEventID uint64         `json:"last_event_id"`
+}
+
+// eventControlMessage служебное сообщение сервера WebSocket (в отличие от событий не содержит topic и id)
+type eventControlMessage struct {
+	Type   string         `json:"type"`
+	Topics []events.Topic `json:"topics,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// HandleStream подписка на события через SSE
+// GET /api/events/stream?topics=normalization,uploads&client_id=1&project_id=2&session_id=...&last_event_id=...
+// При переподключении EventSource передает заголовок Last-Event-ID, пропущенные события воспроизводятся из буфера.
+func (h *EventsHandler) HandleStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	opts, err := parseEventSubscription(r.URL.Query(), r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub, err := h.subscribe(opts)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	defer sub.Close()
+
+	// Поток живет дольше WriteTimeout сервера
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control, Last-Event-ID")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(eventsHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-sub.Events():
+			if err := writeSSEEvent(w, e); err != nil {
+				slog.Error("[Events] Error sending SSE event", "error", err, "path", r.URL.Path)
+				return
+			}
+			flusher.Flush()
+		case <-sub.Done():
+			if reason := sub.Reason(); reason != "" {
+				writeSSEEvent(w, events.Event{Type: reason, Timestamp: time.Now()})
+				flusher.Flush()
+			}
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// HandleWebSocket подписка на события через WebSocket
+// GET /api/events/ws?topics=...&client_id=...&project_id=...&session_id=...&last_event_id=...
+// Клиент может сменить подписку сообщением {"action":"subscribe","topics":[...],"client_id":1,"last_event_id":42}.
+func (h *EventsHandler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseEventSubscription(r.URL.Query(), "")
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	server := websocket.Server{
+		// Проверка Origin не выполняется: CORS для API настраивается на уровне middleware
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serveWebSocket(ws, opts)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveWebSocket обслуживает соединение WebSocket до отключения клиента
+func (h *EventsHandler) serveWebSocket(ws *websocket.Conn, opts events.SubscribeOptions) {
+	defer ws.Close()
+	// Соединение перехвачено у http.Server, снимаем унаследованные таймауты
+	_ = ws.SetDeadline(time.Time{})
+
+	send := func(v interface{}) error {
+		_ = ws.SetWriteDeadline(time.Now().Add(2 * eventsHeartbeatInterval))
+		return websocket.JSON.Send(ws, v)
+	}
+
+	sub, err := h.subscribe(opts)
+	if err != nil {
+		send(eventControlMessage{Type: "error", Error: err.Error()})
+		return
+	}
+	defer func() { sub.Close() }()
+	if err := send(eventControlMessage{Type: "subscribed", Topics: opts.Topics}); err != nil {
+		return
+	}
+
+	requests := make(chan eventSubscriptionRequest)
+	go func() {
+		defer close(requests)
+		for {
+			var req eventSubscriptionRequest
+			if err := websocket.JSON.Receive(ws, &req); err != nil {
+				return
+			}
+			requests <- req
+		}
+	}()
+
+	ticker := time.NewTicker(eventsHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case e := <-sub.Events():
+			if err := send(e); err != nil {
+				return
+			}
+		case <-sub.Done():
+			if reason := sub.Reason(); reason != "" {
+				send(eventControlMessage{Type: reason})
+			}
+			return
+		case req, ok := <-requests:
+			if !ok {
+				return
+			}
+			switch req.Action {
+			case "ping":
+				if err := send(eventControlMessage{Type: "pong"}); err != nil {
+					return
+				}
+			case "subscribe":
+				next, err := h.resubscribe(sub, req)
+				if err != nil {
+					if sendErr := send(eventControlMessage{Type: "error", Error: err.Error()}); sendErr != nil {
+						return
+					}
+					continue
+				}
+				sub = next.sub
+				if err := send(eventControlMessage{Type: "subscribed", Topics: next.topics}); err != nil {
+					return
+				}
+			default:
+				if err := send(eventControlMessage{Type: "error", Error: fmt.Sprintf("unknown action %q", req.Action)}); err != nil {
+					return
+				}
+			}
+		case <-ticker.C:
+			if err := send(eventControlMessage{Type: "heartbeat"}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type resubscription struct {
+	sub    *events.Subscription
+	topics []events.Topic
+}
+
+// resubscribe заменяет подписку соединения; при ошибке текущая подписка сохраняется
+func (h *EventsHandler) resubscribe(current *events.Subscription, req eventSubscriptionRequest) (*resubscription, error) {
+	topics := req.Topics
+	if len(topics) == 0 {
+		topics = append([]events.Topic(nil), events.KnownTopics...)
+	}
+	for _, topic := range topics {
+		if !events.IsKnownTopic(topic) {
+			return nil, fmt.Errorf("unknown topic %q", topic)
+		}
+	}
+	// Текущая подписка закрывается до создания новой, чтобы не упереться в лимит подписчиков
+	current.Close()
+	sub, err := h.subscribe(events.SubscribeOptions{
+		Topics:      topics,
+		Filter:      events.Filter{ClientID: req.ClientID, ProjectID: req.ProjectID, SessionID: req.SessionID},
+		LastEventID: req.LastEventID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resubscription{sub: sub, topics: topics}, nil
+}
+
+// HandleStats возвращает статистику шины событий
+// GET /api/events/stats
+func (h *EventsHandler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, h.bus.Stats(), http.StatusOK)
+}
+
+// subscribe создает подписку и переводит ошибки шины в ошибки приложения
+func (h *EventsHandler) subscribe(opts events.SubscribeOptions) (*events.Subscription, error) {
+	sub, err := h.bus.Subscribe(opts)
+	switch {
+	case err == nil:
+		return sub, nil
+	case errors.Is(err, events.ErrTooManySubscribers):
+		return nil, apperrors.NewServiceUnavailableError("превышено число подписчиков на события", err)
+	case errors.Is(err, events.ErrUnknownTopic):
+		return nil, NewValidationError("неизвестный топик событий", err)
+	default:
+		return nil, apperrors.NewServiceUnavailableError("шина событий недоступна", err)
+	}
+}
+
+// parseEventSubscription разбирает параметры подписки из query; lastEventHeader имеет приоритет над last_event_id
+func parseEventSubscription(query url.Values, lastEventHeader string) (events.SubscribeOptions, error) {
+	var opts events.SubscribeOptions
+
+	topics, err := events.ParseTopics(query.Get("topics"))
+	if err != nil {
+		return opts, NewValidationError(fmt.Sprintf("неизвестный топик в %q", query.Get("topics")), err)
+	}
+	opts.Topics = topics
+
+	intParam := func(name string) (int, error) {
+		value := strings.TrimSpace(query.Get(name))
+		if value == "" {
+			return 0, nil
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, NewValidationError(fmt.Sprintf("некорректный параметр %s", name), err)
+		}
+		return parsed, nil
+	}
+	if opts.Filter.ClientID, err = intParam("client_id"); err != nil {
+		return opts, err
+	}
+	if opts.Filter.ProjectID, err = intParam("project_id"); err != nil {
+		return opts, err
+	}
+	opts.Filter.SessionID = strings.TrimSpace(query.Get("session_id"))
+
+	lastEventID := strings.TrimSpace(lastEventHeader)
+	if lastEventID == "" {
+		lastEventID = strings.TrimSpace(query.Get("last_event_id"))
+	}
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return opts, NewValidationError("некорректный Last-Event-ID", err)
+		}
+		opts.LastEventID = parsed
+	}
+	return opts, nil
+}
+
+// writeSSEEvent пишет событие шины в формате SSE с id для Last-Event-ID
+func writeSSEEvent(w http.ResponseWriter, e events.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if e.ID > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", e.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", payload)
+	return err
+}
+
+// StreamLogEvents транслирует журнальные события топика в SSE в прежнем формате
+// {"type":"log","message":...,"timestamp":...}. Если шина не задана, события читаются из fallback.
+// Заголовки SSE и приветственное сообщение отправляет вызывающий обработчик.
+func StreamLogEvents(w http.ResponseWriter, r *http.Request, flusher http.Flusher, bus *events.Bus, topic events.Topic, fallback <-chan string, heartbeat time.Duration, logPrefix string) {
+	writeLog := func(id uint64, message string, timestamp time.Time) error {
+		if id > 0 {
+			if _, err := fmt.Fprintf(w, "id: %d\n", id); err != nil {
+				return err
+			}
+		}
+		eventJSON, err := json.Marshal(types.StreamEvent{
+			Type:      "log",
+			Message:   message,
+			Timestamp: timestamp.Format(time.RFC3339),
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", eventJSON); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+
+	var busEvents <-chan events.Event
+	var busDone <-chan struct{}
+	if bus != nil {
+		lastEventID, _ := strconv.ParseUint(strings.TrimSpace(r.Header.Get("Last-Event-ID")), 10, 64)
+		sub, err := bus.Subscribe(events.SubscribeOptions{Topics: []events.Topic{topic}, LastEventID: lastEventID})
+		if err != nil {
+			slog.Error(logPrefix+" Failed to subscribe to event bus", "error", err, "path", r.URL.Path)
+			return
+		}
+		defer sub.Close()
+		busEvents, busDone = sub.Events(), sub.Done()
+		// Канал читает шина, прямое чтение украло бы события у других подписчиков
+		fallback = nil
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case message := <-fallback:
+			if err := writeLog(0, message, time.Now()); err != nil {
+				slog.Error(logPrefix+" Error sending SSE event", "error", err, "path", r.URL.Path)
+				return
+			}
+		case e := <-busEvents:
+			if e.Type == events.EventTypeReplayTruncated {
+				continue
+			}
+			if err := writeLog(e.ID, e.Message(), e.Timestamp); err != nil {
+				slog.Error(logPrefix+" Error sending SSE event", "error", err, "path", r.URL.Path)
+				return
+			}
+		case <-busDone:
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
+				slog.Error(logPrefix+" Error sending heartbeat", "error", err, "path", r.URL.Path)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			slog.Info(logPrefix+" Client disconnected", "error", r.Context().Err(), "path", r.URL.Path)
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"httpserver/server/events"
+
+	"golang.org/x/net/websocket"
+)
+
+func newTestEventsServer(t *testing.T) (*events.Bus, *httptest.Server) {
+	t.Helper()
+	bus := events.NewBus(events.DefaultOptions())
+	handler := NewEventsHandler(bus, NewBaseHandlerFromMiddleware())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/events/stream", handler.HandleStream)
+	mux.HandleFunc("/api/events/ws", handler.HandleWebSocket)
+	server := httptest.NewServer(mux)
+	t.Cleanup(func() {
+		bus.Close()
+		server.Close()
+	})
+	return bus, server
+}
+
+// waitForSubscriber ждет, пока обработчик зарегистрирует подписку
+func waitForSubscriber(t *testing.T, bus *events.Bus, topic events.Topic) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !bus.HasSubscribers(topic) {
+		if time.Now().After(deadline) {
+			t.Fatalf("подписка на %s не создана", topic)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestEventsHandler_StreamReplaysAfterLastEventID(t *testing.T) {
+	bus, server := newTestEventsServer(t)
+
+	first := bus.PublishData(events.TopicUploads, "upload.started", events.Filter{ClientID: 1}, nil)
+	bus.PublishData(events.TopicUploads, "upload.started", events.Filter{ClientID: 2}, nil)
+	missed := bus.PublishData(events.TopicUploads, "upload.completed", events.Filter{ClientID: 1}, nil)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/events/stream?topics=uploads&client_id=1", nil)
+	req.Header.Set("Last-Event-ID", strconv.FormatUint(first.ID, 10))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("запрос потока: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var idLine, dataLine string
+	for idLine == "" || dataLine == "" {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("чтение потока: %v", err)
+		}
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			idLine = strings.TrimSpace(strings.TrimPrefix(line, "id: "))
+		case strings.HasPrefix(line, "data: "):
+			dataLine = strings.TrimPrefix(line, "data: ")
+		}
+	}
+
+	if idLine != strconv.FormatUint(missed.ID, 10) {
+		t.Errorf("id = %s, ожидался %d", idLine, missed.ID)
+	}
+	var e events.Event
+	if err := json.Unmarshal([]byte(dataLine), &e); err != nil {
+		t.Fatalf("разбор события: %v", err)
+	}
+	if e.Type != "upload.completed" || e.ClientID != 1 {
+		t.Errorf("получено событие %+v", e)
+	}
+}
+
+func TestEventsHandler_StreamRejectsUnknownTopic(t *testing.T) {
+	_, server := newTestEventsServer(t)
+
+	resp, err := http.Get(server.URL + "/api/events/stream?topics=bogus")
+	if err != nil {
+		t.Fatalf("запрос потока: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("статус %d, ожидался 400", resp.StatusCode)
+	}
+}
+
+func TestEventsHandler_WebSocketResubscribe(t *testing.T) {
+	bus, server := newTestEventsServer(t)
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/events/ws?topics=normalization"
+	ws, err := websocket.Dial(wsURL, "", server.URL)
+	if err != nil {
+		t.Fatalf("подключение WebSocket: %v", err)
+	}
+	defer ws.Close()
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var control eventControlMessage
+	if err := websocket.JSON.Receive(ws, &control); err != nil || control.Type != "subscribed" {
+		t.Fatalf("ожидалось подтверждение подписки, получено %+v (%v)", control, err)
+	}
+
+	if err := websocket.JSON.Send(ws, eventSubscriptionRequest{
+		Action:    "subscribe",
+		Topics:    []events.Topic{events.TopicUploads},
+		ProjectID: 5,
+	}); err != nil {
+		t.Fatalf("отправка подписки: %v", err)
+	}
+	if err := websocket.JSON.Receive(ws, &control); err != nil || control.Type != "subscribed" {
+		t.Fatalf("ожидалось подтверждение новой подписки, получено %+v (%v)", control, err)
+	}
+	waitForSubscriber(t, bus, events.TopicUploads)
+
+	bus.PublishLog(events.TopicNormalization, events.Filter{}, "не должно прийти")
+	bus.PublishData(events.TopicUploads, "upload.progress", events.Filter{ProjectID: 9}, nil)
+	want := bus.PublishData(events.TopicUploads, "upload.progress", events.Filter{ProjectID: 5}, nil)
+
+	var e events.Event
+	if err := websocket.JSON.Receive(ws, &e); err != nil {
+		t.Fatalf("получение события: %v", err)
+	}
+	if e.ID != want.ID || e.Topic != events.TopicUploads {
+		t.Errorf("получено %+v, ожидалось событие %d", e, want.ID)
+	}
+}
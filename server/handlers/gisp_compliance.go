@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"httpserver/server/services"
+)
+
+// GISPComplianceHandler обработчик отчетов об импортозамещении по реестру ГИСП
+type GISPComplianceHandler struct {
+	service     *services.GISPComplianceService
+	baseHandler *BaseHandler
+}
+
+// NewGISPComplianceHandler создает обработчик отчетов об импортозамещении
+func NewGISPComplianceHandler(service *services.GISPComplianceService, baseHandler *BaseHandler) *GISPComplianceHandler {
+	return &GISPComplianceHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleProjectReport сопоставляет номенклатуру проекта с реестром ГИСП и возвращает отчет в JSON или Excel
+// GET /api/gisp/compliance/{projectId}?format=json|xlsx&status=&min_score=&max_matches=
+func (h *GISPComplianceHandler) HandleProjectReport(w http.ResponseWriter, r *http.Request, projectID int) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	query := r.URL.Query()
+	var opts services.GISPComplianceOptions
+	if value := query.Get("min_score"); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil || score < 0 || score > 1 {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("min_score должен быть числом от 0 до 1", err))
+			return
+		}
+		opts.MinScore = score
+	}
+	if value := query.Get("max_matches"); value != "" {
+		maxMatches, err := strconv.Atoi(value)
+		if err != nil || maxMatches < 1 {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("max_matches должен быть положительным числом", err))
+			return
+		}
+		opts.MaxMatches = maxMatches
+	}
+
+	report, err := h.service.BuildReport(projectID, opts)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	// Фильтр по статусу сужает список элементов, сводка остается полной
+	if status := query.Get("status"); status != "" {
+		filtered := report.Items[:0]
+		for _, item := range report.Items {
+			if item.Status == status {
+				filtered = append(filtered, item)
+			}
+		}
+		report.Items = filtered
+	}
+
+	switch query.Get("format") {
+	case "", "json":
+		h.baseHandler.WriteJSONResponse(w, r, report, http.StatusOK)
+	case "xlsx", "excel":
+		var buf bytes.Buffer
+		if err := h.service.ExportExcel(&buf, report); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		filename := fmt.Sprintf("gisp_compliance_%d_%s.xlsx", projectID, time.Now().Format("20060102_150405"))
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+	default:
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("format должен быть json или xlsx", nil))
+	}
+}
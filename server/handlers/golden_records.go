@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"httpserver/normalization"
+	"httpserver/server/services"
+)
+
+// GoldenRecordHandler обработчик эталонных записей номенклатуры проекта
+type GoldenRecordHandler struct {
+	service     *services.GoldenRecordService
+	baseHandler *BaseHandler
+}
+
+// NewGoldenRecordHandler создает обработчик эталонных записей
+func NewGoldenRecordHandler(service *services.GoldenRecordService, baseHandler *BaseHandler) *GoldenRecordHandler {
+	return &GoldenRecordHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleListRecords возвращает эталонные записи проекта
+// GET /api/clients/{clientId}/projects/{projectId}/golden-records?search=&limit=&offset=
+func (h *GoldenRecordHandler) HandleListRecords(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
+	query := r.URL.Query()
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	offset, _ := strconv.Atoi(query.Get("offset"))
+
+	records, total, err := h.service.ListRecords(projectID, query.Get("search"), limit, offset)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"records": records,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	}, http.StatusOK)
+}
+
+// HandleBuild пересобирает эталонные записи проекта по всем активным базам
+// POST /api/clients/{clientId}/projects/{projectId}/golden-records/build
+func (h *GoldenRecordHandler) HandleBuild(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
+	result, err := h.service.BuildProject(projectID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusOK)
+}
+
+// HandleRules возвращает или заменяет правила выживания атрибутов проекта
+// GET/PUT /api/clients/{clientId}/projects/{projectId}/golden-records/rules
+func (h *GoldenRecordHandler) HandleRules(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := h.service.GetRules(projectID)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"rules":      rules,
+			"strategies": []normalization.SurvivorshipStrategy{normalization.SurvivorshipMostRecent, normalization.SurvivorshipMostComplete, normalization.SurvivorshipSourcePriority, normalization.SurvivorshipMostFrequent},
+		}, http.StatusOK)
+	case http.MethodPut:
+		var req struct {
+			Rules []normalization.SurvivorshipRule `json:"rules"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON правил", err))
+			return
+		}
+		if err := h.service.SaveRules(projectID, req.Rules); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"rules": req.Rules}, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut)
+	}
+}
+
+// HandleGetRecord возвращает эталонную запись с происхождением атрибутов и источниками
+// GET /api/clients/{clientId}/projects/{projectId}/golden-records/{recordId}
+func (h *GoldenRecordHandler) HandleGetRecord(w http.ResponseWriter, r *http.Request, projectID, recordID int) {
+	details, err := h.service.GetRecord(projectID, recordID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, details, http.StatusOK)
+}
+
+// HandleAttribute переопределяет значение атрибута вручную или снимает переопределение
+// PUT/DELETE /api/clients/{clientId}/projects/{projectId}/golden-records/{recordId}/attributes/{attribute}
+func (h *GoldenRecordHandler) HandleAttribute(w http.ResponseWriter, r *http.Request, projectID, recordID int, attribute string) {
+	switch r.Method {
+	case http.MethodPut:
+		var req struct {
+			Value  string `json:"value"`
+			Reason string `json:"reason"`
+			User   string `json:"user"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON переопределения", err))
+			return
+		}
+		user := req.User
+		if user == "" {
+			user = r.Header.Get("X-User")
+		}
+		if err := h.service.OverrideAttribute(projectID, recordID, attribute, req.Value, user, req.Reason); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+	case http.MethodDelete:
+		if err := h.service.ClearOverride(projectID, recordID, attribute); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPut, http.MethodDelete)
+		return
+	}
+	h.HandleGetRecord(w, r, projectID, recordID)
+}
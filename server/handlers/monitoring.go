@@ -11,40 +11,15 @@ import (
 	"httpserver/database"
 	apperrors "httpserver/server/errors"
 	"httpserver/server/services"
+	"httpserver/server/types"
 )
 
-// MonitoringData представляет данные мониторинга провайдеров
-// Это алиас для типа из server пакета, чтобы избежать циклических зависимостей
-type MonitoringData struct {
-	Providers []ProviderMetrics `json:"providers"`
-	System    SystemStats       `json:"system"`
-}
-
-// ProviderMetrics метрики для одного провайдера
-type ProviderMetrics struct {
-	ID                string    `json:"id"`
-	Name              string    `json:"name"`
-	ActiveChannels    int       `json:"active_channels"`
-	CurrentRequests   int       `json:"current_requests"`
-	TotalRequests     int64     `json:"total_requests"`
-	SuccessfulRequests int64    `json:"successful_requests"`
-	FailedRequests    int64     `json:"failed_requests"`
-	AverageLatencyMs  float64   `json:"average_latency_ms"`
-	LastRequestTime   string    `json:"last_request_time"`
-	Status            string    `json:"status"`
-	RequestsPerSecond float64   `json:"requests_per_second"`
-}
-
-// SystemStats общая статистика системы
-type SystemStats struct {
-	TotalProviders          int       `json:"total_providers"`
-	ActiveProviders         int       `json:"active_providers"`
-	TotalRequests           int64     `json:"total_requests"`
-	TotalSuccessful         int64     `json:"total_successful"`
-	TotalFailed             int64     `json:"total_failed"`
-	SystemRequestsPerSecond float64   `json:"system_requests_per_second"`
-	Timestamp               string    `json:"timestamp"`
-}
+// Типы данных мониторинга провайдеров объявлены в server/types и используются Go-клиентом
+type (
+	MonitoringData  = types.MonitoringData
+	ProviderMetrics = types.ProviderMetrics
+	SystemStats     = types.SystemStats
+)
 
 // MonitoringHandler обработчик для мониторинга
 type MonitoringHandler struct {
@@ -82,6 +57,19 @@ func NewMonitoringHandler(
 	}
 }
 
+// MonitoringSnapshot возвращает текущие метрики провайдеров для публикации в шину событий
+func (h *MonitoringHandler) MonitoringSnapshot() (data MonitoringData, err error) {
+	if h.getMonitoringMetrics == nil {
+		return MonitoringData{}, apperrors.NewServiceUnavailableError("функция метрик провайдеров не задана", nil)
+	}
+	defer func() {
+		if panicVal := recover(); panicVal != nil {
+			err = apperrors.NewInternalError("паника при получении метрик", fmt.Errorf("panic: %v", panicVal))
+		}
+	}()
+	return h.getMonitoringMetrics(), nil
+}
+
 // HandleMonitoringMetrics обрабатывает запрос общих метрик мониторинга
 func (h *MonitoringHandler) HandleMonitoringMetrics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
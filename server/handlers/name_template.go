@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"httpserver/server/services"
+)
+
+// NameTemplateHandler обработчик шаблонов нормализованных имен
+type NameTemplateHandler struct {
+	service     *services.NameTemplateService
+	baseHandler *BaseHandler
+}
+
+// NewNameTemplateHandler создает обработчик шаблонов имен
+func NewNameTemplateHandler(service *services.NameTemplateService, baseHandler *BaseHandler) *NameTemplateHandler {
+	return &NameTemplateHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// nameTemplateRerenderRequest запрос на перегенерацию имен проекта
+type nameTemplateRerenderRequest struct {
+	ProjectID  int  `json:"project_id"`
+	TemplateID int  `json:"template_id,omitempty"`
+	DryRun     bool `json:"dry_run"`
+}
+
+// HandleList возвращает все шаблоны имен
+// GET /api/normalization/name-templates
+func (h *NameTemplateHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.service.List()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"templates": templates,
+		"total":     len(templates),
+	}, http.StatusOK)
+}
+
+// HandleCreate создает шаблон имени
+// POST /api/normalization/name-templates
+func (h *NameTemplateHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var req services.NameTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	template, err := h.service.Create(req)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, template, http.StatusCreated)
+}
+
+// HandleTemplate возвращает (GET), обновляет (PUT) или удаляет (DELETE) шаблон
+// GET/PUT/DELETE /api/normalization/name-templates/{id}
+func (h *NameTemplateHandler) HandleTemplate(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		template, err := h.service.Get(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, template, http.StatusOK)
+	case http.MethodPut:
+		var req services.NameTemplateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		template, err := h.service.Update(id, req)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, template, http.StatusOK)
+	case http.MethodDelete:
+		if err := h.service.Delete(id); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"id":      id,
+			"deleted": true,
+		}, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// HandlePreview строит имя по шаблону для одного наименования без сохранения
+// POST /api/normalization/name-templates/preview
+func (h *NameTemplateHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	var req services.NameTemplatePreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	render, err := h.service.Preview(req)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, render, http.StatusOK)
+}
+
+// HandleRerender перегенерирует имена элементов проекта по текущим шаблонам
+// POST /api/normalization/name-templates/rerender
+func (h *NameTemplateHandler) HandleRerender(w http.ResponseWriter, r *http.Request) {
+	var req nameTemplateRerenderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	if req.ProjectID <= 0 {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("project_id обязателен", nil))
+		return
+	}
+	result, err := h.service.RerenderProject(req.ProjectID, req.TemplateID, req.DryRun)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusOK)
+}
@@ -18,6 +18,7 @@ import (
 
 	"httpserver/database"
 	apperrors "httpserver/server/errors"
+	"httpserver/server/events"
 	"httpserver/server/services"
 	"httpserver/server/types"
 
@@ -30,6 +31,7 @@ type NormalizationHandler struct {
 	clientService          *services.ClientService
 	baseHandler            *BaseHandler
 	normalizerEvents       <-chan string
+	eventBus               *events.Bus // Шина событий; если задана, канал normalizerEvents читает только она
 	startNormalizationFunc func(clientID, projectID int, options map[string]interface{}) error // Функция для запуска нормализации проекта
 	getArliaiAPIKey        func() string                                                       // Функция для получения API ключа Arliai из конфигурации
 	// Доступ к базам данных
@@ -132,6 +134,11 @@ func NewNormalizationHandlerWithServices(
 	}
 }
 
+// SetEventBus подключает шину событий для трансляции событий нормализации
+func (h *NormalizationHandler) SetEventBus(bus *events.Bus) {
+	h.eventBus = bus
+}
+
 // SetDatabase устанавливает доступ к базам данных
 func (h *NormalizationHandler) SetDatabase(db *database.DB, currentDBPath string, normalizedDB *database.DB, currentNormalizedDBPath string) {
 	h.db = db
@@ -232,51 +239,9 @@ func (h *NormalizationHandler) HandleNormalizationEvents(w http.ResponseWriter,
 	}
 	flusher.Flush()
 
-	// Слушаем события из канала
+	// Слушаем события нормализации (через шину событий, если она подключена)
 	// Heartbeat каждые 10 секунд для предотвращения таймаута (WriteTimeout 60 секунд)
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case event := <-h.normalizerEvents:
-			// Обработка события с защитой от паники
-			func() {
-				defer func() {
-					if panicVal := recover(); panicVal != nil {
-						slog.Error("[Normalization] Panic in HandleNormalizationEvents",
-							"panic", panicVal,
-							"stack", string(debug.Stack()),
-							"path", r.URL.Path,
-						)
-						errorMsg := fmt.Sprintf(`{"error":"internal error processing event","details":"%v"}`, panicVal)
-						fmt.Fprintf(w, "data: %s\n\n", errorMsg)
-						flusher.Flush()
-					}
-				}()
-
-				// Форматируем событие как JSON
-				eventJSON := fmt.Sprintf("{\"type\":\"log\",\"message\":%q,\"timestamp\":%q}",
-					event, time.Now().Format(time.RFC3339))
-				if _, err := fmt.Fprintf(w, "data: %s\n\n", eventJSON); err != nil {
-					slog.Error("[Normalization] Error sending SSE event", "error", err, "path", r.URL.Path)
-					return
-				}
-				flusher.Flush()
-			}()
-		case <-ticker.C:
-			// Отправляем heartbeat для поддержания соединения
-			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
-				slog.Error("[Normalization] Error sending heartbeat", "error", err, "path", r.URL.Path)
-				return
-			}
-			flusher.Flush()
-		case <-r.Context().Done():
-			// Клиент отключился
-			slog.Info("[Normalization] Client disconnected", "error", r.Context().Err(), "path", r.URL.Path)
-			return
-		}
-	}
+	StreamLogEvents(w, r, flusher, h.eventBus, events.TopicNormalization, h.normalizerEvents, 10*time.Second, "[Normalization]")
 }
 
 // HandleNormalizationStatus возвращает текущий статус нормализации
@@ -354,8 +319,8 @@ func (h *NormalizationHandler) HandleNormalizationStatus(w http.ResponseWriter,
 // @Produce json
 // @Param clientId path int true "ID клиента"
 // @Param projectId path int true "ID проекта"
-// @Param payload body map[string]interface{} false "Дополнительные опции запуска"
-// @Success 200 {object} map[string]interface{} "Статус запуска"
+// @Param payload body types.ProjectNormalizationOptions false "Дополнительные опции запуска"
+// @Success 200 {object} types.NormalizationStartResponse "Статус запуска"
 // @Failure 400 {object} ErrorResponse "Некорректный запрос"
 // @Failure 404 {object} ErrorResponse "Клиент или проект не найдены"
 // @Failure 500 {object} ErrorResponse "Не удалось запустить нормализацию"
@@ -441,11 +406,11 @@ func (h *NormalizationHandler) HandleStartClientProjectNormalization(w http.Resp
 		return
 	}
 
-	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
-		"success":    true,
-		"message":    "Normalization started for project",
-		"client_id":  clientID,
-		"project_id": projectID,
+	h.baseHandler.WriteJSONResponse(w, r, types.NormalizationStartResponse{
+		Success:   true,
+		Message:   "Normalization started for project",
+		ClientID:  clientID,
+		ProjectID: projectID,
 	}, http.StatusOK)
 }
 
@@ -553,7 +518,7 @@ func (h *NormalizationHandler) HandleGetClientProjectNormalizationStatus(w http.
 // @Description Останавливает текущий процесс нормализации и возвращает статус операции.
 // @Tags normalization
 // @Produce json
-// @Success 200 {object} map[string]interface{} "Статус остановки с полем was_running"
+// @Success 200 {object} types.NormalizationStopResponse "Статус остановки"
 // @Failure 405 {object} ErrorResponse "Метод не поддерживается"
 // @Router /api/normalization/stop [post]
 func (h *NormalizationHandler) HandleNormalizationStop(w http.ResponseWriter, r *http.Request) {
@@ -564,10 +529,10 @@ func (h *NormalizationHandler) HandleNormalizationStop(w http.ResponseWriter, r
 
 	wasRunning := h.normalizationService.Stop()
 
-	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
-		"success":     true,
-		"message":     "Normalization stopped",
-		"was_running": wasRunning,
+	h.baseHandler.WriteJSONResponse(w, r, types.NormalizationStopResponse{
+		Success:    true,
+		Message:    "Normalization stopped",
+		WasRunning: wasRunning,
 	}, http.StatusOK)
 }
 
@@ -1328,6 +1293,125 @@ func (h *NormalizationHandler) HandleNormalizationItemAttributes(w http.Response
 	h.baseHandler.WriteJSONResponse(w, r, response, http.StatusOK)
 }
 
+// HandleExplainItem обрабатывает запросы к /api/normalization/explain/{id}
+// @Summary Объяснить результат нормализации элемента
+// @Description Возвращает цепочку этапов конвейера (валидация, веб-поиск, паттерны, эталоны, голоса AI провайдеров, КПВЭД) с оценками, которая дала нормализованное имя и коды элемента.
+// @Tags normalization
+// @Produce json
+// @Param id path int true "ID элемента нормализации"
+// @Param database query string false "Путь к базе данных"
+// @Success 200 {object} map[string]interface{} "Элемент и этапы решений"
+// @Failure 400 {object} ErrorResponse "Некорректный ID"
+// @Failure 404 {object} ErrorResponse "Элемент не найден"
+// @Failure 405 {object} ErrorResponse "Метод не поддерживается"
+// @Router /api/normalization/explain/{id} [get]
+func (h *NormalizationHandler) HandleExplainItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet)
+		return
+	}
+
+	// Извлекаем ID из пути /api/normalization/explain/{id}
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	itemID, err := ValidateIntPathParam(parts[len(parts)-1], "id")
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	databasePath := r.URL.Query().Get("database")
+	db, err := h.getDB(databasePath)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	if db != nil && databasePath != "" && databasePath != h.currentDBPath {
+		defer db.Close()
+	}
+
+	result, err := h.normalizationService.ExplainItem(db, itemID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusOK)
+}
+
+// HandleConfirmItemAttribute обрабатывает запросы к /api/normalization/item-attributes/{id}/confirm
+// @Summary Подтвердить атрибут элемента нормализации
+// @Description Отмечает атрибут как подтвержденный ревьюером. Подтвержденные атрибуты используются для обучения NER.
+// @Tags normalization
+// @Accept json
+// @Produce json
+// @Param id path int true "ID атрибута"
+// @Param database query string false "Путь к базе данных"
+// @Success 200 {object} map[string]interface{} "Результат подтверждения"
+// @Failure 400 {object} ErrorResponse "Некорректный ID"
+// @Failure 405 {object} ErrorResponse "Метод не поддерживается"
+// @Router /api/normalization/item-attributes/{id}/confirm [post]
+func (h *NormalizationHandler) HandleConfirmItemAttribute(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+
+	// Извлекаем ID из пути /api/normalization/item-attributes/{id}/confirm
+	parts := strings.Split(strings.TrimSuffix(r.URL.Path, "/"), "/")
+	if len(parts) < 6 {
+		h.baseHandler.WriteJSONError(w, r, "Attribute ID is required", http.StatusBadRequest)
+		return
+	}
+	attributeIDStr := parts[len(parts)-2]
+	attributeID, err := strconv.Atoi(attributeIDStr)
+	if err != nil || attributeID <= 0 {
+		h.baseHandler.WriteJSONError(w, r, fmt.Sprintf("Invalid attribute ID: %s", attributeIDStr), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reviewer  string `json:"reviewer"`
+		Confirmed *bool  `json:"confirmed"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.WriteJSONError(w, r, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	confirmed := true
+	if req.Confirmed != nil {
+		confirmed = *req.Confirmed
+	}
+
+	databasePath := r.URL.Query().Get("database")
+	db, err := h.getDB(databasePath)
+	if err != nil {
+		h.baseHandler.WriteJSONError(w, r, fmt.Sprintf("Failed to open database: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if db == nil {
+		h.baseHandler.WriteJSONError(w, r, "Database is not available", http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if databasePath != "" && databasePath != h.currentDBPath {
+			db.Close()
+		}
+	}()
+
+	if err := db.ConfirmItemAttribute(attributeID, req.Reviewer, confirmed); err != nil {
+		h.baseHandler.WriteJSONError(w, r, fmt.Sprintf("Failed to confirm attribute: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"attribute_id": attributeID,
+		"confirmed":    confirmed,
+		"reviewer":     req.Reviewer,
+	}, http.StatusOK)
+}
+
 // HandleNormalizationExportGroup обрабатывает запросы к /api/normalization/export-group
 // @Summary Экспортировать группу нормализованных данных
 // @Description Экспортирует указанную группу нормализованных данных в выбранном формате (CSV, JSON и т.д.).
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// PipelineStagesHandler обработчик конвейера этапов нормализации
+type PipelineStagesHandler struct {
+	service     *services.PipelineStageService
+	baseHandler *BaseHandler
+}
+
+// NewPipelineStagesHandler создает обработчик конвейера этапов
+func NewPipelineStagesHandler(service *services.PipelineStageService, baseHandler *BaseHandler) *PipelineStagesHandler {
+	return &PipelineStagesHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// pipelineStageConfigRequest запрос на изменение конфигурации конвейера проекта
+type pipelineStageConfigRequest struct {
+	Stages []database.PipelineStageConfigEntry `json:"stages"`
+}
+
+// HandleStages возвращает описания всех доступных этапов
+// GET /api/normalization/pipeline/stages
+func (h *PipelineStagesHandler) HandleStages(w http.ResponseWriter, r *http.Request) {
+	stages := h.service.Stages()
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"stages": stages,
+		"total":  len(stages),
+	}, http.StatusOK)
+}
+
+// HandleConfig возвращает (GET), изменяет (PUT) или сбрасывает (DELETE) конфигурацию конвейера проекта
+// GET/PUT/DELETE /api/normalization/pipeline/config/{projectId}
+func (h *PipelineStagesHandler) HandleConfig(w http.ResponseWriter, r *http.Request, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		config, err := h.service.GetConfig(projectID)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, config, http.StatusOK)
+	case http.MethodPut:
+		var req pipelineStageConfigRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		config, err := h.service.UpdateConfig(projectID, req.Stages)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, config, http.StatusOK)
+	case http.MethodDelete:
+		config, err := h.service.ResetConfig(projectID)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, config, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// HandleRun выполняет проверочный прогон конвейера этапов для нормализованной номенклатуры проекта
+// POST /api/normalization/pipeline/run/{projectId}
+func (h *PipelineStagesHandler) HandleRun(w http.ResponseWriter, r *http.Request, projectID int) {
+	summary, err := h.service.RunProject(projectID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, summary, http.StatusOK)
+}
+
+// HandleResults возвращает статистику результатов этапов; с параметром stage - последние ошибки этапа
+// GET /api/normalization/pipeline/results?stage={stageId}
+func (h *PipelineStagesHandler) HandleResults(w http.ResponseWriter, r *http.Request) {
+	results, err := h.service.GetResultStats(r.URL.Query().Get("stage"))
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, results, http.StatusOK)
+}
+
+// HandleItemResults возвращает результаты этапов для нормализованного элемента
+// GET /api/normalization/pipeline/items/{id}
+func (h *PipelineStagesHandler) HandleItemResults(w http.ResponseWriter, r *http.Request, itemID int) {
+	results, err := h.service.GetItemResults(itemID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"item_id": itemID,
+		"stages":  results,
+	}, http.StatusOK)
+}
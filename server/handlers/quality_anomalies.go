@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// QualityAnomalyHandler обработчик аномалий в трендах качества
+type QualityAnomalyHandler struct {
+	service     *services.QualityAnomalyService
+	baseHandler *BaseHandler
+}
+
+// NewQualityAnomalyHandler создает обработчик аномалий в трендах качества
+func NewQualityAnomalyHandler(service *services.QualityAnomalyService, baseHandler *BaseHandler) *QualityAnomalyHandler {
+	return &QualityAnomalyHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleList возвращает аномалии (новые первыми)
+// GET /api/quality/anomalies?database_id=&metric=&status=&severity=&limit=&offset=
+func (h *QualityAnomalyHandler) HandleList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.QualityAnomalyFilter{
+		Metric:   query.Get("metric"),
+		Status:   query.Get("status"),
+		Severity: query.Get("severity"),
+	}
+	if value := query.Get("database_id"); value != "" {
+		databaseID, err := strconv.Atoi(value)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный database_id", err))
+			return
+		}
+		filter.DatabaseID = &databaseID
+	}
+	filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+
+	anomalies, total, err := h.service.GetAnomalies(filter)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"anomalies": anomalies,
+		"total":     total,
+	}, http.StatusOK)
+}
+
+// HandleSummary возвращает число открытых аномалий по важности и последние из них
+// GET /api/quality/anomalies/summary?recent=
+func (h *QualityAnomalyHandler) HandleSummary(w http.ResponseWriter, r *http.Request) {
+	recent, _ := strconv.Atoi(r.URL.Query().Get("recent"))
+	if recent <= 0 {
+		recent = 10
+	}
+	summary, err := h.service.Summary(recent)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, summary, http.StatusOK)
+}
+
+// HandleBaselines возвращает базовые линии рядов базы данных (database_id=0 — метрики производительности)
+// GET /api/quality/anomalies/baselines?database_id=
+func (h *QualityAnomalyHandler) HandleBaselines(w http.ResponseWriter, r *http.Request) {
+	databaseID, err := strconv.Atoi(r.URL.Query().Get("database_id"))
+	if err != nil || databaseID < 0 {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("требуется database_id", err))
+		return
+	}
+	baselines, err := h.service.GetBaselines(databaseID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"database_id": databaseID,
+		"baselines":   baselines,
+	}, http.StatusOK)
+}
+
+// HandleAnalyze учитывает новые значения рядов вне очереди (database_id=0 — метрики производительности)
+// POST /api/quality/anomalies/analyze
+func (h *QualityAnomalyHandler) HandleAnalyze(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DatabaseID int `json:"database_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON запроса", err))
+		return
+	}
+
+	var run *services.QualityAnomalyRun
+	var err error
+	if req.DatabaseID == database.SystemMetricsDatabaseID {
+		run, err = h.service.AnalyzeSystemMetrics()
+	} else {
+		run, err = h.service.AnalyzeDatabase(req.DatabaseID)
+	}
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, run, http.StatusOK)
+}
+
+// HandleAnomaly возвращает аномалию (GET) или меняет ее статус (PATCH)
+// GET/PATCH /api/quality/anomalies/{id}
+func (h *QualityAnomalyHandler) HandleAnomaly(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		anomaly, err := h.service.GetAnomaly(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, anomaly, http.StatusOK)
+	case http.MethodPatch:
+		var req struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON запроса", err))
+			return
+		}
+		anomaly, err := h.service.UpdateStatus(id, req.Status)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, anomaly, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPatch)
+	}
+}
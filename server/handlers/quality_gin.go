@@ -7,31 +7,14 @@ import (
 
 	"github.com/gin-gonic/gin"
 	apperrors "httpserver/server/errors"
+	"httpserver/server/types"
 )
 
 // QualityReportResponse структура ответа отчета о качестве
-type QualityReportResponse struct {
-	OverallScore      float64                `json:"overall_score"`
-	DatabaseID        int                    `json:"database_id"`
-	DatabaseName      string                 `json:"database_name"`
-	Completeness      float64                `json:"completeness"`
-	Uniqueness        float64                `json:"uniqueness"`
-	Consistency       float64                `json:"consistency"`
-	Accuracy          float64                `json:"accuracy"`
-	Statistics        map[string]interface{} `json:"statistics"`
-	Recommendations   []string               `json:"recommendations"`
-	GeneratedAt       string                 `json:"generated_at"`
-}
+type QualityReportResponse = types.QualityReportResponse
 
 // QualityScoreResponse структура ответа оценки качества
-type QualityScoreResponse struct {
-	DatabaseID   int     `json:"database_id"`
-	Score        float64 `json:"score"`
-	Completeness float64 `json:"completeness"`
-	Uniqueness   float64 `json:"uniqueness"`
-	Consistency  float64 `json:"consistency"`
-	Accuracy     float64 `json:"accuracy"`
-}
+type QualityScoreResponse = types.QualityScoreResponse
 
 // HandleQualityReportGin обработчик получения отчета о качестве для Gin
 // @Summary Получить отчет о качестве данных
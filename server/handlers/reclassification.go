@@ -8,6 +8,7 @@ import (
 	"runtime/debug"
 	"time"
 
+	"httpserver/server/events"
 	"httpserver/server/services"
 )
 
@@ -53,6 +54,7 @@ func (h *ReclassificationHandler) HandleStart(w http.ResponseWriter, r *http.Req
 		"classifier_id": req.ClassifierID,
 		"strategy_id":   req.StrategyID,
 		"limit":         req.Limit,
+		"session_id":    h.reclassificationService.GetStatus().SessionID,
 	}, http.StatusOK)
 }
 
@@ -97,57 +99,8 @@ func (h *ReclassificationHandler) HandleEvents(w http.ResponseWriter, r *http.Re
 	}
 	flusher.Flush()
 
-	events := h.reclassificationService.GetEvents()
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case event := <-events:
-			// Обработка события с защитой от паники
-			func() {
-				defer func() {
-					if panicVal := recover(); panicVal != nil {
-						slog.Error("[Reclassification] Panic in HandleEvents",
-							"panic", panicVal,
-							"stack", string(debug.Stack()),
-							"path", r.URL.Path,
-						)
-						errorMsg := fmt.Sprintf(`{"error":"internal error processing event","details":"%v"}`, panicVal)
-						fmt.Fprintf(w, "data: %s\n\n", errorMsg)
-						flusher.Flush()
-					}
-				}()
-
-				eventJSON := fmt.Sprintf(`{"type":"log","message":%q,"timestamp":%q}`,
-					event, time.Now().Format(time.RFC3339))
-				if _, err := fmt.Fprintf(w, "data: %s\n\n", eventJSON); err != nil {
-					slog.Error("[Reclassification] Error sending SSE event",
-						"error", err,
-						"path", r.URL.Path,
-					)
-					return
-				}
-				flusher.Flush()
-			}()
-		case <-ticker.C:
-			// Heartbeat
-			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
-				slog.Error("[Reclassification] Error sending heartbeat",
-					"error", err,
-					"path", r.URL.Path,
-				)
-				return
-			}
-			flusher.Flush()
-		case <-r.Context().Done():
-			slog.Info("[Reclassification] Client disconnected",
-				"error", r.Context().Err(),
-				"path", r.URL.Path,
-			)
-			return
-		}
-	}
+	StreamLogEvents(w, r, flusher, h.reclassificationService.EventBus(), events.TopicReclassification,
+		h.reclassificationService.GetEvents(), 30*time.Second, "[Reclassification]")
 }
 
 // HandleStatus обрабатывает запросы к /api/reclassification/status
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"httpserver/quality"
+	"httpserver/server/services"
+)
+
+// RequisiteRegistryHandler обработчик справочников реквизитов и перекрестной проверки реквизитов
+type RequisiteRegistryHandler struct {
+	service     *services.RequisiteRegistryService
+	baseHandler *BaseHandler
+}
+
+// NewRequisiteRegistryHandler создает обработчик справочников реквизитов
+func NewRequisiteRegistryHandler(service *services.RequisiteRegistryService, baseHandler *BaseHandler) *RequisiteRegistryHandler {
+	return &RequisiteRegistryHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleStatus возвращает версии загруженных справочников
+// GET /api/quality/requisites/registries
+func (h *RequisiteRegistryHandler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.service.Status()
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, status, http.StatusOK)
+}
+
+// HandleLoad обновляет справочники из каталога TSV или из официальных файлов ЦБ РФ и ФНС на сервере
+// POST /api/quality/requisites/registries/load {"dir": "/data/registries"}
+// POST /api/quality/requisites/registries/load {"cbr_ed807": "/data/ED807.zip", "fns_tax_offices": "/data/soun.xml"}
+func (h *RequisiteRegistryHandler) HandleLoad(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Dir           string `json:"dir"`
+		CBRED807      string `json:"cbr_ed807"`
+		FNSTaxOffices string `json:"fns_tax_offices"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON запроса", err))
+		return
+	}
+
+	official := req.CBRED807 != "" || req.FNSTaxOffices != ""
+	if official && req.Dir != "" {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("укажите dir или файлы cbr_ed807/fns_tax_offices", nil))
+		return
+	}
+
+	var status *services.RequisiteRegistryStatus
+	var err error
+	if official {
+		status, err = h.service.LoadOfficial(req.CBRED807, req.FNSTaxOffices)
+	} else {
+		status, err = h.service.LoadFromDir(req.Dir)
+	}
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, status, http.StatusOK)
+}
+
+// HandleCheck проверяет согласованность переданных реквизитов по справочникам
+// POST /api/quality/requisites/check {"inn": "", "kpp": "", "bin": "", "bik": "", "bank_account": "", "correspondent_account": ""}
+func (h *RequisiteRegistryHandler) HandleCheck(w http.ResponseWriter, r *http.Request) {
+	var req quality.CounterpartyRequisites
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("некорректный JSON реквизитов", err))
+		return
+	}
+
+	violations := h.service.Check(req)
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"valid":      len(violations) == 0,
+		"violations": violations,
+	}, http.StatusOK)
+}
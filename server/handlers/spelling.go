@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// SpellingHandler обработчик исправления опечаток: проверка текста, очередь проверки исправлений
+// и белый список слов
+type SpellingHandler struct {
+	service     *services.SpellingService
+	baseHandler *BaseHandler
+}
+
+// NewSpellingHandler создает обработчик исправления опечаток
+func NewSpellingHandler(service *services.SpellingService, baseHandler *BaseHandler) *SpellingHandler {
+	return &SpellingHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleCheck проверяет текст словарем проекта (project_id = 0 - общий словарь)
+// POST /api/spelling/check
+func (h *SpellingHandler) HandleCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	var req struct {
+		ProjectID int    `json:"project_id"`
+		Text      string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	result, stats, err := h.service.Check(req.ProjectID, req.Text)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"project_id": req.ProjectID,
+		"result":     result,
+		"dictionary": stats,
+	}, http.StatusOK)
+}
+
+// HandleProjectCorrections возвращает очередь проверки (GET) или добавляет исправление вручную (POST)
+// GET/POST /api/spelling/projects/{projectId}/corrections?status=pending&limit=100
+func (h *SpellingHandler) HandleProjectCorrections(w http.ResponseWriter, r *http.Request, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		corrections, err := h.service.ListCorrections(projectID, r.URL.Query().Get("status"), limit)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"project_id":  projectID,
+			"corrections": corrections,
+			"total":       len(corrections),
+		}, http.StatusOK)
+	case http.MethodPost:
+		var req struct {
+			Original   string `json:"original"`
+			Suggested  string `json:"suggested"`
+			Status     string `json:"status"`
+			ReviewedBy string `json:"reviewed_by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		if req.Status == "" {
+			req.Status = database.SpellingStatusApproved
+		}
+		correction, err := h.service.AddReview(projectID, req.Original, req.Suggested, req.Status, req.ReviewedBy)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, correction, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleCorrection сохраняет решение проверки исправления (status: approved, rejected, pending)
+// PUT /api/spelling/corrections/{id}
+func (h *SpellingHandler) HandleCorrection(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPut {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPut)
+		return
+	}
+	var req struct {
+		Status     string `json:"status"`
+		ReviewedBy string `json:"reviewed_by"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	correction, err := h.service.ReviewCorrection(id, req.Status, req.ReviewedBy)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, correction, http.StatusOK)
+}
+
+// HandleProjectWhitelist возвращает (GET) или пополняет (POST) белый список проекта (0 - общий)
+// GET/POST /api/spelling/projects/{projectId}/whitelist
+func (h *SpellingHandler) HandleProjectWhitelist(w http.ResponseWriter, r *http.Request, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := h.service.ListWhitelist(projectID)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"project_id": projectID,
+			"words":      entries,
+			"total":      len(entries),
+		}, http.StatusOK)
+	case http.MethodPost:
+		var req struct {
+			Word string `json:"word"`
+			Note string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		entry, err := h.service.AddWhitelistWord(projectID, req.Word, req.Note)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, entry, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleWhitelistWord удаляет слово из белого списка
+// DELETE /api/spelling/whitelist/{id}
+func (h *SpellingHandler) HandleWhitelistWord(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodDelete {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodDelete)
+		return
+	}
+	if err := h.service.DeleteWhitelistWord(id); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+}
+
+// HandleRebuild перестраивает словарь проекта по текущим данным (0 - общий корпус и все проекты)
+// POST /api/spelling/projects/{projectId}/rebuild
+func (h *SpellingHandler) HandleRebuild(w http.ResponseWriter, r *http.Request, projectID int) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	stats, err := h.service.Rebuild(projectID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"project_id": projectID,
+		"dictionary": stats,
+	}, http.StatusOK)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// tabularImportMaxFileSize максимальный размер импортируемого файла
+const tabularImportMaxFileSize = 100 << 20 // 100 MB
+
+// TabularImportHandler обработчик импорта номенклатуры и контрагентов из XLSX/CSV
+type TabularImportHandler struct {
+	service     *services.TabularImportService
+	baseHandler *BaseHandler
+}
+
+// NewTabularImportHandler создает обработчик табличного импорта
+func NewTabularImportHandler(service *services.TabularImportService, baseHandler *BaseHandler) *TabularImportHandler {
+	return &TabularImportHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleFields возвращает поля, в которые можно сопоставить колонки
+// GET /api/imports/tabular/fields?entity_type=nomenclature
+func (h *TabularImportHandler) HandleFields(w http.ResponseWriter, r *http.Request) {
+	entityType := r.URL.Query().Get("entity_type")
+	fields, err := h.service.Fields(entityType)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"entity_type": entityType,
+		"fields":      fields,
+	}, http.StatusOK)
+}
+
+// HandlePreview разбирает файл и возвращает лист, заголовки, предложенное сопоставление и первые строки
+// POST /api/imports/tabular/preview (multipart: file, entity_type, sheet, header_row, mapping, profile_id, client_id)
+func (h *TabularImportHandler) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	fileName, data, req, err := h.readImportForm(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	preview, err := h.service.Preview(fileName, data, req)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, preview, http.StatusOK)
+}
+
+// HandleImport импортирует файл в проект как выгрузку
+// POST /api/imports/tabular/projects/{projectId} (multipart: file, entity_type, mapping, profile_id, database_id, save_profile)
+func (h *TabularImportHandler) HandleImport(w http.ResponseWriter, r *http.Request, projectID int) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	fileName, data, req, err := h.readImportForm(r)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	result, err := h.service.Import(projectID, fileName, data, req)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusCreated)
+}
+
+// HandleClientProfiles возвращает (GET) или сохраняет (POST) профили сопоставления клиента
+// GET/POST /api/imports/tabular/clients/{clientId}/profiles?entity_type=
+func (h *TabularImportHandler) HandleClientProfiles(w http.ResponseWriter, r *http.Request, clientID int) {
+	switch r.Method {
+	case http.MethodGet:
+		profiles, err := h.service.ListProfiles(clientID, r.URL.Query().Get("entity_type"))
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"client_id": clientID,
+			"profiles":  profiles,
+			"total":     len(profiles),
+		}, http.StatusOK)
+	case http.MethodPost:
+		var profile database.ImportMappingProfile
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		saved, err := h.service.SaveProfile(clientID, &profile)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, saved, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleProfile возвращает (GET), изменяет (PUT) или удаляет (DELETE) профиль сопоставления
+// GET/PUT/DELETE /api/imports/tabular/profiles/{id}
+func (h *TabularImportHandler) HandleProfile(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		profile, err := h.service.GetProfile(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, profile, http.StatusOK)
+	case http.MethodPut:
+		var profile database.ImportMappingProfile
+		if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		updated, err := h.service.UpdateProfile(id, &profile)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, updated, http.StatusOK)
+	case http.MethodDelete:
+		if err := h.service.DeleteProfile(id); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// readImportForm читает файл и параметры импорта из multipart-формы; mapping передается JSON-объектом
+func (h *TabularImportHandler) readImportForm(r *http.Request) (string, []byte, services.TabularImportRequest, error) {
+	var req services.TabularImportRequest
+	if err := r.ParseMultipartForm(tabularImportMaxFileSize); err != nil {
+		return "", nil, req, NewValidationError("не удалось разобрать форму", err)
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return "", nil, req, NewValidationError("не передан файл", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, tabularImportMaxFileSize+1))
+	if err != nil {
+		return "", nil, req, NewValidationError("не удалось прочитать файл", err)
+	}
+	if len(data) > tabularImportMaxFileSize {
+		return "", nil, req, NewValidationError("файл больше 100 МБ", nil)
+	}
+
+	req.EntityType = r.FormValue("entity_type")
+	req.Sheet = r.FormValue("sheet")
+	req.SaveProfile = r.FormValue("save_profile")
+	req.HeaderRow, _ = strconv.Atoi(r.FormValue("header_row"))
+	req.ProfileID, _ = strconv.Atoi(r.FormValue("profile_id"))
+	req.ClientID, _ = strconv.Atoi(r.FormValue("client_id"))
+	req.DatabaseID, _ = strconv.Atoi(r.FormValue("database_id"))
+	if mapping := r.FormValue("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &req.Mapping); err != nil {
+			return "", nil, req, NewValidationError("mapping должен быть JSON-объектом {\"поле\": \"колонка\"}", err)
+		}
+	}
+	return header.Filename, data, req, nil
+}
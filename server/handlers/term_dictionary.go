@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"httpserver/server/services"
+)
+
+// TermDictionaryHandler обработчик словарей сокращений, синонимов и стоп-слов:
+// записи проекта и общего словаря, поиск кандидатов и их проверка
+type TermDictionaryHandler struct {
+	service     *services.TermDictionaryService
+	baseHandler *BaseHandler
+}
+
+// NewTermDictionaryHandler создает обработчик словарей терминов
+func NewTermDictionaryHandler(service *services.TermDictionaryService, baseHandler *BaseHandler) *TermDictionaryHandler {
+	return &TermDictionaryHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleApply применяет словарь проекта к тексту (project_id = 0 - общий словарь)
+// POST /api/dictionaries/apply
+func (h *TermDictionaryHandler) HandleApply(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	var req struct {
+		ProjectID int    `json:"project_id"`
+		Text      string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+		return
+	}
+	result, stats, err := h.service.Apply(req.ProjectID, req.Text)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"project_id": req.ProjectID,
+		"result":     result,
+		"dictionary": stats,
+	}, http.StatusOK)
+}
+
+// HandleProjectEntries возвращает записи словаря (GET) или добавляет запись вручную (POST)
+// GET/POST /api/dictionaries/projects/{projectId}/entries?kind=abbreviation&status=pending&limit=100
+func (h *TermDictionaryHandler) HandleProjectEntries(w http.ResponseWriter, r *http.Request, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		entries, err := h.service.ListEntries(projectID, query.Get("kind"), query.Get("status"), limit)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"project_id": projectID,
+			"entries":    entries,
+			"total":      len(entries),
+		}, http.StatusOK)
+	case http.MethodPost:
+		var req struct {
+			Kind        string `json:"kind"`
+			Term        string `json:"term"`
+			Replacement string `json:"replacement"`
+			ReviewedBy  string `json:"reviewed_by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		entry, err := h.service.AddEntry(projectID, req.Kind, req.Term, req.Replacement, req.ReviewedBy)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, entry, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleMine ищет кандидатов в сокращения по наименованиям проекта (0 - всех проектов)
+// и добавляет их в очередь проверки
+// POST /api/dictionaries/projects/{projectId}/mine
+func (h *TermDictionaryHandler) HandleMine(w http.ResponseWriter, r *http.Request, projectID int) {
+	if r.Method != http.MethodPost {
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPost)
+		return
+	}
+	result, err := h.service.Mine(projectID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, result, http.StatusOK)
+}
+
+// HandleEntry сохраняет решение проверки (PUT, status: approved, rejected, pending;
+// replacement - исправленная полная форма) или удаляет запись (DELETE)
+// PUT/DELETE /api/dictionaries/entries/{id}
+func (h *TermDictionaryHandler) HandleEntry(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodPut:
+		var req struct {
+			Status      string `json:"status"`
+			Replacement string `json:"replacement"`
+			ReviewedBy  string `json:"reviewed_by"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		entry, err := h.service.ReviewEntry(id, req.Status, req.Replacement, req.ReviewedBy)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, entry, http.StatusOK)
+	case http.MethodDelete:
+		if err := h.service.DeleteEntry(id); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodPut, http.MethodDelete)
+	}
+}
@@ -24,6 +24,7 @@ type UploadHandler struct {
 	logFunc             func(entry interface{}) // server.LogEntry, но без прямого импорта для избежания циклических зависимостей
 	normalizedDB        *database.DB            // Нормализованная БД для работы с нормализованными выгрузками
 	currentNormalizedDBPath string
+	automationService   *services.UploadAutomationService // Правила автоматизации после /complete (опционально)
 }
 
 // NewUploadHandler создает новый обработчик для работы с выгрузками
@@ -60,6 +61,11 @@ func (h *UploadHandler) SetNormalizedDB(normalizedDB *database.DB, currentNormal
 	h.currentNormalizedDBPath = currentNormalizedDBPath
 }
 
+// SetAutomationService подключает правила автоматизации, выполняемые после завершения выгрузки
+func (h *UploadHandler) SetAutomationService(service *services.UploadAutomationService) {
+	h.automationService = service
+}
+
 // HandleHandshake обрабатывает рукопожатие
 func (h *UploadHandler) HandleHandshake(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -514,6 +520,11 @@ func (h *UploadHandler) HandleComplete(w http.ResponseWriter, r *http.Request, q
 		_, _ = h.notificationService.AddNotification(ctx, services.NotificationTypeSuccess, "Загрузка завершена", fmt.Sprintf("Загрузка %s успешно завершена", req.UploadUUID), clientID, projectID, map[string]interface{}{"upload_uuid": req.UploadUUID, "upload_id": upload.ID})
 	}
 
+	// Запускаем правила автоматизации проекта
+	if h.automationService != nil {
+		h.automationService.OnUploadCompleted(upload)
+	}
+
 	// Запускаем анализ качества в фоне, если функция предоставлена
 	if qualityAnalyzerFunc != nil {
 		databaseID := 0
@@ -612,13 +623,13 @@ func (h *UploadHandler) HandleListUploads(w http.ResponseWriter, r *http.Request
 	}
 
 	// Формируем ответ
-	response := map[string]interface{}{
-		"uploads": paginatedUploads,
-		"total":   total,
-		"limit":   limit,
-		"offset":  offset,
-		"count":   len(paginatedUploads),
-		"has_more": offset+len(paginatedUploads) < total,
+	response := types.UploadListResponse{
+		Uploads: paginatedUploads,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		Count:   len(paginatedUploads),
+		HasMore: offset+len(paginatedUploads) < total,
 	}
 
 	h.baseHandler.WriteJSONResponse(w, r, response, http.StatusOK)
@@ -732,53 +743,60 @@ func (h *UploadHandler) handleGetUpload(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	// Преобразуем константы в интерфейсы
-	constantData := make([]interface{}, len(constants))
+	// Преобразуем константы
+	constantData := make([]types.UploadConstant, len(constants))
 	for i, constant := range constants {
-		constantData[i] = map[string]interface{}{
-			"id":         constant.ID,
-			"name":       constant.Name,
-			"synonym":    constant.Synonym,
-			"type":       constant.Type,
-			"value":      constant.Value,
-			"created_at": constant.CreatedAt,
+		constantData[i] = types.UploadConstant{
+			ID:        constant.ID,
+			Name:      constant.Name,
+			Synonym:   constant.Synonym,
+			Type:      constant.Type,
+			Value:     constant.Value,
+			CreatedAt: constant.CreatedAt,
 		}
 	}
 
 	// Преобразуем справочники
-	catalogData := make([]interface{}, len(catalogs))
+	catalogData := make([]types.UploadCatalog, len(catalogs))
 	for i, catalog := range catalogs {
-		catalogData[i] = map[string]interface{}{
-			"id":         catalog.ID,
-			"name":       catalog.Name,
-			"synonym":    catalog.Synonym,
-			"created_at": catalog.CreatedAt,
+		catalogData[i] = types.UploadCatalog{
+			ID:        catalog.ID,
+			Name:      catalog.Name,
+			Synonym:   catalog.Synonym,
+			CreatedAt: catalog.CreatedAt,
 		}
 	}
 
-	details := map[string]interface{}{
-		"upload_uuid":      upload.UploadUUID,
-		"started_at":       upload.StartedAt,
-		"completed_at":     upload.CompletedAt,
-		"status":           upload.Status,
-		"version_1c":       upload.Version1C,
-		"config_name":      upload.ConfigName,
-		"total_constants":  upload.TotalConstants,
-		"total_catalogs":   upload.TotalCatalogs,
-		"total_items":      upload.TotalItems,
-		"database_id":      upload.DatabaseID,
-		"client_id":        upload.ClientID,
-		"project_id":       upload.ProjectID,
-		"computer_name":    upload.ComputerName,
-		"user_name":        upload.UserName,
-		"config_version":   upload.ConfigVersion,
-		"iteration_number": upload.IterationNumber,
-		"iteration_label":  upload.IterationLabel,
-		"programmer_name":   upload.ProgrammerName,
-		"upload_purpose":    upload.UploadPurpose,
-		"parent_upload_id":  upload.ParentUploadID,
-		"catalogs":          catalogData,
-		"constants":         constantData,
+	details := types.UploadDetails{
+		UploadUUID:      upload.UploadUUID,
+		StartedAt:       upload.StartedAt,
+		CompletedAt:     upload.CompletedAt,
+		Status:          upload.Status,
+		Version1C:       upload.Version1C,
+		ConfigName:      upload.ConfigName,
+		TotalConstants:  upload.TotalConstants,
+		TotalCatalogs:   upload.TotalCatalogs,
+		TotalItems:      upload.TotalItems,
+		DatabaseID:      upload.DatabaseID,
+		ClientID:        upload.ClientID,
+		ProjectID:       upload.ProjectID,
+		ComputerName:    upload.ComputerName,
+		UserName:        upload.UserName,
+		ConfigVersion:   upload.ConfigVersion,
+		IterationNumber: upload.IterationNumber,
+		IterationLabel:  upload.IterationLabel,
+		ProgrammerName:  upload.ProgrammerName,
+		UploadPurpose:   upload.UploadPurpose,
+		ParentUploadID:  upload.ParentUploadID,
+		Catalogs:        catalogData,
+		Constants:       constantData,
+	}
+
+	// История запусков правил автоматизации для выгрузки
+	if h.automationService != nil {
+		if runs, err := h.automationService.GetUploadRuns(upload.UploadUUID); err == nil {
+			details.AutomationRuns = runs
+		}
 	}
 
 	h.logFunc(types.LogEntry{
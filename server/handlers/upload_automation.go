@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"httpserver/database"
+	"httpserver/server/services"
+)
+
+// UploadAutomationHandler обработчик правил автоматизации после завершения выгрузки
+type UploadAutomationHandler struct {
+	service     *services.UploadAutomationService
+	baseHandler *BaseHandler
+}
+
+// NewUploadAutomationHandler создает обработчик правил автоматизации выгрузок
+func NewUploadAutomationHandler(service *services.UploadAutomationService, baseHandler *BaseHandler) *UploadAutomationHandler {
+	return &UploadAutomationHandler{
+		service:     service,
+		baseHandler: baseHandler,
+	}
+}
+
+// HandleActions возвращает доступные действия правил
+// GET /api/upload-automation/actions
+func (h *UploadAutomationHandler) HandleActions(w http.ResponseWriter, r *http.Request) {
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"actions": h.service.ActionTypes(),
+	}, http.StatusOK)
+}
+
+// HandleProjectRules возвращает (GET) или создает (POST) правила проекта
+// GET/POST /api/upload-automation/projects/{projectId}/rules
+func (h *UploadAutomationHandler) HandleProjectRules(w http.ResponseWriter, r *http.Request, projectID int) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := h.service.ListRules(projectID)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+			"project_id": projectID,
+			"rules":      rules,
+			"total":      len(rules),
+		}, http.StatusOK)
+	case http.MethodPost:
+		var rule database.UploadAutomationRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		created, err := h.service.CreateRule(projectID, &rule)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, created, http.StatusCreated)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+// HandleRule возвращает (GET), изменяет (PUT) или удаляет (DELETE) правило
+// GET/PUT/DELETE /api/upload-automation/rules/{id}
+func (h *UploadAutomationHandler) HandleRule(w http.ResponseWriter, r *http.Request, id int) {
+	switch r.Method {
+	case http.MethodGet:
+		rule, err := h.service.GetRule(id)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, rule, http.StatusOK)
+	case http.MethodPut:
+		var rule database.UploadAutomationRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, NewValidationError("неверный формат запроса", err))
+			return
+		}
+		updated, err := h.service.UpdateRule(id, &rule)
+		if err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, updated, http.StatusOK)
+	case http.MethodDelete:
+		if err := h.service.DeleteRule(id); err != nil {
+			h.baseHandler.HandleHTTPError(w, r, err)
+			return
+		}
+		h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{"success": true, "id": id}, http.StatusOK)
+	default:
+		h.baseHandler.HandleMethodNotAllowed(w, r, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// HandleProjectRuns возвращает последние запуски правил проекта
+// GET /api/upload-automation/projects/{projectId}/runs?limit=50
+func (h *UploadAutomationHandler) HandleProjectRuns(w http.ResponseWriter, r *http.Request, projectID int) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	runs, err := h.service.GetProjectRuns(projectID, limit)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"project_id": projectID,
+		"runs":       runs,
+		"total":      len(runs),
+	}, http.StatusOK)
+}
+
+// HandleUploadRuns возвращает запуски правил для выгрузки
+// GET /api/upload-automation/uploads/{uuid}/runs
+func (h *UploadAutomationHandler) HandleUploadRuns(w http.ResponseWriter, r *http.Request, uploadUUID string) {
+	runs, err := h.service.GetUploadRuns(uploadUUID)
+	if err != nil {
+		h.baseHandler.HandleHTTPError(w, r, err)
+		return
+	}
+	h.baseHandler.WriteJSONResponse(w, r, map[string]interface{}{
+		"upload_uuid": uploadUUID,
+		"runs":        runs,
+		"total":       len(runs),
+	}, http.StatusOK)
+}
@@ -13,6 +13,7 @@ import (
 	"httpserver/internal/domain/models"
 	"httpserver/internal/infrastructure/cache"
 	"httpserver/quality"
+	"httpserver/server/services"
 	"httpserver/server/types"
 
 	"github.com/google/uuid"
@@ -25,6 +26,8 @@ type UploadLegacyHandler struct {
 	dbInfoCache     *cache.DatabaseInfoCache
 	qualityAnalyzer *quality.QualityAnalyzer
 	logFunc         func(entry types.LogEntry)
+	// Правила автоматизации, запускаемые после /complete (опционально)
+	automationService *services.UploadAutomationService
 }
 
 // NewUploadLegacyHandler создает новый обработчик legacy upload
@@ -44,6 +47,11 @@ func NewUploadLegacyHandler(
 	}
 }
 
+// SetAutomationService подключает правила автоматизации, выполняемые после завершения выгрузки
+func (h *UploadLegacyHandler) SetAutomationService(service *services.UploadAutomationService) {
+	h.automationService = service
+}
+
 // writeXMLResponse записывает XML ответ
 func (h *UploadLegacyHandler) writeXMLResponse(w http.ResponseWriter, data interface{}) {
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
@@ -694,6 +702,14 @@ func (h *UploadLegacyHandler) HandleComplete(w http.ResponseWriter, r *http.Requ
 		Endpoint:   "/complete",
 	})
 
+	// Запускаем правила автоматизации проекта (нормализация, мэппинг, КПВЭД, срез, уведомления)
+	if h.automationService != nil {
+		if completed, err := h.db.GetUploadByUUID(req.UploadUUID); err == nil {
+			upload = completed
+		}
+		h.automationService.OnUploadCompleted(upload)
+	}
+
 	// Запускаем анализ качества в фоне
 	go func() {
 		databaseID := 0
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"httpserver/internal/infrastructure/workers"
+	"httpserver/nomenclature"
 	"httpserver/server/services"
 )
 
@@ -309,7 +310,7 @@ func (h *WorkerHandler) HandleCheckArliaiConnection(w http.ResponseWriter, r *ht
 	}
 
 	w.Header().Set("X-Request-ID", traceID)
-	h.WriteJSONResponse(w, r, result, http.StatusOK)
+	h.WriteJSONResponse(w, r, withRateLimits(result, "arliai"), http.StatusOK)
 }
 
 // HandleCheckOpenRouterConnection обрабатывает запрос проверки подключения к OpenRouter
@@ -334,7 +335,7 @@ func (h *WorkerHandler) HandleCheckOpenRouterConnection(w http.ResponseWriter, r
 	}
 
 	w.Header().Set("X-Request-ID", traceID)
-	h.WriteJSONResponse(w, r, result, http.StatusOK)
+	h.WriteJSONResponse(w, r, withRateLimits(result, "openrouter"), http.StatusOK)
 }
 
 // HandleCheckHuggingFaceConnection обрабатывает запрос проверки подключения к Hugging Face
@@ -360,7 +361,7 @@ func (h *WorkerHandler) HandleCheckHuggingFaceConnection(w http.ResponseWriter,
 	}
 
 	w.Header().Set("X-Request-ID", traceID)
-	h.WriteJSONResponse(w, r, result, http.StatusOK)
+	h.WriteJSONResponse(w, r, withRateLimits(result, "huggingface"), http.StatusOK)
 }
 
 // HandleGetModels обрабатывает запрос получения списка моделей
@@ -478,7 +479,51 @@ func (h *WorkerHandler) HandleOrchestratorStats(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	h.WriteJSONResponse(w, r, stats, http.StatusOK)
+	h.WriteJSONResponse(w, r, withRateLimits(stats, "arliai", "openrouter", "huggingface"), http.StatusOK)
+}
+
+// HandleRateLimits возвращает текущие корзины квот AI провайдеров
+// GET /api/workers/rate-limits
+func (h *WorkerHandler) HandleRateLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	states := nomenclature.Quotas().States()
+	providers := make(map[string]nomenclature.QuotaProviderSummary)
+	for _, state := range states {
+		if _, ok := providers[state.Provider]; !ok {
+			providers[state.Provider] = nomenclature.Quotas().ProviderSummary(state.Provider)
+		}
+	}
+	h.WriteJSONResponse(w, r, map[string]interface{}{
+		"providers": providers,
+		"buckets":   states,
+	}, http.StatusOK)
+}
+
+// withRateLimits добавляет в ответ статуса поле rate_limits с эффективной скоростью провайдеров.
+// Ответ, который не является JSON-объектом, возвращается без изменений
+func withRateLimits(result interface{}, providers ...string) interface{} {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return result
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return result
+	}
+	if len(providers) == 1 {
+		fields["rate_limits"] = nomenclature.Quotas().ProviderSummary(providers[0])
+		return fields
+	}
+	limits := make(map[string]nomenclature.QuotaProviderSummary, len(providers))
+	for _, provider := range providers {
+		limits[provider] = nomenclature.Quotas().ProviderSummary(provider)
+	}
+	fields["rate_limits"] = limits
+	return fields
 }
 
 func mapToStruct(src map[string]interface{}, dst interface{}) error {
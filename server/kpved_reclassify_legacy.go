@@ -152,6 +152,7 @@ func (s *Server) createKpvedClassifier(apiKey, model string) (*nomenclature.AICl
 
 	// Создаем один AI клиент и один hierarchical classifier для всех воркеров
 	aiClient := nomenclature.NewAIClient(apiKey, model)
+	aiClient.SetUsageTags(nomenclature.UsageTags{Operation: "kpved"})
 	log.Printf("[KPVED] Created AI client instance (rate limiter: 1 req/sec, burst: 5)")
 
 	hierarchicalClassifier, err := normalization.NewHierarchicalClassifier(s.serviceDB, aiClient)
@@ -328,6 +329,7 @@ func (s *Server) processKpvedClassificationTasks(
 	// Сбрасываем флаг остановки при начале новой классификации
 	s.kpvedWorkersStopMutex.Lock()
 	s.kpvedWorkersStopped = false
+	s.kpvedStoppedByAIBudget = false
 	s.kpvedWorkersStopMutex.Unlock()
 
 	// Запускаем воркеры
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxAuditBodySize максимальный размер тела запроса, сохраняемого для аудита
+const MaxAuditBodySize = 1 << 20
+
+// AuditRequest сведения об изменяющем запросе для журнала аудита
+type AuditRequest struct {
+	Method    string
+	Path      string
+	Actor     string
+	APIKey    string // отпечаток ключа, сам ключ не передается дальше middleware
+	Role      string
+	RequestID string
+	ClientIP  string
+	Reason    string
+	Body      []byte // JSON тело запроса; nil для больших и не-JSON тел
+}
+
+// AuditBeginFunc вызывается до обработки запроса и возвращает функцию,
+// которая фиксирует запись аудита после ответа с его кодом состояния
+type AuditBeginFunc func(req *AuditRequest) (finish func(statusCode int))
+
+// IsMutatingMethod проверяет, изменяет ли запрос данные
+func IsMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// APIKeyFingerprint возвращает короткий отпечаток API ключа для журнала
+func APIKeyFingerprint(key string) string {
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GinAuditMiddleware записывает в журнал аудита каждый изменяющий запрос к API.
+// Выгрузки из 1С не аудируются: это поток данных, а не действия пользователей.
+func GinAuditMiddleware(begin AuditBeginFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsMutatingMethod(c.Request.Method) || IsUploadPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		apiKey := c.GetHeader("X-API-Key")
+		if apiKey == "" {
+			if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				apiKey = strings.TrimPrefix(auth, "Bearer ")
+			}
+		}
+
+		req := &AuditRequest{
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Actor:     c.GetHeader("X-User"),
+			APIKey:    APIKeyFingerprint(apiKey),
+			Role:      c.GetHeader("X-User-Role"),
+			RequestID: GetRequestIDFromGin(c),
+			ClientIP:  c.ClientIP(),
+			Reason:    c.GetHeader("X-Audit-Reason"),
+		}
+		if req.Actor == "" {
+			req.Actor = c.GetHeader("X-User-Id")
+		}
+
+		if c.Request.Body != nil && strings.Contains(c.GetHeader("Content-Type"), "json") &&
+			c.Request.ContentLength <= MaxAuditBodySize {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, MaxAuditBodySize+1))
+			rest := c.Request.Body
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), rest), rest}
+			if err == nil && len(body) <= MaxAuditBodySize {
+				req.Body = body
+			}
+		}
+
+		finish := begin(req)
+		c.Next()
+		if finish != nil {
+			finish(c.Writer.Status())
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGinAuditMiddleware проверяет аудит изменяющих запросов и сохранение тела для обработчика
+func TestGinAuditMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var recorded []*AuditRequest
+	var statuses []int
+	begin := func(req *AuditRequest) func(int) {
+		recorded = append(recorded, req)
+		return func(status int) { statuses = append(statuses, status) }
+	}
+
+	router := gin.New()
+	router.Use(GinRequestIDMiddleware())
+	router.Use(GinAuditMiddleware(begin))
+	var handlerBody string
+	router.Any("/api/items/:id", func(c *gin.Context) {
+		if c.Request.Method == http.MethodPut {
+			data, _ := io.ReadAll(c.Request.Body)
+			handlerBody = string(data)
+		}
+		c.Status(http.StatusAccepted)
+	})
+	router.POST("/complete", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest(http.MethodPut, "/api/items/1", strings.NewReader(`{"name":"x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User", "ivanov")
+	req.Header.Set("X-API-Key", "plain-key")
+	req.Header.Set("X-Audit-Reason", "исправление")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/items/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/complete", strings.NewReader("<complete/>")))
+
+	if len(recorded) != 1 {
+		t.Fatalf("expected only the PUT request to be audited, got %d", len(recorded))
+	}
+	got := recorded[0]
+	if got.Actor != "ivanov" || got.Reason != "исправление" || got.RequestID == "" || string(got.Body) != `{"name":"x"}` {
+		t.Errorf("unexpected audit request: %+v", got)
+	}
+	if got.APIKey == "" || got.APIKey == "plain-key" {
+		t.Errorf("API key must be stored as fingerprint, got %q", got.APIKey)
+	}
+	if handlerBody != `{"name":"x"}` {
+		t.Errorf("handler body = %q", handlerBody)
+	}
+	if len(statuses) != 1 || statuses[0] != http.StatusAccepted {
+		t.Errorf("statuses = %v", statuses)
+	}
+}
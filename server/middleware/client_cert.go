@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ClientCertSubjectKey ключ gin-контекста с CN клиентского сертификата выгрузки
+const ClientCertSubjectKey = "client_cert_subject"
+
+// UploadCertAuthorizer проверяет, что клиентский сертификат может выгружать данные
+// в базу выгрузки upload_uuid (на /handshake - в базу database_id)
+type UploadCertAuthorizer func(cert *x509.Certificate, uploadUUID, databaseID string) error
+
+// CertificateFingerprint возвращает SHA-256 отпечаток сертификата в hex
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// verifiedClientCertificate возвращает проверенный по клиентскому CA сертификат соединения
+func verifiedClientCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return r.TLS.VerifiedChains[0][0]
+}
+
+// GinUploadClientCertMiddleware проверяет клиентские сертификаты на эндпоинтах выгрузки из 1С.
+// При required выгрузка без сертификата отклоняется; предъявленный сертификат проверяется
+// всегда, и его CN должен быть привязан к клиенту и проекту базы выгрузки.
+func GinUploadClientCertMiddleware(required bool, authorize UploadCertAuthorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !IsUploadPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		cert := verifiedClientCertificate(c.Request)
+		if cert == nil {
+			if required {
+				abortUploadCert(c, http.StatusUnauthorized, "client certificate required", "Для выгрузки требуется клиентский сертификат")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		var uploadUUID, databaseID string
+		if c.Request.Body != nil {
+			body, err := io.ReadAll(c.Request.Body)
+			c.Request.Body.Close()
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			if err == nil {
+				uploadUUID, databaseID = UploadIdentifiers(c.Request.URL.Path, body)
+			}
+		}
+
+		if err := authorize(cert, uploadUUID, databaseID); err != nil {
+			abortUploadCert(c, http.StatusForbidden, "client certificate not allowed", err.Error())
+			return
+		}
+		c.Set(ClientCertSubjectKey, cert.Subject.CommonName)
+		c.Next()
+	}
+}
+
+// abortUploadCert отвечает ошибкой в XML формате эндпоинтов выгрузки
+func abortUploadCert(c *gin.Context, status int, code, message string) {
+	c.Header("Content-Type", "application/xml; charset=utf-8")
+	c.String(status, fmt.Sprintf(
+		"<error_response><success>false</success><error>%s</error><message>%s</message><timestamp>%s</timestamp></error_response>",
+		code, html.EscapeString(message), time.Now().Format(time.RFC3339)))
+	c.Abort()
+}
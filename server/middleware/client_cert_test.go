@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestGinUploadClientCertMiddleware проверяет обязательность сертификата и проверку CN по базе выгрузки
+func TestGinUploadClientCertMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	authorize := func(cert *x509.Certificate, uploadUUID, databaseID string) error {
+		if cert.Subject.CommonName == "1c-base-01" && databaseID == "42" {
+			return nil
+		}
+		return errors.New("сертификат не привязан к клиенту")
+	}
+
+	router := gin.New()
+	router.Use(GinUploadClientCertMiddleware(true, authorize))
+	var subject, receivedBody string
+	router.POST("/handshake", func(c *gin.Context) {
+		subject = c.GetString(ClientCertSubjectKey)
+		body, _ := c.GetRawData()
+		receivedBody = string(body)
+		c.Status(http.StatusOK)
+	})
+	router.GET("/api/clients", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	newRequest := func(cn, body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/handshake", strings.NewReader(body))
+		if cn != "" {
+			cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+			req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		}
+		return req
+	}
+	body := `<handshake><database_id>42</database_id></handshake>`
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("", body))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("without certificate: code %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("1c-base-02", body))
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "не привязан") {
+		t.Errorf("unbound certificate: code %d, body %s; want 403", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, newRequest("1c-base-01", body))
+	if w.Code != http.StatusOK || subject != "1c-base-01" || receivedBody != body {
+		t.Errorf("bound certificate: code %d, subject %q, body %q; want 200 and unchanged body", w.Code, subject, receivedBody)
+	}
+
+	// Остальные эндпоинты не требуют сертификата
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/clients", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("non-upload route: code %d, want 200", w.Code)
+	}
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadPaths эндпоинты выгрузки из 1С, на которые распространяется клиентский лимит
+var UploadPaths = []string{
+	"/handshake",
+	"/metadata",
+	"/constant",
+	"/catalog/meta",
+	"/catalog/item",
+	"/catalog/items",
+	"/nomenclature/batch",
+	"/complete",
+	"/api/v1/upload/",
+	"/api/normalized/upload/",
+}
+
+// IsUploadPath проверяет, относится ли путь к эндпоинтам выгрузки
+func IsUploadPath(path string) bool {
+	for _, p := range UploadPaths {
+		if strings.HasSuffix(p, "/") {
+			if strings.HasPrefix(path, p) {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadClientResolver определяет клиента по идентификаторам из тела запроса выгрузки
+type UploadClientResolver func(uploadUUID, databaseID string) (clientID int, ok bool)
+
+// UploadAllowFunc проверяет лимит клиента; возвращает время ожидания при превышении
+type UploadAllowFunc func(clientID int) (allowed bool, retryAfter time.Duration)
+
+// GinClientUploadRateLimitMiddleware ограничивает частоту запросов выгрузки по клиентам.
+// Клиент определяется по базе выгрузки upload_uuid (на /handshake - по database_id);
+// запросы, для которых клиент не определен, не ограничиваются.
+func GinClientUploadRateLimitMiddleware(resolve UploadClientResolver, allow UploadAllowFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !IsUploadPath(c.Request.URL.Path) || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		uploadUUID, databaseID := UploadIdentifiers(c.Request.URL.Path, body)
+		if uploadUUID == "" && databaseID == "" {
+			c.Next()
+			return
+		}
+		clientID, ok := resolve(uploadUUID, databaseID)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		allowed, retryAfter := allow(clientID)
+		if allowed {
+			c.Next()
+			return
+		}
+
+		seconds := int(retryAfter.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.Header("Content-Type", "application/xml; charset=utf-8")
+		c.String(http.StatusTooManyRequests, fmt.Sprintf(
+			"<error_response><success>false</success><error>rate limit exceeded</error><message>Превышен лимит запросов выгрузки для клиента %d, повторите через %d с</message><timestamp>%s</timestamp></error_response>",
+			clientID, seconds, time.Now().Format(time.RFC3339)))
+		c.Abort()
+	}
+}
+
+// ExtractUploadIdentifiers извлекает upload_uuid и database_id из XML тела запроса выгрузки.
+// Разбор останавливается на элементах данных, не читая их.
+func ExtractUploadIdentifiers(body []byte) (uploadUUID, databaseID string) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.Strict = false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return uploadUUID, databaseID
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "upload_uuid":
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err == nil {
+				uploadUUID = strings.TrimSpace(value)
+			}
+		case "database_id":
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err == nil {
+				databaseID = strings.TrimSpace(value)
+			}
+		case "items", "item", "value":
+			// Данные выгрузки идут после идентификаторов - дальше не читаем
+			return uploadUUID, databaseID
+		}
+	}
+}
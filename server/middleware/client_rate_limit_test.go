@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestExtractUploadIdentifiers проверяет извлечение идентификаторов выгрузки из XML
+func TestExtractUploadIdentifiers(t *testing.T) {
+	uuid, dbID := ExtractUploadIdentifiers([]byte(`<catalog_items><upload_uuid>abc-1</upload_uuid><items><item/></items></catalog_items>`))
+	if uuid != "abc-1" || dbID != "" {
+		t.Errorf("got %q/%q, want abc-1/empty", uuid, dbID)
+	}
+	uuid, dbID = ExtractUploadIdentifiers([]byte(`<handshake><database_id> 42 </database_id><version_1c>8.3</version_1c></handshake>`))
+	if uuid != "" || dbID != "42" {
+		t.Errorf("got %q/%q, want empty/42", uuid, dbID)
+	}
+	// database_id перед upload_uuid не подменяет базу выгрузки: извлекаются оба
+	uuid, dbID = ExtractUploadIdentifiers([]byte(`<catalog_items><database_id>1</database_id><upload_uuid>abc-2</upload_uuid><items/></catalog_items>`))
+	if uuid != "abc-2" || dbID != "1" {
+		t.Errorf("got %q/%q, want abc-2/1", uuid, dbID)
+	}
+	// Вне /handshake database_id без upload_uuid не определяет базу
+	if uuid, dbID = UploadIdentifiers("/catalog/items", []byte(`<catalog_items><database_id>1</database_id></catalog_items>`)); uuid != "" || dbID != "" {
+		t.Errorf("UploadIdentifiers(/catalog/items) = %q/%q, want empty", uuid, dbID)
+	}
+}
+
+// TestGinClientUploadRateLimitMiddleware проверяет ответ 429 и сохранение тела запроса
+func TestGinClientUploadRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	calls := 0
+	allow := func(clientID int) (bool, time.Duration) {
+		calls++
+		return calls <= 1, 3 * time.Second
+	}
+	resolve := func(uploadUUID, databaseID string) (int, bool) {
+		return 7, uploadUUID == "u-1"
+	}
+
+	router := gin.New()
+	router.Use(GinClientUploadRateLimitMiddleware(resolve, allow))
+	var receivedBody string
+	router.POST("/catalog/items", func(c *gin.Context) {
+		body, _ := c.GetRawData()
+		receivedBody = string(body)
+		c.Status(http.StatusOK)
+	})
+
+	body := `<catalog_items><upload_uuid>u-1</upload_uuid></catalog_items>`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/catalog/items", strings.NewReader(body)))
+	if w.Code != http.StatusOK || receivedBody != body {
+		t.Fatalf("first request: code %d, body %q; want 200 and unchanged body", w.Code, receivedBody)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/catalog/items", strings.NewReader(body)))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: code %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "3" {
+		t.Errorf("Retry-After = %q, want 3", got)
+	}
+}
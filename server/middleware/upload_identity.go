@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UploadHandshakePath эндпоинт начала выгрузки: только на нем база определяется по database_id
+const UploadHandshakePath = "/handshake"
+
+// UploadIdentityVerifier проверяет, что database_id из тела запроса совпадает с базой выгрузки upload_uuid
+type UploadIdentityVerifier func(uploadUUID, databaseID string) error
+
+// UploadIdentifiers возвращает идентификаторы, по которым определяется база выгрузки запроса.
+// Вне /handshake обработчики пишут в выгрузку upload_uuid и не читают database_id,
+// поэтому без upload_uuid database_id не учитывается.
+func UploadIdentifiers(path string, body []byte) (uploadUUID, databaseID string) {
+	uploadUUID, databaseID = ExtractUploadIdentifiers(body)
+	if path != UploadHandshakePath && uploadUUID == "" {
+		databaseID = ""
+	}
+	return uploadUUID, databaseID
+}
+
+// GinUploadIdentityMiddleware отклоняет запросы выгрузки, в которых database_id
+// не совпадает с базой выгрузки upload_uuid
+func GinUploadIdentityMiddleware(verify UploadIdentityVerifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !IsUploadPath(c.Request.URL.Path) || c.Request.Body == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body.Close()
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		uploadUUID, databaseID := ExtractUploadIdentifiers(body)
+		if uploadUUID == "" || databaseID == "" {
+			c.Next()
+			return
+		}
+		if err := verify(uploadUUID, databaseID); err != nil {
+			abortUploadCert(c, http.StatusForbidden, "database mismatch", err.Error())
+			return
+		}
+		c.Next()
+	}
+}
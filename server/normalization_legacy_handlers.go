@@ -22,6 +22,8 @@ import (
 
 	"httpserver/database"
 	"httpserver/normalization"
+	"httpserver/server/events"
+	"httpserver/server/handlers"
 	"httpserver/server/services"
 )
 
@@ -278,7 +280,14 @@ func (s *Server) handleNormalizeStart(w http.ResponseWriter, r *http.Request) {
 		if uploadID == 0 {
 			uploadID = 1 // Значение по умолчанию, если не указано
 		}
-		if err := normalizerToUse.ProcessNormalization(uploadID); err != nil {
+		err := normalizerToUse.ProcessNormalization(uploadID)
+		if s.spellingService != nil {
+			// Исправления общей нормализации попадают в общую очередь проверки (project_id = 0)
+			if flushErr := s.spellingService.FlushObserved(0, s.spellingService.GlobalCorrector()); flushErr != nil {
+				log.Printf("Failed to save spelling corrections: %v", flushErr)
+			}
+		}
+		if err != nil {
 			log.Printf("Ошибка нормализации данных: %v", err)
 			s.normalizerEvents <- fmt.Sprintf("Ошибка нормализации: %v", err)
 			s.normalizerMutex.Lock()
@@ -597,34 +606,8 @@ func (s *Server) handleNormalizationEvents(w http.ResponseWriter, r *http.Reques
 	fmt.Fprintf(w, "data: %s\n\n", "{\"type\":\"connected\",\"message\":\"Connected to normalization events\"}")
 	flusher.Flush()
 
-	// Слушаем события из канала
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case event := <-s.normalizerEvents:
-			// Форматируем событие как JSON
-			eventJSON := fmt.Sprintf("{\"type\":\"log\",\"message\":%q,\"timestamp\":%q}",
-				event, time.Now().Format(time.RFC3339))
-			if _, err := fmt.Fprintf(w, "data: %s\n\n", eventJSON); err != nil {
-				log.Printf("Ошибка отправки SSE события: %v", err)
-				return
-			}
-			flusher.Flush()
-		case <-ticker.C:
-			// Отправляем heartbeat для поддержания соединения
-			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
-				log.Printf("Ошибка отправки heartbeat: %v", err)
-				return
-			}
-			flusher.Flush()
-		case <-r.Context().Done():
-			// Клиент отключился
-			log.Printf("SSE клиент отключился: %v", r.Context().Err())
-			return
-		}
-	}
+	// Слушаем события нормализации (через шину событий, если она подключена)
+	handlers.StreamLogEvents(w, r, flusher, s.eventBus, events.TopicNormalization, s.normalizerEvents, 30*time.Second, "[Normalization]")
 }
 
 // NormalizationStatus теперь определен в internal/domain/models и доступен через алиас в server/models.go
@@ -2627,7 +2610,25 @@ func (s *Server) processLegacyNormalizationDatabase(clientID, projectID int, pro
 	}
 
 	// Создаем клиентский нормализатор
-	clientNormalizer := normalization.NewClientNormalizerWithConfig(clientID, projectID, sourceDB, s.serviceDB, s.normalizerEvents, s.workerConfigManager)
+	// События нормализатора публикуются с ID сессии
+	sessionEvents, stopSessionEvents := s.normalizationSessionEvents(clientID, projectID, sessionID)
+	defer stopSessionEvents()
+	clientNormalizer := normalization.NewClientNormalizerWithConfig(clientID, projectID, sourceDB, s.serviceDB, sessionEvents, s.clientWorkerConfig(clientID))
+	clientNormalizer.SetCacheNamespace(s.clientCacheNamespace(clientID))
+	var spellCorrector *normalization.SpellCorrector
+	if s.spellingService != nil {
+		// Словарь опечаток проекта; найденные исправления попадают в очередь проверки
+		spellCorrector = s.spellingService.Corrector(projectID)
+		clientNormalizer.SetSpellCorrector(spellCorrector)
+	}
+	if s.termDictionaryService != nil {
+		// Сокращения и синонимы проекта поверх общего словаря
+		clientNormalizer.SetTermDictionary(s.termDictionaryService.Dictionary(projectID))
+	}
+	if tagger := s.projectNERTagger(projectID); tagger != nil {
+		// Обученная модель NER проекта дополняет атрибуты, извлеченные правилами
+		clientNormalizer.SetNERTagger(tagger)
+	}
 	clientNormalizer.SetSessionID(sessionID)
 
 	// Проверяем статус сессии перед запуском
@@ -2663,6 +2664,11 @@ func (s *Server) processLegacyNormalizationDatabase(clientID, projectID int, pro
 	activityTicker.Stop()
 	activityDone <- true
 	finishedAt := time.Now()
+	if s.spellingService != nil {
+		if flushErr := s.spellingService.FlushObserved(projectID, spellCorrector); flushErr != nil {
+			log.Printf("Failed to save spelling corrections for project %d: %v", projectID, flushErr)
+		}
+	}
 
 	// Проверяем, не была ли сессия остановлена
 	session, _ = s.serviceDB.GetNormalizationSession(sessionID)
@@ -2887,6 +2893,10 @@ func (s *Server) processNomenclatureDatabasesParallel(databases []*database.Proj
 			log.Printf("[Nomenclature] Using global MaxWorkers=%d for parallel processing", maxWorkers)
 		}
 	}
+	if clientMax := s.clientMaxWorkers(clientID, maxWorkers); clientMax < maxWorkers {
+		maxWorkers = clientMax
+		log.Printf("[Nomenclature] Using client %d MaxWorkers=%d for parallel processing", clientID, maxWorkers)
+	}
 
 	// Ограничиваем максимальным количеством БД
 	if len(databases) < maxWorkers {
@@ -3094,6 +3104,10 @@ func (s *Server) processCounterpartyDatabasesParallel(databases []*database.Proj
 			log.Printf("[Counterparty] Using global MaxWorkers=%d for parallel processing", maxWorkers)
 		}
 	}
+	if clientMax := s.clientMaxWorkers(clientID, maxWorkers); clientMax < maxWorkers {
+		maxWorkers = clientMax
+		log.Printf("[Counterparty] Using client %d MaxWorkers=%d for parallel processing", clientID, maxWorkers)
+	}
 
 	// Ограничиваем максимальным количеством БД
 	if len(databases) < maxWorkers {
@@ -3550,28 +3564,7 @@ func (s *Server) handleStopClientNormalization(w http.ResponseWriter, r *http.Re
 	log.Printf("Normalization stop signal set for client_id=%d, project_id=%d", clientID, projectID)
 
 	// Останавливаем все активные сессии нормализации для этого проекта
-	databases, err := s.serviceDB.GetProjectDatabases(projectID, false)
-	if err == nil {
-		runningSessions, err := s.serviceDB.GetRunningSessions()
-		if err == nil {
-			stoppedCount := 0
-			for _, session := range runningSessions {
-				// Проверяем, принадлежит ли сессия этому проекту
-				for _, db := range databases {
-					if session.ProjectDatabaseID == db.ID {
-						if err := s.serviceDB.StopNormalizationSession(session.ID); err == nil {
-							stoppedCount++
-							log.Printf("Stopped normalization session %d for database %d", session.ID, db.ID)
-						}
-						break
-					}
-				}
-			}
-			if stoppedCount > 0 {
-				log.Printf("Stopped %d normalization sessions for project %d", stoppedCount, projectID)
-			}
-		}
-	}
+	s.stopProjectNormalizationSessions(projectID)
 
 	log.Printf("Normalization stopped for client_id=%d, project_id=%d", clientID, projectID)
 
@@ -3581,6 +3574,37 @@ func (s *Server) handleStopClientNormalization(w http.ResponseWriter, r *http.Re
 	}, http.StatusOK)
 }
 
+// stopProjectNormalizationSessions останавливает активные сессии нормализации баз проекта
+func (s *Server) stopProjectNormalizationSessions(projectID int) {
+	if s.serviceDB == nil {
+		return
+	}
+	databases, err := s.serviceDB.GetProjectDatabases(projectID, false)
+	if err != nil {
+		return
+	}
+	runningSessions, err := s.serviceDB.GetRunningSessions()
+	if err != nil {
+		return
+	}
+	stoppedCount := 0
+	for _, session := range runningSessions {
+		// Проверяем, принадлежит ли сессия этому проекту
+		for _, db := range databases {
+			if session.ProjectDatabaseID == db.ID {
+				if err := s.serviceDB.StopNormalizationSession(session.ID); err == nil {
+					stoppedCount++
+					log.Printf("Stopped normalization session %d for database %d", session.ID, db.ID)
+				}
+				break
+			}
+		}
+	}
+	if stoppedCount > 0 {
+		log.Printf("Stopped %d normalization sessions for project %d", stoppedCount, projectID)
+	}
+}
+
 // handleGetClientNormalizationStatus получает статус нормализации для клиента
 func (s *Server) handleGetClientNormalizationStatus(w http.ResponseWriter, r *http.Request, clientID, projectID int) {
 	// Проверяем существование проекта
@@ -3607,12 +3631,19 @@ func (s *Server) handleGetClientNormalizationStatus(w http.ResponseWriter, r *ht
 
 	// Получаем события нормализации (последние 50)
 	var logs []string
-	for i := 0; i < 50; i++ {
-		select {
-		case event := <-s.normalizerEvents:
-			logs = append(logs, event)
-		default:
-			goto done
+	if s.eventBus != nil {
+		// Канал читает шина событий, берем последние события из буфера воспроизведения
+		for _, event := range s.eventBus.Recent(events.TopicNormalization, 50) {
+			logs = append(logs, event.Message())
+		}
+	} else {
+		for i := 0; i < 50; i++ {
+			select {
+			case event := <-s.normalizerEvents:
+				logs = append(logs, event)
+			default:
+				goto done
+			}
 		}
 	}
 done:
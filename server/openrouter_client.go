@@ -181,6 +181,8 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 
 	var lastErr error
 	delay := c.retryConfig.InitialDelay
+	// Общая для процессов квота ключа и модели: скорость снижается при 429 и растет при успехах
+	limiter := nomenclature.Quotas().Limiter("openrouter", c.apiKey, model)
 
 	// Retry логика для обработки rate limit и quota ошибок
 	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
@@ -193,6 +195,10 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 			}
 		}
 
+		if err := limiter.Wait(context.Background()); err != nil {
+			return "", fmt.Errorf("rate limiter error: %w", err)
+		}
+
 		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return "", fmt.Errorf("failed to create request: %w", err)
@@ -216,6 +222,7 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 
 		// Обработка HTTP 429 (Too Many Requests) и quota exceeded
 		if resp.StatusCode == http.StatusTooManyRequests {
+			limiter.OnThrottled(resp.Header)
 			retryAfter := c.parseRetryAfter(resp)
 			if retryAfter > 0 {
 				delay = retryAfter
@@ -247,6 +254,7 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 				if strings.Contains(strings.ToLower(errorMsg), "quota") || 
 				   strings.Contains(strings.ToLower(errorMsg), "exceeded") ||
 				   strings.Contains(strings.ToLower(errorResp.Error.Type), "quota") {
+					limiter.OnThrottled(resp.Header)
 					lastErr = fmt.Errorf("quota exceeded: %s (type: %s)", errorMsg, errorResp.Error.Type)
 					log.Printf("[OpenRouter] Quota exceeded (attempt %d/%d): %s", 
 						attempt+1, c.retryConfig.MaxRetries+1, errorMsg)
@@ -293,6 +301,7 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 			// Проверяем на quota/rate limit в сообщении об ошибке
 			if strings.Contains(strings.ToLower(errorMsg), "quota") || 
 			   strings.Contains(strings.ToLower(errorMsg), "rate limit") {
+				limiter.OnThrottled(resp.Header)
 				lastErr = fmt.Errorf("quota/rate limit error: %s (type: %s)", errorMsg, response.Error.Type)
 				log.Printf("[OpenRouter] Quota/rate limit error in response (attempt %d/%d): %s", 
 					attempt+1, c.retryConfig.MaxRetries+1, errorMsg)
@@ -307,6 +316,7 @@ func (c *OpenRouterClient) ChatCompletion(model string, messages []nomenclature.
 			return "", fmt.Errorf("no choices in response")
 		}
 
+		limiter.OnSuccess(resp.Header)
 		return response.Choices[0].Message.Content, nil
 	}
 
@@ -119,6 +119,9 @@ func (m *MultiProviderAINormalizer) NormalizeWithAI(name string) (*normalization
 		result.Category = "другое"
 	}
 
+	// Голоса провайдеров попадают в запись решений по элементу (в кэш не сохраняются)
+	result.Votes = providerVotes(aggregated.AllResults)
+
 	// Сохраняем в кэш
 	if m.cache != nil {
 		sourceName := strings.ToLower(strings.TrimSpace(name))
@@ -132,6 +135,31 @@ func (m *MultiProviderAINormalizer) NormalizeWithAI(name string) (*normalization
 	return result, nil
 }
 
+// providerVotes преобразует ответы провайдеров оркестратора в голоса для записи решений
+func providerVotes(results []ai.ProviderResult) []normalization.ProviderVote {
+	votes := make([]normalization.ProviderVote, 0, len(results))
+	for _, providerResult := range results {
+		vote := normalization.ProviderVote{
+			Provider:   providerResult.ProviderName,
+			Success:    providerResult.Success,
+			DurationMs: providerResult.Duration.Milliseconds(),
+		}
+		if vote.Provider == "" {
+			vote.Provider = providerResult.ProviderID
+		}
+		if providerResult.Error != nil {
+			vote.Error = providerResult.Error.Error()
+		}
+		if providerResult.Result != nil {
+			vote.NormalizedName = providerResult.Result.NormalizedName
+			vote.Category = providerResult.Result.KpvedName
+			vote.Confidence = providerResult.Result.Confidence
+		}
+		votes = append(votes, vote)
+	}
+	return votes
+}
+
 // RequiresAI определяет, требует ли товар AI обработки
 func (m *MultiProviderAINormalizer) RequiresAI(name, category string) bool {
 	// Используем ту же логику, что и в обычном AINormalizer
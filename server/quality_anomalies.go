@@ -0,0 +1,127 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"httpserver/database"
+	"httpserver/server/handlers"
+	"httpserver/server/services"
+)
+
+// setupQualityAnomalies создает сервис и обработчик аномалий в трендах качества и подключает
+// поиск аномалий к завершению анализа качества выгрузки
+func (s *Server) setupQualityAnomalies(baseHandler *handlers.BaseHandler) {
+	s.qualityAnomalyService = services.NewQualityAnomalyService(s.db, services.QualityAnomalyOptions{})
+	s.qualityAnomalyService.SetAlertHandler(s.onQualityAnomalyAlert)
+	s.qualityAnomalyHandler = handlers.NewQualityAnomalyHandler(s.qualityAnomalyService, baseHandler)
+
+	if s.qualityAnalyzer != nil {
+		s.qualityAnalyzer.SetCompletionHandler(func(uploadID, databaseID int) {
+			if databaseID <= 0 {
+				return
+			}
+			run, err := s.qualityAnomalyService.AnalyzeDatabase(databaseID)
+			if err != nil {
+				log.Printf("[QualityAnomaly] analysis of database %d after upload %d failed: %v", databaseID, uploadID, err)
+				return
+			}
+			if run.Detected > 0 || run.Resolved > 0 {
+				log.Printf("[QualityAnomaly] database %d: %d new anomalies, %d resolved", databaseID, run.Detected, run.Resolved)
+			}
+		})
+	}
+	if s.dashboardHandler != nil {
+		s.dashboardHandler.SetQualityAnomalySummary(s.qualityAnomalySummary)
+	}
+}
+
+// qualityAnomalySummary возвращает сводку открытых аномалий для обзора дашборда
+func (s *Server) qualityAnomalySummary() (*services.QualityAnomalySummary, error) {
+	if s.qualityAnomalyService == nil {
+		return nil, fmt.Errorf("quality anomaly service is not initialized")
+	}
+	return s.qualityAnomalyService.Summary(5)
+}
+
+// startQualityAnomalyMonitor ежечасно учитывает метрики производительности сервера
+// (ряды качества выгрузок анализируются по завершении анализа качества)
+func (s *Server) startQualityAnomalyMonitor() {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.shutdownChan:
+			return
+		}
+
+		run, err := s.qualityAnomalyService.AnalyzeSystemMetrics()
+		if err != nil {
+			log.Printf("[QualityAnomaly] system metrics analysis failed: %v", err)
+			continue
+		}
+		if run.Detected > 0 {
+			log.Printf("[QualityAnomaly] system metrics: %d new anomalies", run.Detected)
+		}
+	}
+}
+
+// onQualityAnomalyAlert отправляет уведомление о новой аномалии или повышении ее важности
+func (s *Server) onQualityAnomalyAlert(alert *services.QualityAnomalyAlert) {
+	if s.notificationService == nil {
+		return
+	}
+	a := alert.Anomaly
+
+	notificationType := services.NotificationTypeWarning
+	if a.Severity == database.QualityAnomalySeverityHigh || a.Severity == database.QualityAnomalySeverityCritical {
+		notificationType = services.NotificationTypeError
+	}
+	change := "снижение"
+	if a.Direction == "up" {
+		change = "рост"
+	}
+	kind := "скачок"
+	if a.Detector == "cusum" {
+		kind = "устойчивый дрейф"
+	}
+	title := "Аномалия в трендах качества"
+	if alert.Escalated {
+		title = "Аномалия в трендах качества усилилась"
+	}
+	source := fmt.Sprintf("база %d", a.DatabaseID)
+	if a.DatabaseID == database.SystemMetricsDatabaseID {
+		source = "метрики сервера"
+	}
+	message := fmt.Sprintf("%s: %s %s метрики %s — %.2f при ожидаемом %.2f (%+.1fσ)",
+		source, kind, change, a.Metric, a.ObservedValue, a.ExpectedValue, a.Deviation)
+
+	var clientID, projectID *int
+	if a.DatabaseID != database.SystemMetricsDatabaseID && s.serviceDB != nil {
+		if projectDB, err := s.serviceDB.GetProjectDatabase(a.DatabaseID); err == nil && projectDB != nil {
+			pid := projectDB.ClientProjectID
+			projectID = &pid
+			if project, err := s.serviceDB.GetClientProject(pid); err == nil && project != nil {
+				cid := project.ClientID
+				clientID = &cid
+			}
+		}
+	}
+	metadata := map[string]interface{}{
+		"anomaly_id":  a.ID,
+		"database_id": a.DatabaseID,
+		"metric":      a.Metric,
+		"severity":    a.Severity,
+		"detector":    a.Detector,
+		"direction":   a.Direction,
+		"upload_id":   a.UploadID,
+		"escalated":   alert.Escalated,
+	}
+	if _, err := s.notificationService.AddNotification(context.Background(), notificationType, title, message, clientID, projectID, metadata); err != nil {
+		log.Printf("[QualityAnomaly] failed to add notification: %v", err)
+	}
+}
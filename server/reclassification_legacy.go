@@ -16,7 +16,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"httpserver/classification"
+	"httpserver/server/events"
+	"httpserver/server/handlers"
 )
 
 // ReclassificationStatus статус процесса переклассификации
@@ -33,6 +37,7 @@ type ReclassificationStatus struct {
 	StartTime   string   `json:"startTime,omitempty"`
 	ElapsedTime string   `json:"elapsedTime,omitempty"`
 	Rate        float64  `json:"rate"` // записей в секунду
+	SessionID   string   `json:"sessionId,omitempty"` // ID запуска, с ним события публикуются в шину
 }
 
 // ReclassificationRequest запрос на запуск переклассификации
@@ -92,7 +97,8 @@ func (s *Server) handleReclassificationStart(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Запускаем переклассификацию в отдельной горутине
-	go s.runReclassification(req)
+	sessionID := uuid.New().String()
+	go s.runReclassification(req, sessionID)
 
 	s.writeJSONResponse(w, r, map[string]interface{}{
 		"success": true,
@@ -100,6 +106,7 @@ func (s *Server) handleReclassificationStart(w http.ResponseWriter, r *http.Requ
 		"classifier_id": req.ClassifierID,
 		"strategy_id": req.StrategyID,
 		"limit": req.Limit,
+		"session_id": sessionID,
 	}, http.StatusOK)
 }
 
@@ -145,62 +152,7 @@ func (s *Server) handleReclassificationEvents(w http.ResponseWriter, r *http.Req
 	}
 	flusher.Flush()
 
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case event := <-reclassificationEvents:
-			// Обработка события с защитой от паники
-			func() {
-				defer func() {
-					if panicVal := recover(); panicVal != nil {
-						slog.Error("[Reclassification] Panic in handleReclassificationEvents",
-							"panic", panicVal,
-							"stack", string(debug.Stack()),
-							"path", r.URL.Path,
-						)
-						errorMsg := fmt.Sprintf(`{"type":"error","error":"internal error processing event","details":"%v"}`, panicVal)
-						if _, writeErr := fmt.Fprintf(w, "data: %s\n\n", errorMsg); writeErr != nil {
-							slog.Error("[Reclassification] Error sending panic error message",
-								"error", writeErr,
-								"path", r.URL.Path,
-							)
-							return
-						}
-						flusher.Flush()
-					}
-				}()
-
-				eventJSON := fmt.Sprintf(`{"type":"log","message":%q,"timestamp":%q}`,
-					event, time.Now().Format(time.RFC3339))
-				if _, err := fmt.Fprintf(w, "data: %s\n\n", eventJSON); err != nil {
-					slog.Error("[Reclassification] Error sending SSE event",
-						"error", err,
-						"path", r.URL.Path,
-					)
-					return
-				}
-				flusher.Flush()
-			}()
-		case <-ticker.C:
-			// Heartbeat
-			if _, err := fmt.Fprintf(w, ": heartbeat\n\n"); err != nil {
-				slog.Error("[Reclassification] Error sending heartbeat",
-					"error", err,
-					"path", r.URL.Path,
-				)
-				return
-			}
-			flusher.Flush()
-		case <-r.Context().Done():
-			slog.Info("[Reclassification] Client disconnected",
-				"error", r.Context().Err(),
-				"path", r.URL.Path,
-			)
-			return
-		}
-	}
+	handlers.StreamLogEvents(w, r, flusher, s.eventBus, events.TopicReclassification, reclassificationEvents, 30*time.Second, "[Reclassification]")
 }
 
 // handleReclassificationStatus возвращает текущий статус переклассификации
@@ -244,7 +196,7 @@ func (s *Server) handleReclassificationStop(w http.ResponseWriter, r *http.Reque
 }
 
 // runReclassification выполняет переклассификацию
-func (s *Server) runReclassification(req ReclassificationRequest) {
+func (s *Server) runReclassification(req ReclassificationRequest, sessionID string) {
 	defer func() {
 		reclassificationMutex.Lock()
 		reclassificationRunning = false
@@ -271,6 +223,7 @@ func (s *Server) runReclassification(req ReclassificationRequest) {
 		CurrentStep: "Инициализация...",
 		Logs:        make([]string, 0),
 		StartTime:   startTime.Format(time.RFC3339),
+		SessionID:   sessionID,
 	}
 	reclassificationStatusMutex.Unlock()
 
@@ -546,17 +499,20 @@ func (s *Server) sendReclassificationEvent(message string) {
 		)
 	}
 
+	// При подключенной шине событие получают все подписчики, канал не используется
+	if s.eventBus != nil {
+		reclassificationStatusMutex.RLock()
+		scope := events.Filter{SessionID: reclassificationStatus.SessionID}
+		reclassificationStatusMutex.RUnlock()
+		s.eventBus.PublishLog(events.TopicReclassification, scope, message)
+		appendReclassificationLog(message)
+		return
+	}
+
 	select {
 	case reclassificationEvents <- message:
 		// Событие отправлено
-		reclassificationStatusMutex.Lock()
-		reclassificationStatus.Logs = append(reclassificationStatus.Logs, message)
-		// Ограничиваем размер логов
-		if len(reclassificationStatus.Logs) > 1000 {
-			reclassificationStatus.Logs = reclassificationStatus.Logs[len(reclassificationStatus.Logs)-1000:]
-		}
-		reclassificationStatus.CurrentStep = message
-		reclassificationStatusMutex.Unlock()
+		appendReclassificationLog(message)
 	default:
 		// Канал переполнен, но для ошибок все равно логируем
 		if isError {
@@ -571,3 +527,14 @@ func (s *Server) sendReclassificationEvent(message string) {
 	}
 }
 
+// appendReclassificationLog добавляет сообщение в журнал статуса переклассификации
+func appendReclassificationLog(message string) {
+	reclassificationStatusMutex.Lock()
+	defer reclassificationStatusMutex.Unlock()
+	reclassificationStatus.Logs = append(reclassificationStatus.Logs, message)
+	// Ограничиваем размер логов
+	if len(reclassificationStatus.Logs) > 1000 {
+		reclassificationStatus.Logs = reclassificationStatus.Logs[len(reclassificationStatus.Logs)-1000:]
+	}
+	reclassificationStatus.CurrentStep = message
+}
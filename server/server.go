@@ -23,6 +23,7 @@ import (
 	"httpserver/normalization"
 	"httpserver/normalization/algorithms"
 	"httpserver/quality"
+	"httpserver/server/events"
 	"httpserver/server/handlers"
 	servermonitoring "httpserver/server/monitoring"
 	"httpserver/server/services"
@@ -52,12 +53,14 @@ type Server struct {
 	currentNormalizedDBPath string
 	config                  *Config
 	httpServer              *http.Server
+	certReloader            *certReloader // nil, если HTTPS выключен
 	httpHandler             http.Handler
 	logChan                 chan LogEntry
 	nomenclatureProcessor   *nomenclature.NomenclatureProcessor
 	processorMutex          sync.RWMutex
 	normalizer              *normalization.Normalizer
 	normalizerEvents        chan string
+	eventBus                *events.Bus // Шина событий прогресса и мониторинга (единственный читатель normalizerEvents)
 	// АРХИТЕКТУРНАЯ ЗАМЕТКА: normalizerRunning дублируется в NormalizationService и CounterpartyService.
 	// TODO: Централизовать управление состоянием через services.NormalizationStateManager интерфейс.
 	// См. services/normalization_service.go для деталей рефакторинга.
@@ -70,6 +73,9 @@ type Server struct {
 	// Context для управления жизненным циклом нормализации
 	normalizerCtx        context.Context
 	normalizerCancel     context.CancelFunc
+	// Клиент и проект запущенной нормализации проекта (0 - основная нормализация)
+	normalizerClientID  int
+	normalizerProjectID int
 	dbMutex              sync.RWMutex
 	shutdownChan         chan struct{}
 	startTime            time.Time
@@ -98,6 +104,8 @@ type Server struct {
 	// Флаг остановки воркеров КПВЭД классификации
 	kpvedWorkersStopped   bool
 	kpvedWorkersStopMutex sync.RWMutex
+	// Воркеры остановлены исчерпанием глобального бюджета на AI и возобновятся с началом нового периода
+	kpvedStoppedByAIBudget bool
 	// Обогащение контрагентов
 	enrichmentFactory *enrichment.EnricherFactory
 	// Мониторинг провайдеров
@@ -117,6 +125,23 @@ type Server struct {
 	normalizationService  *services.NormalizationService
 	counterpartyService   *services.CounterpartyService
 	uploadService         *services.UploadService
+	exchangeImportService *services.ExchangeImportService
+	clientConfigService   *services.ClientConfigService
+	clientCertService     *services.ClientCertificateService
+	aiCostService         *services.AICostService
+	goldenRecordService   *services.GoldenRecordService
+	auditService          *services.AuditService
+	benchmarkBundleService *services.BenchmarkBundleService
+	reverificationService  *services.CounterpartyReverificationService
+	requisiteRegistryService *services.RequisiteRegistryService
+	nameTemplateService      *services.NameTemplateService
+	gispComplianceService    *services.GISPComplianceService
+	pipelineStageService     *services.PipelineStageService
+	uploadAutomationService  *services.UploadAutomationService
+	qualityAnomalyService    *services.QualityAnomalyService
+	spellingService          *services.SpellingService
+	termDictionaryService    *services.TermDictionaryService
+	tabularImportService     *services.TabularImportService
 	clientService         *services.ClientService
 	databaseService       *services.DatabaseService
 	qualityService        *services.QualityService
@@ -131,6 +156,23 @@ type Server struct {
 	// Handlers
 	uploadHandler         *handlers.UploadHandler
 	clientHandler         *handlers.ClientHandler
+	clientConfigHandler   *handlers.ClientConfigHandler
+	clientCertHandler     *handlers.ClientCertificateHandler
+	aiCostHandler         *handlers.AICostHandler
+	goldenRecordHandler   *handlers.GoldenRecordHandler
+	auditHandler          *handlers.AuditHandler
+	benchmarkBundleHandler *handlers.BenchmarkBundleHandler
+	reverificationHandler  *handlers.CounterpartyReverificationHandler
+	requisiteRegistryHandler *handlers.RequisiteRegistryHandler
+	nameTemplateHandler      *handlers.NameTemplateHandler
+	gispComplianceHandler    *handlers.GISPComplianceHandler
+	pipelineStagesHandler    *handlers.PipelineStagesHandler
+	uploadAutomationHandler  *handlers.UploadAutomationHandler
+	spellingHandler          *handlers.SpellingHandler
+	termDictionaryHandler    *handlers.TermDictionaryHandler
+	tabularImportHandler     *handlers.TabularImportHandler
+	qualityAnomalyHandler    *handlers.QualityAnomalyHandler
+	eventsHandler            *handlers.EventsHandler
 	normalizationHandler  *handlers.NormalizationHandler
 	qualityHandler        *handlers.QualityHandler
 	classificationHandler *handlers.ClassificationHandler
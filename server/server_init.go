@@ -635,6 +635,9 @@ func (s *Server) ensureDashboardComponents() {
 		baseHandler,
 		s.buildMonitoringMetricsFunc(),
 	)
+	if s.qualityAnomalyService != nil {
+		s.dashboardHandler.SetQualityAnomalySummary(s.qualityAnomalySummary)
+	}
 }
 
 func (s *Server) buildDashboardStatsFunc() func() map[string]interface{} {
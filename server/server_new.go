@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"httpserver/database"
@@ -18,6 +19,8 @@ import (
 	infranormalization "httpserver/internal/infrastructure/normalization"
 	"httpserver/internal/infrastructure/workers"
 	"httpserver/nomenclature"
+	"httpserver/normalization"
+	"httpserver/server/events"
 	"httpserver/server/handlers"
 	"httpserver/server/services"
 )
@@ -52,6 +55,8 @@ func NewServerWithConfig(db *database.DB, normalizedDB *database.DB, serviceDB *
 
 	// Получаем зависимости из контейнера
 	normalizerEvents := container.NormalizerEvents
+	// Шина событий прогресса и мониторинга; единственный читатель normalizerEvents
+	eventBus := events.NewBus(events.DefaultOptions())
 	normalizer := container.Normalizer
 	qualityAnalyzer := container.QualityAnalyzer
 	arliaiClient := container.ArliaiClient
@@ -357,6 +362,7 @@ func NewServerWithConfig(db *database.DB, normalizedDB *database.DB, serviceDB *
 	normalizationHandler := handlers.NewNormalizationHandler(normalizationService, baseHandler, normalizerEvents)
 	// Устанавливаем доступ к базам данных
 	normalizationHandler.SetDatabase(db, dbPath, normalizedDB, normalizedDBPath)
+	normalizationHandler.SetEventBus(eventBus)
 
 	// Создаем quality service и handler
 	qualityService, err := services.NewQualityService(db, qualityAnalyzer)
@@ -638,6 +644,7 @@ func NewServerWithConfig(db *database.DB, normalizedDB *database.DB, serviceDB *
 
 	// Создаем reclassification service и handler
 	reclassificationService := services.NewReclassificationService()
+	reclassificationService.SetEventBus(eventBus)
 	reclassificationHandler := handlers.NewReclassificationHandler(
 		reclassificationService,
 		baseHandler,
@@ -688,6 +695,7 @@ func NewServerWithConfig(db *database.DB, normalizedDB *database.DB, serviceDB *
 		normalizerEvents:              normalizerEvents,
 		normalizerRunning:             false,
 		shutdownChan:                  make(chan struct{}),
+		eventBus:                      eventBus,
 		startTime:                     time.Now(),
 		qualityAnalyzer:               qualityAnalyzer,
 		workerConfigManager:           workerConfigManager,
@@ -759,6 +767,110 @@ func NewServerWithConfig(db *database.DB, normalizedDB *database.DB, serviceDB *
 	// Инициализируем diagnostics handler после создания Server (требует Server в качестве параметра)
 	srv.diagnosticsHandler = handlers.NewDiagnosticsHandler(srv)
 
+	// Импорт файлов обмена 1С (файловый обмен вместо HTTP), логирует через srv.log
+	srv.exchangeImportService = services.NewExchangeImportService(uploadService, func(entry interface{}) {
+		if logEntry, ok := entry.(LogEntry); ok {
+			srv.log(logEntry)
+		}
+	})
+	srv.exchangeImportService.SetCompletionHook(srv.onExchangeUploadCompleted)
+
+	// Клиентские overlay конфигурации поверх глобальных настроек
+	srv.clientConfigService = services.NewClientConfigService(serviceDB)
+	srv.clientConfigHandler = handlers.NewClientConfigHandler(srv.clientConfigService, baseHandler, func() *Config {
+		return srv.config
+	})
+
+	// Привязки клиентских сертификатов 1С к клиентам и проектам (mTLS выгрузки)
+	srv.clientCertService = services.NewClientCertificateService(serviceDB)
+	srv.clientCertHandler = handlers.NewClientCertificateHandler(srv.clientCertService, baseHandler)
+
+	// Учет затрат на AI: цены, журнал потребления и бюджеты
+	srv.aiCostService = services.NewAICostService(serviceDB, log.Printf)
+	srv.aiCostService.SetClientLimitsSource(srv.clientSpendLimits)
+	srv.aiCostService.SetLimitHandlers(srv.onAISoftBudgetExceeded, srv.onAIHardBudgetExceeded)
+	nomenclature.SetUsageRecorder(srv.aiCostService)
+	// Общие для процессов корзины квот AI провайдеров (адаптивное ограничение скорости)
+	nomenclature.SetQuotaStore(services.NewAIQuotaService(serviceDB))
+	srv.aiCostHandler = handlers.NewAICostHandler(srv.aiCostService, baseHandler, srv.providerMetrics)
+
+	// Эталонные записи номенклатуры по базам проекта
+	srv.goldenRecordService = services.NewGoldenRecordService(serviceDB)
+	srv.goldenRecordHandler = handlers.NewGoldenRecordHandler(srv.goldenRecordService, baseHandler)
+
+	// Журнал аудита изменяющих операций (срок хранения по умолчанию из AUDIT_RETENTION_DAYS)
+	auditRetentionDays, _ := strconv.Atoi(os.Getenv("AUDIT_RETENTION_DAYS"))
+	srv.auditService = services.NewAuditService(serviceDB, auditRetentionDays)
+	srv.auditHandler = handlers.NewAuditHandler(srv.auditService, baseHandler)
+	srv.registerAuditRules()
+
+	// Перенос эталонов между инсталляциями подписанными пакетами (имя инсталляции из INSTANCE_NAME)
+	srv.benchmarkBundleService = services.NewBenchmarkBundleService(benchmarksDB, serviceDB, os.Getenv("INSTANCE_NAME"))
+	srv.benchmarkBundleHandler = handlers.NewBenchmarkBundleHandler(srv.benchmarkBundleService, baseHandler)
+
+	// Повторная сверка нормализованных контрагентов с реестрами DaData/Adata/ГИСП
+	srv.setupCounterpartyReverification(enrichmentFactory, baseHandler)
+
+	// Справочники БИК, кодов причин КПП и регионов для перекрестной проверки реквизитов
+	srv.requisiteRegistryService = services.NewRequisiteRegistryService(serviceDB, qualityAnalyzer)
+	srv.requisiteRegistryHandler = handlers.NewRequisiteRegistryHandler(srv.requisiteRegistryService, baseHandler)
+
+	// Шаблоны нормализованных имен по разделам КПВЭД/ОКПД2 и категориям
+	srv.nameTemplateService = services.NewNameTemplateService(serviceDB, normalizedDB, normalizer)
+	srv.nameTemplateHandler = handlers.NewNameTemplateHandler(srv.nameTemplateService, baseHandler)
+
+	// Проверка импортозамещения: сопоставление номенклатуры проектов с реестром ГИСП
+	srv.gispComplianceService = services.NewGISPComplianceService(serviceDB, normalizedDB)
+	srv.gispComplianceHandler = handlers.NewGISPComplianceHandler(srv.gispComplianceService, baseHandler)
+
+	// Исправление опечаток по словарям из эталонов, классификаторов и данных проектов
+	srv.spellingService = services.NewSpellingService(serviceDB, normalizedDB, benchmarksDB)
+	srv.spellingHandler = handlers.NewSpellingHandler(srv.spellingService, baseHandler)
+	if normalizer != nil {
+		// Словарь строится при первой нормализации и подхватывается заново после Rebuild
+		normalizer.SetSpellCorrectorSource(srv.spellingService.GlobalCorrector)
+	}
+
+	// Словари сокращений, синонимов и стоп-слов (общий и проектов) с поиском кандидатов
+	srv.termDictionaryService = services.NewTermDictionaryService(serviceDB, normalizedDB)
+	srv.termDictionaryHandler = handlers.NewTermDictionaryHandler(srv.termDictionaryService, baseHandler)
+	if normalizer != nil {
+		normalizer.SetTermDictionary(srv.termDictionaryService.GlobalDictionary())
+	}
+
+	// Конвейер этапов нормализации с настраиваемым по проектам графом этапов
+	stageRegistry, err := normalization.NewDefaultStageRegistry(normalization.StageDependencies{
+		KpvedDB:        serviceDB,
+		SpellCorrector: srv.spellingService.GlobalCorrector,
+		TermDictionary: srv.termDictionaryService.GlobalDictionary,
+	})
+	if err != nil {
+		log.Printf("Warning: KPVED stages are unavailable: %v", err)
+		stageRegistry, err = normalization.NewDefaultStageRegistry(normalization.StageDependencies{
+			SpellCorrector: srv.spellingService.GlobalCorrector,
+			TermDictionary: srv.termDictionaryService.GlobalDictionary,
+		})
+	}
+	if err == nil {
+		srv.pipelineStageService = services.NewPipelineStageService(serviceDB, normalizedDB, stageRegistry)
+		srv.pipelineStagesHandler = handlers.NewPipelineStagesHandler(srv.pipelineStageService, baseHandler)
+	}
+
+	// Правила автоматизации после завершения выгрузки (нормализация, мэппинг, КПВЭД, срез, уведомления)
+	srv.setupUploadAutomation(baseHandler)
+
+	// Импорт номенклатуры и контрагентов из XLSX/CSV как выгрузки (с запуском правил автоматизации)
+	srv.tabularImportService = services.NewTabularImportService(serviceDB, filepath.Join("data", "uploads"))
+	srv.tabularImportService.SetCompletionHandler(srv.uploadAutomationService.OnUploadCompleted)
+	srv.tabularImportHandler = handlers.NewTabularImportHandler(srv.tabularImportService, baseHandler)
+
+	// Аномалии в трендах качества (EWMA/CUSUM по базам и метрикам) с уведомлениями
+	srv.setupQualityAnomalies(baseHandler)
+
+	// Единая подписка на события (SSE и WebSocket) поверх шины событий
+	uploadService.SetEventBus(eventBus)
+	srv.setupEventBus(baseHandler)
+
 	// Валидация критических зависимостей перед возвратом
 	if err := srv.validateCriticalDependencies(); err != nil {
 		log.Fatalf("Failed to validate critical dependencies: %v", err)
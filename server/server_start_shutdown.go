@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"httpserver/internal/api/routes"
+	"httpserver/internal/config"
 	"httpserver/server/handlers"
 	"httpserver/server/middleware"
 )
@@ -35,6 +36,13 @@ func (s *Server) Start() error {
 		IdleTimeout:  120 * time.Second, // Увеличено для долгих SSE соединений
 	}
 
+	// HTTPS: сертификаты перечитываются по SIGHUP
+	tlsConfig, err := s.setupTLS()
+	if err != nil {
+		return fmt.Errorf("failed to setup TLS: %w", err)
+	}
+	s.httpServer.TLSConfig = tlsConfig
+
 	log.Printf("Сервер запускается на порту %s", s.config.Port)
 
 	// Проверяем, что httpServer создан корректно
@@ -50,16 +58,55 @@ func (s *Server) Start() error {
 
 	// Запускаем фоновые задачи
 	go s.startSessionTimeoutChecker()
+	go s.startExchangeWatcher()
+	if s.auditService != nil {
+		go s.startAuditRetention()
+	}
+	if s.certReloader != nil {
+		go s.startTLSReloadOnSignal()
+	}
+	if s.reverificationService != nil && s.reverificationSchedulerEnabled() {
+		go s.startCounterpartyReverification()
+	}
+	if s.eventBus != nil {
+		go s.startEventSnapshotPublisher()
+	}
+	if s.qualityAnomalyService != nil {
+		go s.startQualityAnomalyMonitor()
+	}
 
 	// Проверяем и загружаем КПВЭД при необходимости
 	s.ensureKpvedLoaded()
 
+	// Загружаем справочники реквизитов (поставляемые, если в БД их еще нет)
+	if s.requisiteRegistryService != nil {
+		if err := s.requisiteRegistryService.EnsureLoaded(); err != nil {
+			log.Printf("[Requisites] Failed to load requisite registries: %v", err)
+		}
+	}
+
+	// Загружаем шаблоны нормализованных имен в нормализатор
+	if s.nameTemplateService != nil {
+		if err := s.nameTemplateService.Refresh(); err != nil {
+			log.Printf("[NameTemplates] Failed to load name templates: %v", err)
+		}
+	}
+
 	// Логируем перед запуском сервера
 	log.Printf("Starting HTTP server on %s...", s.httpServer.Addr)
-	log.Printf("API доступно по адресу: http://localhost%s", s.httpServer.Addr)
+	scheme := "http"
+	if tlsConfig != nil {
+		scheme = "https"
+	}
+	log.Printf("API доступно по адресу: %s://localhost%s", scheme, s.httpServer.Addr)
 
-	// Запускаем сервер
-	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	// Запускаем сервер; при HTTPS сертификаты берутся из TLSConfig
+	if tlsConfig != nil {
+		err = s.httpServer.ListenAndServeTLS("", "")
+	} else {
+		err = s.httpServer.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
 		log.Fatalf("✗ КРИТИЧЕСКАЯ ОШИБКА: Не удалось запустить HTTP сервер на %s: %v", s.httpServer.Addr, err)
 	}
 
@@ -111,9 +158,25 @@ func (s *Server) buildHTTPHandler() (http.Handler, error) {
 
 	// Применяем middleware
 	router.Use(middleware.GinRequestIDMiddleware())
+	if s.auditService != nil {
+		// Журнал аудита всех изменяющих запросов к API
+		router.Use(middleware.GinAuditMiddleware(s.beginAudit))
+	}
 	router.Use(middleware.GinCORSMiddleware())
 	router.Use(middleware.GinGzipMiddleware())
 	router.Use(middleware.GinLoggerMiddleware())
+	if s.uploadService != nil {
+		// database_id в теле запроса выгрузки должен совпадать с базой upload_uuid
+		router.Use(middleware.GinUploadIdentityMiddleware(s.verifyUploadIdentifiers))
+	}
+	if mode := s.uploadClientAuthMode(); mode != config.ClientAuthNone && s.clientCertService != nil {
+		// Проверка клиентских сертификатов 1С на эндпоинтах выгрузки
+		router.Use(middleware.GinUploadClientCertMiddleware(mode == config.ClientAuthRequire, s.authorizeUploadCertificate))
+	}
+	if s.clientConfigService != nil {
+		// Клиентские лимиты частоты запросов на эндпоинтах выгрузки из 1С
+		router.Use(middleware.GinClientUploadRateLimitMiddleware(s.resolveUploadClient, s.clientConfigService.AllowUpload))
+	}
 	router.Use(gin.Recovery())
 	log.Printf("[buildHTTPHandler] Middleware применены")
 
@@ -180,6 +243,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	// Останавливаем фоновые задачи
 	close(s.shutdownChan)
 
+	// Закрываем подписки на события, чтобы потоки SSE и WebSocket завершились
+	if s.eventBus != nil {
+		s.eventBus.Close()
+	}
+
 	// Останавливаем сервер
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("ошибка остановки сервера: %w", err)
@@ -315,6 +383,8 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 		{
 			// Используем стандартные HTTP handlers через адаптер
 			classificationAPI.GET("/classifiers", httpHandlerToGin(s.classificationHandler.HandleGetClassifiers))
+			// POST /api/classification/classify - классификация одного элемента
+			classificationAPI.POST("/classify", httpHandlerToGin(s.classificationHandler.HandleClassifyItem))
 		}
 
 	}
@@ -338,6 +408,8 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 			normalizationAPI.GET("/groups", httpHandlerToGin(s.normalizationHandler.HandleNormalizationGroups))
 			normalizationAPI.GET("/group-items", httpHandlerToGin(s.normalizationHandler.HandleNormalizationGroupItems))
 			normalizationAPI.GET("/item-attributes/:id", httpHandlerToGin(s.normalizationHandler.HandleNormalizationItemAttributes))
+			normalizationAPI.POST("/item-attributes/:id/confirm", httpHandlerToGin(s.normalizationHandler.HandleConfirmItemAttribute))
+			normalizationAPI.GET("/explain/:id", httpHandlerToGin(s.normalizationHandler.HandleExplainItem))
 			normalizationAPI.GET("/export-group", httpHandlerToGin(s.normalizationHandler.HandleNormalizationExportGroup))
 			normalizationAPI.GET("/export", httpHandlerToGin(s.normalizationHandler.HandleExport))
 			normalizationAPI.GET("/config", httpHandlerToGin(s.normalizationHandler.HandleNormalizationConfig))
@@ -396,6 +468,40 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 			// GET /api/clients/:clientId/databases - базы данных клиента
 			clientsAPI.GET("/:clientId/databases", clientIDWrapper(s.clientHandler.GetClientDatabases))
 
+			// Клиентские настройки поверх глобальной конфигурации
+			if s.clientConfigHandler != nil {
+				// GET /api/clients/:clientId/config
+				clientsAPI.GET("/:clientId/config", clientIDWrapper(s.clientConfigHandler.HandleGetClientConfig))
+				// PUT /api/clients/:clientId/config
+				clientsAPI.PUT("/:clientId/config", clientIDWrapper(s.clientConfigHandler.HandleUpdateClientConfig))
+				// DELETE /api/clients/:clientId/config
+				clientsAPI.DELETE("/:clientId/config", clientIDWrapper(s.clientConfigHandler.HandleDeleteClientConfig))
+				// GET /api/clients/:clientId/config/effective - глобальная конфигурация с overlay клиента
+				clientsAPI.GET("/:clientId/config/effective", clientIDWrapper(s.clientConfigHandler.HandleGetEffectiveClientConfig))
+			}
+
+			// Клиентские сертификаты 1С для выгрузки по mTLS
+			if s.clientCertHandler != nil {
+				// GET /api/clients/:clientId/certificates
+				clientsAPI.GET("/:clientId/certificates", clientIDWrapper(s.clientCertHandler.HandleListCertificates))
+				// POST /api/clients/:clientId/certificates
+				clientsAPI.POST("/:clientId/certificates", clientIDWrapper(s.clientCertHandler.HandleCreateCertificate))
+				// DELETE /api/clients/:clientId/certificates/:certId
+				clientsAPI.DELETE("/:clientId/certificates/:certId", func(c *gin.Context) {
+					clientID, err := strconv.Atoi(c.Param("clientId"))
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client ID"})
+						return
+					}
+					certID, err := strconv.Atoi(c.Param("certId"))
+					if err != nil {
+						c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid certificate ID"})
+						return
+					}
+					s.clientCertHandler.HandleRevokeCertificate(c.Writer, c.Request, clientID, certID)
+				})
+			}
+
 			// Documents для клиента
 			clientDocumentsAPI := clientsAPI.Group("/:clientId/documents")
 			{
@@ -557,6 +663,46 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 					}))
 				}
 
+				// Эталонные записи номенклатуры для проекта
+				if s.goldenRecordHandler != nil {
+					projectGoldenAPI := clientProjectsAPI.Group("/:projectId/golden-records")
+					// goldenRecordWrapper разбирает projectId и recordId из пути
+					goldenRecordWrapper := func(handler func(w http.ResponseWriter, r *http.Request, projectID, recordID int)) gin.HandlerFunc {
+						return func(c *gin.Context) {
+							projectID, err := strconv.Atoi(c.Param("projectId"))
+							if err != nil {
+								c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+								return
+							}
+							recordID, err := strconv.Atoi(c.Param("recordId"))
+							if err != nil {
+								c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid record ID"})
+								return
+							}
+							handler(c.Writer, c.Request, projectID, recordID)
+						}
+					}
+					{
+						// GET /api/clients/:clientId/projects/:projectId/golden-records
+						projectGoldenAPI.GET("", clientProjectIDWrapper(s.goldenRecordHandler.HandleListRecords))
+						// POST /api/clients/:clientId/projects/:projectId/golden-records/build
+						projectGoldenAPI.POST("/build", clientProjectIDWrapper(s.goldenRecordHandler.HandleBuild))
+						// GET/PUT /api/clients/:clientId/projects/:projectId/golden-records/rules
+						projectGoldenAPI.GET("/rules", clientProjectIDWrapper(s.goldenRecordHandler.HandleRules))
+						projectGoldenAPI.PUT("/rules", clientProjectIDWrapper(s.goldenRecordHandler.HandleRules))
+						// GET /api/clients/:clientId/projects/:projectId/golden-records/:recordId
+						projectGoldenAPI.GET("/:recordId", goldenRecordWrapper(s.goldenRecordHandler.HandleGetRecord))
+						// PUT/DELETE /api/clients/:clientId/projects/:projectId/golden-records/:recordId/attributes/:attribute
+						attributeHandler := func(c *gin.Context) {
+							goldenRecordWrapper(func(w http.ResponseWriter, r *http.Request, projectID, recordID int) {
+								s.goldenRecordHandler.HandleAttribute(w, r, projectID, recordID, c.Param("attribute"))
+							})(c)
+						}
+						projectGoldenAPI.PUT("/:recordId/attributes/:attribute", attributeHandler)
+						projectGoldenAPI.DELETE("/:recordId/attributes/:attribute", attributeHandler)
+					}
+				}
+
 				// Normalization для проекта
 				if s.normalizationHandler != nil {
 					projectNormalizationAPI := clientProjectsAPI.Group("/:projectId/normalization")
@@ -648,12 +794,74 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 			workersAPI.GET("/orchestrator/strategy", httpHandlerToGin(s.workerHandler.HandleOrchestratorStrategy))
 			workersAPI.POST("/orchestrator/strategy", httpHandlerToGin(s.workerHandler.HandleOrchestratorStrategy))
 			workersAPI.GET("/orchestrator/stats", httpHandlerToGin(s.workerHandler.HandleOrchestratorStats))
+			workersAPI.GET("/rate-limits", httpHandlerToGin(s.workerHandler.HandleRateLimits))
 		}
 	}
 	if s.workerTraceHandler != nil {
 		api.GET("/workers/trace", httpHandlerToGin(s.workerTraceHandler.HandleWorkerTraceStream))
 	}
 
+	// AI costs API (учет затрат на AI провайдеров)
+	if s.aiCostHandler != nil {
+		aiCostsAPI := api.Group("/ai-costs")
+		{
+			// GET/PUT /api/ai-costs/prices - таблица цен провайдеров и моделей
+			aiCostsAPI.GET("/prices", httpHandlerToGin(s.aiCostHandler.HandlePrices))
+			aiCostsAPI.PUT("/prices", httpHandlerToGin(s.aiCostHandler.HandlePrices))
+			aiCostsAPI.DELETE("/prices/:id", httpHandlerToGin(s.aiCostHandler.HandleDeletePrice))
+			// GET/PUT /api/ai-costs/budgets - бюджеты и их состояние за текущий период
+			aiCostsAPI.GET("/budgets", httpHandlerToGin(s.aiCostHandler.HandleBudgets))
+			aiCostsAPI.PUT("/budgets", httpHandlerToGin(s.aiCostHandler.HandleBudgets))
+			aiCostsAPI.DELETE("/budgets/:id", httpHandlerToGin(s.aiCostHandler.HandleDeleteBudget))
+			// GET /api/ai-costs/usage - журнал потребления по клиентам/проектам/сессиям/дням
+			aiCostsAPI.GET("/usage", httpHandlerToGin(s.aiCostHandler.HandleUsage))
+			// GET /api/ai-costs/export - выгрузка в Excel
+			aiCostsAPI.GET("/export", httpHandlerToGin(s.aiCostHandler.HandleExport))
+		}
+	}
+
+	// Audit API (журнал аудита изменяющих операций)
+	if s.auditHandler != nil {
+		auditAPI := api.Group("/audit")
+		{
+			// GET /api/audit - выборка журнала по фильтрам
+			auditAPI.GET("", httpHandlerToGin(s.auditHandler.HandleQuery))
+			// GET /api/audit/export - выгрузка в JSON/CSV
+			auditAPI.GET("/export", httpHandlerToGin(s.auditHandler.HandleExport))
+			// GET /api/audit/verify - проверка цепочки хешей
+			auditAPI.GET("/verify", httpHandlerToGin(s.auditHandler.HandleVerify))
+			// GET/PUT /api/audit/settings - срок хранения
+			auditAPI.GET("/settings", httpHandlerToGin(s.auditHandler.HandleSettings))
+			auditAPI.PUT("/settings", httpHandlerToGin(s.auditHandler.HandleSettings))
+		}
+	}
+
+	// Benchmark bundles API (перенос эталонов между инсталляциями)
+	if s.benchmarkBundleHandler != nil {
+		bundleAPI := api.Group("/benchmarks/bundle")
+		{
+			// GET /api/benchmarks/bundle/export - подписанный пакет эталонов
+			bundleAPI.GET("/export", httpHandlerToGin(s.benchmarkBundleHandler.HandleExport))
+			// POST /api/benchmarks/bundle/import - импорт пакета (dry_run=true - только отчет)
+			bundleAPI.POST("/import", httpHandlerToGin(s.benchmarkBundleHandler.HandleImport))
+			// GET /api/benchmarks/bundle/public-key - открытый ключ подписи этой инсталляции
+			bundleAPI.GET("/public-key", httpHandlerToGin(s.benchmarkBundleHandler.HandlePublicKey))
+			// GET/POST /api/benchmarks/bundle/trusted-keys - доверенные ключи других инсталляций
+			bundleAPI.GET("/trusted-keys", httpHandlerToGin(s.benchmarkBundleHandler.HandleTrustedKeys))
+			bundleAPI.POST("/trusted-keys", httpHandlerToGin(s.benchmarkBundleHandler.HandleTrustedKeys))
+			// DELETE /api/benchmarks/bundle/trusted-keys/:keyId
+			bundleAPI.DELETE("/trusted-keys/:keyId", func(c *gin.Context) {
+				s.benchmarkBundleHandler.HandleDeleteTrustedKey(c.Writer, c.Request, c.Param("keyId"))
+			})
+		}
+	}
+
+	// Exchange imports API (файловый обмен 1С)
+	if s.exchangeImportService != nil {
+		// GET /api/exchange-imports - прогресс импорта файлов обмена
+		api.GET("/exchange-imports", httpHandlerToGin(s.handleExchangeImports))
+	}
+
 	// Counterparties API
 	log.Printf("[DEBUG] counterpartyHandler status: %v (nil=%t)", s.counterpartyHandler, s.counterpartyHandler == nil)
 	if s.counterpartyHandler != nil {
@@ -663,12 +871,374 @@ func (s *Server) registerGinHandlers(router *gin.Engine) {
 			counterpartiesAPI.GET("/all", httpHandlerToGin(s.counterpartyHandler.HandleGetAllCounterparties))
 			// GET /api/counterparties/all/export - экспорт контрагентов
 			counterpartiesAPI.GET("/all/export", httpHandlerToGin(s.counterpartyHandler.HandleExportAllCounterparties))
+			// GET /api/counterparties/normalized - нормализованные контрагенты клиента или проекта
+			counterpartiesAPI.GET("/normalized", httpHandlerToGin(s.counterpartyHandler.HandleNormalizedCounterparties))
+			// GET /api/counterparties/normalized/:id/merges - история слияний контрагента
+			counterpartiesAPI.GET("/normalized/:id/merges", httpHandlerToGin(s.counterpartyHandler.HandleCounterpartyMergeHistory))
+			// GET /api/counterparties/merges/:mergeId - событие слияния с состоянием участников
+			counterpartiesAPI.GET("/merges/:mergeId", httpHandlerToGin(s.counterpartyHandler.HandleCounterpartyMergeRoutes))
+			// POST /api/counterparties/merges/:mergeId/unmerge - отмена слияния
+			counterpartiesAPI.POST("/merges/:mergeId/unmerge", httpHandlerToGin(s.counterpartyHandler.HandleCounterpartyMergeRoutes))
 		}
 		log.Printf("[Routes] ✓ Counterparties API routes registered: GET /api/counterparties/all, GET /api/counterparties/all/export")
 	} else {
 		log.Printf("⚠ WARNING: counterpartyHandler is nil, Counterparties API routes will not be registered")
 	}
 
+	// Counterparty reverification API (повторная сверка контрагентов с реестрами)
+	if s.reverificationHandler != nil {
+		reverificationAPI := api.Group("/counterparties/reverification")
+		{
+			// GET /api/counterparties/reverification/status - расписание, последний запуск, статусы
+			reverificationAPI.GET("/status", httpHandlerToGin(s.reverificationHandler.HandleStatus))
+			// POST /api/counterparties/reverification/run - внеплановый запуск
+			reverificationAPI.POST("/run", httpHandlerToGin(s.reverificationHandler.HandleRun))
+			// GET /api/counterparties/reverification/changes - журнал изменений реквизитов
+			reverificationAPI.GET("/changes", httpHandlerToGin(s.reverificationHandler.HandleChanges))
+			// GET/POST /api/counterparties/reverification/:id - состояние / немедленная сверка контрагента
+			counterpartyRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid counterparty ID"})
+					return
+				}
+				s.reverificationHandler.HandleCounterparty(c.Writer, c.Request, id)
+			}
+			reverificationAPI.GET("/:id", counterpartyRoute)
+			reverificationAPI.POST("/:id", counterpartyRoute)
+		}
+	}
+
+	// Requisite registries API (справочники БИК, кодов причин КПП и регионов)
+	if s.requisiteRegistryHandler != nil {
+		requisitesAPI := api.Group("/quality/requisites")
+		{
+			// GET /api/quality/requisites/registries - версии загруженных справочников
+			requisitesAPI.GET("/registries", httpHandlerToGin(s.requisiteRegistryHandler.HandleStatus))
+			// POST /api/quality/requisites/registries/load - обновление справочников из каталога
+			requisitesAPI.POST("/registries/load", httpHandlerToGin(s.requisiteRegistryHandler.HandleLoad))
+			// POST /api/quality/requisites/check - перекрестная проверка реквизитов
+			requisitesAPI.POST("/check", httpHandlerToGin(s.requisiteRegistryHandler.HandleCheck))
+		}
+	}
+
+	// Quality anomalies API (аномалии в трендах качества)
+	if s.qualityAnomalyHandler != nil {
+		anomaliesAPI := api.Group("/quality/anomalies")
+		{
+			// GET /api/quality/anomalies - список аномалий с фильтрами
+			anomaliesAPI.GET("", httpHandlerToGin(s.qualityAnomalyHandler.HandleList))
+			// GET /api/quality/anomalies/summary - открытые аномалии по важности
+			anomaliesAPI.GET("/summary", httpHandlerToGin(s.qualityAnomalyHandler.HandleSummary))
+			// GET /api/quality/anomalies/baselines - базовые линии рядов базы данных
+			anomaliesAPI.GET("/baselines", httpHandlerToGin(s.qualityAnomalyHandler.HandleBaselines))
+			// POST /api/quality/anomalies/analyze - внеочередной анализ рядов базы данных
+			anomaliesAPI.POST("/analyze", httpHandlerToGin(s.qualityAnomalyHandler.HandleAnalyze))
+			// GET/PATCH /api/quality/anomalies/:id - аномалия / смена статуса
+			anomalyRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anomaly ID"})
+					return
+				}
+				s.qualityAnomalyHandler.HandleAnomaly(c.Writer, c.Request, id)
+			}
+			anomaliesAPI.GET("/:id", anomalyRoute)
+			anomaliesAPI.PATCH("/:id", anomalyRoute)
+		}
+	}
+
+	// Name templates API (шаблоны нормализованных имен по разделам и категориям)
+	if s.nameTemplateHandler != nil {
+		nameTemplatesAPI := api.Group("/normalization/name-templates")
+		{
+			// GET /api/normalization/name-templates - список шаблонов
+			nameTemplatesAPI.GET("", httpHandlerToGin(s.nameTemplateHandler.HandleList))
+			// POST /api/normalization/name-templates - создание шаблона
+			nameTemplatesAPI.POST("", httpHandlerToGin(s.nameTemplateHandler.HandleCreate))
+			// POST /api/normalization/name-templates/preview - имя по шаблону для одного наименования
+			nameTemplatesAPI.POST("/preview", httpHandlerToGin(s.nameTemplateHandler.HandlePreview))
+			// POST /api/normalization/name-templates/rerender - перегенерация имен проекта
+			nameTemplatesAPI.POST("/rerender", httpHandlerToGin(s.nameTemplateHandler.HandleRerender))
+			// GET/PUT/DELETE /api/normalization/name-templates/:id - шаблон по ID
+			templateRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid template ID"})
+					return
+				}
+				s.nameTemplateHandler.HandleTemplate(c.Writer, c.Request, id)
+			}
+			nameTemplatesAPI.GET("/:id", templateRoute)
+			nameTemplatesAPI.PUT("/:id", templateRoute)
+			nameTemplatesAPI.DELETE("/:id", templateRoute)
+		}
+	}
+
+	// GISP compliance API (импортозамещение: номенклатура проекта против реестра ГИСП)
+	if s.gispComplianceHandler != nil {
+		// GET /api/gisp/compliance/:projectId - отчет в JSON или Excel (format=xlsx)
+		api.GET("/gisp/compliance/:projectId", func(c *gin.Context) {
+			projectID, err := strconv.Atoi(c.Param("projectId"))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+				return
+			}
+			s.gispComplianceHandler.HandleProjectReport(c.Writer, c.Request, projectID)
+		})
+	}
+
+	// Pipeline stages API (конвейер этапов нормализации и граф этапов проекта)
+	if s.pipelineStagesHandler != nil {
+		pipelineAPI := api.Group("/normalization/pipeline")
+		{
+			// GET /api/normalization/pipeline/stages - доступные этапы
+			pipelineAPI.GET("/stages", httpHandlerToGin(s.pipelineStagesHandler.HandleStages))
+			// GET/PUT/DELETE /api/normalization/pipeline/config/:projectId - граф этапов проекта
+			configRoute := func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.pipelineStagesHandler.HandleConfig(c.Writer, c.Request, projectID)
+			}
+			pipelineAPI.GET("/config/:projectId", configRoute)
+			pipelineAPI.PUT("/config/:projectId", configRoute)
+			pipelineAPI.DELETE("/config/:projectId", configRoute)
+			// POST /api/normalization/pipeline/run/:projectId - проверочный прогон этапов по номенклатуре проекта
+			pipelineAPI.POST("/run/:projectId", func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.pipelineStagesHandler.HandleRun(c.Writer, c.Request, projectID)
+			})
+			// GET /api/normalization/pipeline/results - статистика результатов этапов
+			pipelineAPI.GET("/results", httpHandlerToGin(s.pipelineStagesHandler.HandleResults))
+			// GET /api/normalization/pipeline/items/:id - результаты этапов элемента
+			pipelineAPI.GET("/items/:id", func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+					return
+				}
+				s.pipelineStagesHandler.HandleItemResults(c.Writer, c.Request, id)
+			})
+		}
+	}
+
+	// Upload automation API (правила проекта, выполняемые после завершения выгрузки)
+	if s.uploadAutomationHandler != nil {
+		automationAPI := api.Group("/upload-automation")
+		{
+			// GET /api/upload-automation/actions - доступные действия
+			automationAPI.GET("/actions", httpHandlerToGin(s.uploadAutomationHandler.HandleActions))
+			// GET/POST /api/upload-automation/projects/:projectId/rules - правила проекта
+			projectRulesRoute := func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.uploadAutomationHandler.HandleProjectRules(c.Writer, c.Request, projectID)
+			}
+			automationAPI.GET("/projects/:projectId/rules", projectRulesRoute)
+			automationAPI.POST("/projects/:projectId/rules", projectRulesRoute)
+			// GET /api/upload-automation/projects/:projectId/runs - последние запуски правил проекта
+			automationAPI.GET("/projects/:projectId/runs", func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.uploadAutomationHandler.HandleProjectRuns(c.Writer, c.Request, projectID)
+			})
+			// GET/PUT/DELETE /api/upload-automation/rules/:id - правило по ID
+			ruleRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+					return
+				}
+				s.uploadAutomationHandler.HandleRule(c.Writer, c.Request, id)
+			}
+			automationAPI.GET("/rules/:id", ruleRoute)
+			automationAPI.PUT("/rules/:id", ruleRoute)
+			automationAPI.DELETE("/rules/:id", ruleRoute)
+			// GET /api/upload-automation/uploads/:uuid/runs - запуски правил для выгрузки
+			automationAPI.GET("/uploads/:uuid/runs", func(c *gin.Context) {
+				s.uploadAutomationHandler.HandleUploadRuns(c.Writer, c.Request, c.Param("uuid"))
+			})
+		}
+	}
+
+	// Uploads API (история выгрузок из 1С и табличного импорта)
+	if s.uploadHandler != nil {
+		uploadsAPI := api.Group("/uploads")
+		{
+			// GET /api/uploads - список выгрузок с фильтрами и пагинацией
+			uploadsAPI.GET("", httpHandlerToGin(s.uploadHandler.HandleListUploads))
+			// GET /api/uploads/:uuid - детали выгрузки
+			uploadsAPI.GET("/:uuid", httpHandlerToGin(s.uploadHandler.HandleUploadRoutes))
+		}
+	}
+
+	// Spelling API (исправление опечаток: проверка, очередь исправлений, белый список)
+	if s.spellingHandler != nil {
+		spellingAPI := api.Group("/spelling")
+		{
+			// POST /api/spelling/check - проверка текста словарем проекта
+			spellingAPI.POST("/check", httpHandlerToGin(s.spellingHandler.HandleCheck))
+			// GET/POST /api/spelling/projects/:projectId/corrections - очередь проверки исправлений
+			projectCorrectionsRoute := func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.spellingHandler.HandleProjectCorrections(c.Writer, c.Request, projectID)
+			}
+			spellingAPI.GET("/projects/:projectId/corrections", projectCorrectionsRoute)
+			spellingAPI.POST("/projects/:projectId/corrections", projectCorrectionsRoute)
+			// GET/POST /api/spelling/projects/:projectId/whitelist - белый список (0 - общий)
+			projectWhitelistRoute := func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.spellingHandler.HandleProjectWhitelist(c.Writer, c.Request, projectID)
+			}
+			spellingAPI.GET("/projects/:projectId/whitelist", projectWhitelistRoute)
+			spellingAPI.POST("/projects/:projectId/whitelist", projectWhitelistRoute)
+			// POST /api/spelling/projects/:projectId/rebuild - перестроение словаря
+			spellingAPI.POST("/projects/:projectId/rebuild", func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.spellingHandler.HandleRebuild(c.Writer, c.Request, projectID)
+			})
+			// PUT /api/spelling/corrections/:id - решение проверки исправления
+			spellingAPI.PUT("/corrections/:id", func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correction ID"})
+					return
+				}
+				s.spellingHandler.HandleCorrection(c.Writer, c.Request, id)
+			})
+			// DELETE /api/spelling/whitelist/:id - удаление слова из белого списка
+			spellingAPI.DELETE("/whitelist/:id", func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid word ID"})
+					return
+				}
+				s.spellingHandler.HandleWhitelistWord(c.Writer, c.Request, id)
+			})
+		}
+	}
+
+	// Term dictionaries API (сокращения, синонимы и стоп-слова: записи, поиск кандидатов, проверка)
+	if s.termDictionaryHandler != nil {
+		dictionariesAPI := api.Group("/dictionaries")
+		{
+			// POST /api/dictionaries/apply - применение словаря проекта к тексту
+			dictionariesAPI.POST("/apply", httpHandlerToGin(s.termDictionaryHandler.HandleApply))
+			// GET/POST /api/dictionaries/projects/:projectId/entries - записи словаря (0 - общий)
+			projectEntriesRoute := func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.termDictionaryHandler.HandleProjectEntries(c.Writer, c.Request, projectID)
+			}
+			dictionariesAPI.GET("/projects/:projectId/entries", projectEntriesRoute)
+			dictionariesAPI.POST("/projects/:projectId/entries", projectEntriesRoute)
+			// POST /api/dictionaries/projects/:projectId/mine - поиск кандидатов в сокращения
+			dictionariesAPI.POST("/projects/:projectId/mine", func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.termDictionaryHandler.HandleMine(c.Writer, c.Request, projectID)
+			})
+			// PUT/DELETE /api/dictionaries/entries/:id - решение проверки или удаление записи
+			entryRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entry ID"})
+					return
+				}
+				s.termDictionaryHandler.HandleEntry(c.Writer, c.Request, id)
+			}
+			dictionariesAPI.PUT("/entries/:id", entryRoute)
+			dictionariesAPI.DELETE("/entries/:id", entryRoute)
+		}
+	}
+
+	// Tabular import API (номенклатура и контрагенты из XLSX/CSV с сопоставлением колонок)
+	if s.tabularImportHandler != nil {
+		tabularAPI := api.Group("/imports/tabular")
+		{
+			// GET /api/imports/tabular/fields - поля для сопоставления
+			tabularAPI.GET("/fields", httpHandlerToGin(s.tabularImportHandler.HandleFields))
+			// POST /api/imports/tabular/preview - разбор файла и предложенное сопоставление
+			tabularAPI.POST("/preview", httpHandlerToGin(s.tabularImportHandler.HandlePreview))
+			// POST /api/imports/tabular/projects/:projectId - импорт файла в проект как выгрузки
+			tabularAPI.POST("/projects/:projectId", func(c *gin.Context) {
+				projectID, err := strconv.Atoi(c.Param("projectId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID"})
+					return
+				}
+				s.tabularImportHandler.HandleImport(c.Writer, c.Request, projectID)
+			})
+			// GET/POST /api/imports/tabular/clients/:clientId/profiles - профили сопоставления клиента
+			clientProfilesRoute := func(c *gin.Context) {
+				clientID, err := strconv.Atoi(c.Param("clientId"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid client ID"})
+					return
+				}
+				s.tabularImportHandler.HandleClientProfiles(c.Writer, c.Request, clientID)
+			}
+			tabularAPI.GET("/clients/:clientId/profiles", clientProfilesRoute)
+			tabularAPI.POST("/clients/:clientId/profiles", clientProfilesRoute)
+			// GET/PUT/DELETE /api/imports/tabular/profiles/:id - профиль по ID
+			profileRoute := func(c *gin.Context) {
+				id, err := strconv.Atoi(c.Param("id"))
+				if err != nil {
+					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid profile ID"})
+					return
+				}
+				s.tabularImportHandler.HandleProfile(c.Writer, c.Request, id)
+			}
+			tabularAPI.GET("/profiles/:id", profileRoute)
+			tabularAPI.PUT("/profiles/:id", profileRoute)
+			tabularAPI.DELETE("/profiles/:id", profileRoute)
+		}
+	}
+
+	// Events API (единая подписка на события прогресса и мониторинга)
+	if s.eventsHandler != nil {
+		eventsAPI := api.Group("/events")
+		{
+			// GET /api/events/stream - SSE с фильтрами и воспроизведением по Last-Event-ID
+			eventsAPI.GET("/stream", httpHandlerToGin(s.eventsHandler.HandleStream))
+			// GET /api/events/ws - WebSocket с управлением подпиской
+			eventsAPI.GET("/ws", httpHandlerToGin(s.eventsHandler.HandleWebSocket))
+			// GET /api/events/stats - статистика шины событий
+			eventsAPI.GET("/stats", httpHandlerToGin(s.eventsHandler.HandleStats))
+		}
+	}
+
 	// Reports API
 	if s.reportHandler != nil {
 		reportsAPI := api.Group("/reports")
@@ -788,6 +1358,7 @@ func (s *Server) registerLegacyHandlers(mux *http.ServeMux) {
 		mux.HandleFunc("/api/workers/huggingface/status", s.workerHandler.HandleCheckHuggingFaceConnection)
 		mux.HandleFunc("/api/workers/orchestrator/strategy", s.workerHandler.HandleOrchestratorStrategy)
 		mux.HandleFunc("/api/workers/orchestrator/stats", s.workerHandler.HandleOrchestratorStats)
+		mux.HandleFunc("/api/workers/rate-limits", s.workerHandler.HandleRateLimits)
 	}
 	if s.workerTraceHandler != nil {
 		mux.HandleFunc("/api/internal/worker-trace/stream", s.workerTraceHandler.HandleWorkerTraceStream)
@@ -0,0 +1,655 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"httpserver/database"
+	"httpserver/internal/config"
+	"httpserver/nomenclature"
+	apperrors "httpserver/server/errors"
+)
+
+// Области и периоды бюджетов AI
+const (
+	AIBudgetScopeGlobal  = "global"
+	AIBudgetScopeClient  = "client"
+	AIBudgetScopeProject = "project"
+
+	AIBudgetPeriodDaily   = "daily"
+	AIBudgetPeriodMonthly = "monthly"
+)
+
+// AIBudgetStatus состояние бюджета за текущий период
+type AIBudgetStatus struct {
+	Budget       *database.AIBudget `json:"budget"`
+	Source       string             `json:"source"` // budgets или client_config (лимиты из overlay клиента)
+	PeriodStart  string             `json:"period_start"`
+	PeriodEnd    string             `json:"period_end"`
+	Spent        float64            `json:"spent"`
+	Remaining    float64            `json:"remaining"`
+	SoftExceeded bool               `json:"soft_exceeded"`
+	HardExceeded bool               `json:"hard_exceeded"`
+}
+
+// AICostService ведет учет затрат на AI: цены провайдеров, журнал потребления
+// по клиентам/проектам/сессиям/дням и мягкие/жесткие бюджеты.
+// Реализует nomenclature.UsageRecorder и подключается через nomenclature.SetUsageRecorder.
+type AICostService struct {
+	serviceDB *database.ServiceDB
+	logFunc   func(format string, args ...interface{})
+	now       func() time.Time
+
+	mu      sync.RWMutex
+	prices  map[string]*database.AIPrice // provider|model -> цена
+	budgets []*database.AIBudget
+	loaded  bool
+
+	spendMu  sync.Mutex
+	spend    map[string]float64 // scope|scope_id|period_start -> затраты (кэш поверх журнала)
+	notified map[string]bool    // ключи бюджетов, по которым уже отправлено уведомление
+
+	clientLimits func(clientID int) *config.ClientSpendLimits
+	onSoftLimit  func(status *AIBudgetStatus)
+	onHardLimit  func(status *AIBudgetStatus)
+}
+
+// NewAICostService создает сервис учета затрат на AI. logFunc может быть nil.
+func NewAICostService(serviceDB *database.ServiceDB, logFunc func(format string, args ...interface{})) *AICostService {
+	if logFunc == nil {
+		logFunc = func(string, ...interface{}) {}
+	}
+	return &AICostService{
+		serviceDB: serviceDB,
+		logFunc:   logFunc,
+		now:       time.Now,
+		prices:    make(map[string]*database.AIPrice),
+		spend:     make(map[string]float64),
+		notified:  make(map[string]bool),
+	}
+}
+
+// SetClientLimitsSource задает источник лимитов расходов из overlay клиента
+func (s *AICostService) SetClientLimitsSource(source func(clientID int) *config.ClientSpendLimits) {
+	s.clientLimits = source
+}
+
+// SetLimitHandlers задает обработчики превышения мягкого и жесткого бюджетов.
+// Каждый обработчик вызывается один раз за период бюджета.
+func (s *AICostService) SetLimitHandlers(onSoft, onHard func(status *AIBudgetStatus)) {
+	s.onSoftLimit = onSoft
+	s.onHardLimit = onHard
+}
+
+// load загружает цены и бюджеты из сервисной БД
+func (s *AICostService) load() error {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if loaded || s.serviceDB == nil {
+		return nil
+	}
+	return s.reload()
+}
+
+// reload перечитывает цены и бюджеты
+func (s *AICostService) reload() error {
+	if s.serviceDB == nil {
+		return nil
+	}
+	prices, err := s.serviceDB.GetAIPrices()
+	if err != nil {
+		return err
+	}
+	budgets, err := s.serviceDB.GetAIBudgets()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.prices = make(map[string]*database.AIPrice, len(prices))
+	for _, p := range prices {
+		s.prices[priceKey(p.Provider, p.Model)] = p
+	}
+	s.budgets = budgets
+	s.loaded = true
+	s.mu.Unlock()
+	return nil
+}
+
+func priceKey(provider, model string) string {
+	return strings.ToLower(provider) + "|" + model
+}
+
+// normalizeProvider приводит имя провайдера к идентификатору ("Eden AI" -> "edenai")
+func normalizeProvider(provider string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(provider)), " ", "")
+}
+
+// findPrice ищет цену модели, затем цену провайдера по умолчанию ("*")
+func (s *AICostService) findPrice(provider, model string) *database.AIPrice {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if p, ok := s.prices[priceKey(provider, model)]; ok {
+		return p
+	}
+	return s.prices[priceKey(provider, "*")]
+}
+
+// CalculateCost рассчитывает стоимость вызова по таблице цен (0, если цена не задана)
+func (s *AICostService) CalculateCost(provider, model string, inputTokens, outputTokens int) float64 {
+	if err := s.load(); err != nil {
+		s.logFunc("[AICost] failed to load prices: %v", err)
+	}
+	price := s.findPrice(normalizeProvider(provider), model)
+	if price == nil {
+		return 0
+	}
+	return price.PerRequest +
+		float64(inputTokens)/1000*price.InputPer1KTokens +
+		float64(outputTokens)/1000*price.OutputPer1KTokens
+}
+
+// RecordAIUsage записывает вызов в журнал и проверяет бюджеты. Возвращает стоимость вызова.
+func (s *AICostService) RecordAIUsage(record nomenclature.UsageRecord) float64 {
+	provider := normalizeProvider(record.Provider)
+	cost := s.CalculateCost(provider, record.Model, record.InputTokens, record.OutputTokens)
+
+	ts := record.Timestamp
+	if ts.IsZero() {
+		ts = s.now()
+	}
+	entry := &database.AIUsageLedgerEntry{
+		Day:          ts.Format("2006-01-02"),
+		Provider:     provider,
+		Model:        record.Model,
+		ClientID:     record.Tags.ClientID,
+		ProjectID:    record.Tags.ProjectID,
+		SessionID:    record.Tags.SessionID,
+		Operation:    record.Tags.Operation,
+		Requests:     1,
+		InputTokens:  int64(record.InputTokens),
+		OutputTokens: int64(record.OutputTokens),
+		Cost:         cost,
+	}
+	if record.Estimated {
+		entry.Estimated = 1
+	}
+	if s.serviceDB != nil {
+		if err := s.serviceDB.AddAIUsage(entry); err != nil {
+			s.logFunc("[AICost] failed to record usage: %v", err)
+		}
+	}
+
+	if cost > 0 {
+		s.addSpend(record.Tags, ts, cost)
+		s.evaluate(record.Tags)
+	}
+	return cost
+}
+
+// AllowAIRequest запрещает вызов AI, если исчерпан любой жесткий бюджет, относящийся к вызову
+func (s *AICostService) AllowAIRequest(tags nomenclature.UsageTags) error {
+	for _, status := range s.statusesFor(tags) {
+		if status.HardExceeded {
+			return fmt.Errorf("%w: %s budget %s (spent %.2f of %.2f %s)", nomenclature.ErrAIBudgetExceeded,
+				status.Budget.Scope, status.Budget.Period, status.Spent, status.Budget.HardLimit, status.Budget.Currency)
+		}
+	}
+	return nil
+}
+
+// periodBounds возвращает границы текущего периода бюджета (YYYY-MM-DD)
+func periodBounds(period string, now time.Time) (string, string) {
+	if period == AIBudgetPeriodMonthly {
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		end := start.AddDate(0, 1, -1)
+		return start.Format("2006-01-02"), end.Format("2006-01-02")
+	}
+	day := now.Format("2006-01-02")
+	return day, day
+}
+
+func spendKey(scope string, scopeID int, periodStart string) string {
+	return fmt.Sprintf("%s|%d|%s", scope, scopeID, periodStart)
+}
+
+// getSpend возвращает затраты области за период, при первом обращении - из журнала
+func (s *AICostService) getSpend(scope string, scopeID int, from, to string) float64 {
+	key := spendKey(scope, scopeID, from)
+	s.spendMu.Lock()
+	spent, ok := s.spend[key]
+	s.spendMu.Unlock()
+	if ok {
+		return spent
+	}
+	if s.serviceDB == nil {
+		return 0
+	}
+	spent, err := s.serviceDB.GetAISpend(scope, scopeID, from, to)
+	if err != nil {
+		s.logFunc("[AICost] failed to get spend: %v", err)
+		return 0
+	}
+	s.spendMu.Lock()
+	// Параллельная запись могла уже добавить затраты в кэш - значение из журнала их включает
+	s.spend[key] = spent
+	s.spendMu.Unlock()
+	return spent
+}
+
+// addSpend увеличивает закэшированные затраты всех областей, к которым относится вызов
+func (s *AICostService) addSpend(tags nomenclature.UsageTags, ts time.Time, cost float64) {
+	s.spendMu.Lock()
+	defer s.spendMu.Unlock()
+	for _, period := range []string{AIBudgetPeriodDaily, AIBudgetPeriodMonthly} {
+		start, _ := periodBounds(period, ts)
+		keys := []string{spendKey(AIBudgetScopeGlobal, 0, start)}
+		if tags.ClientID > 0 {
+			keys = append(keys, spendKey(AIBudgetScopeClient, tags.ClientID, start))
+		}
+		if tags.ProjectID > 0 {
+			keys = append(keys, spendKey(AIBudgetScopeProject, tags.ProjectID, start))
+		}
+		for _, key := range keys {
+			// Ключи без кэша будут прочитаны из журнала, который уже содержит вызов
+			if _, ok := s.spend[key]; ok {
+				s.spend[key] += cost
+			}
+		}
+	}
+}
+
+// budgetsFor возвращает бюджеты, относящиеся к вызову (включая лимиты из overlay клиента)
+func (s *AICostService) budgetsFor(tags nomenclature.UsageTags) []*AIBudgetStatus {
+	if err := s.load(); err != nil {
+		s.logFunc("[AICost] failed to load budgets: %v", err)
+	}
+
+	var result []*AIBudgetStatus
+	s.mu.RLock()
+	for _, b := range s.budgets {
+		if !b.Enabled {
+			continue
+		}
+		if b.Scope == AIBudgetScopeGlobal ||
+			(b.Scope == AIBudgetScopeClient && tags.ClientID > 0 && b.ScopeID == tags.ClientID) ||
+			(b.Scope == AIBudgetScopeProject && tags.ProjectID > 0 && b.ScopeID == tags.ProjectID) {
+			result = append(result, &AIBudgetStatus{Budget: b, Source: "budgets"})
+		}
+	}
+	s.mu.RUnlock()
+
+	if tags.ClientID > 0 {
+		result = append(result, s.clientConfigBudgets(tags.ClientID)...)
+	}
+	return result
+}
+
+// clientConfigBudgets представляет лимиты расходов из overlay клиента как жесткие бюджеты
+func (s *AICostService) clientConfigBudgets(clientID int) []*AIBudgetStatus {
+	if s.clientLimits == nil {
+		return nil
+	}
+	limits := s.clientLimits(clientID)
+	if limits == nil {
+		return nil
+	}
+	var result []*AIBudgetStatus
+	add := func(period string, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		result = append(result, &AIBudgetStatus{
+			Budget: &database.AIBudget{
+				Scope:     AIBudgetScopeClient,
+				ScopeID:   clientID,
+				Period:    period,
+				HardLimit: limit,
+				Currency:  limits.Currency,
+				Enabled:   true,
+			},
+			Source: "client_config",
+		})
+	}
+	add(AIBudgetPeriodDaily, limits.DailyLimit)
+	add(AIBudgetPeriodMonthly, limits.MonthlyLimit)
+	return result
+}
+
+// fillStatus рассчитывает затраты и превышения бюджета за текущий период
+func (s *AICostService) fillStatus(status *AIBudgetStatus) {
+	b := status.Budget
+	status.PeriodStart, status.PeriodEnd = periodBounds(b.Period, s.now())
+	status.Spent = s.getSpend(b.Scope, b.ScopeID, status.PeriodStart, status.PeriodEnd)
+	if b.HardLimit > 0 {
+		status.Remaining = b.HardLimit - status.Spent
+		if status.Remaining < 0 {
+			status.Remaining = 0
+		}
+		status.HardExceeded = status.Spent >= b.HardLimit
+	}
+	status.SoftExceeded = b.SoftLimit > 0 && status.Spent >= b.SoftLimit
+}
+
+// statusesFor возвращает состояние бюджетов, относящихся к вызову
+func (s *AICostService) statusesFor(tags nomenclature.UsageTags) []*AIBudgetStatus {
+	statuses := s.budgetsFor(tags)
+	for _, status := range statuses {
+		s.fillStatus(status)
+	}
+	return statuses
+}
+
+// evaluate уведомляет о превышении бюджетов после записи вызова
+func (s *AICostService) evaluate(tags nomenclature.UsageTags) {
+	for _, status := range s.statusesFor(tags) {
+		b := status.Budget
+		base := fmt.Sprintf("%s|%s|%d|%s|%s", status.Source, b.Scope, b.ScopeID, b.Period, status.PeriodStart)
+		if status.HardExceeded && s.markNotified(base+"|hard") {
+			s.logFunc("[AICost] hard budget exceeded: %s %d %s spent %.2f of %.2f %s",
+				b.Scope, b.ScopeID, b.Period, status.Spent, b.HardLimit, b.Currency)
+			if s.onHardLimit != nil {
+				s.onHardLimit(status)
+			}
+		} else if status.SoftExceeded && s.markNotified(base+"|soft") {
+			s.logFunc("[AICost] soft budget exceeded: %s %d %s spent %.2f of %.2f %s",
+				b.Scope, b.ScopeID, b.Period, status.Spent, b.SoftLimit, b.Currency)
+			if s.onSoftLimit != nil {
+				s.onSoftLimit(status)
+			}
+		}
+	}
+}
+
+// markNotified отмечает уведомление; возвращает false, если оно уже было
+func (s *AICostService) markNotified(key string) bool {
+	s.spendMu.Lock()
+	defer s.spendMu.Unlock()
+	if s.notified[key] {
+		return false
+	}
+	s.notified[key] = true
+	return true
+}
+
+// resetSpendCache сбрасывает кэш затрат и уведомлений (после изменения бюджетов)
+func (s *AICostService) resetSpendCache() {
+	s.spendMu.Lock()
+	s.spend = make(map[string]float64)
+	s.notified = make(map[string]bool)
+	s.spendMu.Unlock()
+}
+
+// GetPrices возвращает таблицу цен
+func (s *AICostService) GetPrices() ([]*database.AIPrice, error) {
+	if s.serviceDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	prices, err := s.serviceDB.GetAIPrices()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить цены AI", err)
+	}
+	return prices, nil
+}
+
+// SavePrice валидирует и сохраняет цену модели провайдера
+func (s *AICostService) SavePrice(price *database.AIPrice) error {
+	if price == nil || strings.TrimSpace(price.Provider) == "" {
+		return apperrors.NewValidationError("provider обязателен", nil)
+	}
+	if price.InputPer1KTokens < 0 || price.OutputPer1KTokens < 0 || price.PerRequest < 0 {
+		return apperrors.NewValidationError("цены не могут быть отрицательными", nil)
+	}
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	price.Provider = normalizeProvider(price.Provider)
+	if err := s.serviceDB.UpsertAIPrice(price); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить цену AI", err)
+	}
+	if err := s.reload(); err != nil {
+		return apperrors.NewInternalError("не удалось перечитать цены AI", err)
+	}
+	return nil
+}
+
+// DeletePrice удаляет цену
+func (s *AICostService) DeletePrice(id int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	if err := s.serviceDB.DeleteAIPrice(id); err != nil {
+		return apperrors.NewInternalError("не удалось удалить цену AI", err)
+	}
+	if err := s.reload(); err != nil {
+		return apperrors.NewInternalError("не удалось перечитать цены AI", err)
+	}
+	return nil
+}
+
+// SaveBudget валидирует и сохраняет бюджет
+func (s *AICostService) SaveBudget(budget *database.AIBudget) error {
+	if budget == nil {
+		return apperrors.NewValidationError("бюджет не задан", nil)
+	}
+	switch budget.Scope {
+	case AIBudgetScopeGlobal:
+		budget.ScopeID = 0
+	case AIBudgetScopeClient, AIBudgetScopeProject:
+		if budget.ScopeID <= 0 {
+			return apperrors.NewValidationError("scope_id обязателен для бюджета клиента или проекта", nil)
+		}
+	default:
+		return apperrors.NewValidationError(fmt.Sprintf("неизвестная область бюджета: %s", budget.Scope), nil)
+	}
+	if budget.Period != AIBudgetPeriodDaily && budget.Period != AIBudgetPeriodMonthly {
+		return apperrors.NewValidationError(fmt.Sprintf("неизвестный период бюджета: %s", budget.Period), nil)
+	}
+	if budget.SoftLimit < 0 || budget.HardLimit < 0 {
+		return apperrors.NewValidationError("лимиты не могут быть отрицательными", nil)
+	}
+	if budget.SoftLimit == 0 && budget.HardLimit == 0 {
+		return apperrors.NewValidationError("нужно задать soft_limit или hard_limit", nil)
+	}
+	if budget.HardLimit > 0 && budget.SoftLimit > budget.HardLimit {
+		return apperrors.NewValidationError("soft_limit не может превышать hard_limit", nil)
+	}
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	if err := s.serviceDB.UpsertAIBudget(budget); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить бюджет AI", err)
+	}
+	if err := s.reload(); err != nil {
+		return apperrors.NewInternalError("не удалось перечитать бюджеты AI", err)
+	}
+	s.resetSpendCache()
+	return nil
+}
+
+// DeleteBudget удаляет бюджет
+func (s *AICostService) DeleteBudget(id int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	if err := s.serviceDB.DeleteAIBudget(id); err != nil {
+		return apperrors.NewInternalError("не удалось удалить бюджет AI", err)
+	}
+	if err := s.reload(); err != nil {
+		return apperrors.NewInternalError("не удалось перечитать бюджеты AI", err)
+	}
+	s.resetSpendCache()
+	return nil
+}
+
+// GetBudgetStatuses возвращает состояние всех бюджетов за текущие периоды
+func (s *AICostService) GetBudgetStatuses() ([]*AIBudgetStatus, error) {
+	if err := s.load(); err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить бюджеты AI", err)
+	}
+
+	var statuses []*AIBudgetStatus
+	s.mu.RLock()
+	for _, b := range s.budgets {
+		statuses = append(statuses, &AIBudgetStatus{Budget: b, Source: "budgets"})
+	}
+	s.mu.RUnlock()
+
+	if s.clientLimits != nil && s.serviceDB != nil {
+		overlays, err := s.serviceDB.GetAllClientConfigOverlays()
+		if err == nil {
+			for _, o := range overlays {
+				statuses = append(statuses, s.clientConfigBudgets(o.ClientID)...)
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		s.fillStatus(status)
+	}
+	return statuses, nil
+}
+
+// IsBlocked сообщает, исчерпан ли хотя бы один жесткий бюджет для указанных атрибутов
+func (s *AICostService) IsBlocked(tags nomenclature.UsageTags) bool {
+	return s.AllowAIRequest(tags) != nil
+}
+
+// GetUsage возвращает агрегированный журнал потребления
+func (s *AICostService) GetUsage(filter database.AIUsageFilter) ([]*database.AIUsageLedgerEntry, error) {
+	if s.serviceDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	entries, err := s.serviceDB.GetAIUsage(filter)
+	if err != nil {
+		if strings.Contains(err.Error(), "unsupported group by") {
+			return nil, apperrors.NewValidationError("некорректный параметр group_by", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось получить журнал потребления AI", err)
+	}
+	return entries, nil
+}
+
+// ExportExcel выгружает журнал потребления, сводку по провайдерам и состояние бюджетов в Excel
+func (s *AICostService) ExportExcel(w io.Writer, filter database.AIUsageFilter) error {
+	filter.GroupBy = nil
+	entries, err := s.GetUsage(filter)
+	if err != nil {
+		return err
+	}
+	statuses, err := s.GetBudgetStatuses()
+	if err != nil {
+		return err
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		return apperrors.NewInternalError("не удалось создать стиль заголовков", err)
+	}
+
+	writeSheet := func(sheet string, headers []string, rows [][]interface{}) error {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+		for i, header := range headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheet, cell, header)
+			f.SetCellStyle(sheet, cell, cell, headerStyle)
+		}
+		for r, row := range rows {
+			for c, value := range row {
+				cell, _ := excelize.CoordinatesToCellName(c+1, r+2)
+				f.SetCellValue(sheet, cell, value)
+			}
+		}
+		for i := range headers {
+			col, _ := excelize.ColumnNumberToName(i + 1)
+			f.SetColWidth(sheet, col, col, 15)
+		}
+		return nil
+	}
+
+	// Журнал
+	usageRows := make([][]interface{}, 0, len(entries))
+	byProvider := make(map[string]*database.AIUsageLedgerEntry)
+	for _, e := range entries {
+		usageRows = append(usageRows, []interface{}{
+			e.Day, e.Provider, e.Model, e.ClientID, e.ProjectID, e.SessionID, e.Operation,
+			e.Requests, e.InputTokens, e.OutputTokens, e.Cost, e.Estimated,
+		})
+		key := e.Provider + "|" + e.Model
+		agg, ok := byProvider[key]
+		if !ok {
+			agg = &database.AIUsageLedgerEntry{Provider: e.Provider, Model: e.Model}
+			byProvider[key] = agg
+		}
+		agg.Requests += e.Requests
+		agg.InputTokens += e.InputTokens
+		agg.OutputTokens += e.OutputTokens
+		agg.Cost += e.Cost
+	}
+	if err := writeSheet("Usage", []string{
+		"Day", "Provider", "Model", "Client ID", "Project ID", "Session", "Operation",
+		"Requests", "Input Tokens", "Output Tokens", "Cost", "Estimated Requests",
+	}, usageRows); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист Usage", err)
+	}
+
+	// Сводка по провайдерам и моделям
+	keys := make([]string, 0, len(byProvider))
+	for key := range byProvider {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	summaryRows := make([][]interface{}, 0, len(keys))
+	for _, key := range keys {
+		agg := byProvider[key]
+		summaryRows = append(summaryRows, []interface{}{
+			agg.Provider, agg.Model, agg.Requests, agg.InputTokens, agg.OutputTokens, agg.Cost,
+		})
+	}
+	if err := writeSheet("By Provider", []string{
+		"Provider", "Model", "Requests", "Input Tokens", "Output Tokens", "Cost",
+	}, summaryRows); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист By Provider", err)
+	}
+
+	// Бюджеты
+	budgetRows := make([][]interface{}, 0, len(statuses))
+	for _, st := range statuses {
+		budgetRows = append(budgetRows, []interface{}{
+			st.Budget.Scope, st.Budget.ScopeID, st.Budget.Period, st.Source, st.PeriodStart, st.PeriodEnd,
+			st.Budget.SoftLimit, st.Budget.HardLimit, st.Spent, st.Budget.Currency, st.SoftExceeded, st.HardExceeded,
+		})
+	}
+	if err := writeSheet("Budgets", []string{
+		"Scope", "Scope ID", "Period", "Source", "Period Start", "Period End",
+		"Soft Limit", "Hard Limit", "Spent", "Currency", "Soft Exceeded", "Hard Exceeded",
+	}, budgetRows); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист Budgets", err)
+	}
+
+	f.DeleteSheet("Sheet1")
+	if index, err := f.GetSheetIndex("Usage"); err == nil {
+		f.SetActiveSheet(index)
+	}
+
+	if err := f.Write(w); err != nil {
+		return apperrors.NewInternalError("не удалось записать Excel файл", err)
+	}
+	return nil
+}
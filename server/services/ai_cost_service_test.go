@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"httpserver/database"
+	"httpserver/internal/config"
+	"httpserver/nomenclature"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestAICostService_RecordUsage проверяет расчет стоимости по таблице цен и агрегацию журнала
+func TestAICostService_RecordUsage(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	service := NewAICostService(serviceDB, nil)
+	if err := service.SavePrice(&database.AIPrice{Provider: "Eden AI", Model: "*", PerRequest: 0.01}); err != nil {
+		t.Fatalf("SavePrice() error = %v", err)
+	}
+	if err := service.SavePrice(&database.AIPrice{Provider: "openrouter", Model: "m1", InputPer1KTokens: 1, OutputPer1KTokens: 2}); err != nil {
+		t.Fatalf("SavePrice() error = %v", err)
+	}
+
+	tags := nomenclature.UsageTags{ClientID: 1, ProjectID: 2, SessionID: "s1", Operation: "normalization"}
+	cost := service.RecordAIUsage(nomenclature.UsageRecord{Provider: "openrouter", Model: "m1", InputTokens: 500, OutputTokens: 250, Tags: tags})
+	if !almostEqual(cost, 1.0) {
+		t.Errorf("openrouter cost = %v, want 1.0", cost)
+	}
+	service.RecordAIUsage(nomenclature.UsageRecord{Provider: "openrouter", Model: "m1", InputTokens: 500, OutputTokens: 250, Tags: tags})
+	if cost := service.RecordAIUsage(nomenclature.UsageRecord{Provider: "edenai", Model: "any", Tags: tags}); !almostEqual(cost, 0.01) {
+		t.Errorf("edenai per-request cost = %v, want 0.01", cost)
+	}
+	if cost := service.RecordAIUsage(nomenclature.UsageRecord{Provider: "huggingface", Model: "x", InputTokens: 100}); cost != 0 {
+		t.Errorf("unpriced cost = %v, want 0", cost)
+	}
+
+	byClient, err := service.GetUsage(database.AIUsageFilter{ClientID: 1, GroupBy: []string{"client", "provider"}})
+	if err != nil {
+		t.Fatalf("GetUsage() error = %v", err)
+	}
+	if len(byClient) != 2 {
+		t.Fatalf("GetUsage() returned %d rows, want 2", len(byClient))
+	}
+	for _, e := range byClient {
+		if e.Provider == "openrouter" && (e.Requests != 2 || e.InputTokens != 1000 || !almostEqual(e.Cost, 2.0)) {
+			t.Errorf("openrouter row = %+v, want 2 requests, 1000 input tokens, cost 2", e)
+		}
+	}
+
+	if _, err := service.GetUsage(database.AIUsageFilter{GroupBy: []string{"bogus"}}); err == nil {
+		t.Error("GetUsage() with unknown group_by should fail")
+	}
+}
+
+// TestAICostService_Budgets проверяет мягкий и жесткий бюджеты и блокировку вызовов
+func TestAICostService_Budgets(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	service := NewAICostService(serviceDB, nil)
+	if err := service.SavePrice(&database.AIPrice{Provider: "arliai", PerRequest: 1}); err != nil {
+		t.Fatalf("SavePrice() error = %v", err)
+	}
+	if err := service.SaveBudget(&database.AIBudget{Scope: AIBudgetScopeProject, ScopeID: 7, Period: AIBudgetPeriodDaily, SoftLimit: 2, HardLimit: 3, Enabled: true}); err != nil {
+		t.Fatalf("SaveBudget() error = %v", err)
+	}
+	if err := service.SaveBudget(&database.AIBudget{Scope: AIBudgetScopeProject, Period: AIBudgetPeriodDaily, HardLimit: 1}); err == nil {
+		t.Error("SaveBudget() without scope_id should fail")
+	}
+	if err := service.SaveBudget(&database.AIBudget{Scope: AIBudgetScopeGlobal, Period: AIBudgetPeriodDaily, SoftLimit: 5, HardLimit: 1}); err == nil {
+		t.Error("SaveBudget() with soft > hard should fail")
+	}
+
+	var softCalls, hardCalls int
+	service.SetLimitHandlers(func(*AIBudgetStatus) { softCalls++ }, func(*AIBudgetStatus) { hardCalls++ })
+
+	tags := nomenclature.UsageTags{ProjectID: 7}
+	for i := 0; i < 2; i++ {
+		if err := service.AllowAIRequest(tags); err != nil {
+			t.Fatalf("AllowAIRequest() before limit error = %v", err)
+		}
+		service.RecordAIUsage(nomenclature.UsageRecord{Provider: "arliai", Model: "m", Tags: tags})
+	}
+	if softCalls != 1 || hardCalls != 0 {
+		t.Errorf("after soft limit: soft=%d hard=%d, want 1/0", softCalls, hardCalls)
+	}
+
+	service.RecordAIUsage(nomenclature.UsageRecord{Provider: "arliai", Model: "m", Tags: tags})
+	service.RecordAIUsage(nomenclature.UsageRecord{Provider: "arliai", Model: "m", Tags: tags})
+	if hardCalls != 1 {
+		t.Errorf("hard limit handler called %d times, want 1", hardCalls)
+	}
+	if err := service.AllowAIRequest(tags); !errors.Is(err, nomenclature.ErrAIBudgetExceeded) {
+		t.Errorf("AllowAIRequest() after hard limit = %v, want ErrAIBudgetExceeded", err)
+	}
+	if err := service.AllowAIRequest(nomenclature.UsageTags{ProjectID: 8}); err != nil {
+		t.Errorf("other project should not be blocked: %v", err)
+	}
+
+	// Новый сервис читает затраты из журнала
+	fresh := NewAICostService(serviceDB, nil)
+	if !fresh.IsBlocked(tags) {
+		t.Error("budget state should be restored from the ledger")
+	}
+}
+
+// TestAICostService_ClientConfigLimits проверяет лимиты из overlay клиента и экспорт в Excel
+func TestAICostService_ClientConfigLimits(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	service := NewAICostService(serviceDB, nil)
+	service.now = func() time.Time { return time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC) }
+	service.SetClientLimitsSource(func(clientID int) *config.ClientSpendLimits {
+		if clientID == 3 {
+			return &config.ClientSpendLimits{MonthlyLimit: 0.5, Currency: "USD"}
+		}
+		return nil
+	})
+	if err := service.SavePrice(&database.AIPrice{Provider: "arliai", PerRequest: 0.5}); err != nil {
+		t.Fatalf("SavePrice() error = %v", err)
+	}
+
+	tags := nomenclature.UsageTags{ClientID: 3}
+	service.RecordAIUsage(nomenclature.UsageRecord{Provider: "arliai", Tags: tags, Timestamp: service.now()})
+	if !service.IsBlocked(tags) {
+		t.Error("client monthly limit from overlay should block AI calls")
+	}
+
+	var buf bytes.Buffer
+	if err := service.ExportExcel(&buf, database.AIUsageFilter{}); err != nil {
+		t.Fatalf("ExportExcel() error = %v", err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("failed to open exported file: %v", err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Usage")
+	if err != nil {
+		t.Fatalf("GetRows() error = %v", err)
+	}
+	if len(rows) != 2 || rows[1][1] != "arliai" {
+		t.Errorf("Usage sheet rows = %v, want header and one arliai row", rows)
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"time"
+
+	"httpserver/database"
+	"httpserver/nomenclature"
+)
+
+// AIQuotaService хранит корзины квот AI провайдеров в сервисной БД, чтобы несколько
+// процессов с одним ключом API делили общий бюджет запросов.
+// Реализует nomenclature.QuotaStore и подключается через nomenclature.SetQuotaStore.
+type AIQuotaService struct {
+	serviceDB *database.ServiceDB
+}
+
+// NewAIQuotaService создает хранилище квот поверх сервисной БД
+func NewAIQuotaService(serviceDB *database.ServiceDB) *AIQuotaService {
+	return &AIQuotaService{serviceDB: serviceDB}
+}
+
+// EnsureQuota создает корзину в БД, если ее нет
+func (s *AIQuotaService) EnsureQuota(state nomenclature.QuotaState) error {
+	return s.serviceDB.EnsureAIProviderQuota(&database.AIProviderQuota{
+		Key:            state.Key,
+		Provider:       state.Provider,
+		Model:          state.Model,
+		KeyFingerprint: state.KeyFingerprint,
+		Rate:           state.Rate,
+		MinRate:        state.MinRate,
+		Ceiling:        state.Ceiling,
+		Burst:          state.Burst,
+		Tokens:         state.Tokens,
+		LastRefill:     state.LastRefill,
+		UpdatedAt:      state.UpdatedAt,
+	})
+}
+
+// AcquireQuota забирает токен из общей корзины
+func (s *AIQuotaService) AcquireQuota(key string, now time.Time) (time.Duration, error) {
+	return s.serviceDB.AcquireAIProviderQuota(key, now)
+}
+
+// AdjustQuota применяет изменение скорости к общей корзине
+func (s *AIQuotaService) AdjustQuota(key string, adj nomenclature.QuotaAdjustment, now time.Time) error {
+	return s.serviceDB.AdjustAIProviderQuota(key, database.AIProviderQuotaAdjustment{
+		RateFactor:   adj.RateFactor,
+		RateStep:     adj.RateStep,
+		Ceiling:      adj.Ceiling,
+		BlockedUntil: adj.BlockedUntil,
+		Throttled:    adj.Throttled,
+	}, now)
+}
+
+// QuotaStates возвращает состояние всех корзин из БД
+func (s *AIQuotaService) QuotaStates() ([]nomenclature.QuotaState, error) {
+	quotas, err := s.serviceDB.GetAIProviderQuotas()
+	if err != nil {
+		return nil, err
+	}
+	states := make([]nomenclature.QuotaState, 0, len(quotas))
+	for _, quota := range quotas {
+		states = append(states, nomenclature.QuotaState{
+			Key:             quota.Key,
+			Provider:        quota.Provider,
+			Model:           quota.Model,
+			KeyFingerprint:  quota.KeyFingerprint,
+			Rate:            quota.Rate,
+			MinRate:         quota.MinRate,
+			Ceiling:         quota.Ceiling,
+			Burst:           quota.Burst,
+			Tokens:          quota.Tokens,
+			LastRefill:      quota.LastRefill,
+			BlockedUntil:    quota.BlockedUntil,
+			ThrottledCount:  quota.ThrottledCount,
+			LastThrottledAt: quota.LastThrottledAt,
+			UpdatedAt:       quota.UpdatedAt,
+		})
+	}
+	return states, nil
+}
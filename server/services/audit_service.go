@@ -0,0 +1,449 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"httpserver/database"
+	apperrors "httpserver/server/errors"
+)
+
+// DefaultAuditRetentionDays срок хранения журнала аудита по умолчанию
+const DefaultAuditRetentionDays = 365
+
+// AuditRequest изменяющий запрос, передаваемый сервису аудита из middleware
+type AuditRequest struct {
+	Method    string
+	Path      string
+	Actor     string
+	APIKey    string
+	Role      string
+	RequestID string
+	ClientIP  string
+	Reason    string
+	Body      []byte
+}
+
+// AuditSnapshotInput данные запроса для снимка состояния сущности
+type AuditSnapshotInput struct {
+	Params map[string]string      // параметры пути, например {id}
+	Body   map[string]interface{} // JSON тело запроса
+}
+
+// AuditSnapshotFunc возвращает идентификатор и текущее состояние сущности, затрагиваемой запросом.
+// Вызывается до обработки запроса и после нее для построения диффа.
+type AuditSnapshotFunc func(in *AuditSnapshotInput) (entityID string, state interface{}, err error)
+
+// auditRule правило аудита конкретного эндпоинта
+type auditRule struct {
+	method     string
+	pattern    *regexp.Regexp
+	names      []string
+	entityType string
+	action     string
+	snapshot   AuditSnapshotFunc
+}
+
+// AuditFieldChange изменение одного поля сущности
+type AuditFieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditService журнал аудита изменяющих операций API
+type AuditService struct {
+	serviceDB        *database.ServiceDB
+	defaultRetention int
+
+	mu    sync.RWMutex
+	rules []*auditRule
+}
+
+// NewAuditService создает сервис аудита
+func NewAuditService(serviceDB *database.ServiceDB, defaultRetentionDays int) *AuditService {
+	if defaultRetentionDays <= 0 {
+		defaultRetentionDays = DefaultAuditRetentionDays
+	}
+	return &AuditService{
+		serviceDB:        serviceDB,
+		defaultRetention: defaultRetentionDays,
+	}
+}
+
+var auditParamPattern = regexp.MustCompile(`\{([a-zA-Z]+)\}`)
+
+// Register регистрирует правило аудита для эндпоинта. Шаблон пути использует
+// параметры в фигурных скобках: /api/counterparties/normalized/{id}.
+// snapshot может быть nil — тогда сохраняется только тело запроса.
+func (s *AuditService) Register(method, pathPattern, entityType, action string, snapshot AuditSnapshotFunc) {
+	var names []string
+	expr := "^"
+	last := 0
+	for _, m := range auditParamPattern.FindAllStringSubmatchIndex(pathPattern, -1) {
+		expr += regexp.QuoteMeta(pathPattern[last:m[0]]) + `([^/]+)`
+		names = append(names, pathPattern[m[2]:m[3]])
+		last = m[1]
+	}
+	expr += regexp.QuoteMeta(pathPattern[last:]) + "/?$"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, &auditRule{
+		method:     method,
+		pattern:    regexp.MustCompile(expr),
+		names:      names,
+		entityType: entityType,
+		action:     action,
+		snapshot:   snapshot,
+	})
+}
+
+// matchRule находит правило для запроса
+func (s *AuditService) matchRule(method, path string) (*auditRule, map[string]string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, rule := range s.rules {
+		if rule.method != "" && rule.method != method {
+			continue
+		}
+		m := rule.pattern.FindStringSubmatch(path)
+		if m == nil {
+			continue
+		}
+		params := make(map[string]string, len(rule.names))
+		for i, name := range rule.names {
+			params[name] = m[i+1]
+		}
+		return rule, params
+	}
+	return nil, nil
+}
+
+// Begin снимает состояние сущности до обработки запроса и возвращает функцию,
+// которая после ответа дописывает запись в журнал. Ошибки аудита только логируются,
+// чтобы не блокировать работу API.
+func (s *AuditService) Begin(req *AuditRequest) func(statusCode int) {
+	if s == nil || s.serviceDB == nil || req == nil {
+		return nil
+	}
+
+	var body map[string]interface{}
+	if len(req.Body) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(req.Body))
+		decoder.UseNumber()
+		_ = decoder.Decode(&body)
+	}
+
+	entry := &database.AuditLogEntry{
+		Actor:     req.Actor,
+		APIKey:    req.APIKey,
+		Role:      req.Role,
+		RequestID: req.RequestID,
+		ClientIP:  req.ClientIP,
+		Method:    req.Method,
+		Path:      req.Path,
+		Reason:    req.Reason,
+	}
+	if entry.Reason == "" {
+		if reason, ok := body["reason"].(string); ok {
+			entry.Reason = reason
+		}
+	}
+	if entry.Actor == "" {
+		for _, key := range []string{"user", "updated_by", "created_by", "changed_by"} {
+			if actor, ok := body[key].(string); ok && actor != "" {
+				entry.Actor = actor
+				break
+			}
+		}
+	}
+
+	rule, params := s.matchRule(req.Method, req.Path)
+	var input *AuditSnapshotInput
+	var before interface{}
+	if rule != nil {
+		entry.EntityType = rule.entityType
+		entry.Action = rule.action
+		entry.EntityID = params["id"]
+		if rule.snapshot != nil {
+			input = &AuditSnapshotInput{Params: params, Body: body}
+			id, state, err := rule.snapshot(input)
+			if err != nil {
+				log.Printf("[Audit] before snapshot failed for %s %s: %v", req.Method, req.Path, err)
+			}
+			if id != "" {
+				entry.EntityID = id
+			}
+			before = state
+		}
+	} else {
+		entry.EntityType, entry.EntityID = auditEntityFromPath(req.Path)
+		entry.Action = strings.ToLower(req.Method)
+	}
+	if before == nil && body != nil && rule == nil {
+		// Без снимка сохраняем тело запроса как описание изменения
+		entry.After = marshalAuditState(redactAuditState(body))
+	}
+
+	return func(statusCode int) {
+		entry.StatusCode = statusCode
+		entry.CreatedAt = time.Now()
+		if rule != nil && rule.snapshot != nil {
+			entry.Before = marshalAuditState(before)
+			if statusCode < 400 {
+				_, after, err := rule.snapshot(input)
+				if err != nil {
+					log.Printf("[Audit] after snapshot failed for %s %s: %v", req.Method, req.Path, err)
+				}
+				entry.After = marshalAuditState(after)
+				if diff := ComputeAuditDiff(before, after); len(diff) > 0 {
+					entry.Diff = marshalAuditState(diff)
+				}
+			}
+		} else if rule != nil && body != nil {
+			entry.After = marshalAuditState(redactAuditState(body))
+		}
+		if err := s.serviceDB.AppendAuditLogEntry(entry); err != nil {
+			log.Printf("[Audit] failed to record %s %s: %v", req.Method, req.Path, err)
+		}
+	}
+}
+
+// auditEntityFromPath определяет тип и идентификатор сущности по пути запроса
+// для эндпоинтов без зарегистрированного правила
+func auditEntityFromPath(path string) (string, string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 0 && parts[0] == "api" {
+		parts = parts[1:]
+	}
+	entityType, entityID := "", ""
+	for _, part := range parts {
+		if _, err := strconv.Atoi(part); err == nil {
+			entityID = part
+			continue
+		}
+		if entityType == "" || entityID != "" {
+			if entityType != "" {
+				entityType += "."
+			}
+			entityType += part
+			entityID = ""
+		}
+	}
+	return entityType, entityID
+}
+
+// auditSecretKeys ключи, значения которых не попадают в журнал
+var auditSecretKeys = []string{"api_key", "apikey", "password", "secret", "token"}
+
+// redactAuditState маскирует секреты во вложенных JSON структурах
+func redactAuditState(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, val := range t {
+			lower := strings.ToLower(k)
+			secret := false
+			for _, key := range auditSecretKeys {
+				if strings.Contains(lower, key) {
+					secret = true
+					break
+				}
+			}
+			if secret {
+				if s, ok := val.(string); ok && s == "" {
+					result[k] = ""
+				} else {
+					result[k] = "***"
+				}
+				continue
+			}
+			result[k] = redactAuditState(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(t))
+		for i, val := range t {
+			result[i] = redactAuditState(val)
+		}
+		return result
+	}
+	return v
+}
+
+// marshalAuditState сериализует состояние для журнала
+func marshalAuditState(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+	return string(data)
+}
+
+// toAuditMap приводит состояние к плоскому виду "путь поля" -> значение
+func toAuditMap(v interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	if v == nil {
+		return result
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return result
+	}
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return result
+	}
+	flattenAuditValue("", redactAuditState(generic), result)
+	return result
+}
+
+func flattenAuditValue(prefix string, v interface{}, out map[string]interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		for k, val := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flattenAuditValue(key, val, out)
+		}
+		return
+	}
+	if prefix == "" {
+		prefix = "value"
+	}
+	out[prefix] = v
+}
+
+// ComputeAuditDiff возвращает поля, значения которых отличаются в состояниях до и после.
+// Вложенные объекты сравниваются по полям, массивы — целиком.
+func ComputeAuditDiff(before, after interface{}) map[string]AuditFieldChange {
+	b, a := toAuditMap(before), toAuditMap(after)
+	diff := make(map[string]AuditFieldChange)
+	for key, bv := range b {
+		av, ok := a[key]
+		if !ok || !reflect.DeepEqual(bv, av) {
+			diff[key] = AuditFieldChange{Before: bv, After: av}
+		}
+	}
+	for key, av := range a {
+		if _, ok := b[key]; !ok {
+			diff[key] = AuditFieldChange{Before: nil, After: av}
+		}
+	}
+	return diff
+}
+
+// Query возвращает записи журнала по фильтру
+func (s *AuditService) Query(filter database.AuditLogFilter) ([]*database.AuditLogEntry, int, error) {
+	if filter.Limit <= 0 || filter.Limit > 1000 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	entries, total, err := s.serviceDB.QueryAuditLog(filter)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalError("не удалось получить журнал аудита", err)
+	}
+	return entries, total, nil
+}
+
+// Export выгружает записи журнала по фильтру в формате json или csv
+func (s *AuditService) Export(filter database.AuditLogFilter, format string, w io.Writer) error {
+	filter.Limit, filter.Offset = 0, 0
+	entries, _, err := s.serviceDB.QueryAuditLog(filter)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось выгрузить журнал аудита", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	switch format {
+	case "", "json":
+		if entries == nil {
+			entries = []*database.AuditLogEntry{}
+		}
+		return json.NewEncoder(w).Encode(entries)
+	case "csv":
+		writer := csv.NewWriter(w)
+		header := []string{"id", "created_at", "actor", "api_key", "role", "request_id", "client_ip", "method", "path",
+			"status_code", "action", "entity_type", "entity_id", "before", "after", "diff", "reason", "prev_hash", "hash"}
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := writer.Write([]string{
+				strconv.FormatInt(e.ID, 10), e.CreatedAt.Format(time.RFC3339Nano), e.Actor, e.APIKey, e.Role,
+				e.RequestID, e.ClientIP, e.Method, e.Path, strconv.Itoa(e.StatusCode), e.Action, e.EntityType,
+				e.EntityID, e.Before, e.After, e.Diff, e.Reason, e.PrevHash, e.Hash,
+			}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return apperrors.NewValidationError(fmt.Sprintf("неподдерживаемый формат выгрузки: %s", format), nil)
+	}
+}
+
+// Verify проверяет целостность цепочки хешей журнала
+func (s *AuditService) Verify() (*database.AuditChainVerification, error) {
+	result, err := s.serviceDB.VerifyAuditLogChain()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось проверить журнал аудита", err)
+	}
+	return result, nil
+}
+
+// GetRetentionDays возвращает срок хранения журнала в днях
+func (s *AuditService) GetRetentionDays() (int, error) {
+	days, err := s.serviceDB.GetAuditRetentionDays()
+	if err != nil {
+		return 0, apperrors.NewInternalError("не удалось получить срок хранения журнала аудита", err)
+	}
+	if days <= 0 {
+		days = s.defaultRetention
+	}
+	return days, nil
+}
+
+// SetRetentionDays изменяет срок хранения журнала
+func (s *AuditService) SetRetentionDays(days int, updatedBy string) error {
+	if days < 1 {
+		return apperrors.NewValidationError("срок хранения должен быть не меньше 1 дня", nil)
+	}
+	if err := s.serviceDB.SetAuditRetentionDays(days, updatedBy); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить срок хранения журнала аудита", err)
+	}
+	return nil
+}
+
+// ApplyRetention удаляет записи старше срока хранения
+func (s *AuditService) ApplyRetention() (int64, error) {
+	days, err := s.GetRetentionDays()
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := s.serviceDB.DeleteAuditLogBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return 0, apperrors.NewInternalError("не удалось очистить журнал аудита", err)
+	}
+	return deleted, nil
+}
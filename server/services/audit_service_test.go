@@ -0,0 +1,160 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"httpserver/database"
+)
+
+// TestAuditService_BeginRecordsDiffAndChain проверяет дифф «до/после», маскирование секретов
+// и обнаружение подмены записи по цепочке хешей
+func TestAuditService_BeginRecordsDiffAndChain(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	service := NewAuditService(serviceDB, 0)
+
+	state := map[string]interface{}{"name": "ООО Ромашка", "inn": "7701234567"}
+	service.Register("PUT", "/api/items/{id}", "item", "update", func(in *AuditSnapshotInput) (string, interface{}, error) {
+		copied := make(map[string]interface{}, len(state))
+		for k, v := range state {
+			copied[k] = v
+		}
+		return in.Params["id"], copied, nil
+	})
+
+	finish := service.Begin(&AuditRequest{
+		Method:    "PUT",
+		Path:      "/api/items/15",
+		Actor:     "ivanov",
+		RequestID: "req-1",
+		Body:      []byte(`{"name":"ООО Ромашка+","reason":"опечатка"}`),
+	})
+	state["name"] = "ООО Ромашка+"
+	finish(200)
+
+	service.Begin(&AuditRequest{
+		Method: "POST",
+		Path:   "/api/config",
+		Body:   []byte(`{"arliai_api_key":"secret-value","port":"9999"}`),
+	})(200)
+
+	entries, total, err := service.Query(database.AuditLogFilter{EntityType: "item"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if total != 1 || len(entries) != 1 {
+		t.Fatalf("expected 1 item entry, got %d", total)
+	}
+	entry := entries[0]
+	if entry.EntityID != "15" || entry.Actor != "ivanov" || entry.Reason != "опечатка" || entry.RequestID != "req-1" {
+		t.Errorf("unexpected entry metadata: %+v", entry)
+	}
+	if entry.Diff != `{"name":{"before":"ООО Ромашка","after":"ООО Ромашка+"}}` {
+		t.Errorf("unexpected diff: %s", entry.Diff)
+	}
+
+	all, _, err := service.Query(database.AuditLogFilter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(all) != 2 || all[0].After != `{"arliai_api_key":"***","port":"9999"}` {
+		t.Fatalf("expected redacted config entry, got %+v", all[0])
+	}
+	if all[0].PrevHash != all[1].Hash {
+		t.Errorf("entries are not chained: %s != %s", all[0].PrevHash, all[1].Hash)
+	}
+
+	result, err := service.Verify()
+	if err != nil || !result.Valid || result.Checked != 2 {
+		t.Fatalf("Verify() = %+v, %v; want valid chain of 2", result, err)
+	}
+
+	if _, err := serviceDB.Exec(`UPDATE audit_log SET actor = 'petrov' WHERE id = ?`, entry.ID); err == nil {
+		t.Fatal("expected update of audit log to be rejected")
+	}
+	if _, err := serviceDB.Exec(`DROP TRIGGER audit_log_no_update`); err != nil {
+		t.Fatalf("drop trigger: %v", err)
+	}
+	if _, err := serviceDB.Exec(`UPDATE audit_log SET actor = 'petrov' WHERE id = ?`, entry.ID); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	result, err = service.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Valid || result.BrokenAt != entry.ID {
+		t.Errorf("expected chain broken at %d, got %+v", entry.ID, result)
+	}
+}
+
+// TestComputeAuditDiff проверяет сравнение вложенных состояний
+func TestComputeAuditDiff(t *testing.T) {
+	before := map[string]interface{}{"a": 1, "nested": map[string]interface{}{"b": "x", "c": "y"}}
+	after := map[string]interface{}{"a": 1, "nested": map[string]interface{}{"b": "z"}, "d": true}
+
+	diff := ComputeAuditDiff(before, after)
+	if len(diff) != 3 {
+		t.Fatalf("expected 3 changed fields, got %v", diff)
+	}
+	if diff["nested.b"].After != "z" || diff["nested.c"].After != nil || diff["d"].Before != nil {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+	if _, ok := diff["a"]; ok {
+		t.Error("unchanged field must not be in diff")
+	}
+}
+
+// TestAuditService_RetentionCheckpoint проверяет, что очистка по сроку хранения сохраняет
+// отметку очистки, а удаление записей в обход очистки обнаруживается проверкой цепочки
+func TestAuditService_RetentionCheckpoint(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	service := NewAuditService(serviceDB, 30)
+
+	now := time.Now()
+	for i, age := range []int{90, 60, 10, 5, 1} {
+		entry := &database.AuditLogEntry{
+			CreatedAt: now.AddDate(0, 0, -age),
+			Method:    "POST",
+			Path:      "/api/items",
+			Action:    "create",
+			EntityID:  strconv.Itoa(i),
+		}
+		if err := serviceDB.AppendAuditLogEntry(entry); err != nil {
+			t.Fatalf("AppendAuditLogEntry() error = %v", err)
+		}
+	}
+
+	deleted, err := service.ApplyRetention()
+	if err != nil || deleted != 2 {
+		t.Fatalf("ApplyRetention() = %d, %v; want 2", deleted, err)
+	}
+	result, err := service.Verify()
+	if err != nil || !result.Valid || result.Checked != 3 {
+		t.Fatalf("Verify() after retention = %+v, %v", result, err)
+	}
+	if result.Checkpoint == nil || result.Checkpoint.PrunedCount != 2 || result.Checkpoint.TotalPruned != 2 {
+		t.Fatalf("unexpected checkpoint: %+v", result.Checkpoint)
+	}
+
+	if _, err := serviceDB.Exec(`DELETE FROM audit_checkpoints`); err == nil {
+		t.Fatal("expected deletion of audit checkpoints to be rejected")
+	}
+
+	// Удаление первой оставшейся записи в обход очистки
+	entries, _, err := service.Query(database.AuditLogFilter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	first := entries[len(entries)-1]
+	if _, err := serviceDB.Exec(`DELETE FROM audit_log WHERE id = ?`, first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	result, err = service.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Valid || result.BrokenAt != first.ID+1 {
+		t.Errorf("expected chain broken at %d, got %+v", first.ID+1, result)
+	}
+}
@@ -0,0 +1,815 @@
+package services
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"httpserver/database"
+	apperrors "httpserver/server/errors"
+
+	"github.com/google/uuid"
+)
+
+// BenchmarkBundleFormatVersion текущая версия формата пакета эталонов
+const BenchmarkBundleFormatVersion = 1
+
+// Стратегии разрешения совпадений при импорте пакета эталонов
+const (
+	BundleStrategySkip      = "skip"      // оставить локальную версию
+	BundleStrategyOverwrite = "overwrite" // заменить локальную версию входящей
+	BundleStrategyMerge     = "merge"     // дополнить пустые поля, локальные значения приоритетны
+	BundleStrategyNewer     = "newer"     // оставить версию с более поздней датой изменения
+)
+
+// BenchmarkBundle подписанный пакет эталонов для переноса между инсталляциями.
+// Подпись Ed25519 вычисляется над компактным JSON поля payload.
+type BenchmarkBundle struct {
+	FormatVersion int                      `json:"format_version"`
+	Payload       json.RawMessage          `json:"payload"`
+	Signature     BenchmarkBundleSignature `json:"signature"`
+}
+
+// BenchmarkBundleSignature подпись пакета эталонов
+type BenchmarkBundleSignature struct {
+	Algorithm string `json:"algorithm"`
+	KeyID     string `json:"key_id"`
+	PublicKey string `json:"public_key"` // base64
+	Value     string `json:"value"`      // base64
+}
+
+// BenchmarkBundlePayload содержимое пакета эталонов
+type BenchmarkBundlePayload struct {
+	BundleID         string                   `json:"bundle_id"`
+	SourceInstance   string                   `json:"source_instance"`
+	CreatedAt        time.Time                `json:"created_at"`
+	CreatedBy        string                   `json:"created_by,omitempty"`
+	EntityType       string                   `json:"entity_type,omitempty"`
+	SourceProjectID  int                      `json:"source_project_id,omitempty"`
+	Benchmarks       []BundledBenchmark       `json:"benchmarks"`
+	ClientBenchmarks []BundledClientBenchmark `json:"client_benchmarks,omitempty"`
+}
+
+// BundledBenchmark эталон в пакете вместе с кодами классификаторов и происхождением
+type BundledBenchmark struct {
+	database.Benchmark
+	KPVEDCode  string                        `json:"kpved_code,omitempty"`
+	OKPD2Code  string                        `json:"okpd2_code,omitempty"`
+	Provenance *database.BenchmarkProvenance `json:"provenance,omitempty"`
+}
+
+// BundledClientBenchmark эталон клиента в пакете; ссылка на ОКПД2 передается кодом,
+// так как идентификаторы справочника различаются между инсталляциями
+type BundledClientBenchmark struct {
+	database.ClientBenchmark
+	OKPD2Code string `json:"okpd2_code,omitempty"`
+	OKPD2Name string `json:"okpd2_name,omitempty"`
+}
+
+// BenchmarkBundleExportOptions параметры экспорта пакета эталонов
+type BenchmarkBundleExportOptions struct {
+	EntityType string
+	ActiveOnly bool
+	ProjectID  int // если задан, в пакет включаются эталоны клиента этого проекта
+	ExportedBy string
+}
+
+// BenchmarkBundleImportOptions параметры импорта пакета эталонов
+type BenchmarkBundleImportOptions struct {
+	Strategy       string
+	DryRun         bool
+	ProjectID      int // проект, в который импортируются эталоны клиента
+	AllowUntrusted bool
+	ImportedBy     string
+}
+
+// BenchmarkBundleConflict совпадение входящего эталона с локальным при различающихся данных
+type BenchmarkBundleConflict struct {
+	Kind       string   `json:"kind"` // benchmark | client_benchmark
+	IncomingID string   `json:"incoming_id"`
+	LocalID    string   `json:"local_id"`
+	Name       string   `json:"name"`
+	MatchedBy  string   `json:"matched_by"` // id | inn | name
+	Fields     []string `json:"fields"`
+	Resolution string   `json:"resolution"` // kept_local | overwritten | merged
+}
+
+// BenchmarkBundleImportReport отчет об импорте (или пробном импорте) пакета эталонов
+type BenchmarkBundleImportReport struct {
+	BundleID       string                    `json:"bundle_id"`
+	SourceInstance string                    `json:"source_instance"`
+	SignerKeyID    string                    `json:"signer_key_id"`
+	Trusted        bool                      `json:"trusted"`
+	Strategy       string                    `json:"strategy"`
+	DryRun         bool                      `json:"dry_run"`
+	Total          int                       `json:"total"`
+	Created        int                       `json:"created"`
+	Updated        int                       `json:"updated"`
+	Unchanged      int                       `json:"unchanged"`
+	Skipped        int                       `json:"skipped"`
+	Conflicts      []BenchmarkBundleConflict `json:"conflicts"`
+	Warnings       []string                  `json:"warnings,omitempty"`
+}
+
+// BenchmarkBundleService экспорт и импорт пакетов эталонов между инсталляциями сервера
+type BenchmarkBundleService struct {
+	benchmarksDB *database.BenchmarksDB
+	serviceDB    *database.ServiceDB
+	instanceName string
+}
+
+// NewBenchmarkBundleService создает сервис пакетов эталонов.
+// instanceName попадает в пакет как источник; по умолчанию используется имя хоста.
+func NewBenchmarkBundleService(benchmarksDB *database.BenchmarksDB, serviceDB *database.ServiceDB, instanceName string) *BenchmarkBundleService {
+	if instanceName == "" {
+		instanceName, _ = os.Hostname()
+	}
+	if instanceName == "" {
+		instanceName = "unknown"
+	}
+	return &BenchmarkBundleService{
+		benchmarksDB: benchmarksDB,
+		serviceDB:    serviceDB,
+		instanceName: instanceName,
+	}
+}
+
+// GetPublicKey возвращает идентификатор и открытый ключ подписи этой инсталляции
+func (s *BenchmarkBundleService) GetPublicKey() (string, string, error) {
+	keyID, privateKey, err := s.serviceDB.GetOrCreateBenchmarkBundleSigningKey()
+	if err != nil {
+		return "", "", apperrors.NewInternalError("не удалось получить ключ подписи", err)
+	}
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	return keyID, base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// ListTrustedKeys возвращает доверенные ключи подписи
+func (s *BenchmarkBundleService) ListTrustedKeys() ([]*database.BenchmarkBundleTrustedKey, error) {
+	keys, err := s.serviceDB.GetBenchmarkBundleTrustedKeys()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить доверенные ключи", err)
+	}
+	if keys == nil {
+		keys = []*database.BenchmarkBundleTrustedKey{}
+	}
+	return keys, nil
+}
+
+// AddTrustedKey добавляет открытый ключ другой инсталляции в список доверенных
+func (s *BenchmarkBundleService) AddTrustedKey(name, publicKey, addedBy string) (*database.BenchmarkBundleTrustedKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, apperrors.NewValidationError("некорректный открытый ключ Ed25519", err)
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, apperrors.NewValidationError("не указано имя ключа", nil)
+	}
+	key := &database.BenchmarkBundleTrustedKey{
+		KeyID:     database.BundleKeyID(raw),
+		PublicKey: base64.StdEncoding.EncodeToString(raw),
+		Name:      strings.TrimSpace(name),
+		AddedBy:   addedBy,
+		CreatedAt: time.Now(),
+	}
+	if err := s.serviceDB.AddBenchmarkBundleTrustedKey(key); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить доверенный ключ", err)
+	}
+	return key, nil
+}
+
+// RemoveTrustedKey удаляет ключ из списка доверенных
+func (s *BenchmarkBundleService) RemoveTrustedKey(keyID string) error {
+	if err := s.serviceDB.DeleteBenchmarkBundleTrustedKey(keyID); err != nil {
+		return apperrors.NewNotFoundError("доверенный ключ не найден", err)
+	}
+	return nil
+}
+
+// Export формирует подписанный пакет эталонов
+func (s *BenchmarkBundleService) Export(opts BenchmarkBundleExportOptions) (*BenchmarkBundle, error) {
+	benchmarks, err := s.benchmarksDB.ListAllBenchmarks(opts.EntityType, opts.ActiveOnly)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить эталоны", err)
+	}
+	provenance, err := s.benchmarksDB.GetBenchmarkProvenance()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить происхождение эталонов", err)
+	}
+
+	payload := BenchmarkBundlePayload{
+		BundleID:        uuid.New().String(),
+		SourceInstance:  s.instanceName,
+		CreatedAt:       time.Now().UTC(),
+		CreatedBy:       opts.ExportedBy,
+		EntityType:      opts.EntityType,
+		SourceProjectID: opts.ProjectID,
+		Benchmarks:      make([]BundledBenchmark, 0, len(benchmarks)),
+	}
+	for _, b := range benchmarks {
+		payload.Benchmarks = append(payload.Benchmarks, BundledBenchmark{
+			Benchmark:  *b,
+			KPVEDCode:  benchmarkDataString(b.Data, "kpved_code", "kpved"),
+			OKPD2Code:  benchmarkDataString(b.Data, "okpd2_code", "okpd2"),
+			Provenance: provenance[b.ID],
+		})
+	}
+
+	if opts.ProjectID > 0 {
+		clientBenchmarks, err := s.serviceDB.GetClientBenchmarks(opts.ProjectID, "", false)
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось получить эталоны проекта", err)
+		}
+		var okpd2IDs []int
+		for _, cb := range clientBenchmarks {
+			if cb.OKPD2ReferenceID != nil {
+				okpd2IDs = append(okpd2IDs, *cb.OKPD2ReferenceID)
+			}
+		}
+		okpd2, err := s.serviceDB.GetOKPD2EntriesByIDs(okpd2IDs)
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось получить коды ОКПД2", err)
+		}
+		for _, cb := range clientBenchmarks {
+			item := BundledClientBenchmark{ClientBenchmark: *cb}
+			if cb.OKPD2ReferenceID != nil {
+				entry := okpd2[*cb.OKPD2ReferenceID]
+				item.OKPD2Code, item.OKPD2Name = entry.Code, entry.Name
+			}
+			// Локальные ссылки не имеют смысла в другой инсталляции
+			item.OKPD2ReferenceID = nil
+			item.TNVEDReferenceID = nil
+			item.TUGOSTReferenceID = nil
+			item.ManufacturerBenchmarkID = nil
+			payload.ClientBenchmarks = append(payload.ClientBenchmarks, item)
+		}
+	}
+
+	return s.sign(&payload)
+}
+
+// sign сериализует и подписывает содержимое пакета ключом инсталляции
+func (s *BenchmarkBundleService) sign(payload *BenchmarkBundlePayload) (*BenchmarkBundle, error) {
+	keyID, privateKey, err := s.serviceDB.GetOrCreateBenchmarkBundleSigningKey()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить ключ подписи", err)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сериализовать пакет эталонов", err)
+	}
+	return &BenchmarkBundle{
+		FormatVersion: BenchmarkBundleFormatVersion,
+		Payload:       raw,
+		Signature: BenchmarkBundleSignature{
+			Algorithm: "ed25519",
+			KeyID:     keyID,
+			PublicKey: base64.StdEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)),
+			Value:     base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, raw)),
+		},
+	}, nil
+}
+
+// Verify проверяет версию формата и подпись пакета; возвращает содержимое и признак доверенного ключа
+func (s *BenchmarkBundleService) Verify(bundle *BenchmarkBundle) (*BenchmarkBundlePayload, bool, error) {
+	if bundle == nil || len(bundle.Payload) == 0 {
+		return nil, false, apperrors.NewValidationError("пакет эталонов пуст", nil)
+	}
+	if bundle.FormatVersion < 1 || bundle.FormatVersion > BenchmarkBundleFormatVersion {
+		return nil, false, apperrors.NewValidationError(
+			fmt.Sprintf("неподдерживаемая версия формата пакета: %d", bundle.FormatVersion), nil)
+	}
+	if bundle.Signature.Algorithm != "ed25519" {
+		return nil, false, apperrors.NewValidationError("неподдерживаемый алгоритм подписи", nil)
+	}
+	publicKey, err := base64.StdEncoding.DecodeString(bundle.Signature.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return nil, false, apperrors.NewValidationError("некорректный открытый ключ подписи", err)
+	}
+	if database.BundleKeyID(publicKey) != bundle.Signature.KeyID {
+		return nil, false, apperrors.NewValidationError("идентификатор ключа не соответствует открытому ключу", nil)
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.Signature.Value)
+	if err != nil {
+		return nil, false, apperrors.NewValidationError("некорректная подпись пакета", err)
+	}
+
+	// Содержимое могло быть переформатировано при передаче - подпись сверяется с компактной формой
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, bundle.Payload); err != nil {
+		return nil, false, apperrors.NewValidationError("некорректный JSON содержимого пакета", err)
+	}
+	if !ed25519.Verify(publicKey, compact.Bytes(), signature) {
+		return nil, false, apperrors.NewValidationError("подпись пакета недействительна: содержимое изменено", nil)
+	}
+
+	var payload BenchmarkBundlePayload
+	if err := json.Unmarshal(compact.Bytes(), &payload); err != nil {
+		return nil, false, apperrors.NewValidationError("некорректное содержимое пакета", err)
+	}
+
+	trusted, err := s.isTrustedKey(bundle.Signature.KeyID, base64.StdEncoding.EncodeToString(publicKey))
+	if err != nil {
+		return nil, false, err
+	}
+	return &payload, trusted, nil
+}
+
+// isTrustedKey проверяет, подписан ли пакет собственным или доверенным ключом
+func (s *BenchmarkBundleService) isTrustedKey(keyID, publicKey string) (bool, error) {
+	ownKeyID, _, err := s.serviceDB.GetOrCreateBenchmarkBundleSigningKey()
+	if err != nil {
+		return false, apperrors.NewInternalError("не удалось получить ключ подписи", err)
+	}
+	if ownKeyID == keyID {
+		return true, nil
+	}
+	keys, err := s.serviceDB.GetBenchmarkBundleTrustedKeys()
+	if err != nil {
+		return false, apperrors.NewInternalError("не удалось получить доверенные ключи", err)
+	}
+	for _, key := range keys {
+		if key.KeyID == keyID && key.PublicKey == publicKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Import применяет пакет эталонов выбранной стратегией; при DryRun изменения не сохраняются
+func (s *BenchmarkBundleService) Import(bundle *BenchmarkBundle, opts BenchmarkBundleImportOptions) (*BenchmarkBundleImportReport, error) {
+	if opts.Strategy == "" {
+		opts.Strategy = BundleStrategySkip
+	}
+	switch opts.Strategy {
+	case BundleStrategySkip, BundleStrategyOverwrite, BundleStrategyMerge, BundleStrategyNewer:
+	default:
+		return nil, apperrors.NewValidationError(
+			fmt.Sprintf("неизвестная стратегия импорта: %s (skip, overwrite, merge, newer)", opts.Strategy), nil)
+	}
+
+	payload, trusted, err := s.Verify(bundle)
+	if err != nil {
+		return nil, err
+	}
+	if !trusted && !opts.AllowUntrusted {
+		return nil, apperrors.NewForbiddenError(
+			fmt.Sprintf("пакет подписан недоверенным ключом %s", bundle.Signature.KeyID), nil)
+	}
+
+	report := &BenchmarkBundleImportReport{
+		BundleID:       payload.BundleID,
+		SourceInstance: payload.SourceInstance,
+		SignerKeyID:    bundle.Signature.KeyID,
+		Trusted:        trusted,
+		Strategy:       opts.Strategy,
+		DryRun:         opts.DryRun,
+		Conflicts:      []BenchmarkBundleConflict{},
+	}
+
+	if err := s.importBenchmarks(payload, bundle.Signature.KeyID, opts, report); err != nil {
+		return nil, err
+	}
+
+	if len(payload.ClientBenchmarks) > 0 {
+		if opts.ProjectID > 0 {
+			if _, err := s.serviceDB.GetClientProject(opts.ProjectID); err != nil {
+				return nil, apperrors.NewNotFoundError("проект не найден", err)
+			}
+			if err := s.importClientBenchmarks(payload, opts, report); err != nil {
+				return nil, err
+			}
+		} else {
+			report.Warnings = append(report.Warnings, fmt.Sprintf(
+				"пакет содержит %d эталонов клиента, но проект для импорта не указан", len(payload.ClientBenchmarks)))
+		}
+	}
+
+	if !opts.DryRun {
+		record := &database.BenchmarkBundleImportRecord{
+			BundleID:       payload.BundleID,
+			OriginInstance: payload.SourceInstance,
+			SignerKeyID:    bundle.Signature.KeyID,
+			Strategy:       opts.Strategy,
+			Created:        report.Created,
+			Updated:        report.Updated,
+			Skipped:        report.Skipped,
+			Conflicts:      len(report.Conflicts),
+			ImportedBy:     opts.ImportedBy,
+		}
+		if err := s.serviceDB.RecordBenchmarkBundleImport(record); err != nil {
+			return nil, apperrors.NewInternalError("не удалось записать журнал импорта", err)
+		}
+	}
+
+	return report, nil
+}
+
+// importBenchmarks сопоставляет эталоны пакета с локальными по ID, ИНН и нормализованному наименованию
+func (s *BenchmarkBundleService) importBenchmarks(payload *BenchmarkBundlePayload, keyID string, opts BenchmarkBundleImportOptions, report *BenchmarkBundleImportReport) error {
+	local, err := s.benchmarksDB.ListAllBenchmarks("", false)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось получить локальные эталоны", err)
+	}
+	byID := make(map[string]*database.Benchmark, len(local))
+	byINN := make(map[string]*database.Benchmark)
+	byName := make(map[string]*database.Benchmark)
+	for _, b := range local {
+		byID[b.ID] = b
+		if inn := benchmarkDataString(b.Data, "inn", "tax_id"); inn != "" {
+			byINN[b.EntityType+"|"+inn] = b
+		}
+		byName[b.EntityType+"|"+normalizeBundleName(b.Name)] = b
+	}
+
+	for i := range payload.Benchmarks {
+		incoming := &payload.Benchmarks[i].Benchmark
+		report.Total++
+
+		existing, matchedBy := byID[incoming.ID], "id"
+		if existing == nil {
+			if inn := benchmarkDataString(incoming.Data, "inn", "tax_id"); inn != "" {
+				existing, matchedBy = byINN[incoming.EntityType+"|"+inn], "inn"
+			}
+		}
+		if existing == nil {
+			existing, matchedBy = byName[incoming.EntityType+"|"+normalizeBundleName(incoming.Name)], "name"
+		}
+
+		if existing == nil {
+			created := *incoming
+			if created.ID == "" || byID[created.ID] != nil {
+				created.ID = uuid.New().String()
+			}
+			report.Created++
+			if opts.DryRun {
+				continue
+			}
+			if err := s.benchmarksDB.CreateBenchmark(&created); err != nil {
+				return apperrors.NewInternalError("не удалось создать эталон", err)
+			}
+			if err := s.benchmarksDB.SetBenchmarkProvenance(bundleProvenance(created.ID, &payload.Benchmarks[i], payload, keyID, opts.ImportedBy)); err != nil {
+				return apperrors.NewInternalError("не удалось сохранить происхождение эталона", err)
+			}
+			byID[created.ID] = &created
+			continue
+		}
+
+		conflictFields, hasAdditions := diffBenchmarks(existing, incoming)
+		if len(conflictFields) == 0 && !hasAdditions {
+			report.Unchanged++
+			continue
+		}
+
+		merged, resolution := resolveBenchmark(existing, incoming, opts.Strategy)
+		if len(conflictFields) > 0 {
+			report.Conflicts = append(report.Conflicts, BenchmarkBundleConflict{
+				Kind:       "benchmark",
+				IncomingID: incoming.ID,
+				LocalID:    existing.ID,
+				Name:       incoming.Name,
+				MatchedBy:  matchedBy,
+				Fields:     conflictFields,
+				Resolution: resolution,
+			})
+		}
+		if merged == nil {
+			report.Skipped++
+			continue
+		}
+		report.Updated++
+		if opts.DryRun {
+			continue
+		}
+		if err := s.benchmarksDB.UpdateBenchmark(merged); err != nil {
+			return apperrors.NewInternalError("не удалось обновить эталон", err)
+		}
+		if resolution == "overwritten" {
+			if err := s.benchmarksDB.SetBenchmarkProvenance(bundleProvenance(merged.ID, &payload.Benchmarks[i], payload, keyID, opts.ImportedBy)); err != nil {
+				return apperrors.NewInternalError("не удалось сохранить происхождение эталона", err)
+			}
+		}
+	}
+	return nil
+}
+
+// bundleProvenance формирует происхождение эталона; для эталонов, уже перенесенных ранее,
+// сохраняется исходная инсталляция
+func bundleProvenance(localID string, item *BundledBenchmark, payload *BenchmarkBundlePayload, keyID, importedBy string) *database.BenchmarkProvenance {
+	p := &database.BenchmarkProvenance{
+		BenchmarkID:       localID,
+		OriginInstance:    payload.SourceInstance,
+		OriginBenchmarkID: item.ID,
+		BundleID:          payload.BundleID,
+		SignerKeyID:       keyID,
+		ImportedBy:        importedBy,
+	}
+	if item.Provenance != nil {
+		p.OriginInstance = item.Provenance.OriginInstance
+		p.OriginBenchmarkID = item.Provenance.OriginBenchmarkID
+	}
+	return p
+}
+
+// diffBenchmarks возвращает поля с разными непустыми значениями и признак того,
+// что входящий эталон содержит данные, отсутствующие в локальном
+func diffBenchmarks(local, incoming *database.Benchmark) ([]string, bool) {
+	var conflicts []string
+	additions := false
+
+	if normalizeBundleName(local.Name) != normalizeBundleName(incoming.Name) {
+		conflicts = append(conflicts, "name")
+	}
+	for key, value := range incoming.Data {
+		incomingValue := bundleValueString(value)
+		if incomingValue == "" {
+			continue
+		}
+		localValue := bundleValueString(local.Data[key])
+		switch {
+		case localValue == "":
+			additions = true
+		case localValue != incomingValue:
+			conflicts = append(conflicts, "data."+key)
+		}
+	}
+	known := make(map[string]bool, len(local.Variations))
+	for _, v := range local.Variations {
+		known[normalizeBundleName(v)] = true
+	}
+	for _, v := range incoming.Variations {
+		if !known[normalizeBundleName(v)] {
+			additions = true
+			break
+		}
+	}
+
+	sort.Strings(conflicts)
+	return conflicts, additions
+}
+
+// resolveBenchmark применяет стратегию; возвращает nil, если локальный эталон не меняется
+func resolveBenchmark(local, incoming *database.Benchmark, strategy string) (*database.Benchmark, string) {
+	switch strategy {
+	case BundleStrategyOverwrite:
+		return overwriteBenchmark(local, incoming), "overwritten"
+	case BundleStrategyNewer:
+		if incoming.UpdatedAt.After(local.UpdatedAt) {
+			return overwriteBenchmark(local, incoming), "overwritten"
+		}
+		return nil, "kept_local"
+	case BundleStrategyMerge:
+		merged := *local
+		merged.Data = make(map[string]interface{}, len(local.Data)+len(incoming.Data))
+		for key, value := range local.Data {
+			merged.Data[key] = value
+		}
+		for key, value := range incoming.Data {
+			if bundleValueString(merged.Data[key]) == "" {
+				merged.Data[key] = value
+			}
+		}
+		merged.Variations = unionVariations(local.Variations, incoming.Variations)
+		return &merged, "merged"
+	default:
+		return nil, "kept_local"
+	}
+}
+
+// overwriteBenchmark заменяет данные локального эталона входящими, сохраняя локальный ID
+func overwriteBenchmark(local, incoming *database.Benchmark) *database.Benchmark {
+	result := *incoming
+	result.ID = local.ID
+	result.CreatedAt = local.CreatedAt
+	return &result
+}
+
+// unionVariations объединяет вариации без повторов
+func unionVariations(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	var result []string
+	for _, v := range append(append([]string{}, a...), b...) {
+		key := normalizeBundleName(v)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, v)
+	}
+	return result
+}
+
+// clientBenchmarkField поле эталона клиента, участвующее в сравнении и слиянии
+type clientBenchmarkField struct {
+	name string
+	get  func(*BundledClientBenchmark) *string
+}
+
+var clientBenchmarkFields = []clientBenchmarkField{
+	{"normalized_name", func(b *BundledClientBenchmark) *string { return &b.NormalizedName }},
+	{"category", func(b *BundledClientBenchmark) *string { return &b.Category }},
+	{"subcategory", func(b *BundledClientBenchmark) *string { return &b.Subcategory }},
+	{"attributes", func(b *BundledClientBenchmark) *string { return &b.Attributes }},
+	{"tax_id", func(b *BundledClientBenchmark) *string { return &b.TaxID }},
+	{"kpp", func(b *BundledClientBenchmark) *string { return &b.KPP }},
+	{"ogrn", func(b *BundledClientBenchmark) *string { return &b.OGRN }},
+	{"region", func(b *BundledClientBenchmark) *string { return &b.Region }},
+	{"legal_address", func(b *BundledClientBenchmark) *string { return &b.LegalAddress }},
+	{"postal_address", func(b *BundledClientBenchmark) *string { return &b.PostalAddress }},
+	{"contact_phone", func(b *BundledClientBenchmark) *string { return &b.ContactPhone }},
+	{"contact_email", func(b *BundledClientBenchmark) *string { return &b.ContactEmail }},
+	{"contact_person", func(b *BundledClientBenchmark) *string { return &b.ContactPerson }},
+	{"legal_form", func(b *BundledClientBenchmark) *string { return &b.LegalForm }},
+	{"bank_name", func(b *BundledClientBenchmark) *string { return &b.BankName }},
+	{"bank_account", func(b *BundledClientBenchmark) *string { return &b.BankAccount }},
+	{"correspondent_account", func(b *BundledClientBenchmark) *string { return &b.CorrespondentAccount }},
+	{"bik", func(b *BundledClientBenchmark) *string { return &b.BIK }},
+	{"okpd2_code", func(b *BundledClientBenchmark) *string { return &b.OKPD2Code }},
+}
+
+// importClientBenchmarks сопоставляет эталоны клиента с эталонами проекта по ИНН и нормализованному наименованию
+func (s *BenchmarkBundleService) importClientBenchmarks(payload *BenchmarkBundlePayload, opts BenchmarkBundleImportOptions, report *BenchmarkBundleImportReport) error {
+	local, err := s.serviceDB.GetClientBenchmarks(opts.ProjectID, "", false)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось получить эталоны проекта", err)
+	}
+	var okpd2IDs []int
+	for _, cb := range local {
+		if cb.OKPD2ReferenceID != nil {
+			okpd2IDs = append(okpd2IDs, *cb.OKPD2ReferenceID)
+		}
+	}
+	okpd2, err := s.serviceDB.GetOKPD2EntriesByIDs(okpd2IDs)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось получить коды ОКПД2", err)
+	}
+
+	byINN := make(map[string]*BundledClientBenchmark)
+	byName := make(map[string]*BundledClientBenchmark)
+	for _, cb := range local {
+		item := &BundledClientBenchmark{ClientBenchmark: *cb}
+		if cb.OKPD2ReferenceID != nil {
+			item.OKPD2Code = okpd2[*cb.OKPD2ReferenceID].Code
+		}
+		if cb.TaxID != "" {
+			byINN[cb.TaxID] = item
+		}
+		byName[cb.Category+"|"+normalizeBundleName(cb.NormalizedName)] = item
+	}
+
+	for i := range payload.ClientBenchmarks {
+		incoming := &payload.ClientBenchmarks[i]
+		report.Total++
+
+		var existing *BundledClientBenchmark
+		matchedBy := "inn"
+		if incoming.TaxID != "" {
+			existing = byINN[incoming.TaxID]
+		}
+		if existing == nil {
+			existing, matchedBy = byName[incoming.Category+"|"+normalizeBundleName(incoming.NormalizedName)], "name"
+		}
+
+		if existing == nil {
+			report.Created++
+			if opts.DryRun {
+				continue
+			}
+			created := *incoming
+			created.ID = 0
+			created.ClientProjectID = opts.ProjectID
+			if err := s.saveClientBenchmark(&created); err != nil {
+				return err
+			}
+			if created.TaxID != "" {
+				byINN[created.TaxID] = &created
+			}
+			byName[created.Category+"|"+normalizeBundleName(created.NormalizedName)] = &created
+			continue
+		}
+
+		var conflictFields []string
+		additions := false
+		for _, field := range clientBenchmarkFields {
+			localValue, incomingValue := strings.TrimSpace(*field.get(existing)), strings.TrimSpace(*field.get(incoming))
+			switch {
+			case incomingValue == "" || localValue == incomingValue:
+			case localValue == "":
+				additions = true
+			default:
+				conflictFields = append(conflictFields, field.name)
+			}
+		}
+		if len(conflictFields) == 0 && !additions {
+			report.Unchanged++
+			continue
+		}
+
+		var merged *BundledClientBenchmark
+		resolution := "kept_local"
+		switch opts.Strategy {
+		case BundleStrategyOverwrite:
+			merged, resolution = overwriteClientBenchmark(existing, incoming), "overwritten"
+		case BundleStrategyNewer:
+			if incoming.UpdatedAt.After(existing.UpdatedAt) {
+				merged, resolution = overwriteClientBenchmark(existing, incoming), "overwritten"
+			}
+		case BundleStrategyMerge:
+			copied := *existing
+			for _, field := range clientBenchmarkFields {
+				if strings.TrimSpace(*field.get(&copied)) == "" {
+					*field.get(&copied) = *field.get(incoming)
+				}
+			}
+			merged, resolution = &copied, "merged"
+		}
+
+		if len(conflictFields) > 0 {
+			report.Conflicts = append(report.Conflicts, BenchmarkBundleConflict{
+				Kind:       "client_benchmark",
+				IncomingID: fmt.Sprintf("%d", incoming.ID),
+				LocalID:    fmt.Sprintf("%d", existing.ID),
+				Name:       incoming.NormalizedName,
+				MatchedBy:  matchedBy,
+				Fields:     conflictFields,
+				Resolution: resolution,
+			})
+		}
+		if merged == nil {
+			report.Skipped++
+			continue
+		}
+		report.Updated++
+		if opts.DryRun {
+			continue
+		}
+		if err := s.saveClientBenchmark(merged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overwriteClientBenchmark заменяет данные эталона клиента входящими, сохраняя локальные ID и проект
+func overwriteClientBenchmark(local, incoming *BundledClientBenchmark) *BundledClientBenchmark {
+	result := *incoming
+	result.ID = local.ID
+	result.ClientProjectID = local.ClientProjectID
+	result.UsageCount = local.UsageCount
+	return &result
+}
+
+// saveClientBenchmark сохраняет эталон клиента, разрешая код ОКПД2 в локальный справочник
+func (s *BenchmarkBundleService) saveClientBenchmark(item *BundledClientBenchmark) error {
+	item.OKPD2ReferenceID = nil
+	if item.OKPD2Code != "" {
+		refID, err := s.serviceDB.FindOrCreateOKPD2Reference(item.OKPD2Code, item.OKPD2Name)
+		if err != nil {
+			return apperrors.NewInternalError("не удалось сопоставить код ОКПД2", err)
+		}
+		item.OKPD2ReferenceID = refID
+	}
+	if err := s.serviceDB.SaveClientBenchmarkRecord(&item.ClientBenchmark); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить эталон клиента", err)
+	}
+	return nil
+}
+
+// benchmarkDataString возвращает первое непустое строковое значение из данных эталона
+func benchmarkDataString(data map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if value := bundleValueString(data[key]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// bundleValueString приводит значение из данных эталона к строке для сравнения
+func bundleValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+// normalizeBundleName приводит наименование к виду для сопоставления: регистр, ё, пробелы и кавычки
+func normalizeBundleName(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(name), "ё", "е")
+	name = strings.NewReplacer("\"", " ", "«", " ", "»", " ", "'", " ").Replace(name)
+	return strings.Join(strings.Fields(name), " ")
+}
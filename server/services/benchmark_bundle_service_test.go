@@ -0,0 +1,255 @@
+package services
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"httpserver/database"
+)
+
+// setupBundleInstance создает пару баз (эталоны и сервисная) отдельной инсталляции
+func setupBundleInstance(t *testing.T, name string) *BenchmarkBundleService {
+	t.Helper()
+	dir := t.TempDir()
+	benchmarksDB, err := database.NewBenchmarksDB(filepath.Join(dir, "benchmarks.db"))
+	if err != nil {
+		t.Fatalf("Failed to create benchmarks DB: %v", err)
+	}
+	t.Cleanup(func() { benchmarksDB.Close() })
+	serviceDB, err := database.NewServiceDB(filepath.Join(dir, "service.db"))
+	if err != nil {
+		t.Fatalf("Failed to create service DB: %v", err)
+	}
+	t.Cleanup(func() { serviceDB.Close() })
+	return NewBenchmarkBundleService(benchmarksDB, serviceDB, name)
+}
+
+func TestBenchmarkBundleService_ExportImport(t *testing.T) {
+	source := setupBundleInstance(t, "moscow")
+	target := setupBundleInstance(t, "kazan")
+
+	for _, b := range []*database.Benchmark{
+		{ID: "b1", EntityType: "counterparty", Name: "ООО Ромашка", IsActive: true,
+			Data: map[string]interface{}{"inn": "7701234567", "kpp": "770101001"}, Variations: []string{"Ромашка"}},
+		{ID: "b2", EntityType: "nomenclature", Name: "Болт М10х50", IsActive: true,
+			Data: map[string]interface{}{"okpd2_code": "25.94.11", "unit": "шт"}},
+	} {
+		if err := source.benchmarksDB.CreateBenchmark(b); err != nil {
+			t.Fatalf("CreateBenchmark: %v", err)
+		}
+	}
+	// Локальный эталон с тем же ИНН и другим КПП и эталон с тем же наименованием без единицы измерения
+	if err := target.benchmarksDB.CreateBenchmark(&database.Benchmark{ID: "local-1", EntityType: "counterparty",
+		Name: "Ромашка ООО", IsActive: true, Data: map[string]interface{}{"inn": "7701234567", "kpp": "770102002"}}); err != nil {
+		t.Fatalf("CreateBenchmark: %v", err)
+	}
+	if err := target.benchmarksDB.CreateBenchmark(&database.Benchmark{ID: "local-2", EntityType: "nomenclature",
+		Name: "болт  м10х50", IsActive: true, Data: map[string]interface{}{"okpd2_code": "25.94.11"}}); err != nil {
+		t.Fatalf("CreateBenchmark: %v", err)
+	}
+
+	bundle, err := source.Export(BenchmarkBundleExportOptions{ActiveOnly: true})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// Ключ источника неизвестен получателю
+	if _, err := target.Import(bundle, BenchmarkBundleImportOptions{DryRun: true}); err == nil {
+		t.Fatal("expected untrusted key error")
+	}
+	_, publicKey, err := source.GetPublicKey()
+	if err != nil {
+		t.Fatalf("GetPublicKey: %v", err)
+	}
+	if _, err := target.AddTrustedKey("Москва", publicKey, "admin"); err != nil {
+		t.Fatalf("AddTrustedKey: %v", err)
+	}
+
+	report, err := target.Import(bundle, BenchmarkBundleImportOptions{Strategy: BundleStrategyMerge, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry-run Import: %v", err)
+	}
+	if !report.Trusted || report.Total != 2 || report.Updated != 2 || report.Created != 0 {
+		t.Errorf("unexpected dry-run report: %+v", report)
+	}
+	// Второй эталон только дополняется единицей измерения - это не конфликт
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %+v", report.Conflicts)
+	}
+	conflict := report.Conflicts[0]
+	if conflict.LocalID != "local-1" || conflict.MatchedBy != "inn" || len(conflict.Fields) != 2 {
+		t.Errorf("unexpected conflict: %+v", conflict)
+	}
+	if local, _ := target.benchmarksDB.GetBenchmark("local-2"); local.Data["unit"] != nil {
+		t.Error("dry-run must not change local benchmarks")
+	}
+
+	if _, err := target.Import(bundle, BenchmarkBundleImportOptions{Strategy: BundleStrategyMerge}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	merged, err := target.benchmarksDB.GetBenchmark("local-1")
+	if err != nil {
+		t.Fatalf("GetBenchmark: %v", err)
+	}
+	if merged.Data["kpp"] != "770102002" || len(merged.Variations) != 1 {
+		t.Errorf("merge must keep local values and add variations: %+v", merged)
+	}
+	if local, _ := target.benchmarksDB.GetBenchmark("local-2"); local.Data["unit"] != "шт" {
+		t.Errorf("merge must fill missing fields: %+v", local.Data)
+	}
+
+	// Повторный импорт того же пакета ничего не меняет
+	report, err = target.Import(bundle, BenchmarkBundleImportOptions{Strategy: BundleStrategyMerge, DryRun: true})
+	if err != nil {
+		t.Fatalf("repeat Import: %v", err)
+	}
+	if report.Unchanged+len(report.Conflicts) != 2 || report.Created != 0 {
+		t.Errorf("unexpected repeat report: %+v", report)
+	}
+}
+
+func TestBenchmarkBundleService_NewBenchmarkProvenance(t *testing.T) {
+	source := setupBundleInstance(t, "moscow")
+	target := setupBundleInstance(t, "kazan")
+
+	if err := source.benchmarksDB.CreateBenchmark(&database.Benchmark{ID: "b1", EntityType: "counterparty",
+		Name: "АО Лютик", IsActive: true, Data: map[string]interface{}{"inn": "1650000000"}}); err != nil {
+		t.Fatalf("CreateBenchmark: %v", err)
+	}
+	bundle, err := source.Export(BenchmarkBundleExportOptions{})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	report, err := target.Import(bundle, BenchmarkBundleImportOptions{AllowUntrusted: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if report.Trusted || report.Created != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	provenance, err := target.benchmarksDB.GetBenchmarkProvenance()
+	if err != nil {
+		t.Fatalf("GetBenchmarkProvenance: %v", err)
+	}
+	p := provenance["b1"]
+	if p == nil || p.OriginInstance != "moscow" || p.BundleID != report.BundleID {
+		t.Errorf("unexpected provenance: %+v", p)
+	}
+
+	// При повторном экспорте исходная инсталляция сохраняется
+	reexported, err := target.Export(BenchmarkBundleExportOptions{})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	payload, _, err := target.Verify(reexported)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if payload.Benchmarks[0].Provenance == nil || payload.Benchmarks[0].Provenance.OriginInstance != "moscow" {
+		t.Errorf("provenance must travel with the benchmark: %+v", payload.Benchmarks[0].Provenance)
+	}
+}
+
+func TestBenchmarkBundleService_VerifyRejectsTampering(t *testing.T) {
+	source := setupBundleInstance(t, "moscow")
+	if err := source.benchmarksDB.CreateBenchmark(&database.Benchmark{ID: "b1", EntityType: "nomenclature",
+		Name: "Гайка М10", IsActive: true, Data: map[string]interface{}{}}); err != nil {
+		t.Fatalf("CreateBenchmark: %v", err)
+	}
+	bundle, err := source.Export(BenchmarkBundleExportOptions{})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	// Переформатирование при передаче подпись не нарушает
+	indented, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent: %v", err)
+	}
+	var decoded BenchmarkBundle
+	if err := json.Unmarshal(indented, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if _, trusted, err := source.Verify(&decoded); err != nil || !trusted {
+		t.Fatalf("reformatted bundle must verify: trusted=%t err=%v", trusted, err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(bundle.Payload, &payload); err != nil {
+		t.Fatalf("Unmarshal payload: %v", err)
+	}
+	payload["source_instance"] = "forged"
+	tampered := *bundle
+	tampered.Payload, _ = json.Marshal(payload)
+	if _, _, err := source.Verify(&tampered); err == nil {
+		t.Error("expected signature error for tampered payload")
+	}
+
+	unsupported := *bundle
+	unsupported.FormatVersion = BenchmarkBundleFormatVersion + 1
+	if _, _, err := source.Verify(&unsupported); err == nil {
+		t.Error("expected error for unsupported format version")
+	}
+}
+
+func TestBenchmarkBundleService_ClientBenchmarks(t *testing.T) {
+	source := setupBundleInstance(t, "moscow")
+	target := setupBundleInstance(t, "kazan")
+
+	projectIDs := make([]int, 2)
+	for i, svc := range []*BenchmarkBundleService{source, target} {
+		client, err := svc.serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+		if err != nil {
+			t.Fatalf("CreateClient: %v", err)
+		}
+		project, err := svc.serviceDB.CreateClientProject(client.ID, "Проект", "counterparty", "", "1c", 0.8)
+		if err != nil {
+			t.Fatalf("CreateClientProject: %v", err)
+		}
+		projectIDs[i] = project.ID
+	}
+
+	okpd2ID, err := source.serviceDB.FindOrCreateOKPD2Reference("25.94.11", "Болты")
+	if err != nil {
+		t.Fatalf("FindOrCreateOKPD2Reference: %v", err)
+	}
+	if err := source.serviceDB.SaveClientBenchmarkRecord(&database.ClientBenchmark{
+		ClientProjectID: projectIDs[0], OriginalName: "Болт М10", NormalizedName: "болт м10", Category: "nomenclature",
+		OKPD2ReferenceID: okpd2ID,
+	}); err != nil {
+		t.Fatalf("SaveClientBenchmarkRecord: %v", err)
+	}
+
+	bundle, err := source.Export(BenchmarkBundleExportOptions{ProjectID: projectIDs[0]})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	report, err := target.Import(bundle, BenchmarkBundleImportOptions{AllowUntrusted: true})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(report.Warnings) != 1 || report.Created != 0 {
+		t.Errorf("client benchmarks must be skipped without project: %+v", report)
+	}
+
+	if _, err := target.Import(bundle, BenchmarkBundleImportOptions{AllowUntrusted: true, ProjectID: projectIDs[1]}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	imported, err := target.serviceDB.GetClientBenchmarks(projectIDs[1], "", false)
+	if err != nil {
+		t.Fatalf("GetClientBenchmarks: %v", err)
+	}
+	if len(imported) != 1 || imported[0].OKPD2ReferenceID == nil {
+		t.Fatalf("expected imported client benchmark with OKPD2 link, got %+v", imported)
+	}
+	entries, err := target.serviceDB.GetOKPD2EntriesByIDs([]int{*imported[0].OKPD2ReferenceID})
+	if err != nil {
+		t.Fatalf("GetOKPD2EntriesByIDs: %v", err)
+	}
+	if entries[*imported[0].OKPD2ReferenceID].Code != "25.94.11" {
+		t.Errorf("OKPD2 link must be resolved by code: %+v", entries)
+	}
+}
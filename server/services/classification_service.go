@@ -485,6 +485,58 @@ func (cs *ClassificationService) ResetClassification(normalizedName string, cate
 	return result.RowsAffected()
 }
 
+// ClassificationResetSnapshot возвращает количество классифицированных записей по кодам КПВЭД,
+// подпадающих под критерии сброса (для журнала аудита)
+func (cs *ClassificationService) ClassificationResetSnapshot(normalizedName string, category string, kpvedCode string, maxConfidence float64, resetAll bool) (map[string]interface{}, error) {
+	if cs == nil || cs.db == nil {
+		return nil, apperrors.NewInternalError("database not available", nil)
+	}
+
+	conditions := []string{"kpved_code IS NOT NULL", "kpved_code != ''"}
+	var args []interface{}
+	if !resetAll {
+		if normalizedName != "" {
+			conditions = append(conditions, "normalized_name = ?")
+			args = append(args, normalizedName)
+		}
+		if category != "" {
+			conditions = append(conditions, "category = ?")
+			args = append(args, category)
+		}
+		if kpvedCode != "" {
+			conditions = append(conditions, "kpved_code = ?")
+			args = append(args, kpvedCode)
+		}
+		if maxConfidence > 0 {
+			conditions = append(conditions, "kpved_confidence < ?")
+			args = append(args, maxConfidence)
+		}
+	}
+
+	rows, err := cs.db.Query(fmt.Sprintf(`SELECT kpved_code, COUNT(*) FROM normalized_data
+		WHERE %s GROUP BY kpved_code`, strings.Join(conditions, " AND ")), args...)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить состояние классификации", err)
+	}
+	defer rows.Close()
+
+	byCode := make(map[string]int)
+	total := 0
+	for rows.Next() {
+		var code string
+		var count int
+		if err := rows.Scan(&code, &count); err != nil {
+			return nil, apperrors.NewInternalError("не удалось прочитать состояние классификации", err)
+		}
+		byCode[code] = count
+		total += count
+	}
+	return map[string]interface{}{
+		"classified": total,
+		"by_code":    byCode,
+	}, rows.Err()
+}
+
 // MarkIncorrect помечает классификацию как неправильную
 func (cs *ClassificationService) MarkIncorrect(normalizedName string, category string, reason string) (int64, error) {
 	query := `UPDATE normalized_data 
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+	apperrors "httpserver/server/errors"
+)
+
+// ClientCertificateService управляет привязками клиентских сертификатов 1С к клиентам
+// и проектам и проверяет право выгрузки по сертификату
+type ClientCertificateService struct {
+	serviceDB *database.ServiceDB
+
+	mu    sync.RWMutex
+	cache map[string][]*database.ClientCertificateBinding // subject|fingerprint -> действующие привязки
+}
+
+// NewClientCertificateService создает сервис привязок клиентских сертификатов
+func NewClientCertificateService(serviceDB *database.ServiceDB) *ClientCertificateService {
+	return &ClientCertificateService{
+		serviceDB: serviceDB,
+		cache:     make(map[string][]*database.ClientCertificateBinding),
+	}
+}
+
+// List возвращает привязки сертификатов клиента
+func (s *ClientCertificateService) List(clientID int) ([]*database.ClientCertificateBinding, error) {
+	bindings, err := s.serviceDB.GetClientCertificateBindings(clientID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить сертификаты клиента", err)
+	}
+	if bindings == nil {
+		bindings = []*database.ClientCertificateBinding{}
+	}
+	return bindings, nil
+}
+
+// Create привязывает сертификат к клиенту и, если указан, к проекту клиента
+func (s *ClientCertificateService) Create(binding *database.ClientCertificateBinding) error {
+	binding.Subject = strings.TrimSpace(binding.Subject)
+	binding.Fingerprint = normalizeCertificateFingerprint(binding.Fingerprint)
+	if binding.Subject == "" {
+		return apperrors.NewValidationError("subject (CN сертификата) обязателен", nil)
+	}
+	if _, err := s.serviceDB.GetClient(binding.ClientID); err != nil {
+		return apperrors.NewNotFoundError("клиент не найден", err)
+	}
+	if binding.ProjectID != nil {
+		project, err := s.serviceDB.GetClientProject(*binding.ProjectID)
+		if err != nil {
+			return apperrors.NewNotFoundError("проект не найден", err)
+		}
+		if project.ClientID != binding.ClientID {
+			return apperrors.NewValidationError("проект принадлежит другому клиенту", nil)
+		}
+	}
+
+	if err := s.serviceDB.CreateClientCertificateBinding(binding); err != nil {
+		return apperrors.NewInternalError("не удалось привязать сертификат", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+// Revoke отзывает привязку сертификата клиента
+func (s *ClientCertificateService) Revoke(clientID, id int) error {
+	if err := s.serviceDB.RevokeClientCertificateBinding(clientID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperrors.NewNotFoundError("привязка сертификата не найдена", err)
+		}
+		return apperrors.NewInternalError("не удалось отозвать сертификат", err)
+	}
+	s.invalidate()
+	return nil
+}
+
+// Authorize проверяет, что сертификат с данным CN и отпечатком может выгружать данные
+// в клиента и проект. Выгрузка разрешается, если подходит хотя бы одна привязка сертификата.
+// Если база выгрузки не определена (clientID = 0), выгрузка по сертификату отклоняется.
+// projectID = 0 - база клиента без проекта, подходит любая привязка клиента
+func (s *ClientCertificateService) Authorize(subject, fingerprint string, clientID, projectID int) (*database.ClientCertificateBinding, error) {
+	bindings, err := s.find(subject, normalizeCertificateFingerprint(fingerprint))
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось проверить сертификат", err)
+	}
+	if len(bindings) == 0 {
+		return nil, apperrors.NewForbiddenError(fmt.Sprintf("сертификат %q не привязан к клиенту", subject), nil)
+	}
+	if clientID <= 0 {
+		return nil, apperrors.NewForbiddenError(fmt.Sprintf("сертификат %q: не удалось определить клиента по upload_uuid или database_id", subject), nil)
+	}
+
+	clientBound := false
+	for _, binding := range bindings {
+		if binding.ClientID != clientID {
+			continue
+		}
+		clientBound = true
+		if projectID <= 0 || binding.ProjectID == nil || *binding.ProjectID == projectID {
+			return binding, nil
+		}
+	}
+	if !clientBound {
+		return nil, apperrors.NewForbiddenError(fmt.Sprintf("сертификат %q не дает права выгрузки для клиента %d", subject, clientID), nil)
+	}
+	return nil, apperrors.NewForbiddenError(fmt.Sprintf("сертификат %q не дает права выгрузки в проект %d", subject, projectID), nil)
+}
+
+// find ищет привязки с кэшированием результата до следующего изменения привязок
+func (s *ClientCertificateService) find(subject, fingerprint string) ([]*database.ClientCertificateBinding, error) {
+	key := subject + "|" + fingerprint
+	s.mu.RLock()
+	bindings, cached := s.cache[key]
+	s.mu.RUnlock()
+	if cached {
+		return bindings, nil
+	}
+
+	bindings, err := s.serviceDB.FindClientCertificateBindings(subject, fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[key] = bindings
+	s.mu.Unlock()
+	return bindings, nil
+}
+
+func (s *ClientCertificateService) invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[string][]*database.ClientCertificateBinding)
+	s.mu.Unlock()
+}
+
+// normalizeCertificateFingerprint приводит отпечаток к hex в нижнем регистре без разделителей
+func normalizeCertificateFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "").Replace(strings.TrimSpace(fingerprint)))
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"httpserver/database"
+	apperrors "httpserver/server/errors"
+)
+
+// TestClientCertificateService_Authorize проверяет сопоставление CN сертификата с клиентом и проектом
+func TestClientCertificateService_Authorize(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	service := NewClientCertificateService(serviceDB)
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.9)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	other, err := serviceDB.CreateClientProject(client.ID, "Другой", "nomenclature", "", "1C", 0.9)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	binding := &database.ClientCertificateBinding{ClientID: client.ID, ProjectID: &project.ID, Subject: "1c-base-01", Fingerprint: "AB:CD"}
+	if err := service.Create(binding); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if _, err := service.Authorize("1c-base-01", "abcd", client.ID, project.ID); err != nil {
+		t.Errorf("expected certificate to be allowed, got %v", err)
+	}
+
+	// Второй проект того же сертификата: подходит любая из привязок
+	third, err := serviceDB.CreateClientProject(client.ID, "Третий", "nomenclature", "", "1C", 0.9)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	if err := service.Create(&database.ClientCertificateBinding{ClientID: client.ID, ProjectID: &third.ID, Subject: "1c-base-01"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, projectID := range []int{project.ID, third.ID} {
+		if _, err := service.Authorize("1c-base-01", "abcd", client.ID, projectID); err != nil {
+			t.Errorf("expected certificate to be allowed for project %d, got %v", projectID, err)
+		}
+	}
+
+	forbidden := []struct {
+		name        string
+		subject     string
+		fingerprint string
+		clientID    int
+		projectID   int
+	}{
+		{"база не определена", "1c-base-01", "abcd", 0, 0},
+		{"чужой проект", "1c-base-01", "abcd", client.ID, other.ID},
+		{"чужой клиент", "1c-base-01", "abcd", client.ID + 1, 0},
+		{"другой отпечаток", "1c-base-01", "ffff", client.ID, project.ID},
+		{"неизвестный CN", "1c-base-02", "abcd", client.ID, project.ID},
+	}
+	for _, tt := range forbidden {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Authorize(tt.subject, tt.fingerprint, tt.clientID, tt.projectID)
+			if appErr, ok := err.(*apperrors.AppError); !ok || appErr.Code != http.StatusForbidden {
+				t.Errorf("expected forbidden error, got %v", err)
+			}
+		})
+	}
+
+	if err := service.Revoke(client.ID, binding.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if _, err := service.Authorize("1c-base-01", "abcd", client.ID, project.ID); err == nil {
+		t.Error("expected revoked certificate to be rejected")
+	}
+	if _, err := service.Authorize("1c-base-01", "abcd", client.ID, third.ID); err != nil {
+		t.Errorf("expected remaining binding to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"httpserver/database"
+	"httpserver/internal/config"
+	apperrors "httpserver/server/errors"
+)
+
+// ClientConfigService управляет клиентскими overlay конфигурации поверх глобальных настроек:
+// учетными данными и лимитами провайдеров, ограничением частоты выгрузок и пространствами имен кэшей.
+type ClientConfigService struct {
+	serviceDB *database.ServiceDB
+
+	mu       sync.RWMutex
+	overlays map[int]*config.ClientConfigOverlay // кэш overlay, nil-значение - overlay не задан
+
+	limiterMu sync.Mutex
+	limiters  map[int]*clientUploadLimiter
+}
+
+// clientUploadLimiter ограничитель частоты выгрузок клиента
+type clientUploadLimiter struct {
+	limiter           *rate.Limiter
+	requestsPerMinute int
+	burst             int
+}
+
+// NewClientConfigService создает сервис клиентских настроек
+func NewClientConfigService(serviceDB *database.ServiceDB) *ClientConfigService {
+	return &ClientConfigService{
+		serviceDB: serviceDB,
+		overlays:  make(map[int]*config.ClientConfigOverlay),
+		limiters:  make(map[int]*clientUploadLimiter),
+	}
+}
+
+// GetOverlay возвращает overlay клиента. Если overlay не задан, возвращает пустой overlay.
+func (s *ClientConfigService) GetOverlay(clientID int) (*config.ClientConfigOverlay, error) {
+	s.mu.RLock()
+	overlay, cached := s.overlays[clientID]
+	s.mu.RUnlock()
+	if cached {
+		return s.orEmpty(clientID, overlay), nil
+	}
+
+	if s.serviceDB == nil {
+		return s.orEmpty(clientID, nil), nil
+	}
+
+	record, err := s.serviceDB.GetClientConfigOverlay(clientID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить настройки клиента", err)
+	}
+	if record != nil {
+		overlay, err = config.ParseClientConfigOverlay(record.OverlayJSON)
+		if err != nil {
+			return nil, apperrors.NewInternalError("некорректные настройки клиента в БД", err)
+		}
+		overlay.ClientID = clientID
+	}
+
+	s.mu.Lock()
+	s.overlays[clientID] = overlay
+	s.mu.Unlock()
+
+	return s.orEmpty(clientID, overlay), nil
+}
+
+// orEmpty возвращает overlay или пустой overlay клиента
+func (s *ClientConfigService) orEmpty(clientID int, overlay *config.ClientConfigOverlay) *config.ClientConfigOverlay {
+	if overlay == nil {
+		return &config.ClientConfigOverlay{ClientID: clientID}
+	}
+	return overlay
+}
+
+// SaveOverlay валидирует и сохраняет overlay клиента
+func (s *ClientConfigService) SaveOverlay(clientID int, overlay *config.ClientConfigOverlay, updatedBy string) error {
+	if overlay == nil {
+		return apperrors.NewValidationError("настройки клиента не заданы", nil)
+	}
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+
+	client, err := s.serviceDB.GetClient(clientID)
+	if err != nil || client == nil {
+		return apperrors.NewNotFoundError(fmt.Sprintf("клиент %d не найден", clientID), err)
+	}
+
+	overlay.ClientID = clientID
+	if err := overlay.Validate(); err != nil {
+		return apperrors.NewValidationError("некорректные настройки клиента", err)
+	}
+
+	data, err := json.Marshal(overlay)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось сериализовать настройки клиента", err)
+	}
+	if err := s.serviceDB.SaveClientConfigOverlay(clientID, string(data), updatedBy); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить настройки клиента", err)
+	}
+
+	s.mu.Lock()
+	s.overlays[clientID] = overlay
+	s.mu.Unlock()
+	s.resetLimiter(clientID)
+	return nil
+}
+
+// DeleteOverlay удаляет overlay клиента (клиент возвращается к глобальным настройкам)
+func (s *ClientConfigService) DeleteOverlay(clientID int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("сервисная БД недоступна", nil)
+	}
+	if err := s.serviceDB.DeleteClientConfigOverlay(clientID); err != nil {
+		return apperrors.NewInternalError("не удалось удалить настройки клиента", err)
+	}
+
+	s.mu.Lock()
+	s.overlays[clientID] = nil
+	s.mu.Unlock()
+	s.resetLimiter(clientID)
+	return nil
+}
+
+// resetLimiter сбрасывает ограничитель выгрузок клиента после изменения настроек
+func (s *ClientConfigService) resetLimiter(clientID int) {
+	s.limiterMu.Lock()
+	delete(s.limiters, clientID)
+	s.limiterMu.Unlock()
+}
+
+// GetEffectiveConfig возвращает глобальную конфигурацию с примененным overlay клиента
+func (s *ClientConfigService) GetEffectiveConfig(base *config.Config, clientID int) (*config.Config, error) {
+	overlay, err := s.GetOverlay(clientID)
+	if err != nil {
+		return nil, err
+	}
+	return config.ApplyClientOverlay(base, overlay), nil
+}
+
+// CacheNamespace возвращает пространство имен кэшей клиента
+func (s *ClientConfigService) CacheNamespace(clientID int) string {
+	overlay, err := s.GetOverlay(clientID)
+	if err != nil {
+		return config.ClientCacheNamespace(clientID)
+	}
+	return overlay.EffectiveCacheNamespace()
+}
+
+// ProviderCredentials возвращает клиентские учетные данные провайдера, если они заданы
+func (s *ClientConfigService) ProviderCredentials(clientID int, provider string) (apiKey, model string, ok bool) {
+	overlay, err := s.GetOverlay(clientID)
+	if err != nil {
+		return "", "", false
+	}
+	p, found := overlay.Provider(provider)
+	if !found || p.APIKey == "" || (p.Enabled != nil && !*p.Enabled) {
+		return "", "", false
+	}
+	return p.APIKey, p.Model, true
+}
+
+// MaxWorkers ограничивает количество воркеров значением из overlay клиента.
+// Без клиентского ограничения возвращает maxWorkers без изменений.
+func (s *ClientConfigService) MaxWorkers(clientID, maxWorkers int) int {
+	overlay, err := s.GetOverlay(clientID)
+	if err != nil || overlay.MaxWorkers == nil || *overlay.MaxWorkers <= 0 {
+		return maxWorkers
+	}
+	if *overlay.MaxWorkers < maxWorkers {
+		return *overlay.MaxWorkers
+	}
+	return maxWorkers
+}
+
+// AllowUpload проверяет ограничение частоты выгрузок клиента.
+// Возвращает false и время ожидания, если лимит исчерпан. Без лимита всегда разрешает.
+func (s *ClientConfigService) AllowUpload(clientID int) (bool, time.Duration) {
+	overlay, err := s.GetOverlay(clientID)
+	if err != nil || overlay.UploadRateLimit == nil || overlay.UploadRateLimit.RequestsPerMinute <= 0 {
+		return true, 0
+	}
+	rpm := overlay.UploadRateLimit.RequestsPerMinute
+	burst := overlay.UploadRateLimit.Burst
+	if burst <= 0 {
+		burst = rpm
+	}
+
+	s.limiterMu.Lock()
+	l, ok := s.limiters[clientID]
+	if !ok || l.requestsPerMinute != rpm || l.burst != burst {
+		l = &clientUploadLimiter{
+			limiter:           rate.NewLimiter(rate.Limit(float64(rpm)/60.0), burst),
+			requestsPerMinute: rpm,
+			burst:             burst,
+		}
+		s.limiters[clientID] = l
+	}
+	s.limiterMu.Unlock()
+
+	reservation := l.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay == 0 {
+		return true, 0
+	}
+	reservation.Cancel()
+	return false, time.Duration(math.Ceil(delay.Seconds())) * time.Second
+}
+
+// modelAndAPIKeyProvider источник модели и API ключа (WorkerConfigManager)
+type modelAndAPIKeyProvider interface {
+	GetModelAndAPIKey() (apiKey string, modelName string, err error)
+}
+
+// ClientWorkerConfig отдает модель и API ключ с учетом overlay клиента,
+// при отсутствии клиентских данных использует глобальную конфигурацию воркеров.
+type ClientWorkerConfig struct {
+	service  *ClientConfigService
+	clientID int
+	base     modelAndAPIKeyProvider
+}
+
+// NewClientWorkerConfig создает клиентскую обертку конфигурации воркеров. base может быть nil.
+func NewClientWorkerConfig(service *ClientConfigService, clientID int, base modelAndAPIKeyProvider) *ClientWorkerConfig {
+	return &ClientWorkerConfig{service: service, clientID: clientID, base: base}
+}
+
+// GetModelAndAPIKey возвращает клиентские API ключ и модель Arliai, иначе - глобальные значения.
+// Ключи других провайдеров не возвращаются: вызывающий код работает только с Arliai.
+func (c *ClientWorkerConfig) GetModelAndAPIKey() (string, string, error) {
+	var baseKey, baseModel string
+	var baseErr error
+	if c.base != nil {
+		baseKey, baseModel, baseErr = c.base.GetModelAndAPIKey()
+	}
+
+	if c.service != nil {
+		if apiKey, model, ok := c.service.ProviderCredentials(c.clientID, "arliai"); ok {
+			if model == "" {
+				model = baseModel
+			}
+			return apiKey, model, nil
+		}
+	}
+
+	if c.base == nil {
+		return "", "", fmt.Errorf("no worker config available for client %d", c.clientID)
+	}
+	return baseKey, baseModel, baseErr
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/internal/config"
+)
+
+// staticWorkerConfig глобальная конфигурация воркеров для тестов
+type staticWorkerConfig struct{}
+
+func (staticWorkerConfig) GetModelAndAPIKey() (string, string, error) {
+	return "global-key", "global-model", nil
+}
+
+// TestClientConfigService_Overlay проверяет сохранение overlay и клиентские учетные данные
+func TestClientConfigService_Overlay(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	service := NewClientConfigService(serviceDB)
+	workerConfig := NewClientWorkerConfig(service, client.ID, staticWorkerConfig{})
+
+	apiKey, model, _ := workerConfig.GetModelAndAPIKey()
+	if apiKey != "global-key" || model != "global-model" {
+		t.Errorf("without overlay got %q/%q, want global values", apiKey, model)
+	}
+	if ns := service.CacheNamespace(client.ID); ns != config.ClientCacheNamespace(client.ID) {
+		t.Errorf("CacheNamespace() = %q, want default client namespace", ns)
+	}
+
+	overlay := &config.ClientConfigOverlay{
+		Providers:       map[string]*config.ClientProviderOverride{"arliai": {APIKey: "client-key"}},
+		UploadRateLimit: &config.ClientRateLimit{RequestsPerMinute: 60, Burst: 2},
+		CacheNamespace:  "tenant-a",
+	}
+	if err := service.SaveOverlay(client.ID, overlay, "tester"); err != nil {
+		t.Fatalf("SaveOverlay() error = %v", err)
+	}
+
+	// Новый сервис читает overlay из БД
+	reloaded := NewClientConfigService(serviceDB)
+	apiKey, model, _ = NewClientWorkerConfig(reloaded, client.ID, staticWorkerConfig{}).GetModelAndAPIKey()
+	if apiKey != "client-key" || model != "global-model" {
+		t.Errorf("with overlay got %q/%q, want client-key/global-model", apiKey, model)
+	}
+	if ns := reloaded.CacheNamespace(client.ID); ns != "tenant-a" {
+		t.Errorf("CacheNamespace() = %q, want tenant-a", ns)
+	}
+
+	if err := reloaded.SaveOverlay(client.ID+1000, overlay, "tester"); err == nil {
+		t.Error("expected error for unknown client")
+	}
+
+	if err := reloaded.DeleteOverlay(client.ID); err != nil {
+		t.Fatalf("DeleteOverlay() error = %v", err)
+	}
+	if apiKey, _, _ := NewClientWorkerConfig(reloaded, client.ID, staticWorkerConfig{}).GetModelAndAPIKey(); apiKey != "global-key" {
+		t.Errorf("after delete got %q, want global-key", apiKey)
+	}
+}
+
+// TestClientConfigService_ArliaiOnlyAndMaxWorkers проверяет, что ключи других провайдеров
+// не подставляются вместо Arliai, а max_workers ограничивает количество воркеров
+func TestClientConfigService_ArliaiOnlyAndMaxWorkers(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	service := NewClientConfigService(serviceDB)
+	if got := service.MaxWorkers(client.ID, 8); got != 8 {
+		t.Errorf("MaxWorkers() without overlay = %d, want 8", got)
+	}
+
+	workers := 3
+	overlay := &config.ClientConfigOverlay{
+		Providers:  map[string]*config.ClientProviderOverride{"openrouter": {APIKey: "openrouter-key", Model: "gpt"}},
+		MaxWorkers: &workers,
+	}
+	if err := service.SaveOverlay(client.ID, overlay, "tester"); err != nil {
+		t.Fatalf("SaveOverlay() error = %v", err)
+	}
+
+	apiKey, model, _ := NewClientWorkerConfig(service, client.ID, staticWorkerConfig{}).GetModelAndAPIKey()
+	if apiKey != "global-key" || model != "global-model" {
+		t.Errorf("with openrouter overlay got %q/%q, want global arliai values", apiKey, model)
+	}
+	if got := service.MaxWorkers(client.ID, 8); got != 3 {
+		t.Errorf("MaxWorkers(8) = %d, want 3", got)
+	}
+	if got := service.MaxWorkers(client.ID, 2); got != 2 {
+		t.Errorf("MaxWorkers(2) = %d, want 2", got)
+	}
+}
+
+// TestClientConfigService_AllowUpload проверяет клиентский лимит частоты выгрузок
+func TestClientConfigService_AllowUpload(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	service := NewClientConfigService(serviceDB)
+	for i := 0; i < 5; i++ {
+		if ok, _ := service.AllowUpload(client.ID); !ok {
+			t.Fatal("upload must not be limited without overlay")
+		}
+	}
+
+	overlay := &config.ClientConfigOverlay{UploadRateLimit: &config.ClientRateLimit{RequestsPerMinute: 1, Burst: 2}}
+	if err := service.SaveOverlay(client.ID, overlay, ""); err != nil {
+		t.Fatalf("SaveOverlay() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := service.AllowUpload(client.ID); !ok {
+			t.Fatalf("request %d within burst must be allowed", i+1)
+		}
+	}
+	ok, retryAfter := service.AllowUpload(client.ID)
+	if ok {
+		t.Fatal("request above burst must be limited")
+	}
+	if retryAfter <= 0 {
+		t.Errorf("retryAfter = %v, want positive", retryAfter)
+	}
+
+	// Другой клиент не затрагивается
+	if ok, _ := service.AllowUpload(client.ID + 1); !ok {
+		t.Error("limit must be per client")
+	}
+}
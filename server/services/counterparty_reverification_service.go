@@ -0,0 +1,480 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"httpserver/database"
+	"httpserver/enrichment"
+	apperrors "httpserver/server/errors"
+)
+
+// ReverificationEnricher источник данных реестров для повторной сверки (реализуется enrichment.EnricherFactory)
+type ReverificationEnricher interface {
+	Enrich(inn, bin string) *enrichment.EnrichmentResponse
+	GetBestResult(results []*enrichment.EnrichmentResult) *enrichment.EnrichmentResult
+}
+
+// CounterpartyReverificationOptions параметры расписания сверки
+type CounterpartyReverificationOptions struct {
+	MaxAge         time.Duration // срок актуальности данных при нулевом риске
+	HighRiskMaxAge time.Duration // срок актуальности данных при максимальном риске
+	BatchSize      int           // контрагентов за один запуск
+	ApplyChanges   bool          // записывать новые реквизиты в контрагента
+}
+
+// CounterpartyReverificationAlert уведомление о значимых изменениях контрагента
+type CounterpartyReverificationAlert struct {
+	Counterparty   *database.NormalizedCounterparty         `json:"counterparty"`
+	Severity       string                                   `json:"severity"`
+	PreviousStatus string                                   `json:"previous_status"`
+	Status         string                                   `json:"status"`
+	Changes        []*database.CounterpartyEnrichmentChange `json:"changes"`
+}
+
+// CounterpartyReverificationOutcome результат сверки одного контрагента
+type CounterpartyReverificationOutcome struct {
+	CounterpartyID int                                      `json:"counterparty_id"`
+	PreviousStatus string                                   `json:"previous_status"`
+	Status         string                                   `json:"status"`
+	RiskScore      float64                                  `json:"risk_score"`
+	NextVerifyAt   time.Time                                `json:"next_verify_at"`
+	Source         string                                   `json:"source,omitempty"`
+	Changes        []*database.CounterpartyEnrichmentChange `json:"changes"`
+	Error          string                                   `json:"error,omitempty"`
+}
+
+// CounterpartyReverificationRun итог запуска сверки
+type CounterpartyReverificationRun struct {
+	StartedAt    time.Time                            `json:"started_at"`
+	FinishedAt   time.Time                            `json:"finished_at"`
+	Checked      int                                  `json:"checked"`
+	Changed      int                                  `json:"changed"`
+	StatusAlerts int                                  `json:"status_alerts"`
+	Failed       int                                  `json:"failed"`
+	Outcomes     []*CounterpartyReverificationOutcome `json:"outcomes"` // только с изменениями или ошибками
+}
+
+// CounterpartyReverificationService повторно обогащает нормализованных контрагентов по расписанию,
+// сравнивает ответ реестров с сохраненными данными и сообщает о смене статуса юрлица
+type CounterpartyReverificationService struct {
+	serviceDB *database.ServiceDB
+	enricher  ReverificationEnricher
+	opts      CounterpartyReverificationOptions
+	onAlert   func(*CounterpartyReverificationAlert)
+	now       func() time.Time
+
+	mu      sync.Mutex
+	running bool
+	lastRun *CounterpartyReverificationRun
+}
+
+// NewCounterpartyReverificationService создает сервис повторной сверки контрагентов
+func NewCounterpartyReverificationService(serviceDB *database.ServiceDB, enricher ReverificationEnricher, opts CounterpartyReverificationOptions) *CounterpartyReverificationService {
+	if opts.HighRiskMaxAge <= 0 {
+		opts.HighRiskMaxAge = 7 * 24 * time.Hour
+	}
+	if opts.MaxAge < opts.HighRiskMaxAge {
+		opts.MaxAge = 30 * 24 * time.Hour
+		if opts.MaxAge < opts.HighRiskMaxAge {
+			opts.MaxAge = opts.HighRiskMaxAge
+		}
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	return &CounterpartyReverificationService{
+		serviceDB: serviceDB,
+		enricher:  enricher,
+		opts:      opts,
+		now:       func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SetAlertHandler задает обработчик уведомлений о значимых изменениях (warning и critical)
+func (s *CounterpartyReverificationService) SetAlertHandler(handler func(*CounterpartyReverificationAlert)) {
+	s.onAlert = handler
+}
+
+// Options возвращает параметры расписания
+func (s *CounterpartyReverificationService) Options() CounterpartyReverificationOptions {
+	return s.opts
+}
+
+// LastRun возвращает итог последнего запуска
+func (s *CounterpartyReverificationService) LastRun() *CounterpartyReverificationRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRun
+}
+
+// RunOnce сверяет очередную порцию контрагентов, срок сверки которых наступил
+func (s *CounterpartyReverificationService) RunOnce() (*CounterpartyReverificationRun, error) {
+	if s.enricher == nil {
+		return nil, apperrors.NewServiceUnavailableError("обогащение контрагентов не настроено", nil)
+	}
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, apperrors.NewConflictError("сверка контрагентов уже выполняется", nil)
+	}
+	s.running = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	run := &CounterpartyReverificationRun{
+		StartedAt: s.now(),
+		Outcomes:  []*CounterpartyReverificationOutcome{},
+	}
+	due, err := s.serviceDB.GetCounterpartiesDueForReverification(run.StartedAt, s.opts.BatchSize)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить контрагентов для сверки", err)
+	}
+
+	for _, cp := range due {
+		outcome, err := s.verify(cp)
+		if err != nil {
+			return nil, err
+		}
+		run.Checked++
+		switch {
+		case outcome.Error != "":
+			run.Failed++
+		case len(outcome.Changes) > 0:
+			run.Changed++
+		}
+		if outcome.Status != outcome.PreviousStatus && enrichment.IsCriticalCompanyStatus(outcome.Status) {
+			run.StatusAlerts++
+		}
+		if outcome.Error != "" || len(outcome.Changes) > 0 {
+			run.Outcomes = append(run.Outcomes, outcome)
+		}
+	}
+
+	run.FinishedAt = s.now()
+	s.mu.Lock()
+	s.lastRun = run
+	s.mu.Unlock()
+	return run, nil
+}
+
+// VerifyCounterparty сверяет одного контрагента вне расписания
+func (s *CounterpartyReverificationService) VerifyCounterparty(id int) (*CounterpartyReverificationOutcome, error) {
+	if s.enricher == nil {
+		return nil, apperrors.NewServiceUnavailableError("обогащение контрагентов не настроено", nil)
+	}
+	cp, err := s.serviceDB.GetNormalizedCounterparty(id)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("контрагент не найден", err)
+	}
+	if cp.TaxID == "" && cp.BIN == "" {
+		return nil, apperrors.NewValidationError("у контрагента не указан ИНН или БИН", nil)
+	}
+	return s.verify(cp)
+}
+
+// GetState возвращает состояние сверки контрагента
+func (s *CounterpartyReverificationService) GetState(id int) (*database.CounterpartyVerificationState, error) {
+	state, err := s.serviceDB.GetCounterpartyVerificationState(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить состояние сверки", err)
+	}
+	if state == nil {
+		return nil, apperrors.NewNotFoundError("контрагент еще не сверялся с реестрами", nil)
+	}
+	return state, nil
+}
+
+// GetChanges возвращает журнал обнаруженных изменений
+func (s *CounterpartyReverificationService) GetChanges(filter database.CounterpartyEnrichmentChangeFilter) ([]*database.CounterpartyEnrichmentChange, int, error) {
+	changes, total, err := s.serviceDB.GetCounterpartyEnrichmentChanges(filter)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalError("не удалось получить журнал изменений", err)
+	}
+	return changes, total, nil
+}
+
+// Summary возвращает количество сверенных контрагентов по статусам
+func (s *CounterpartyReverificationService) Summary() (map[string]int, error) {
+	summary, err := s.serviceDB.GetCounterpartyVerificationSummary()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить сводку сверки", err)
+	}
+	return summary, nil
+}
+
+// verify обогащает контрагента, сравнивает результат с сохраненными данными и планирует следующую сверку.
+// Ошибка реестра не прерывает запуск: она записывается в состояние и результат.
+func (s *CounterpartyReverificationService) verify(cp *database.NormalizedCounterparty) (*CounterpartyReverificationOutcome, error) {
+	now := s.now()
+	state, err := s.serviceDB.GetCounterpartyVerificationState(cp.ID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить состояние сверки", err)
+	}
+	if state == nil {
+		state = &database.CounterpartyVerificationState{
+			CounterpartyID: cp.ID,
+			Status:         enrichment.CompanyStatusUnknown,
+		}
+	}
+	state.ClientProjectID = cp.ClientProjectID
+	outcome := &CounterpartyReverificationOutcome{
+		CounterpartyID: cp.ID,
+		PreviousStatus: state.Status,
+		Status:         state.Status,
+		Changes:        []*database.CounterpartyEnrichmentChange{},
+	}
+
+	result, enrichErr := s.fetch(cp)
+	if enrichErr != nil {
+		state.Failures++
+		state.LastError = enrichErr.Error()
+		// Повторная попытка с нарастающей задержкой, но не позже срока для высокого риска
+		shift := state.Failures - 1
+		if shift > 8 {
+			shift = 8
+		}
+		retry := time.Hour << uint(shift)
+		if retry > s.opts.HighRiskMaxAge {
+			retry = s.opts.HighRiskMaxAge
+		}
+		state.NextVerifyAt = now.Add(retry)
+		outcome.Error = enrichErr.Error()
+		outcome.RiskScore = state.RiskScore
+		outcome.NextVerifyAt = state.NextVerifyAt
+		if err := s.serviceDB.SaveCounterpartyVerificationState(state); err != nil {
+			return nil, apperrors.NewInternalError("не удалось сохранить состояние сверки", err)
+		}
+		return outcome, nil
+	}
+
+	var previous *enrichment.EnrichmentResult
+	if state.LastResult != "" {
+		previous, _ = enrichment.FromJSON(state.LastResult)
+	}
+	newStatus := enrichment.NormalizeCompanyStatus(result.Status)
+	if result.LiquidationDate != nil && newStatus == enrichment.CompanyStatusUnknown {
+		newStatus = enrichment.CompanyStatusLiquidated
+	}
+	changes := diffEnrichmentResult(cp, previous, result, state.Status, newStatus)
+
+	applied := false
+	if s.opts.ApplyChanges {
+		applied = applyEnrichmentChanges(cp, changes)
+		if applied {
+			if err := s.serviceDB.UpdateNormalizedCounterparty(cp.ID, cp.NormalizedName, cp.TaxID, cp.KPP, cp.BIN,
+				cp.LegalAddress, cp.PostalAddress, cp.ContactPhone, cp.ContactEmail, cp.ContactPerson, cp.LegalForm,
+				cp.BankName, cp.BankAccount, cp.CorrespondentAccount, cp.BIK, cp.QualityScore, result.Source, cp.Subcategory); err != nil {
+				return nil, apperrors.NewInternalError("не удалось обновить контрагента", err)
+			}
+		}
+	}
+	for _, change := range changes {
+		change.CounterpartyID = cp.ID
+		change.ClientProjectID = cp.ClientProjectID
+		change.Source = result.Source
+		change.DetectedAt = now
+	}
+	if err := s.serviceDB.AddCounterpartyEnrichmentChanges(changes); err != nil {
+		return nil, apperrors.NewInternalError("не удалось записать изменения", err)
+	}
+
+	state.Status = newStatus
+	state.RiskScore = reverificationRisk(cp, newStatus, changes)
+	state.LastVerifiedAt = &now
+	state.NextVerifyAt = now.Add(s.interval(state.RiskScore))
+	state.LastSource = result.Source
+	state.LastResult, _ = result.ToJSON()
+	state.Failures = 0
+	state.LastError = ""
+	if err := s.serviceDB.SaveCounterpartyVerificationState(state); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить состояние сверки", err)
+	}
+
+	outcome.Status = newStatus
+	outcome.RiskScore = state.RiskScore
+	outcome.NextVerifyAt = state.NextVerifyAt
+	outcome.Source = result.Source
+	outcome.Changes = changes
+
+	if severity := maxChangeSeverity(changes); s.onAlert != nil && severity != database.ReverificationSeverityInfo {
+		s.onAlert(&CounterpartyReverificationAlert{
+			Counterparty:   cp,
+			Severity:       severity,
+			PreviousStatus: outcome.PreviousStatus,
+			Status:         newStatus,
+			Changes:        changes,
+		})
+	}
+	return outcome, nil
+}
+
+// fetch запрашивает реестры и выбирает лучший ответ
+func (s *CounterpartyReverificationService) fetch(cp *database.NormalizedCounterparty) (*enrichment.EnrichmentResult, error) {
+	response := s.enricher.Enrich(cp.TaxID, cp.BIN)
+	if response == nil || !response.Success {
+		if response != nil && len(response.Errors) > 0 {
+			return nil, fmt.Errorf("%s", strings.Join(response.Errors, "; "))
+		}
+		return nil, fmt.Errorf("реестры не вернули данных")
+	}
+	best := s.enricher.GetBestResult(response.Results)
+	if best == nil {
+		return nil, fmt.Errorf("реестры не вернули успешного результата")
+	}
+	return best, nil
+}
+
+// interval срок следующей сверки: чем выше риск, тем ближе к HighRiskMaxAge
+func (s *CounterpartyReverificationService) interval(risk float64) time.Duration {
+	span := s.opts.MaxAge - s.opts.HighRiskMaxAge
+	return s.opts.HighRiskMaxAge + time.Duration(float64(span)*(1-risk))
+}
+
+// reverificationRisk оценивает риск контрагента от 0 до 1
+func reverificationRisk(cp *database.NormalizedCounterparty, status string, changes []*database.CounterpartyEnrichmentChange) float64 {
+	switch status {
+	case enrichment.CompanyStatusLiquidating, enrichment.CompanyStatusBankrupt, enrichment.CompanyStatusReorganizing:
+		return 1
+	case enrichment.CompanyStatusLiquidated:
+		// Статус окончательный, частая сверка не нужна
+		return 0.5
+	}
+	risk := 0.1
+	if status == enrichment.CompanyStatusUnknown {
+		risk += 0.2
+	}
+	switch maxChangeSeverity(changes) {
+	case database.ReverificationSeverityWarning, database.ReverificationSeverityCritical:
+		risk += 0.4
+	case database.ReverificationSeverityInfo:
+		if len(changes) > 0 {
+			risk += 0.1
+		}
+	}
+	if cp.QualityScore > 0 && cp.QualityScore < 0.5 {
+		risk += 0.2
+	}
+	if risk > 1 {
+		risk = 1
+	}
+	return risk
+}
+
+// diffEnrichmentResult сравнивает ответ реестров с реквизитами контрагента и предыдущим ответом
+func diffEnrichmentResult(cp *database.NormalizedCounterparty, previous, result *enrichment.EnrichmentResult, oldStatus, newStatus string) []*database.CounterpartyEnrichmentChange {
+	var changes []*database.CounterpartyEnrichmentChange
+	add := func(field, oldValue, newValue, severity string) {
+		changes = append(changes, &database.CounterpartyEnrichmentChange{
+			Field:    field,
+			OldValue: oldValue,
+			NewValue: newValue,
+			Severity: severity,
+		})
+	}
+
+	if newStatus != enrichment.CompanyStatusUnknown && newStatus != oldStatus {
+		severity := database.ReverificationSeverityInfo
+		if enrichment.IsCriticalCompanyStatus(newStatus) {
+			severity = database.ReverificationSeverityCritical
+		} else if oldStatus != enrichment.CompanyStatusUnknown {
+			severity = database.ReverificationSeverityWarning
+		}
+		add("status", oldStatus, newStatus, severity)
+	}
+
+	fields := []struct {
+		name     string
+		local    string
+		remote   string
+		severity string
+		compare  func(string) string
+	}{
+		{"normalized_name", cp.NormalizedName, result.FullName, database.ReverificationSeverityInfo, normalizeCompareText},
+		{"kpp", cp.KPP, result.KPP, database.ReverificationSeverityWarning, normalizeCompareDigits},
+		{"legal_address", cp.LegalAddress, result.LegalAddress, database.ReverificationSeverityWarning, normalizeCompareText},
+		{"contact_phone", cp.ContactPhone, result.Phone, database.ReverificationSeverityInfo, normalizeCompareDigits},
+		{"contact_email", cp.ContactEmail, result.Email, database.ReverificationSeverityInfo, normalizeCompareText},
+	}
+	for _, f := range fields {
+		if strings.TrimSpace(f.remote) == "" || f.compare(f.local) == f.compare(f.remote) {
+			continue
+		}
+		add(f.name, f.local, strings.TrimSpace(f.remote), f.severity)
+	}
+
+	// Руководитель не хранится в контрагенте - сравниваем с предыдущим ответом реестра
+	if previous != nil && result.Director != "" && previous.Director != "" &&
+		normalizeCompareText(previous.Director) != normalizeCompareText(result.Director) {
+		add("director", previous.Director, result.Director, database.ReverificationSeverityWarning)
+	}
+	return changes
+}
+
+// applyEnrichmentChanges переносит в контрагента реквизиты из реестра; наименование
+// и статус остаются результатом нормализации и только фиксируются в журнале
+func applyEnrichmentChanges(cp *database.NormalizedCounterparty, changes []*database.CounterpartyEnrichmentChange) bool {
+	applied := false
+	for _, change := range changes {
+		var target *string
+		switch change.Field {
+		case "kpp":
+			target = &cp.KPP
+		case "legal_address":
+			target = &cp.LegalAddress
+		case "contact_phone":
+			target = &cp.ContactPhone
+		case "contact_email":
+			target = &cp.ContactEmail
+		default:
+			continue
+		}
+		*target = change.NewValue
+		change.Applied = true
+		applied = true
+	}
+	return applied
+}
+
+// maxChangeSeverity возвращает наибольшую важность среди изменений
+func maxChangeSeverity(changes []*database.CounterpartyEnrichmentChange) string {
+	severity := database.ReverificationSeverityInfo
+	for _, change := range changes {
+		switch change.Severity {
+		case database.ReverificationSeverityCritical:
+			return database.ReverificationSeverityCritical
+		case database.ReverificationSeverityWarning:
+			severity = database.ReverificationSeverityWarning
+		}
+	}
+	return severity
+}
+
+// normalizeCompareText приводит строку к виду для сравнения: регистр, ё, пунктуация и пробелы
+func normalizeCompareText(value string) string {
+	value = strings.ReplaceAll(strings.ToLower(value), "ё", "е")
+	return strings.Join(strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// normalizeCompareDigits оставляет только цифры (телефоны, КПП); для телефонов 8 и +7 считаются одинаковыми
+func normalizeCompareDigits(value string) string {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, value)
+	if len(digits) == 11 && digits[0] == '8' {
+		digits = "7" + digits[1:]
+	}
+	return digits
+}
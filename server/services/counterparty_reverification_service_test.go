@@ -0,0 +1,166 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"httpserver/database"
+	"httpserver/enrichment"
+)
+
+func setupReverificationTest(t *testing.T) (*database.ServiceDB, *enrichment.StubEnricher, *enrichment.EnricherFactory, int) {
+	t.Helper()
+	serviceDB, err := database.NewServiceDB(filepath.Join(t.TempDir(), "service.db"))
+	if err != nil {
+		t.Fatalf("Failed to create service DB: %v", err)
+	}
+	t.Cleanup(func() { serviceDB.Close() })
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "counterparty", "", "1c", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject: %v", err)
+	}
+	if err := serviceDB.SaveNormalizedCounterparty(project.ID, "ref-1", "Ромашка", "ООО Ромашка",
+		"7701234567", "770101001", "", "г. Москва, ул. Ленина, 1", "", "8 (495) 123-45-67", "", "", "ООО",
+		"", "", "", "", 0, 0.9, false, "", "base.db", ""); err != nil {
+		t.Fatalf("SaveNormalizedCounterparty: %v", err)
+	}
+	// Контрагент без ИНН/БИН в сверку не попадает
+	if err := serviceDB.SaveNormalizedCounterparty(project.ID, "ref-2", "Без ИНН", "Без ИНН",
+		"", "", "", "", "", "", "", "", "", "", "", "", "", 0, 0.5, false, "", "base.db", ""); err != nil {
+		t.Fatalf("SaveNormalizedCounterparty: %v", err)
+	}
+
+	stub := enrichment.NewStubEnricher("stub", 1)
+	factory := enrichment.NewEnricherFactory(map[string]*enrichment.EnricherConfig{})
+	factory.AddEnricher(stub)
+	return serviceDB, stub, factory, project.ID
+}
+
+func TestCounterpartyReverificationService_RunOnce(t *testing.T) {
+	serviceDB, stub, factory, projectID := setupReverificationTest(t)
+	stub.SetResult("7701234567", &enrichment.EnrichmentResult{
+		Success: true, Confidence: 0.9, INN: "7701234567", KPP: "770101001",
+		FullName: "ООО \"Ромашка\"", LegalAddress: "г. Москва, ул. Тверская, 10",
+		Phone: "+7 495 123-45-67", Status: "ACTIVE", Director: "Иванов И.И.",
+	})
+
+	service := NewCounterpartyReverificationService(serviceDB, factory, CounterpartyReverificationOptions{
+		MaxAge: 30 * 24 * time.Hour, HighRiskMaxAge: 7 * 24 * time.Hour, ApplyChanges: true,
+	})
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	var alerts []*CounterpartyReverificationAlert
+	service.SetAlertHandler(func(alert *CounterpartyReverificationAlert) { alerts = append(alerts, alert) })
+
+	run, err := service.RunOnce()
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if run.Checked != 1 || run.Changed != 1 || run.Failed != 0 {
+		t.Fatalf("unexpected first run: %+v", run)
+	}
+	// Адрес изменился (warning), телефон и наименование совпадают после нормализации
+	changes := run.Outcomes[0].Changes
+	fields := map[string]*database.CounterpartyEnrichmentChange{}
+	for _, change := range changes {
+		fields[change.Field] = change
+	}
+	if fields["legal_address"] == nil || !fields["legal_address"].Applied || fields["contact_phone"] != nil || fields["normalized_name"] != nil {
+		t.Errorf("unexpected changes: %+v", changes)
+	}
+	if fields["status"] == nil || fields["status"].Severity != database.ReverificationSeverityInfo {
+		t.Errorf("first known status must be recorded as info: %+v", fields["status"])
+	}
+	if len(alerts) != 1 || alerts[0].Severity != database.ReverificationSeverityWarning {
+		t.Errorf("expected one warning alert, got %+v", alerts)
+	}
+
+	cp, err := serviceDB.GetNormalizedCounterparty(run.Outcomes[0].CounterpartyID)
+	if err != nil {
+		t.Fatalf("GetNormalizedCounterparty: %v", err)
+	}
+	if cp.LegalAddress != "г. Москва, ул. Тверская, 10" || cp.SourceEnrichment != "stub" {
+		t.Errorf("registry data must be applied: %+v", cp)
+	}
+
+	// До наступления срока повторная сверка не выполняется
+	if run, err = service.RunOnce(); err != nil || run.Checked != 0 {
+		t.Fatalf("expected nothing due, got %+v, %v", run, err)
+	}
+
+	// Компания ликвидируется и сменила руководителя
+	stub.SetResult("7701234567", &enrichment.EnrichmentResult{
+		Success: true, Confidence: 0.9, INN: "7701234567", KPP: "770101001",
+		FullName: "ООО Ромашка", LegalAddress: "г. Москва, ул. Тверская, 10",
+		Status: "LIQUIDATING", Director: "Петров П.П.",
+	})
+	now = now.Add(31 * 24 * time.Hour)
+	alerts = nil
+	run, err = service.RunOnce()
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if run.Checked != 1 || run.StatusAlerts != 1 {
+		t.Fatalf("unexpected run: %+v", run)
+	}
+	if len(alerts) != 1 || alerts[0].Severity != database.ReverificationSeverityCritical ||
+		alerts[0].PreviousStatus != enrichment.CompanyStatusActive || alerts[0].Status != enrichment.CompanyStatusLiquidating {
+		t.Errorf("expected critical status alert, got %+v", alerts)
+	}
+
+	state, err := service.GetState(cp.ID)
+	if err != nil {
+		t.Fatalf("GetState: %v", err)
+	}
+	// Высокий риск - следующая сверка через HighRiskMaxAge
+	if state.RiskScore != 1 || !state.NextVerifyAt.Equal(now.Add(7*24*time.Hour)) {
+		t.Errorf("unexpected state: risk=%v next=%v", state.RiskScore, state.NextVerifyAt)
+	}
+
+	history, total, err := service.GetChanges(database.CounterpartyEnrichmentChangeFilter{ProjectID: projectID, Severity: database.ReverificationSeverityCritical})
+	if err != nil {
+		t.Fatalf("GetChanges: %v", err)
+	}
+	if total != 1 || history[0].Field != "status" {
+		t.Errorf("unexpected critical history: %+v", history)
+	}
+	if _, total, _ := service.GetChanges(database.CounterpartyEnrichmentChangeFilter{CounterpartyID: cp.ID}); total != 4 {
+		t.Errorf("expected 4 recorded changes (status, address, status, director), got %d", total)
+	}
+}
+
+func TestCounterpartyReverificationService_EnrichmentFailure(t *testing.T) {
+	serviceDB, stub, factory, _ := setupReverificationTest(t)
+	service := NewCounterpartyReverificationService(serviceDB, factory, CounterpartyReverificationOptions{})
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	run, err := service.RunOnce()
+	if err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if run.Checked != 1 || run.Failed != 1 || run.Outcomes[0].Error == "" {
+		t.Fatalf("expected failed outcome, got %+v", run)
+	}
+	// Повтор через час, затем через два
+	if !run.Outcomes[0].NextVerifyAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("unexpected retry time: %v", run.Outcomes[0].NextVerifyAt)
+	}
+	now = now.Add(time.Hour)
+	if run, err = service.RunOnce(); err != nil || !run.Outcomes[0].NextVerifyAt.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("expected backoff, got %+v, %v", run, err)
+	}
+	if stub.Calls() != 2 {
+		t.Errorf("Calls() = %d, want 2", stub.Calls())
+	}
+
+	if _, err := NewCounterpartyReverificationService(serviceDB, nil, CounterpartyReverificationOptions{}).RunOnce(); err == nil {
+		t.Error("expected error without enricher")
+	}
+}
@@ -32,6 +32,12 @@ func (a *BenchmarkFinderAdapter) FindBestMatch(name string, entityType string) (
 	return benchmark.Name, true, nil
 }
 
+// Источники слияния контрагентов в журнале слияний
+const (
+	CounterpartyMergeSourceManual         = "manual"
+	CounterpartyMergeSourceDuplicateGroup = "duplicate_group"
+)
+
 // CounterpartyService сервис для управления нормализацией контрагентов
 // АРХИТЕКТУРНАЯ ЗАМЕТКА: normalizerRunning дублируется в Server и NormalizationService.
 // TODO: Централизовать управление состоянием через services.NormalizationStateManager интерфейс.
@@ -173,7 +179,8 @@ func (cs *CounterpartyService) UpdateNormalizedCounterparty(
 
 // GetNormalizedCounterparties получает список нормализованных контрагентов проекта
 func (cs *CounterpartyService) GetNormalizedCounterparties(projectID int, limit, offset int, search, taxID, bin string) ([]*database.NormalizedCounterparty, int, error) {
-	return cs.serviceDB.GetNormalizedCounterparties(projectID, limit, offset, search, taxID, bin)
+	// ServiceDB принимает offset раньше limit
+	return cs.serviceDB.GetNormalizedCounterparties(projectID, offset, limit, search, taxID, bin)
 }
 
 // GetCounterpartyDuplicates получает группы дубликатов контрагентов по проекту
@@ -212,8 +219,14 @@ func (cs *CounterpartyService) GetCounterpartyDuplicates(projectID int) ([]map[s
 }
 
 // MergeCounterpartyDuplicates выполняет слияние дубликатов контрагентов
-// Сохраняет все связи с базами данных из дубликатов в эталонного контрагента
+// Сохраняет все связи с базами данных из дубликатов в эталонного контрагента.
+// Состояние участников до слияния записывается в журнал, слияние можно отменить через UnmergeCounterparties.
 func (cs *CounterpartyService) MergeCounterpartyDuplicates(masterID int, mergeIDs []int) (*database.NormalizedCounterparty, error) {
+	return cs.mergeCounterparties(masterID, mergeIDs, CounterpartyMergeSourceManual, "")
+}
+
+// mergeCounterparties объединяет контрагентов в мастер-контрагента и записывает событие слияния
+func (cs *CounterpartyService) mergeCounterparties(masterID int, mergeIDs []int, source, groupKey string) (*database.NormalizedCounterparty, error) {
 	// Получаем мастер-контрагента
 	master, err := cs.serviceDB.GetNormalizedCounterparty(masterID)
 	if err != nil {
@@ -230,6 +243,21 @@ func (cs *CounterpartyService) MergeCounterpartyDuplicates(masterID int, mergeID
 		masterDatabases = []database.DatabaseSource{}
 	}
 
+	// Снимок мастер-контрагента до слияния
+	masterBefore := *master
+	event := &database.CounterpartyMergeEvent{
+		ProjectID: master.ClientProjectID,
+		MasterID:  masterID,
+		Source:    source,
+		GroupKey:  groupKey,
+		Participants: []*database.CounterpartyMergeParticipant{{
+			CounterpartyID: masterID,
+			Role:           database.CounterpartyMergeRoleMaster,
+			State:          &masterBefore,
+			Links:          masterDatabases,
+		}},
+	}
+
 	// Создаем map для быстрой проверки существующих связей
 	existingLinks := make(map[int]bool) // key: databaseID
 	for _, dbSource := range masterDatabases {
@@ -267,55 +295,45 @@ func (cs *CounterpartyService) MergeCounterpartyDuplicates(masterID int, mergeID
 			}
 		}
 
+		event.MergedIDs = append(event.MergedIDs, mergeID)
+		event.Participants = append(event.Participants, &database.CounterpartyMergeParticipant{
+			CounterpartyID: mergeID,
+			Role:           database.CounterpartyMergeRoleMerged,
+			State:          duplicate,
+			Links:          duplicateDatabases,
+		})
+
 		// Объединяем данные (выбираем максимальный набор данных)
 		// Если поле пустое в эталоне, но заполнено в дубликате, используем значение из дубликата
 		// Если оба заполнены, выбираем более полное значение
-		if master.TaxID == "" && duplicate.TaxID != "" {
-			master.TaxID = duplicate.TaxID
-		}
-		if master.BIN == "" && duplicate.BIN != "" {
-			master.BIN = duplicate.BIN
-		}
-		if master.KPP == "" && duplicate.KPP != "" {
-			master.KPP = duplicate.KPP
-		}
-		if master.LegalAddress == "" && duplicate.LegalAddress != "" {
-			master.LegalAddress = duplicate.LegalAddress
-		} else if master.LegalAddress != "" && duplicate.LegalAddress != "" && len(duplicate.LegalAddress) > len(master.LegalAddress) {
-			// Используем более полный адрес
-			master.LegalAddress = duplicate.LegalAddress
-		}
-		if master.PostalAddress == "" && duplicate.PostalAddress != "" {
-			master.PostalAddress = duplicate.PostalAddress
-		} else if master.PostalAddress != "" && duplicate.PostalAddress != "" && len(duplicate.PostalAddress) > len(master.PostalAddress) {
-			master.PostalAddress = duplicate.PostalAddress
-		}
-		if master.ContactPhone == "" && duplicate.ContactPhone != "" {
-			master.ContactPhone = duplicate.ContactPhone
-		}
-		if master.ContactEmail == "" && duplicate.ContactEmail != "" {
-			master.ContactEmail = duplicate.ContactEmail
-		}
-		if master.ContactPerson == "" && duplicate.ContactPerson != "" {
-			master.ContactPerson = duplicate.ContactPerson
-		} else if master.ContactPerson != "" && duplicate.ContactPerson != "" && len(duplicate.ContactPerson) > len(master.ContactPerson) {
-			master.ContactPerson = duplicate.ContactPerson
-		}
-		if master.BankName == "" && duplicate.BankName != "" {
-			master.BankName = duplicate.BankName
-		}
-		if master.BankAccount == "" && duplicate.BankAccount != "" {
-			master.BankAccount = duplicate.BankAccount
-		}
-		if master.CorrespondentAccount == "" && duplicate.CorrespondentAccount != "" {
-			master.CorrespondentAccount = duplicate.CorrespondentAccount
-		}
-		if master.BIK == "" && duplicate.BIK != "" {
-			master.BIK = duplicate.BIK
-		}
-		if master.LegalForm == "" && duplicate.LegalForm != "" {
-			master.LegalForm = duplicate.LegalForm
+		merge := func(field string, target *string, value string, preferLonger bool) {
+			if value == "" || *target == value {
+				return
+			}
+			if *target == "" || (preferLonger && len(value) > len(*target)) {
+				event.FieldSources = append(event.FieldSources, &database.CounterpartyMergeFieldSource{
+					Field:              field,
+					FromCounterpartyID: mergeID,
+					OldValue:           *target,
+					NewValue:           value,
+				})
+				*target = value
+			}
 		}
+		merge("tax_id", &master.TaxID, duplicate.TaxID, false)
+		merge("bin", &master.BIN, duplicate.BIN, false)
+		merge("kpp", &master.KPP, duplicate.KPP, false)
+		// Используем более полный адрес
+		merge("legal_address", &master.LegalAddress, duplicate.LegalAddress, true)
+		merge("postal_address", &master.PostalAddress, duplicate.PostalAddress, true)
+		merge("contact_phone", &master.ContactPhone, duplicate.ContactPhone, false)
+		merge("contact_email", &master.ContactEmail, duplicate.ContactEmail, false)
+		merge("contact_person", &master.ContactPerson, duplicate.ContactPerson, true)
+		merge("bank_name", &master.BankName, duplicate.BankName, false)
+		merge("bank_account", &master.BankAccount, duplicate.BankAccount, false)
+		merge("correspondent_account", &master.CorrespondentAccount, duplicate.CorrespondentAccount, false)
+		merge("bik", &master.BIK, duplicate.BIK, false)
+		merge("legal_form", &master.LegalForm, duplicate.LegalForm, false)
 	}
 
 	// Обновляем мастер-контрагента
@@ -336,6 +354,13 @@ func (cs *CounterpartyService) MergeCounterpartyDuplicates(masterID int, mergeID
 		return nil, apperrors.NewInternalError("не удалось обновить мастер-контрагента", err)
 	}
 
+	// Записываем событие до удаления дубликатов: без него слияние нельзя будет отменить
+	if len(event.MergedIDs) > 0 {
+		if _, err := cs.serviceDB.CreateCounterpartyMergeEvent(event); err != nil {
+			return nil, apperrors.NewInternalError("не удалось сохранить событие слияния", err)
+		}
+	}
+
 	// Удаляем дубликаты
 	for _, mergeID := range mergeIDs {
 		if mergeID != masterID {
@@ -358,6 +383,60 @@ func (cs *CounterpartyService) MergeCounterpartyDuplicates(masterID int, mergeID
 	return updated, nil
 }
 
+// GetCounterpartyMergeHistory возвращает историю слияний нормализованного контрагента
+func (cs *CounterpartyService) GetCounterpartyMergeHistory(counterpartyID int) ([]*database.CounterpartyMergeEvent, error) {
+	events, err := cs.serviceDB.GetCounterpartyMergeHistory(counterpartyID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить историю слияний", err)
+	}
+	if events == nil {
+		events = []*database.CounterpartyMergeEvent{}
+	}
+	return events, nil
+}
+
+// GetCounterpartyMergeEvent возвращает событие слияния с состоянием участников до слияния
+func (cs *CounterpartyService) GetCounterpartyMergeEvent(eventID int) (*database.CounterpartyMergeEvent, error) {
+	event, err := cs.serviceDB.GetCounterpartyMergeEvent(eventID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить событие слияния", err)
+	}
+	if event == nil {
+		return nil, apperrors.NewNotFoundError("событие слияния не найдено", nil)
+	}
+	return event, nil
+}
+
+// UnmergeCounterparties отменяет слияние: восстанавливает мастер-контрагента и объединенных контрагентов
+// в состоянии до слияния и возвращает им их связи с базами данных
+func (cs *CounterpartyService) UnmergeCounterparties(eventID int, unmergedBy string) (*database.CounterpartyMergeEvent, error) {
+	event, err := cs.GetCounterpartyMergeEvent(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if event.Status != database.CounterpartyMergeStatusMerged {
+		return nil, apperrors.NewConflictError("слияние уже отменено", nil)
+	}
+
+	// Отмена возможна только в обратном порядке: более поздние слияния с теми же контрагентами отменяются первыми
+	participantIDs := append([]int{event.MasterID}, event.MergedIDs...)
+	later, err := cs.serviceDB.HasLaterCounterpartyMerges(eventID, participantIDs)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось проверить последующие слияния", err)
+	}
+	if later {
+		return nil, apperrors.NewConflictError("контрагенты участвуют в более позднем слиянии, сначала отмените его", nil)
+	}
+
+	if err := cs.serviceDB.RevertCounterpartyMerge(eventID, unmergedBy); err != nil {
+		if errors.Is(err, database.ErrCounterpartyMergeConflict) {
+			return nil, apperrors.NewConflictError("не удалось отменить слияние", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось отменить слияние", err)
+	}
+	return cs.GetCounterpartyMergeEvent(eventID)
+}
+
 // GetNormalizedCounterpartiesByClient получает нормализованных контрагентов по клиенту
 func (cs *CounterpartyService) GetNormalizedCounterpartiesByClient(clientID int, projectID *int, offset, limit int, search, enrichment, subcategory string) ([]*database.NormalizedCounterparty, []*database.ClientProject, int, error) {
 	return cs.serviceDB.GetNormalizedCounterpartiesByClient(clientID, projectID, offset, limit, search, enrichment, subcategory)
@@ -782,5 +861,5 @@ func (cs *CounterpartyService) ResolveCounterpartyDuplicateGroup(projectID int,
 	}
 
 	// Объединяем дубликаты в мастер-контрагента
-	return cs.MergeCounterpartyDuplicates(master.ID, mergeIDs)
+	return cs.mergeCounterparties(master.ID, mergeIDs, CounterpartyMergeSourceDuplicateGroup, groupID)
 }
\ No newline at end of file
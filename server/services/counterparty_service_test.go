@@ -186,4 +186,112 @@ func TestCounterpartyService_GetNormalizedCounterpartyStats(t *testing.T) {
 	}
 }
 
+// TestCounterpartyService_MergeAndUnmerge проверяет журнал слияний и восстановление контрагентов при отмене
+func TestCounterpartyService_MergeAndUnmerge(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "counterparty", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	db1, err := serviceDB.CreateProjectDatabase(project.ID, "main", t.TempDir()+"/main.db", "", 0)
+	if err != nil {
+		t.Fatalf("CreateProjectDatabase() error = %v", err)
+	}
+	db2, err := serviceDB.CreateProjectDatabase(project.ID, "branch", t.TempDir()+"/branch.db", "", 0)
+	if err != nil {
+		t.Fatalf("CreateProjectDatabase() error = %v", err)
+	}
+
+	save := func(ref, kpp, address string, score float64) *database.NormalizedCounterparty {
+		if err := serviceDB.SaveNormalizedCounterparty(project.ID, ref, "ООО Ромашка", "ООО \"Ромашка\"",
+			"7701234567", kpp, "", address, "", "", "", "", "ООО", "", "", "", "", 0, score, false, "", "", ""); err != nil {
+			t.Fatalf("SaveNormalizedCounterparty() error = %v", err)
+		}
+		items, _, err := serviceDB.GetNormalizedCounterparties(project.ID, 0, 10, "", "", "")
+		if err != nil {
+			t.Fatalf("GetNormalizedCounterparties() error = %v", err)
+		}
+		for _, cp := range items {
+			if cp.SourceReference == ref {
+				return cp
+			}
+		}
+		t.Fatalf("counterparty %s not saved", ref)
+		return nil
+	}
+	master := save("ref-1", "770101001", "", 0.9)
+	branch := save("ref-2", "770102002", "г. Москва, ул. Ленина, д. 1", 0.5)
+	if err := serviceDB.SaveCounterpartyDatabaseLink(master.ID, db1.ID, "ref-1", "ООО Ромашка"); err != nil {
+		t.Fatalf("SaveCounterpartyDatabaseLink() error = %v", err)
+	}
+	if err := serviceDB.SaveCounterpartyDatabaseLink(branch.ID, db2.ID, "ref-2", "ООО Ромашка"); err != nil {
+		t.Fatalf("SaveCounterpartyDatabaseLink() error = %v", err)
+	}
+
+	service := NewCounterpartyService(serviceDB, make(chan string, 10), nil)
+	merged, err := service.ResolveCounterpartyDuplicateGroup(project.ID, "7701234567")
+	if err != nil {
+		t.Fatalf("ResolveCounterpartyDuplicateGroup() error = %v", err)
+	}
+	if merged.ID != master.ID || merged.LegalAddress != branch.LegalAddress || merged.KPP != master.KPP {
+		t.Errorf("merged counterparty = %+v", merged)
+	}
+	if _, err := serviceDB.GetNormalizedCounterparty(branch.ID); err == nil {
+		t.Error("merged duplicate should be deleted")
+	}
+
+	history, err := service.GetCounterpartyMergeHistory(master.ID)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("GetCounterpartyMergeHistory() = %d events, err %v, want 1", len(history), err)
+	}
+	event := history[0]
+	if event.Source != CounterpartyMergeSourceDuplicateGroup || len(event.MergedIDs) != 1 || event.MergedIDs[0] != branch.ID {
+		t.Errorf("merge event = %+v", event)
+	}
+	var addressSource *database.CounterpartyMergeFieldSource
+	for _, fs := range event.FieldSources {
+		if fs.Field == "legal_address" {
+			addressSource = fs
+		}
+	}
+	if addressSource == nil || addressSource.FromCounterpartyID != branch.ID || addressSource.OldValue != "" {
+		t.Errorf("legal_address provenance = %+v, want taken from %d", addressSource, branch.ID)
+	}
+
+	if _, err := service.UnmergeCounterparties(event.ID, "tester"); err != nil {
+		t.Fatalf("UnmergeCounterparties() error = %v", err)
+	}
+	restored, err := serviceDB.GetNormalizedCounterparty(branch.ID)
+	if err != nil {
+		t.Fatalf("restored counterparty not found: %v", err)
+	}
+	if restored.KPP != branch.KPP || restored.SourceReference != "ref-2" {
+		t.Errorf("restored counterparty = %+v", restored)
+	}
+	masterAfter, _ := serviceDB.GetNormalizedCounterparty(master.ID)
+	if masterAfter.LegalAddress != "" {
+		t.Errorf("master legal_address after unmerge = %q, want empty", masterAfter.LegalAddress)
+	}
+	links, _ := serviceDB.GetCounterpartyDatabases(branch.ID)
+	if len(links) != 1 || links[0].DatabaseID != db2.ID {
+		t.Errorf("restored links = %+v, want link to database %d", links, db2.ID)
+	}
+	masterLinks, _ := serviceDB.GetCounterpartyDatabases(master.ID)
+	if len(masterLinks) != 1 || masterLinks[0].DatabaseID != db1.ID {
+		t.Errorf("master links after unmerge = %+v, want only database %d", masterLinks, db1.ID)
+	}
 
+	if _, err := service.UnmergeCounterparties(event.ID, "tester"); err == nil {
+		t.Error("second unmerge should fail")
+	}
+	history, _ = service.GetCounterpartyMergeHistory(branch.ID)
+	if len(history) != 1 || history[0].Status != database.CounterpartyMergeStatusUnmerged || history[0].UnmergedBy != "tester" {
+		t.Errorf("history of restored counterparty = %+v", history)
+	}
+}
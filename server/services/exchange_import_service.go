@@ -0,0 +1,685 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"httpserver/database"
+	"httpserver/server/types"
+)
+
+// Статусы импорта файла обмена
+const (
+	ExchangeImportStatusPending    = "pending"
+	ExchangeImportStatusInProgress = "in_progress"
+	ExchangeImportStatusCompleted  = "completed"
+	ExchangeImportStatusFailed     = "failed"
+)
+
+// DefaultExchangeImportBatchSize размер пакета элементов, передаваемых в UploadService
+const DefaultExchangeImportBatchSize = 500
+
+// maxExchangeImportHistory сколько завершенных импортов хранится для GetImports;
+// более старые вытесняются, импорты в работе не удаляются
+const maxExchangeImportHistory = 100
+
+// ExchangeImportOptions параметры импорта файла обмена
+type ExchangeImportOptions struct {
+	// DatabaseID переопределяет database_id из заголовка файла (как в handshake)
+	DatabaseID string
+	// IterationLabel переопределяет метку итерации из заголовка файла
+	IterationLabel string
+	// UploadPurpose переопределяет назначение выгрузки
+	UploadPurpose string
+}
+
+// ExchangeImportProgress прогресс импорта одного файла обмена
+type ExchangeImportProgress struct {
+	FileName          string     `json:"file_name"`
+	UploadUUID        string     `json:"upload_uuid,omitempty"`
+	DatabaseID        *int       `json:"database_id,omitempty"`
+	ClientName        string     `json:"client_name,omitempty"`
+	ProjectName       string     `json:"project_name,omitempty"`
+	IdentifiedBy      string     `json:"identified_by,omitempty"`
+	Status            string     `json:"status"`
+	Constants         int        `json:"constants"`
+	Catalogs          int        `json:"catalogs"`
+	CatalogItems      int        `json:"catalog_items"`
+	NomenclatureItems int        `json:"nomenclature_items"`
+	FailedItems       int        `json:"failed_items"`
+	BytesRead         int64      `json:"bytes_read"`
+	TotalBytes        int64      `json:"total_bytes"`
+	Progress          float64    `json:"progress"`
+	StartedAt         time.Time  `json:"started_at"`
+	CompletedAt       *time.Time `json:"completed_at,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// ExchangeImportService импортирует файлы обмена 1С (XML), доставленные файловым обменом.
+//
+// Файл обмена содержит те же XML-сообщения, что 1С отправляет по HTTP, внутри корневого элемента:
+//
+//	<exchange_file>
+//	  <handshake>...</handshake>
+//	  <constant>...</constant>
+//	  <catalog_meta>...</catalog_meta>
+//	  <catalog_items><catalog_name>...</catalog_name><items><item>...</item></items></catalog_items>
+//	  <catalog_item>...</catalog_item>
+//	  <nomenclature_batch><items><item>...</item></items></nomenclature_batch>
+//	  <complete/>
+//	</exchange_file>
+//
+// Такой файл записывает обработка 1С в режиме выгрузки в файл (реквизит ФайлОбмена),
+// см. docs/EXCHANGE_IMPORT.md. Файл читается потоково, элементы передаются в UploadService
+// пакетами, поэтому размер файла не ограничен объемом памяти.
+type ExchangeImportService struct {
+	uploadService *UploadService
+	logFunc       func(entry interface{})
+	batchSize     int
+
+	mu      sync.RWMutex
+	imports map[string]*ExchangeImportProgress // file name -> progress
+
+	onComplete func(upload *database.Upload)
+}
+
+// NewExchangeImportService создает сервис импорта файлов обмена
+func NewExchangeImportService(uploadService *UploadService, logFunc func(entry interface{})) *ExchangeImportService {
+	if logFunc == nil {
+		logFunc = func(entry interface{}) {}
+	}
+	return &ExchangeImportService{
+		uploadService: uploadService,
+		logFunc:       logFunc,
+		batchSize:     DefaultExchangeImportBatchSize,
+		imports:       make(map[string]*ExchangeImportProgress),
+	}
+}
+
+// SetBatchSize устанавливает размер пакета элементов
+func (s *ExchangeImportService) SetBatchSize(size int) {
+	if size > 0 {
+		s.batchSize = size
+	}
+}
+
+// SetCompletionHook устанавливает функцию, вызываемую после завершения выгрузки
+// (аналог обработки /complete, например запуск анализа качества)
+func (s *ExchangeImportService) SetCompletionHook(hook func(upload *database.Upload)) {
+	s.onComplete = hook
+}
+
+// GetImports возвращает прогресс всех импортов, начиная с последних
+func (s *ExchangeImportService) GetImports() []ExchangeImportProgress {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]ExchangeImportProgress, 0, len(s.imports))
+	for _, p := range s.imports {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].StartedAt.After(result[j].StartedAt) })
+	return result
+}
+
+// pruneImportsLocked удаляет самые старые завершенные импорты сверх maxExchangeImportHistory.
+// Вызывается под s.mu.
+func (s *ExchangeImportService) pruneImportsLocked() {
+	if len(s.imports) <= maxExchangeImportHistory {
+		return
+	}
+	finished := make([]string, 0, len(s.imports))
+	for name, p := range s.imports {
+		if p.CompletedAt != nil {
+			finished = append(finished, name)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return s.imports[finished[i]].CompletedAt.Before(*s.imports[finished[j]].CompletedAt)
+	})
+	for _, name := range finished {
+		if len(s.imports) <= maxExchangeImportHistory {
+			break
+		}
+		delete(s.imports, name)
+	}
+}
+
+// ImportFile импортирует файл обмена с диска
+func (s *ExchangeImportService) ImportFile(ctx context.Context, path string, opts ExchangeImportOptions) (*ExchangeImportProgress, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exchange file: %w", err)
+	}
+	defer f.Close()
+
+	var size int64
+	if info, err := f.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	return s.ImportReader(ctx, f, filepath.Base(path), size, opts)
+}
+
+// ImportReader импортирует файл обмена из потока. size может быть 0, если размер неизвестен.
+func (s *ExchangeImportService) ImportReader(ctx context.Context, r io.Reader, fileName string, size int64, opts ExchangeImportOptions) (*ExchangeImportProgress, error) {
+	progress := &ExchangeImportProgress{
+		FileName:   fileName,
+		Status:     ExchangeImportStatusInProgress,
+		TotalBytes: size,
+		StartedAt:  time.Now(),
+	}
+	s.mu.Lock()
+	s.imports[fileName] = progress
+	s.mu.Unlock()
+
+	imp := &exchangeImport{
+		service:  s,
+		ctx:      ctx,
+		decoder:  xml.NewDecoder(r),
+		opts:     opts,
+		progress: progress,
+		catalogs: make(map[string]bool),
+	}
+	imp.decoder.Strict = false
+
+	err := imp.run()
+
+	s.mu.Lock()
+	now := time.Now()
+	progress.CompletedAt = &now
+	if err != nil {
+		progress.Status = ExchangeImportStatusFailed
+		progress.Error = err.Error()
+	} else {
+		progress.Status = ExchangeImportStatusCompleted
+		progress.Progress = 100
+	}
+	result := *progress
+	s.pruneImportsLocked()
+	s.mu.Unlock()
+
+	level, message := "INFO", fmt.Sprintf("Exchange file %s imported: %d catalog items, %d nomenclature items, %d failed",
+		fileName, result.CatalogItems, result.NomenclatureItems, result.FailedItems)
+	if err != nil {
+		level, message = "ERROR", fmt.Sprintf("Exchange file %s import failed: %v", fileName, err)
+	}
+	s.logFunc(types.LogEntry{
+		Timestamp:  time.Now(),
+		Level:      level,
+		Message:    message,
+		UploadUUID: result.UploadUUID,
+		Endpoint:   "/exchange-import",
+	})
+
+	if err != nil {
+		return &result, err
+	}
+	return &result, nil
+}
+
+// exchangeImport состояние разбора одного файла
+type exchangeImport struct {
+	service  *ExchangeImportService
+	ctx      context.Context
+	decoder  *xml.Decoder
+	opts     ExchangeImportOptions
+	progress *ExchangeImportProgress
+
+	uploadUUID string
+	completed  bool
+	catalogs   map[string]bool
+}
+
+// run выполняет потоковый разбор файла
+func (imp *exchangeImport) run() error {
+	for {
+		if err := imp.ctx.Err(); err != nil {
+			return err
+		}
+
+		token, err := imp.decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read exchange file: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "exchange_file":
+			// Корневой элемент - продолжаем разбор вложенных элементов
+		case "handshake":
+			if err := imp.handleHandshake(start); err != nil {
+				return err
+			}
+		case "constant":
+			if err := imp.handleConstant(start); err != nil {
+				return err
+			}
+		case "catalog_meta":
+			if err := imp.handleCatalogMeta(start); err != nil {
+				return err
+			}
+		case "catalog_items":
+			if err := imp.handleCatalogItems(); err != nil {
+				return err
+			}
+		case "catalog_item":
+			if err := imp.handleCatalogItem(start); err != nil {
+				return err
+			}
+		case "nomenclature_batch":
+			if err := imp.handleNomenclatureBatch(); err != nil {
+				return err
+			}
+		case "complete":
+			if err := imp.decoder.Skip(); err != nil {
+				return fmt.Errorf("failed to read complete element: %w", err)
+			}
+			if err := imp.complete(); err != nil {
+				return err
+			}
+		default:
+			if err := imp.decoder.Skip(); err != nil {
+				return fmt.Errorf("failed to skip element %s: %w", start.Name.Local, err)
+			}
+		}
+	}
+
+	if imp.uploadUUID == "" {
+		return errors.New("exchange file does not contain handshake element")
+	}
+	// Файл целиком доставлен - завершаем выгрузку, даже если элемента complete нет
+	if !imp.completed {
+		return imp.complete()
+	}
+	return nil
+}
+
+// requireUpload проверяет, что handshake уже обработан
+func (imp *exchangeImport) requireUpload(element string) error {
+	if imp.uploadUUID == "" {
+		return fmt.Errorf("element %s found before handshake", element)
+	}
+	return nil
+}
+
+// handleHandshake создает выгрузку по тем же правилам определения DatabaseID, что и /handshake
+func (imp *exchangeImport) handleHandshake(start xml.StartElement) error {
+	if imp.uploadUUID != "" {
+		return errors.New("exchange file contains more than one handshake")
+	}
+
+	var req types.HandshakeRequest
+	if err := imp.decoder.DecodeElement(&req, &start); err != nil {
+		return fmt.Errorf("failed to parse handshake: %w", err)
+	}
+	if imp.opts.DatabaseID != "" {
+		req.DatabaseID = imp.opts.DatabaseID
+	}
+	if imp.opts.IterationLabel != "" {
+		req.IterationLabel = imp.opts.IterationLabel
+	}
+	if imp.opts.UploadPurpose != "" {
+		req.UploadPurpose = imp.opts.UploadPurpose
+	}
+
+	result, err := imp.service.uploadService.ProcessHandshake(req)
+	if err != nil {
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	imp.uploadUUID = result.UploadUUID
+
+	imp.updateProgress(func(p *ExchangeImportProgress) {
+		p.UploadUUID = result.UploadUUID
+		p.DatabaseID = result.DatabaseID
+		p.ClientName = result.ClientName
+		p.ProjectName = result.ProjectName
+		p.IdentifiedBy = result.IdentifiedBy
+	})
+
+	imp.service.logFunc(types.LogEntry{
+		Timestamp:  time.Now(),
+		Level:      "INFO",
+		Message:    fmt.Sprintf("Exchange file %s: upload %s created (database_id: %v, identified_by: %s)", imp.progress.FileName, result.UploadUUID, result.DatabaseID, result.IdentifiedBy),
+		UploadUUID: result.UploadUUID,
+		Endpoint:   "/exchange-import",
+	})
+	return nil
+}
+
+// handleConstant обрабатывает константу
+func (imp *exchangeImport) handleConstant(start xml.StartElement) error {
+	if err := imp.requireUpload("constant"); err != nil {
+		return err
+	}
+	var req types.ConstantRequest
+	if err := imp.decoder.DecodeElement(&req, &start); err != nil {
+		return fmt.Errorf("failed to parse constant: %w", err)
+	}
+	if err := imp.service.uploadService.ProcessConstant(imp.uploadUUID, req.Name, req.Synonym, req.Type, req.Value.Content); err != nil {
+		return fmt.Errorf("failed to add constant %s: %w", req.Name, err)
+	}
+	imp.updateProgress(func(p *ExchangeImportProgress) { p.Constants++ })
+	return nil
+}
+
+// handleCatalogMeta обрабатывает метаданные справочника
+func (imp *exchangeImport) handleCatalogMeta(start xml.StartElement) error {
+	if err := imp.requireUpload("catalog_meta"); err != nil {
+		return err
+	}
+	var req types.CatalogMetaRequest
+	if err := imp.decoder.DecodeElement(&req, &start); err != nil {
+		return fmt.Errorf("failed to parse catalog_meta: %w", err)
+	}
+	return imp.ensureCatalog(req.Name, req.Synonym)
+}
+
+// ensureCatalog регистрирует справочник один раз на выгрузку
+func (imp *exchangeImport) ensureCatalog(name, synonym string) error {
+	if imp.catalogs[name] {
+		return nil
+	}
+	if _, err := imp.service.uploadService.ProcessCatalogMeta(imp.uploadUUID, name, synonym); err != nil {
+		return fmt.Errorf("failed to add catalog %s: %w", name, err)
+	}
+	imp.catalogs[name] = true
+	imp.updateProgress(func(p *ExchangeImportProgress) { p.Catalogs++ })
+	return nil
+}
+
+// handleCatalogItems потоково разбирает элементы справочника и передает их пакетами
+func (imp *exchangeImport) handleCatalogItems() error {
+	if err := imp.requireUpload("catalog_items"); err != nil {
+		return err
+	}
+
+	var catalogName string
+	batch := make([]types.CatalogItem, 0, imp.service.batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if catalogName == "" {
+			return errors.New("catalog_items without catalog_name")
+		}
+		if err := imp.ensureCatalog(catalogName, ""); err != nil {
+			return err
+		}
+		processed, failed, err := imp.service.uploadService.ProcessCatalogItemsBatch(imp.uploadUUID, catalogName, batch)
+		if err != nil {
+			return fmt.Errorf("failed to add catalog items: %w", err)
+		}
+		imp.updateProgress(func(p *ExchangeImportProgress) {
+			p.CatalogItems += processed
+			p.FailedItems += failed
+		})
+		batch = batch[:0]
+		return imp.ctx.Err()
+	}
+
+	for {
+		token, err := imp.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read catalog_items: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "catalog_name":
+				if err := imp.decoder.DecodeElement(&catalogName, &t); err != nil {
+					return fmt.Errorf("failed to parse catalog_name: %w", err)
+				}
+				catalogName = strings.TrimSpace(catalogName)
+			case "items", "upload_uuid":
+				if t.Name.Local == "upload_uuid" {
+					if err := imp.decoder.Skip(); err != nil {
+						return err
+					}
+				}
+			case "item":
+				var item types.CatalogItem
+				if err := imp.decoder.DecodeElement(&item, &t); err != nil {
+					return fmt.Errorf("failed to parse catalog item: %w", err)
+				}
+				batch = append(batch, item)
+				if len(batch) >= imp.service.batchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			default:
+				if err := imp.decoder.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "catalog_items" {
+				return flush()
+			}
+		}
+	}
+}
+
+// handleCatalogItem обрабатывает одиночный элемент справочника (выгрузка без пакетов).
+// Ошибка сохранения элемента, как и в пакете, учитывается в FailedItems и не прерывает импорт.
+func (imp *exchangeImport) handleCatalogItem(start xml.StartElement) error {
+	if err := imp.requireUpload("catalog_item"); err != nil {
+		return err
+	}
+	var req types.CatalogItemRequest
+	if err := imp.decoder.DecodeElement(&req, &start); err != nil {
+		return fmt.Errorf("failed to parse catalog_item: %w", err)
+	}
+	catalogName := strings.TrimSpace(req.CatalogName)
+	if catalogName == "" {
+		return errors.New("catalog_item without catalog_name")
+	}
+	if err := imp.ensureCatalog(catalogName, ""); err != nil {
+		return err
+	}
+	err := imp.service.uploadService.ProcessCatalogItem(imp.uploadUUID, catalogName, req.Reference, req.Code, req.Name, req.Attributes, req.TableParts)
+	imp.updateProgress(func(p *ExchangeImportProgress) {
+		if err != nil {
+			p.FailedItems++
+		} else {
+			p.CatalogItems++
+		}
+	})
+	return nil
+}
+
+// handleNomenclatureBatch потоково разбирает номенклатуру с характеристиками
+func (imp *exchangeImport) handleNomenclatureBatch() error {
+	if err := imp.requireUpload("nomenclature_batch"); err != nil {
+		return err
+	}
+
+	batch := make([]types.NomenclatureItem, 0, imp.service.batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		processed, err := imp.service.uploadService.ProcessNomenclatureBatch(imp.uploadUUID, batch)
+		if err != nil {
+			return fmt.Errorf("failed to add nomenclature items: %w", err)
+		}
+		imp.updateProgress(func(p *ExchangeImportProgress) { p.NomenclatureItems += processed })
+		batch = batch[:0]
+		return imp.ctx.Err()
+	}
+
+	for {
+		token, err := imp.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read nomenclature_batch: %w", err)
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "items":
+				// Контейнер элементов - разбираем вложенные item
+			case "item":
+				var item types.NomenclatureItem
+				if err := imp.decoder.DecodeElement(&item, &t); err != nil {
+					return fmt.Errorf("failed to parse nomenclature item: %w", err)
+				}
+				batch = append(batch, item)
+				if len(batch) >= imp.service.batchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			default:
+				if err := imp.decoder.Skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "nomenclature_batch" {
+				return flush()
+			}
+		}
+	}
+}
+
+// complete завершает выгрузку так же, как /complete
+func (imp *exchangeImport) complete() error {
+	if err := imp.requireUpload("complete"); err != nil {
+		return err
+	}
+	if imp.completed {
+		return nil
+	}
+	upload, err := imp.service.uploadService.ProcessCompleteWithUpload(imp.uploadUUID)
+	if err != nil {
+		return fmt.Errorf("failed to complete upload: %w", err)
+	}
+	imp.completed = true
+	if imp.service.onComplete != nil {
+		imp.service.onComplete(upload)
+	}
+	return nil
+}
+
+// updateProgress изменяет прогресс под блокировкой и пересчитывает процент по прочитанным байтам
+func (imp *exchangeImport) updateProgress(update func(p *ExchangeImportProgress)) {
+	imp.service.mu.Lock()
+	defer imp.service.mu.Unlock()
+	update(imp.progress)
+	imp.progress.BytesRead = imp.decoder.InputOffset()
+	if imp.progress.TotalBytes > 0 {
+		pct := float64(imp.progress.BytesRead) / float64(imp.progress.TotalBytes) * 100
+		if pct > 99 {
+			pct = 99 // 100% выставляется после завершения выгрузки
+		}
+		imp.progress.Progress = pct
+	}
+}
+
+// WatchDirectory периодически проверяет каталог и импортирует появившиеся *.xml файлы.
+// Файл берется в работу, когда его размер не менялся между двумя проверками (передача завершена).
+// Обработанные файлы перемещаются в подкаталог processed, ошибочные - в failed.
+// Блокируется до отмены ctx.
+func (s *ExchangeImportService) WatchDirectory(ctx context.Context, dir string, interval time.Duration, opts ExchangeImportOptions) error {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	processedDir := filepath.Join(dir, "processed")
+	failedDir := filepath.Join(dir, "failed")
+	for _, d := range []string{processedDir, failedDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", d, err)
+		}
+	}
+
+	lastSizes := make(map[string]int64)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.scanDirectory(ctx, dir, processedDir, failedDir, lastSizes, opts)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// scanDirectory выполняет одну проверку каталога
+func (s *ExchangeImportService) scanDirectory(ctx context.Context, dir, processedDir, failedDir string, lastSizes map[string]int64, opts ExchangeImportOptions) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		s.logFunc(types.LogEntry{
+			Timestamp: time.Now(),
+			Level:     "ERROR",
+			Message:   fmt.Sprintf("Failed to read exchange directory %s: %v", dir, err),
+			Endpoint:  "/exchange-import",
+		})
+		return
+	}
+
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".xml") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		name := entry.Name()
+		seen[name] = true
+
+		// Ждем, пока размер файла перестанет меняться
+		prevSize, known := lastSizes[name]
+		lastSizes[name] = info.Size()
+		if !known || prevSize != info.Size() {
+			continue
+		}
+		delete(lastSizes, name)
+
+		path := filepath.Join(dir, name)
+		target := processedDir
+		if _, err := s.ImportFile(ctx, path, opts); err != nil {
+			target = failedDir
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		dest := filepath.Join(target, fmt.Sprintf("%s_%s", time.Now().Format("20060102_150405"), name))
+		if err := os.Rename(path, dest); err != nil {
+			s.logFunc(types.LogEntry{
+				Timestamp: time.Now(),
+				Level:     "ERROR",
+				Message:   fmt.Sprintf("Failed to move exchange file %s: %v", path, err),
+				Endpoint:  "/exchange-import",
+			})
+		}
+	}
+
+	for name := range lastSizes {
+		if !seen[name] {
+			delete(lastSizes, name)
+		}
+	}
+}
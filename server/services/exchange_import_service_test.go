@@ -0,0 +1,211 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"httpserver/database"
+)
+
+// buildExchangeFile формирует файл обмена с заданным количеством элементов
+func buildExchangeFile(catalogItems, nomenclatureItems int, withComplete bool) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	b.WriteString(`<exchange_file>`)
+	b.WriteString(`<handshake><version_1c>8.3.24</version_1c><config_name>УТ</config_name><computer_name>srv</computer_name><timestamp>2024-01-01T00:00:00</timestamp></handshake>`)
+	b.WriteString(`<constant><name>Организация</name><synonym>Организация</synonym><type>string</type><value>ООО Тест</value></constant>`)
+	b.WriteString(`<catalog_meta><name>Контрагенты</name><synonym>Контрагенты</synonym></catalog_meta>`)
+	b.WriteString(`<catalog_items><catalog_name>Контрагенты</catalog_name><items>`)
+	for i := 0; i < catalogItems; i++ {
+		fmt.Fprintf(&b, `<item><reference>ref-%d</reference><code>%05d</code><name>Контрагент %d</name></item>`, i, i, i)
+	}
+	b.WriteString(`</items></catalog_items>`)
+	b.WriteString(`<nomenclature_batch><items>`)
+	for i := 0; i < nomenclatureItems; i++ {
+		fmt.Fprintf(&b, `<item><nomenclature_reference>nom-%d</nomenclature_reference><nomenclature_code>N%d</nomenclature_code><nomenclature_name>Болт М%d</nomenclature_name></item>`, i, i, i)
+	}
+	b.WriteString(`</items></nomenclature_batch>`)
+	if withComplete {
+		b.WriteString(`<complete/>`)
+	}
+	b.WriteString(`</exchange_file>`)
+	return b.String()
+}
+
+// TestExchangeImportService_ImportReader проверяет потоковый импорт файла обмена пакетами
+func TestExchangeImportService_ImportReader(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uploadService := NewUploadService(db, nil, nil, func(entry interface{}) {})
+	service := NewExchangeImportService(uploadService, nil)
+	service.SetBatchSize(7)
+
+	var completed *database.Upload
+	service.SetCompletionHook(func(upload *database.Upload) { completed = upload })
+
+	content := buildExchangeFile(25, 12, false)
+	progress, err := service.ImportReader(context.Background(), strings.NewReader(content), "exchange.xml", int64(len(content)), ExchangeImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportReader() error = %v", err)
+	}
+
+	if progress.Status != ExchangeImportStatusCompleted {
+		t.Errorf("Status = %s, want %s", progress.Status, ExchangeImportStatusCompleted)
+	}
+	if progress.Constants != 1 || progress.Catalogs != 1 {
+		t.Errorf("Constants = %d, Catalogs = %d, want 1 and 1", progress.Constants, progress.Catalogs)
+	}
+	if progress.CatalogItems != 25 {
+		t.Errorf("CatalogItems = %d, want 25", progress.CatalogItems)
+	}
+	if progress.NomenclatureItems != 12 {
+		t.Errorf("NomenclatureItems = %d, want 12", progress.NomenclatureItems)
+	}
+	if progress.Progress != 100 {
+		t.Errorf("Progress = %.1f, want 100", progress.Progress)
+	}
+
+	// Выгрузка завершается по концу файла даже без элемента complete
+	if completed == nil || completed.UploadUUID != progress.UploadUUID {
+		t.Fatalf("completion hook not called for upload %s", progress.UploadUUID)
+	}
+	upload, err := uploadService.GetUploadByUUID(progress.UploadUUID)
+	if err != nil {
+		t.Fatalf("GetUploadByUUID() error = %v", err)
+	}
+	if upload.Status != "completed" {
+		t.Errorf("upload status = %s, want completed", upload.Status)
+	}
+
+	imports := service.GetImports()
+	if len(imports) != 1 || imports[0].FileName != "exchange.xml" {
+		t.Errorf("GetImports() = %+v, want single exchange.xml entry", imports)
+	}
+}
+
+// TestExchangeImportService_ImportReader_NoHandshake проверяет отказ при отсутствии handshake
+func TestExchangeImportService_ImportReader_NoHandshake(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewExchangeImportService(NewUploadService(db, nil, nil, func(entry interface{}) {}), nil)
+
+	content := `<exchange_file><catalog_items><catalog_name>Контрагенты</catalog_name><items></items></catalog_items></exchange_file>`
+	progress, err := service.ImportReader(context.Background(), strings.NewReader(content), "bad.xml", 0, ExchangeImportOptions{})
+	if err == nil {
+		t.Fatal("expected error for exchange file without handshake")
+	}
+	if progress.Status != ExchangeImportStatusFailed {
+		t.Errorf("Status = %s, want %s", progress.Status, ExchangeImportStatusFailed)
+	}
+}
+
+// TestExchangeImportService_WatchDirectory проверяет подхват файла из каталога и перенос в processed
+func TestExchangeImportService_WatchDirectory(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewExchangeImportService(NewUploadService(db, nil, nil, func(entry interface{}) {}), nil)
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "upload.xml"), []byte(buildExchangeFile(3, 2, true)), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = service.WatchDirectory(ctx, dir, 20*time.Millisecond, ExchangeImportOptions{})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		entries, _ := os.ReadDir(filepath.Join(dir, "processed"))
+		if len(entries) == 1 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "processed"))
+	if len(entries) != 1 {
+		t.Fatalf("expected file to be moved to processed, got %d entries", len(entries))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "upload.xml")); !os.IsNotExist(err) {
+		t.Error("source file should be removed from watched directory")
+	}
+}
+
+// TestExchangeImportService_ImportReader_ProcessingFileMode проверяет импорт файла в том виде,
+// в котором его пишет обработка 1С в режиме ФайлОбмена: сообщения HTTP без объявлений XML,
+// upload_uuid обработки, одиночные catalog_item и BOM после дозаписи файла
+func TestExchangeImportService_ImportReader_ProcessingFileMode(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	uploadService := NewUploadService(db, nil, nil, func(entry interface{}) {})
+	service := NewExchangeImportService(uploadService, nil)
+
+	const localUUID = "7d3c1b2a-0000-4000-8000-000000000001"
+	content := "\ufeff<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<exchange_file>\n" +
+		"<handshake><version_1c>8.3.24</version_1c><config_name>УТ</config_name><timestamp>2024-01-01T00:00:00</timestamp></handshake>\n" +
+		"\ufeff<metadata><upload_uuid>" + localUUID + "</upload_uuid><version_1c>8.3.24</version_1c></metadata>\n" +
+		"<catalog_meta><upload_uuid>" + localUUID + "</upload_uuid><name>Номенклатура</name><synonym>Номенклатура</synonym></catalog_meta>\n" +
+		"<catalog_item><upload_uuid>" + localUUID + "</upload_uuid><catalog_name>Номенклатура</catalog_name><reference>ref-1</reference><code>001</code><name>Болт М8</name></catalog_item>\n" +
+		"<catalog_items><upload_uuid>" + localUUID + "</upload_uuid><catalog_name>Номенклатура</catalog_name><items>" +
+		"<item><reference>ref-2</reference><code>002</code><name>Гайка М8</name></item></items></catalog_items>\n" +
+		"<complete><upload_uuid>" + localUUID + "</upload_uuid></complete>\n</exchange_file>\n"
+
+	progress, err := service.ImportReader(context.Background(), strings.NewReader(content), "ut.xml", int64(len(content)), ExchangeImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportReader() error = %v", err)
+	}
+	if progress.UploadUUID == localUUID {
+		t.Error("upload must be created by the server, not taken from the processing")
+	}
+	if progress.Catalogs != 1 || progress.CatalogItems != 2 || progress.FailedItems != 0 {
+		t.Errorf("progress = %+v, want 1 catalog and 2 items", progress)
+	}
+	upload, err := uploadService.GetUploadByUUID(progress.UploadUUID)
+	if err != nil || upload.Status != "completed" {
+		t.Fatalf("GetUploadByUUID() = %+v, err %v, want completed upload", upload, err)
+	}
+}
+
+// TestExchangeImportService_ImportHistoryLimit проверяет вытеснение старых завершенных импортов
+func TestExchangeImportService_ImportHistoryLimit(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	service := NewExchangeImportService(NewUploadService(db, nil, nil, func(entry interface{}) {}), nil)
+	running := &ExchangeImportProgress{FileName: "running.xml", Status: ExchangeImportStatusInProgress, StartedAt: time.Now().Add(-time.Hour)}
+	service.imports[running.FileName] = running
+
+	for i := 0; i < maxExchangeImportHistory+5; i++ {
+		_, _ = service.ImportReader(context.Background(), strings.NewReader("<exchange_file/>"), fmt.Sprintf("bad-%03d.xml", i), 0, ExchangeImportOptions{})
+	}
+
+	imports := service.GetImports()
+	if len(imports) != maxExchangeImportHistory {
+		t.Fatalf("GetImports() returned %d entries, want %d", len(imports), maxExchangeImportHistory)
+	}
+	names := make(map[string]bool)
+	for _, p := range imports {
+		names[p.FileName] = true
+	}
+	if !names["running.xml"] {
+		t.Error("import in progress must not be evicted")
+	}
+	if names["bad-000.xml"] || !names[fmt.Sprintf("bad-%03d.xml", maxExchangeImportHistory+4)] {
+		t.Error("oldest completed imports should be evicted first")
+	}
+}
@@ -0,0 +1,528 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	"httpserver/normalization/algorithms"
+	apperrors "httpserver/server/errors"
+)
+
+// Статусы импортозамещения элемента номенклатуры
+const (
+	GISPComplianceStatusFound        = "analogue_found"    // найден российский аналог
+	GISPComplianceStatusPossible     = "possible_analogue" // есть кандидаты, требуется ручная проверка
+	GISPComplianceStatusNotFound     = "not_found"         // в разделе ОКПД2 нет подходящих позиций реестра
+	GISPComplianceStatusUnclassified = "unclassified"      // у элемента нет кода ОКПД2/КПВЭД
+)
+
+// Веса компонентов оценки совпадения. Компоненты, для которых у элемента нет данных
+// (производитель, ключевые атрибуты), в оценке не участвуют
+const (
+	gispWeightCode         = 0.30
+	gispWeightName         = 0.45
+	gispWeightManufacturer = 0.10
+	gispWeightAttributes   = 0.15
+
+	gispFoundThreshold    = 0.70
+	gispPossibleThreshold = 0.50
+)
+
+// GISPComplianceOptions параметры построения отчета
+type GISPComplianceOptions struct {
+	MinScore   float64 // минимальная оценка кандидата для включения в отчет (по умолчанию 0.3)
+	MaxMatches int     // число кандидатов на элемент (по умолчанию 3)
+}
+
+// GISPComplianceMatch кандидат из реестра ГИСП для элемента номенклатуры
+type GISPComplianceMatch struct {
+	Entry             *database.GISPRegistryEntry `json:"entry"`
+	Score             float64                     `json:"score"`
+	CodeScore         float64                     `json:"code_score"`
+	NameScore         float64                     `json:"name_score"`
+	ManufacturerScore *float64                    `json:"manufacturer_score,omitempty"`
+	AttributeScore    *float64                    `json:"attribute_score,omitempty"`
+	Evidence          []string                    `json:"evidence"`
+}
+
+// GISPComplianceItem результат проверки одного элемента номенклатуры
+type GISPComplianceItem struct {
+	ItemID         int                   `json:"item_id"`
+	SourceName     string                `json:"source_name"`
+	NormalizedName string                `json:"normalized_name"`
+	Code           string                `json:"code,omitempty"`
+	CodeSource     string                `json:"code_source,omitempty"` // okpd2 или kpved
+	Status         string                `json:"status"`
+	BestScore      float64               `json:"best_score"`
+	Matches        []GISPComplianceMatch `json:"matches"`
+}
+
+// GISPComplianceSummary сводка отчета по статусам
+type GISPComplianceSummary struct {
+	Total            int     `json:"total"`
+	AnalogueFound    int     `json:"analogue_found"`
+	PossibleAnalogue int     `json:"possible_analogue"`
+	NotFound         int     `json:"not_found"`
+	Unclassified     int     `json:"unclassified"`
+	CoveragePercent  float64 `json:"coverage_percent"` // доля элементов с найденным аналогом
+}
+
+// GISPComplianceReport отчет о соответствии номенклатуры проекта требованиям импортозамещения
+type GISPComplianceReport struct {
+	ProjectID       int                   `json:"project_id"`
+	ProjectName     string                `json:"project_name"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+	RegistryEntries int                   `json:"registry_entries"`
+	MinScore        float64               `json:"min_score"`
+	Summary         GISPComplianceSummary `json:"summary"`
+	Items           []*GISPComplianceItem `json:"items"`
+}
+
+// GISPComplianceService сопоставляет нормализованную номенклатуру проекта с реестром ГИСП
+type GISPComplianceService struct {
+	serviceDB    *database.ServiceDB
+	normalizedDB *database.DB
+	stemmer      *algorithms.RussianStemmer
+}
+
+// NewGISPComplianceService создает сервис проверки импортозамещения
+func NewGISPComplianceService(serviceDB *database.ServiceDB, normalizedDB *database.DB) *GISPComplianceService {
+	return &GISPComplianceService{
+		serviceDB:    serviceDB,
+		normalizedDB: normalizedDB,
+		stemmer:      algorithms.NewRussianStemmer(),
+	}
+}
+
+// BuildReport сопоставляет каждый нормализованный элемент проекта с позициями реестра ГИСП
+// того же класса ОКПД2 и возвращает отчет с оценками и обоснованием совпадений
+func (s *GISPComplianceService) BuildReport(projectID int, opts GISPComplianceOptions) (*GISPComplianceReport, error) {
+	if s.serviceDB == nil || s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	if opts.MinScore <= 0 {
+		opts.MinScore = 0.3
+	}
+	if opts.MaxMatches <= 0 {
+		opts.MaxMatches = 3
+	}
+
+	project, err := s.serviceDB.GetClientProject(projectID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("проект %d не найден", projectID), err)
+	}
+	registryEntries, err := s.serviceDB.CountGISPRegistryEntries()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить размер реестра ГИСП", err)
+	}
+	if registryEntries == 0 {
+		return nil, apperrors.NewServiceUnavailableError("реестр ГИСП не загружен: выполните импорт через /api/gisp/nomenclatures/import", nil)
+	}
+
+	items, err := s.normalizedDB.GetProjectItemsForNameTemplates(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить номенклатуру проекта", err)
+	}
+
+	report := &GISPComplianceReport{
+		ProjectID:       project.ID,
+		ProjectName:     project.Name,
+		GeneratedAt:     time.Now(),
+		RegistryEntries: registryEntries,
+		MinScore:        opts.MinScore,
+		Items:           make([]*GISPComplianceItem, 0, len(items)),
+	}
+
+	// Позиции реестра загружаются один раз на класс ОКПД2
+	candidatesByClass := make(map[string][]*database.GISPRegistryEntry)
+	for _, item := range items {
+		result := &GISPComplianceItem{
+			ItemID:         item.ID,
+			SourceName:     item.SourceName,
+			NormalizedName: item.NormalizedName,
+			Matches:        []GISPComplianceMatch{},
+		}
+		report.Items = append(report.Items, result)
+
+		result.Code, result.CodeSource = complianceItemCode(item)
+		if result.Code == "" {
+			result.Status = GISPComplianceStatusUnclassified
+			report.Summary.Unclassified++
+			continue
+		}
+
+		class := okpd2Class(result.Code)
+		candidates, ok := candidatesByClass[class]
+		if !ok {
+			candidates, err = s.serviceDB.GetGISPRegistryEntriesByOKPD2(class)
+			if err != nil {
+				return nil, apperrors.NewInternalError("не удалось получить позиции реестра ГИСП", err)
+			}
+			candidatesByClass[class] = candidates
+		}
+
+		profile := s.newComplianceProfile(item, result)
+		for _, entry := range candidates {
+			match := s.scoreEntry(profile, entry)
+			if match.Score >= opts.MinScore {
+				result.Matches = append(result.Matches, match)
+			}
+		}
+		sort.SliceStable(result.Matches, func(i, j int) bool {
+			return result.Matches[i].Score > result.Matches[j].Score
+		})
+		if len(result.Matches) > opts.MaxMatches {
+			result.Matches = result.Matches[:opts.MaxMatches]
+		}
+		if len(result.Matches) > 0 {
+			result.BestScore = result.Matches[0].Score
+		}
+
+		switch {
+		case result.BestScore >= gispFoundThreshold:
+			result.Status = GISPComplianceStatusFound
+			report.Summary.AnalogueFound++
+		case result.BestScore >= gispPossibleThreshold:
+			result.Status = GISPComplianceStatusPossible
+			report.Summary.PossibleAnalogue++
+		default:
+			result.Status = GISPComplianceStatusNotFound
+			report.Summary.NotFound++
+		}
+	}
+
+	report.Summary.Total = len(report.Items)
+	if report.Summary.Total > 0 {
+		report.Summary.CoveragePercent = roundScore(float64(report.Summary.AnalogueFound) / float64(report.Summary.Total) * 100)
+	}
+	return report, nil
+}
+
+// complianceProfile подготовленные для сравнения данные элемента
+type complianceProfile struct {
+	code          string
+	codeSource    string
+	nameTokens    []string
+	manufacturers []string // значения атрибутов производителя/марки
+	sourceLower   string
+	keyValues     []string // размеры и ссылки на стандарты
+}
+
+func (s *GISPComplianceService) newComplianceProfile(item *database.NameTemplateSourceItem, result *GISPComplianceItem) *complianceProfile {
+	name := item.NormalizedName
+	if name == "" {
+		name = item.SourceName
+	}
+	profile := &complianceProfile{
+		code:        result.Code,
+		codeSource:  result.CodeSource,
+		nameTokens:  s.stemTokens(name),
+		sourceLower: strings.ToLower(item.SourceName),
+	}
+
+	seen := make(map[string]bool)
+	addKey := func(value string) {
+		value = strings.TrimSpace(value)
+		if value != "" && !seen[normalizeKeyValue(value)] {
+			seen[normalizeKeyValue(value)] = true
+			profile.keyValues = append(profile.keyValues, value)
+		}
+	}
+	for _, attr := range item.Attributes {
+		if attr == nil {
+			continue
+		}
+		switch {
+		case attr.AttributeName == "manufacturer" || attr.AttributeName == "brand" ||
+			attr.AttributeType == "manufacturer" || attr.AttributeType == "brand":
+			if value := strings.TrimSpace(attr.AttributeValue); value != "" {
+				profile.manufacturers = append(profile.manufacturers, value)
+			}
+		case attr.AttributeType == "dimension":
+			if attr.OriginalText != "" {
+				addKey(attr.OriginalText)
+			} else {
+				addKey(attr.AttributeValue)
+			}
+		case attr.AttributeName == "standard":
+			addKey(attr.AttributeValue)
+		}
+	}
+	for _, ref := range normalization.StandardReferences(item.SourceName) {
+		addKey(ref)
+	}
+	return profile
+}
+
+// scoreEntry оценивает позицию реестра по коду, наименованию, производителю и ключевым атрибутам
+func (s *GISPComplianceService) scoreEntry(profile *complianceProfile, entry *database.GISPRegistryEntry) GISPComplianceMatch {
+	match := GISPComplianceMatch{Entry: entry}
+
+	// Код ОКПД2: доля совпавших уровней
+	common, total := commonCodeLevels(profile.code, entry.OKPD2Code)
+	if total > 0 {
+		match.CodeScore = float64(common) / float64(total)
+	}
+	if common == total {
+		match.Evidence = append(match.Evidence, fmt.Sprintf("ОКПД2 %s совпадает полностью", entry.OKPD2Code))
+	} else {
+		match.Evidence = append(match.Evidence, fmt.Sprintf("ОКПД2 совпадает до уровня %s", strings.Join(strings.Split(entry.OKPD2Code, ".")[:common], ".")))
+	}
+	if profile.codeSource == "kpved" {
+		match.Evidence = append(match.Evidence, "код ОКПД2 не задан, использован код КПВЭД")
+	}
+
+	// Наименование: доля основ слов элемента, найденных в наименовании реестра
+	entryName := entry.NormalizedName
+	if entryName == "" {
+		entryName = entry.ProductName
+	}
+	entryTokens := make(map[string]bool)
+	for _, token := range s.stemTokens(entryName) {
+		entryTokens[token] = true
+	}
+	var matched []string
+	for _, token := range profile.nameTokens {
+		if entryTokens[token] {
+			matched = append(matched, token)
+		}
+	}
+	if len(profile.nameTokens) > 0 {
+		match.NameScore = float64(len(matched)) / float64(len(profile.nameTokens))
+		// Вид изделия (первое слово) должен совпадать
+		if !entryTokens[profile.nameTokens[0]] {
+			match.NameScore /= 2
+		}
+	}
+	if len(matched) > 0 {
+		match.Evidence = append(match.Evidence, fmt.Sprintf("наименование: совпали %s (%d из %d)",
+			strings.Join(matched, ", "), len(matched), len(profile.nameTokens)))
+	}
+
+	weighted := gispWeightCode*match.CodeScore + gispWeightName*match.NameScore
+	weights := gispWeightCode + gispWeightName
+
+	// Производитель: учитывается, если у элемента указан производитель или он упомянут в наименовании
+	if manufacturerKey := manufacturerKeyword(entry.ManufacturerName); manufacturerKey != "" {
+		mentioned := strings.Contains(profile.sourceLower, manufacturerKey)
+		for _, value := range profile.manufacturers {
+			if strings.Contains(strings.ToLower(value), manufacturerKey) {
+				mentioned = true
+			}
+		}
+		if mentioned || len(profile.manufacturers) > 0 {
+			score := 0.0
+			if mentioned {
+				score = 1
+				match.Evidence = append(match.Evidence, fmt.Sprintf("производитель %s указан у элемента", entry.ManufacturerName))
+			}
+			match.ManufacturerScore = &score
+			weighted += gispWeightManufacturer * score
+			weights += gispWeightManufacturer
+		}
+	}
+
+	// Ключевые атрибуты: размеры и стандарты элемента в наименовании или ТУ/ГОСТ позиции реестра
+	if len(profile.keyValues) > 0 {
+		haystack := normalizeKeyValue(entry.ProductName + " " + entry.Standard)
+		found := 0
+		for _, value := range profile.keyValues {
+			if strings.Contains(haystack, normalizeKeyValue(value)) {
+				found++
+				match.Evidence = append(match.Evidence, fmt.Sprintf("%s есть в позиции реестра", value))
+			}
+		}
+		score := float64(found) / float64(len(profile.keyValues))
+		match.AttributeScore = &score
+		weighted += gispWeightAttributes * score
+		weights += gispWeightAttributes
+	}
+
+	match.CodeScore = roundScore(match.CodeScore)
+	match.NameScore = roundScore(match.NameScore)
+	match.Score = roundScore(weighted / weights)
+	return match
+}
+
+// stemTokens разбивает текст на слова из букв и возвращает их основы (слова короче 2 символов отбрасываются)
+func (s *GISPComplianceService) stemTokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	tokens := make([]string, 0, len(words))
+	seen := make(map[string]bool)
+	for _, word := range words {
+		if len([]rune(word)) < 2 {
+			continue
+		}
+		stem := s.stemmer.StemWithCache(word)
+		if !seen[stem] {
+			seen[stem] = true
+			tokens = append(tokens, stem)
+		}
+	}
+	return tokens
+}
+
+// complianceItemCode возвращает код для поиска в реестре: ОКПД2, иначе КПВЭД
+func complianceItemCode(item *database.NameTemplateSourceItem) (string, string) {
+	if code := strings.TrimSpace(item.Okpd2Code); code != "" {
+		return code, "okpd2"
+	}
+	if code := strings.TrimSpace(item.KpvedCode); code != "" {
+		return code, "kpved"
+	}
+	return "", ""
+}
+
+// okpd2Class возвращает класс кода (два первых уровня: 24.20.13.110 -> 24.20)
+func okpd2Class(code string) string {
+	parts := strings.Split(strings.TrimSuffix(code, "."), ".")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
+
+// commonCodeLevels возвращает число совпавших уровней кодов и число уровней более длинного кода
+func commonCodeLevels(a, b string) (int, int) {
+	left := strings.Split(strings.TrimSuffix(a, "."), ".")
+	right := strings.Split(strings.TrimSuffix(b, "."), ".")
+	total := len(left)
+	if len(right) > total {
+		total = len(right)
+	}
+	common := 0
+	for common < len(left) && common < len(right) && left[common] == right[common] {
+		common++
+	}
+	return common, total
+}
+
+// manufacturerKeyword возвращает отличительное слово наименования производителя без организационно-правовой формы
+func manufacturerKeyword(name string) string {
+	legalForms := map[string]bool{"ооо": true, "ао": true, "пао": true, "зао": true, "оао": true, "ип": true, "нпо": true, "нао": true}
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+	for _, word := range words {
+		if !legalForms[word] && len([]rune(word)) >= 3 {
+			return word
+		}
+	}
+	return ""
+}
+
+// normalizeKeyValue приводит размеры и ссылки на стандарты к виду для сравнения: 57x3.5 -> 57х3,5
+func normalizeKeyValue(value string) string {
+	value = strings.ToLower(value)
+	value = strings.NewReplacer("x", "х", "*", "х", ".", ",", " ", "").Replace(value)
+	return value
+}
+
+func roundScore(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// ExportExcel выгружает отчет в Excel: сводка, элементы с лучшим кандидатом и все кандидаты с обоснованием
+func (s *GISPComplianceService) ExportExcel(w io.Writer, report *GISPComplianceReport) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true, Size: 11},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"#4472C4"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center"},
+	})
+	if err != nil {
+		return apperrors.NewInternalError("не удалось создать стиль заголовков", err)
+	}
+
+	writeSheet := func(sheet string, headers []string, rows [][]interface{}, width float64) error {
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+		for i, header := range headers {
+			cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+			f.SetCellValue(sheet, cell, header)
+			f.SetCellStyle(sheet, cell, cell, headerStyle)
+		}
+		for r, row := range rows {
+			for c, value := range row {
+				cell, _ := excelize.CoordinatesToCellName(c+1, r+2)
+				f.SetCellValue(sheet, cell, value)
+			}
+		}
+		for i := range headers {
+			col, _ := excelize.ColumnNumberToName(i + 1)
+			f.SetColWidth(sheet, col, col, width)
+		}
+		return nil
+	}
+
+	summary := report.Summary
+	if err := writeSheet("Сводка", []string{"Показатель", "Значение"}, [][]interface{}{
+		{"Проект", fmt.Sprintf("%s (%d)", report.ProjectName, report.ProjectID)},
+		{"Дата формирования", report.GeneratedAt.Format("02.01.2006 15:04")},
+		{"Позиций в реестре ГИСП", report.RegistryEntries},
+		{"Всего элементов", summary.Total},
+		{"Найден российский аналог", summary.AnalogueFound},
+		{"Требуется проверка", summary.PossibleAnalogue},
+		{"Аналог не найден", summary.NotFound},
+		{"Нет кода ОКПД2/КПВЭД", summary.Unclassified},
+		{"Покрытие, %", summary.CoveragePercent},
+	}, 30); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист Сводка", err)
+	}
+
+	itemRows := make([][]interface{}, 0, len(report.Items))
+	matchRows := make([][]interface{}, 0, len(report.Items))
+	for _, item := range report.Items {
+		row := []interface{}{item.ItemID, item.SourceName, item.NormalizedName, item.Code, item.CodeSource, item.Status, item.BestScore}
+		if len(item.Matches) > 0 {
+			best := item.Matches[0].Entry
+			row = append(row, best.ProductName, best.RegistryNumber, best.ManufacturerName, best.ManufacturerINN)
+		}
+		itemRows = append(itemRows, row)
+
+		for rank, match := range item.Matches {
+			entry := match.Entry
+			matchRows = append(matchRows, []interface{}{
+				item.ItemID, item.SourceName, rank + 1, match.Score, entry.ProductName, entry.OKPD2Code, entry.TNVEDCode,
+				entry.Standard, entry.RegistryNumber, entry.ValidityPeriod, entry.ManufacturerName, entry.ManufacturerINN,
+				strings.Join(match.Evidence, "; "),
+			})
+		}
+	}
+	if err := writeSheet("Номенклатура", []string{
+		"ID", "Исходное наименование", "Нормализованное наименование", "Код", "Источник кода", "Статус", "Оценка",
+		"Аналог в реестре", "Реестровый номер", "Производитель", "ИНН производителя",
+	}, itemRows, 22); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист Номенклатура", err)
+	}
+	if err := writeSheet("Кандидаты", []string{
+		"ID элемента", "Исходное наименование", "Ранг", "Оценка", "Продукция", "ОКПД2", "ТН ВЭД",
+		"ТУ/ГОСТ", "Реестровый номер", "Срок действия", "Производитель", "ИНН производителя", "Обоснование",
+	}, matchRows, 22); err != nil {
+		return apperrors.NewInternalError("не удалось сформировать лист Кандидаты", err)
+	}
+
+	f.DeleteSheet("Sheet1")
+	if index, err := f.GetSheetIndex("Сводка"); err == nil {
+		f.SetActiveSheet(index)
+	}
+
+	if err := f.Write(w); err != nil {
+		return apperrors.NewInternalError("не удалось записать Excel файл", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"httpserver/database"
+	"httpserver/importer"
+)
+
+// TestGISPComplianceService_BuildReport проверяет подбор аналогов по ОКПД2, наименованию и атрибутам
+func TestGISPComplianceService_BuildReport(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	normalizedDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create normalized DB: %v", err)
+	}
+	defer normalizedDB.Close()
+
+	service := NewGISPComplianceService(serviceDB, normalizedDB)
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Закупки", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	if _, err := service.BuildReport(project.ID, GISPComplianceOptions{}); err == nil {
+		t.Fatal("BuildReport() without registry must fail")
+	}
+
+	systemProject, err := serviceDB.GetOrCreateSystemProject()
+	if err != nil {
+		t.Fatalf("GetOrCreateSystemProject() error = %v", err)
+	}
+	records := []importer.NomenclatureRecord{
+		{ManufacturerName: "ООО \"Волжский трубный завод\"", INN: "3435900517", ProductName: "Трубы стальные электросварные прямошовные 57х3,5",
+			RegistryNumber: "10\\1\\1\\2023", OKPD2: "24.20.13.110", ManufacturedBy: "ГОСТ 10704-91"},
+		{ManufacturerName: "АО \"Метиз\"", INN: "7700000001", ProductName: "Болты с шестигранной головкой", OKPD2: "25.94.11.110"},
+	}
+	if _, err := importer.NewNomenclatureImporter(serviceDB).ImportNomenclatures(records, systemProject.ID); err != nil {
+		t.Fatalf("ImportNomenclatures() error = %v", err)
+	}
+
+	projectID := project.ID
+	items := []*database.NormalizedItem{
+		{SourceName: "Труба стальная 57х3,5 ГОСТ 10704-91", Code: "001", NormalizedName: "труба стальная", NormalizedReference: "труба стальная", KpvedCode: "24.20.13", MergedCount: 1},
+		{SourceName: "Кабель силовой", Code: "002", NormalizedName: "кабель силовой", NormalizedReference: "кабель силовой", KpvedCode: "27.32.13", MergedCount: 1},
+		{SourceName: "Прочее", Code: "003", NormalizedName: "прочее", NormalizedReference: "прочее", MergedCount: 1},
+	}
+	attrs := map[string][]*database.ItemAttribute{
+		"001": {{AttributeType: "dimension", AttributeName: "width", AttributeValue: "57", OriginalText: "57х3,5", Confidence: 1}},
+	}
+	if _, err := normalizedDB.InsertNormalizedItemsWithAttributesBatch(items, attrs, nil, &projectID); err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	report, err := service.BuildReport(project.ID, GISPComplianceOptions{})
+	if err != nil {
+		t.Fatalf("BuildReport() error = %v", err)
+	}
+	if report.RegistryEntries != 2 || report.Summary.Total != 3 {
+		t.Fatalf("report = %+v", report)
+	}
+	if report.Summary.AnalogueFound != 1 || report.Summary.NotFound != 1 || report.Summary.Unclassified != 1 {
+		t.Errorf("summary = %+v", report.Summary)
+	}
+
+	pipe := report.Items[0]
+	if pipe.Status != GISPComplianceStatusFound || pipe.CodeSource != "kpved" || len(pipe.Matches) != 1 {
+		t.Fatalf("pipe item = %+v", pipe)
+	}
+	match := pipe.Matches[0]
+	if match.Entry.RegistryNumber == "" || match.Entry.ManufacturerINN != "3435900517" || match.Entry.Standard != "ГОСТ 10704-91" {
+		t.Errorf("matched entry = %+v", match.Entry)
+	}
+	if match.AttributeScore == nil || *match.AttributeScore != 1 {
+		t.Errorf("attribute score = %v, evidence = %v", match.AttributeScore, match.Evidence)
+	}
+
+	var buf bytes.Buffer
+	if err := service.ExportExcel(&buf, report); err != nil || buf.Len() == 0 {
+		t.Errorf("ExportExcel() = %d bytes, %v", buf.Len(), err)
+	}
+}
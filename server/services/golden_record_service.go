@@ -0,0 +1,475 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"httpserver/database"
+	"httpserver/extractors"
+	"httpserver/normalization"
+	apperrors "httpserver/server/errors"
+)
+
+// Атрибуты эталонной записи, которые берутся из колонок catalog_items
+const (
+	GoldenAttributeName = "name"
+	GoldenAttributeCode = "code"
+)
+
+// GoldenBuildResult результат сборки эталонных записей проекта
+type GoldenBuildResult struct {
+	ProjectID      int       `json:"project_id"`
+	Databases      int       `json:"databases"`
+	SourceItems    int       `json:"source_items"`
+	GoldenRecords  int       `json:"golden_records"`
+	RemovedRecords int       `json:"removed_records"`
+	PruneSkipped   bool      `json:"prune_skipped,omitempty"` // не все базы прочитаны, устаревшие записи не удалялись
+	Errors         []string  `json:"errors,omitempty"`
+	BuiltAt        time.Time `json:"built_at"`
+}
+
+// GoldenAttributeDetails атрибут эталонной записи со всеми значениями-кандидатами из источников
+type GoldenAttributeDetails struct {
+	*database.GoldenRecordAttribute
+	Candidates []normalization.GoldenCandidate `json:"candidates"`
+}
+
+// GoldenRecordDetails эталонная запись с атрибутами и источниками
+type GoldenRecordDetails struct {
+	*database.GoldenRecord
+	Attributes []*GoldenAttributeDetails      `json:"attributes"`
+	Sources    []*database.GoldenRecordSource `json:"sources"`
+}
+
+// GoldenRecordService собирает эталонные записи номенклатуры из всех баз проекта
+// по правилам выживания атрибутов и хранит происхождение каждого значения
+type GoldenRecordService struct {
+	serviceDB *database.ServiceDB
+}
+
+// NewGoldenRecordService создает сервис эталонных записей
+func NewGoldenRecordService(serviceDB *database.ServiceDB) *GoldenRecordService {
+	return &GoldenRecordService{serviceDB: serviceDB}
+}
+
+// GetRules возвращает правила выживания проекта
+func (s *GoldenRecordService) GetRules(projectID int) ([]normalization.SurvivorshipRule, error) {
+	records, err := s.serviceDB.GetGoldenSurvivorshipRules(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить правила выживания", err)
+	}
+	rules := make([]normalization.SurvivorshipRule, 0, len(records))
+	for _, r := range records {
+		rule := normalization.SurvivorshipRule{
+			Attribute: r.Attribute,
+			Strategy:  normalization.SurvivorshipStrategy(r.Strategy),
+		}
+		if r.SourcePriority != "" {
+			if err := json.Unmarshal([]byte(r.SourcePriority), &rule.SourcePriority); err != nil {
+				return nil, apperrors.NewInternalError("некорректный приоритет источников в правиле", err)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SaveRules проверяет и заменяет правила выживания проекта
+func (s *GoldenRecordService) SaveRules(projectID int, rules []normalization.SurvivorshipRule) error {
+	if err := s.ensureProject(projectID); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(rules))
+	records := make([]*database.GoldenSurvivorshipRuleRecord, 0, len(rules))
+	for _, rule := range rules {
+		attribute := strings.TrimSpace(rule.Attribute)
+		if attribute == "" {
+			return apperrors.NewValidationError("не указан атрибут правила", nil)
+		}
+		if seen[attribute] {
+			return apperrors.NewValidationError(fmt.Sprintf("правило для атрибута %q указано несколько раз", attribute), nil)
+		}
+		seen[attribute] = true
+		if !normalization.IsValidSurvivorshipStrategy(rule.Strategy) {
+			return apperrors.NewValidationError(fmt.Sprintf("неизвестная стратегия %q", rule.Strategy), nil)
+		}
+		if rule.Strategy == normalization.SurvivorshipSourcePriority && len(rule.SourcePriority) == 0 {
+			return apperrors.NewValidationError(fmt.Sprintf("для атрибута %q не задан приоритет источников", attribute), nil)
+		}
+
+		record := &database.GoldenSurvivorshipRuleRecord{
+			ProjectID: projectID,
+			Attribute: attribute,
+			Strategy:  string(rule.Strategy),
+		}
+		if len(rule.SourcePriority) > 0 {
+			data, err := json.Marshal(rule.SourcePriority)
+			if err != nil {
+				return apperrors.NewInternalError("не удалось сериализовать приоритет источников", err)
+			}
+			record.SourcePriority = string(data)
+		}
+		records = append(records, record)
+	}
+
+	if err := s.serviceDB.ReplaceGoldenSurvivorshipRules(projectID, records); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить правила выживания", err)
+	}
+	return nil
+}
+
+// goldenGroup записи catalog_items разных баз с одинаковым ключом эталона
+type goldenGroup struct {
+	key     string
+	sources []*database.GoldenRecordSource
+	attrs   []map[string]string
+}
+
+// BuildProject пересобирает эталонные записи проекта по всем активным базам в одной транзакции.
+// Ручные переопределения атрибутов сохраняются. Если какую-то базу прочитать не удалось,
+// записи без источников в прочитанных базах не удаляются.
+func (s *GoldenRecordService) BuildProject(projectID int) (*GoldenBuildResult, error) {
+	if err := s.ensureProject(projectID); err != nil {
+		return nil, err
+	}
+	rules, err := s.GetRules(projectID)
+	if err != nil {
+		return nil, err
+	}
+	ruleSet := normalization.NewGoldenRuleSet(rules)
+
+	databases, err := s.serviceDB.GetProjectDatabases(projectID, true)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить базы проекта", err)
+	}
+
+	result := &GoldenBuildResult{ProjectID: projectID, Databases: len(databases), BuiltAt: time.Now()}
+	groups := make(map[string]*goldenGroup)
+	for _, projectDB := range databases {
+		n, err := s.collectDatabase(projectDB, groups)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", projectDB.Name, err))
+			continue
+		}
+		result.SourceItems += n
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	err = s.serviceDB.RebuildGoldenRecords(func(tx *database.GoldenRecordTx) error {
+		keep := make(map[int]bool, len(groups))
+		for _, key := range keys {
+			recordID, err := s.saveGroup(tx, projectID, groups[key], ruleSet)
+			if err != nil {
+				return fmt.Errorf("failed to save golden record %q: %w", key, err)
+			}
+			keep[recordID] = true
+		}
+		result.GoldenRecords = len(keep)
+
+		// Источники непрочитанной базы неизвестны: удаление приняло бы их записи за устаревшие
+		if len(result.Errors) > 0 {
+			result.PruneSkipped = true
+			return nil
+		}
+		removed, err := tx.DeleteGoldenRecordsExcept(projectID, keep)
+		if err != nil {
+			return err
+		}
+		result.RemovedRecords = removed
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить эталонные записи", err)
+	}
+	return result, nil
+}
+
+// collectDatabase читает номенклатуру базы и раскладывает записи по группам
+func (s *GoldenRecordService) collectDatabase(projectDB *database.ProjectDatabase, groups map[string]*goldenGroup) (int, error) {
+	db, err := database.NewDB(projectDB.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	conn := db.GetDB()
+	// Берем только справочники номенклатуры; если их нет, используем все элементы справочников
+	filter := ""
+	var nomenclatureCatalogs int
+	if err := conn.QueryRow(`SELECT COUNT(*) FROM catalogs WHERE name = 'Номенклатура' OR name LIKE '%оменклатур%'`).Scan(&nomenclatureCatalogs); err == nil && nomenclatureCatalogs > 0 {
+		filter = ` WHERE c.name = 'Номенклатура' OR c.name LIKE '%оменклатур%'`
+	}
+
+	rows, err := conn.Query(`
+		SELECT ci.id, COALESCE(ci.reference, ''), COALESCE(ci.code, ''), COALESCE(ci.name, ''),
+		       COALESCE(ci.attributes_xml, ''), ci.created_at
+		FROM catalog_items ci
+		JOIN catalogs c ON ci.catalog_id = c.id` + filter + `
+		ORDER BY ci.id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query catalog items: %w", err)
+	}
+	defer rows.Close()
+
+	type catalogRow struct {
+		id                             int
+		reference, code, name, attrXML string
+		createdAt                      sql.NullTime
+	}
+	var items []catalogRow
+	var ids []int
+	for rows.Next() {
+		var item catalogRow
+		if err := rows.Scan(&item.id, &item.reference, &item.code, &item.name, &item.attrXML, &item.createdAt); err != nil {
+			return 0, fmt.Errorf("failed to scan catalog item: %w", err)
+		}
+		items = append(items, item)
+		ids = append(ids, item.id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating catalog items: %w", err)
+	}
+	rows.Close()
+
+	// Реквизиты берем из хранилища атрибутов; attributes_xml разбирается
+	// только для элементов, еще не попавших в хранилище
+	sets, err := db.GetSourceItemAttributes(database.SourceItemTypeCatalog, ids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load item attributes: %w", err)
+	}
+
+	count := 0
+	for _, item := range items {
+		key := normalization.GoldenKey(item.name)
+		if key == "" {
+			continue
+		}
+
+		set, ok := sets[item.id]
+		if !ok {
+			set = extractors.ParseAttributes(item.attrXML, "")
+		}
+		attrs := normalization.CanonicalAttributes(set.Map())
+		attrs[GoldenAttributeName] = strings.TrimSpace(item.name)
+		if item.code != "" {
+			attrs[GoldenAttributeCode] = strings.TrimSpace(item.code)
+		}
+		attrsJSON, err := json.Marshal(attrs)
+		if err != nil {
+			return count, fmt.Errorf("failed to marshal attributes: %w", err)
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &goldenGroup{key: key}
+			groups[key] = group
+		}
+		group.sources = append(group.sources, &database.GoldenRecordSource{
+			DatabaseID:     projectDB.ID,
+			CatalogItemID:  item.id,
+			Reference:      item.reference,
+			Code:           item.code,
+			Name:           item.name,
+			AttributesJSON: string(attrsJSON),
+			ObservedAt:     item.createdAt.Time,
+		})
+		group.attrs = append(group.attrs, attrs)
+		count++
+	}
+	return count, nil
+}
+
+// saveGroup применяет правила выживания к группе и сохраняет эталонную запись с lineage
+func (s *GoldenRecordService) saveGroup(tx *database.GoldenRecordTx, projectID int, group *goldenGroup, ruleSet *normalization.GoldenRuleSet) (int, error) {
+	candidates := goldenCandidates(group.sources, group.attrs)
+
+	name := group.sources[0].Name
+	if survivor, ok := normalization.SelectSurvivor(ruleSet.RuleFor(GoldenAttributeName), candidates[GoldenAttributeName]); ok {
+		name = survivor.Value
+	}
+
+	recordID, err := tx.UpsertGoldenRecord(projectID, group.key, name, len(group.sources))
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.ReplaceGoldenRecordSources(recordID, group.sources); err != nil {
+		return 0, err
+	}
+	if err := s.applyRules(tx, recordID, candidates, ruleSet); err != nil {
+		return 0, err
+	}
+	return recordID, nil
+}
+
+// applyRules вычисляет и сохраняет значения атрибутов записи по правилам
+func (s *GoldenRecordService) applyRules(tx *database.GoldenRecordTx, recordID int, candidates map[string][]normalization.GoldenCandidate, ruleSet *normalization.GoldenRuleSet) error {
+	keep := make([]string, 0, len(candidates))
+	for attribute, values := range candidates {
+		rule := ruleSet.RuleFor(attribute)
+		survivor, ok := normalization.SelectSurvivor(rule, values)
+		if !ok {
+			continue
+		}
+		observedAt := survivor.ObservedAt
+		if err := tx.SaveGoldenRecordAttribute(&database.GoldenRecordAttribute{
+			GoldenRecordID:      recordID,
+			Attribute:           attribute,
+			Value:               survivor.Value,
+			Strategy:            string(rule.Strategy),
+			SourceDatabaseID:    survivor.DatabaseID,
+			SourceCatalogItemID: survivor.CatalogItemID,
+			SourceReference:     survivor.Reference,
+			SourceObservedAt:    &observedAt,
+		}); err != nil {
+			return err
+		}
+		keep = append(keep, attribute)
+	}
+	return tx.DeleteStaleGoldenRecordAttributes(recordID, keep)
+}
+
+// goldenCandidates раскладывает атрибуты источников по именам атрибутов
+func goldenCandidates(sources []*database.GoldenRecordSource, attrs []map[string]string) map[string][]normalization.GoldenCandidate {
+	candidates := make(map[string][]normalization.GoldenCandidate)
+	for i, src := range sources {
+		for attribute, value := range attrs[i] {
+			candidates[attribute] = append(candidates[attribute], normalization.GoldenCandidate{
+				DatabaseID:    src.DatabaseID,
+				CatalogItemID: src.CatalogItemID,
+				Reference:     src.Reference,
+				Value:         value,
+				ObservedAt:    src.ObservedAt,
+			})
+		}
+	}
+	return candidates
+}
+
+// storedCandidates восстанавливает кандидатов из сохраненных источников записи
+func storedCandidates(sources []*database.GoldenRecordSource) map[string][]normalization.GoldenCandidate {
+	attrs := make([]map[string]string, len(sources))
+	for i, src := range sources {
+		attrs[i] = make(map[string]string)
+		if src.AttributesJSON != "" {
+			_ = json.Unmarshal([]byte(src.AttributesJSON), &attrs[i])
+		}
+	}
+	return goldenCandidates(sources, attrs)
+}
+
+// ListRecords возвращает эталонные записи проекта
+func (s *GoldenRecordService) ListRecords(projectID int, search string, limit, offset int) ([]*database.GoldenRecord, int, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	records, total, err := s.serviceDB.GetGoldenRecords(projectID, search, limit, offset)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalError("не удалось получить эталонные записи", err)
+	}
+	return records, total, nil
+}
+
+// GetRecord возвращает эталонную запись проекта с lineage и значениями-кандидатами
+func (s *GoldenRecordService) GetRecord(projectID, recordID int) (*GoldenRecordDetails, error) {
+	record, err := s.getProjectRecord(projectID, recordID)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := s.serviceDB.GetGoldenRecordAttributes(recordID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить атрибуты эталонной записи", err)
+	}
+	sources, err := s.serviceDB.GetGoldenRecordSources(recordID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить источники эталонной записи", err)
+	}
+
+	candidates := storedCandidates(sources)
+	details := &GoldenRecordDetails{GoldenRecord: record, Sources: sources}
+	for _, attr := range attrs {
+		details.Attributes = append(details.Attributes, &GoldenAttributeDetails{
+			GoldenRecordAttribute: attr,
+			Candidates:            candidates[attr.Attribute],
+		})
+	}
+	return details, nil
+}
+
+// OverrideAttribute вручную задает значение атрибута; значение не меняется при пересборке
+func (s *GoldenRecordService) OverrideAttribute(projectID, recordID int, attribute, value, user, reason string) error {
+	attribute = strings.TrimSpace(attribute)
+	if attribute == "" {
+		return apperrors.NewValidationError("не указан атрибут", nil)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return apperrors.NewValidationError("укажите причину переопределения", nil)
+	}
+	if _, err := s.getProjectRecord(projectID, recordID); err != nil {
+		return err
+	}
+	if err := s.serviceDB.OverrideGoldenRecordAttribute(recordID, attribute, value, user, reason); err != nil {
+		return apperrors.NewInternalError("не удалось переопределить атрибут", err)
+	}
+	return nil
+}
+
+// ClearOverride снимает ручное переопределение и сразу пересчитывает атрибут по сохраненным источникам
+func (s *GoldenRecordService) ClearOverride(projectID, recordID int, attribute string) error {
+	if _, err := s.getProjectRecord(projectID, recordID); err != nil {
+		return err
+	}
+	if err := s.serviceDB.ClearGoldenRecordOverride(recordID, attribute); err != nil {
+		return apperrors.NewInternalError("не удалось снять переопределение", err)
+	}
+
+	rules, err := s.GetRules(projectID)
+	if err != nil {
+		return err
+	}
+	sources, err := s.serviceDB.GetGoldenRecordSources(recordID)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось получить источники эталонной записи", err)
+	}
+	ruleSet := normalization.NewGoldenRuleSet(rules)
+	err = s.serviceDB.RebuildGoldenRecords(func(tx *database.GoldenRecordTx) error {
+		return s.applyRules(tx, recordID, storedCandidates(sources), ruleSet)
+	})
+	if err != nil {
+		return apperrors.NewInternalError("не удалось пересчитать атрибут", err)
+	}
+	return nil
+}
+
+// getProjectRecord возвращает запись, проверяя принадлежность проекту
+func (s *GoldenRecordService) getProjectRecord(projectID, recordID int) (*database.GoldenRecord, error) {
+	record, err := s.serviceDB.GetGoldenRecord(recordID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить эталонную запись", err)
+	}
+	if record == nil || record.ProjectID != projectID {
+		return nil, apperrors.NewNotFoundError("эталонная запись не найдена", nil)
+	}
+	return record, nil
+}
+
+// ensureProject проверяет существование проекта
+func (s *GoldenRecordService) ensureProject(projectID int) error {
+	project, err := s.serviceDB.GetClientProject(projectID)
+	if err != nil || project == nil {
+		return apperrors.NewNotFoundError("проект не найден", err)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"httpserver/database"
+	"httpserver/normalization"
+)
+
+// createGoldenTestDatabase создает базу проекта со справочником номенклатуры
+func createGoldenTestDatabase(t *testing.T, serviceDB *database.ServiceDB, projectID int, name string, items [][3]string) *database.ProjectDatabase {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".db")
+	db, err := database.NewDB(path)
+	if err != nil {
+		t.Fatalf("Failed to create project DB: %v", err)
+	}
+	upload, err := db.CreateUpload("upload-"+name, "8.3", "test")
+	if err != nil {
+		t.Fatalf("CreateUpload() error = %v", err)
+	}
+	catalog, err := db.AddCatalog(upload.ID, "Номенклатура", "Номенклатура")
+	if err != nil {
+		t.Fatalf("AddCatalog() error = %v", err)
+	}
+	for i, item := range items {
+		if err := db.AddCatalogItem(catalog.ID, name+"-ref-"+string(rune('a'+i)), item[0], item[1], item[2], nil); err != nil {
+			t.Fatalf("AddCatalogItem() error = %v", err)
+		}
+	}
+	db.Close()
+
+	projectDB, err := serviceDB.CreateProjectDatabase(projectID, name, path, "", 0)
+	if err != nil {
+		t.Fatalf("CreateProjectDatabase() error = %v", err)
+	}
+	return projectDB
+}
+
+// TestGoldenRecordService_Build проверяет сборку эталонов, правила выживания и ручные переопределения
+func TestGoldenRecordService_Build(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	db1 := createGoldenTestDatabase(t, serviceDB, project.ID, "erp", [][3]string{
+		{"001", "Болт М8", `<Реквизит Имя="Артикул" Значение="A-1"/><Реквизит Имя="Единица измерения" Значение="шт"/>`},
+		{"002", "Гайка М8", ""},
+	})
+	db2 := createGoldenTestDatabase(t, serviceDB, project.ID, "retail", [][3]string{
+		{"B-01", "болт м8", `<Реквизит Имя="Артикул" Значение="A-1-EXT"/><Реквизит Имя="Единица измерения" Значение="штука"/>`},
+	})
+
+	service := NewGoldenRecordService(serviceDB)
+	if err := service.SaveRules(project.ID, []normalization.SurvivorshipRule{
+		{Attribute: "article", Strategy: normalization.SurvivorshipSourcePriority, SourcePriority: []int{db1.ID, db2.ID}},
+		{Attribute: "bogus", Strategy: "unknown"},
+	}); err == nil {
+		t.Error("SaveRules() with unknown strategy should fail")
+	}
+	if err := service.SaveRules(project.ID, []normalization.SurvivorshipRule{
+		{Attribute: "article", Strategy: normalization.SurvivorshipSourcePriority, SourcePriority: []int{db1.ID, db2.ID}},
+	}); err != nil {
+		t.Fatalf("SaveRules() error = %v", err)
+	}
+
+	result, err := service.BuildProject(project.ID)
+	if err != nil {
+		t.Fatalf("BuildProject() error = %v", err)
+	}
+	if result.SourceItems != 3 || result.GoldenRecords != 2 {
+		t.Fatalf("BuildProject() = %+v, want 3 source items and 2 golden records", result)
+	}
+
+	records, total, err := service.ListRecords(project.ID, "олт", 0, 0)
+	if err != nil || total != 1 {
+		t.Fatalf("ListRecords() = %d records, err %v, want 1", total, err)
+	}
+	details, err := service.GetRecord(project.ID, records[0].ID)
+	if err != nil {
+		t.Fatalf("GetRecord() error = %v", err)
+	}
+	if len(details.Sources) != 2 {
+		t.Errorf("record sources = %d, want 2", len(details.Sources))
+	}
+	values := make(map[string]*GoldenAttributeDetails)
+	for _, a := range details.Attributes {
+		values[a.Attribute] = a
+	}
+	if a := values["article"]; a == nil || a.Value != "A-1" || a.SourceDatabaseID != db1.ID || len(a.Candidates) != 2 {
+		t.Errorf("article = %+v, want A-1 from database %d with 2 candidates", a, db1.ID)
+	}
+	if a := values["unit"]; a == nil || a.Value != "штука" || a.SourceDatabaseID != db2.ID {
+		t.Errorf("unit = %+v, want most complete value from database %d", a, db2.ID)
+	}
+
+	// Ручное переопределение переживает пересборку и снимается с пересчетом
+	if err := service.OverrideAttribute(project.ID, records[0].ID, "unit", "шт.", "tester", ""); err == nil {
+		t.Error("OverrideAttribute() without reason should fail")
+	}
+	if err := service.OverrideAttribute(project.ID, records[0].ID, "unit", "шт.", "tester", "ОКЕИ"); err != nil {
+		t.Fatalf("OverrideAttribute() error = %v", err)
+	}
+	if _, err := service.BuildProject(project.ID); err != nil {
+		t.Fatalf("BuildProject() error = %v", err)
+	}
+	details, _ = service.GetRecord(project.ID, records[0].ID)
+	for _, a := range details.Attributes {
+		if a.Attribute == "unit" && (a.Value != "шт." || !a.Overridden || a.OverrideBy != "tester") {
+			t.Errorf("overridden unit after rebuild = %+v", a.GoldenRecordAttribute)
+		}
+	}
+	if err := service.ClearOverride(project.ID, records[0].ID, "unit"); err != nil {
+		t.Fatalf("ClearOverride() error = %v", err)
+	}
+	details, _ = service.GetRecord(project.ID, records[0].ID)
+	for _, a := range details.Attributes {
+		if a.Attribute == "unit" && (a.Value != "штука" || a.Overridden) {
+			t.Errorf("unit after clearing override = %+v", a.GoldenRecordAttribute)
+		}
+	}
+
+	if _, err := service.GetRecord(project.ID+1, records[0].ID); err == nil {
+		t.Error("GetRecord() for another project should fail")
+	}
+}
+
+// TestGoldenRecordService_BuildKeepsRecordsOfFailedDatabase проверяет, что записи базы,
+// которую не удалось прочитать, не удаляются как устаревшие
+func TestGoldenRecordService_BuildKeepsRecordsOfFailedDatabase(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	createGoldenTestDatabase(t, serviceDB, project.ID, "erp", [][3]string{{"001", "Болт М8", ""}})
+	retail := createGoldenTestDatabase(t, serviceDB, project.ID, "retail", [][3]string{{"B-01", "Шайба М8", ""}})
+
+	service := NewGoldenRecordService(serviceDB)
+	result, err := service.BuildProject(project.ID)
+	if err != nil || result.GoldenRecords != 2 {
+		t.Fatalf("BuildProject() = %+v, err %v, want 2 golden records", result, err)
+	}
+
+	if err := os.WriteFile(retail.FilePath, []byte("not a sqlite database"), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	result, err = service.BuildProject(project.ID)
+	if err != nil {
+		t.Fatalf("BuildProject() error = %v", err)
+	}
+	if len(result.Errors) == 0 || !result.PruneSkipped || result.RemovedRecords != 0 {
+		t.Errorf("BuildProject() with failed database = %+v, want errors and skipped prune", result)
+	}
+	if _, total, err := service.ListRecords(project.ID, "", 0, 0); err != nil || total != 2 {
+		t.Errorf("ListRecords() = %d records, err %v, want 2", total, err)
+	}
+}
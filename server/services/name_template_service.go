@@ -0,0 +1,390 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	apperrors "httpserver/server/errors"
+)
+
+// maxRerenderSamples число примеров изменений в ответе повторного применения шаблонов
+const maxRerenderSamples = 20
+
+// NameTemplateRequest параметры создания или изменения шаблона имени
+type NameTemplateRequest struct {
+	Name     string `json:"name"`
+	Scope    string `json:"scope"`
+	Match    string `json:"match"`
+	Pattern  string `json:"pattern"`
+	Priority int    `json:"priority"`
+	Enabled  *bool  `json:"enabled,omitempty"` // по умолчанию true
+}
+
+// NameTemplatePreviewRequest проверка шаблона на одном наименовании без сохранения
+type NameTemplatePreviewRequest struct {
+	TemplateID int    `json:"template_id,omitempty"` // сохраненный шаблон; иначе используется pattern
+	Pattern    string `json:"pattern,omitempty"`
+	SourceName string `json:"source_name"`
+	Category   string `json:"category,omitempty"`
+	KpvedCode  string `json:"kpved_code,omitempty"`
+	Okpd2Code  string `json:"okpd2_code,omitempty"`
+}
+
+// NameTemplateRerenderSample пример имени до и после применения шаблона
+type NameTemplateRerenderSample struct {
+	ItemID       int      `json:"item_id"`
+	SourceName   string   `json:"source_name"`
+	OldName      string   `json:"old_name"`
+	NewName      string   `json:"new_name"`
+	TemplateID   int      `json:"template_id"`
+	MissingSlots []string `json:"missing_slots,omitempty"`
+}
+
+// NameTemplateRerenderResult итог повторного построения имен проекта по шаблонам
+type NameTemplateRerenderResult struct {
+	ProjectID  int  `json:"project_id"`
+	TemplateID int  `json:"template_id,omitempty"`
+	DryRun     bool `json:"dry_run"`
+	Items      int  `json:"items"`      // нормализованные элементы проекта
+	Matched    int  `json:"matched"`    // элементы, для которых выбран шаблон (с учетом template_id)
+	Changed    int  `json:"changed"`    // элементы, у которых изменилось имя
+	Incomplete int  `json:"incomplete"` // элементы с незаполненными слотами
+	// MissingSlots число элементов с незаполненным слотом по шаблонам: template_id -> слот -> количество
+	MissingSlots map[int]map[string]int       `json:"missing_slots"`
+	Samples      []NameTemplateRerenderSample `json:"samples"`
+}
+
+// NameTemplateService управляет шаблонами нормализованных имен и их применением
+type NameTemplateService struct {
+	serviceDB    *database.ServiceDB
+	normalizedDB *database.DB
+	normalizer   *normalization.Normalizer
+
+	mu             sync.RWMutex
+	set            *normalization.NameTemplateSet
+	nameNormalizer *normalization.NameNormalizer
+}
+
+// NewNameTemplateService создает сервис шаблонов имен.
+// normalizer может быть nil, тогда шаблоны применяются только к нормализации проектов и через rerender.
+func NewNameTemplateService(serviceDB *database.ServiceDB, normalizedDB *database.DB, normalizer *normalization.Normalizer) *NameTemplateService {
+	return &NameTemplateService{
+		serviceDB:      serviceDB,
+		normalizedDB:   normalizedDB,
+		normalizer:     normalizer,
+		set:            normalization.NewNameTemplateSet(nil),
+		nameNormalizer: normalization.NewNameNormalizer(),
+	}
+}
+
+// Refresh перечитывает включенные шаблоны и передает их нормализатору
+func (s *NameTemplateService) Refresh() error {
+	records, err := s.serviceDB.GetNameTemplates(true)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось загрузить шаблоны имен", err)
+	}
+	set := normalization.NewNameTemplateSet(toNameTemplates(records))
+
+	s.mu.Lock()
+	s.set = set
+	s.mu.Unlock()
+
+	if s.normalizer != nil {
+		s.normalizer.SetNameTemplates(set)
+	}
+	return nil
+}
+
+func (s *NameTemplateService) currentSet() *normalization.NameTemplateSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.set
+}
+
+// List возвращает все шаблоны
+func (s *NameTemplateService) List() ([]*database.NameTemplateRecord, error) {
+	records, err := s.serviceDB.GetNameTemplates(false)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить шаблоны имен", err)
+	}
+	if records == nil {
+		records = []*database.NameTemplateRecord{}
+	}
+	return records, nil
+}
+
+// Get возвращает шаблон по ID
+func (s *NameTemplateService) Get(id int) (*database.NameTemplateRecord, error) {
+	record, err := s.serviceDB.GetNameTemplate(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить шаблон имени", err)
+	}
+	if record == nil {
+		return nil, apperrors.NewNotFoundError("шаблон имени не найден", nil)
+	}
+	return record, nil
+}
+
+// Create проверяет и сохраняет новый шаблон
+func (s *NameTemplateService) Create(req NameTemplateRequest) (*database.NameTemplateRecord, error) {
+	record, err := nameTemplateRecordFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.serviceDB.CreateNameTemplate(record); err != nil {
+		return nil, nameTemplateStoreError(err)
+	}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s.Get(record.ID)
+}
+
+// Update проверяет и заменяет шаблон. Имена уже нормализованных элементов не меняются до вызова RerenderProject
+func (s *NameTemplateService) Update(id int, req NameTemplateRequest) (*database.NameTemplateRecord, error) {
+	record, err := nameTemplateRecordFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	record.ID = id
+	updated, err := s.serviceDB.UpdateNameTemplate(record)
+	if err != nil {
+		return nil, nameTemplateStoreError(err)
+	}
+	if !updated {
+		return nil, apperrors.NewNotFoundError("шаблон имени не найден", nil)
+	}
+	if err := s.Refresh(); err != nil {
+		return nil, err
+	}
+	return s.Get(id)
+}
+
+// Delete удаляет шаблон
+func (s *NameTemplateService) Delete(id int) error {
+	deleted, err := s.serviceDB.DeleteNameTemplate(id)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось удалить шаблон имени", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError("шаблон имени не найден", nil)
+	}
+	return s.Refresh()
+}
+
+// Preview применяет шаблон к наименованию: атрибуты извлекаются так же, как в конвейере нормализации.
+// Без template_id и pattern шаблон выбирается среди включенных по category, kpved_code и okpd2_code
+func (s *NameTemplateService) Preview(req NameTemplatePreviewRequest) (*normalization.NameTemplateRender, error) {
+	if strings.TrimSpace(req.SourceName) == "" {
+		return nil, apperrors.NewValidationError("не указано наименование source_name", nil)
+	}
+
+	baseName, attributes := s.nameNormalizer.ExtractAttributes(req.SourceName)
+	input := &normalization.NameTemplateInput{
+		SourceName: req.SourceName,
+		BaseName:   baseName,
+		Category:   req.Category,
+		KpvedCode:  req.KpvedCode,
+		Okpd2Code:  req.Okpd2Code,
+		Attributes: attributes,
+	}
+	set := s.currentSet()
+
+	var template *normalization.NameTemplate
+	switch {
+	case req.TemplateID > 0:
+		record, err := s.Get(req.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		template = toNameTemplate(record)
+	case strings.TrimSpace(req.Pattern) != "":
+		template = &normalization.NameTemplate{Pattern: req.Pattern, Enabled: true}
+	default:
+		render, _ := set.Apply(input)
+		if render == nil {
+			return nil, apperrors.NewNotFoundError("для раздела и категории нет включенного шаблона", nil)
+		}
+		return render, nil
+	}
+
+	render, err := set.Render(template, input)
+	if err != nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("некорректный шаблон: %v", err), err)
+	}
+	return render, nil
+}
+
+// ApplyToItems строит имена по шаблонам для элементов перед сохранением в normalized_data.
+// normalized_reference не меняется. Возвращает число элементов, имя которых построено по шаблону
+func (s *NameTemplateService) ApplyToItems(items []*database.NormalizedItem, attributes map[string][]*database.ItemAttribute) int {
+	set := s.currentSet()
+	if set.Len() == 0 {
+		return 0
+	}
+	applied := 0
+	for _, item := range items {
+		render, ok := set.Apply(&normalization.NameTemplateInput{
+			SourceName: item.SourceName,
+			BaseName:   item.NormalizedReference,
+			Category:   item.Category,
+			KpvedCode:  item.KpvedCode,
+			Attributes: attributes[item.Code],
+		})
+		if ok {
+			item.NormalizedName = render.Name
+			applied++
+		}
+	}
+	return applied
+}
+
+// RerenderProject заново строит имена нормализованных элементов проекта по текущим шаблонам.
+// templateID > 0 ограничивает обработку элементами, для которых выбран этот шаблон (после его изменения).
+// dryRun - только отчет без записи в базу.
+func (s *NameTemplateService) RerenderProject(projectID, templateID int, dryRun bool) (*NameTemplateRerenderResult, error) {
+	if s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база нормализованных данных недоступна", nil)
+	}
+	if project, err := s.serviceDB.GetClientProject(projectID); err != nil || project == nil {
+		return nil, apperrors.NewNotFoundError("проект не найден", err)
+	}
+	if templateID > 0 {
+		if _, err := s.Get(templateID); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := s.normalizedDB.GetProjectItemsForNameTemplates(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить нормализованные элементы проекта", err)
+	}
+
+	set := s.currentSet()
+	result := &NameTemplateRerenderResult{
+		ProjectID:    projectID,
+		TemplateID:   templateID,
+		DryRun:       dryRun,
+		MissingSlots: make(map[int]map[string]int),
+		Samples:      []NameTemplateRerenderSample{},
+	}
+	updates := make(map[int]string)
+	for _, item := range items {
+		baseName := item.NormalizedReference
+		if baseName == "" {
+			baseName = item.NormalizedName
+		}
+		render, ok := set.Apply(&normalization.NameTemplateInput{
+			SourceName: item.SourceName,
+			BaseName:   baseName,
+			Category:   item.Category,
+			KpvedCode:  item.KpvedCode,
+			Okpd2Code:  item.Okpd2Code,
+			Attributes: item.Attributes,
+		})
+		if render == nil || (templateID > 0 && render.TemplateID != templateID) {
+			continue
+		}
+		result.Matched++
+		if len(render.MissingSlots) > 0 {
+			result.Incomplete++
+			slots := result.MissingSlots[render.TemplateID]
+			if slots == nil {
+				slots = make(map[string]int)
+				result.MissingSlots[render.TemplateID] = slots
+			}
+			for _, slot := range render.MissingSlots {
+				slots[slot]++
+			}
+		}
+		if !ok || render.Name == item.NormalizedName {
+			continue
+		}
+		result.Changed++
+		updates[item.ID] = render.Name
+		if len(result.Samples) < maxRerenderSamples {
+			result.Samples = append(result.Samples, NameTemplateRerenderSample{
+				ItemID:       item.ID,
+				SourceName:   item.SourceName,
+				OldName:      item.NormalizedName,
+				NewName:      render.Name,
+				TemplateID:   render.TemplateID,
+				MissingSlots: render.MissingSlots,
+			})
+		}
+	}
+	result.Items = len(items)
+
+	if !dryRun {
+		if err := s.normalizedDB.UpdateNormalizedNames(updates); err != nil {
+			return nil, apperrors.NewInternalError("не удалось обновить имена элементов", err)
+		}
+	}
+	return result, nil
+}
+
+// nameTemplateRecordFromRequest проверяет параметры шаблона
+func nameTemplateRecordFromRequest(req NameTemplateRequest) (*database.NameTemplateRecord, error) {
+	scope := normalization.NameTemplateScope(strings.ToLower(strings.TrimSpace(req.Scope)))
+	if !normalization.IsValidNameTemplateScope(scope) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестная область шаблона %q: допустимы kpved, okpd2, category", req.Scope), nil)
+	}
+	match := strings.TrimSpace(req.Match)
+	if scope != normalization.NameTemplateScopeCategory {
+		match = strings.TrimSuffix(match, ".")
+	}
+	if match == "" {
+		return nil, apperrors.NewValidationError("не указан раздел классификатора или категория (match)", nil)
+	}
+	if _, err := normalization.ParseNameTemplatePattern(req.Pattern); err != nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("некорректный шаблон: %v", err), err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s %s", scope, match)
+	}
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return &database.NameTemplateRecord{
+		Name:     name,
+		Scope:    string(scope),
+		Match:    match,
+		Pattern:  strings.TrimSpace(req.Pattern),
+		Priority: req.Priority,
+		Enabled:  enabled,
+	}, nil
+}
+
+func nameTemplateStoreError(err error) error {
+	if errors.Is(err, database.ErrNameTemplateConflict) {
+		return apperrors.NewConflictError("шаблон для этого раздела уже существует", err)
+	}
+	return apperrors.NewInternalError("не удалось сохранить шаблон имени", err)
+}
+
+func toNameTemplate(record *database.NameTemplateRecord) *normalization.NameTemplate {
+	return &normalization.NameTemplate{
+		ID:       record.ID,
+		Name:     record.Name,
+		Scope:    normalization.NameTemplateScope(record.Scope),
+		Match:    record.Match,
+		Pattern:  record.Pattern,
+		Priority: record.Priority,
+		Enabled:  record.Enabled,
+	}
+}
+
+func toNameTemplates(records []*database.NameTemplateRecord) []*normalization.NameTemplate {
+	templates := make([]*normalization.NameTemplate, 0, len(records))
+	for _, record := range records {
+		templates = append(templates, toNameTemplate(record))
+	}
+	return templates
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/database"
+)
+
+// TestNameTemplateService_RerenderProject проверяет отчет dry-run, запись имен и отчет о незаполненных слотах
+func TestNameTemplateService_RerenderProject(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	normalizedDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create normalized DB: %v", err)
+	}
+	defer normalizedDB.Close()
+
+	projectID := project.ID
+	items := []*database.NormalizedItem{
+		{SourceName: "Труба стальная 57х3,5 ГОСТ 10704-91", Code: "001", NormalizedName: "труба стальная", NormalizedReference: "труба стальная", KpvedCode: "24.20.13", MergedCount: 1},
+		{SourceName: "Труба", Code: "002", NormalizedName: "труба", NormalizedReference: "труба", KpvedCode: "24.20.13", MergedCount: 1},
+		{SourceName: "Болт М8", Code: "003", NormalizedName: "болт", NormalizedReference: "болт", KpvedCode: "25.94.11", MergedCount: 1},
+	}
+	attrs := map[string][]*database.ItemAttribute{
+		"001": {{AttributeType: "dimension", AttributeName: "width", AttributeValue: "57", OriginalText: "57х3,5", Confidence: 1}},
+	}
+	codeToID, err := normalizedDB.InsertNormalizedItemsWithAttributesBatch(items, attrs, nil, &projectID)
+	if err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	service := NewNameTemplateService(serviceDB, normalizedDB, nil)
+	template, err := service.Create(NameTemplateRequest{Scope: "kpved", Match: "24.20.", Pattern: "{type} {material} {dimensions} {standard}"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if template.Match != "24.20" || !template.Enabled {
+		t.Errorf("created template = %+v", template)
+	}
+	if _, err := service.Create(NameTemplateRequest{Scope: "kpved", Match: "24.20", Pattern: "{type}"}); err == nil {
+		t.Error("Create() duplicate must fail")
+	}
+	if _, err := service.Create(NameTemplateRequest{Scope: "section", Match: "24", Pattern: "{type}"}); err == nil {
+		t.Error("Create() with unknown scope must fail")
+	}
+
+	dryRun, err := service.RerenderProject(projectID, 0, true)
+	if err != nil {
+		t.Fatalf("RerenderProject(dry run) error = %v", err)
+	}
+	if dryRun.Items != 3 || dryRun.Matched != 2 || dryRun.Changed != 1 || dryRun.Incomplete != 1 {
+		t.Errorf("dry run result = %+v", dryRun)
+	}
+	if missing := dryRun.MissingSlots[template.ID]; missing["material"] != 1 || missing["dimensions"] != 1 || missing["standard"] != 1 {
+		t.Errorf("missing slots = %v", dryRun.MissingSlots)
+	}
+	item, _ := normalizedDB.GetNormalizedItem(codeToID["001"])
+	if item.NormalizedName != "труба стальная" {
+		t.Fatalf("dry run must not change names, got %q", item.NormalizedName)
+	}
+
+	if _, err := service.RerenderProject(projectID, template.ID, false); err != nil {
+		t.Fatalf("RerenderProject() error = %v", err)
+	}
+	item, _ = normalizedDB.GetNormalizedItem(codeToID["001"])
+	if want := "труба сталь 57х3,5 ГОСТ 10704-91"; item.NormalizedName != want {
+		t.Errorf("NormalizedName = %q, want %q", item.NormalizedName, want)
+	}
+
+	// Повторный запуск ничего не меняет
+	again, err := service.RerenderProject(projectID, 0, false)
+	if err != nil || again.Changed != 0 {
+		t.Errorf("second RerenderProject() = %+v, %v", again, err)
+	}
+}
@@ -394,6 +394,46 @@ func (ns *NormalizationService) GetSessionHistory(sessionID int) (map[string]int
 	}, nil
 }
 
+// ExplainItem возвращает цепочку решений конвейера, которая дала нормализованное имя и коды элемента.
+// Для элементов, нормализованных до появления записей решений, steps пустой и trace_available = false.
+// db - база с normalized_data (nil - основная база сервиса)
+func (ns *NormalizationService) ExplainItem(db *database.DB, itemID int) (map[string]interface{}, error) {
+	if db == nil {
+		db = ns.db
+	}
+	if db == nil {
+		return nil, apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+
+	item, err := db.GetNormalizedItem(itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NewNotFoundError("нормализованная запись не найдена", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось получить нормализованную запись", err)
+	}
+
+	rawTrace, err := db.GetNormalizedItemDecision(itemID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить запись решений", err)
+	}
+	trace, err := normalization.ParseDecisionTrace(rawTrace)
+	if err != nil {
+		return nil, apperrors.NewInternalError("запись решений повреждена", err)
+	}
+
+	steps := trace.Steps
+	if steps == nil {
+		steps = []normalization.DecisionStep{}
+	}
+
+	return map[string]interface{}{
+		"item":            item,
+		"trace_available": len(steps) > 0,
+		"steps":           steps,
+	}, nil
+}
+
 // RevertStage откатывает сессию к указанной стадии
 func (ns *NormalizationService) RevertStage(sessionID, stageIndex int, getArliaiAPIKey func() string) (map[string]interface{}, error) {
 	if ns.db == nil {
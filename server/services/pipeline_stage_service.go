@@ -0,0 +1,233 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	apperrors "httpserver/server/errors"
+)
+
+// PipelineStageService управляет конвейером этапов нормализации: реестр этапов,
+// конфигурация DAG проекта и проверочный прогон этапов по сохраненной номенклатуре проекта.
+// Прогон записывает только результаты этапов и не меняет normalized_data
+type PipelineStageService struct {
+	serviceDB    *database.ServiceDB
+	normalizedDB *database.DB
+	registry     *normalization.StageRegistry
+}
+
+// NewPipelineStageService создает сервис конвейера этапов
+func NewPipelineStageService(serviceDB *database.ServiceDB, normalizedDB *database.DB, registry *normalization.StageRegistry) *PipelineStageService {
+	return &PipelineStageService{
+		serviceDB:    serviceDB,
+		normalizedDB: normalizedDB,
+		registry:     registry,
+	}
+}
+
+// PipelineStageConfigView конфигурация конвейера проекта вместе с собранным графом
+type PipelineStageConfigView struct {
+	ProjectID int                                 `json:"project_id"`
+	IsDefault bool                                `json:"is_default"`
+	Stages    []database.PipelineStageConfigEntry `json:"stages"`
+	Graph     []*normalization.StageNode          `json:"graph"`
+	UpdatedAt *time.Time                          `json:"updated_at,omitempty"`
+}
+
+// PipelineStageRunCounts число элементов по статусам для этапа
+type PipelineStageRunCounts struct {
+	StageID   string `json:"stage_id"`
+	Completed int    `json:"completed"`
+	Review    int    `json:"review"`
+	Rejected  int    `json:"rejected"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+}
+
+// PipelineStageRunSummary итог выполнения конвейера для проекта
+type PipelineStageRunSummary struct {
+	ProjectID  int                       `json:"project_id"`
+	Items      int                       `json:"items"`
+	Stages     []*PipelineStageRunCounts `json:"stages"`
+	DurationMs int64                     `json:"duration_ms"`
+}
+
+// PipelineStageResultsView статистика результатов проверочных прогонов по этапам
+type PipelineStageResultsView struct {
+	Stages         []*database.StageResultStats  `json:"stages"`
+	StageDetails   *database.StageResultStats    `json:"stage_details,omitempty"`
+	RecentFailures []*database.StageResultRecord `json:"recent_failures,omitempty"`
+}
+
+// Stages возвращает описания всех доступных этапов
+func (s *PipelineStageService) Stages() []normalization.StageSpec {
+	return s.registry.Specs()
+}
+
+// GetConfig возвращает конфигурацию конвейера проекта; если она не задана - конфигурацию по умолчанию
+func (s *PipelineStageService) GetConfig(projectID int) (*PipelineStageConfigView, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	stored, err := s.serviceDB.GetPipelineStageConfig(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить конфигурацию конвейера", err)
+	}
+
+	view := &PipelineStageConfigView{ProjectID: projectID, IsDefault: stored == nil}
+	if stored != nil {
+		view.Stages = stored.Stages
+		view.UpdatedAt = &stored.UpdatedAt
+	} else {
+		view.Stages = s.registry.DefaultConfig()
+	}
+	graph, err := normalization.BuildStageGraph(s.registry, view.Stages)
+	if err != nil {
+		// Сохраненная конфигурация могла стать некорректной после изменения набора этапов
+		return nil, apperrors.NewConflictError(fmt.Sprintf("конфигурация конвейера проекта некорректна: %v", err), err)
+	}
+	view.Graph = graph.Nodes()
+	return view, nil
+}
+
+// UpdateConfig проверяет и сохраняет конфигурацию конвейера проекта
+func (s *PipelineStageService) UpdateConfig(projectID int, stages []database.PipelineStageConfigEntry) (*PipelineStageConfigView, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, apperrors.NewValidationError("список этапов не может быть пустым", nil)
+	}
+	if _, err := normalization.BuildStageGraph(s.registry, stages); err != nil {
+		return nil, apperrors.NewValidationError(err.Error(), err)
+	}
+	if err := s.serviceDB.SavePipelineStageConfig(&database.PipelineStageConfig{ProjectID: projectID, Stages: stages}); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить конфигурацию конвейера", err)
+	}
+	return s.GetConfig(projectID)
+}
+
+// ResetConfig удаляет конфигурацию проекта, возвращая конвейер по умолчанию
+func (s *PipelineStageService) ResetConfig(projectID int) (*PipelineStageConfigView, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	if err := s.serviceDB.DeletePipelineStageConfig(projectID); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сбросить конфигурацию конвейера", err)
+	}
+	return s.GetConfig(projectID)
+}
+
+// GraphForProject собирает граф этапов по конфигурации проекта
+func (s *PipelineStageService) GraphForProject(projectID int) (*normalization.StageGraph, error) {
+	view, err := s.GetConfig(projectID)
+	if err != nil {
+		return nil, err
+	}
+	return normalization.BuildStageGraph(s.registry, view.Stages)
+}
+
+// RunProject выполняет конвейер этапов для нормализованной номенклатуры проекта и сохраняет результаты этапов.
+// Записи normalized_data не меняются
+func (s *PipelineStageService) RunProject(projectID int) (*PipelineStageRunSummary, error) {
+	if s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база нормализованных данных недоступна", nil)
+	}
+	start := time.Now()
+	graph, err := s.GraphForProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.normalizedDB.GetProjectItemsForNameTemplates(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить номенклатуру проекта", err)
+	}
+
+	stageItems := make([]*normalization.StageItem, 0, len(items))
+	for _, item := range items {
+		stageItems = append(stageItems, normalization.NewStageItemWithKpved(item.ID, item.SourceName, "", item.Category, item.KpvedCode))
+	}
+	results, err := graph.RunAndSave(s.normalizedDB, stageItems)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить результаты этапов", err)
+	}
+
+	summary := &PipelineStageRunSummary{ProjectID: projectID, Items: len(stageItems)}
+	counts := make(map[string]*PipelineStageRunCounts)
+	for _, node := range graph.Nodes() {
+		counts[node.ID] = &PipelineStageRunCounts{StageID: node.ID}
+		summary.Stages = append(summary.Stages, counts[node.ID])
+	}
+	for _, result := range results {
+		c := counts[result.StageID]
+		switch result.Status {
+		case database.StageStatusCompleted:
+			c.Completed++
+		case database.StageStatusReview:
+			c.Review++
+		case database.StageStatusRejected:
+			c.Rejected++
+		case database.StageStatusSkipped:
+			c.Skipped++
+		case database.StageStatusFailed:
+			c.Failed++
+		}
+	}
+	summary.DurationMs = time.Since(start).Milliseconds()
+	return summary, nil
+}
+
+// GetResultStats возвращает статистику результатов этапов; для stageID - также последние ошибки и отбраковки этапа
+func (s *PipelineStageService) GetResultStats(stageID string) (*PipelineStageResultsView, error) {
+	if s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база нормализованных данных недоступна", nil)
+	}
+	stats, err := database.GetStageResultStats(s.normalizedDB)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить статистику этапов", err)
+	}
+	view := &PipelineStageResultsView{Stages: stats}
+	if stageID == "" {
+		return view, nil
+	}
+	for _, stat := range stats {
+		if stat.StageID == stageID {
+			view.StageDetails = stat
+		}
+	}
+	if view.StageDetails == nil {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("результаты этапа %s не найдены", stageID), nil)
+	}
+	view.RecentFailures, err = database.GetStageFailures(s.normalizedDB, stageID, 20)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить ошибки этапа", err)
+	}
+	return view, nil
+}
+
+// GetItemResults возвращает результаты всех этапов для нормализованного элемента
+func (s *PipelineStageService) GetItemResults(itemID int) ([]*database.StageResultRecord, error) {
+	if s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база нормализованных данных недоступна", nil)
+	}
+	results, err := s.normalizedDB.GetItemStageResults(itemID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить результаты этапов", err)
+	}
+	if len(results) == 0 {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("результаты этапов для элемента %d не найдены", itemID), nil)
+	}
+	return results, nil
+}
+
+func (s *PipelineStageService) checkProject(projectID int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	if _, err := s.serviceDB.GetClientProject(projectID); err != nil {
+		return apperrors.NewNotFoundError(fmt.Sprintf("проект %d не найден", projectID), err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/database"
+	"httpserver/normalization"
+)
+
+// TestPipelineStageService проверяет конфигурацию графа этапов проекта и проверочный прогон этапов для номенклатуры
+func TestPipelineStageService(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	normalizedDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create normalized DB: %v", err)
+	}
+	defer normalizedDB.Close()
+
+	registry, err := normalization.NewDefaultStageRegistry(normalization.StageDependencies{})
+	if err != nil {
+		t.Fatalf("NewDefaultStageRegistry() error = %v", err)
+	}
+	service := NewPipelineStageService(serviceDB, normalizedDB, registry)
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	config, err := service.GetConfig(project.ID)
+	if err != nil || !config.IsDefault || len(config.Graph) != len(registry.Specs()) {
+		t.Fatalf("GetConfig() = %+v, %v", config, err)
+	}
+
+	// Отключение этапа, от которого зависят включенные этапы, отклоняется
+	if _, err := service.UpdateConfig(project.ID, []database.PipelineStageConfigEntry{
+		{ID: "lowercase"}, {ID: "grouping", Enabled: true},
+	}); err == nil {
+		t.Error("UpdateConfig() with disabled dependency expected error")
+	}
+	config, err = service.UpdateConfig(project.ID, []database.PipelineStageConfigEntry{
+		{ID: "lowercase", Enabled: true}, {ID: "grouping", Enabled: true}, {ID: "final_decision", Enabled: true},
+	})
+	if err != nil || config.IsDefault || len(config.Graph) != 3 {
+		t.Fatalf("UpdateConfig() = %+v, %v", config, err)
+	}
+
+	projectID := project.ID
+	items := []*database.NormalizedItem{
+		{SourceName: "Болт М12х50", Code: "001", NormalizedName: "болт", Category: "Крепеж", KpvedCode: "25.94.11", MergedCount: 1},
+		{SourceName: "Гайка М12", Code: "002", NormalizedName: "гайка", Category: "Крепеж", MergedCount: 1},
+	}
+	codeToID, err := normalizedDB.InsertNormalizedItemsWithAttributesBatch(items, nil, nil, &projectID)
+	if err != nil {
+		t.Fatalf("InsertNormalizedItemsWithAttributesBatch() error = %v", err)
+	}
+
+	summary, err := service.RunProject(project.ID)
+	if err != nil {
+		t.Fatalf("RunProject() error = %v", err)
+	}
+	if summary.Items != 2 || len(summary.Stages) != 3 {
+		t.Fatalf("summary = %+v", summary)
+	}
+	final := summary.Stages[2]
+	if final.StageID != "final_decision" || final.Completed != 1 || final.Review != 1 {
+		t.Errorf("final_decision counts = %+v", final)
+	}
+
+	results, err := service.GetItemResults(codeToID["001"])
+	if err != nil || len(results) != 3 {
+		t.Fatalf("GetItemResults() = %d results, %v", len(results), err)
+	}
+	if _, err := service.GetItemResults(codeToID["002"] + 100); err == nil {
+		t.Error("GetItemResults() for unknown item expected error")
+	}
+
+	// Прогон не меняет сохраненные записи
+	saved, err := normalizedDB.GetProjectItemsForNameTemplates(project.ID)
+	if err != nil || len(saved) != 2 {
+		t.Fatalf("GetProjectItemsForNameTemplates() = %d, %v", len(saved), err)
+	}
+	for _, item := range saved {
+		if item.ID == codeToID["002"] && (item.KpvedCode != "" || item.NormalizedName != "гайка") {
+			t.Errorf("normalized item changed by stage run: %+v", item)
+		}
+	}
+
+	stats, err := service.GetResultStats("final_decision")
+	if err != nil || len(stats.Stages) != 3 || stats.StageDetails == nil || stats.StageDetails.Review != 1 {
+		t.Fatalf("GetResultStats() = %+v, %v", stats, err)
+	}
+	if _, err := service.GetResultStats("unknown"); err == nil {
+		t.Error("GetResultStats() for unknown stage expected error")
+	}
+
+	if config, err := service.ResetConfig(project.ID); err != nil || !config.IsDefault {
+		t.Errorf("ResetConfig() = %+v, %v", config, err)
+	}
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"httpserver/database"
+	"httpserver/quality"
+	apperrors "httpserver/server/errors"
+)
+
+// QualityAnomalyOptions параметры поиска аномалий в трендах качества
+type QualityAnomalyOptions struct {
+	Lookback    time.Duration               // глубина истории для рядов без базовой линии
+	AlertWindow time.Duration               // аномалии в более старых значениях записываются без уведомления
+	Detector    quality.TrendDetectorConfig // параметры для метрик в процентах; для счетчиков и долей границы сигмы свои
+}
+
+// QualityAnomalyAlert уведомление о новой аномалии или о повышении ее важности
+type QualityAnomalyAlert struct {
+	Anomaly   *database.QualityAnomaly `json:"anomaly"`
+	Escalated bool                     `json:"escalated"`
+}
+
+// QualityAnomalyRun итог анализа рядов одной базы данных
+type QualityAnomalyRun struct {
+	DatabaseID   int                        `json:"database_id"`
+	Observations int                        `json:"observations"` // новых значений, учтенных в базовых линиях
+	Series       int                        `json:"series"`
+	Detected     int                        `json:"detected"` // новых аномалий
+	Repeated     int                        `json:"repeated"` // повторных срабатываний открытых аномалий
+	Resolved     int                        `json:"resolved"` // закрыто после возврата метрики к прежнему уровню
+	Anomalies    []*database.QualityAnomaly `json:"anomalies"`
+}
+
+// QualityAnomalySummary сводка открытых аномалий для дашборда
+type QualityAnomalySummary struct {
+	Open       int                        `json:"open"`
+	BySeverity map[string]int             `json:"by_severity"`
+	Recent     []*database.QualityAnomaly `json:"recent"`
+}
+
+// QualityAnomalyService ведет базовые линии рядов метрик качества (EWMA и CUSUM по каждой базе
+// и метрике) и записывает аномалии — изменения уровня в неблагоприятную сторону
+type QualityAnomalyService struct {
+	db      *database.DB
+	opts    QualityAnomalyOptions
+	scores  *quality.TrendDetector // баллы и метрики в процентах
+	counts  *quality.TrendDetector // число проблем
+	rates   *quality.TrendDetector // доли 0-1 из метрик производительности
+	onAlert func(*QualityAnomalyAlert)
+	now     func() time.Time
+
+	mu sync.Mutex
+}
+
+// NewQualityAnomalyService создает сервис поиска аномалий в трендах качества
+func NewQualityAnomalyService(db *database.DB, opts QualityAnomalyOptions) *QualityAnomalyService {
+	if opts.Lookback <= 0 {
+		opts.Lookback = 90 * 24 * time.Hour
+	}
+	if opts.AlertWindow <= 0 {
+		opts.AlertWindow = 7 * 24 * time.Hour
+	}
+	countsCfg := opts.Detector
+	countsCfg.MinSigma, countsCfg.RelativeSigma = 1, 0.2
+	ratesCfg := opts.Detector
+	ratesCfg.MinSigma, ratesCfg.RelativeSigma = 0.02, 0
+
+	return &QualityAnomalyService{
+		db:     db,
+		opts:   opts,
+		scores: quality.NewTrendDetector(opts.Detector),
+		counts: quality.NewTrendDetector(countsCfg),
+		rates:  quality.NewTrendDetector(ratesCfg),
+		now:    time.Now,
+	}
+}
+
+// SetAlertHandler задает обработчик уведомлений о новых аномалиях и повышении их важности
+func (s *QualityAnomalyService) SetAlertHandler(handler func(*QualityAnomalyAlert)) {
+	s.onAlert = handler
+}
+
+// seriesProfile возвращает детектор ряда и благоприятное направление изменения
+func (s *QualityAnomalyService) seriesProfile(metric string) (detector *quality.TrendDetector, higherIsBetter bool) {
+	switch {
+	case strings.HasPrefix(metric, "issues."), metric == "trend.issues_count":
+		return s.counts, false
+	case strings.HasPrefix(metric, "perf."):
+		return s.rates, true
+	default:
+		return s.scores, true
+	}
+}
+
+// AnalyzeDatabase учитывает новые значения рядов базы данных: метрики и проблемы выгрузок
+// и дневные тренды. Вызывается после анализа качества выгрузки.
+func (s *QualityAnomalyService) AnalyzeDatabase(databaseID int) (*QualityAnomalyRun, error) {
+	if databaseID <= 0 {
+		return nil, apperrors.NewValidationError("database_id должен быть положительным", nil)
+	}
+	return s.analyze(databaseID, func(since time.Time) ([]database.QualityObservation, error) {
+		return s.db.GetQualityObservations(databaseID, since)
+	})
+}
+
+// AnalyzeSystemMetrics учитывает часовые значения метрик производительности сервера
+// (доля успешных вызовов AI, попадания в кэш). Ряды хранятся с database_id = 0.
+func (s *QualityAnomalyService) AnalyzeSystemMetrics() (*QualityAnomalyRun, error) {
+	return s.analyze(database.SystemMetricsDatabaseID, s.db.GetPerformanceObservations)
+}
+
+func (s *QualityAnomalyService) analyze(databaseID int, load func(since time.Time) ([]database.QualityObservation, error)) (*QualityAnomalyRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	observations, err := load(now.Add(-s.opts.Lookback))
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить историю метрик", err)
+	}
+	stored, err := s.db.GetQualityMetricBaselines(databaseID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить базовые линии метрик", err)
+	}
+	active, err := s.db.GetActiveQualityAnomalies(databaseID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить открытые аномалии", err)
+	}
+
+	baselines := make(map[string]*database.QualityMetricBaseline, len(stored))
+	for _, b := range stored {
+		baselines[b.Metric] = b
+	}
+	activeByKey := make(map[string]*database.QualityAnomaly, len(active))
+	for _, a := range active {
+		activeByKey[a.Metric+"|"+a.Direction] = a
+	}
+
+	run := &QualityAnomalyRun{DatabaseID: databaseID, Anomalies: []*database.QualityAnomaly{}}
+	changed := make(map[string]bool)
+	var alerts []*QualityAnomalyAlert
+	for _, obs := range observations {
+		b := baselines[obs.Metric]
+		if b == nil {
+			b = &database.QualityMetricBaseline{DatabaseID: databaseID, Metric: obs.Metric}
+			baselines[obs.Metric] = b
+		}
+		if !obs.ObservedAt.After(b.LastObservedAt) {
+			continue
+		}
+		detector, higherIsBetter := s.seriesProfile(obs.Metric)
+
+		state := quality.TrendBaseline{Mean: b.Mean, Variance: b.Variance, CUSUMHigh: b.CUSUMHigh, CUSUMLow: b.CUSUMLow, Samples: b.Samples}
+		signal := detector.Observe(&state, obs.Value)
+		b.Mean, b.Variance, b.CUSUMHigh, b.CUSUMLow, b.Samples = state.Mean, state.Variance, state.CUSUMHigh, state.CUSUMLow, state.Samples
+		b.LastValue, b.LastObservedAt = obs.Value, obs.ObservedAt
+		changed[obs.Metric] = true
+		run.Observations++
+
+		// Метрика вернулась к уровню до аномалии
+		for _, direction := range []string{quality.TrendDirectionUp, quality.TrendDirectionDown} {
+			key := obs.Metric + "|" + direction
+			if a := activeByKey[key]; a != nil && adverseGap(a.Direction, a.ExpectedValue, obs.Value) < a.Sigma {
+				if err := s.db.UpdateQualityAnomalyStatus(a.ID, database.QualityAnomalyStatusResolved, now); err != nil {
+					return nil, apperrors.NewInternalError("не удалось закрыть аномалию", err)
+				}
+				delete(activeByKey, key)
+				run.Resolved++
+			}
+		}
+
+		if signal == nil || (signal.Direction == quality.TrendDirectionUp) == higherIsBetter {
+			// Улучшение метрики аномалией не считается, базовая линия просто следует за ним
+			continue
+		}
+		severity := qualityAnomalySeverity(signal, detector.Config().EWMAThreshold)
+		var uploadID *int
+		if obs.UploadID > 0 {
+			id := obs.UploadID
+			uploadID = &id
+		}
+		notify := now.Sub(obs.ObservedAt) <= s.opts.AlertWindow
+
+		key := obs.Metric + "|" + signal.Direction
+		if a := activeByKey[key]; a != nil {
+			escalated := severityRank(severity) > severityRank(a.Severity)
+			if escalated {
+				a.Severity = severity
+			}
+			a.ObservedValue, a.Deviation, a.UploadID = signal.Observed, signal.Deviation, uploadID
+			a.Occurrences++
+			a.LastSeenAt = obs.ObservedAt
+			if err := s.db.UpdateQualityAnomalyOccurrence(a); err != nil {
+				return nil, apperrors.NewInternalError("не удалось обновить аномалию", err)
+			}
+			run.Repeated++
+			if escalated && notify && a.Status == database.QualityAnomalyStatusOpen {
+				alerts = append(alerts, &QualityAnomalyAlert{Anomaly: a, Escalated: true})
+			}
+			continue
+		}
+
+		a := &database.QualityAnomaly{
+			DatabaseID:    databaseID,
+			Metric:        obs.Metric,
+			Detector:      signal.Detector,
+			Direction:     signal.Direction,
+			Severity:      severity,
+			Status:        database.QualityAnomalyStatusOpen,
+			ObservedValue: signal.Observed,
+			ExpectedValue: signal.Expected,
+			Sigma:         signal.Sigma,
+			Deviation:     signal.Deviation,
+			UploadID:      uploadID,
+			DetectedAt:    obs.ObservedAt,
+		}
+		if err := s.db.CreateQualityAnomaly(a); err != nil {
+			return nil, apperrors.NewInternalError("не удалось сохранить аномалию", err)
+		}
+		activeByKey[key] = a
+		run.Detected++
+		run.Anomalies = append(run.Anomalies, a)
+		if notify {
+			alerts = append(alerts, &QualityAnomalyAlert{Anomaly: a})
+		}
+	}
+
+	for metric := range changed {
+		if err := s.db.SaveQualityMetricBaseline(baselines[metric]); err != nil {
+			return nil, apperrors.NewInternalError("не удалось сохранить базовую линию метрики", err)
+		}
+	}
+	run.Series = len(baselines)
+
+	if s.onAlert != nil {
+		for _, alert := range alerts {
+			s.onAlert(alert)
+		}
+	}
+	return run, nil
+}
+
+// adverseGap возвращает отклонение значения от уровня до аномалии в сторону аномалии
+func adverseGap(direction string, expected, value float64) float64 {
+	if direction == quality.TrendDirectionDown {
+		return expected - value
+	}
+	return value - expected
+}
+
+// qualityAnomalySeverity определяет важность по отклонению в сигмах относительно порога скачка:
+// дрейф, не превысивший порог, — low, скачок — medium, вдвое больше порога — high, втрое — critical
+func qualityAnomalySeverity(signal *quality.TrendSignal, threshold float64) string {
+	deviation := math.Abs(signal.Deviation)
+	switch {
+	case deviation >= 3*threshold:
+		return database.QualityAnomalySeverityCritical
+	case deviation >= 2*threshold:
+		return database.QualityAnomalySeverityHigh
+	case deviation >= threshold:
+		return database.QualityAnomalySeverityMedium
+	default:
+		return database.QualityAnomalySeverityLow
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case database.QualityAnomalySeverityCritical:
+		return 3
+	case database.QualityAnomalySeverityHigh:
+		return 2
+	case database.QualityAnomalySeverityMedium:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// GetAnomalies возвращает аномалии по фильтру
+func (s *QualityAnomalyService) GetAnomalies(filter database.QualityAnomalyFilter) ([]*database.QualityAnomaly, int, error) {
+	anomalies, total, err := s.db.GetQualityAnomalies(filter)
+	if err != nil {
+		return nil, 0, apperrors.NewInternalError("не удалось получить аномалии", err)
+	}
+	return anomalies, total, nil
+}
+
+// GetAnomaly возвращает аномалию по ID
+func (s *QualityAnomalyService) GetAnomaly(id int) (*database.QualityAnomaly, error) {
+	anomaly, err := s.db.GetQualityAnomaly(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить аномалию", err)
+	}
+	if anomaly == nil {
+		return nil, apperrors.NewNotFoundError("аномалия не найдена", nil)
+	}
+	return anomaly, nil
+}
+
+// UpdateStatus принимает аномалию в работу (acknowledged), закрывает (resolved) или открывает заново
+func (s *QualityAnomalyService) UpdateStatus(id int, status string) (*database.QualityAnomaly, error) {
+	switch status {
+	case database.QualityAnomalyStatusOpen, database.QualityAnomalyStatusAcknowledged, database.QualityAnomalyStatusResolved:
+	default:
+		return nil, apperrors.NewValidationError(fmt.Sprintf("недопустимый статус аномалии: %q", status), nil)
+	}
+	s.mu.Lock()
+	err := s.db.UpdateQualityAnomalyStatus(id, status, s.now())
+	s.mu.Unlock()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperrors.NewNotFoundError("аномалия не найдена", err)
+	}
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось изменить статус аномалии", err)
+	}
+	return s.GetAnomaly(id)
+}
+
+// GetBaselines возвращает базовые линии рядов базы данных
+func (s *QualityAnomalyService) GetBaselines(databaseID int) ([]*database.QualityMetricBaseline, error) {
+	baselines, err := s.db.GetQualityMetricBaselines(databaseID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить базовые линии метрик", err)
+	}
+	if baselines == nil {
+		baselines = []*database.QualityMetricBaseline{}
+	}
+	return baselines, nil
+}
+
+// Summary возвращает число открытых аномалий по важности и последние из них
+func (s *QualityAnomalyService) Summary(recent int) (*QualityAnomalySummary, error) {
+	counts, err := s.db.CountOpenQualityAnomalies()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось посчитать аномалии", err)
+	}
+	summary := &QualityAnomalySummary{BySeverity: counts}
+	for _, count := range counts {
+		summary.Open += count
+	}
+	summary.Recent, _, err = s.db.GetQualityAnomalies(database.QualityAnomalyFilter{
+		Status: database.QualityAnomalyStatusOpen,
+		Limit:  recent,
+	})
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить аномалии", err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"httpserver/database"
+)
+
+// addQualityUpload сохраняет метрику полноты и проблемы invalid_inn выгрузки, как это делает анализатор качества
+func addQualityUpload(t *testing.T, db *database.DB, databaseID, n int, completeness float64, invalidINN int, at time.Time) {
+	t.Helper()
+	upload, err := db.CreateUploadWithDatabase(fmt.Sprintf("upload-%d", n), "8.3", "УТ", &databaseID, "", "", "", n, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("CreateUploadWithDatabase: %v", err)
+	}
+	if err := db.SaveQualityMetric(&database.DataQualityMetric{
+		UploadID: upload.ID, DatabaseID: databaseID, MetricCategory: "completeness",
+		MetricName: "counterparty_completeness", MetricValue: completeness, Status: "PASS", MeasuredAt: at,
+	}); err != nil {
+		t.Fatalf("SaveQualityMetric: %v", err)
+	}
+	for i := 0; i < invalidINN; i++ {
+		if err := db.SaveQualityIssue(&database.DataQualityIssue{
+			UploadID: upload.ID, DatabaseID: databaseID, EntityType: "counterparty",
+			EntityReference: fmt.Sprintf("ref-%d-%d", n, i), IssueType: "invalid_inn",
+			IssueSeverity: "HIGH", DetectedAt: at, Status: "OPEN",
+		}); err != nil {
+			t.Fatalf("SaveQualityIssue: %v", err)
+		}
+	}
+}
+
+func TestQualityAnomalyService_DetectAndResolve(t *testing.T) {
+	db, err := database.NewDB(filepath.Join(t.TempDir(), "main.db"))
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	service := NewQualityAnomalyService(db, QualityAnomalyOptions{})
+	start := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	service.now = func() time.Time { return start.Add(12 * time.Hour) }
+	var alerts []*QualityAnomalyAlert
+	service.SetAlertHandler(func(alert *QualityAnomalyAlert) { alerts = append(alerts, alert) })
+
+	const databaseID = 7
+	history := []float64{95.2, 94.8, 95.1, 94.9, 95.0, 95.3, 94.7, 95.1}
+	for i, completeness := range history {
+		addQualityUpload(t, db, databaseID, i, completeness, 2+i%2, start.Add(time.Duration(i)*time.Hour))
+		if _, err := service.AnalyzeDatabase(databaseID); err != nil {
+			t.Fatalf("AnalyzeDatabase: %v", err)
+		}
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("alerts on stable history: %+v", alerts[0].Anomaly)
+	}
+
+	// Обновление конфигурации 1С: полнота упала, число невалидных ИНН выросло
+	addQualityUpload(t, db, databaseID, 8, 72, 40, start.Add(8*time.Hour))
+	run, err := service.AnalyzeDatabase(databaseID)
+	if err != nil {
+		t.Fatalf("AnalyzeDatabase: %v", err)
+	}
+	if run.Detected != 2 || len(alerts) != 2 {
+		t.Fatalf("run = %+v, alerts = %d, want 2 anomalies", run, len(alerts))
+	}
+	byMetric := make(map[string]*database.QualityAnomaly)
+	for _, a := range run.Anomalies {
+		byMetric[a.Metric] = a
+	}
+	completeness := byMetric["counterparty_completeness"]
+	if completeness == nil || completeness.Direction != "down" || completeness.Severity != database.QualityAnomalySeverityCritical {
+		t.Errorf("completeness anomaly = %+v", completeness)
+	}
+	if inn := byMetric["issues.invalid_inn"]; inn == nil || inn.Direction != "up" || inn.UploadID == nil {
+		t.Errorf("invalid_inn anomaly = %+v", inn)
+	}
+
+	// Повторный запуск без новых данных ничего не меняет
+	if run, err := service.AnalyzeDatabase(databaseID); err != nil || run.Observations != 0 || run.Detected != 0 {
+		t.Fatalf("repeated run = %+v, %v", run, err)
+	}
+
+	// Полнота вернулась к прежнему уровню — аномалия закрывается
+	addQualityUpload(t, db, databaseID, 9, 95, 40, start.Add(9*time.Hour))
+	run, err = service.AnalyzeDatabase(databaseID)
+	if err != nil {
+		t.Fatalf("AnalyzeDatabase: %v", err)
+	}
+	if run.Resolved != 1 {
+		t.Errorf("Resolved = %d, want 1", run.Resolved)
+	}
+	if a, _ := service.GetAnomaly(completeness.ID); a.Status != database.QualityAnomalyStatusResolved || a.ResolvedAt == nil {
+		t.Errorf("completeness anomaly after recovery = %+v", a)
+	}
+
+	summary, err := service.Summary(5)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if summary.Open != 1 || len(summary.Recent) != 1 || summary.Recent[0].Metric != "issues.invalid_inn" {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	if _, err := service.UpdateStatus(summary.Recent[0].ID, "ignored"); err == nil {
+		t.Error("UpdateStatus accepted unknown status")
+	}
+	if _, err := service.UpdateStatus(999, database.QualityAnomalyStatusAcknowledged); err == nil {
+		t.Error("UpdateStatus accepted unknown anomaly")
+	}
+	acknowledged, err := service.UpdateStatus(summary.Recent[0].ID, database.QualityAnomalyStatusAcknowledged)
+	if err != nil || acknowledged.AcknowledgedAt == nil {
+		t.Fatalf("UpdateStatus = %+v, %v", acknowledged, err)
+	}
+}
@@ -4,7 +4,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	apperrors "httpserver/server/errors"
+	"httpserver/server/events"
 )
 
 // ReclassificationStatus статус процесса переклассификации
@@ -21,6 +24,7 @@ type ReclassificationStatus struct {
 	StartTime   string   `json:"startTime,omitempty"`
 	ElapsedTime string   `json:"elapsedTime,omitempty"`
 	Rate        float64  `json:"rate"` // записей в секунду
+	SessionID   string   `json:"sessionId,omitempty"` // ID запуска, с ним события публикуются в шину
 }
 
 // ReclassificationRequest запрос на запуск переклассификации
@@ -37,6 +41,7 @@ type ReclassificationService struct {
 	status      ReclassificationStatus
 	statusMu    sync.RWMutex
 	events      chan string
+	eventBus    *events.Bus
 	stopChan    chan bool
 }
 
@@ -84,6 +89,7 @@ func (s *ReclassificationService) Start(req ReclassificationRequest) error {
 		CurrentStep: "Инициализация...",
 		Logs:        make([]string, 0),
 		StartTime:   time.Now().Format(time.RFC3339),
+		SessionID:   uuid.New().String(),
 	}
 	s.statusMu.Unlock()
 
@@ -131,8 +137,22 @@ func (s *ReclassificationService) GetEvents() <-chan string {
 	return s.events
 }
 
-// sendEvent отправляет событие
+// SetEventBus подключает шину событий; после этого события публикуются в топик reclassification, а не в канал
+func (s *ReclassificationService) SetEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// EventBus возвращает подключенную шину событий или nil
+func (s *ReclassificationService) EventBus() *events.Bus {
+	return s.eventBus
+}
+
+// sendEvent отправляет событие; в шину оно публикуется с ID текущего запуска
 func (s *ReclassificationService) sendEvent(message string) {
+	if s.eventBus != nil {
+		s.eventBus.PublishLog(events.TopicReclassification, events.Filter{SessionID: s.GetStatus().SessionID}, message)
+		return
+	}
 	select {
 	case s.events <- message:
 	default:
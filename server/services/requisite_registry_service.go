@@ -0,0 +1,157 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+	"httpserver/quality"
+	apperrors "httpserver/server/errors"
+)
+
+// RequisiteRegistryService управляет справочниками БИК, кодов причин КПП и регионов
+// и перекрестной проверкой реквизитов контрагентов
+type RequisiteRegistryService struct {
+	serviceDB *database.ServiceDB
+	analyzer  *quality.QualityAnalyzer
+
+	mu       sync.RWMutex
+	registry *quality.RequisitesRegistry
+}
+
+// RequisiteRegistryStatus состояние загруженных справочников
+type RequisiteRegistryStatus struct {
+	Versions []database.RequisiteRegistryVersion `json:"versions"`
+	// BIKUnknownCheck включены ли проверки bik_unknown и iban_bank_unknown (только с полным справочником БИК)
+	BIKUnknownCheck bool `json:"bik_unknown_check"`
+	// TaxOfficeCheck включена ли проверка kpp_tax_office_unknown (справочник инспекций не пуст)
+	TaxOfficeCheck bool `json:"tax_office_check"`
+	// Warnings проверки, отключенные до загрузки официальных справочников
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewRequisiteRegistryService создает сервис справочников реквизитов.
+// analyzer может быть nil, тогда обновленные справочники используются только для проверок через API.
+func NewRequisiteRegistryService(serviceDB *database.ServiceDB, analyzer *quality.QualityAnalyzer) *RequisiteRegistryService {
+	return &RequisiteRegistryService{
+		serviceDB: serviceDB,
+		analyzer:  analyzer,
+		registry:  quality.DefaultRequisitesRegistry(),
+	}
+}
+
+// EnsureLoaded загружает поставляемые справочники, если таблицы пусты, и применяет их к анализатору
+func (s *RequisiteRegistryService) EnsureLoaded() error {
+	versions, err := database.GetRequisiteRegistryVersions(s.serviceDB)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось получить версии справочников реквизитов", err)
+	}
+	if len(versions) == 0 {
+		if err := database.LoadBundledRequisiteRegistries(s.serviceDB); err != nil {
+			return apperrors.NewInternalError("не удалось загрузить поставляемые справочники реквизитов", err)
+		}
+	}
+	return s.reload()
+}
+
+// LoadFromDir обновляет справочники из каталога с файлами bik.tsv, kpp_reasons.tsv, tax_regions.tsv, tax_offices.tsv
+func (s *RequisiteRegistryService) LoadFromDir(dir string) (*RequisiteRegistryStatus, error) {
+	if strings.TrimSpace(dir) == "" {
+		return nil, apperrors.NewValidationError("не указан каталог со справочниками", nil)
+	}
+	data, err := database.ParseRequisiteRegistryDir(dir)
+	if err != nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("не удалось прочитать справочники из %s", dir), err)
+	}
+	if err := database.LoadRequisiteRegistriesToDatabase(s.serviceDB, data, dir); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить справочники реквизитов", err)
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s.Status()
+}
+
+// LoadOfficial обновляет справочник БИК из ED807 Банка России и справочник инспекций из перечня ФНС.
+// Можно передать один из файлов, второй справочник не изменяется.
+func (s *RequisiteRegistryService) LoadOfficial(ed807Path, taxOfficesPath string) (*RequisiteRegistryStatus, error) {
+	ed807Path, taxOfficesPath = strings.TrimSpace(ed807Path), strings.TrimSpace(taxOfficesPath)
+	if ed807Path == "" && taxOfficesPath == "" {
+		return nil, apperrors.NewValidationError("не указан файл ED807 или перечень налоговых органов", nil)
+	}
+	data, err := database.ParseOfficialRequisiteRegistries(ed807Path, taxOfficesPath)
+	if err != nil {
+		return nil, apperrors.NewValidationError("не удалось прочитать официальные справочники", err)
+	}
+	if err := database.LoadRequisiteRegistriesToDatabase(s.serviceDB, data, strings.Trim(strings.Join([]string{ed807Path, taxOfficesPath}, ", "), ", ")); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить справочники реквизитов", err)
+	}
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s.Status()
+}
+
+// Status возвращает версии загруженных справочников и проверки, отключенные из-за неполных данных
+func (s *RequisiteRegistryService) Status() (*RequisiteRegistryStatus, error) {
+	versions, err := database.GetRequisiteRegistryVersions(s.serviceDB)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить версии справочников реквизитов", err)
+	}
+	if versions == nil {
+		versions = []database.RequisiteRegistryVersion{}
+	}
+
+	status := &RequisiteRegistryStatus{Versions: versions}
+	banks := -1
+	for _, v := range versions {
+		switch v.Registry {
+		case database.RequisiteRegistryBanks:
+			banks = v.Entries
+			status.BIKUnknownCheck = v.Complete
+		case database.RequisiteRegistryTaxOffices:
+			status.TaxOfficeCheck = v.Entries > 0
+		}
+	}
+	if !status.BIKUnknownCheck {
+		if banks < 0 {
+			banks = 0
+		}
+		status.Warnings = append(status.Warnings, fmt.Sprintf(
+			"справочник БИК неполный (%d записей): проверки bik_unknown и iban_bank_unknown отключены до загрузки ED807 Банка России", banks))
+	}
+	if !status.TaxOfficeCheck {
+		status.Warnings = append(status.Warnings,
+			"справочник налоговых органов пуст: проверка kpp_tax_office_unknown отключена до загрузки перечня ФНС")
+	}
+	return status, nil
+}
+
+// Check проверяет согласованность реквизитов по текущим справочникам
+func (s *RequisiteRegistryService) Check(req quality.CounterpartyRequisites) []quality.RequisiteViolation {
+	s.mu.RLock()
+	registry := s.registry
+	s.mu.RUnlock()
+
+	violations := registry.Check(req)
+	if violations == nil {
+		violations = []quality.RequisiteViolation{}
+	}
+	return violations
+}
+
+// reload перечитывает справочники из сервисной БД
+func (s *RequisiteRegistryService) reload() error {
+	registry, err := quality.LoadRequisitesRegistry(s.serviceDB)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось прочитать справочники реквизитов", err)
+	}
+	s.mu.Lock()
+	s.registry = registry
+	s.mu.Unlock()
+	if s.analyzer != nil {
+		s.analyzer.SetRequisitesRegistry(registry)
+	}
+	return nil
+}
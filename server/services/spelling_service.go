@@ -0,0 +1,345 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	apperrors "httpserver/server/errors"
+)
+
+// SpellingService строит словари исправления опечаток по корпусам наименований
+// (общие эталоны, КПВЭД/ОКПД2, нормализованные данные и эталоны проекта)
+// и ведет очередь проверки найденных исправлений и белый список слов
+type SpellingService struct {
+	serviceDB    *database.ServiceDB
+	normalizedDB *database.DB
+	benchmarksDB *database.BenchmarksDB
+	config       normalization.SpellCorrectorConfig
+
+	mu           sync.Mutex
+	globalCorpus *normalization.SpellCorpus
+	correctors   map[int]*normalization.SpellCorrector // 0 - общий словарь без данных проекта
+}
+
+// NewSpellingService создает сервис исправления опечаток. normalizedDB и benchmarksDB могут быть nil
+func NewSpellingService(serviceDB *database.ServiceDB, normalizedDB *database.DB, benchmarksDB *database.BenchmarksDB) *SpellingService {
+	return &SpellingService{
+		serviceDB:    serviceDB,
+		normalizedDB: normalizedDB,
+		benchmarksDB: benchmarksDB,
+		config:       normalization.DefaultSpellCorrectorConfig(),
+		correctors:   make(map[int]*normalization.SpellCorrector),
+	}
+}
+
+// GlobalCorrector возвращает словарь без данных проектов (для общей нормализации и этапа конвейера).
+// При ошибке построения возвращает nil - исправление опечаток пропускается
+func (s *SpellingService) GlobalCorrector() *normalization.SpellCorrector {
+	return s.Corrector(0)
+}
+
+// Corrector возвращает словарь проекта, построенный при первом обращении.
+// При ошибке построения возвращает nil - исправление опечаток пропускается
+func (s *SpellingService) Corrector(projectID int) *normalization.SpellCorrector {
+	corrector, err := s.corrector(projectID)
+	if err != nil {
+		return nil
+	}
+	return corrector
+}
+
+func (s *SpellingService) corrector(projectID int) (*normalization.SpellCorrector, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if corrector, ok := s.correctors[projectID]; ok {
+		return corrector, nil
+	}
+	corrector, err := s.buildCorrectorLocked(projectID)
+	if err != nil {
+		return nil, err
+	}
+	s.correctors[projectID] = corrector
+	return corrector, nil
+}
+
+// Rebuild перестраивает словарь проекта по текущим данным (projectID = 0 - общий корпус
+// и словари всех проектов)
+func (s *SpellingService) Rebuild(projectID int) (normalization.SpellDictionaryStats, error) {
+	s.mu.Lock()
+	if projectID == 0 {
+		s.globalCorpus = nil
+		s.correctors = make(map[int]*normalization.SpellCorrector)
+	} else {
+		delete(s.correctors, projectID)
+	}
+	s.mu.Unlock()
+
+	corrector, err := s.corrector(projectID)
+	if err != nil {
+		return normalization.SpellDictionaryStats{}, err
+	}
+	return corrector.Stats(), nil
+}
+
+// Check проверяет произвольный текст словарем проекта; найденные исправления не попадают в очередь проверки
+func (s *SpellingService) Check(projectID int, text string) (*normalization.SpellCheckResult, normalization.SpellDictionaryStats, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, normalization.SpellDictionaryStats{}, apperrors.NewValidationError("текст для проверки не указан", nil)
+	}
+	corrector, err := s.corrector(projectID)
+	if err != nil {
+		return nil, normalization.SpellDictionaryStats{}, err
+	}
+	result := corrector.Check(text)
+	return &result, corrector.Stats(), nil
+}
+
+// FlushObserved сохраняет исправления, встреченные при нормализации проекта, в очередь проверки
+func (s *SpellingService) FlushObserved(projectID int, corrector *normalization.SpellCorrector) error {
+	if corrector == nil {
+		return nil
+	}
+	observed := corrector.TakeObserved()
+	if len(observed) == 0 {
+		return nil
+	}
+	corrections := make([]database.SpellingCorrection, 0, len(observed))
+	for _, item := range observed {
+		corrections = append(corrections, database.SpellingCorrection{
+			Original:    item.Original,
+			Suggested:   item.Suggested,
+			Occurrences: item.Occurrences,
+			Distance:    item.Distance,
+			Confidence:  item.Confidence,
+			Example:     item.Example,
+		})
+	}
+	if err := s.serviceDB.RecordSpellingCorrections(projectID, corrections); err != nil {
+		return apperrors.NewInternalError("не удалось сохранить исправления опечаток", err)
+	}
+	return nil
+}
+
+// ListCorrections возвращает очередь проверки исправлений проекта
+func (s *SpellingService) ListCorrections(projectID int, status string, limit int) ([]*database.SpellingCorrection, error) {
+	if status != "" && !isSpellingStatus(status) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный статус %q", status), nil)
+	}
+	corrections, err := s.serviceDB.GetSpellingCorrections(projectID, status, limit)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить исправления опечаток", err)
+	}
+	return corrections, nil
+}
+
+// ReviewCorrection подтверждает (approved) или отклоняет (rejected) исправление
+// и сразу применяет решение к загруженным словарям
+func (s *SpellingService) ReviewCorrection(id int, status, reviewedBy string) (*database.SpellingCorrection, error) {
+	if !isSpellingStatus(status) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный статус %q", status), nil)
+	}
+	if err := s.serviceDB.UpdateSpellingCorrectionStatus(id, status, reviewedBy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NewNotFoundError("исправление не найдено", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось сохранить решение", err)
+	}
+	correction, err := s.serviceDB.GetSpellingCorrection(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить исправление", err)
+	}
+	if correction == nil {
+		return nil, apperrors.NewNotFoundError("исправление не найдено", nil)
+	}
+	s.refreshReviews(correction.ProjectID)
+	return correction, nil
+}
+
+// AddReview добавляет исправление вручную сразу с решением проверки
+func (s *SpellingService) AddReview(projectID int, original, suggested, status, reviewedBy string) (*database.SpellingCorrection, error) {
+	original, suggested = strings.TrimSpace(original), strings.TrimSpace(suggested)
+	if original == "" || suggested == "" {
+		return nil, apperrors.NewValidationError("нужно указать исходное слово и исправление", nil)
+	}
+	if status != database.SpellingStatusApproved && status != database.SpellingStatusRejected {
+		return nil, apperrors.NewValidationError("статус должен быть approved или rejected", nil)
+	}
+	correction, err := s.serviceDB.SaveSpellingReview(projectID, strings.ToLower(original), strings.ToLower(suggested), status, reviewedBy)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить исправление", err)
+	}
+	s.refreshReviews(projectID)
+	return correction, nil
+}
+
+// ListWhitelist возвращает белый список проекта вместе с общими словами
+func (s *SpellingService) ListWhitelist(projectID int) ([]*database.SpellingWhitelistEntry, error) {
+	entries, err := s.serviceDB.GetSpellingWhitelist(projectID, true)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить белый список", err)
+	}
+	return entries, nil
+}
+
+// AddWhitelistWord добавляет слово, которое не нужно исправлять (projectID = 0 - для всех проектов)
+func (s *SpellingService) AddWhitelistWord(projectID int, word, note string) (*database.SpellingWhitelistEntry, error) {
+	word = strings.TrimSpace(word)
+	if word == "" || strings.ContainsAny(word, " \t") {
+		return nil, apperrors.NewValidationError("нужно указать одно слово", nil)
+	}
+	entry, err := s.serviceDB.AddSpellingWhitelistWord(projectID, strings.ToLower(word), note)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось добавить слово в белый список", err)
+	}
+	s.refreshReviews(projectID)
+	return entry, nil
+}
+
+// DeleteWhitelistWord удаляет слово из белого списка
+func (s *SpellingService) DeleteWhitelistWord(id int) error {
+	projectID, err := s.serviceDB.DeleteSpellingWhitelistWord(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperrors.NewNotFoundError("слово не найдено в белом списке", err)
+		}
+		return apperrors.NewInternalError("не удалось удалить слово из белого списка", err)
+	}
+	s.refreshReviews(projectID)
+	return nil
+}
+
+// buildCorrectorLocked строит словарь из общего корпуса и корпуса проекта и загружает решения проверки
+func (s *SpellingService) buildCorrectorLocked(projectID int) (*normalization.SpellCorrector, error) {
+	if s.globalCorpus == nil {
+		corpus, err := s.buildGlobalCorpus()
+		if err != nil {
+			return nil, err
+		}
+		s.globalCorpus = corpus
+	}
+
+	corpora := []*normalization.SpellCorpus{s.globalCorpus}
+	if projectID > 0 {
+		corpus, err := s.buildProjectCorpus(projectID)
+		if err != nil {
+			return nil, err
+		}
+		corpora = append(corpora, corpus)
+	}
+
+	corrector := normalization.NewSpellCorrector(s.config, corpora...)
+	if err := s.applyReviews(projectID, corrector); err != nil {
+		return nil, err
+	}
+	return corrector, nil
+}
+
+// buildGlobalCorpus собирает корпус из общих эталонов номенклатуры и наименований классификаторов
+func (s *SpellingService) buildGlobalCorpus() (*normalization.SpellCorpus, error) {
+	corpus := normalization.NewSpellCorpus()
+
+	if s.benchmarksDB != nil {
+		benchmarks, err := s.benchmarksDB.ListAllBenchmarks("nomenclature", true)
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось загрузить эталоны для словаря", err)
+		}
+		for _, benchmark := range benchmarks {
+			corpus.AddName(benchmark.Name, 2)
+			for _, variation := range benchmark.Variations {
+				corpus.AddName(variation, 1)
+			}
+		}
+	}
+
+	names, err := s.serviceDB.GetClassifierNames()
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось загрузить классификаторы для словаря", err)
+	}
+	for _, name := range names {
+		corpus.AddName(name, 1)
+	}
+	return corpus, nil
+}
+
+// buildProjectCorpus собирает корпус из нормализованных записей и эталонов проекта
+func (s *SpellingService) buildProjectCorpus(projectID int) (*normalization.SpellCorpus, error) {
+	corpus := normalization.NewSpellCorpus()
+
+	if s.normalizedDB != nil {
+		counts, err := s.normalizedDB.GetProjectNormalizedNameCounts(projectID)
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось загрузить нормализованные данные для словаря", err)
+		}
+		for name, count := range counts {
+			corpus.AddName(name, count)
+		}
+	}
+
+	weights, err := s.serviceDB.GetClientBenchmarkNameWeights(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось загрузить эталоны проекта для словаря", err)
+	}
+	for name, weight := range weights {
+		corpus.AddName(name, weight)
+	}
+	return corpus, nil
+}
+
+// applyReviews загружает в словарь белый список и решения проверки проекта и общие
+func (s *SpellingService) applyReviews(projectID int, corrector *normalization.SpellCorrector) error {
+	entries, err := s.serviceDB.GetSpellingWhitelist(projectID, true)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось загрузить белый список", err)
+	}
+	words := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		words = append(words, entry.Word)
+	}
+
+	corrections, err := s.serviceDB.GetSpellingReviews(projectID)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось загрузить решения проверки", err)
+	}
+	reviews := make([]normalization.SpellReview, 0, len(corrections))
+	for _, correction := range corrections {
+		reviews = append(reviews, normalization.SpellReview{
+			Original:  correction.Original,
+			Suggested: correction.Suggested,
+			Approved:  correction.Status == database.SpellingStatusApproved,
+		})
+	}
+
+	corrector.SetWhitelist(words)
+	corrector.SetReviews(reviews)
+	return nil
+}
+
+// refreshReviews применяет изменения проверки к загруженным словарям без перестроения корпуса.
+// Изменения общего списка (projectID = 0) касаются всех словарей
+func (s *SpellingService) refreshReviews(projectID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, corrector := range s.correctors {
+		if projectID != 0 && id != projectID {
+			continue
+		}
+		if err := s.applyReviews(id, corrector); err != nil {
+			// Словарь с устаревшими решениями перестроится при следующем обращении
+			delete(s.correctors, id)
+		}
+	}
+}
+
+func isSpellingStatus(status string) bool {
+	switch status {
+	case database.SpellingStatusPending, database.SpellingStatusApproved, database.SpellingStatusRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/database"
+)
+
+// TestSpellingService проверяет словарь проекта, очередь проверки исправлений и белый список
+func TestSpellingService(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	normalizedDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer normalizedDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	for i := 0; i < 30; i++ {
+		if _, err := normalizedDB.Exec(`INSERT INTO normalized_data (source_reference, source_name, code, normalized_name, normalized_reference, category, merged_count, project_id)
+			VALUES (?, 'Болт', ?, 'болт оцинкованный', 'болт оцинкованный', 'крепеж', 1, ?)`, i, i, project.ID); err != nil {
+			t.Fatalf("insert normalized_data: %v", err)
+		}
+	}
+
+	service := NewSpellingService(serviceDB, normalizedDB, nil)
+
+	if _, _, err := service.Check(project.ID, " "); err == nil {
+		t.Error("Check() with empty text expected error")
+	}
+	result, stats, err := service.Check(project.ID, "Болт оцинкованый М8")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.Corrected != "Болт оцинкованный М8" {
+		t.Errorf("Corrected = %q", result.Corrected)
+	}
+	if stats.Words == 0 {
+		t.Error("project dictionary is empty")
+	}
+	if global, _, _ := service.Check(0, "Болт оцинкованый"); global.Corrected != "Болт оцинкованый" {
+		t.Errorf("global dictionary uses project data: %q", global.Corrected)
+	}
+
+	// Исправления нормализации попадают в очередь проверки
+	corrector := service.Corrector(project.ID)
+	corrector.Correct("Болт оцинкованый М10")
+	corrector.Correct("Болт оцинкованый М12")
+	if err := service.FlushObserved(project.ID, corrector); err != nil {
+		t.Fatalf("FlushObserved() error = %v", err)
+	}
+	pending, err := service.ListCorrections(project.ID, database.SpellingStatusPending, 0)
+	if err != nil {
+		t.Fatalf("ListCorrections() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Original != "оцинкованый" || pending[0].Occurrences != 2 {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if _, err := service.ListCorrections(project.ID, "unknown", 0); err == nil {
+		t.Error("ListCorrections() with unknown status expected error")
+	}
+
+	// Отклоненное исправление больше не применяется
+	if _, err := service.ReviewCorrection(pending[0].ID, database.SpellingStatusRejected, "tester"); err != nil {
+		t.Fatalf("ReviewCorrection() error = %v", err)
+	}
+	if result := corrector.Check("Болт оцинкованый"); result.Corrected != "Болт оцинкованый" {
+		t.Errorf("rejected correction applied: %q", result.Corrected)
+	}
+	if _, err := service.ReviewCorrection(9999, database.SpellingStatusApproved, ""); err == nil {
+		t.Error("ReviewCorrection() for missing correction expected error")
+	}
+
+	// Подтвержденное вручную исправление применяется без словаря
+	if _, err := service.AddReview(project.ID, "болд", "болт", database.SpellingStatusApproved, "tester"); err != nil {
+		t.Fatalf("AddReview() error = %v", err)
+	}
+	if result := corrector.Check("Болд оцинкованный"); result.Corrected != "Болт оцинкованный" {
+		t.Errorf("approved correction not applied: %q", result.Corrected)
+	}
+
+	// Общий белый список действует для словаря проекта
+	entry, err := service.AddWhitelistWord(0, "Болд", "бренд")
+	if err != nil {
+		t.Fatalf("AddWhitelistWord() error = %v", err)
+	}
+	if result := corrector.Check("Болд оцинкованный"); result.Corrected != "Болд оцинкованный" {
+		t.Errorf("whitelisted word corrected: %q", result.Corrected)
+	}
+	words, err := service.ListWhitelist(project.ID)
+	if err != nil || len(words) != 1 {
+		t.Fatalf("ListWhitelist() = %v, %v", words, err)
+	}
+	if err := service.DeleteWhitelistWord(entry.ID); err != nil {
+		t.Fatalf("DeleteWhitelistWord() error = %v", err)
+	}
+	if err := service.DeleteWhitelistWord(entry.ID); err == nil {
+		t.Error("DeleteWhitelistWord() for missing word expected error")
+	}
+	if _, err := service.AddWhitelistWord(project.ID, "два слова", ""); err == nil {
+		t.Error("AddWhitelistWord() with several words expected error")
+	}
+
+	if stats, err := service.Rebuild(project.ID); err != nil || stats.Approved != 1 || stats.Rejected != 1 {
+		t.Errorf("Rebuild() = %+v, %v", stats, err)
+	}
+	if service.GlobalCorrector() == nil {
+		t.Error("GlobalCorrector() = nil")
+	}
+}
@@ -0,0 +1,442 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"httpserver/database"
+	"httpserver/extractors"
+	"httpserver/importer"
+	apperrors "httpserver/server/errors"
+)
+
+// tabularImportConfigName имя конфигурации выгрузок, созданных табличным импортом
+// (по нему правила автоматизации могут отличать импорт от выгрузки из 1С)
+const tabularImportConfigName = "TabularImport"
+
+// tabularPreviewRows число строк, возвращаемых в предпросмотре
+const tabularPreviewRows = 20
+
+// tabularImportCatalogs справочник, в который попадают строки каждого типа сущности
+var tabularImportCatalogs = map[string]string{
+	importer.TabularEntityNomenclature:   "Номенклатура",
+	importer.TabularEntityCounterparties: "Контрагенты",
+}
+
+// TabularImportRequest параметры чтения и сопоставления колонок файла.
+// Если задан ProfileID, пустые параметры берутся из профиля
+type TabularImportRequest struct {
+	EntityType  string            `json:"entity_type"`
+	Sheet       string            `json:"sheet,omitempty"`
+	HeaderRow   int               `json:"header_row,omitempty"`
+	Mapping     map[string]string `json:"mapping,omitempty"` // поле -> заголовок колонки
+	ProfileID   int               `json:"profile_id,omitempty"`
+	ClientID    int               `json:"client_id,omitempty"`    // для предпросмотра: профили клиента
+	DatabaseID  int               `json:"database_id,omitempty"`  // БД проекта для выгрузки; 0 - новая БД
+	SaveProfile string            `json:"save_profile,omitempty"` // сохранить сопоставление в профиль с этим именем
+}
+
+// TabularPreview результат разбора файла: лист, заголовки, предложенное сопоставление и первые строки
+type TabularPreview struct {
+	*importer.TabularTable
+	EntityType  string                           `json:"entity_type"`
+	Fields      []importer.TabularField          `json:"fields"`
+	Suggestions []importer.ColumnSuggestion      `json:"suggestions"`
+	Mapping     map[string]string                `json:"mapping"`
+	MappingFrom string                           `json:"mapping_from"` // request, profile, suggestions
+	MappingErr  string                           `json:"mapping_error,omitempty"`
+	TotalRows   int                              `json:"total_rows"`
+	Records     []importer.TabularRecord         `json:"records"`
+	Errors      []importer.TabularRowError       `json:"errors,omitempty"`
+	Profiles    []*database.ImportMappingProfile `json:"profiles,omitempty"`
+}
+
+// TabularImportResult итог импорта файла в выгрузку
+type TabularImportResult struct {
+	UploadUUID   string                         `json:"upload_uuid"`
+	DatabaseID   int                            `json:"database_id"`
+	DatabasePath string                         `json:"database_path"`
+	Catalog      string                         `json:"catalog"`
+	Imported     int                            `json:"imported"`
+	Skipped      int                            `json:"skipped"`
+	Errors       []importer.TabularRowError     `json:"errors,omitempty"`
+	Profile      *database.ImportMappingProfile `json:"profile,omitempty"`
+}
+
+// TabularImportService импортирует номенклатуру и контрагентов из XLSX/CSV как обычную выгрузку
+// в БД проекта, чтобы строки проходили стандартную нормализацию и правила автоматизации
+type TabularImportService struct {
+	serviceDB  *database.ServiceDB
+	uploadsDir string
+	onComplete func(upload *database.Upload)
+}
+
+// NewTabularImportService создает сервис табличного импорта. uploadsDir - каталог для новых БД проектов
+func NewTabularImportService(serviceDB *database.ServiceDB, uploadsDir string) *TabularImportService {
+	if uploadsDir == "" {
+		uploadsDir = filepath.Join("data", "uploads")
+	}
+	return &TabularImportService{
+		serviceDB:  serviceDB,
+		uploadsDir: uploadsDir,
+	}
+}
+
+// SetCompletionHandler задает обработчик завершенной выгрузки (правила автоматизации)
+func (s *TabularImportService) SetCompletionHandler(fn func(upload *database.Upload)) {
+	s.onComplete = fn
+}
+
+// Fields возвращает поля, доступные для сопоставления
+func (s *TabularImportService) Fields(entityType string) ([]importer.TabularField, error) {
+	fields := importer.TabularFields(entityType)
+	if fields == nil {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный тип данных %q", entityType), nil)
+	}
+	return fields, nil
+}
+
+// Preview разбирает файл и возвращает предложенное сопоставление и первые строки.
+// Ошибка сопоставления не прерывает предпросмотр: она возвращается в mapping_error
+func (s *TabularImportService) Preview(fileName string, data []byte, req TabularImportRequest) (*TabularPreview, error) {
+	profile, err := s.applyProfile(&req)
+	if err != nil {
+		return nil, err
+	}
+	fields, err := s.Fields(req.EntityType)
+	if err != nil {
+		return nil, err
+	}
+	table, err := importer.ReadTabularFile(fileName, data, importer.TabularReadOptions{Sheet: req.Sheet, HeaderRow: req.HeaderRow})
+	if err != nil {
+		return nil, apperrors.NewValidationError("не удалось прочитать файл", err)
+	}
+
+	preview := &TabularPreview{
+		TabularTable: table,
+		EntityType:   req.EntityType,
+		Fields:       fields,
+		Suggestions:  importer.SuggestColumnMapping(req.EntityType, table),
+		Mapping:      req.Mapping,
+		MappingFrom:  "request",
+		Records:      []importer.TabularRecord{},
+	}
+	switch {
+	case profile != nil && len(req.Mapping) > 0:
+		preview.MappingFrom = "profile"
+	case len(req.Mapping) == 0:
+		preview.Mapping = make(map[string]string, len(preview.Suggestions))
+		for _, suggestion := range preview.Suggestions {
+			preview.Mapping[suggestion.Field] = suggestion.Column
+		}
+		preview.MappingFrom = "suggestions"
+	}
+
+	if req.ClientID > 0 {
+		if preview.Profiles, err = s.serviceDB.GetImportMappingProfiles(req.ClientID, req.EntityType); err != nil {
+			return nil, apperrors.NewInternalError("не удалось получить профили сопоставления", err)
+		}
+	}
+
+	columns, err := importer.ResolveColumnMapping(req.EntityType, table, preview.Mapping)
+	if err != nil {
+		preview.MappingErr = err.Error()
+		return preview, nil
+	}
+	records, rowErrors := importer.MapTabularRows(req.EntityType, table, columns)
+	preview.TotalRows = len(records)
+	if len(records) > tabularPreviewRows {
+		records = records[:tabularPreviewRows]
+	}
+	preview.Records = records
+	preview.Errors = limitRowErrors(rowErrors)
+	return preview, nil
+}
+
+// Import записывает строки файла в БД проекта как завершенную выгрузку со справочником
+// "Номенклатура" или "Контрагенты" и запускает обработчик завершения выгрузки
+func (s *TabularImportService) Import(projectID int, fileName string, data []byte, req TabularImportRequest) (*TabularImportResult, error) {
+	project, err := s.serviceDB.GetClientProject(projectID)
+	if err != nil {
+		return nil, apperrors.NewNotFoundError("проект не найден", err)
+	}
+	usedProfile, err := s.applyProfile(&req)
+	if err != nil {
+		return nil, err
+	}
+	if usedProfile != nil && usedProfile.ClientID != project.ClientID {
+		return nil, apperrors.NewValidationError("профиль сопоставления принадлежит другому клиенту", nil)
+	}
+	catalogName, ok := tabularImportCatalogs[req.EntityType]
+	if !ok {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный тип данных %q", req.EntityType), nil)
+	}
+	if len(req.Mapping) == 0 {
+		return nil, apperrors.NewValidationError("не указано сопоставление колонок", nil)
+	}
+
+	table, err := importer.ReadTabularFile(fileName, data, importer.TabularReadOptions{Sheet: req.Sheet, HeaderRow: req.HeaderRow})
+	if err != nil {
+		return nil, apperrors.NewValidationError("не удалось прочитать файл", err)
+	}
+	columns, err := importer.ResolveColumnMapping(req.EntityType, table, req.Mapping)
+	if err != nil {
+		return nil, apperrors.NewValidationError("неверное сопоставление колонок", err)
+	}
+	records, rowErrors := importer.MapTabularRows(req.EntityType, table, columns)
+	if len(records) == 0 {
+		return nil, apperrors.NewValidationError("в файле нет строк с наименованием", nil)
+	}
+
+	var profile *database.ImportMappingProfile
+	if req.SaveProfile != "" {
+		profile, err = s.serviceDB.SaveImportMappingProfile(&database.ImportMappingProfile{
+			ClientID:   project.ClientID,
+			Name:       strings.TrimSpace(req.SaveProfile),
+			EntityType: req.EntityType,
+			Sheet:      req.Sheet,
+			HeaderRow:  req.HeaderRow,
+			Mapping:    req.Mapping,
+		})
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось сохранить профиль сопоставления", err)
+		}
+	}
+
+	projectDB, err := s.targetDatabase(project, req.DatabaseID, fileName)
+	if err != nil {
+		return nil, err
+	}
+	sourceDB, err := database.NewDB(projectDB.FilePath)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось открыть БД проекта", err)
+	}
+	defer sourceDB.Close()
+
+	uploadUUID := uuid.New().String()
+	databaseID := projectDB.ID
+	upload, err := sourceDB.CreateUploadWithDatabase(uploadUUID, "", tabularImportConfigName, &databaseID,
+		"", "", "", 1, "", "", "Импорт из файла "+filepath.Base(fileName), nil)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось создать выгрузку", err)
+	}
+	if err := sourceDB.UpdateUploadClientProject(upload.ID, project.ClientID, project.ID); err != nil {
+		return nil, apperrors.NewInternalError("не удалось привязать выгрузку к проекту", err)
+	}
+	catalog, err := sourceDB.AddCatalog(upload.ID, catalogName, catalogName)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось создать справочник выгрузки", err)
+	}
+
+	result := &TabularImportResult{
+		UploadUUID:   uploadUUID,
+		DatabaseID:   projectDB.ID,
+		DatabasePath: projectDB.FilePath,
+		Catalog:      catalogName,
+		Skipped:      len(rowErrors),
+		Profile:      profile,
+	}
+	for _, record := range records {
+		reference := record.Reference
+		if reference == "" {
+			// Одинаковая строка при повторном импорте получает ту же ссылку
+			reference = uuid.NewSHA1(uuid.NameSpaceOID, []byte(req.EntityType+"|"+record.Code+"|"+record.Name)).String()
+		}
+		attrs := tabularRecordAttributes(req.EntityType, record)
+		if err := sourceDB.AddCatalogItemWithAttributes(catalog.ID, reference, record.Code, record.Name, attrs.XML(), "", attrs); err != nil {
+			rowErrors = append(rowErrors, importer.TabularRowError{Row: record.Row, Message: err.Error()})
+			result.Skipped++
+			continue
+		}
+		result.Imported++
+	}
+	result.Errors = limitRowErrors(rowErrors)
+
+	if err := sourceDB.CompleteUpload(upload.ID); err != nil {
+		return nil, apperrors.NewInternalError("не удалось завершить выгрузку", err)
+	}
+	if s.onComplete != nil {
+		if completed, err := sourceDB.GetUploadByUUID(uploadUUID); err == nil {
+			s.onComplete(completed)
+		}
+	}
+	return result, nil
+}
+
+// ListProfiles возвращает профили сопоставления клиента
+func (s *TabularImportService) ListProfiles(clientID int, entityType string) ([]*database.ImportMappingProfile, error) {
+	profiles, err := s.serviceDB.GetImportMappingProfiles(clientID, entityType)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить профили сопоставления", err)
+	}
+	return profiles, nil
+}
+
+// GetProfile возвращает профиль сопоставления
+func (s *TabularImportService) GetProfile(id int) (*database.ImportMappingProfile, error) {
+	profile, err := s.serviceDB.GetImportMappingProfile(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить профиль сопоставления", err)
+	}
+	if profile == nil {
+		return nil, apperrors.NewNotFoundError("профиль сопоставления не найден", nil)
+	}
+	return profile, nil
+}
+
+// SaveProfile проверяет и сохраняет профиль клиента (профиль с тем же именем заменяется)
+func (s *TabularImportService) SaveProfile(clientID int, profile *database.ImportMappingProfile) (*database.ImportMappingProfile, error) {
+	if _, err := s.serviceDB.GetClient(clientID); err != nil {
+		return nil, apperrors.NewNotFoundError("клиент не найден", err)
+	}
+	if err := validateImportMappingProfile(profile); err != nil {
+		return nil, err
+	}
+	profile.ClientID = clientID
+	saved, err := s.serviceDB.SaveImportMappingProfile(profile)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить профиль сопоставления", err)
+	}
+	return saved, nil
+}
+
+// UpdateProfile изменяет профиль сопоставления
+func (s *TabularImportService) UpdateProfile(id int, profile *database.ImportMappingProfile) (*database.ImportMappingProfile, error) {
+	if err := validateImportMappingProfile(profile); err != nil {
+		return nil, err
+	}
+	profile.ID = id
+	if err := s.serviceDB.UpdateImportMappingProfile(profile); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NewNotFoundError("профиль сопоставления не найден", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось изменить профиль сопоставления", err)
+	}
+	return s.GetProfile(id)
+}
+
+// DeleteProfile удаляет профиль сопоставления
+func (s *TabularImportService) DeleteProfile(id int) error {
+	if err := s.serviceDB.DeleteImportMappingProfile(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperrors.NewNotFoundError("профиль сопоставления не найден", err)
+		}
+		return apperrors.NewInternalError("не удалось удалить профиль сопоставления", err)
+	}
+	return nil
+}
+
+// applyProfile дополняет запрос параметрами профиля; явно указанные параметры запроса сохраняются
+func (s *TabularImportService) applyProfile(req *TabularImportRequest) (*database.ImportMappingProfile, error) {
+	if req.ProfileID <= 0 {
+		return nil, nil
+	}
+	profile, err := s.GetProfile(req.ProfileID)
+	if err != nil {
+		return nil, err
+	}
+	if req.EntityType == "" {
+		req.EntityType = profile.EntityType
+	}
+	if req.Sheet == "" {
+		req.Sheet = profile.Sheet
+	}
+	if req.HeaderRow == 0 {
+		req.HeaderRow = profile.HeaderRow
+	}
+	if len(req.Mapping) == 0 {
+		req.Mapping = profile.Mapping
+	}
+	return profile, nil
+}
+
+// targetDatabase возвращает БД проекта для выгрузки или создает новую в uploadsDir
+func (s *TabularImportService) targetDatabase(project *database.ClientProject, databaseID int, fileName string) (*database.ProjectDatabase, error) {
+	if databaseID > 0 {
+		projectDB, err := s.serviceDB.GetProjectDatabase(databaseID)
+		if err != nil {
+			return nil, apperrors.NewInternalError("не удалось получить БД проекта", err)
+		}
+		if projectDB == nil || projectDB.ClientProjectID != project.ID {
+			return nil, apperrors.NewNotFoundError("БД проекта не найдена", nil)
+		}
+		return projectDB, nil
+	}
+
+	if err := os.MkdirAll(s.uploadsDir, 0755); err != nil {
+		return nil, apperrors.NewInternalError("не удалось создать каталог для БД", err)
+	}
+	base := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	path := filepath.Join(s.uploadsDir, fmt.Sprintf("import_%d_%s_%s.db", project.ID, time.Now().Format("20060102_150405"), sanitizeImportFileName(base)))
+	projectDB, err := s.serviceDB.CreateProjectDatabase(project.ID, "Импорт: "+filepath.Base(fileName), path,
+		"Табличный импорт (XLSX/CSV)", 0)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось зарегистрировать БД проекта", err)
+	}
+	return projectDB, nil
+}
+
+// tabularRecordAttributes переводит сопоставленные поля строки в реквизиты 1С в порядке полей
+func tabularRecordAttributes(entityType string, record importer.TabularRecord) extractors.AttributeSet {
+	attrs := make(extractors.AttributeSet, 0, len(record.Attributes))
+	for _, field := range importer.TabularFields(entityType) {
+		if value, ok := record.Attributes[field.Attribute]; ok && field.Attribute != "" {
+			attrs = append(attrs, extractors.Attribute{Name: field.Attribute, Value: value, Type: extractors.InferAttributeType(value)})
+		}
+	}
+	return attrs
+}
+
+func validateImportMappingProfile(profile *database.ImportMappingProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return apperrors.NewValidationError("не указано имя профиля", nil)
+	}
+	fields := importer.TabularFields(profile.EntityType)
+	if fields == nil {
+		return apperrors.NewValidationError(fmt.Sprintf("неизвестный тип данных %q", profile.EntityType), nil)
+	}
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Key] = true
+	}
+	for field := range profile.Mapping {
+		if !known[field] {
+			return apperrors.NewValidationError(fmt.Sprintf("неизвестное поле %q", field), nil)
+		}
+	}
+	if profile.Mapping["name"] == "" {
+		return apperrors.NewValidationError("не сопоставлена колонка наименования", nil)
+	}
+	return nil
+}
+
+// sanitizeImportFileName оставляет в имени файла буквы, цифры, точку, дефис и подчеркивание
+func sanitizeImportFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= 'а' && r <= 'я') || (r >= 'А' && r <= 'Я') {
+			return r
+		}
+		return '_'
+	}, name)
+	if len([]rune(name)) > 40 {
+		name = string([]rune(name)[:40])
+	}
+	return name
+}
+
+// limitRowErrors ограничивает число ошибок строк в ответе
+func limitRowErrors(rowErrors []importer.TabularRowError) []importer.TabularRowError {
+	if len(rowErrors) > 100 {
+		return rowErrors[:100]
+	}
+	return rowErrors
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/database"
+	"httpserver/importer"
+)
+
+// TestTabularImportService проверяет предпросмотр, профили сопоставления и импорт CSV как выгрузки проекта
+func TestTabularImportService(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+
+	csvData := []byte("Остатки склада\n" +
+		"Код;Номенклатура;Артикул;Ед.\n" +
+		"001;Болт М8х40 оцинкованный;DIN933;шт\n" +
+		"002;Гайка М8;DIN934;шт\n" +
+		"003;;;шт\n")
+
+	service := NewTabularImportService(serviceDB, t.TempDir())
+	var completed []*database.Upload
+	service.SetCompletionHandler(func(upload *database.Upload) { completed = append(completed, upload) })
+
+	preview, err := service.Preview("stock.csv", csvData, TabularImportRequest{EntityType: importer.TabularEntityNomenclature})
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if preview.HeaderRow != 2 || preview.MappingFrom != "suggestions" || preview.MappingErr != "" {
+		t.Fatalf("preview = %+v", preview)
+	}
+	if preview.Mapping["name"] != "Номенклатура" || preview.Mapping["article"] != "Артикул" || preview.Mapping["code"] != "Код" {
+		t.Errorf("mapping = %v", preview.Mapping)
+	}
+	if preview.TotalRows != 2 || len(preview.Errors) != 1 {
+		t.Errorf("total = %d, errors = %+v", preview.TotalRows, preview.Errors)
+	}
+
+	if _, err := service.Preview("stock.csv", csvData, TabularImportRequest{EntityType: "unknown"}); err == nil {
+		t.Error("Preview() with unknown entity type expected error")
+	}
+	if _, err := service.Import(project.ID, "stock.csv", csvData, TabularImportRequest{EntityType: importer.TabularEntityNomenclature}); err == nil {
+		t.Error("Import() without mapping expected error")
+	}
+
+	result, err := service.Import(project.ID, "stock.csv", csvData, TabularImportRequest{
+		EntityType:  importer.TabularEntityNomenclature,
+		Mapping:     preview.Mapping,
+		SaveProfile: "Склад",
+	})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 || result.Catalog != "Номенклатура" || result.Profile == nil {
+		t.Fatalf("result = %+v", result)
+	}
+	if len(completed) != 1 || completed[0].ProjectID == nil || *completed[0].ProjectID != project.ID {
+		t.Fatalf("completion handler uploads = %+v", completed)
+	}
+
+	// Строки попадают в БД проекта как обычная выгрузка
+	projectDB, err := serviceDB.GetProjectDatabase(result.DatabaseID)
+	if err != nil || projectDB == nil || projectDB.ClientProjectID != project.ID {
+		t.Fatalf("project database = %+v, %v", projectDB, err)
+	}
+	sourceDB, err := database.NewDB(projectDB.FilePath)
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer sourceDB.Close()
+	upload, err := sourceDB.GetUploadByUUID(result.UploadUUID)
+	if err != nil || upload.Status != "completed" {
+		t.Fatalf("upload = %+v, %v", upload, err)
+	}
+	items, _, err := sourceDB.GetCatalogItemsByUpload(upload.ID, []string{"Номенклатура"}, 0, 0)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("items = %d, %v", len(items), err)
+	}
+	if items[0].Code != "001" || items[0].Name != "Болт М8х40 оцинкованный" {
+		t.Errorf("item = %+v", items[0])
+	}
+
+	// Повторный импорт по профилю в ту же БД
+	again, err := service.Import(project.ID, "stock.csv", csvData, TabularImportRequest{
+		ProfileID:  result.Profile.ID,
+		DatabaseID: result.DatabaseID,
+	})
+	if err != nil {
+		t.Fatalf("Import() by profile error = %v", err)
+	}
+	if again.DatabaseID != result.DatabaseID || again.Imported != 2 {
+		t.Errorf("import by profile = %+v", again)
+	}
+
+	profiles, err := service.ListProfiles(client.ID, importer.TabularEntityNomenclature)
+	if err != nil || len(profiles) != 1 || profiles[0].Mapping["name"] != "Номенклатура" {
+		t.Fatalf("ListProfiles() = %+v, %v", profiles, err)
+	}
+	if _, err := service.SaveProfile(client.ID, &database.ImportMappingProfile{
+		Name: "Без наименования", EntityType: importer.TabularEntityNomenclature, Mapping: map[string]string{"code": "Код"},
+	}); err == nil {
+		t.Error("SaveProfile() without name column expected error")
+	}
+	if err := service.DeleteProfile(profiles[0].ID); err != nil {
+		t.Fatalf("DeleteProfile() error = %v", err)
+	}
+	if _, err := service.GetProfile(profiles[0].ID); err == nil {
+		t.Error("GetProfile() after delete expected error")
+	}
+}
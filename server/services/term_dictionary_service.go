@@ -0,0 +1,270 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	apperrors "httpserver/server/errors"
+)
+
+// TermMiningResult результат поиска кандидатов в сокращения по наименованиям проекта
+type TermMiningResult struct {
+	ProjectID  int                                   `json:"project_id"`
+	Names      int                                   `json:"names"` // Уникальных наименований в корпусе
+	Candidates []normalization.AbbreviationCandidate `json:"candidates"`
+}
+
+// TermDictionaryService ведет словари сокращений, синонимов и стоп-слов (общий и проектов),
+// ищет кандидатов в сокращения по исходным наименованиям и загружает проверенные записи
+// в словари, применяемые нормализацией и поиском дубликатов
+type TermDictionaryService struct {
+	serviceDB    *database.ServiceDB
+	normalizedDB *database.DB
+	minerConfig  normalization.AbbreviationMinerConfig
+
+	mu           sync.Mutex
+	dictionaries map[int]*normalization.TermDictionary // 0 - только общий словарь
+}
+
+// NewTermDictionaryService создает сервис словарей терминов. normalizedDB может быть nil
+// (поиск кандидатов тогда недоступен)
+func NewTermDictionaryService(serviceDB *database.ServiceDB, normalizedDB *database.DB) *TermDictionaryService {
+	return &TermDictionaryService{
+		serviceDB:    serviceDB,
+		normalizedDB: normalizedDB,
+		minerConfig:  normalization.DefaultAbbreviationMinerConfig(),
+		dictionaries: make(map[int]*normalization.TermDictionary),
+	}
+}
+
+// GlobalDictionary возвращает общий словарь (для общей нормализации и этапа конвейера).
+// При ошибке загрузки возвращает nil - словарь не применяется
+func (s *TermDictionaryService) GlobalDictionary() *normalization.TermDictionary {
+	return s.Dictionary(0)
+}
+
+// Dictionary возвращает словарь проекта (общие записи, переопределенные записями проекта),
+// загруженный при первом обращении. При ошибке загрузки возвращает nil - словарь не применяется
+func (s *TermDictionaryService) Dictionary(projectID int) *normalization.TermDictionary {
+	dictionary, err := s.dictionary(projectID)
+	if err != nil {
+		return nil
+	}
+	return dictionary
+}
+
+func (s *TermDictionaryService) dictionary(projectID int) (*normalization.TermDictionary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if dictionary, ok := s.dictionaries[projectID]; ok {
+		return dictionary, nil
+	}
+	entries, err := s.loadEntries(projectID)
+	if err != nil {
+		return nil, err
+	}
+	dictionary := normalization.NewTermDictionary(entries)
+	s.dictionaries[projectID] = dictionary
+	return dictionary, nil
+}
+
+// Apply применяет словарь проекта к произвольному тексту
+func (s *TermDictionaryService) Apply(projectID int, text string) (*normalization.TermDictionaryResult, normalization.TermDictionaryStats, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, normalization.TermDictionaryStats{}, apperrors.NewValidationError("текст не указан", nil)
+	}
+	dictionary, err := s.dictionary(projectID)
+	if err != nil {
+		return nil, normalization.TermDictionaryStats{}, err
+	}
+	result := dictionary.Apply(text)
+	return &result, dictionary.Stats(), nil
+}
+
+// ListEntries возвращает записи словаря проекта (projectID = 0 - общего словаря)
+func (s *TermDictionaryService) ListEntries(projectID int, kind, status string, limit int) ([]*database.TermDictionaryEntry, error) {
+	if kind != "" && !normalization.IsTermKind(kind) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный вид записи %q", kind), nil)
+	}
+	if status != "" && !isTermStatus(status) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный статус %q", status), nil)
+	}
+	entries, err := s.serviceDB.GetTermDictionaryEntries(projectID, kind, status, limit)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить записи словаря", err)
+	}
+	return entries, nil
+}
+
+// AddEntry добавляет запись вручную; она сразу применяется (approved). Повторное добавление
+// термина заменяет его запись в том же словаре
+func (s *TermDictionaryService) AddEntry(projectID int, kind, term, replacement, reviewedBy string) (*database.TermDictionaryEntry, error) {
+	if !normalization.IsTermKind(kind) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный вид записи %q", kind), nil)
+	}
+	key := normalization.TermKey(term)
+	if key == "" {
+		return nil, apperrors.NewValidationError("термин не указан", nil)
+	}
+	replacement = strings.Join(strings.Fields(replacement), " ")
+	if kind == normalization.TermKindStopWord {
+		replacement = ""
+	} else if replacement == "" {
+		return nil, apperrors.NewValidationError("нужно указать замену термина", nil)
+	} else if normalization.TermKey(replacement) == key {
+		return nil, apperrors.NewValidationError("замена совпадает с термином", nil)
+	}
+
+	entry, err := s.serviceDB.SaveTermDictionaryEntry(projectID, kind, key, strings.ToLower(replacement), database.TermStatusApproved, reviewedBy)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить запись словаря", err)
+	}
+	s.refresh(projectID)
+	return entry, nil
+}
+
+// ReviewEntry подтверждает (approved) или отклоняет (rejected) запись и сразу применяет решение
+// к загруженным словарям. Непустая replacement исправляет предложенную полную форму.
+// Отклоненная запись проекта отключает одноименную запись общего словаря для этого проекта
+func (s *TermDictionaryService) ReviewEntry(id int, status, replacement, reviewedBy string) (*database.TermDictionaryEntry, error) {
+	if !isTermStatus(status) {
+		return nil, apperrors.NewValidationError(fmt.Sprintf("неизвестный статус %q", status), nil)
+	}
+	replacement = strings.ToLower(strings.Join(strings.Fields(replacement), " "))
+	if err := s.serviceDB.ReviewTermDictionaryEntry(id, status, replacement, reviewedBy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperrors.NewNotFoundError("запись словаря не найдена", err)
+		}
+		return nil, apperrors.NewInternalError("не удалось сохранить решение", err)
+	}
+	entry, err := s.serviceDB.GetTermDictionaryEntry(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить запись словаря", err)
+	}
+	if entry == nil {
+		return nil, apperrors.NewNotFoundError("запись словаря не найдена", nil)
+	}
+	s.refresh(entry.ProjectID)
+	return entry, nil
+}
+
+// DeleteEntry удаляет запись словаря
+func (s *TermDictionaryService) DeleteEntry(id int) error {
+	projectID, err := s.serviceDB.DeleteTermDictionaryEntry(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperrors.NewNotFoundError("запись словаря не найдена", err)
+		}
+		return apperrors.NewInternalError("не удалось удалить запись словаря", err)
+	}
+	s.refresh(projectID)
+	return nil
+}
+
+// Mine ищет сокращения в исходных наименованиях проекта (projectID = 0 - всех проектов)
+// и добавляет новых кандидатов в очередь проверки. Термины, уже записанные в словарь проекта
+// или общий словарь, пропускаются
+func (s *TermDictionaryService) Mine(projectID int) (*TermMiningResult, error) {
+	if s.normalizedDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база нормализованных данных недоступна", nil)
+	}
+	counts, err := s.normalizedDB.GetSourceNameCounts(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось загрузить наименования проекта", err)
+	}
+	known, err := s.serviceDB.GetTermDictionaryTerms(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось загрузить словарь", err)
+	}
+
+	// Наименования добавляются по порядку, чтобы примеры не зависели от порядка обхода карты
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	miner := normalization.NewAbbreviationMiner(s.minerConfig)
+	for _, name := range names {
+		miner.AddName(name, counts[name])
+	}
+
+	candidates := miner.Mine(func(term string) bool { return known[term] })
+	entries := make([]database.TermDictionaryEntry, 0, len(candidates))
+	for _, candidate := range candidates {
+		entries = append(entries, database.TermDictionaryEntry{
+			Kind:        normalization.TermKindAbbreviation,
+			Term:        candidate.Term,
+			Replacement: candidate.Replacement,
+			Occurrences: candidate.Occurrences,
+			Confidence:  candidate.Confidence,
+			Example:     candidate.Example,
+		})
+	}
+	if err := s.serviceDB.RecordTermCandidates(projectID, entries); err != nil {
+		return nil, apperrors.NewInternalError("не удалось сохранить кандидатов", err)
+	}
+	return &TermMiningResult{ProjectID: projectID, Names: len(counts), Candidates: candidates}, nil
+}
+
+// loadEntries собирает записи словаря: подтвержденные общие, затем записи проекта.
+// Отклоненная запись проекта убирает одноименную общую
+func (s *TermDictionaryService) loadEntries(projectID int) ([]normalization.TermEntry, error) {
+	reviewed, err := s.serviceDB.GetReviewedTermEntries(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось загрузить словарь терминов", err)
+	}
+	byTerm := make(map[string]normalization.TermEntry)
+	order := make([]string, 0, len(reviewed))
+	for _, entry := range reviewed {
+		switch {
+		case entry.Status == database.TermStatusApproved:
+			if _, ok := byTerm[entry.Term]; !ok {
+				order = append(order, entry.Term)
+			}
+			byTerm[entry.Term] = normalization.TermEntry{Kind: entry.Kind, Term: entry.Term, Replacement: entry.Replacement}
+		case entry.ProjectID == projectID:
+			delete(byTerm, entry.Term)
+		}
+	}
+	entries := make([]normalization.TermEntry, 0, len(byTerm))
+	for _, term := range order {
+		if entry, ok := byTerm[term]; ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// refresh перезагружает записи загруженных словарей на месте (нормализаторы держат ссылки на них).
+// Изменения общего словаря (projectID = 0) касаются всех словарей
+func (s *TermDictionaryService) refresh(projectID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, dictionary := range s.dictionaries {
+		if projectID != 0 && id != projectID {
+			continue
+		}
+		entries, err := s.loadEntries(id)
+		if err != nil {
+			// Словарь с устаревшими записями перезагрузится при следующем обращении
+			delete(s.dictionaries, id)
+			continue
+		}
+		dictionary.SetEntries(entries)
+	}
+}
+
+func isTermStatus(status string) bool {
+	switch status {
+	case database.TermStatusPending, database.TermStatusApproved, database.TermStatusRejected:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"testing"
+
+	"httpserver/database"
+	"httpserver/normalization"
+)
+
+// TestTermDictionaryService проверяет поиск сокращений, проверку кандидатов и слои словарей
+func TestTermDictionaryService(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	normalizedDB, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer normalizedDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	names := map[string]int{
+		"Лист нержавеющий 2мм": 5,
+		"Уголок нержавеющий":   3,
+		"Лист нерж. 3мм":       3,
+		"Уголок нерж.":         1,
+	}
+	code := 0
+	for name, count := range names {
+		for i := 0; i < count; i++ {
+			code++
+			if _, err := normalizedDB.Exec(`INSERT INTO normalized_data (source_reference, source_name, code, normalized_name, normalized_reference, category, merged_count, project_id)
+				VALUES (?, ?, ?, ?, ?, 'металл', 1, ?)`, code, name, code, name, name, project.ID); err != nil {
+				t.Fatalf("insert normalized_data: %v", err)
+			}
+		}
+	}
+
+	service := NewTermDictionaryService(serviceDB, normalizedDB)
+	// Словарь загружен до проверки кандидатов: решения применяются к уже выданному словарю
+	dictionary := service.Dictionary(project.ID)
+	global := service.GlobalDictionary()
+	if dictionary == nil || global == nil {
+		t.Fatal("dictionaries are not loaded")
+	}
+
+	run, err := service.Mine(project.ID)
+	if err != nil {
+		t.Fatalf("Mine() error = %v", err)
+	}
+	if run.Names != len(names) || len(run.Candidates) != 1 || run.Candidates[0].Term != "нерж" {
+		t.Fatalf("Mine() = %+v", run)
+	}
+	pending, err := service.ListEntries(project.ID, "", database.TermStatusPending, 0)
+	if err != nil {
+		t.Fatalf("ListEntries() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Replacement != "нержавеющий" || pending[0].Source != database.TermSourceMined {
+		t.Fatalf("pending = %+v", pending)
+	}
+	if got := dictionary.Apply("Лист нерж. 4мм").Result; got != "Лист нерж. 4мм" {
+		t.Errorf("pending candidate applied: %q", got)
+	}
+
+	// Подтвержденное сокращение применяется с исправленной при проверке полной формой
+	if _, err := service.ReviewEntry(pending[0].ID, database.TermStatusApproved, "Нержавеющая", "tester"); err != nil {
+		t.Fatalf("ReviewEntry() error = %v", err)
+	}
+	if got := dictionary.Apply("Лист нерж. 4мм").Result; got != "Лист нержавеющая 4мм" {
+		t.Errorf("approved abbreviation: %q", got)
+	}
+	if got := global.Apply("Лист нерж. 4мм").Result; got != "Лист нерж. 4мм" {
+		t.Errorf("project entry applied globally: %q", got)
+	}
+
+	// Повторный поиск не предлагает проверенный термин
+	if run, err := service.Mine(project.ID); err != nil || len(run.Candidates) != 0 {
+		t.Errorf("repeated Mine() = %+v, %v", run, err)
+	}
+
+	// Общая запись действует во всех проектах, пока проект не отклонит ее
+	if _, err := service.AddEntry(0, normalization.TermKindAbbreviation, "Оцинк.", "оцинкованный", "tester"); err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if got := dictionary.Apply("Труба оцинк.").Result; got != "Труба оцинкованный" {
+		t.Errorf("global entry in project dictionary: %q", got)
+	}
+	override, err := service.AddEntry(project.ID, normalization.TermKindAbbreviation, "оцинк", "оцинковка", "tester")
+	if err != nil {
+		t.Fatalf("AddEntry() error = %v", err)
+	}
+	if _, err := service.ReviewEntry(override.ID, database.TermStatusRejected, "", "tester"); err != nil {
+		t.Fatalf("ReviewEntry() error = %v", err)
+	}
+	if got := dictionary.Apply("Труба оцинк.").Result; got != "Труба оцинк." {
+		t.Errorf("rejected project entry did not disable global one: %q", got)
+	}
+	if got := global.Apply("Труба оцинк.").Result; got != "Труба оцинкованный" {
+		t.Errorf("global dictionary: %q", got)
+	}
+
+	if _, err := service.AddEntry(project.ID, normalization.TermKindStopWord, "Прочее", "", ""); err != nil {
+		t.Fatalf("AddEntry(stopword) error = %v", err)
+	}
+	result, stats, err := service.Apply(project.ID, "Прочее: лист нерж.")
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.Result != ": лист нержавеющая" || stats.StopWords != 1 || stats.Abbreviations != 1 {
+		t.Errorf("Apply() = %q, stats = %+v", result.Result, stats)
+	}
+
+	if err := service.DeleteEntry(override.ID); err != nil {
+		t.Fatalf("DeleteEntry() error = %v", err)
+	}
+	if got := dictionary.Apply("Труба оцинк.").Result; got != "Труба оцинкованный" {
+		t.Errorf("global entry after override removal: %q", got)
+	}
+
+	if _, err := service.AddEntry(project.ID, "acronym", "ВВГ", "кабель", ""); err == nil {
+		t.Error("AddEntry() with unknown kind expected error")
+	}
+	if _, err := service.AddEntry(project.ID, normalization.TermKindSynonym, "болт", "", ""); err == nil {
+		t.Error("AddEntry() without replacement expected error")
+	}
+	if _, err := service.ReviewEntry(9999, database.TermStatusApproved, "", ""); err == nil {
+		t.Error("ReviewEntry() for missing entry expected error")
+	}
+	if err := service.DeleteEntry(9999); err == nil {
+		t.Error("DeleteEntry() for missing entry expected error")
+	}
+}
@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"httpserver/database"
+	apperrors "httpserver/server/errors"
+)
+
+// Действия правил автоматизации выгрузок
+const (
+	UploadActionNormalization       = "normalization"        // нормализация проекта (params: use_kpved, use_okpd2, all_active, timeout_minutes)
+	UploadActionCounterpartyMapping = "counterparty_mapping" // мэппинг контрагентов проекта
+	UploadActionKpvedClassification = "kpved_classification" // КПВЭД для новых групп без кода (params: limit)
+	UploadActionQualityAnalysis     = "quality_analysis"     // анализ качества выгрузки
+	UploadActionSnapshot            = "snapshot"             // срез последних выгрузок (params: uploads_per_database, name)
+	UploadActionNotify              = "notify"               // уведомление (params: title, message, type)
+)
+
+// UploadAutomationContext данные выгрузки, для которой выполняется правило
+type UploadAutomationContext struct {
+	Upload     *database.Upload
+	Rule       *database.UploadAutomationRule
+	ClientID   int
+	ProjectID  int
+	DatabaseID int
+}
+
+// UploadAutomationActionFunc выполняет шаг правила; результат сохраняется в истории запуска
+type UploadAutomationActionFunc func(ctx context.Context, run *UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error)
+
+// UploadAutomationService хранит правила проектов "выгрузка завершена -> цепочка действий"
+// и выполняет их по порядку после завершения выгрузки из 1С
+type UploadAutomationService struct {
+	serviceDB *database.ServiceDB
+	logFunc   func(format string, args ...interface{})
+	now       func() time.Time
+
+	mu      sync.RWMutex
+	actions map[string]UploadAutomationActionFunc
+
+	projectLocksMu sync.Mutex
+	projectLocks   map[int]*sync.Mutex // правила одного проекта не выполняются параллельно
+}
+
+// NewUploadAutomationService создает сервис автоматизации выгрузок. logFunc может быть nil.
+func NewUploadAutomationService(serviceDB *database.ServiceDB, logFunc func(format string, args ...interface{})) *UploadAutomationService {
+	if logFunc == nil {
+		logFunc = func(string, ...interface{}) {}
+	}
+	return &UploadAutomationService{
+		serviceDB:    serviceDB,
+		logFunc:      logFunc,
+		now:          time.Now,
+		actions:      make(map[string]UploadAutomationActionFunc),
+		projectLocks: make(map[int]*sync.Mutex),
+	}
+}
+
+// RegisterAction регистрирует исполнителя действия
+func (s *UploadAutomationService) RegisterAction(actionType string, fn UploadAutomationActionFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[actionType] = fn
+}
+
+// ActionTypes возвращает зарегистрированные действия
+func (s *UploadAutomationService) ActionTypes() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	types := make([]string, 0, len(s.actions))
+	for actionType := range s.actions {
+		types = append(types, actionType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func (s *UploadAutomationService) action(actionType string) UploadAutomationActionFunc {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.actions[actionType]
+}
+
+// ListRules возвращает правила проекта в порядке выполнения
+func (s *UploadAutomationService) ListRules(projectID int) ([]*database.UploadAutomationRule, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	rules, err := s.serviceDB.GetUploadAutomationRules(projectID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить правила автоматизации", err)
+	}
+	return rules, nil
+}
+
+// GetRule возвращает правило по ID
+func (s *UploadAutomationService) GetRule(id int) (*database.UploadAutomationRule, error) {
+	if s.serviceDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	rule, err := s.serviceDB.GetUploadAutomationRule(id)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить правило автоматизации", err)
+	}
+	if rule == nil {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("правило автоматизации %d не найдено", id), nil)
+	}
+	return rule, nil
+}
+
+// CreateRule проверяет и сохраняет правило проекта
+func (s *UploadAutomationService) CreateRule(projectID int, rule *database.UploadAutomationRule) (*database.UploadAutomationRule, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	rule.ProjectID = projectID
+	if err := s.validateRule(rule); err != nil {
+		return nil, err
+	}
+	created, err := s.serviceDB.CreateUploadAutomationRule(rule)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось создать правило автоматизации", err)
+	}
+	return created, nil
+}
+
+// UpdateRule проверяет и изменяет правило; проект правила не меняется
+func (s *UploadAutomationService) UpdateRule(id int, rule *database.UploadAutomationRule) (*database.UploadAutomationRule, error) {
+	existing, err := s.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	rule.ProjectID = existing.ProjectID
+	if err := s.validateRule(rule); err != nil {
+		return nil, err
+	}
+	updated, err := s.serviceDB.UpdateUploadAutomationRule(rule)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось изменить правило автоматизации", err)
+	}
+	if updated == nil {
+		return nil, apperrors.NewNotFoundError(fmt.Sprintf("правило автоматизации %d не найдено", id), nil)
+	}
+	return updated, nil
+}
+
+// DeleteRule удаляет правило
+func (s *UploadAutomationService) DeleteRule(id int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	deleted, err := s.serviceDB.DeleteUploadAutomationRule(id)
+	if err != nil {
+		return apperrors.NewInternalError("не удалось удалить правило автоматизации", err)
+	}
+	if !deleted {
+		return apperrors.NewNotFoundError(fmt.Sprintf("правило автоматизации %d не найдено", id), nil)
+	}
+	return nil
+}
+
+// GetUploadRuns возвращает запуски правил для выгрузки (история выгрузки)
+func (s *UploadAutomationService) GetUploadRuns(uploadUUID string) ([]*database.UploadAutomationRun, error) {
+	if s.serviceDB == nil {
+		return nil, apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	runs, err := s.serviceDB.GetUploadAutomationRuns(uploadUUID)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить историю автоматизации выгрузки", err)
+	}
+	return runs, nil
+}
+
+// GetProjectRuns возвращает последние запуски правил проекта
+func (s *UploadAutomationService) GetProjectRuns(projectID, limit int) ([]*database.UploadAutomationRun, error) {
+	if err := s.checkProject(projectID); err != nil {
+		return nil, err
+	}
+	runs, err := s.serviceDB.GetProjectUploadAutomationRuns(projectID, limit)
+	if err != nil {
+		return nil, apperrors.NewInternalError("не удалось получить историю автоматизации проекта", err)
+	}
+	return runs, nil
+}
+
+// OnUploadCompleted запускает правила проекта для завершенной выгрузки в фоне
+func (s *UploadAutomationService) OnUploadCompleted(upload *database.Upload) {
+	if upload == nil {
+		return
+	}
+	go func() {
+		if _, err := s.RunForUpload(context.Background(), upload); err != nil {
+			s.logFunc("[UploadAutomation] Failed to run rules for upload %s: %v", upload.UploadUUID, err)
+		}
+	}()
+}
+
+// RunForUpload выполняет подходящие правила проекта выгрузки по порядку и сохраняет запуски.
+// Правила, не подходящие по БД или конфигурации, не попадают в историю; правила,
+// не прошедшие условия, сохраняются со статусом skipped и причиной
+func (s *UploadAutomationService) RunForUpload(ctx context.Context, upload *database.Upload) ([]*database.UploadAutomationRun, error) {
+	if s.serviceDB == nil {
+		return nil, fmt.Errorf("service database not available")
+	}
+	runCtx, err := s.resolveUpload(upload)
+	if err != nil || runCtx == nil {
+		return nil, err
+	}
+	rules, err := s.serviceDB.GetUploadAutomationRules(runCtx.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := s.projectLock(runCtx.ProjectID)
+	lock.Lock()
+	defer lock.Unlock()
+
+	runs := make([]*database.UploadAutomationRun, 0)
+	for _, rule := range rules {
+		if !rule.Enabled || !ruleMatchesUpload(rule, runCtx) {
+			continue
+		}
+		ruleCtx := *runCtx
+		ruleCtx.Rule = rule
+		run, err := s.runRule(ctx, &ruleCtx)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// resolveUpload определяет проект выгрузки; nil, если выгрузка не привязана к проекту
+func (s *UploadAutomationService) resolveUpload(upload *database.Upload) (*UploadAutomationContext, error) {
+	runCtx := &UploadAutomationContext{Upload: upload}
+	if upload.DatabaseID != nil {
+		runCtx.DatabaseID = *upload.DatabaseID
+	}
+	if upload.ProjectID != nil {
+		runCtx.ProjectID = *upload.ProjectID
+	} else if runCtx.DatabaseID > 0 {
+		projectDB, err := s.serviceDB.GetProjectDatabase(runCtx.DatabaseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get project database %d: %w", runCtx.DatabaseID, err)
+		}
+		if projectDB != nil {
+			runCtx.ProjectID = projectDB.ClientProjectID
+		}
+	}
+	if runCtx.ProjectID <= 0 {
+		return nil, nil
+	}
+	project, err := s.serviceDB.GetClientProject(runCtx.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project %d: %w", runCtx.ProjectID, err)
+	}
+	runCtx.ClientID = project.ClientID
+	return runCtx, nil
+}
+
+// runRule выполняет шаги правила; ошибка шага прерывает правило, если не задан continue_on_error
+func (s *UploadAutomationService) runRule(ctx context.Context, runCtx *UploadAutomationContext) (*database.UploadAutomationRun, error) {
+	rule := runCtx.Rule
+	run := &database.UploadAutomationRun{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		ProjectID:  runCtx.ProjectID,
+		UploadID:   runCtx.Upload.ID,
+		UploadUUID: runCtx.Upload.UploadUUID,
+		Status:     database.UploadAutomationStatusRunning,
+		Steps:      make([]database.UploadAutomationStepResult, 0, len(rule.Actions)),
+		StartedAt:  s.now(),
+	}
+
+	if reason := ruleConditionsFailure(rule.Conditions, runCtx.Upload); reason != "" {
+		finished := s.now()
+		run.Status = database.UploadAutomationStatusSkipped
+		run.Reason = reason
+		run.FinishedAt = &finished
+		return run, s.serviceDB.CreateUploadAutomationRun(run)
+	}
+	if err := s.serviceDB.CreateUploadAutomationRun(run); err != nil {
+		return nil, err
+	}
+	s.logFunc("[UploadAutomation] Running rule %q for upload %s (project %d)", rule.Name, run.UploadUUID, run.ProjectID)
+
+	stopped := false
+	for _, action := range rule.Actions {
+		step := database.UploadAutomationStepResult{Type: action.Type, StartedAt: s.now()}
+		if stopped {
+			step.Status = database.UploadAutomationStatusSkipped
+			run.Steps = append(run.Steps, step)
+			continue
+		}
+
+		result, err := s.runAction(ctx, runCtx, action)
+		finished := s.now()
+		step.FinishedAt = &finished
+		step.Result = result
+		if err != nil {
+			step.Status = database.UploadAutomationStatusFailed
+			step.Error = err.Error()
+			if run.Error == "" {
+				run.Error = fmt.Sprintf("%s: %v", action.Type, err)
+			}
+			stopped = !action.ContinueOnError
+		} else {
+			step.Status = database.UploadAutomationStatusCompleted
+		}
+		run.Steps = append(run.Steps, step)
+		if err := s.serviceDB.UpdateUploadAutomationRun(run); err != nil {
+			s.logFunc("[UploadAutomation] Failed to save progress of rule %q: %v", rule.Name, err)
+		}
+	}
+
+	finished := s.now()
+	run.FinishedAt = &finished
+	run.Status = database.UploadAutomationStatusCompleted
+	if run.Error != "" {
+		run.Status = database.UploadAutomationStatusFailed
+	}
+	s.logFunc("[UploadAutomation] Rule %q for upload %s finished: %s", rule.Name, run.UploadUUID, run.Status)
+	return run, s.serviceDB.UpdateUploadAutomationRun(run)
+}
+
+// runAction выполняет одно действие, перехватывая панику исполнителя
+func (s *UploadAutomationService) runAction(ctx context.Context, runCtx *UploadAutomationContext, action database.UploadAutomationAction) (result map[string]interface{}, err error) {
+	fn := s.action(action.Type)
+	if fn == nil {
+		return nil, fmt.Errorf("action %s is not available", action.Type)
+	}
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("action %s panicked: %v", action.Type, rec)
+		}
+	}()
+	params := action.Params
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	return fn(ctx, runCtx, params)
+}
+
+func (s *UploadAutomationService) projectLock(projectID int) *sync.Mutex {
+	s.projectLocksMu.Lock()
+	defer s.projectLocksMu.Unlock()
+	lock, ok := s.projectLocks[projectID]
+	if !ok {
+		lock = &sync.Mutex{}
+		s.projectLocks[projectID] = lock
+	}
+	return lock
+}
+
+func (s *UploadAutomationService) validateRule(rule *database.UploadAutomationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return apperrors.NewValidationError("название правила обязательно", nil)
+	}
+	if len(rule.Actions) == 0 {
+		return apperrors.NewValidationError("правило должно содержать хотя бы одно действие", nil)
+	}
+	for i, action := range rule.Actions {
+		if s.action(action.Type) == nil {
+			return apperrors.NewValidationError(fmt.Sprintf("неизвестное действие %q в шаге %d; доступны: %s",
+				action.Type, i+1, strings.Join(s.ActionTypes(), ", ")), nil)
+		}
+	}
+	if rule.Conditions.MinItems < 0 {
+		return apperrors.NewValidationError("min_items не может быть отрицательным", nil)
+	}
+	if rule.DatabaseID != nil {
+		projectDB, err := s.serviceDB.GetProjectDatabase(*rule.DatabaseID)
+		if err != nil || projectDB == nil || projectDB.ClientProjectID != rule.ProjectID {
+			return apperrors.NewValidationError(fmt.Sprintf("база данных %d не принадлежит проекту %d", *rule.DatabaseID, rule.ProjectID), err)
+		}
+	}
+	return nil
+}
+
+func (s *UploadAutomationService) checkProject(projectID int) error {
+	if s.serviceDB == nil {
+		return apperrors.NewServiceUnavailableError("база данных недоступна", nil)
+	}
+	if _, err := s.serviceDB.GetClientProject(projectID); err != nil {
+		return apperrors.NewNotFoundError(fmt.Sprintf("проект %d не найден", projectID), err)
+	}
+	return nil
+}
+
+// ruleMatchesUpload проверяет фильтры правила по базе данных и конфигурации 1С
+func ruleMatchesUpload(rule *database.UploadAutomationRule, runCtx *UploadAutomationContext) bool {
+	if rule.DatabaseID != nil && *rule.DatabaseID != runCtx.DatabaseID {
+		return false
+	}
+	if rule.ConfigName != "" && !strings.EqualFold(rule.ConfigName, runCtx.Upload.ConfigName) {
+		return false
+	}
+	return true
+}
+
+// ruleConditionsFailure возвращает причину, по которой выгрузка не прошла условия правила
+func ruleConditionsFailure(conditions database.UploadAutomationConditions, upload *database.Upload) string {
+	if conditions.MinItems > 0 && upload.TotalItems < conditions.MinItems {
+		return fmt.Sprintf("в выгрузке %d элементов, требуется не менее %d", upload.TotalItems, conditions.MinItems)
+	}
+	if conditions.IterationLabel != "" && !strings.EqualFold(conditions.IterationLabel, upload.IterationLabel) {
+		return fmt.Sprintf("метка итерации %q не совпадает с %q", upload.IterationLabel, conditions.IterationLabel)
+	}
+	if conditions.UploadPurpose != "" && !strings.EqualFold(conditions.UploadPurpose, upload.UploadPurpose) {
+		return fmt.Sprintf("назначение выгрузки %q не совпадает с %q", upload.UploadPurpose, conditions.UploadPurpose)
+	}
+	return ""
+}
+
+// UploadAutomationParamInt возвращает целочисленный параметр действия (JSON-числа приходят как float64)
+func UploadAutomationParamInt(params map[string]interface{}, name string, def int) int {
+	switch v := params[name].(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	}
+	return def
+}
+
+// UploadAutomationParamBool возвращает логический параметр действия
+func UploadAutomationParamBool(params map[string]interface{}, name string, def bool) bool {
+	if v, ok := params[name].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// UploadAutomationParamString возвращает строковый параметр действия
+func UploadAutomationParamString(params map[string]interface{}, name, def string) string {
+	if v, ok := params[name].(string); ok && v != "" {
+		return v
+	}
+	return def
+}
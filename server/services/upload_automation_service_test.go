@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"httpserver/database"
+)
+
+// TestUploadAutomationService проверяет выбор правил по выгрузке, порядок шагов и историю запусков
+func TestUploadAutomationService(t *testing.T) {
+	serviceDB := setupTestServiceDB(t)
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "", "", "", "", "", "test")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	projectDB, err := serviceDB.CreateProjectDatabase(project.ID, "УТ", "/tmp/ut.db", "", 0)
+	if err != nil {
+		t.Fatalf("CreateProjectDatabase() error = %v", err)
+	}
+
+	service := NewUploadAutomationService(serviceDB, nil)
+	var calls []string
+	for _, actionType := range []string{UploadActionNormalization, UploadActionCounterpartyMapping, UploadActionNotify} {
+		actionType := actionType
+		service.RegisterAction(actionType, func(ctx context.Context, run *UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+			calls = append(calls, actionType)
+			if run.ClientID != client.ID || run.ProjectID != project.ID {
+				t.Errorf("context = %+v", run)
+			}
+			if UploadAutomationParamBool(params, "fail", false) {
+				return nil, errors.New("step failed")
+			}
+			return map[string]interface{}{"ok": true}, nil
+		})
+	}
+
+	if _, err := service.CreateRule(project.ID, &database.UploadAutomationRule{
+		Name: "Неизвестное действие", Actions: []database.UploadAutomationAction{{Type: "unknown"}},
+	}); err == nil {
+		t.Error("CreateRule() with unknown action expected error")
+	}
+
+	databaseID := projectDB.ID
+	if _, err := service.CreateRule(project.ID, &database.UploadAutomationRule{
+		Name: "Основная цепочка", Enabled: true, Position: 1, DatabaseID: &databaseID,
+		Conditions: database.UploadAutomationConditions{MinItems: 10},
+		Actions: []database.UploadAutomationAction{
+			{Type: UploadActionNormalization},
+			{Type: UploadActionCounterpartyMapping, Params: map[string]interface{}{"fail": true}},
+			{Type: UploadActionNotify},
+		},
+	}); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if _, err := service.CreateRule(project.ID, &database.UploadAutomationRule{
+		Name: "Только финальные выгрузки", Enabled: true, Position: 2,
+		Conditions: database.UploadAutomationConditions{IterationLabel: "final"},
+		Actions:    []database.UploadAutomationAction{{Type: UploadActionNotify}},
+	}); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+	if _, err := service.CreateRule(project.ID, &database.UploadAutomationRule{
+		Name: "Другая конфигурация", Enabled: true, Position: 3, ConfigName: "БухгалтерияПредприятия",
+		Actions: []database.UploadAutomationAction{{Type: UploadActionNotify}},
+	}); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	upload := &database.Upload{ID: 7, UploadUUID: "upload-7", ConfigName: "УправлениеТорговлей", TotalItems: 25, DatabaseID: &databaseID}
+	runs, err := service.RunForUpload(context.Background(), upload)
+	if err != nil {
+		t.Fatalf("RunForUpload() error = %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("runs = %d, want 2 (rule for another config is not applicable)", len(runs))
+	}
+
+	main := runs[0]
+	if main.Status != database.UploadAutomationStatusFailed || len(main.Steps) != 3 {
+		t.Fatalf("main run = %+v", main)
+	}
+	if main.Steps[0].Status != database.UploadAutomationStatusCompleted ||
+		main.Steps[1].Status != database.UploadAutomationStatusFailed ||
+		main.Steps[2].Status != database.UploadAutomationStatusSkipped {
+		t.Errorf("steps = %+v", main.Steps)
+	}
+	if len(calls) != 2 || calls[0] != UploadActionNormalization || calls[1] != UploadActionCounterpartyMapping {
+		t.Errorf("calls = %v", calls)
+	}
+	if runs[1].Status != database.UploadAutomationStatusSkipped || runs[1].Reason == "" {
+		t.Errorf("iteration rule run = %+v", runs[1])
+	}
+
+	history, err := service.GetUploadRuns("upload-7")
+	if err != nil || len(history) != 2 || history[0].RuleName != "Основная цепочка" || len(history[0].Steps) != 3 {
+		t.Fatalf("GetUploadRuns() = %+v, %v", history, err)
+	}
+
+	// Выгрузка меньше порога не запускает шаги правила
+	calls = nil
+	small := &database.Upload{ID: 8, UploadUUID: "upload-8", TotalItems: 3, DatabaseID: &databaseID}
+	runs, err = service.RunForUpload(context.Background(), small)
+	if err != nil || len(runs) != 2 || runs[0].Status != database.UploadAutomationStatusSkipped || len(calls) != 0 {
+		t.Errorf("small upload runs = %+v, calls = %v, err = %v", runs, calls, err)
+	}
+
+	// Выгрузка без проекта игнорируется
+	if runs, err := service.RunForUpload(context.Background(), &database.Upload{UploadUUID: "orphan"}); err != nil || len(runs) != 0 {
+		t.Errorf("orphan upload runs = %v, %v", runs, err)
+	}
+}
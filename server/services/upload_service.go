@@ -9,7 +9,9 @@ import (
 	"github.com/google/uuid"
 
 	"httpserver/database"
+	"httpserver/extractors"
 	apperrors "httpserver/server/errors"
+	"httpserver/server/events"
 	"httpserver/server/types"
 	"httpserver/server/utils"
 )
@@ -20,6 +22,7 @@ type UploadService struct {
 	serviceDB       *database.ServiceDB
 	dbInfoCache     interface{} // *server.DatabaseInfoCache
 	logFunc         func(entry interface{}) // server.LogEntry, но без прямого импорта для избежания циклических зависимостей
+	eventBus        *events.Bus             // Шина событий прогресса выгрузок (может быть nil)
 }
 
 // NewUploadService создает новый сервис для работы с выгрузками
@@ -37,6 +40,33 @@ func NewUploadService(
 	}
 }
 
+// SetEventBus подключает шину событий для публикации прогресса выгрузок в топик uploads
+func (s *UploadService) SetEventBus(bus *events.Bus) {
+	s.eventBus = bus
+}
+
+// publishUploadEvent публикует событие выгрузки с областью клиента и проекта выгрузки
+func (s *UploadService) publishUploadEvent(eventType string, upload *database.Upload, clientID, projectID int, data map[string]interface{}) {
+	if s.eventBus == nil || upload == nil {
+		return
+	}
+	if clientID == 0 && upload.ClientID != nil {
+		clientID = *upload.ClientID
+	}
+	if projectID == 0 && upload.ProjectID != nil {
+		projectID = *upload.ProjectID
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	data["upload_uuid"] = upload.UploadUUID
+	s.eventBus.PublishData(events.TopicUploads, eventType, events.Filter{
+		ClientID:  clientID,
+		ProjectID: projectID,
+		SessionID: upload.UploadUUID,
+	}, data)
+}
+
 // HandshakeResult результат выполнения handshake
 type HandshakeResult struct {
 	UploadUUID   string
@@ -116,9 +146,8 @@ func (s *UploadService) ProcessHandshake(req types.HandshakeRequest) (*Handshake
 	}
 
 	// Обновляем кэшированные значения client_id и project_id
+	var clientID, projectID int
 	if databaseID != nil {
-		var clientID, projectID int
-
 		// Если идентификация была по похожей выгрузке, используем её значения
 		if identifiedBy != "" && similarUpload != nil {
 			if similarUpload.ClientID != nil {
@@ -158,6 +187,11 @@ func (s *UploadService) ProcessHandshake(req types.HandshakeRequest) (*Handshake
 		}
 	}
 
+	s.publishUploadEvent("upload.started", upload, clientID, projectID, map[string]interface{}{
+		"config_name":   req.ConfigName,
+		"database_name": databaseName,
+	})
+
 	return &HandshakeResult{
 		UploadUUID:   uploadUUID,
 		DatabaseID:   databaseID,
@@ -242,8 +276,9 @@ func (s *UploadService) ProcessCatalogItem(uploadUUID, catalogName, reference, c
 		return apperrors.NewInternalError("не удалось получить каталог", err)
 	}
 
-	// Добавляем элемент справочника
-	if err := s.db.AddCatalogItem(catalogID, reference, code, name, attributes, tableParts); err != nil {
+	// Добавляем элемент справочника; реквизиты разбираются один раз при загрузке
+	attrs := extractors.ParseAttributes(attributes, tableParts)
+	if err := s.db.AddCatalogItemWithAttributes(catalogID, reference, code, name, attributes, tableParts, attrs); err != nil {
 		return apperrors.NewInternalError("не удалось добавить элемент каталога", err)
 	}
 
@@ -276,7 +311,8 @@ func (s *UploadService) ProcessCatalogItemsBatch(uploadUUID, catalogName string,
 	failedCount = 0
 
 	for _, item := range items {
-		if err := s.db.AddCatalogItem(catalogID, item.Reference, item.Code, item.Name, item.Attributes, item.TableParts); err != nil {
+		attrs := extractors.ParseAttributes(item.Attributes, item.TableParts)
+		if err := s.db.AddCatalogItemWithAttributes(catalogID, item.Reference, item.Code, item.Name, item.Attributes, item.TableParts, attrs); err != nil {
 			failedCount++
 			s.logFunc(types.LogEntry{
 				Timestamp:  time.Now(),
@@ -290,6 +326,12 @@ func (s *UploadService) ProcessCatalogItemsBatch(uploadUUID, catalogName string,
 		}
 	}
 
+	s.publishUploadEvent("upload.progress", upload, 0, 0, map[string]interface{}{
+		"catalog":   catalogName,
+		"processed": processedCount,
+		"failed":    failedCount,
+	})
+
 	return processedCount, failedCount, nil
 }
 
@@ -315,6 +357,7 @@ func (s *UploadService) ProcessNomenclatureBatch(uploadUUID string, items []type
 			CharacteristicName:      item.CharacteristicName,
 			AttributesXML:           item.Attributes,
 			TablePartsXML:           item.TableParts,
+			AttributeSet:            extractors.ParseAttributes(item.Attributes, item.TableParts),
 		})
 	}
 
@@ -323,6 +366,11 @@ func (s *UploadService) ProcessNomenclatureBatch(uploadUUID string, items []type
 		return 0, apperrors.NewInternalError("не удалось добавить элементы номенклатуры", err)
 	}
 
+	s.publishUploadEvent("upload.progress", upload, 0, 0, map[string]interface{}{
+		"catalog":   "nomenclature",
+		"processed": len(items),
+	})
+
 	return len(items), nil
 }
 
@@ -348,6 +396,8 @@ func (s *UploadService) ProcessCompleteWithUpload(uploadUUID string) (*database.
 		return nil, apperrors.NewInternalError("не удалось завершить выгрузку", err)
 	}
 
+	s.publishUploadEvent("upload.completed", upload, 0, 0, nil)
+
 	return upload, nil
 }
 
@@ -99,6 +99,25 @@ func (s *Server) handleSystemSummaryStream(w http.ResponseWriter, r *http.Reques
 
 // sendSummaryUpdate отправляет обновление сводки через SSE
 func (s *Server) sendSummaryUpdate(w http.ResponseWriter, flusher http.Flusher) {
+	updateJSON, err := json.Marshal(s.buildSummaryUpdate())
+	if err != nil {
+		slog.Error("[SystemScanner] Error marshaling summary update",
+			"error", err,
+		)
+		return
+	}
+
+	if _, err := fmt.Fprintf(w, "data: %s\n\n", string(updateJSON)); err != nil {
+		slog.Error("[SystemScanner] Error sending summary update",
+			"error", err,
+		)
+		return
+	}
+	flusher.Flush()
+}
+
+// buildSummaryUpdate формирует обновление сводки для SSE и шины событий
+func (s *Server) buildSummaryUpdate() map[string]interface{} {
 	// Получаем кэшированную сводку или создаем новую
 	var summary *SystemSummary
 	var fromCache bool
@@ -148,20 +167,6 @@ func (s *Server) sendSummaryUpdate(w http.ResponseWriter, flusher http.Flusher)
 		}
 	}
 
-	updateJSON, err := json.Marshal(update)
-	if err != nil {
-		slog.Error("[SystemScanner] Error marshaling summary update",
-			"error", err,
-		)
-		return
-	}
-
-	if _, err := fmt.Fprintf(w, "data: %s\n\n", string(updateJSON)); err != nil {
-		slog.Error("[SystemScanner] Error sending summary update",
-			"error", err,
-		)
-		return
-	}
-	flusher.Flush()
+	return update
 }
 
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"httpserver/internal/config"
+	"httpserver/server/middleware"
+)
+
+// certReloader хранит текущий сертификат сервера и пул клиентских CA
+// и перечитывает их с диска по SIGHUP без перезапуска сервера
+type certReloader struct {
+	cfg *config.TLSConfig
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+}
+
+// newCertReloader загружает сертификат сервера и клиентские CA
+func newCertReloader(cfg *config.TLSConfig) (*certReloader, error) {
+	r := &certReloader{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload перечитывает сертификат, ключ и клиентские CA. При ошибке остаются прежние.
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pemData, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pemData) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.mu.Unlock()
+	return nil
+}
+
+// TLSConfig возвращает конфигурацию, которая для каждого соединения берет актуальные сертификаты
+func (r *certReloader) TLSConfig() *tls.Config {
+	clientAuth := tls.NoClientCert
+	if r.cfg.UploadClientAuth != "" && r.cfg.UploadClientAuth != config.ClientAuthNone {
+		// Сертификат нужен только эндпоинтам выгрузки, поэтому на уровне TLS он необязателен;
+		// обязательность проверяет GinUploadClientCertMiddleware
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   clientAuth,
+				ClientCAs:    r.clientCAs,
+				NextProtos:   []string{"h2", "http/1.1"},
+			}, nil
+		},
+	}
+}
+
+// ensureSelfSignedCertificate выпускает самоподписанный сертификат для разработки,
+// если файлы сертификата и ключа отсутствуют
+func ensureSelfSignedCertificate(certFile, keyFile string) (bool, error) {
+	if _, err := os.Stat(certFile); err == nil {
+		if _, err := os.Stat(keyFile); err == nil {
+			return false, nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return false, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return false, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	hosts := []string{"localhost"}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" && hostname != "localhost" {
+		hosts = append(hosts, hostname)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: hosts[len(hosts)-1], Organization: []string{"httpserver dev"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              hosts,
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal key: %w", err)
+	}
+
+	for _, path := range []string{certFile, keyFile} {
+		if dir := filepath.Dir(path); dir != "" {
+			if err := os.MkdirAll(dir, 0o700); err != nil {
+				return false, fmt.Errorf("failed to create directory %s: %w", dir, err)
+			}
+		}
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return false, fmt.Errorf("failed to write certificate: %w", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return false, fmt.Errorf("failed to write key: %w", err)
+	}
+	return true, nil
+}
+
+// uploadClientAuthMode возвращает режим проверки клиентских сертификатов выгрузки
+func (s *Server) uploadClientAuthMode() string {
+	if s.config == nil || s.config.TLS == nil || !s.config.TLS.Enabled || s.config.TLS.UploadClientAuth == "" {
+		return config.ClientAuthNone
+	}
+	return s.config.TLS.UploadClientAuth
+}
+
+// setupTLS подготавливает сертификаты и возвращает TLS конфигурацию сервера
+// (nil, если HTTPS выключен)
+func (s *Server) setupTLS() (*tls.Config, error) {
+	if s.config == nil || s.config.TLS == nil || !s.config.TLS.Enabled {
+		return nil, nil
+	}
+	cfg := s.config.TLS
+
+	if cfg.SelfSigned {
+		created, err := ensureSelfSignedCertificate(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to bootstrap self-signed certificate: %w", err)
+		}
+		if created {
+			log.Printf("[TLS] Выпущен самоподписанный сертификат для разработки: %s", cfg.CertFile)
+		}
+	}
+
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.certReloader = reloader
+	return reloader.TLSConfig(), nil
+}
+
+// startTLSReloadOnSignal перечитывает сертификаты по SIGHUP
+func (s *Server) startTLSReloadOnSignal() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-sighup:
+			if err := s.certReloader.Reload(); err != nil {
+				log.Printf("[TLS] Не удалось перечитать сертификаты, используются прежние: %v", err)
+			} else {
+				log.Printf("[TLS] Сертификаты перечитаны")
+			}
+		case <-s.shutdownChan:
+			return
+		}
+	}
+}
+
+// authorizeUploadCertificate сверяет CN сертификата 1С с клиентом и проектом базы выгрузки
+func (s *Server) authorizeUploadCertificate(cert *x509.Certificate, uploadUUID, databaseID string) error {
+	clientID, projectID, _ := s.resolveUploadClientProject(uploadUUID, databaseID)
+	_, err := s.clientCertService.Authorize(cert.Subject.CommonName, middleware.CertificateFingerprint(cert), clientID, projectID)
+	return err
+}
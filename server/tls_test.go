@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"httpserver/database"
+	"httpserver/internal/config"
+	"httpserver/server/middleware"
+	"httpserver/server/services"
+)
+
+// TestCertReloader_SelfSignedAndReload проверяет выпуск самоподписанного сертификата
+// и подмену сертификата без пересоздания TLS конфигурации
+func TestCertReloader_SelfSignedAndReload(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.TLSConfig{
+		Enabled:  true,
+		CertFile: filepath.Join(dir, "certs", "server.crt"),
+		KeyFile:  filepath.Join(dir, "certs", "server.key"),
+	}
+
+	created, err := ensureSelfSignedCertificate(cfg.CertFile, cfg.KeyFile)
+	if err != nil || !created {
+		t.Fatalf("ensureSelfSignedCertificate() = %v, %v; want created", created, err)
+	}
+	if created, err = ensureSelfSignedCertificate(cfg.CertFile, cfg.KeyFile); err != nil || created {
+		t.Fatalf("existing certificate must be kept, got created=%v, err=%v", created, err)
+	}
+
+	reloader, err := newCertReloader(cfg)
+	if err != nil {
+		t.Fatalf("newCertReloader() error = %v", err)
+	}
+	tlsConfig := reloader.TLSConfig()
+	first, err := tlsConfig.GetConfigForClient(nil)
+	if err != nil || len(first.Certificates) != 1 {
+		t.Fatalf("GetConfigForClient() = %v, %v", first, err)
+	}
+
+	// Сертификат обновлен на диске - после Reload соединения получают новый
+	os.Remove(cfg.CertFile)
+	if _, err := ensureSelfSignedCertificate(cfg.CertFile, cfg.KeyFile); err != nil {
+		t.Fatalf("reissue certificate: %v", err)
+	}
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	second, _ := tlsConfig.GetConfigForClient(nil)
+	if bytes.Equal(first.Certificates[0].Certificate[0], second.Certificates[0].Certificate[0]) {
+		t.Error("expected reloaded certificate to differ")
+	}
+
+	// Битый файл не ломает текущую конфигурацию
+	os.WriteFile(cfg.CertFile, []byte("broken"), 0o644)
+	if err := reloader.Reload(); err == nil {
+		t.Error("expected Reload() to fail on broken certificate")
+	}
+	third, _ := tlsConfig.GetConfigForClient(nil)
+	if !bytes.Equal(second.Certificates[0].Certificate[0], third.Certificates[0].Certificate[0]) {
+		t.Error("previous certificate must be kept after failed reload")
+	}
+}
+
+// uploadIdentityFixture две базы разных клиентов и выгрузка upload-b в базу клиента B
+type uploadIdentityFixture struct {
+	server           *Server
+	clientA, clientB int
+	databaseA        int
+}
+
+// newUploadIdentityFixture создает клиентов A и B, их базы и выгрузку upload-b клиента B.
+// Сертификат 1c-client-a привязан к клиенту A
+func newUploadIdentityFixture(t *testing.T) *uploadIdentityFixture {
+	t.Helper()
+	dir := t.TempDir()
+	serviceDB, err := database.NewServiceDB(filepath.Join(dir, "service.db"))
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	t.Cleanup(func() { serviceDB.Close() })
+	db, err := database.NewDB(filepath.Join(dir, "uploads.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	newDatabase := func(name string) (int, int) {
+		client, err := serviceDB.CreateClient(name, name, "", "", "", "")
+		if err != nil {
+			t.Fatalf("CreateClient() error = %v", err)
+		}
+		project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+		if err != nil {
+			t.Fatalf("CreateClientProject() error = %v", err)
+		}
+		projectDB, err := serviceDB.CreateProjectDatabase(project.ID, name, filepath.Join(dir, name+".db"), "", 0)
+		if err != nil {
+			t.Fatalf("CreateProjectDatabase() error = %v", err)
+		}
+		return client.ID, projectDB.ID
+	}
+	f := &uploadIdentityFixture{}
+	f.clientA, f.databaseA = newDatabase("a")
+	var databaseB int
+	f.clientB, databaseB = newDatabase("b")
+	if _, err := db.CreateUploadWithDatabase("upload-b", "8.3", "УТ", &databaseB, "", "", "", 1, "", "", "", nil); err != nil {
+		t.Fatalf("CreateUploadWithDatabase() error = %v", err)
+	}
+
+	certService := services.NewClientCertificateService(serviceDB)
+	if err := certService.Create(&database.ClientCertificateBinding{ClientID: f.clientA, Subject: "1c-client-a"}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	f.server = &Server{
+		uploadService:     services.NewUploadService(db, serviceDB, nil, nil),
+		clientCertService: certService,
+	}
+	return f
+}
+
+// TestUploadCertificate_ForeignUploadUUID проверяет, что сертификат клиента A не дает писать
+// в выгрузку клиента B, даже если в теле запроса указан database_id базы клиента A
+func TestUploadCertificate_ForeignUploadUUID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	f := newUploadIdentityFixture(t)
+	s, clientB, databaseA := f.server, f.clientB, f.databaseA
+
+	if clientID, ok := s.resolveUploadClient("upload-b", ""); !ok || clientID != clientB {
+		t.Errorf("resolveUploadClient(upload-b) = %d/%v, want client %d", clientID, ok, clientB)
+	}
+	if _, ok := s.resolveUploadClient("upload-b", fmt.Sprint(databaseA)); ok {
+		t.Error("resolveUploadClient() with foreign database_id should not resolve a client")
+	}
+
+	router := gin.New()
+	router.Use(middleware.GinUploadIdentityMiddleware(s.verifyUploadIdentifiers))
+	router.Use(middleware.GinUploadClientCertMiddleware(true, s.authorizeUploadCertificate))
+	router.POST("/catalog/items", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/handshake", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(path, body string) int {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: "1c-client-a"}}
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"свой database_id перед чужим upload_uuid", "/catalog/items",
+			fmt.Sprintf("<catalog_items><database_id>%d</database_id><upload_uuid>upload-b</upload_uuid><items/></catalog_items>", databaseA), http.StatusForbidden},
+		{"чужой upload_uuid", "/catalog/items", "<catalog_items><upload_uuid>upload-b</upload_uuid><items/></catalog_items>", http.StatusForbidden},
+		{"database_id без upload_uuid вне handshake", "/catalog/items",
+			fmt.Sprintf("<catalog_items><database_id>%d</database_id><items/></catalog_items>", databaseA), http.StatusForbidden},
+		{"handshake своей базы", "/handshake", fmt.Sprintf("<handshake><database_id>%d</database_id></handshake>", databaseA), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := post(tt.path, tt.body); got != tt.want {
+				t.Errorf("POST %s: code %d, want %d", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package types
+
+import (
+	"time"
+
+	"httpserver/database"
+)
+
+// Типы запросов и ответов REST API, общие для обработчиков сервера и Go-клиента (пакет client).
+// Изменение JSON-тегов здесь меняет контракт API для всех потребителей.
+
+// CreateClientRequest запрос на создание клиента
+type CreateClientRequest struct {
+	Name         string `json:"name"`
+	LegalName    string `json:"legal_name"`
+	Description  string `json:"description"`
+	ContactEmail string `json:"contact_email"`
+	ContactPhone string `json:"contact_phone"`
+	TaxID        string `json:"tax_id"`
+	Country      string `json:"country"`
+}
+
+// ClientProjectRequest запрос на создание или обновление проекта клиента
+type ClientProjectRequest struct {
+	Name               string  `json:"name"`
+	ProjectType        string  `json:"project_type"`
+	Description        string  `json:"description"`
+	SourceSystem       string  `json:"source_system"`
+	TargetQualityScore float64 `json:"target_quality_score"`
+}
+
+// ClientProjectDetails проект клиента с именем клиента и статистикой эталонов
+type ClientProjectDetails struct {
+	Project    *database.ClientProject `json:"project"`
+	ClientName string                  `json:"client_name"`
+	Statistics map[string]interface{}  `json:"statistics"`
+}
+
+// ProjectDatabaseRequest запрос на регистрацию базы данных проекта
+type ProjectDatabaseRequest struct {
+	Name        string `json:"name"`
+	FilePath    string `json:"file_path"`
+	Description string `json:"description"`
+}
+
+// ProjectDatabaseInfo база данных проекта в списке баз проекта.
+// Даты передаются в формате RFC3339, размер файла берется с диска, если файл доступен.
+type ProjectDatabaseInfo struct {
+	ID              int                    `json:"id"`
+	ClientProjectID int                    `json:"client_project_id"`
+	Name            string                 `json:"name"`
+	FilePath        string                 `json:"file_path"`
+	Description     string                 `json:"description"`
+	IsActive        bool                   `json:"is_active"`
+	FileSize        int64                  `json:"file_size"`
+	CreatedAt       string                 `json:"created_at"`
+	UpdatedAt       string                 `json:"updated_at"`
+	LastUsedAt      string                 `json:"last_used_at,omitempty"`
+	ConfigName      string                 `json:"config_name,omitempty"`
+	DisplayName     string                 `json:"display_name,omitempty"`
+	Stats           map[string]interface{} `json:"stats,omitempty"`
+}
+
+// ProjectDatabaseDetails детальная информация о базе данных проекта с таблицами
+type ProjectDatabaseDetails struct {
+	ProjectDatabaseInfo
+	Path       string                   `json:"path"`
+	Status     string                   `json:"status"`
+	Size       int64                    `json:"size"`
+	Tables     []map[string]interface{} `json:"tables,omitempty"`
+	Statistics map[string]interface{}   `json:"statistics,omitempty"`
+}
+
+// ProjectDatabasesResponse список баз данных проекта
+type ProjectDatabasesResponse struct {
+	Databases []ProjectDatabaseInfo `json:"databases"`
+	Total     int                   `json:"total"`
+}
+
+// UploadListResponse страница списка выгрузок
+type UploadListResponse struct {
+	Uploads []*database.Upload `json:"uploads"`
+	Total   int                `json:"total"`
+	Limit   int                `json:"limit"`
+	Offset  int                `json:"offset"`
+	Count   int                `json:"count"`
+	HasMore bool               `json:"has_more"`
+}
+
+// UploadCatalog справочник в деталях выгрузки
+type UploadCatalog struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Synonym   string    `json:"synonym"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UploadConstant константа в деталях выгрузки
+type UploadConstant struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Synonym   string    `json:"synonym"`
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UploadDetails детальная информация о выгрузке со справочниками, константами
+// и историей запусков правил автоматизации
+type UploadDetails struct {
+	UploadUUID      string                          `json:"upload_uuid"`
+	StartedAt       time.Time                       `json:"started_at"`
+	CompletedAt     *time.Time                      `json:"completed_at"`
+	Status          string                          `json:"status"`
+	Version1C       string                          `json:"version_1c"`
+	ConfigName      string                          `json:"config_name"`
+	TotalConstants  int                             `json:"total_constants"`
+	TotalCatalogs   int                             `json:"total_catalogs"`
+	TotalItems      int                             `json:"total_items"`
+	DatabaseID      *int                            `json:"database_id"`
+	ClientID        *int                            `json:"client_id"`
+	ProjectID       *int                            `json:"project_id"`
+	ComputerName    string                          `json:"computer_name"`
+	UserName        string                          `json:"user_name"`
+	ConfigVersion   string                          `json:"config_version"`
+	IterationNumber int                             `json:"iteration_number"`
+	IterationLabel  string                          `json:"iteration_label"`
+	ProgrammerName  string                          `json:"programmer_name"`
+	UploadPurpose   string                          `json:"upload_purpose"`
+	ParentUploadID  *int                            `json:"parent_upload_id"`
+	Catalogs        []UploadCatalog                 `json:"catalogs"`
+	Constants       []UploadConstant                `json:"constants"`
+	AutomationRuns  []*database.UploadAutomationRun `json:"automation_runs,omitempty"`
+}
+
+// ProjectRef краткая ссылка на проект
+type ProjectRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// NormalizedCounterpartiesResponse страница нормализованных контрагентов клиента или проекта
+type NormalizedCounterpartiesResponse struct {
+	Counterparties []*database.NormalizedCounterparty `json:"counterparties"`
+	Projects       []ProjectRef                       `json:"projects"`
+	Total          int                                `json:"total"`
+	Offset         int                                `json:"offset"`
+	Limit          int                                `json:"limit"`
+	Page           int                                `json:"page"`
+}
+
+// ProjectNormalizationOptions опции запуска нормализации проекта.
+// Если AllActive не задан, обрабатываются все активные БД проекта.
+type ProjectNormalizationOptions struct {
+	AllActive    *bool  `json:"all_active,omitempty"`
+	DatabasePath string `json:"database_path,omitempty"`
+	DatabaseIDs  []int  `json:"database_ids,omitempty"`
+	UseKpved     bool   `json:"use_kpved,omitempty"`
+	UseOkpd2     bool   `json:"use_okpd2,omitempty"`
+}
+
+// NormalizationStartResponse ответ на запуск нормализации проекта
+type NormalizationStartResponse struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ClientID  int    `json:"client_id"`
+	ProjectID int    `json:"project_id"`
+}
+
+// NormalizationStopResponse ответ на остановку нормализации
+type NormalizationStopResponse struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	WasRunning bool   `json:"was_running"`
+}
+
+// StreamEvent событие SSE-потока нормализации (поле data)
+type StreamEvent struct {
+	Type      string `json:"type"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// ClassifyRequest описывает запрос на классификацию
+type ClassifyRequest struct {
+	ItemID     int                    `json:"item_id"`
+	ItemName   string                 `json:"item_name"`
+	ItemCode   string                 `json:"item_code,omitempty"`
+	Classifier string                 `json:"classifier,omitempty"`
+	Model      string                 `json:"model,omitempty"`
+	StrategyID string                 `json:"strategy_id,omitempty"`
+	Category   string                 `json:"category,omitempty"`
+	Context    map[string]interface{} `json:"context,omitempty"`
+	Options    map[string]interface{} `json:"options,omitempty"`
+}
+
+// ClassificationPayload результат AI-классификатора
+type ClassificationPayload struct {
+	CategoryPath []string   `json:"category_path"`
+	Confidence   float64    `json:"confidence"`
+	Reasoning    string     `json:"reasoning"`
+	Alternatives [][]string `json:"alternatives"`
+	Strategy     string     `json:"strategy"`
+}
+
+// ClassificationResult ответ на запрос классификации элемента
+type ClassificationResult struct {
+	ItemID         int                    `json:"item_id"`
+	ItemName       string                 `json:"item_name"`
+	ItemCode       string                 `json:"item_code"`
+	OriginalName   string                 `json:"original_name"`
+	Classifier     string                 `json:"classifier"`
+	Strategy       string                 `json:"strategy"`
+	StrategyID     string                 `json:"strategy_id"`
+	Category       []string               `json:"category"`
+	CategoryPath   []string               `json:"category_path"`
+	Confidence     float64                `json:"confidence"`
+	Reasoning      string                 `json:"reasoning"`
+	Context        map[string]interface{} `json:"context"`
+	Options        map[string]interface{} `json:"options"`
+	Classification ClassificationPayload  `json:"classification"`
+}
+
+// QualityReportResponse структура ответа отчета о качестве
+type QualityReportResponse struct {
+	OverallScore    float64                `json:"overall_score"`
+	DatabaseID      int                    `json:"database_id"`
+	DatabaseName    string                 `json:"database_name"`
+	Completeness    float64                `json:"completeness"`
+	Uniqueness      float64                `json:"uniqueness"`
+	Consistency     float64                `json:"consistency"`
+	Accuracy        float64                `json:"accuracy"`
+	Statistics      map[string]interface{} `json:"statistics"`
+	Recommendations []string               `json:"recommendations"`
+	GeneratedAt     string                 `json:"generated_at"`
+}
+
+// QualityScoreResponse структура ответа оценки качества
+type QualityScoreResponse struct {
+	DatabaseID   int     `json:"database_id"`
+	Score        float64 `json:"score"`
+	Completeness float64 `json:"completeness"`
+	Uniqueness   float64 `json:"uniqueness"`
+	Consistency  float64 `json:"consistency"`
+	Accuracy     float64 `json:"accuracy"`
+}
+
+// MonitoringData данные мониторинга AI-провайдеров
+type MonitoringData struct {
+	Providers []ProviderMetrics `json:"providers"`
+	System    SystemStats       `json:"system"`
+}
+
+// ProviderMetrics метрики для одного провайдера
+type ProviderMetrics struct {
+	ID                 string  `json:"id"`
+	Name               string  `json:"name"`
+	ActiveChannels     int     `json:"active_channels"`
+	CurrentRequests    int     `json:"current_requests"`
+	TotalRequests      int64   `json:"total_requests"`
+	SuccessfulRequests int64   `json:"successful_requests"`
+	FailedRequests     int64   `json:"failed_requests"`
+	AverageLatencyMs   float64 `json:"average_latency_ms"`
+	LastRequestTime    string  `json:"last_request_time"`
+	Status             string  `json:"status"`
+	RequestsPerSecond  float64 `json:"requests_per_second"`
+}
+
+// SystemStats общая статистика системы
+type SystemStats struct {
+	TotalProviders          int     `json:"total_providers"`
+	ActiveProviders         int     `json:"active_providers"`
+	TotalRequests           int64   `json:"total_requests"`
+	TotalSuccessful         int64   `json:"total_successful"`
+	TotalFailed             int64   `json:"total_failed"`
+	SystemRequestsPerSecond float64 `json:"system_requests_per_second"`
+	Timestamp               string  `json:"timestamp"`
+}
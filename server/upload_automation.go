@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"httpserver/database"
+	"httpserver/normalization"
+	"httpserver/server/handlers"
+	"httpserver/server/services"
+)
+
+// uploadAutomationPollInterval интервал проверки завершения нормализации в шаге правила
+const uploadAutomationPollInterval = 2 * time.Second
+
+// setupUploadAutomation создает сервис правил автоматизации выгрузок, регистрирует действия
+// и подключает запуск правил к завершению выгрузок (/complete и импорт файлов обмена)
+func (s *Server) setupUploadAutomation(baseHandler *handlers.BaseHandler) {
+	s.uploadAutomationService = services.NewUploadAutomationService(s.serviceDB, log.Printf)
+	s.uploadAutomationService.RegisterAction(services.UploadActionNormalization, s.automationNormalization)
+	s.uploadAutomationService.RegisterAction(services.UploadActionCounterpartyMapping, s.automationCounterpartyMapping)
+	s.uploadAutomationService.RegisterAction(services.UploadActionKpvedClassification, s.automationKpvedClassification)
+	s.uploadAutomationService.RegisterAction(services.UploadActionQualityAnalysis, s.automationQualityAnalysis)
+	s.uploadAutomationService.RegisterAction(services.UploadActionSnapshot, s.automationSnapshot)
+	s.uploadAutomationService.RegisterAction(services.UploadActionNotify, s.automationNotify)
+	s.uploadAutomationHandler = handlers.NewUploadAutomationHandler(s.uploadAutomationService, baseHandler)
+
+	if s.uploadLegacyHandler != nil {
+		s.uploadLegacyHandler.SetAutomationService(s.uploadAutomationService)
+	}
+	if s.uploadHandler != nil {
+		s.uploadHandler.SetAutomationService(s.uploadAutomationService)
+	}
+}
+
+// automationNormalization запускает нормализацию проекта и ждет ее завершения,
+// чтобы следующие шаги работали с результатами
+func (s *Server) automationNormalization(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	options := map[string]interface{}{
+		"all_active": services.UploadAutomationParamBool(params, "all_active", false),
+		"use_kpved":  services.UploadAutomationParamBool(params, "use_kpved", false),
+		"use_okpd2":  services.UploadAutomationParamBool(params, "use_okpd2", false),
+	}
+	// config_id задает сохраненную конфигурацию нормализации с ее базой данных;
+	// без нее по умолчанию нормализуется только база, из которой пришла выгрузка
+	if configID := services.UploadAutomationParamInt(params, "config_id", 0); configID > 0 && !options["all_active"].(bool) {
+		options["config_id"] = configID
+	} else if !options["all_active"].(bool) && run.DatabaseID > 0 {
+		options["database_ids"] = []int{run.DatabaseID}
+	} else {
+		options["all_active"] = true
+	}
+
+	started := time.Now()
+	if err := s.startProjectNormalization(run.ClientID, run.ProjectID, options); err != nil {
+		return nil, err
+	}
+	s.normalizerMutex.RLock()
+	normalizerCtx := s.normalizerCtx
+	s.normalizerMutex.RUnlock()
+
+	timeout := time.Duration(services.UploadAutomationParamInt(params, "timeout_minutes", 120)) * time.Minute
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(uploadAutomationPollInterval)
+	defer ticker.Stop()
+	for !s.shouldStopNormalization() {
+		select {
+		case <-waitCtx.Done():
+			return nil, fmt.Errorf("normalization did not finish within %v", timeout)
+		case <-ticker.C:
+		}
+	}
+
+	// Остановка нормализации отменяет ее контекст: шаг не считается выполненным
+	if normalizerCtx != nil && normalizerCtx.Err() != nil {
+		return nil, fmt.Errorf("normalization was stopped before completion")
+	}
+	if err := s.checkAutomationNormalizationSessions(run.ProjectID, started); err != nil {
+		return nil, err
+	}
+
+	s.normalizerMutex.RLock()
+	result := map[string]interface{}{
+		"processed":   s.normalizerProcessed,
+		"success":     s.normalizerSuccess,
+		"errors":      s.normalizerErrors,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	s.normalizerMutex.RUnlock()
+	return result, nil
+}
+
+// checkAutomationNormalizationSessions возвращает ошибку, если сессия нормализации базы проекта,
+// начатая шагом правила, завершилась ошибкой или была остановлена
+func (s *Server) checkAutomationNormalizationSessions(projectID int, started time.Time) error {
+	databases, err := s.serviceDB.GetProjectDatabases(projectID, false)
+	if err != nil {
+		return fmt.Errorf("failed to get project databases: %w", err)
+	}
+	for _, db := range databases {
+		session, err := s.serviceDB.GetLastNormalizationSession(db.ID)
+		if err != nil {
+			return err
+		}
+		// started_at хранится с точностью до секунды
+		if session == nil || session.StartedAt.Before(started.Truncate(time.Second)) {
+			continue
+		}
+		switch session.Status {
+		case "failed":
+			return fmt.Errorf("normalization session %d for database %s failed", session.ID, db.Name)
+		case "stopped":
+			return fmt.Errorf("normalization session %d for database %s was stopped", session.ID, db.Name)
+		}
+	}
+	return nil
+}
+
+// automationCounterpartyMapping выполняет мэппинг контрагентов проекта
+func (s *Server) automationCounterpartyMapping(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	mapper := normalization.NewCounterpartyMapper(s.serviceDB)
+	if err := mapper.MapAllCounterpartiesForProject(run.ProjectID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"project_id": run.ProjectID}, nil
+}
+
+// automationKpvedClassification классифицирует по КПВЭД группы проекта, у которых еще нет кода
+func (s *Server) automationKpvedClassification(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	s.kpvedClassifierMutex.RLock()
+	classifier := s.hierarchicalClassifier
+	s.kpvedClassifierMutex.RUnlock()
+	if classifier == nil {
+		return nil, fmt.Errorf("KPVED classifier is not available")
+	}
+	if s.normalizedDB == nil {
+		return nil, fmt.Errorf("normalized database is not available")
+	}
+
+	limit := services.UploadAutomationParamInt(params, "limit", 500)
+	rows, err := s.normalizedDB.Query(`
+		SELECT normalized_name, category, MAX(merged_count) AS merged_count
+		FROM normalized_data
+		WHERE project_id = ? AND (kpved_code IS NULL OR TRIM(kpved_code) = '')
+		GROUP BY normalized_name, category
+		ORDER BY merged_count DESC
+		LIMIT ?
+	`, run.ProjectID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups without KPVED: %w", err)
+	}
+	type group struct{ name, category string }
+	var groups []group
+	for rows.Next() {
+		var g group
+		var mergedCount int
+		if err := rows.Scan(&g.name, &g.category, &mergedCount); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		groups = append(groups, g)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read groups without KPVED: %w", err)
+	}
+
+	classified, failed := 0, 0
+	for _, g := range groups {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		result, err := classifier.Classify(g.name, g.category)
+		if err != nil || result == nil || result.FinalCode == "" {
+			failed++
+			continue
+		}
+		if _, err := s.normalizedDB.Exec(`
+			UPDATE normalized_data SET kpved_code = ?, kpved_name = ?, kpved_confidence = ?
+			WHERE project_id = ? AND normalized_name = ? AND category = ?
+			  AND (kpved_code IS NULL OR TRIM(kpved_code) = '')
+		`, result.FinalCode, result.FinalName, result.FinalConfidence, run.ProjectID, g.name, g.category); err != nil {
+			failed++
+			continue
+		}
+		classified++
+	}
+	return map[string]interface{}{"groups": len(groups), "classified": classified, "failed": failed}, nil
+}
+
+// automationQualityAnalysis выполняет анализ качества выгрузки
+func (s *Server) automationQualityAnalysis(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	if s.qualityAnalyzer == nil {
+		return nil, fmt.Errorf("quality analyzer is not available")
+	}
+	if run.DatabaseID <= 0 {
+		return nil, fmt.Errorf("upload is not bound to a project database")
+	}
+	if err := s.qualityAnalyzer.AnalyzeUpload(run.Upload.ID, run.DatabaseID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"upload_id": run.Upload.ID, "database_id": run.DatabaseID}, nil
+}
+
+// automationSnapshot создает срез последних выгрузок баз проекта
+func (s *Server) automationSnapshot(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	uploadsPerDatabase := services.UploadAutomationParamInt(params, "uploads_per_database", 1)
+	name := services.UploadAutomationParamString(params, "name", "")
+	description := services.UploadAutomationParamString(params, "description",
+		fmt.Sprintf("Создан правилом автоматизации \"%s\" после выгрузки %s", run.Rule.Name, run.Upload.UploadUUID))
+	snapshot, err := s.createAutoSnapshot(run.ProjectID, uploadsPerDatabase, name, description)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"snapshot_id": snapshot.ID, "name": snapshot.Name}, nil
+}
+
+// automationNotify отправляет уведомление о выполнении правила
+func (s *Server) automationNotify(ctx context.Context, run *services.UploadAutomationContext, params map[string]interface{}) (map[string]interface{}, error) {
+	if s.notificationService == nil {
+		return nil, fmt.Errorf("notification service is not available")
+	}
+	notificationType := services.NotificationType(services.UploadAutomationParamString(params, "type", string(services.NotificationTypeSuccess)))
+	title := services.UploadAutomationParamString(params, "title", "Автоматизация выгрузки")
+	message := services.UploadAutomationParamString(params, "message",
+		fmt.Sprintf("Правило \"%s\" выполнено для выгрузки %s", run.Rule.Name, run.Upload.UploadUUID))
+	clientID, projectID := run.ClientID, run.ProjectID
+	notification, err := s.notificationService.AddNotification(ctx, notificationType, title, message, &clientID, &projectID,
+		map[string]interface{}{"upload_uuid": run.Upload.UploadUUID, "upload_id": run.Upload.ID, "rule_id": run.Rule.ID})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"notification_id": notification.ID}, nil
+}
+
+// onUploadCompletedAutomation запускает правила автоматизации для выгрузки, завершенной вне HTTP обработчиков
+func (s *Server) onUploadCompletedAutomation(upload *database.Upload) {
+	if s.uploadAutomationService != nil {
+		s.uploadAutomationService.OnUploadCompleted(upload)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"httpserver/database"
+)
+
+// TestCheckAutomationNormalizationSessions проверяет, что шаг нормализации правила
+// завершается ошибкой, если сессия базы проекта упала или была остановлена
+func TestCheckAutomationNormalizationSessions(t *testing.T) {
+	serviceDB, err := database.NewServiceDB(filepath.Join(t.TempDir(), "service.db"))
+	if err != nil {
+		t.Fatalf("NewServiceDB() error = %v", err)
+	}
+	defer serviceDB.Close()
+
+	client, err := serviceDB.CreateClient("Клиент", "ООО Клиент", "", "", "", "")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	project, err := serviceDB.CreateClientProject(client.ID, "Проект", "nomenclature", "", "1C", 0.8)
+	if err != nil {
+		t.Fatalf("CreateClientProject() error = %v", err)
+	}
+	projectDB, err := serviceDB.CreateProjectDatabase(project.ID, "УТ", filepath.Join(t.TempDir(), "ut.db"), "", 0)
+	if err != nil {
+		t.Fatalf("CreateProjectDatabase() error = %v", err)
+	}
+
+	s := &Server{serviceDB: serviceDB}
+	started := time.Now()
+	sessionID, err := serviceDB.CreateNormalizationSession(projectDB.ID, 0, 3600)
+	if err != nil {
+		t.Fatalf("CreateNormalizationSession() error = %v", err)
+	}
+
+	finishedAt := time.Now()
+	if err := serviceDB.UpdateNormalizationSession(sessionID, "completed", &finishedAt); err != nil {
+		t.Fatalf("UpdateNormalizationSession() error = %v", err)
+	}
+	if err := s.checkAutomationNormalizationSessions(project.ID, started); err != nil {
+		t.Errorf("completed session: unexpected error %v", err)
+	}
+
+	if err := serviceDB.UpdateNormalizationSession(sessionID, "stopped", &finishedAt); err != nil {
+		t.Fatalf("UpdateNormalizationSession() error = %v", err)
+	}
+	if err := s.checkAutomationNormalizationSessions(project.ID, started); err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Errorf("stopped session: error = %v, want stopped", err)
+	}
+
+	// Сессии, начатые до шага правила, не учитываются
+	if err := s.checkAutomationNormalizationSessions(project.ID, started.Add(time.Hour)); err != nil {
+		t.Errorf("earlier session: unexpected error %v", err)
+	}
+}